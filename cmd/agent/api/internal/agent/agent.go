@@ -67,7 +67,9 @@ func SetupHandlers(r *mux.Router, flareComp flare.Component, server dogstatsdSer
 	r.HandleFunc("/config/{setting}", settingshttp.Server.SetValue).Methods("POST")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
 	r.HandleFunc("/workload-list", getWorkloadList).Methods("GET")
+	r.HandleFunc("/workload-list/diagnose", getWorkloadListDiagnose).Methods("GET")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
+	r.HandleFunc("/secret/refresh", secretRefresh).Methods("POST")
 	r.HandleFunc("/metadata/{payload}", metadataPayload).Methods("GET")
 
 	// Some agent subcommands do not provide these dependencies (such as JMX)
@@ -390,10 +392,33 @@ func getWorkloadList(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonDump)
 }
 
+func getWorkloadListDiagnose(w http.ResponseWriter, r *http.Request) {
+	response := collectors.DiagnoseUnifiedServiceTagging(workloadmeta.GetGlobalStore())
+	jsonReport, err := json.Marshal(response)
+	if err != nil {
+		setJSONError(w, log.Errorf("Unable to marshal unified service tagging report: %v", err), 500)
+		return
+	}
+
+	w.Write(jsonReport)
+}
+
 func secretInfo(w http.ResponseWriter, r *http.Request) {
 	secrets.GetDebugInfo(w)
 }
 
+func secretRefresh(w http.ResponseWriter, r *http.Request) {
+	changedHandles, err := secrets.Refresh()
+	if err != nil {
+		setJSONError(w, err, 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(changedHandles)
+	w.Write(j)
+}
+
 func metadataPayload(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	payloadType := vars["payload"]
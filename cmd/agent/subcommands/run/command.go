@@ -17,6 +17,7 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -58,6 +59,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/netflow"
 	"github.com/DataDog/datadog-agent/pkg/otlp"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -399,6 +401,9 @@ func startAgent(
 	// create and setup the Autoconfig instance
 	common.LoadComponents(common.MainCtx, pkgconfig.Datadog.GetString("confd_path"))
 
+	// periodically refresh secrets, once AutoConfig can reschedule the configs using them
+	secrets.StartRefreshRoutine(time.Duration(pkgconfig.Datadog.GetInt("secret_refresh_interval")) * time.Second)
+
 	// start the cloudfoundry container tagger
 	if pkgconfig.IsFeaturePresent(pkgconfig.CloudFoundry) && !pkgconfig.Datadog.GetBool("cloud_foundry_buildpack") {
 		containerTagger, err := containertagger.NewContainerTagger()
@@ -553,6 +558,7 @@ func stopAgent(cliParams *cliParams, server dogstatsdServer.Component) {
 	if common.OTLP != nil {
 		common.OTLP.Stop()
 	}
+	secrets.StopRefreshRoutine()
 	if common.AC != nil {
 		common.AC.Stop()
 	}
@@ -7,6 +7,7 @@
 package secret
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -45,6 +46,20 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 		},
 	}
 
+	secretRefreshCommand := &cobra.Command{
+		Use:   "refresh",
+		Short: "Fetch again every secret from the secret backend and reschedule the integrations using secrets that changed.",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(secretRefresh,
+				fx.Supply(cliParams),
+				fx.Supply(command.GetDefaultCoreBundleParams(cliParams.GlobalParams)),
+				core.Bundle,
+			)
+		},
+	}
+	secretInfoCommand.AddCommand(secretRefreshCommand)
+
 	return []*cobra.Command{secretInfoCommand}
 }
 
@@ -61,6 +76,55 @@ func secret(log log.Component, config config.Component, cliParams *cliParams) er
 	return nil
 }
 
+func secretRefresh(log log.Component, config config.Component, cliParams *cliParams) error {
+	if err := util.SetAuthToken(); err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	if err := refreshSecrets(config); err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return nil
+}
+
+func refreshSecrets(config config.Component) error {
+	c := util.GetClient(false)
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	apiConfigURL := fmt.Sprintf("https://%v:%v/agent/secret/refresh", ipcAddress, config.GetInt("cmd_port"))
+
+	r, err := util.DoPost(c, apiConfigURL, "application/json", bytes.NewBuffer([]byte{}))
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap) //nolint:errcheck
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			return fmt.Errorf("%s", e)
+		}
+
+		return fmt.Errorf("Could not reach agent: %v\nMake sure the agent is running before refreshing secrets and contact support if you continue having issues", err)
+	}
+
+	var changedHandles []string
+	if err := json.Unmarshal(r, &changedHandles); err != nil {
+		return fmt.Errorf("Could not parse the agent response: %v", err)
+	}
+
+	if len(changedHandles) == 0 {
+		fmt.Println("Secrets were refreshed, none of them changed")
+		return nil
+	}
+	fmt.Println("Secrets were refreshed, the following handles changed:")
+	for _, handle := range changedHandles {
+		fmt.Printf("- '%s'\n", handle)
+	}
+	return nil
+}
+
 func showSecretInfo(config config.Component) error {
 	c := util.GetClient(false)
 	ipcAddress, err := pkgconfig.GetIPCAddress()
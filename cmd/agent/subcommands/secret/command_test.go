@@ -24,3 +24,13 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestRefreshCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"secret", "refresh"},
+		secretRefresh,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.Equal(t, false, coreParams.ConfigLoadSecrets())
+		})
+}
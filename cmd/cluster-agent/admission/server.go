@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
@@ -36,6 +37,11 @@ const jsonContentType = "application/json"
 
 type admissionFunc func([]byte, string, dynamic.Interface) ([]byte, error)
 
+type validationFunc func([]byte, string, dynamic.Interface) (bool, []string, error)
+
+// responseFunc builds the response to an admission request from the raw object and its namespace
+type responseFunc func([]byte, string) *admiv1.AdmissionResponse
+
 // Server TODO <container-integrations>
 type Server struct {
 	decoder runtime.Decoder
@@ -73,7 +79,21 @@ func (s *Server) initDecoder() {
 // Register must be called to register the desired webhook handlers before calling Run.
 func (s *Server) Register(uri string, f admissionFunc, dc dynamic.Interface) {
 	s.mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
-		s.mutateHandler(w, r, f, dc)
+		s.handle(w, r, func(raw []byte, ns string) *admiv1.AdmissionResponse {
+			jsonPatch, err := f(raw, ns, dc)
+			return mutationResponse(jsonPatch, err)
+		})
+	})
+}
+
+// RegisterValidation adds a validating admission webhook handler.
+// RegisterValidation must be called to register the desired webhook handlers before calling Run.
+func (s *Server) RegisterValidation(uri string, f validationFunc, dc dynamic.Interface) {
+	s.mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		s.handle(w, r, func(raw []byte, ns string) *admiv1.AdmissionResponse {
+			allowed, warnings, err := f(raw, ns, dc)
+			return validationResponse(allowed, warnings, err)
+		})
 	})
 }
 
@@ -111,9 +131,9 @@ func (s *Server) Run(mainCtx context.Context, client kubernetes.Interface) error
 	return server.Shutdown(shutdownCtx)
 }
 
-// mutateHandler contains the main logic responsible for handling mutation requests.
+// handle contains the main logic responsible for handling mutation and validation requests.
 // It supports both v1 and v1beta1 requests.
-func (s *Server) mutateHandler(w http.ResponseWriter, r *http.Request, mutateFunc admissionFunc, dc dynamic.Interface) {
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, respond responseFunc) {
 	metrics.WebhooksReceived.Inc()
 
 	start := time.Now()
@@ -157,8 +177,7 @@ func (s *Server) mutateHandler(w http.ResponseWriter, r *http.Request, mutateFun
 		}
 		admissionReviewResp := &admiv1.AdmissionReview{}
 		admissionReviewResp.SetGroupVersionKind(*gvk)
-		jsonPatch, err := mutateFunc(admissionReviewReq.Request.Object.Raw, admissionReviewReq.Request.Namespace, dc)
-		admissionReviewResp.Response = mutationResponse(jsonPatch, err)
+		admissionReviewResp.Response = respond(admissionReviewReq.Request.Object.Raw, admissionReviewReq.Request.Namespace)
 		admissionReviewResp.Response.UID = admissionReviewReq.Request.UID
 		response = admissionReviewResp
 	case admiv1beta1.SchemeGroupVersion.WithKind("AdmissionReview"):
@@ -168,8 +187,7 @@ func (s *Server) mutateHandler(w http.ResponseWriter, r *http.Request, mutateFun
 		}
 		admissionReviewResp := &admiv1beta1.AdmissionReview{}
 		admissionReviewResp.SetGroupVersionKind(*gvk)
-		jsonPatch, err := mutateFunc(admissionReviewReq.Request.Object.Raw, admissionReviewReq.Request.Namespace, dc)
-		admissionReviewResp.Response = responseV1ToV1beta1(mutationResponse(jsonPatch, err))
+		admissionReviewResp.Response = responseV1ToV1beta1(respond(admissionReviewReq.Request.Object.Raw, admissionReviewReq.Request.Namespace))
 		admissionReviewResp.Response.UID = admissionReviewReq.Request.UID
 		response = admissionReviewResp
 	default:
@@ -210,6 +228,38 @@ func mutationResponse(jsonPatch []byte, err error) *admiv1.AdmissionResponse {
 	}
 }
 
+// validationResponse returns the adequate v1.AdmissionResponse based on the validation result.
+// Validation errors never block the admission.
+func validationResponse(allowed bool, warnings []string, err error) *admiv1.AdmissionResponse {
+	if err != nil {
+		log.Warnf("Failed to validate: %v", err)
+
+		return &admiv1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+			Allowed: true,
+		}
+	}
+
+	if !allowed {
+		return &admiv1.AdmissionResponse{
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInvalid,
+				Code:    http.StatusUnprocessableEntity,
+				Message: strings.Join(warnings, "; "),
+			},
+			Allowed: false,
+		}
+	}
+
+	return &admiv1.AdmissionResponse{
+		Warnings: warnings,
+		Allowed:  true,
+	}
+}
+
 // responseV1ToV1beta1 converts a v1.AdmissionResponse into a v1beta1.AdmissionResponse.
 func responseV1ToV1beta1(resp *admiv1.AdmissionResponse) *admiv1beta1.AdmissionResponse {
 	var patchType *admiv1beta1.PatchType
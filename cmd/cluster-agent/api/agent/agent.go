@@ -49,6 +49,7 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/config/{setting}", settingshttp.Server.SetValue).Methods("POST")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
 	r.HandleFunc("/workload-list", getWorkloadList).Methods("GET")
+	r.HandleFunc("/workload-list/diagnose", getWorkloadListDiagnose).Methods("GET")
 }
 
 func getStatus(w http.ResponseWriter, r *http.Request) {
@@ -202,6 +203,17 @@ func getWorkloadList(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonDump)
 }
 
+func getWorkloadListDiagnose(w http.ResponseWriter, r *http.Request) {
+	response := collectors.DiagnoseUnifiedServiceTagging(workloadmeta.GetGlobalStore())
+	jsonReport, err := json.Marshal(response)
+	if err != nil {
+		setJSONError(w, log.Errorf("Unable to marshal unified service tagging report: %v", err), 500)
+		return
+	}
+
+	w.Write(jsonReport)
+}
+
 func setJSONError(w http.ResponseWriter, err error, errorCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	body, _ := json.Marshal(map[string]string{"error": err.Error()})
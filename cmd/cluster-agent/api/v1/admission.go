@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/mutate"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/api"
+	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxDryRunBodySize is the maximum size of a pod submitted to the dry-run endpoint
+const maxDryRunBodySize = 1 << 20
+
+func installAdmissionEndpoints(r *mux.Router) {
+	r.HandleFunc("/admission/policies", api.WithTelemetryWrapper("getInjectionPolicies", getInjectionPolicies)).Methods("GET")
+	r.HandleFunc("/admission/dry-run", api.WithTelemetryWrapper("postAdmissionDryRun", postAdmissionDryRun)).Methods("POST")
+}
+
+// getInjectionPolicies returns the injection policies in effect
+func getInjectionPolicies(w http.ResponseWriter, r *http.Request) {
+	policies := policy.List()
+	if policies == nil {
+		policies = []*policy.InjectionPolicy{}
+	}
+	writeJSON(w, policy.Policies{Policies: policies})
+}
+
+// postAdmissionDryRun returns the JSON patches the admission webhooks would
+// apply to the pod in the request body, without admitting it
+func postAdmissionDryRun(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			POST localhost:5005/api/v1/admission/dry-run?namespace=default
+			Body: a Pod object in JSON
+		Outputs
+			Status: 200
+			Returns: mutate.DryRunResult
+
+			Status: 400
+			Returns: string
+			Example: "failed to decode raw object: ..."
+	*/
+	rawPod, err := io.ReadAll(io.LimitReader(r.Body, maxDryRunBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cl, err := as.GetAPIClient()
+	if err != nil {
+		log.Errorf("Can't create client to query the API Server: %v", err) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result, err := mutate.DryRun(rawPod, r.URL.Query().Get("namespace"), cl.DynamicCl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("Could not marshal the response: %v", err) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data) //nolint:errcheck
+}
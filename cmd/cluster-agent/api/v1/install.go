@@ -30,3 +30,9 @@ func InstallChecksEndpoints(r *mux.Router, sc clusteragent.ServerContext) {
 	installClusterCheckEndpoints(r, sc)
 	installEndpointsCheckEndpoints(r, sc)
 }
+
+// InstallAdmissionEndpoints registers endpoints for the admission controller
+func InstallAdmissionEndpoints(r *mux.Router) {
+	log.Debug("Registering admission endpoints")
+	installAdmissionEndpoints(r)
+}
@@ -31,6 +31,7 @@ func installKubernetesMetadataEndpoints(r *mux.Router) {
 	r.HandleFunc("/tags/pod", api.WithTelemetryWrapper("getAllMetadata", getAllMetadata)).Methods("GET")
 	r.HandleFunc("/tags/node/{nodeName}", api.WithTelemetryWrapper("getNodeLabels", getNodeLabels)).Methods("GET")
 	r.HandleFunc("/tags/namespace/{ns}", api.WithTelemetryWrapper("getNamespaceLabels", getNamespaceLabels)).Methods("GET")
+	r.HandleFunc("/tags/owner/{ns}/{kind}/{name}", api.WithTelemetryWrapper("getOwnerLabels", getOwnerLabels)).Methods("GET")
 	r.HandleFunc("/cluster/id", api.WithTelemetryWrapper("getClusterID", getClusterID)).Methods("GET")
 }
 
@@ -151,6 +152,49 @@ func getNamespaceLabels(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Could not find labels on the namespace: %s", nsName)
 }
 
+// getOwnerLabels is only used when the node agent hits the DCA for the labels of the owner of a pod
+func getOwnerLabels(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/tags/owner/default/Deployment/redis
+		Outputs
+			Status: 200
+			Returns: map[string]string
+			Example: {"label1": "value1", "label2": "value2"}
+
+			Status: 404
+			Returns: string
+			Example: 404 page not found
+
+			Status: 500
+			Returns: string
+			Example: "deployments.apps \"redis\" not found"
+	*/
+
+	vars := mux.Vars(r)
+	var labelBytes []byte
+	nsName, kind, name := vars["ns"], vars["kind"], vars["name"]
+	ownerLabels, err := as.GetOwnerLabels(nsName, kind, name)
+	if err != nil {
+		log.Errorf("Could not retrieve the labels of the %s %s/%s: %v", kind, nsName, name, err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	labelBytes, err = json.Marshal(ownerLabels)
+	if err != nil {
+		log.Errorf("Could not process the labels of the %s %s/%s: %v", kind, nsName, name, err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(labelBytes) > 0 {
+		w.WriteHeader(http.StatusOK)
+		w.Write(labelBytes)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, "Could not find labels on the %s: %s/%s", kind, nsName, name)
+}
+
 // getPodMetadata is only used when the node agent hits the DCA for the tags list.
 // It returns a list of all the tags that can be directly used in the tagger of the agent.
 func getPodMetadata(w http.ResponseWriter, r *http.Request) {
@@ -37,6 +37,7 @@ import (
 	admissionpkg "github.com/DataDog/datadog-agent/pkg/clusteragent/admission"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/mutate"
 	admissionpatch "github.com/DataDog/datadog-agent/pkg/clusteragent/admission/patch"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/validate"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
 	"github.com/DataDog/datadog-agent/pkg/collector"
 	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -331,6 +332,14 @@ func start(log log.Component, config config.Component, forwarder defaultforwarde
 			server.Register(pkgconfig.Datadog.GetString("admission_controller.inject_config.endpoint"), mutate.InjectConfig, apiCl.DynamicCl)
 			server.Register(pkgconfig.Datadog.GetString("admission_controller.inject_tags.endpoint"), mutate.InjectTags, apiCl.DynamicCl)
 			server.Register(pkgconfig.Datadog.GetString("admission_controller.auto_instrumentation.endpoint"), mutate.InjectAutoInstrumentation, apiCl.DynamicCl)
+			if pkgconfig.Datadog.GetBool("admission_controller.validate_annotations.enabled") {
+				server.RegisterValidation(pkgconfig.Datadog.GetString("admission_controller.validate_annotations.endpoint"), validate.ADAnnotations, apiCl.DynamicCl)
+			}
+
+			// Expose the injection policies and the dry-run of the webhooks
+			api.ModifyAPIRouter(func(r *mux.Router) {
+				dcav1.InstallAdmissionEndpoints(r)
+			})
 
 			// Start the k8s admission webhook server
 			wg.Add(1)
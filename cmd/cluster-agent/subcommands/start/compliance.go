@@ -81,6 +81,7 @@ func startCompliance(stopper startstop.Stopper, apiCl *apiserver.APIClient, isLe
 	runPath := coreconfig.Datadog.GetString("compliance_config.run_path")
 	configDir := coreconfig.Datadog.GetString("compliance_config.dir")
 	checkInterval := coreconfig.Datadog.GetDuration("compliance_config.check_interval")
+	waiversFile := coreconfig.Datadog.GetString("compliance_config.waivers_file")
 
 	reporter, err := compliance.NewLogReporter(stopper, "compliance-agent", "compliance", runPath, endpoints, ctx)
 	if err != nil {
@@ -99,6 +100,7 @@ func startCompliance(stopper startstop.Stopper, apiCl *apiserver.APIClient, isLe
 		ConfigDir:     configDir,
 		Reporter:      reporter,
 		CheckInterval: checkInterval,
+		WaiversFile:   waiversFile,
 		RuleFilter: func(rule *compliance.Rule) bool {
 			return rule.HasScope(compliance.KubernetesClusterScope)
 		},
@@ -44,6 +44,9 @@ const (
 	RemoteFormat      = "remote-format"
 	Input             = "input"
 	Remote            = "remote"
+	Base              = "base"
+	New               = "new"
+	FailOnDrift       = "fail-on-drift"
 
 	// Compliance Subcommand
 	SourceType   = "source-type"
@@ -59,4 +62,8 @@ const (
 	Report            = "report"
 	OverrideRegoInput = "override-rego-input"
 	DumpReports       = "dump-reports" // TODO: Unify with OutputPath
+
+	// Compliance Scan Subcommand
+	FailOnFailure = "fail-on-failure"
+	Waivers       = "waivers"
 )
@@ -141,6 +141,7 @@ func RunCheck(log log.Component, config config.Component, checkArgs *CliParams)
 			HostRoot:           os.Getenv("HOST_ROOT"),
 			DockerProvider:     compliance.DefaultDockerProvider,
 			LinuxAuditProvider: compliance.DefaultLinuxAuditProvider,
+			SystemdProvider:    compliance.DefaultSystemdProvider,
 			StatsdClient:       statsdClient,
 		})
 	}
@@ -175,6 +176,15 @@ func RunCheck(log log.Component, config config.Component, checkArgs *CliParams)
 		return fmt.Errorf("could not find any benchmark in %q", filepath.Join(benchDir, benchGlob))
 	}
 
+	var waivers *compliance.Waivers
+	if waiversFile := config.GetString("compliance_config.waivers_file"); waiversFile != "" {
+		waivers, err = compliance.LoadWaivers(waiversFile)
+		if err != nil {
+			return fmt.Errorf("could not load waivers file %q: %w", waiversFile, err)
+		}
+	}
+
+	now := time.Now()
 	events := make([]*compliance.CheckEvent, 0)
 	for _, benchmark := range benchmarks {
 		for _, rule := range benchmark.Rules {
@@ -187,6 +197,7 @@ func RunCheck(log log.Component, config config.Component, checkArgs *CliParams)
 				ruleEvents = compliance.ResolveAndEvaluateRegoRule(context.Background(), resolver, benchmark, rule)
 			}
 			for _, event := range ruleEvents {
+				waivers.Apply(event, hname, now)
 				b, _ := json.MarshalIndent(event, "", "\t")
 				fmt.Println(string(b))
 				if event.Result != compliance.CheckSkipped {
@@ -81,7 +81,7 @@ func TestCommand_kubeapiserver(t *testing.T) {
 		for _, subcommand := range rootCommand.Commands() {
 			subcommandNames = append(subcommandNames, subcommand.Use)
 		}
-		require.Equal(t, []string{"check", "event"}, subcommandNames, "subcommand missing")
+		require.Equal(t, []string{"check", "event", "scan <root path>"}, subcommandNames, "subcommand missing")
 
 		fxutil.TestOneShotSubcommand(t,
 			Commands(&command.GlobalParams{}),
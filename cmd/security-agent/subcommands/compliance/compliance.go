@@ -26,6 +26,7 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 	configDir := config.GetString("compliance_config.dir")
 	metricsEnabled := config.GetBool("compliance_config.metrics.enabled")
 	checkInterval := config.GetDuration("compliance_config.check_interval")
+	waiversFile := config.GetString("compliance_config.waivers_file")
 
 	if !enabled {
 		return nil, nil
@@ -53,11 +54,13 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 			HostRoot:           os.Getenv("HOST_ROOT"),
 			DockerProvider:     compliance.DefaultDockerProvider,
 			LinuxAuditProvider: compliance.DefaultLinuxAuditProvider,
+			SystemdProvider:    compliance.DefaultSystemdProvider,
 			StatsdClient:       statsdClient,
 		},
 		ConfigDir:     configDir,
 		Reporter:      reporter,
 		CheckInterval: checkInterval,
+		WaiversFile:   waiversFile,
 	})
 	err = agent.Start()
 	if err != nil {
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/cmd/security-agent/flags"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+type scanCliParams struct {
+	*command.GlobalParams
+
+	rootPath      string
+	framework     string
+	file          string
+	format        string
+	outputPath    string
+	waiversFile   string
+	failOnFailure bool
+}
+
+func scanCommand(globalParams *command.GlobalParams) *cobra.Command {
+	scanArgs := &scanCliParams{
+		GlobalParams: globalParams,
+	}
+
+	scanCmd := &cobra.Command{
+		Use:   "scan <root path>",
+		Short: "Evaluate the compliance rules against a root filesystem, an OCI image layout or an image tarball",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanArgs.rootPath = args[0]
+			return fxutil.OneShot(scanRun,
+				fx.Supply(scanArgs),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					LogParams:    log.LogForOneShot(command.LoggerName, "off", true),
+				}),
+				core.Bundle,
+			)
+		},
+	}
+
+	scanCmd.Flags().StringVarP(&scanArgs.framework, flags.Framework, "", "", "Framework to run the checks from")
+	scanCmd.Flags().StringVarP(&scanArgs.file, flags.File, "f", "", "Compliance suite file to read rules from")
+	scanCmd.Flags().StringVar(&scanArgs.format, flags.Format, "json", "Format of the report: json or sarif")
+	scanCmd.Flags().StringVar(&scanArgs.outputPath, flags.OutputPath, "", "Path of the report file, standard output by default")
+	scanCmd.Flags().StringVar(&scanArgs.waiversFile, flags.Waivers, "", "Path of the file defining the waived findings, compliance_config.waivers_file by default")
+	scanCmd.Flags().BoolVar(&scanArgs.failOnFailure, flags.FailOnFailure, false, "Exit with an error if some rules fail")
+
+	return scanCmd
+}
+
+func scanRun(log log.Component, config config.Component, scanArgs *scanCliParams) error {
+	if scanArgs.format != "json" && scanArgs.format != "sarif" {
+		return fmt.Errorf("unsupported report format %q", scanArgs.format)
+	}
+
+	var benchDir, benchGlob string
+	if scanArgs.file != "" {
+		benchDir, benchGlob = filepath.Dir(scanArgs.file), filepath.Base(scanArgs.file)
+	} else if scanArgs.framework != "" {
+		benchDir, benchGlob = config.GetString("compliance_config.dir"), fmt.Sprintf("%s.yaml", scanArgs.framework)
+	} else {
+		benchDir, benchGlob = config.GetString("compliance_config.dir"), "*.yaml"
+	}
+
+	benchmarks, err := compliance.LoadBenchmarks(benchDir, benchGlob, func(r *compliance.Rule) bool {
+		return r.IsRego()
+	})
+	if err != nil {
+		return fmt.Errorf("could not load benchmark files %q: %w", filepath.Join(benchDir, benchGlob), err)
+	}
+	if len(benchmarks) == 0 {
+		return fmt.Errorf("could not find any benchmark in %q", filepath.Join(benchDir, benchGlob))
+	}
+
+	waiversFile := scanArgs.waiversFile
+	if waiversFile == "" {
+		waiversFile = config.GetString("compliance_config.waivers_file")
+	}
+	var waivers *compliance.Waivers
+	if waiversFile != "" {
+		waivers, err = compliance.LoadWaivers(waiversFile)
+		if err != nil {
+			return fmt.Errorf("could not load waivers file %q: %w", waiversFile, err)
+		}
+	}
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{
+		RootPath: scanArgs.rootPath,
+		Waivers:  waivers,
+	}, benchmarks)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if scanArgs.outputPath != "" {
+		f, err := os.Create(scanArgs.outputPath)
+		if err != nil {
+			return fmt.Errorf("could not create report file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if scanArgs.format == "sarif" {
+		err = report.WriteSARIF(w)
+	} else {
+		err = report.WriteJSON(w)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	s := report.Summary
+	fmt.Fprintf(os.Stderr, "Evaluated %s: %d passed, %d failed, %d waived, %d errors, %d skipped\n", scanArgs.rootPath, s.Passed, s.Failed, s.Waived, s.Error, s.Skipped)
+	if scanArgs.failOnFailure && report.HasFailures() {
+		return errors.New("some compliance rules failed")
+	}
+	return nil
+}
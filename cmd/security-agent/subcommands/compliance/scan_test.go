@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestScanCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"compliance", "scan", "/tmp/rootfs", "--format", "sarif", "--fail-on-failure", "--output-path", "/tmp/report.sarif", "--waivers", "/tmp/waivers.yaml"},
+		scanRun,
+		func(scanArgs *scanCliParams, params core.BundleParams) {
+			require.Equal(t, command.LoggerName, params.LoggerName(), "logger name not matching")
+			require.Equal(t, "/tmp/rootfs", scanArgs.rootPath)
+			require.Equal(t, "sarif", scanArgs.format)
+			require.Equal(t, "/tmp/report.sarif", scanArgs.outputPath)
+			require.Equal(t, "/tmp/waivers.yaml", scanArgs.waiversFile)
+			require.True(t, scanArgs.failOnFailure)
+		})
+}
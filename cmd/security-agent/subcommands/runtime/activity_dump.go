@@ -9,7 +9,9 @@
 package runtime
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -52,6 +54,7 @@ func activityDumpCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	activityDumpCmd.AddCommand(generateCommands(globalParams)...)
 	activityDumpCmd.AddCommand(listCommands(globalParams)...)
 	activityDumpCmd.AddCommand(stopCommands(globalParams)...)
+	activityDumpCmd.AddCommand(diffCommands(globalParams)...)
 
 	return []*cobra.Command{activityDumpCmd}
 }
@@ -114,6 +117,75 @@ func stopCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	return []*cobra.Command{activityDumpStopCmd}
 }
 
+type activityDumpDiffCliParams struct {
+	*command.GlobalParams
+
+	base        string
+	new         string
+	format      string
+	outputPath  string
+	failOnDrift bool
+}
+
+func diffCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &activityDumpDiffCliParams{
+		GlobalParams: globalParams,
+	}
+
+	activityDumpDiffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "compute the drift between two activity dumps or security profiles of the same workload",
+		Long: `Compute the drift between two activity dumps or security profiles of the same workload.
+
+The diff lists the processes, files, DNS names and sockets found in only one of them.
+Processes are matched by executable path.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(diffActivityDumps,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					LogParams:    log.LogForOneShot(command.LoggerName, "off", false)}),
+				core.Bundle,
+			)
+		},
+	}
+
+	activityDumpDiffCmd.Flags().StringVar(
+		&cliParams.base,
+		flags.Base,
+		"",
+		"path to the base activity dump or security profile file",
+	)
+	_ = activityDumpDiffCmd.MarkFlagRequired(flags.Base)
+	activityDumpDiffCmd.Flags().StringVar(
+		&cliParams.new,
+		flags.New,
+		"",
+		"path to the new activity dump or security profile file",
+	)
+	_ = activityDumpDiffCmd.MarkFlagRequired(flags.New)
+	activityDumpDiffCmd.Flags().StringVar(
+		&cliParams.format,
+		flags.Format,
+		"text",
+		"output format of the diff. Available options are [text json dot].",
+	)
+	activityDumpDiffCmd.Flags().StringVar(
+		&cliParams.outputPath,
+		flags.OutputPath,
+		"",
+		"path of the output file, the diff is printed on the standard output by default",
+	)
+	activityDumpDiffCmd.Flags().BoolVar(
+		&cliParams.failOnDrift,
+		flags.FailOnDrift,
+		false,
+		"exit with an error when the activity dumps differ",
+	)
+
+	return []*cobra.Command{activityDumpDiffCmd}
+}
+
 func generateCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	activityDumpGenerateCmd := &cobra.Command{
 		Use:   "generate",
@@ -377,6 +449,64 @@ func generateEncodingFromActivityDump(log log.Component, config config.Component
 	return nil
 }
 
+func decodeActivityDump(file string) (*dump.ActivityDump, error) {
+	ad := dump.NewEmptyActivityDump()
+	if err := ad.Decode(file); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", file, err)
+	}
+	return ad, nil
+}
+
+func diffActivityDumps(log log.Component, config config.Component, args *activityDumpDiffCliParams) error {
+	base, err := decodeActivityDump(args.base)
+	if err != nil {
+		return err
+	}
+	newDump, err := decodeActivityDump(args.new)
+	if err != nil {
+		return err
+	}
+
+	diff := base.Diff(newDump)
+
+	out := os.Stdout
+	if args.outputPath != "" {
+		f, err := os.Create(args.outputPath)
+		if err != nil {
+			return fmt.Errorf("couldn't create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch args.format {
+	case "text":
+		diff.Write(out)
+	case "json":
+		output, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s\n", string(output))
+	case "dot":
+		raw, err := dump.EncodeDiffDOT(base.DiffTitle(newDump), diff)
+		if err != nil {
+			return err
+		}
+		if _, err := raw.WriteTo(out); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported diff format: %s", args.format)
+	}
+
+	if args.failOnDrift && diff.HasDrift() {
+		return fmt.Errorf("drift detected between %s and %s", args.base, args.new)
+	}
+
+	return nil
+}
+
 func listActivityDumps(log log.Component, config config.Component) error {
 	client, err := secagent.NewRuntimeSecurityClient()
 	if err != nil {
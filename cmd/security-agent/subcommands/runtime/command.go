@@ -43,6 +43,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules/ruletest"
 	"github.com/DataDog/datadog-agent/pkg/security/seclog"
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -134,6 +135,8 @@ func commonPolicyCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	commonPolicyCmd.AddCommand(commonCheckPoliciesCommands(globalParams)...)
 	commonPolicyCmd.AddCommand(commonReloadPoliciesCommands(globalParams)...)
 	commonPolicyCmd.AddCommand(downloadPolicyCommands(globalParams)...)
+	commonPolicyCmd.AddCommand(testPoliciesCommands(globalParams)...)
+	commonPolicyCmd.AddCommand(replayEventsCommands(globalParams)...)
 
 	return []*cobra.Command{commonPolicyCmd}
 }
@@ -503,56 +506,6 @@ type EvalReport struct {
 	Error     error `json:",omitempty"`
 }
 
-// EventData defines the structure used to represent an event
-type EventData struct {
-	Type   eval.EventType
-	Values map[string]interface{}
-}
-
-func eventDataFromJSON(file string) (eval.Event, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	decoder := json.NewDecoder(f)
-	decoder.UseNumber()
-
-	var eventData EventData
-	if err := decoder.Decode(&eventData); err != nil {
-		return nil, err
-	}
-
-	kind := model.ParseEvalEventType(eventData.Type)
-	if kind == model.UnknownEventType {
-		return nil, errors.New("unknown event type")
-	}
-
-	m := &model.Model{}
-	event := m.NewDefaultEventWithType(kind)
-	event.Init()
-
-	for k, v := range eventData.Values {
-		switch v := v.(type) {
-		case json.Number:
-			value, err := v.Int64()
-			if err != nil {
-				return nil, err
-			}
-			if err := event.SetFieldValue(k, int(value)); err != nil {
-				return nil, err
-			}
-		default:
-			if err := event.SetFieldValue(k, v); err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return event, nil
-}
-
 func evalRule(log log.Component, config config.Component, evalArgs *evalCliParams) error {
 	policiesDir := evalArgs.dir
 
@@ -595,13 +548,18 @@ func evalRule(log log.Component, config config.Component, evalArgs *evalCliParam
 		return err
 	}
 
-	event, err := eventDataFromJSON(evalArgs.eventFile)
+	data, err := os.ReadFile(evalArgs.eventFile)
+	if err != nil {
+		return err
+	}
+
+	event, err := ruletest.DecodeEvent(data)
 	if err != nil {
 		return err
 	}
 
 	report := EvalReport{
-		Event: event,
+		Event: event.Event,
 	}
 
 	approvers, err := ruleSet.GetApprovers(kfilters.GetCapababilities())
@@ -611,7 +569,7 @@ func evalRule(log log.Component, config config.Component, evalArgs *evalCliParam
 		report.Approvers = approvers
 	}
 
-	report.Succeeded = ruleSet.Evaluate(event)
+	report.Succeeded = ruleSet.Evaluate(event.Event)
 	output, err := json.MarshalIndent(report, "", "    ")
 	if err != nil {
 		return err
@@ -43,3 +43,39 @@ func TestDownloadCommand(t *testing.T) {
 		)
 	}
 }
+
+func TestPolicyTestCommands(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		testPoliciesCommands(&command.GlobalParams{}),
+		[]string{"test", "--policies-dir", "/tmp/policies", "--json"},
+		testPolicies,
+		func(cliParams *testPoliciesCliParams) {
+			require.Equal(t, "/tmp/policies", cliParams.dir)
+			require.True(t, cliParams.json)
+		},
+	)
+
+	fxutil.TestOneShotSubcommand(t,
+		replayEventsCommands(&command.GlobalParams{}),
+		[]string{"replay", "--input", "/tmp/events.jsonl"},
+		replayEvents,
+		func(cliParams *replayEventsCliParams) {
+			require.Equal(t, "/tmp/events.jsonl", cliParams.input)
+			require.False(t, cliParams.json)
+		},
+	)
+}
+
+func TestActivityDumpDiffCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		diffCommands(&command.GlobalParams{}),
+		[]string{"diff", "--base", "/tmp/v1.profile", "--new", "/tmp/v2.profile", "--format", "json", "--fail-on-drift"},
+		diffActivityDumps,
+		func(cliParams *activityDumpDiffCliParams) {
+			require.Equal(t, "/tmp/v1.profile", cliParams.base)
+			require.Equal(t, "/tmp/v2.profile", cliParams.new)
+			require.Equal(t, "json", cliParams.format)
+			require.True(t, cliParams.failOnDrift)
+		},
+	)
+}
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/cmd/security-agent/flags"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules/ruletest"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+type testPoliciesCliParams struct {
+	*command.GlobalParams
+
+	dir  string
+	json bool
+}
+
+func testPoliciesCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &testPoliciesCliParams{
+		GlobalParams: globalParams,
+	}
+
+	testPoliciesCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run the rule tests of a policies directory against their event fixtures",
+		Long: fmt.Sprintf(`Run the rule tests of a policies directory against their event fixtures.
+
+Each %s file of the directory lists test cases: an event fixture, in the format
+of the eval command or of the events sent by the agent, and the rules it should
+or should not trigger. No kernel support is required.`, ruletest.TestFilePattern),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(testPolicies,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					LogParams:    log.LogForOneShot(command.LoggerName, "off", false)}),
+				core.Bundle,
+			)
+		},
+	}
+
+	testPoliciesCmd.Flags().StringVar(&cliParams.dir, flags.PoliciesDir, pkgconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+	testPoliciesCmd.Flags().BoolVar(&cliParams.json, flags.JSON, false, "Print the report in JSON format")
+
+	return []*cobra.Command{testPoliciesCmd}
+}
+
+type replayEventsCliParams struct {
+	*command.GlobalParams
+
+	dir   string
+	input string
+	json  bool
+}
+
+func replayEventsCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &replayEventsCliParams{
+		GlobalParams: globalParams,
+	}
+
+	replayEventsCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay a stream of recorded events against the policies",
+		Long: `Replay a stream of recorded events, one JSON event per line, against the policies.
+
+The command fails if an event recorded with the ID of the rule that triggered it
+doesn't trigger this rule anymore.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(replayEvents,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					LogParams:    log.LogForOneShot(command.LoggerName, "off", false)}),
+				core.Bundle,
+			)
+		},
+	}
+
+	replayEventsCmd.Flags().StringVar(&cliParams.dir, flags.PoliciesDir, pkgconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+	replayEventsCmd.Flags().StringVar(&cliParams.input, flags.Input, "", "Path of the JSON lines file of events to replay")
+	_ = replayEventsCmd.MarkFlagRequired(flags.Input)
+	replayEventsCmd.Flags().BoolVar(&cliParams.json, flags.JSON, false, "Print the report in JSON format")
+
+	return []*cobra.Command{replayEventsCmd}
+}
+
+// loadRuleSet returns a rule set with all the rules of a policies directory
+func loadRuleSet(policiesDir string) (*rules.RuleSet, error) {
+	// enabled all the rules
+	enabled := map[eval.EventType]bool{"*": true}
+
+	ruleOpts, evalOpts := rules.NewEvalOpts(enabled)
+	ruleOpts.WithLogger(seclog.DefaultLogger)
+
+	agentVersionFilter, err := newAgentVersionFilter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent version filter: %w", err)
+	}
+
+	loaderOpts := rules.PolicyLoaderOpts{
+		MacroFilters: []rules.MacroFilter{
+			agentVersionFilter,
+		},
+		RuleFilters: []rules.RuleFilter{
+			agentVersionFilter,
+		},
+	}
+
+	provider, err := rules.NewPoliciesDirProvider(policiesDir, false)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := rules.NewPolicyLoader(provider)
+
+	ruleSet := rules.NewRuleSet(&model.Model{}, model.NewDefaultEvent, ruleOpts, evalOpts)
+	evaluationSet, err := rules.NewEvaluationSet([]*rules.RuleSet{ruleSet})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := evaluationSet.LoadPolicies(loader, loaderOpts); err.ErrorOrNil() != nil {
+		return nil, err
+	}
+
+	return ruleSet, nil
+}
+
+func testPolicies(log log.Component, config config.Component, args *testPoliciesCliParams) error {
+	ruleSet, err := loadRuleSet(args.dir)
+	if err != nil {
+		return err
+	}
+
+	report, err := ruletest.NewRunner(ruleSet).RunDir(args.dir)
+	if err != nil {
+		return err
+	}
+
+	if args.json {
+		output, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(output))
+	} else {
+		report.Write(os.Stdout)
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rule tests failed", report.Failed, report.Failed+report.Passed)
+	}
+
+	return nil
+}
+
+func replayEvents(log log.Component, config config.Component, args *replayEventsCliParams) error {
+	ruleSet, err := loadRuleSet(args.dir)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args.input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := ruletest.NewRunner(ruleSet).Replay(f)
+	if err != nil {
+		return err
+	}
+
+	if args.json {
+		output, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(output))
+	} else {
+		report.Write(os.Stdout)
+	}
+
+	if report.Failed() {
+		return fmt.Errorf("%d events no longer trigger their rule, %d events couldn't be decoded", len(report.Lost), len(report.Errors))
+	}
+
+	return nil
+}
@@ -49,6 +49,10 @@ type UDSListener struct {
 	OriginDetection         bool
 	config                  config.ConfigReader
 
+	// hostProcessOrigin makes the packets sent by processes running outside
+	// of containers use the process as origin, see processUDSOrigin.
+	hostProcessOrigin bool
+
 	dogstatsdMemBasedRateLimiter bool
 }
 
@@ -108,6 +112,7 @@ func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *pac
 		sharedPacketPoolManager:      sharedPacketPoolManager,
 		trafficCapture:               capture,
 		dogstatsdMemBasedRateLimiter: cfg.GetBool("dogstatsd_mem_based_rate_limiter.enabled"),
+		hostProcessOrigin:            cfg.GetBool("workloadmeta_process_collection.enabled"),
 		config:                       cfg,
 	}
 
@@ -195,7 +200,7 @@ func (l *UDSListener) Listen() {
 			t1 = time.Now()
 
 			// Extract container id from credentials
-			pid, container, taggingErr := processUDSOrigin(oobS[:oobn])
+			pid, container, taggingErr := processUDSOrigin(oobS[:oobn], l.hostProcessOrigin)
 
 			if capBuff != nil {
 				capBuff.Pb.Timestamp = time.Now().UnixNano()
@@ -24,6 +24,8 @@ import (
 const (
 	pidToEntityCacheKeyPrefix = "pid_to_entity"
 	pidToEntityCacheDuration  = time.Minute
+
+	processEntityPrefix = "process://"
 )
 
 // ErrNoContainerMatch is returned when no container ID can be matched
@@ -54,7 +56,10 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 // source, and an error if any.
 // PID is added to ancillary data by the Linux kernel if we added the
 // SO_PASSCRED to the socket, see enableUDSPassCred.
-func processUDSOrigin(ancillary []byte) (int, string, error) {
+// If hostProcessOrigin is true, the origin of the packets sent by processes
+// running outside of containers is the process itself, tagged from the
+// process entities of workloadmeta.
+func processUDSOrigin(ancillary []byte, hostProcessOrigin bool) (int, string, error) {
 	messages, err := unix.ParseSocketControlMessage(ancillary)
 	if err != nil {
 		return 0, packets.NoOrigin, err
@@ -79,7 +84,7 @@ func processUDSOrigin(ancillary []byte) (int, string, error) {
 		capture = true
 	}
 
-	entity, err := getEntityForPID(pid, capture)
+	entity, err := getEntityForPID(pid, capture, hostProcessOrigin)
 	if err != nil {
 		return int(pid), packets.NoOrigin, err
 	}
@@ -90,7 +95,7 @@ func processUDSOrigin(ancillary []byte) (int, string, error) {
 // getEntityForPID returns the container entity name and caches the value for future lookups
 // As the result is cached and the lookup is really fast (parsing local files), it can be
 // called from the intake goroutine.
-func getEntityForPID(pid int32, capture bool) (string, error) {
+func getEntityForPID(pid int32, capture bool, hostProcessOrigin bool) (string, error) {
 	key := cache.BuildAgentKey(pidToEntityCacheKeyPrefix, strconv.Itoa(int(pid)))
 	if x, found := cache.Cache.Get(key); found {
 		return x.(string), nil
@@ -105,9 +110,14 @@ func getEntityForPID(pid int32, capture bool) (string, error) {
 		}
 		return entity, nil
 	case errNoContainerMatch:
-		// No runtime detected, cache the `packets.NoOrigin` result
-		cache.Cache.Set(key, packets.NoOrigin, pidToEntityCacheDuration)
-		return packets.NoOrigin, nil
+		// No runtime detected, cache the process entity or the
+		// `packets.NoOrigin` result
+		entity = packets.NoOrigin
+		if hostProcessOrigin && !capture {
+			entity = fmt.Sprintf("%s%d", processEntityPrefix, pid)
+		}
+		cache.Cache.Set(key, entity, pidToEntityCacheDuration)
+		return entity, nil
 	default:
 		// Other lookup error, retry next time
 		return packets.NoOrigin, err
@@ -29,6 +29,6 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 }
 
 // processUDSOrigin returns a "not implemented" error on non-linux hosts
-func processUDSOrigin(oob []byte) (int, string, error) {
+func processUDSOrigin(oob []byte, hostProcessOrigin bool) (int, string, error) {
 	return 0, packets.NoOrigin, ErrLinuxOnly
 }
@@ -14,15 +14,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/endpoints"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/internal/archive"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/internal/retry"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
 	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/config/resolver"
 	"github.com/DataDog/datadog-agent/pkg/config/utils"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -200,10 +203,15 @@ type DefaultForwarder struct {
 
 	domainForwarders map[string]*domainForwarder
 	domainResolvers  map[string]resolver.DomainResolver
+	failover         *failoverGroup
+	archiver         *archive.Archiver
 	healthChecker    *forwarderHealth
 	internalState    *atomic.Uint32
 	m                sync.Mutex // To control Start/Stop races
 
+	// unsubscribeAPIKeys stops the updates of the API keys on secret refreshes
+	unsubscribeAPIKeys func()
+
 	completionHandler transaction.HTTPCompletionHandler
 
 	agentName                       string
@@ -215,6 +223,7 @@ type DefaultForwarder struct {
 // TODO: (components) Remove this method and other exported methods in comp/forwarder.
 func NewDefaultForwarder(config config.Component, options *Options) *DefaultForwarder {
 	agentName := getAgentName(options)
+	domainResolvers, secondaryDomain := addFailoverDomain(config, options, utils.GetInfraEndpoint(config))
 	f := &DefaultForwarder{
 		config:           config,
 		NumberOfWorkers:  options.NumberOfWorkers,
@@ -222,16 +231,18 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 		domainResolvers:  map[string]resolver.DomainResolver{},
 		internalState:    atomic.NewUint32(Stopped),
 		healthChecker: &forwarderHealth{
-			domainResolvers:       options.DomainResolvers,
+			domainResolvers:       domainResolvers,
 			disableAPIKeyChecking: options.DisableAPIKeyChecking,
 			validationInterval:    options.APIKeyValidationInterval,
 		},
 		completionHandler: options.CompletionHandler,
 		agentName:         agentName,
+		archiver:          archive.NewArchiver(config),
 	}
 	var optionalRemovalPolicy *retry.FileRemovalPolicy
 	storageMaxSize := config.GetInt64("forwarder_storage_max_size_in_bytes")
 	var diskUsageLimit *retry.DiskUsageLimit
+	var storageClasses *retry.StorageClasses
 
 	// Disk Persistence is a core-only feature for now.
 	if storageMaxSize == 0 {
@@ -258,6 +269,7 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 
 		diskRatio := config.GetFloat64("forwarder_storage_max_disk_ratio")
 		diskUsageLimit = retry.NewDiskUsageLimit(storagePath, filesystem.NewDisk(), storageMaxSize, diskRatio)
+		storageClasses = newStorageClasses(config)
 
 	} else {
 		log.Infof("Retry queue storage on disk is disabled because the feature is unavailable for this process.")
@@ -267,7 +279,7 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 	domainForwarderSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: true}
 	transactionContainerSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: false}
 
-	for domain, resolver := range options.DomainResolvers {
+	for domain, resolver := range domainResolvers {
 		domain, _ := pkgconfig.AddAgentVersionToDomain(domain, "app")
 		resolver.SetBaseDomain(domain)
 		if resolver.GetAPIKeys() == nil || len(resolver.GetAPIKeys()) == 0 {
@@ -288,6 +300,7 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 				flushToDiskMemRatio,
 				domainFolderPath,
 				diskUsageLimit,
+				storageClasses,
 				transactionContainerSort,
 				resolver,
 				pointCountTelemetry)
@@ -308,6 +321,10 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 		}
 	}
 
+	if secondaryDomain != "" {
+		f.setupFailover(utils.GetInfraEndpoint(config), secondaryDomain)
+	}
+
 	timeInterval := config.GetInt("forwarder_retry_queue_capacity_time_interval_sec")
 	if f.agentName != "" {
 		f.queueDurationCapacity = retry.NewQueueDurationCapacity(
@@ -328,6 +345,96 @@ func NewDefaultForwarder(config config.Component, options *Options) *DefaultForw
 	return f
 }
 
+// newStorageClasses returns the storage classes of the transactions stored on
+// disk, defined in `forwarder_storage_classes`. All transactions belong to the
+// default class when this setting is invalid.
+func newStorageClasses(config config.Component) *retry.StorageClasses {
+	var classConfigs []retry.StorageClassConfig
+	if config.IsSet("forwarder_storage_classes") {
+		if err := mapstructure.Decode(config.Get("forwarder_storage_classes"), &classConfigs); err != nil {
+			log.Errorf("Cannot parse forwarder_storage_classes, storage classes are disabled: %v", err)
+			classConfigs = nil
+		}
+	}
+
+	storageClasses, err := retry.NewStorageClasses(classConfigs)
+	if err != nil {
+		log.Errorf("Invalid forwarder_storage_classes, storage classes are disabled: %v", err)
+		storageClasses, _ = retry.NewStorageClasses(nil)
+	} else if len(classConfigs) > 0 {
+		log.Infof("Retry queue storage classes on disk, by priority: %s", strings.Join(storageClasses.Names(), ", "))
+	}
+	return storageClasses
+}
+
+// addFailoverDomain returns the domain resolvers of the options with the
+// secondary domain of the failover group, and the secondary domain. The
+// resolvers of the options are not modified. The secondary domain is empty
+// when failover is disabled or misconfigured, so that it is neither forwarded
+// to nor checked by the health checker.
+func addFailoverDomain(config config.Component, options *Options, primaryURL string) (map[string]resolver.DomainResolver, string) {
+	if !config.GetBool("forwarder_failover.enabled") {
+		return options.DomainResolvers, ""
+	}
+
+	secondaryURL := config.GetString("forwarder_failover.secondary_url")
+	secondaryAPIKey := pkgconfig.SanitizeAPIKey(config.GetString("forwarder_failover.secondary_api_key"))
+	if secondaryURL == "" || secondaryAPIKey == "" {
+		log.Errorf("Forwarder failover is enabled but forwarder_failover.secondary_url or forwarder_failover.secondary_api_key is not set: failover is disabled")
+		return options.DomainResolvers, ""
+	}
+
+	// the primary domain must have a domainForwarder for the failover group to be set up
+	if primary, found := options.DomainResolvers[primaryURL]; !found || len(primary.GetAPIKeys()) == 0 {
+		log.Errorf("The forwarder failover primary domain '%s' is not configured with an API key: failover is disabled", primaryURL)
+		return options.DomainResolvers, ""
+	}
+
+	if _, found := options.DomainResolvers[secondaryURL]; found {
+		log.Errorf("The failover secondary domain '%s' is already configured as an additional endpoint: failover is disabled", secondaryURL)
+		return options.DomainResolvers, ""
+	}
+
+	// Don't modify the resolvers given by the caller
+	domainResolvers := make(map[string]resolver.DomainResolver, len(options.DomainResolvers)+1)
+	for domain, dr := range options.DomainResolvers {
+		domainResolvers[domain] = dr
+	}
+	domainResolvers[secondaryURL] = resolver.NewSingleDomainResolver(secondaryURL, []string{secondaryAPIKey})
+
+	secondaryDomain, _ := pkgconfig.AddAgentVersionToDomain(secondaryURL, "app")
+	return domainResolvers, secondaryDomain
+}
+
+// setupFailover creates the failover group between the main endpoint and the
+// secondary domain, once their domainForwarders are created. addFailoverDomain
+// checked that both domains have one.
+func (f *DefaultForwarder) setupFailover(primaryURL string, secondaryDomain string) {
+	primaryDomain, _ := pkgconfig.AddAgentVersionToDomain(primaryURL, "app")
+	primaryForwarder := f.domainForwarders[primaryDomain]
+
+	f.failover = newFailoverGroup(f.config, primaryDomain, secondaryDomain, newValidateProbe(primaryDomain, f.domainResolvers[primaryDomain]))
+	primaryForwarder.failover = f.failover
+	log.Infof("Forwarder failover enabled: payloads are sent to '%s' and to '%s' when it is unhealthy", primaryDomain, secondaryDomain)
+}
+
+// updateAPIKeys replaces the API keys whose secret value changed during a refresh
+func (f *DefaultForwarder) updateAPIKeys(changes []secrets.Change) {
+	for _, change := range changes {
+		oldKey := pkgconfig.SanitizeAPIKey(change.OldValue)
+		newKey := pkgconfig.SanitizeAPIKey(change.NewValue)
+		for domain, dr := range f.domainResolvers {
+			for _, apiKey := range dr.GetAPIKeys() {
+				if apiKey == oldKey {
+					log.Infof("API key for domain '%s' was refreshed from secret '%s'", domain, change.Handle)
+					dr.UpdateAPIKey(oldKey, newKey)
+					break
+				}
+			}
+		}
+	}
+}
+
 func getAgentName(options *Options) string {
 	if HasFeature(options.EnabledFeatures, CoreFeatures) {
 		return "core"
@@ -361,6 +468,10 @@ func (f *DefaultForwarder) Start() error {
 	log.Infof("Forwarder started, sending to %v endpoint(s) with %v worker(s) each: %s",
 		len(endpointLogs), f.NumberOfWorkers, strings.Join(endpointLogs, " ; "))
 
+	// API keys can be stored as secrets: new transactions use the new keys once those secrets are refreshed
+	f.unsubscribeAPIKeys = secrets.SubscribeToChanges(f.updateAPIKeys)
+
+	f.archiver.Start()
 	f.healthChecker.Start()
 	f.internalState.Store(Started)
 	return nil
@@ -409,7 +520,9 @@ func (f *DefaultForwarder) Stop() {
 		}
 	}
 
+	f.unsubscribeAPIKeys()
 	f.healthChecker.Stop()
+	f.archiver.Stop()
 
 	f.healthChecker = nil
 	f.domainForwarders = map[string]*domainForwarder{}
@@ -434,7 +547,11 @@ func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.E
 	allowArbitraryTags := f.config.GetBool("allow_arbitrary_tags")
 
 	for _, payload := range payloads {
+		f.archiver.Archive(endpoint, payload, extra)
 		for domain, dr := range f.domainResolvers {
+			if !f.failover.isActive(domain) {
+				continue
+			}
 			for _, apiKey := range dr.GetAPIKeys() {
 				t := transaction.NewHTTPTransaction()
 				t.Domain, _ = dr.Resolve(endpoint)
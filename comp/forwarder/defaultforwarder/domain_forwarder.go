@@ -43,6 +43,7 @@ type domainForwarder struct {
 	transactionPrioritySorter retry.TransactionPrioritySorter
 	blockedList               *blockedEndpoints
 	pointCountTelemetry       *retry.PointCountTelemetry
+	failover                  *failoverGroup
 }
 
 func newDomainForwarder(
@@ -205,6 +206,7 @@ func (f *domainForwarder) Start() error {
 
 	for i := 0; i < f.numberOfWorkers; i++ {
 		w := NewWorker(f.config, f.highPrio, f.lowPrio, f.requeuedTransaction, f.blockedList, f.pointCountTelemetry)
+		w.failover = f.failover
 		w.Start()
 		f.workers = append(f.workers, w)
 	}
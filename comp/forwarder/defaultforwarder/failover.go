@@ -0,0 +1,259 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package defaultforwarder
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/endpoints"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const failoverProbeTimeout = 10 * time.Second
+
+// errEndpointBlocked is reported to the failover group when a transaction is
+// requeued because its endpoint is blocked
+var errEndpointBlocked = errors.New("the endpoint is blocked after too many errors")
+
+var (
+	failoverExpvars = expvar.Map{}
+
+	tlmFailoverActive = telemetry.NewGauge("forwarder", "failover_active",
+		[]string{"domain"}, "1 if the domain is the one receiving payloads in a failover group, 0 otherwise")
+	tlmFailovers = telemetry.NewCounter("forwarder", "failovers",
+		[]string{"from", "to"}, "Number of switches between the primary and the secondary domain of a failover group")
+)
+
+func init() {
+	failoverExpvars.Init()
+	transaction.ForwarderExpvars.Set("Failover", &failoverExpvars)
+}
+
+// failoverGroup routes payloads to a primary domain and switches to a
+// secondary one when the primary is unhealthy.
+//
+// The primary is considered unhealthy after `failureThreshold` consecutive
+// transactions either failed, took longer than `latencyThreshold` or were
+// requeued because the primary is blocked. Once failed over, transactions
+// already queued for the primary keep being retried by its domainForwarder.
+// When the primary did not report any failure for `failbackInterval`, the
+// group probes it and fails back only if the probe succeeds.
+type failoverGroup struct {
+	primary          string
+	secondary        string
+	failureThreshold int
+	latencyThreshold time.Duration
+	failbackInterval time.Duration
+
+	m                   sync.Mutex
+	failedOver          bool
+	consecutiveFailures int
+	lastFailure         time.Time
+	failedOverAt        time.Time
+	failoverCount       int
+	probing             bool
+
+	// probe checks that the primary domain accepts requests again
+	probe func() error
+	// now can be overridden in tests
+	now func() time.Time
+}
+
+func newFailoverGroup(config config.Component, primary, secondary string, probe func() error) *failoverGroup {
+	failureThreshold := config.GetInt("forwarder_failover.failure_threshold")
+	if failureThreshold <= 0 {
+		log.Warnf("Configured forwarder_failover.failure_threshold (%v) is not positive; 5 will be used", failureThreshold)
+		failureThreshold = 5
+	}
+
+	failbackInterval := config.GetInt("forwarder_failover.failback_interval")
+	if failbackInterval <= 0 {
+		log.Warnf("Configured forwarder_failover.failback_interval (%v) is not positive; 300 seconds will be used", failbackInterval)
+		failbackInterval = 300
+	}
+
+	g := &failoverGroup{
+		primary:          primary,
+		secondary:        secondary,
+		failureThreshold: failureThreshold,
+		latencyThreshold: time.Duration(config.GetInt("forwarder_failover.latency_threshold")) * time.Second,
+		failbackInterval: time.Duration(failbackInterval) * time.Second,
+		probe:            probe,
+		now:              time.Now,
+	}
+
+	failoverExpvars.Set("Primary", expvarString(primary))
+	failoverExpvars.Set("Secondary", expvarString(secondary))
+	failoverExpvars.Set("ActiveDomain", expvar.Func(func() interface{} { return g.activeDomain() }))
+	failoverExpvars.Set("ConsecutiveFailures", expvar.Func(func() interface{} { return g.failures() }))
+	failoverExpvars.Set("FailoverCount", expvar.Func(func() interface{} { return g.failovers() }))
+	g.updateTelemetry()
+
+	return g
+}
+
+func expvarString(value string) *expvar.String {
+	s := &expvar.String{}
+	s.Set(value)
+	return s
+}
+
+// isActive returns whether new transactions should be created for the domain.
+// Domains that are not part of the group are always active.
+func (g *failoverGroup) isActive(domain string) bool {
+	if g == nil || (domain != g.primary && domain != g.secondary) {
+		return true
+	}
+	return g.activeDomain() == domain
+}
+
+// activeDomain returns the domain currently receiving payloads. When the
+// primary did not fail for the failback interval, it starts probing it in the
+// background: the secondary stays active until the probe succeeds.
+func (g *failoverGroup) activeDomain() string {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	if g.failedOver && !g.probing {
+		now := g.now()
+		if now.Sub(g.failedOverAt) >= g.failbackInterval && now.Sub(g.lastFailure) >= g.failbackInterval {
+			g.probing = true
+			go g.probePrimary()
+		}
+	}
+
+	if g.failedOver {
+		return g.secondary
+	}
+	return g.primary
+}
+
+// probePrimary fails back to the primary domain if it is healthy, or delays
+// the next probe by the failback interval otherwise.
+func (g *failoverGroup) probePrimary() {
+	start := g.now()
+	err := g.probe()
+	latency := g.now().Sub(start)
+
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.probing = false
+
+	if err == nil && (g.latencyThreshold == 0 || latency <= g.latencyThreshold) {
+		log.Infof("Primary domain '%s' did not fail for %s and answered the probe, failing back from '%s'", g.primary, g.failbackInterval, g.secondary)
+		g.failedOver = false
+		g.consecutiveFailures = 0
+		tlmFailovers.Inc(g.secondary, g.primary)
+		g.updateTelemetry()
+		return
+	}
+
+	g.lastFailure = g.now()
+	if err != nil {
+		log.Warnf("The probe of the primary domain '%s' failed, staying on '%s': %v", g.primary, g.secondary, err)
+	} else {
+		log.Warnf("The probe of the primary domain '%s' took %s, staying on '%s'", g.primary, latency, g.secondary)
+	}
+}
+
+// onTransactionResult records the result of a transaction sent to the primary
+// domain. Transactions slower than the latency threshold count as failures.
+func (g *failoverGroup) onTransactionResult(err error, latency time.Duration) {
+	if g == nil {
+		return
+	}
+
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	if err == nil && (g.latencyThreshold == 0 || latency <= g.latencyThreshold) {
+		g.consecutiveFailures = 0
+		return
+	}
+
+	g.consecutiveFailures++
+	g.lastFailure = g.now()
+
+	if !g.failedOver && g.consecutiveFailures >= g.failureThreshold {
+		if err != nil {
+			log.Warnf("%d consecutive transactions failed for domain '%s', failing over to '%s': %v", g.consecutiveFailures, g.primary, g.secondary, err)
+		} else {
+			log.Warnf("%d consecutive transactions failed or took more than %s for domain '%s', failing over to '%s'", g.consecutiveFailures, g.latencyThreshold, g.primary, g.secondary)
+		}
+		g.failedOver = true
+		g.failedOverAt = g.lastFailure
+		g.failoverCount++
+		tlmFailovers.Inc(g.primary, g.secondary)
+		g.updateTelemetry()
+	}
+}
+
+func (g *failoverGroup) failures() int {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.consecutiveFailures
+}
+
+func (g *failoverGroup) failovers() int {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.failoverCount
+}
+
+// updateTelemetry must be called with g.m held
+func (g *failoverGroup) updateTelemetry() {
+	if g.failedOver {
+		tlmFailoverActive.Set(0, g.primary)
+		tlmFailoverActive.Set(1, g.secondary)
+	} else {
+		tlmFailoverActive.Set(1, g.primary)
+		tlmFailoverActive.Set(0, g.secondary)
+	}
+}
+
+// newValidateProbe returns a probe validating the first API key of the domain
+// resolver against the domain
+func newValidateProbe(domain string, dr resolver.DomainResolver) func() error {
+	client := &http.Client{
+		Transport: httputils.CreateHTTPTransport(),
+		Timeout:   failoverProbeTimeout,
+	}
+	return func() error {
+		apiKeys := dr.GetAPIKeys()
+		if len(apiKeys) == 0 {
+			return fmt.Errorf("no API key for domain '%s'", domain)
+		}
+
+		req, err := http.NewRequest("GET", domain+endpoints.V1ValidateEndpoint.Route, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(apiHTTPHeaderKey, apiKeys[0])
+		req.Header.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected response code from the API key validation endpoint: %v", resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package defaultforwarder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+)
+
+// testProbe is a probe of the primary domain whose result is set by the tests
+type testProbe struct {
+	err   *atomic.Error
+	calls *atomic.Int32
+}
+
+func (p testProbe) probe() error {
+	p.calls.Inc()
+	return p.err.Load()
+}
+
+func newTestFailoverGroup(t *testing.T) (*failoverGroup, *time.Time, testProbe) {
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_failover.failure_threshold", 3)
+	mockConfig.Set("forwarder_failover.latency_threshold", 2)
+	mockConfig.Set("forwarder_failover.failback_interval", 60)
+
+	now := time.Now()
+	p := testProbe{err: atomic.NewError(nil), calls: atomic.NewInt32(0)}
+	g := newFailoverGroup(mockConfig, "primary", "secondary", p.probe)
+	g.now = func() time.Time { return now }
+	return g, &now, p
+}
+
+// waitForProbe waits for the background probe of the group to complete
+func waitForProbe(t *testing.T, g *failoverGroup) {
+	assert.Eventually(t, func() bool {
+		g.m.Lock()
+		defer g.m.Unlock()
+		return !g.probing
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestFailoverAfterConsecutiveFailures(t *testing.T) {
+	g, _, _ := newTestFailoverGroup(t)
+	err := fmt.Errorf("error")
+
+	g.onTransactionResult(err, time.Millisecond)
+	g.onTransactionResult(err, time.Millisecond)
+	g.onTransactionResult(nil, time.Millisecond)
+	g.onTransactionResult(err, time.Millisecond)
+	g.onTransactionResult(err, time.Millisecond)
+	assert.True(t, g.isActive("primary"))
+	assert.False(t, g.isActive("secondary"))
+	assert.True(t, g.isActive("other"))
+
+	g.onTransactionResult(err, time.Millisecond)
+	assert.False(t, g.isActive("primary"))
+	assert.True(t, g.isActive("secondary"))
+	assert.True(t, g.isActive("other"))
+	assert.Equal(t, 1, g.failovers())
+}
+
+func TestFailoverOnLatency(t *testing.T) {
+	g, _, _ := newTestFailoverGroup(t)
+
+	for i := 0; i < 3; i++ {
+		g.onTransactionResult(nil, 3*time.Second)
+	}
+	assert.Equal(t, "secondary", g.activeDomain())
+}
+
+func TestFailback(t *testing.T) {
+	g, now, probe := newTestFailoverGroup(t)
+	err := fmt.Errorf("error")
+
+	for i := 0; i < 3; i++ {
+		g.onTransactionResult(err, time.Millisecond)
+	}
+	assert.Equal(t, "secondary", g.activeDomain())
+
+	// retried transactions keep failing on the primary domain
+	*now = now.Add(50 * time.Second)
+	g.onTransactionResult(err, time.Millisecond)
+	*now = now.Add(50 * time.Second)
+	assert.Equal(t, "secondary", g.activeDomain())
+
+	// no failure during the failback interval: the primary is probed before
+	// failing back
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, "secondary", g.activeDomain())
+	waitForProbe(t, g)
+	assert.EqualValues(t, 1, probe.calls.Load())
+	assert.Equal(t, "primary", g.activeDomain())
+	assert.Equal(t, 0, g.failures())
+
+	// the primary domain fails again
+	for i := 0; i < 3; i++ {
+		g.onTransactionResult(err, time.Millisecond)
+	}
+	assert.Equal(t, "secondary", g.activeDomain())
+	assert.Equal(t, 2, g.failovers())
+}
+
+func TestFailbackProbeFailure(t *testing.T) {
+	g, now, probe := newTestFailoverGroup(t)
+
+	for i := 0; i < 3; i++ {
+		g.onTransactionResult(errEndpointBlocked, 0)
+	}
+	assert.Equal(t, "secondary", g.activeDomain())
+
+	// the primary domain still rejects requests: the next probe is delayed by
+	// the failback interval
+	probe.err.Store(fmt.Errorf("error"))
+	*now = now.Add(60 * time.Second)
+	assert.Equal(t, "secondary", g.activeDomain())
+	waitForProbe(t, g)
+	assert.Equal(t, "secondary", g.activeDomain())
+	*now = now.Add(30 * time.Second)
+	assert.Equal(t, "secondary", g.activeDomain())
+	assert.EqualValues(t, 1, probe.calls.Load())
+
+	probe.err.Store(nil)
+	*now = now.Add(30 * time.Second)
+	g.activeDomain()
+	waitForProbe(t, g)
+	assert.EqualValues(t, 2, probe.calls.Load())
+	assert.Equal(t, "primary", g.activeDomain())
+}
+
+func TestValidateProbe(t *testing.T) {
+	status := atomic.NewInt32(http.StatusForbidden)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/validate", r.URL.Path)
+		assert.Equal(t, "api-key-1", r.Header.Get("DD-Api-Key"))
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer ts.Close()
+
+	probe := newValidateProbe(ts.URL, resolver.NewSingleDomainResolver(ts.URL, []string{"api-key-1"}))
+	assert.Error(t, probe())
+	status.Store(http.StatusOK)
+	assert.NoError(t, probe())
+}
+
+func TestFailoverNilGroup(t *testing.T) {
+	var g *failoverGroup
+	g.onTransactionResult(fmt.Errorf("error"), time.Millisecond)
+	assert.True(t, g.isActive("primary"))
+}
+
+func TestCreateHTTPTransactionsWithFailover(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("dd_url", testDomain)
+	mockConfig.Set("forwarder_failover.enabled", true)
+	mockConfig.Set("forwarder_failover.secondary_url", "https://app.datadoghq.eu")
+	mockConfig.Set("forwarder_failover.secondary_api_key", "secondary-key")
+	mockConfig.Set("forwarder_failover.failure_threshold", 1)
+
+	options := NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains))
+	forwarder := NewDefaultForwarder(mockConfig, options)
+	require.NotNil(t, forwarder.failover)
+	// the resolvers of the caller are not modified
+	assert.Len(t, options.DomainResolvers, 2)
+	assert.Len(t, forwarder.domainResolvers, 3)
+	secondaryDomain, _ := pkgconfig.AddAgentVersionToDomain("https://app.datadoghq.eu", "app")
+	assert.Equal(t, testVersionDomain, forwarder.failover.primary)
+	assert.Equal(t, secondaryDomain, forwarder.failover.secondary)
+	assert.Equal(t, forwarder.failover, forwarder.domainForwarders[testVersionDomain].failover)
+	assert.Nil(t, forwarder.domainForwarders[secondaryDomain].failover)
+
+	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
+	p1 := []byte("A payload")
+	payloads := transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&p1})
+
+	countByDomain := func() map[string]int {
+		count := map[string]int{}
+		for _, t := range forwarder.createHTTPTransactions(endpoint, payloads, make(http.Header)) {
+			count[t.Domain]++
+		}
+		return count
+	}
+
+	assert.Equal(t, map[string]int{testVersionDomain: 2, "datadog.bar": 1}, countByDomain())
+
+	forwarder.failover.onTransactionResult(fmt.Errorf("error"), time.Millisecond)
+	assert.Equal(t, map[string]int{secondaryDomain: 1, "datadog.bar": 1}, countByDomain())
+}
+
+func TestFailoverMisconfigured(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_failover.enabled", true)
+	mockConfig.Set("forwarder_failover.secondary_url", "https://app.datadoghq.eu")
+
+	forwarder := NewDefaultForwarder(mockConfig, NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	assert.Nil(t, forwarder.failover)
+	assert.Len(t, forwarder.domainResolvers, 2)
+}
+
+func TestFailoverWithoutPrimaryDomain(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("dd_url", "https://unknown.datadoghq.com")
+	mockConfig.Set("forwarder_failover.enabled", true)
+	mockConfig.Set("forwarder_failover.secondary_url", "https://app.datadoghq.eu")
+	mockConfig.Set("forwarder_failover.secondary_api_key", "secondary-key")
+
+	forwarder := NewDefaultForwarder(mockConfig, NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	assert.Nil(t, forwarder.failover)
+	assert.Len(t, forwarder.domainResolvers, 2)
+	// the secondary domain is not checked either
+	assert.Len(t, forwarder.healthChecker.domainResolvers, 2)
+	assert.NotContains(t, forwarder.healthChecker.domainResolvers, "https://app.datadoghq.eu")
+}
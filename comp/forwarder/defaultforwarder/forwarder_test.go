@@ -10,6 +10,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -23,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -168,6 +172,33 @@ func TestCreateHTTPTransactions(t *testing.T) {
 	assert.Equal(t, p2, transactions[3].Payload.GetContent())
 }
 
+func TestCreateHTTPTransactionsArchivesOncePerPayload(t *testing.T) {
+	dir := t.TempDir()
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.enabled", true)
+	mockConfig.Set("forwarder_archive.file.path", dir)
+	forwarder := NewDefaultForwarder(mockConfig, NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	forwarder.archiver.Start()
+	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
+	p1 := []byte("A payload")
+	p2 := []byte("Another payload")
+	payloads := transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&p1, &p2})
+
+	transactions := forwarder.createHTTPTransactions(endpoint, payloads, make(http.Header))
+	require.Len(t, transactions, 6)
+	forwarder.archiver.Stop()
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	lines := 0
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, err)
+		lines += strings.Count(string(content), "\n")
+	}
+	assert.Equal(t, 2, lines)
+}
+
 func TestCreateHTTPTransactionsWithMultipleDomains(t *testing.T) {
 	mockConfig := pkgconfig.Mock(t)
 	forwarder := NewDefaultForwarder(mockConfig, NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
@@ -204,6 +235,28 @@ func TestCreateHTTPTransactionsWithMultipleDomains(t *testing.T) {
 	assert.Equal(t, txBar[0].Headers.Get("DD-Api-Key"), "api-key-3")
 }
 
+func TestUpdateAPIKeysOnSecretsRefresh(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	forwarder := NewDefaultForwarder(mockConfig, NewOptionsWithResolvers(mockConfig, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+
+	forwarder.updateAPIKeys([]secrets.Change{
+		{Handle: "unrelated", OldValue: "password", NewValue: "new-password"},
+		{Handle: "key2", OldValue: "api-key-2", NewValue: "api-key-5\n"},
+	})
+
+	assert.Equal(t, []string{"api-key-1", "api-key-5"}, forwarder.domainResolvers[testVersionDomain].GetAPIKeys())
+	assert.Equal(t, []string{"api-key-3"}, forwarder.domainResolvers["datadog.bar"].GetAPIKeys())
+
+	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
+	p1 := []byte("A payload")
+	transactions := forwarder.createHTTPTransactions(endpoint, transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&p1}), make(http.Header))
+	apiKeys := []string{}
+	for _, tr := range transactions {
+		apiKeys = append(apiKeys, tr.Headers.Get("DD-Api-Key"))
+	}
+	assert.ElementsMatch(t, []string{"api-key-1", "api-key-3", "api-key-5"}, apiKeys)
+}
+
 func TestCreateHTTPTransactionsWithDifferentResolvers(t *testing.T) {
 	resolvers := resolver.NewSingleDomainResolvers(keysWithMultipleDomains)
 	additionalResolver := resolver.NewMultiDomainResolver("datadog.vector", []string{"api-key-4"})
@@ -683,3 +736,24 @@ func TestCustomCompletionHandler(t *testing.T) {
 
 	assert.True(t, handlerCalled)
 }
+
+func TestNewStorageClasses(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.SetConfigType("yaml")
+	err := mockConfig.ReadConfig(strings.NewReader(`
+forwarder_storage_classes:
+  - name: series
+    endpoints: [series_v2, services_checks_v2]
+    quota_ratio: 0.5
+  - name: process
+    endpoints: [process]
+    drop_policy: newest
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"series", "process", "default"}, newStorageClasses(mockConfig).Names())
+
+	// invalid classes are ignored
+	mockConfig.Set("forwarder_storage_classes", []map[string]interface{}{{"name": "series", "quota_ratio": 2}})
+	assert.Equal(t, []string{"default"}, newStorageClasses(mockConfig).Names())
+}
@@ -0,0 +1,372 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package archive writes a copy of the payloads sent by the forwarder to a
+// local directory or to an S3-compatible storage, for audit and debugging
+// purposes.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tlmRecords = telemetry.NewCounter("forwarder_archive", "records",
+		[]string{"status"}, "Number of payloads processed by the archiver, by status (archived, dropped, error)")
+	tlmBytes = telemetry.NewCounter("forwarder_archive", "bytes",
+		nil, "Number of bytes written by the archiver")
+)
+
+// Record is the archived copy of a payload, written as one JSON line. A
+// payload is archived once, whatever the number of domains and API keys it is
+// sent to.
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Endpoint  string    `json:"endpoint"`
+	Path      string    `json:"path"`
+	// Headers are the payload specific HTTP headers, like its encoding. The
+	// headers common to every transaction, like the API key, are not archived.
+	Headers map[string]string `json:"headers"`
+	// Decompressed is true if the body was decompressed according to the
+	// Content-Encoding header
+	Decompressed bool `json:"decompressed"`
+	// Truncated is true if the body exceeded the maximum payload size
+	Truncated bool `json:"truncated"`
+	// Size is the size of the body before it was truncated
+	Size int    `json:"size"`
+	Body []byte `json:"body"`
+}
+
+// sink is a destination of the archived records
+type sink interface {
+	// write writes a serialized record, terminated by a new line
+	write(line []byte) error
+	// flush is called periodically, and before close
+	flush() error
+	close() error
+	String() string
+}
+
+// entry is a payload queued to be archived. It references the payload content
+// without copying it: decompressing and serializing it is left to the archiver
+// goroutine.
+type entry struct {
+	timestamp time.Time
+	endpoint  transaction.Endpoint
+	headers   http.Header
+	body      []byte
+}
+
+// Archiver copies payloads to one or more sinks. Payloads are archived
+// asynchronously: they are dropped if the archiver can't keep up.
+type Archiver struct {
+	sampleRate     float64
+	paths          []string
+	decompress     bool
+	maxPayloadSize int
+	flushInterval  time.Duration
+	sinks          []sink
+
+	input   chan entry
+	stop    chan struct{}
+	stopped chan struct{}
+
+	// m protects started and closed
+	m       sync.Mutex
+	started bool
+	closed  bool
+
+	// random can be overridden in tests
+	random func() float64
+}
+
+// NewArchiver returns a new Archiver configured with the `forwarder_archive`
+// settings, or nil if archiving is disabled or misconfigured.
+func NewArchiver(config config.Component) *Archiver {
+	if !config.GetBool("forwarder_archive.enabled") {
+		return nil
+	}
+
+	sinks := []sink{}
+	if config.GetString("forwarder_archive.file.path") != "" {
+		s, err := newFileSink(config)
+		if err != nil {
+			log.Errorf("Cannot archive payloads to a local directory: %v", err)
+		} else {
+			sinks = append(sinks, s)
+		}
+	}
+	if config.GetString("forwarder_archive.s3.bucket") != "" {
+		s, err := newS3Sink(config)
+		if err != nil {
+			log.Errorf("Cannot archive payloads to S3: %v", err)
+		} else {
+			sinks = append(sinks, s)
+		}
+	}
+	if len(sinks) == 0 {
+		log.Errorf("Forwarder archiving is enabled but neither forwarder_archive.file.path nor forwarder_archive.s3.bucket is usable: archiving is disabled")
+		return nil
+	}
+
+	sampleRate := config.GetFloat64("forwarder_archive.sample_rate")
+	if sampleRate <= 0 || sampleRate > 1 {
+		log.Warnf("Configured forwarder_archive.sample_rate (%v) is not in ]0, 1]; 1 will be used", sampleRate)
+		sampleRate = 1
+	}
+
+	queueSize := config.GetInt("forwarder_archive.queue_size")
+	if queueSize <= 0 {
+		log.Warnf("Configured forwarder_archive.queue_size (%v) is not positive; 100 will be used", queueSize)
+		queueSize = 100
+	}
+
+	return &Archiver{
+		sampleRate:     sampleRate,
+		paths:          config.GetStringSlice("forwarder_archive.paths"),
+		decompress:     config.GetBool("forwarder_archive.decompress"),
+		maxPayloadSize: config.GetInt("forwarder_archive.max_payload_size"),
+		flushInterval:  time.Duration(config.GetInt("forwarder_archive.flush_interval")) * time.Second,
+		sinks:          sinks,
+		input:          make(chan entry, queueSize),
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+		random:         rand.Float64,
+	}
+}
+
+// Start starts archiving the payloads. The payloads queued before are
+// archived too. An archiver can't be started again once stopped.
+func (a *Archiver) Start() {
+	if a == nil {
+		return
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.started || a.closed {
+		return
+	}
+	a.started = true
+
+	sinkNames := make([]string, 0, len(a.sinks))
+	for _, s := range a.sinks {
+		sinkNames = append(sinkNames, s.String())
+	}
+	log.Infof("Archiving forwarder payloads to %s", strings.Join(sinkNames, ", "))
+
+	go a.run()
+}
+
+// Stop archives the payloads still in the queue and closes the sinks.
+func (a *Archiver) Stop() {
+	if a == nil {
+		return
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+	if !a.started || a.closed {
+		return
+	}
+	a.closed = true
+	close(a.stop)
+	<-a.stopped
+}
+
+// Archive queues the payload to be archived, if its endpoint matches the
+// paths allowlist and it is sampled. headers are the payload specific headers,
+// and must not be modified after this call. It never blocks.
+func (a *Archiver) Archive(endpoint transaction.Endpoint, payload *transaction.BytesPayload, headers http.Header) {
+	if a == nil || !a.matches(endpoint.Route) {
+		return
+	}
+	if a.sampleRate < 1 && a.random() >= a.sampleRate {
+		return
+	}
+
+	e := entry{
+		timestamp: time.Now(),
+		endpoint:  endpoint,
+		headers:   headers,
+	}
+	if payload != nil {
+		e.body = payload.GetContent()
+	}
+
+	select {
+	case a.input <- e:
+	default:
+		tlmRecords.Inc("dropped")
+		log.Debugf("Dropping the archive copy of a payload to %s: the archive queue is full", endpoint.Route)
+	}
+}
+
+func (a *Archiver) matches(path string) bool {
+	if len(a.paths) == 0 {
+		return true
+	}
+	for _, prefix := range a.paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Archiver) newRecord(e entry) *Record {
+	headers := make(map[string]string, len(e.headers))
+	for key := range e.headers {
+		headers[key] = e.headers.Get(key)
+	}
+
+	record := &Record{
+		Timestamp: e.timestamp,
+		Endpoint:  e.endpoint.Name,
+		Path:      e.endpoint.Route,
+		Headers:   headers,
+		Body:      e.body,
+		Size:      len(e.body),
+	}
+
+	if a.decompress {
+		if decompressed, size, ok := decompress(e.body, e.headers.Get("Content-Encoding"), a.maxPayloadSize); ok {
+			record.Body = decompressed
+			record.Size = size
+			record.Decompressed = true
+		}
+	}
+
+	if a.maxPayloadSize > 0 && len(record.Body) > a.maxPayloadSize {
+		record.Body = record.Body[:a.maxPayloadSize]
+		record.Truncated = true
+	}
+
+	return record
+}
+
+// decompress decompresses the body if its encoding is supported. If limit is
+// positive, at most limit+1 bytes of the decompressed body are kept so that
+// the caller can flag it as truncated. It returns the size of the whole
+// decompressed body. The body is not copied when it is not compressed, and
+// the caller must not modify it.
+func decompress(body []byte, encoding string, limit int) ([]byte, int, bool) {
+	var reader io.ReadCloser
+	var err error
+	switch {
+	case encoding == "":
+		return body, len(body), false
+	case encoding == "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case encoding == compression.ContentEncoding:
+		reader, err = compression.NewReader(bytes.NewReader(body))
+	default:
+		return body, len(body), false
+	}
+	if err != nil {
+		return body, len(body), false
+	}
+	defer reader.Close()
+
+	if limit <= 0 {
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			return body, len(body), false
+		}
+		return decompressed, len(decompressed), true
+	}
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return body, len(body), false
+	}
+	size := len(decompressed)
+	if size > limit {
+		// the rest of the body is discarded, it is only read for its size
+		rest, err := io.Copy(io.Discard, reader)
+		if err != nil {
+			return body, len(body), false
+		}
+		size += int(rest)
+	}
+	return decompressed, size, true
+}
+
+func (a *Archiver) run() {
+	defer close(a.stopped)
+
+	flushInterval := a.flushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Minute
+	}
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-a.input:
+			a.write(a.newRecord(e))
+		case <-ticker.C:
+			a.flush()
+		case <-a.stop:
+			for {
+				select {
+				case e := <-a.input:
+					a.write(a.newRecord(e))
+				default:
+					a.flush()
+					for _, s := range a.sinks {
+						if err := s.close(); err != nil {
+							log.Errorf("Error when closing the archive %s: %v", s, err)
+						}
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *Archiver) write(record *Record) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		tlmRecords.Inc("error")
+		log.Errorf("Cannot serialize the archive copy of a payload: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	status := "archived"
+	for _, s := range a.sinks {
+		if err := s.write(line); err != nil {
+			status = "error"
+			log.Errorf("Cannot write the archive copy of a payload to %s: %v", s, err)
+		}
+	}
+	tlmRecords.Inc(status)
+	tlmBytes.Add(float64(len(line)))
+}
+
+func (a *Archiver) flush() {
+	for _, s := range a.sinks {
+		if err := s.flush(); err != nil {
+			log.Errorf("Cannot flush the archive %s: %v", s, err)
+		}
+	}
+}
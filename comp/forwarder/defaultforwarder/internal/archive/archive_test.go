@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func archive(a *Archiver, route string, payload []byte, headers http.Header) {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set("Content-Type", "application/json")
+	a.Archive(transaction.Endpoint{Route: route, Name: "series_v2"}, transaction.NewBytesPayloadWithoutMetaData(payload), headers)
+}
+
+func readRecords(t *testing.T, dir string) []Record {
+	files, err := filepath.Glob(filepath.Join(dir, archiveFilePrefix+"*"+archiveFileSuffix))
+	require.NoError(t, err)
+
+	records := []Record{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			record := Record{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			records = append(records, record)
+		}
+		f.Close()
+	}
+	return records
+}
+
+func TestNewArchiverDisabled(t *testing.T) {
+	mockConfig := pkgconfig.Mock(t)
+	assert.Nil(t, NewArchiver(mockConfig))
+
+	mockConfig.Set("forwarder_archive.enabled", true)
+	assert.Nil(t, NewArchiver(mockConfig))
+
+	var a *Archiver
+	a.Start()
+	archive(a, "/api/v2/series", []byte("payload"), nil)
+	a.Stop()
+}
+
+func TestArchiveToFiles(t *testing.T) {
+	dir := t.TempDir()
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.enabled", true)
+	mockConfig.Set("forwarder_archive.file.path", dir)
+	mockConfig.Set("forwarder_archive.paths", []string{"/api/v2/series", "/intake/"})
+	mockConfig.Set("forwarder_archive.max_payload_size", 10)
+
+	a := NewArchiver(mockConfig)
+	require.NotNil(t, a)
+	a.Start()
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(`{"series":[]}`))
+	writer.Close()
+
+	archive(a, "/api/v2/series", []byte("payload"), nil)
+	archive(a, "/api/v1/check_run", []byte("filtered"), nil)
+	archive(a, "/intake/", compressed.Bytes(), http.Header{"Content-Encoding": []string{"gzip"}})
+	a.Stop()
+
+	records := readRecords(t, dir)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "/api/v2/series", records[0].Path)
+	assert.Equal(t, "series_v2", records[0].Endpoint)
+	assert.Equal(t, []byte("payload"), records[0].Body)
+	assert.Equal(t, "application/json", records[0].Headers["Content-Type"])
+	assert.False(t, records[0].Decompressed)
+	assert.False(t, records[0].Truncated)
+
+	assert.Equal(t, "/intake/", records[1].Path)
+	assert.True(t, records[1].Decompressed)
+	assert.True(t, records[1].Truncated)
+	assert.Equal(t, len(`{"series":[]}`), records[1].Size)
+	assert.Equal(t, []byte(`{"series":`), records[1].Body)
+}
+
+func TestArchiveSampling(t *testing.T) {
+	dir := t.TempDir()
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.enabled", true)
+	mockConfig.Set("forwarder_archive.file.path", dir)
+	mockConfig.Set("forwarder_archive.sample_rate", 0.5)
+
+	a := NewArchiver(mockConfig)
+	require.NotNil(t, a)
+	samples := []float64{0.2, 0.7, 0.4}
+	a.random = func() float64 {
+		sample := samples[0]
+		samples = samples[1:]
+		return sample
+	}
+	a.Start()
+	for _, payload := range []string{"first", "second", "third"} {
+		archive(a, "/api/v2/series", []byte(payload), nil)
+	}
+	a.Stop()
+
+	records := readRecords(t, dir)
+	require.Len(t, records, 2)
+	assert.Equal(t, []byte("first"), records[0].Body)
+	assert.Equal(t, []byte("third"), records[1].Body)
+}
+
+func TestDecompressLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(bytes.Repeat([]byte("a"), 1000))
+	writer.Close()
+
+	decompressed, size, ok := decompress(compressed.Bytes(), "gzip", 10)
+	require.True(t, ok)
+	assert.Equal(t, 1000, size)
+	assert.Len(t, decompressed, 11)
+
+	decompressed, size, ok = decompress(compressed.Bytes(), "gzip", 0)
+	require.True(t, ok)
+	assert.Equal(t, 1000, size)
+	assert.Len(t, decompressed, 1000)
+
+	decompressed, size, ok = decompress([]byte("payload"), "gzip", 10)
+	assert.False(t, ok)
+	assert.Equal(t, 7, size)
+	assert.Equal(t, []byte("payload"), decompressed)
+}
+
+func TestArchiveWhileStarting(t *testing.T) {
+	dir := t.TempDir()
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.enabled", true)
+	mockConfig.Set("forwarder_archive.file.path", dir)
+
+	a := NewArchiver(mockConfig)
+	require.NotNil(t, a)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		archive(a, "/api/v2/series", []byte("payload"), nil)
+	}()
+	a.Start()
+	<-done
+	a.Stop()
+	// stopping twice, or starting a stopped archiver, is a no-op
+	a.Stop()
+	a.Start()
+
+	records := readRecords(t, dir)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte("payload"), records[0].Body)
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package archive
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	archiveFilePrefix = "transactions-"
+	archiveFileSuffix = ".jsonl"
+)
+
+// fileSink writes the records to files in a directory. The current file is
+// rotated when it exceeds `maxFileSize`, and the oldest files are removed when
+// the directory exceeds `maxTotalSize`.
+type fileSink struct {
+	path         string
+	maxFileSize  int64
+	maxTotalSize int64
+
+	file     *os.File
+	writer   *bufio.Writer
+	fileSize int64
+
+	// now can be overridden in tests
+	now func() time.Time
+}
+
+func newFileSink(config config.Component) (*fileSink, error) {
+	path := config.GetString("forwarder_archive.file.path")
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+
+	maxFileSize := config.GetInt64("forwarder_archive.file.max_file_size")
+	if maxFileSize <= 0 {
+		log.Warnf("Configured forwarder_archive.file.max_file_size (%v) is not positive; 10MB will be used", maxFileSize)
+		maxFileSize = 10 * 1024 * 1024
+	}
+
+	return &fileSink{
+		path:         path,
+		maxFileSize:  maxFileSize,
+		maxTotalSize: config.GetInt64("forwarder_archive.file.max_total_size"),
+		now:          time.Now,
+	}, nil
+}
+
+func (s *fileSink) String() string {
+	return fmt.Sprintf("directory %s", s.path)
+}
+
+func (s *fileSink) write(line []byte) error {
+	if s.file != nil && s.fileSize+int64(len(line)) > s.maxFileSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writer.Write(line)
+	s.fileSize += int64(n)
+	return err
+}
+
+func (s *fileSink) open() error {
+	name := archiveFilePrefix + s.now().UTC().Format("20060102T150405.000000000") + archiveFileSuffix
+	file, err := os.OpenFile(filepath.Join(s.path, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.fileSize = 0
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.close(); err != nil {
+		return err
+	}
+	return s.removeOldestFiles()
+}
+
+// removeOldestFiles removes the oldest archive files until the total size of
+// the directory is below `maxTotalSize`.
+func (s *fileSink) removeOldestFiles() error {
+	if s.maxTotalSize <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	type archiveFile struct {
+		name string
+		size int64
+	}
+	files := []archiveFile{}
+	var totalSize int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, archiveFilePrefix) || !strings.HasSuffix(name, archiveFileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, archiveFile{name: name, size: info.Size()})
+		totalSize += info.Size()
+	}
+
+	// file names contain their creation time
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	for _, f := range files {
+		if totalSize <= s.maxTotalSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(s.path, f.name)); err != nil {
+			return err
+		}
+		log.Debugf("Removed the archive file %s to stay below forwarder_archive.file.max_total_size", f.name)
+		totalSize -= f.size
+	}
+	return nil
+}
+
+func (s *fileSink) flush() error {
+	if s.writer == nil {
+		return nil
+	}
+	return s.writer.Flush()
+}
+
+func (s *fileSink) close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.writer.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	s.writer = nil
+	return err
+}
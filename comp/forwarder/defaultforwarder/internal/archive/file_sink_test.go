@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package archive
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.file.path", dir)
+	mockConfig.Set("forwarder_archive.file.max_file_size", 20)
+	mockConfig.Set("forwarder_archive.file.max_total_size", 40)
+
+	s, err := newFileSink(mockConfig)
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	line := []byte(strings.Repeat("x", 14) + "\n")
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.write(line))
+	}
+	require.NoError(t, s.close())
+
+	// every line needs its own file, and only the 2 most recent closed files
+	// are kept along with the last one
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	for _, entry := range entries {
+		content, err := os.ReadFile(dir + "/" + entry.Name())
+		require.NoError(t, err)
+		assert.Equal(t, line, content)
+	}
+}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// s3Sink buffers the records and uploads them as objects to an S3-compatible
+// storage, once the buffer exceeds `maxObjectSize` or on each flush. Requests
+// are signed with AWS Signature Version 4 and use path-style URLs
+// (<endpoint>/<bucket>/<key>), which every S3-compatible storage supports.
+type s3Sink struct {
+	client        *http.Client
+	signer        *v4.Signer
+	endpoint      string
+	bucket        string
+	region        string
+	prefix        string
+	maxObjectSize int
+
+	buffer bytes.Buffer
+
+	// now can be overridden in tests
+	now func() time.Time
+}
+
+func newS3Sink(config config.Component) (*s3Sink, error) {
+	endpoint := config.GetString("forwarder_archive.s3.endpoint")
+	region := config.GetString("forwarder_archive.s3.region")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid forwarder_archive.s3.endpoint: %v", err)
+	}
+
+	// Credentials default to the standard AWS environment variables
+	creds := credentials.NewEnvCredentials()
+	if accessKeyID := config.GetString("forwarder_archive.s3.access_key_id"); accessKeyID != "" {
+		creds = credentials.NewStaticCredentials(accessKeyID, config.GetString("forwarder_archive.s3.secret_access_key"), "")
+	}
+	if _, err := creds.Get(); err != nil {
+		return nil, fmt.Errorf("no S3 credentials: %v", err)
+	}
+
+	maxObjectSize := config.GetInt("forwarder_archive.s3.max_object_size")
+	if maxObjectSize <= 0 {
+		log.Warnf("Configured forwarder_archive.s3.max_object_size (%v) is not positive; 5MB will be used", maxObjectSize)
+		maxObjectSize = 5 * 1024 * 1024
+	}
+
+	return &s3Sink{
+		client: &http.Client{
+			Transport: httputils.CreateHTTPTransport(),
+			Timeout:   config.GetDuration("forwarder_timeout") * time.Second,
+		},
+		signer: v4.NewSigner(creds, func(s *v4.Signer) {
+			s.DisableURIPathEscaping = true
+		}),
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		bucket:        config.GetString("forwarder_archive.s3.bucket"),
+		region:        region,
+		prefix:        config.GetString("forwarder_archive.s3.prefix"),
+		maxObjectSize: maxObjectSize,
+		now:           time.Now,
+	}, nil
+}
+
+func (s *s3Sink) String() string {
+	return fmt.Sprintf("bucket %s on %s", s.bucket, s.endpoint)
+}
+
+func (s *s3Sink) write(line []byte) error {
+	s.buffer.Write(line)
+	if s.buffer.Len() >= s.maxObjectSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush uploads the buffered records. They are dropped if the upload fails,
+// so that the memory used by the sink stays bounded.
+func (s *s3Sink) flush() error {
+	if s.buffer.Len() == 0 {
+		return nil
+	}
+	defer s.buffer.Reset()
+
+	now := s.now().UTC()
+	key := path.Join(s.prefix, now.Format("2006/01/02"), archiveFilePrefix+now.Format("20060102T150405.000000000")+archiveFileSuffix)
+	return s.upload(key, s.buffer.Bytes())
+}
+
+func (s *s3Sink) upload(key string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.ContentLength = int64(len(body))
+
+	// Sign attaches the body to the request
+	if _, err := s.signer.Sign(req, bytes.NewReader(body), "s3", s.region, s.now()); err != nil {
+		return fmt.Errorf("cannot sign the request: %v", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d when uploading %s: %s", resp.StatusCode, key, message)
+	}
+	log.Debugf("Uploaded %d bytes of archived transactions to %s", len(body), key)
+	return nil
+}
+
+func (s *s3Sink) close() error {
+	return s.flush()
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package archive
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type s3Upload struct {
+	path          string
+	authorization string
+	body          string
+}
+
+func newTestS3Sink(t *testing.T, handler http.HandlerFunc) *s3Sink {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	mockConfig := pkgconfig.Mock(t)
+	mockConfig.Set("forwarder_archive.s3.endpoint", ts.URL)
+	mockConfig.Set("forwarder_archive.s3.bucket", "archive")
+	mockConfig.Set("forwarder_archive.s3.prefix", "agent")
+	mockConfig.Set("forwarder_archive.s3.access_key_id", "access-key")
+	mockConfig.Set("forwarder_archive.s3.secret_access_key", "secret-key")
+	mockConfig.Set("forwarder_archive.s3.max_object_size", 30)
+
+	s, err := newS3Sink(mockConfig)
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestS3SinkUpload(t *testing.T) {
+	uploads := []s3Upload{}
+	s := newTestS3Sink(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		uploads = append(uploads, s3Upload{path: r.URL.Path, authorization: r.Header.Get("Authorization"), body: string(body)})
+	})
+
+	require.NoError(t, s.write([]byte("first line\n")))
+	assert.Empty(t, uploads)
+	require.NoError(t, s.write([]byte("second line, over the limit\n")))
+	require.Len(t, uploads, 1)
+	require.NoError(t, s.write([]byte("third line\n")))
+	require.NoError(t, s.close())
+	require.Len(t, uploads, 2)
+
+	assert.Equal(t, "/archive/agent/2023/06/01/transactions-20230601T120000.000000000.jsonl", uploads[0].path)
+	assert.True(t, strings.HasPrefix(uploads[0].authorization, "AWS4-HMAC-SHA256 Credential=access-key/20230601/us-east-1/s3/aws4_request"))
+	assert.Equal(t, "first line\nsecond line, over the limit\n", uploads[0].body)
+	assert.Equal(t, "third line\n", uploads[1].body)
+
+	// nothing to upload
+	require.NoError(t, s.flush())
+	assert.Len(t, uploads, 2)
+}
+
+func TestS3SinkUploadError(t *testing.T) {
+	s := newTestS3Sink(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("AccessDenied"))
+	})
+
+	require.NoError(t, s.write([]byte("line\n")))
+	err := s.flush()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AccessDenied")
+
+	// records are dropped on error
+	assert.Equal(t, 0, s.buffer.Len())
+}
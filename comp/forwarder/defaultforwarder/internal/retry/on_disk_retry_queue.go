@@ -11,8 +11,11 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
+
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -21,11 +24,34 @@ import (
 const retryTransactionsExtension = ".retry"
 const retryFileFormat = "2006_01_02__15_04_05_"
 
+// storeError is returned by Store when some storage classes cannot be stored
+type storeError struct {
+	err error
+	// transactions are the transactions of the storage classes that were not stored
+	transactions []transaction.Transaction
+}
+
+func (e *storeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storeError) Unwrap() error {
+	return e.err
+}
+
+// retryFile is a file storing transactions of a single storage class
+type retryFile struct {
+	path  string
+	class *storageClass
+	size  int64
+}
+
 type onDiskRetryQueue struct {
 	serializer          *HTTPTransactionsSerializer
 	storagePath         string
 	diskUsageLimit      *DiskUsageLimit
-	filenames           []string
+	storageClasses      *StorageClasses
+	files               []retryFile // sorted from the oldest to the newest
 	currentSizeInBytes  int64
 	telemetry           onDiskRetryQueueTelemetry
 	pointCountTelemetry *PointCountTelemetry
@@ -35,6 +61,7 @@ func newOnDiskRetryQueue(
 	serializer *HTTPTransactionsSerializer,
 	storagePath string,
 	diskUsageLimit *DiskUsageLimit,
+	storageClasses *StorageClasses,
 	telemetry onDiskRetryQueueTelemetry,
 	pointCountTelemetry *PointCountTelemetry) (*onDiskRetryQueue, error) {
 
@@ -42,10 +69,16 @@ func newOnDiskRetryQueue(
 		return nil, err
 	}
 
+	if storageClasses == nil {
+		// Every transaction belongs to the default class
+		storageClasses, _ = NewStorageClasses(nil)
+	}
+
 	storage := &onDiskRetryQueue{
 		serializer:          serializer,
 		storagePath:         storagePath,
 		diskUsageLimit:      diskUsageLimit,
+		storageClasses:      storageClasses,
 		telemetry:           telemetry,
 		pointCountTelemetry: pointCountTelemetry,
 	}
@@ -61,10 +94,38 @@ func newOnDiskRetryQueue(
 	return storage, err
 }
 
-// Store stores transactions to the file system.
+// Store stores transactions to the file system, in one file per storage class.
+// The storage classes are stored independently: if some of them fail, a *storeError
+// with the transactions of these classes is returned.
 func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 	s.telemetry.addSerializeCount()
 
+	var classes []*storageClass
+	transactionsByClass := make(map[*storageClass][]transaction.Transaction)
+	for _, t := range transactions {
+		class := s.storageClasses.classOf(t.GetEndpointName())
+		if _, found := transactionsByClass[class]; !found {
+			classes = append(classes, class)
+		}
+		transactionsByClass[class] = append(transactionsByClass[class], t)
+	}
+
+	var errs error
+	var notStored []transaction.Transaction
+	for _, class := range classes {
+		if err := s.storeClass(class, transactionsByClass[class]); err != nil {
+			errs = multierror.Append(errs, err)
+			notStored = append(notStored, transactionsByClass[class]...)
+		}
+	}
+
+	if errs != nil {
+		return &storeError{err: errs, transactions: notStored}
+	}
+	return nil
+}
+
+func (s *onDiskRetryQueue) storeClass(class *storageClass, transactions []transaction.Transaction) error {
 	// Reset the serializer in case some transactions were serialized
 	// but `GetBytesAndReset` was not called because of an error.
 	_, _ = s.serializer.GetBytesAndReset()
@@ -81,11 +142,13 @@ func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 	}
 	bufferSize := int64(len(bytes))
 
-	if err := s.makeRoomFor(bufferSize); err != nil {
+	if err := s.makeRoomFor(bufferSize, class); err != nil {
+		s.telemetry.addClassPayloadsDroppedCount(len(transactions), class.name)
 		return err
 	}
 
-	filename := time.Now().UTC().Format(retryFileFormat)
+	// The class is part of the file name so that it is known when the files are reloaded
+	filename := time.Now().UTC().Format(retryFileFormat) + class.name + "."
 	file, err := os.CreateTemp(s.storagePath, filename+"*"+retryTransactionsExtension)
 	if err != nil {
 		return err
@@ -101,21 +164,28 @@ func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 		return err
 	}
 	s.currentSizeInBytes += bufferSize
-	s.filenames = append(s.filenames, file.Name())
+	s.files = append(s.files, retryFile{path: file.Name(), class: class, size: bufferSize})
 	s.telemetry.setFileSize(bufferSize)
 	s.telemetry.setCurrentSizeInBytes(s.GetDiskSpaceUsed())
 	s.telemetry.setFilesCount(s.getFilesCount())
+	s.updateClassesTelemetry()
 	return nil
 }
 
-// ExtractLast extracts the last transactions stored.
+// ExtractLast extracts the last transactions stored for the storage class
+// with the highest priority.
 func (s *onDiskRetryQueue) ExtractLast() ([]transaction.Transaction, error) {
-	if len(s.filenames) == 0 {
+	if len(s.files) == 0 {
 		return nil, nil
 	}
 	s.telemetry.addDeserializeCount()
-	index := len(s.filenames) - 1
-	path := s.filenames[index]
+	index := len(s.files) - 1
+	for i := len(s.files) - 1; i >= 0; i-- {
+		if s.files[i].class.priority < s.files[index].class.priority {
+			index = i
+		}
+	}
+	path := s.files[index].path
 	bytes, err := os.ReadFile(path)
 
 	// Remove the file even in case of a read failure.
@@ -135,12 +205,13 @@ func (s *onDiskRetryQueue) ExtractLast() ([]transaction.Transaction, error) {
 	s.telemetry.addDeserializeTransactionsCount(len(transactions))
 	s.telemetry.setCurrentSizeInBytes(s.GetDiskSpaceUsed())
 	s.telemetry.setFilesCount(s.getFilesCount())
+	s.updateClassesTelemetry()
 	return transactions, err
 }
 
 // GetFileCount returns the current files count.
 func (s *onDiskRetryQueue) getFilesCount() int {
-	return len(s.filenames)
+	return len(s.files)
 }
 
 // GetDiskSpaceUsed() returns the current disk space used.
@@ -148,7 +219,7 @@ func (s *onDiskRetryQueue) GetDiskSpaceUsed() int64 {
 	return s.currentSizeInBytes
 }
 
-func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
+func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64, class *storageClass) error {
 	maxSizeInBytes := s.diskUsageLimit.getMaxSizeInBytes()
 	if bufferSize > maxSizeInBytes {
 		return fmt.Errorf("The payload is too big. Current:%v Maximum:%v", bufferSize, maxSizeInBytes)
@@ -158,9 +229,13 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 	if err != nil {
 		return err
 	}
-	for len(s.filenames) > 0 && s.currentSizeInBytes+bufferSize > maxStorageInBytes {
-		index := 0
-		filename := s.filenames[index]
+	for len(s.files) > 0 && s.currentSizeInBytes+bufferSize > maxStorageInBytes {
+		index := s.fileToRemove(bufferSize, class, maxStorageInBytes)
+		if index < 0 {
+			return fmt.Errorf("Maximum disk space for retry transactions is reached and the other storage classes use less than their quota: dropping transactions of the %q storage class", class.name)
+		}
+		filename := s.files[index].path
+		removedClass := s.files[index].class
 		log.Errorf("Maximum disk space for retry transactions is reached. Removing %s", filename)
 
 		bytes, err := os.ReadFile(filename)
@@ -180,22 +255,81 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 			return err
 		}
 		s.telemetry.addFilesRemovedCount()
+		s.telemetry.addClassFilesRemovedCount(removedClass.name)
 	}
 
 	return nil
 }
 
+// fileToRemove returns the index of the next file to remove to store a new
+// file of `bufferSize` bytes for the storage class `incoming`, or -1 if no
+// file can be removed.
+// Files are removed from the class exceeding its guaranteed share of
+// `maxStorageInBytes` the most, including the new file, according to the drop
+// policy of the class. Ties are broken by removing files from the class with
+// the lowest priority.
+func (s *onDiskRetryQueue) fileToRemove(bufferSize int64, incoming *storageClass, maxStorageInBytes int64) int {
+	usage := map[*storageClass]int64{incoming: bufferSize}
+	for _, f := range s.files {
+		usage[f.class] += f.size
+	}
+
+	var victim *storageClass
+	var victimExcess int64
+	for _, f := range s.files {
+		class := f.class
+		excess := usage[class] - int64(class.quotaRatio*float64(maxStorageInBytes))
+		if excess <= 0 || class == victim {
+			continue
+		}
+		if victim == nil || excess > victimExcess || (excess == victimExcess && class.priority > victim.priority) {
+			victim = class
+			victimExcess = excess
+		}
+	}
+	if victim == nil {
+		return -1
+	}
+
+	if victim.dropPolicy == DropNewestFirst {
+		for i := len(s.files) - 1; i >= 0; i-- {
+			if s.files[i].class == victim {
+				return i
+			}
+		}
+	}
+	for i, f := range s.files {
+		if f.class == victim {
+			return i
+		}
+	}
+	return -1
+}
+
 func (s *onDiskRetryQueue) onPointDropped(count int) {
 	s.telemetry.addPointDroppedCount(count)
 	s.pointCountTelemetry.OnPointDropped(count)
 }
 
+func (s *onDiskRetryQueue) updateClassesTelemetry() {
+	usage := make(map[string]int64, len(s.storageClasses.classes))
+	for _, class := range s.storageClasses.classes {
+		usage[class.name] = 0
+	}
+	for _, f := range s.files {
+		usage[f.class.name] += f.size
+	}
+	for name, size := range usage {
+		s.telemetry.setClassSizeInBytes(size, name)
+	}
+}
+
 func (s *onDiskRetryQueue) removeFileAt(index int) error {
-	filename := s.filenames[index]
+	filename := s.files[index].path
 
-	// Remove the file from s.filenames also in case of error to not
+	// Remove the file from s.files also in case of error to not
 	// fail on the next call.
-	s.filenames = append(s.filenames[:index], s.filenames[index+1:]...)
+	s.files = append(s.files[:index], s.files[index+1:]...)
 
 	size, err := util.GetFileSize(filename)
 	if err != nil {
@@ -220,16 +354,36 @@ func (s *onDiskRetryQueue) reloadExistingRetryFiles() error {
 	sort.Slice(files, func(i, j int) bool {
 		return files[i].ModTime().Before(files[j].ModTime())
 	})
-	var filenames []string
+	var retryFiles []retryFile
 	for _, file := range files {
-		fullPath := path.Join(s.storagePath, file.Name())
-		filenames = append(filenames, fullPath)
+		retryFiles = append(retryFiles, retryFile{
+			path:  path.Join(s.storagePath, file.Name()),
+			class: s.storageClasses.get(storageClassFromFilename(file.Name())),
+			size:  file.Size(),
+		})
 	}
-	s.telemetry.setReloadedRetryFilesCount(len(filenames))
-	s.filenames = append(s.filenames, filenames...)
+	s.telemetry.setReloadedRetryFilesCount(len(retryFiles))
+	s.files = append(s.files, retryFiles...)
+	s.updateClassesTelemetry()
 	return nil
 }
 
+// storageClassFromFilename returns the storage class of a file named
+// `<date><class>.<random>.retry`. Files created by previous versions of the
+// Agent are named `<date><random>.retry` and belong to the default class.
+func storageClassFromFilename(filename string) string {
+	name := strings.TrimSuffix(filename, retryTransactionsExtension)
+	if len(name) < len(retryFileFormat) {
+		return DefaultStorageClassName
+	}
+	name = name[len(retryFileFormat):]
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return DefaultStorageClassName
+	}
+	return name[:dot]
+}
+
 func (s *onDiskRetryQueue) getExistingRetryFiles() ([]os.FileInfo, int64, error) {
 	entries, err := os.ReadDir(s.storagePath)
 	if err != nil {
@@ -133,7 +133,157 @@ func newTestOnDiskRetryQueue(a *assert.Assertions, path string, maxSizeInBytes i
 			Total:     10000,
 		}}
 	diskUsageLimit := NewDiskUsageLimit("", disk, maxSizeInBytes, 1)
-	storage, err := newOnDiskRetryQueue(NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, telemetry, NewPointCountTelemetryMock())
+	storage, err := newOnDiskRetryQueue(NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, nil, telemetry, NewPointCountTelemetryMock())
+	a.NoError(err)
+	return storage
+}
+
+func TestOnDiskRetryQueueStorageClassesQuota(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	storageClasses, err := NewStorageClasses([]StorageClassConfig{
+		{Name: "series", Endpoints: []string{"series"}, QuotaRatio: 0.5},
+		{Name: "events", Endpoints: []string{"events"}},
+	})
+	a.NoError(err)
+
+	q := newTestOnDiskRetryQueueWithClasses(a, path, 1000, storageClasses)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("series")))
+	fileSize := q.GetDiskSpaceUsed()
+	maxNumberOfFiles := int(1000 / fileSize)
+	a.Greaterf(maxNumberOfFiles, 4, "Not enough files for this test")
+
+	// events payloads, of the same size, fill the disk
+	for i := 1; i < maxNumberOfFiles; i++ {
+		a.NoError(q.Store(createHTTPTransactionCollectionTests("events")))
+	}
+	a.Equal(maxNumberOfFiles, q.getFilesCount())
+
+	// events files are removed as long as series use less than their quota
+	for i := 1; i < maxNumberOfFiles/2; i++ {
+		a.NoError(q.Store(createHTTPTransactionCollectionTests("series")))
+		a.Equal(i+1, q.countFiles("series"))
+	}
+	a.Equal(maxNumberOfFiles, q.getFilesCount())
+
+	// beyond their quota, series compete with events for the rest of the disk:
+	// files are removed from the class exceeding its quota the most
+	for i := 0; i < maxNumberOfFiles; i++ {
+		a.NoError(q.Store(createHTTPTransactionCollectionTests("series")))
+	}
+	a.Equal(maxNumberOfFiles, q.getFilesCount())
+	seriesExcess := int64(q.countFiles("series"))*fileSize - 500
+	eventsExcess := int64(q.countFiles("events")) * fileSize
+	a.Greater(eventsExcess, int64(0))
+	a.InDelta(seriesExcess, eventsExcess, float64(2*fileSize))
+}
+
+func TestOnDiskRetryQueueStorageClassesStoredIndependently(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	storageClasses, err := NewStorageClasses([]StorageClassConfig{
+		{Name: "series", Endpoints: []string{"series"}},
+		{Name: "events", Endpoints: []string{"events"}},
+	})
+	a.NoError(err)
+
+	q := newTestOnDiskRetryQueueWithClasses(a, path, 1000, storageClasses)
+	transactions := createHTTPTransactionCollectionTests("events", "series")
+	tooBig := transactions[0].(*transaction.HTTPTransaction)
+	tooBig.Payload = transaction.NewBytesPayload(make([]byte, 2000), 1)
+
+	// the events payload is too big, the series one is still stored
+	err = q.Store(transactions)
+	var storeErr *storeError
+	if a.ErrorAs(err, &storeErr) {
+		a.Equal([]transaction.Transaction{tooBig}, storeErr.transactions)
+	}
+	a.Equal(1, q.countFiles("series"))
+	a.Equal(0, q.countFiles("events"))
+}
+
+func TestOnDiskRetryQueueStorageClassesDropNewest(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	storageClasses, err := NewStorageClasses([]StorageClassConfig{
+		{Name: "default", DropPolicy: "newest"},
+	})
+	a.NoError(err)
+
+	q := newTestOnDiskRetryQueueWithClasses(a, path, 100, storageClasses)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("0")))
+	maxNumberOfFiles := int(100 / q.GetDiskSpaceUsed())
+	for i := 1; i <= maxNumberOfFiles; i++ {
+		a.NoError(q.Store(createHTTPTransactionCollectionTests(strconv.Itoa(i))))
+	}
+
+	// the newest file was removed to store the last one
+	a.Equal(maxNumberOfFiles, q.getFilesCount())
+	transactions, err := q.ExtractLast()
+	a.NoError(err)
+	a.Equal([]string{strconv.Itoa(maxNumberOfFiles)}, getEndpointsFromTransactions(transactions))
+	transactions, err = q.ExtractLast()
+	a.NoError(err)
+	a.Equal([]string{strconv.Itoa(maxNumberOfFiles - 2)}, getEndpointsFromTransactions(transactions))
+}
+
+func TestOnDiskRetryQueueStorageClassesPriority(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	storageClasses, err := NewStorageClasses([]StorageClassConfig{
+		{Name: "checks", Endpoints: []string{"check_run"}},
+		{Name: "series", Endpoints: []string{"series"}},
+	})
+	a.NoError(err)
+
+	q := newTestOnDiskRetryQueueWithClasses(a, path, 1000, storageClasses)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("series", "check_run", "process")))
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("series")))
+	a.Equal(4, q.getFilesCount())
+
+	// files are reloaded with their class
+	q = newTestOnDiskRetryQueueWithClasses(a, path, 1000, storageClasses)
+	a.Equal(2, q.countFiles("series"))
+
+	var extracted []string
+	for q.getFilesCount() > 0 {
+		transactions, err := q.ExtractLast()
+		a.NoError(err)
+		extracted = append(extracted, getEndpointsFromTransactions(transactions)...)
+	}
+	a.Equal([]string{"check_run", "series", "series", "process"}, extracted)
+}
+
+func TestStorageClassFromFilename(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("series", storageClassFromFilename("2023_06_01__12_00_00_series.123456.retry"))
+	a.Equal(DefaultStorageClassName, storageClassFromFilename("2023_06_01__12_00_00_123456.retry"))
+	a.Equal(DefaultStorageClassName, storageClassFromFilename("short.retry"))
+}
+
+func (s *onDiskRetryQueue) countFiles(class string) int {
+	count := 0
+	for _, f := range s.files {
+		if f.class.name == class {
+			count++
+		}
+	}
+	return count
+}
+
+func newTestOnDiskRetryQueueWithClasses(a *assert.Assertions, path string, maxSizeInBytes int64, storageClasses *StorageClasses) *onDiskRetryQueue {
+	telemetry := newOnDiskRetryQueueTelemetry("domain")
+	disk := diskUsageRetrieverMock{
+		diskUsage: &filesystem.DiskUsage{
+			Available: 10000,
+			Total:     10000,
+		}}
+	diskUsageLimit := NewDiskUsageLimit("", disk, maxSizeInBytes, 1)
+	storage, err := newOnDiskRetryQueue(NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, storageClasses, telemetry, NewPointCountTelemetryMock())
 	a.NoError(err)
 	return storage
 }
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultStorageClassName is the name of the storage class of the transactions
+// whose endpoint is not part of any configured storage class.
+const DefaultStorageClassName = "default"
+
+var storageClassNameRegexp = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// DropPolicy defines which files of a storage class are removed first when
+// the disk space is needed by another class.
+type DropPolicy int
+
+const (
+	// DropOldestFirst removes the oldest files first
+	DropOldestFirst DropPolicy = iota
+	// DropNewestFirst removes the newest files first
+	DropNewestFirst
+)
+
+// StorageClassConfig is the configuration of a storage class, as defined in
+// `forwarder_storage_classes`.
+type StorageClassConfig struct {
+	// Name is the name of the class, used in telemetry and file names.
+	Name string `mapstructure:"name"`
+	// Endpoints are the names of the endpoints (for instance `series_v2`)
+	// whose transactions belong to the class.
+	Endpoints []string `mapstructure:"endpoints"`
+	// QuotaRatio is the share of the maximum disk space guaranteed to the
+	// class. When the disk space is needed, files are removed from the class
+	// exceeding its share the most.
+	QuotaRatio float64 `mapstructure:"quota_ratio"`
+	// DropPolicy is either `oldest` (default) or `newest`.
+	DropPolicy string `mapstructure:"drop_policy"`
+}
+
+type storageClass struct {
+	name       string
+	quotaRatio float64
+	dropPolicy DropPolicy
+	// priority is the rank of the class in the configuration: transactions of
+	// the classes with the lowest ranks are retried first.
+	priority int
+}
+
+// StorageClasses assigns the transactions stored on disk to storage classes.
+// Each class has a guaranteed share of the disk space and its own drop policy.
+type StorageClasses struct {
+	classes    []*storageClass
+	byName     map[string]*storageClass
+	byEndpoint map[string]*storageClass
+}
+
+// NewStorageClasses creates the storage classes. Transactions not matching any
+// class belong to the `default` class, which can be configured by declaring a
+// class named `default` without endpoints.
+func NewStorageClasses(configs []StorageClassConfig) (*StorageClasses, error) {
+	s := &StorageClasses{
+		byName:     map[string]*storageClass{},
+		byEndpoint: map[string]*storageClass{},
+	}
+
+	totalQuotaRatio := 0.0
+	for _, config := range configs {
+		if !storageClassNameRegexp.MatchString(config.Name) {
+			return nil, fmt.Errorf("invalid storage class name %q: only lowercase letters, digits, '_' and '-' are allowed", config.Name)
+		}
+		if _, found := s.byName[config.Name]; found {
+			return nil, fmt.Errorf("storage class %q is defined more than once", config.Name)
+		}
+		if config.QuotaRatio < 0 || config.QuotaRatio > 1 {
+			return nil, fmt.Errorf("invalid quota_ratio %v for storage class %q: it must be between 0 and 1", config.QuotaRatio, config.Name)
+		}
+		totalQuotaRatio += config.QuotaRatio
+
+		class := &storageClass{
+			name:       config.Name,
+			quotaRatio: config.QuotaRatio,
+			priority:   len(s.classes),
+		}
+		switch config.DropPolicy {
+		case "", "oldest":
+			class.dropPolicy = DropOldestFirst
+		case "newest":
+			class.dropPolicy = DropNewestFirst
+		default:
+			return nil, fmt.Errorf("invalid drop_policy %q for storage class %q: it must be 'oldest' or 'newest'", config.DropPolicy, config.Name)
+		}
+
+		if config.Name == DefaultStorageClassName && len(config.Endpoints) > 0 {
+			return nil, fmt.Errorf("the %q storage class cannot have endpoints", DefaultStorageClassName)
+		}
+		for _, endpoint := range config.Endpoints {
+			if other, found := s.byEndpoint[endpoint]; found {
+				return nil, fmt.Errorf("endpoint %q is part of both the %q and %q storage classes", endpoint, other.name, config.Name)
+			}
+			s.byEndpoint[endpoint] = class
+		}
+
+		s.classes = append(s.classes, class)
+		s.byName[class.name] = class
+	}
+
+	if totalQuotaRatio > 1 {
+		return nil, fmt.Errorf("the sum of the quota_ratio of the storage classes (%v) exceeds 1", totalQuotaRatio)
+	}
+
+	if _, found := s.byName[DefaultStorageClassName]; !found {
+		class := &storageClass{name: DefaultStorageClassName, priority: len(s.classes)}
+		s.classes = append(s.classes, class)
+		s.byName[class.name] = class
+	}
+
+	return s, nil
+}
+
+// classOf returns the storage class of the transactions sent to an endpoint.
+func (s *StorageClasses) classOf(endpointName string) *storageClass {
+	if class, found := s.byEndpoint[endpointName]; found {
+		return class
+	}
+	return s.byName[DefaultStorageClassName]
+}
+
+// get returns the storage class with this name, or the default class if it is
+// unknown, for instance if the configuration changed since it was stored.
+func (s *StorageClasses) get(name string) *storageClass {
+	if class, found := s.byName[name]; found {
+		return class
+	}
+	return s.byName[DefaultStorageClassName]
+}
+
+// Names returns the names of the storage classes, by priority.
+func (s *StorageClasses) Names() []string {
+	names := make([]string, 0, len(s.classes))
+	for _, class := range s.classes {
+		names = append(names, class.name)
+	}
+	return names
+}
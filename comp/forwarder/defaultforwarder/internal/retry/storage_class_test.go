@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStorageClasses(t *testing.T) {
+	s, err := NewStorageClasses([]StorageClassConfig{
+		{Name: "series", Endpoints: []string{"series_v2", "sketches_v2"}, QuotaRatio: 0.4},
+		{Name: "checks", Endpoints: []string{"services_checks_v2"}, QuotaRatio: 0.2, DropPolicy: "newest"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "series", s.classOf("sketches_v2").name)
+	assert.Equal(t, DropNewestFirst, s.classOf("services_checks_v2").dropPolicy)
+	assert.Equal(t, 1, s.classOf("services_checks_v2").priority)
+	assert.Equal(t, DefaultStorageClassName, s.classOf("process").name)
+	assert.Equal(t, 2, s.classOf("process").priority)
+	assert.Equal(t, DefaultStorageClassName, s.get("unknown").name)
+}
+
+func TestNewStorageClassesErrors(t *testing.T) {
+	for name, configs := range map[string][]StorageClassConfig{
+		"invalid name":       {{Name: "Series.v2"}},
+		"duplicate name":     {{Name: "series"}, {Name: "series"}},
+		"invalid ratio":      {{Name: "series", QuotaRatio: 1.5}},
+		"ratios exceed 1":    {{Name: "series", QuotaRatio: 0.6}, {Name: "checks", QuotaRatio: 0.6}},
+		"invalid policy":     {{Name: "series", DropPolicy: "random"}},
+		"duplicate endpoint": {{Name: "series", Endpoints: []string{"series_v2"}}, {Name: "other", Endpoints: []string{"series_v2"}}},
+		"default endpoints":  {{Name: "default", Endpoints: []string{"series_v2"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewStorageClasses(configs)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -77,6 +77,13 @@ var (
 	fileStoragePointDroppedCountTelemetry   *counterExpvar
 	deserializeErrorsCountTelemetry         *counterExpvar
 	deserializeTransactionsCountTelemetry   *counterExpvar
+
+	classSizeInBytesTelemetry = telemetry.NewGauge("file_storage", "class_size_in_bytes",
+		[]string{"domain", "class"}, "The number of bytes used to store transactions on the disk, by storage class")
+	classFilesRemovedCountTelemetry = telemetry.NewCounter("file_storage", "class_files_removed_count",
+		[]string{"domain", "class"}, "The number of files removed because the disk limit was reached, by storage class")
+	classPayloadsDroppedCountTelemetry = telemetry.NewCounter("file_storage", "class_payloads_dropped_count",
+		[]string{"domain", "class"}, "The number of payloads not stored on the disk because other storage classes use less than their quota, by storage class")
 )
 
 func init() {
@@ -304,6 +311,18 @@ func (t onDiskRetryQueueTelemetry) addDeserializeTransactionsCount(count int) {
 	deserializeTransactionsCountTelemetry.add(float64(count), t.domainName)
 }
 
+func (t onDiskRetryQueueTelemetry) setClassSizeInBytes(count int64, class string) {
+	classSizeInBytesTelemetry.Set(float64(count), t.domainName, class)
+}
+
+func (t onDiskRetryQueueTelemetry) addClassFilesRemovedCount(class string) {
+	classFilesRemovedCountTelemetry.Inc(t.domainName, class)
+}
+
+func (t onDiskRetryQueueTelemetry) addClassPayloadsDroppedCount(count int, class string) {
+	classPayloadsDroppedCountTelemetry.Add(float64(count), t.domainName, class)
+}
+
 func toCamelCase(s string) string {
 	parts := strings.Split(s, "_")
 	var camelCase string
@@ -6,6 +6,7 @@
 package retry
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
@@ -48,6 +49,7 @@ func BuildTransactionRetryQueue(
 	flushToStorageRatio float64,
 	optionalDomainFolderPath string,
 	optionalDiskUsageLimit *DiskUsageLimit,
+	storageClasses *StorageClasses,
 	dropPrioritySorter TransactionPrioritySorter,
 	resolver resolver.DomainResolver,
 	pointCountTelemetry *PointCountTelemetry) *TransactionRetryQueue {
@@ -57,7 +59,7 @@ func BuildTransactionRetryQueue(
 
 	if optionalDomainFolderPath != "" && optionalDiskUsageLimit != nil {
 		serializer := NewHTTPTransactionsSerializer(resolver)
-		storage, err = newOnDiskRetryQueue(serializer, optionalDomainFolderPath, optionalDiskUsageLimit, newOnDiskRetryQueueTelemetry(resolver.GetBaseDomain()), pointCountTelemetry)
+		storage, err = newOnDiskRetryQueue(serializer, optionalDomainFolderPath, optionalDiskUsageLimit, storageClasses, newOnDiskRetryQueueTelemetry(resolver.GetBaseDomain()), pointCountTelemetry)
 
 		// If the storage on disk cannot be used, log the error and continue.
 		// Returning `nil, err` would mean not using `TransactionRetryQueue` and so not using `forwarder_retry_queue_payloads_max_size` config.
@@ -114,9 +116,15 @@ func (tc *TransactionRetryQueue) Add(t transaction.Transaction) (int, error) {
 		for _, payloads := range payloadsGroupToFlush {
 			if err := tc.optionalStorage.Store(payloads); err != nil {
 				diskErr = multierror.Append(diskErr, err)
-				// Assuming all payloads failed during serialization
+				// Only the payloads of the storage classes that failed are dropped,
+				// assume all of them were dropped if the error doesn't tell
+				dropped := payloads
+				var storeErr *storeError
+				if errors.As(err, &storeErr) {
+					dropped = storeErr.transactions
+				}
 				pointCountDroppped := 0
-				for _, payload := range payloads {
+				for _, payload := range dropped {
 					pointCountDroppped += payload.GetPointCount()
 				}
 				tc.onDropPoints(pointCountDroppped)
@@ -158,6 +158,7 @@ func newOnDiskRetryQueueTest(t *testing.T, a *assert.Assertions) *onDiskRetryQue
 		NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver("", nil)),
 		path,
 		diskUsageLimit,
+		nil,
 		newOnDiskRetryQueueTelemetry("domain"),
 		NewPointCountTelemetryMock())
 	a.NoError(err)
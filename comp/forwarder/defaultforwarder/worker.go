@@ -38,6 +38,9 @@ type Worker struct {
 	stopped               chan struct{}
 	blockedList           *blockedEndpoints
 	pointSuccessfullySent PointSuccessfullySent
+	// failover is notified of the result of each transaction when the worker
+	// sends to the primary domain of a failover group, nil otherwise.
+	failover *failoverGroup
 }
 
 // PointSuccessfullySent is called when sending successfully a point to the intake.
@@ -187,9 +190,16 @@ func (w *Worker) process(ctx context.Context, t transaction.Transaction) {
 	// Run the endpoint through our blockedEndpoints circuit breaker
 	target := t.GetTarget()
 	if w.blockedList.isBlock(target) {
+		w.failover.onTransactionResult(errEndpointBlocked, 0)
 		requeue()
 		log.Errorf("Too many errors for endpoint '%s': retrying later", target)
-	} else if err := t.Process(ctx, w.config, w.Client); err != nil {
+		return
+	}
+
+	start := time.Now()
+	err := t.Process(ctx, w.config, w.Client)
+	w.failover.onTransactionResult(err, time.Since(start))
+	if err != nil {
 		w.blockedList.close(target)
 		requeue()
 		log.Errorf("Error while processing transaction: %v", err)
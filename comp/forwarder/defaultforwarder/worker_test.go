@@ -113,6 +113,7 @@ func TestWorkerRetryBlockedTransaction(t *testing.T) {
 	mock := newTestTransaction()
 	mock.On("GetTarget").Return("error_url").Times(1)
 
+	w.failover = newFailoverGroup(mockConfig, "primary", "secondary", func() error { return nil })
 	w.blockedList.close("error_url")
 	w.Start()
 	highPrio <- mock
@@ -123,6 +124,8 @@ func TestWorkerRetryBlockedTransaction(t *testing.T) {
 	mock.AssertNumberOfCalls(t, "GetTarget", 1)
 	assert.Equal(t, mock, retryTransaction)
 	assert.True(t, w.blockedList.isBlock("error_url"))
+	// the blocked endpoint counts as a failure of the primary domain
+	assert.Equal(t, 1, w.failover.failures())
 }
 
 func TestWorkerResetConnections(t *testing.T) {
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -68,6 +69,9 @@ func NewAutoConfig(scheduler *scheduler.MetaScheduler) *AutoConfig {
 	// We need to listen to the service channels before anything is sent to them
 	go ac.serviceListening()
 
+	// Configs using secrets are rescheduled when those secrets are refreshed
+	secrets.SubscribeToChanges(ac.processSecretsChanges)
+
 	return ac
 }
 
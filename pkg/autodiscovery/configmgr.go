@@ -38,6 +38,11 @@ type configManager interface {
 	// interface apply to only one config.
 	processDelConfigs(configs []integration.Config) integration.ConfigChanges
 
+	// processSecretsRefresh handles a change in the value of secrets used by
+	// the configs with the given names, decrypting those configs (or the
+	// configs resolved from them, for templates) again and rescheduling them.
+	processSecretsRefresh(configNames []string) integration.ConfigChanges
+
 	// mapOverLoadedConfigs calls the given function with a map of all
 	// loaded configs (those which have been scheduled but not unscheduled).
 	// The call is made with the manager's lock held, so callers should perform
@@ -94,6 +99,11 @@ type reconcilingConfigManager struct {
 	// configs.  The returned integration.ConfigChanges from interface
 	// methods correspond exactly to changes in this map.
 	scheduledConfigs map[string]integration.Config
+
+	// decryptedConfigs maps the digest of each non-template config in
+	// activeConfigs to the digest of the config, with secrets decrypted,
+	// found in scheduledConfigs.
+	decryptedConfigs map[string]string
 }
 
 var _ configManager = &reconcilingConfigManager{}
@@ -107,6 +117,7 @@ func newReconcilingConfigManager() configManager {
 		servicesByADID:     newMultimap(),
 		serviceResolutions: map[string]map[string]string{},
 		scheduledConfigs:   map[string]integration.Config{},
+		decryptedConfigs:   map[string]string{},
 	}
 }
 
@@ -208,6 +219,7 @@ func (cm *reconcilingConfigManager) processNewConfig(config integration.Config)
 			log.Errorf("Unable to resolve secrets for config '%s', dropping check configuration, err: %s", config.Name, err.Error())
 		}
 
+		cm.decryptedConfigs[digest] = config.Digest()
 		changes.ScheduleConfig(config)
 	}
 
@@ -256,6 +268,7 @@ func (cm *reconcilingConfigManager) processDelConfigs(configs []integration.Conf
 				log.Errorf("Unable to resolve secrets for config '%s', check may not be unscheduled properly, err: %s", config.Name, err.Error())
 			}
 
+			delete(cm.decryptedConfigs, digest)
 			changes.UnscheduleConfig(config)
 		}
 
@@ -266,6 +279,60 @@ func (cm *reconcilingConfigManager) processDelConfigs(configs []integration.Conf
 	return allChanges
 }
 
+// processSecretsRefresh implements configManager#processSecretsRefresh.
+func (cm *reconcilingConfigManager) processSecretsRefresh(configNames []string) integration.ConfigChanges {
+	cm.m.Lock()
+	defer cm.m.Unlock()
+
+	names := map[string]struct{}{}
+	for _, name := range configNames {
+		names[name] = struct{}{}
+	}
+
+	var changes integration.ConfigChanges
+	for digest, config := range cm.activeConfigs {
+		if _, found := names[config.Name]; !found || config.IsTemplate() {
+			continue
+		}
+
+		decrypted, err := decryptConfig(config)
+		if err != nil {
+			log.Errorf("Unable to resolve refreshed secrets for config '%s', keeping the previous values, err: %s", config.Name, err.Error())
+			continue
+		}
+
+		oldDigest := cm.decryptedConfigs[digest]
+		if decrypted.Digest() == oldDigest {
+			continue
+		}
+		if old, found := cm.scheduledConfigs[oldDigest]; found {
+			changes.UnscheduleConfig(old)
+		}
+		cm.decryptedConfigs[digest] = decrypted.Digest()
+		changes.ScheduleConfig(decrypted)
+	}
+
+	for svcID, resolutions := range cm.serviceResolutions {
+		svc := cm.activeServices[svcID].svc
+		for templateDigest, resolvedDigest := range resolutions {
+			tpl := cm.activeConfigs[templateDigest]
+			if _, found := names[tpl.Name]; !found || svc == nil {
+				continue
+			}
+
+			resolved, ok := cm.resolveTemplateForService(tpl, svc)
+			if !ok || resolved.Digest() == resolvedDigest {
+				continue
+			}
+			changes.UnscheduleConfig(cm.scheduledConfigs[resolvedDigest])
+			changes.ScheduleConfig(resolved)
+			resolutions[templateDigest] = resolved.Digest()
+		}
+	}
+
+	return cm.applyChanges(changes)
+}
+
 // mapOverLoadedConfigs implements configManager#mapOverLoadedConfigs.
 func (cm *reconcilingConfigManager) mapOverLoadedConfigs(f func(map[string]integration.Config)) {
 	cm.m.Lock()
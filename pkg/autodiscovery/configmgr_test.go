@@ -162,6 +162,68 @@ func (suite *ConfigManagerSuite) TestNewNonTemplateWithSecretsScheduled() {
 	require.True(suite.T(), strings.Contains(string(changes.Unschedule[0].Instances[0]), "barDecoded"))
 }
 
+// A non-template config with secrets is rescheduled when the value of its
+// secrets changes
+func (suite *ConfigManagerSuite) TestNonTemplateWithSecretsRefreshed() {
+	secretValue := "barDecoded"
+	originalSecretsDecrypt := secretsDecrypt
+	secretsDecrypt = func(data []byte, origin string) ([]byte, error) {
+		return []byte(strings.ReplaceAll(string(data), "ENC[bar]", secretValue)), nil
+	}
+	defer func() { secretsDecrypt = originalSecretsDecrypt }()
+
+	changes := suite.cm.processNewConfig(nonTemplateConfigWithSecrets)
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(nonTemplateConfigWithSecrets.Name))
+	oldDigest := changes.Schedule[0].Digest()
+
+	// nothing changed for this config
+	changes = suite.cm.processSecretsRefresh([]string{nonTemplateConfigWithSecrets.Name})
+	assertConfigsMatch(suite.T(), changes.Schedule)
+	assertConfigsMatch(suite.T(), changes.Unschedule)
+
+	secretValue = "barRotated"
+	changes = suite.cm.processSecretsRefresh([]string{"unrelated"})
+	assertConfigsMatch(suite.T(), changes.Schedule)
+	assertConfigsMatch(suite.T(), changes.Unschedule)
+
+	changes = suite.cm.processSecretsRefresh([]string{nonTemplateConfigWithSecrets.Name})
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchDigest(oldDigest))
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(nonTemplateConfigWithSecrets.Name))
+	require.True(suite.T(), strings.Contains(string(changes.Schedule[0].Instances[0]), "barRotated"))
+	assertLoadedConfigsMatch(suite.T(), suite.cm, matchDigest(changes.Schedule[0].Digest()))
+
+	changes = suite.cm.processDelConfigs([]integration.Config{nonTemplateConfigWithSecrets})
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchName(nonTemplateConfigWithSecrets.Name))
+	assertLoadedConfigsMatch(suite.T(), suite.cm)
+}
+
+// A template config with secrets resolved for a service is resolved again
+// when the value of its secrets changes
+func (suite *ConfigManagerSuite) TestTemplateWithSecretsRefreshed() {
+	secretValue := "passDecoded"
+	originalSecretsDecrypt := secretsDecrypt
+	secretsDecrypt = func(data []byte, origin string) ([]byte, error) {
+		return []byte(strings.ReplaceAll(string(data), "ENC[pass]", secretValue)), nil
+	}
+	defer func() { secretsDecrypt = originalSecretsDecrypt }()
+
+	tpl := integration.Config{Name: "template-with-secrets", Instances: []integration.Data{integration.Data("password: ENC[pass]")}, ADIdentifiers: []string{"my-service"}}
+	suite.cm.processNewConfig(tpl)
+	changes := suite.cm.processNewService(myService.ADIdentifiers, myService)
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(tpl.Name))
+	oldDigest := changes.Schedule[0].Digest()
+
+	secretValue = "passRotated"
+	changes = suite.cm.processSecretsRefresh([]string{tpl.Name})
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchDigest(oldDigest))
+	assertConfigsMatch(suite.T(), changes.Schedule, matchName(tpl.Name))
+	require.True(suite.T(), strings.Contains(string(changes.Schedule[0].Instances[0]), "passRotated"))
+	newDigest := changes.Schedule[0].Digest()
+
+	changes = suite.cm.processDelService(context.TODO(), myService)
+	assertConfigsMatch(suite.T(), changes.Unschedule, matchDigest(newDigest))
+}
+
 // A new template config is not scheduled when there is no matching service, and
 // not unscheduled when removed
 func (suite *ConfigManagerSuite) TestNewTemplateNotScheduled() {
@@ -39,6 +39,13 @@ var templateVariables = map[string]variableGetter{
 	"kube":     getAdditionalTplVariables,
 }
 
+// IsTemplateVariableSupported returns whether a template variable
+// name (e.g. host in %%host_network%%) can be resolved
+func IsTemplateVariableSupported(name string) bool {
+	_, found := templateVariables[name]
+	return found
+}
+
 type NoServiceError struct {
 	message string
 }
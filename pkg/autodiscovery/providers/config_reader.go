@@ -363,6 +363,15 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		log.Warnf("reading config file %v: %v\n", fpath, strictErr)
 	}
 
+	return buildIntegrationConfig(name, "file:"+fpath, cf)
+}
+
+// buildIntegrationConfig returns an instance of integration.Config from a parsed config, `source` being
+// the description of where the config comes from.
+func buildIntegrationConfig(name, source string, cf configFormat) (integration.Config, error) {
+	var err error
+	conf := integration.Config{Name: name}
+
 	// If no valid instances were found & this is neither a metrics file, nor a logs file
 	// this is not a valid configuration file
 	if cf.MetricConfig == nil && cf.LogsConfig == nil && len(cf.Instances) < 1 {
@@ -386,7 +395,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 			tags := config.GetGlobalConfiguredTags(false)
 			err := dataConf.MergeAdditionalTags(tags)
 			if err != nil {
-				log.Debugf("Could not add agent-level tags to instance of %v: %v", fpath, err)
+				log.Debugf("Could not add agent-level tags to instance of %v: %v", source, err)
 			}
 		}
 		conf.Instances = append(conf.Instances, dataConf)
@@ -429,7 +438,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		}
 	}
 
-	conf.Source = "file:" + fpath
+	conf.Source = source
 
 	return conf, err
 }
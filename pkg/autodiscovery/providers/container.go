@@ -57,7 +57,7 @@ func (k *ContainerConfigProvider) Stream(ctx context.Context) <-chan integration
 	outCh := make(chan integration.ConfigChanges)
 
 	inCh := k.workloadmetaStore.Subscribe(name, workloadmeta.ConfigProviderPriority, workloadmeta.NewFilter(
-		[]workloadmeta.Kind{workloadmeta.KindKubernetesPod, workloadmeta.KindContainer},
+		[]workloadmeta.Kind{workloadmeta.KindKubernetesPod, workloadmeta.KindContainer, workloadmeta.KindNomadAllocation},
 		workloadmeta.SourceAll,
 		workloadmeta.EventTypeAll,
 	))
@@ -243,6 +243,31 @@ func (k *ContainerConfigProvider) generateConfig(e workloadmeta.Entity) ([]integ
 			containerIdentifiers,
 			containerNames)...)
 
+	case *workloadmeta.NomadAllocation:
+		// container labels are handled with the container itself,
+		// only templates from the task meta and service tags are
+		// generated here.
+		for _, allocContainer := range entity.Containers {
+			container, err := k.workloadmetaStore.GetContainer(allocContainer.ID)
+			if err != nil {
+				log.Debugf("Allocation %q has reference to non-existing container %q", entity.ID, allocContainer.ID)
+				continue
+			}
+
+			containerEntity := containers.BuildEntityName(string(container.Runtime), container.ID)
+			c, errors := utils.ExtractTemplatesFromContainerLabels(
+				containerEntity,
+				nomadTaskTemplates(entity, allocContainer.Name),
+			)
+			errs = append(errs, errors...)
+
+			for idx := range c {
+				c[idx].Source = names.Container + ":" + containerEntity
+			}
+
+			configs = append(configs, c...)
+		}
+
 	default:
 		log.Errorf("cannot handle entity of kind %s", e.GetID().Kind)
 	}
@@ -301,6 +326,26 @@ func buildEntityName(e workloadmeta.Entity) string {
 	}
 }
 
+// nomadTaskTemplates returns the autodiscovery templates of a task of a Nomad
+// allocation, in the container labels format. Templates are set in the task
+// meta or in the tags of its services as `<label>=<value>`, meta taking
+// precedence.
+func nomadTaskTemplates(alloc *workloadmeta.NomadAllocation, taskName string) map[string]string {
+	templates := make(map[string]string)
+
+	for _, tag := range alloc.TaskServiceTags[taskName] {
+		if key, value, found := strings.Cut(tag, "="); found {
+			templates[key] = value
+		}
+	}
+
+	for key, value := range alloc.TaskMeta[taskName] {
+		templates[key] = value
+	}
+
+	return templates
+}
+
 // findKubernetesInLabels traverses a map of container labels and
 // returns true if a kubernetes label is detected
 func findKubernetesInLabels(labels map[string]string) bool {
@@ -365,6 +365,57 @@ func TestGenerateConfig(t *testing.T) {
 			},
 			containerCollectAll: true,
 		},
+		{
+			name: "nomad allocation, templates from meta and service tags",
+			entity: &workloadmeta.NomadAllocation{
+				EntityID: workloadmeta.EntityID{
+					Kind: workloadmeta.KindNomadAllocation,
+					ID:   "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+				},
+				Containers: []workloadmeta.OrchestratorContainer{
+					{
+						Name: "redis",
+						ID:   "3b8efe0c50e8",
+					},
+					{
+						Name: "exporter",
+						ID:   "4ac8352d70bf1",
+					},
+				},
+				TaskMeta: map[string]map[string]string{
+					"redis": {
+						"owner":                         "cache-team",
+						"com.datadoghq.ad.init_configs": "[{}]",
+						"com.datadoghq.ad.instances":    "[{\"host\": \"%%host%%\", \"port\": \"6379\"}]",
+					},
+					"exporter": {
+						"com.datadoghq.ad.check_names": "[\"openmetrics\"]",
+					},
+				},
+				TaskServiceTags: map[string][]string{
+					"redis": {
+						"cache",
+						"com.datadoghq.ad.check_names=[\"redisdb\"]",
+						"com.datadoghq.ad.instances=[{}]",
+					},
+					"exporter": {
+						"com.datadoghq.ad.check_names=[\"prometheus\"]",
+					},
+				},
+			},
+			expectedConfigs: []integration.Config{
+				{
+					Name:          "redisdb",
+					ADIdentifiers: []string{"docker://3b8efe0c50e8"},
+					InitConfig:    integration.Data("{}"),
+					Instances:     []integration.Data{integration.Data("{\"host\":\"%%host%%\",\"port\":\"6379\"}")},
+					Source:        "container:docker://3b8efe0c50e8",
+				},
+			},
+			expectedErr: ErrorMsgSet{
+				"could not extract checks config: missing init_configs key": {},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -374,16 +425,22 @@ func TestGenerateConfig(t *testing.T) {
 
 			store := workloadmetatesting.NewStore()
 
-			if pod, ok := tt.entity.(*workloadmeta.KubernetesPod); ok {
-				for _, c := range pod.Containers {
-					store.Set(&workloadmeta.Container{
-						EntityID: workloadmeta.EntityID{
-							Kind: workloadmeta.KindContainer,
-							ID:   c.ID,
-						},
-						Runtime: workloadmeta.ContainerRuntimeDocker,
-					})
-				}
+			var orchestratorContainers []workloadmeta.OrchestratorContainer
+			switch entity := tt.entity.(type) {
+			case *workloadmeta.KubernetesPod:
+				orchestratorContainers = entity.Containers
+			case *workloadmeta.NomadAllocation:
+				orchestratorContainers = entity.Containers
+			}
+
+			for _, c := range orchestratorContainers {
+				store.Set(&workloadmeta.Container{
+					EntityID: workloadmeta.EntityID{
+						Kind: workloadmeta.KindContainer,
+						ID:   c.ID,
+					},
+					Runtime: workloadmeta.ContainerRuntimeDocker,
+				})
 			}
 
 			cp := &ContainerConfigProvider{
@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// httpResponseMaxSize is the maximum size of a response from the HTTP endpoint
+const httpResponseMaxSize = 10 * 1024 * 1024
+
+// httpResponseFormat is the format of the configs returned by the HTTP
+// endpoint, either as JSON or YAML.
+type httpResponseFormat struct {
+	Configs []httpConfigFormat `yaml:"configs"`
+}
+
+// httpConfigFormat is the format of a config returned by the HTTP endpoint.
+// It is the same as a config file, along with the name of the integration.
+type httpConfigFormat struct {
+	Name         string `yaml:"name"`
+	configFormat `yaml:",inline"`
+}
+
+// HTTPConfigProvider implements the ConfigProvider interface. It collects
+// integration configs from an HTTP endpoint, returning them as JSON or YAML.
+// The endpoint is polled with conditional requests (If-None-Match) when it
+// provides an ETag, so that configs are only parsed again when they changed.
+type HTTPConfigProvider struct {
+	client  *http.Client
+	url     string
+	headers http.Header
+
+	// etag is the ETag of the last response
+	etag string
+	// lastBody is the body of the last response
+	lastBody []byte
+	// pendingBody is the body fetched by IsUpToDate, to be parsed by the
+	// next call to Collect
+	pendingBody []byte
+
+	// mu protects errors, read by GetConfigErrors from other goroutines
+	mu     sync.RWMutex
+	errors map[string]ErrorMsgSet
+}
+
+// NewHTTPConfigProvider creates a new HTTPConfigProvider from the
+// `template_url` of the provider config, with optional TLS client
+// certificates, custom CA, basic authentication, bearer token and headers.
+func NewHTTPConfigProvider(providerConfig *config.ConfigurationProviders) (ConfigProvider, error) {
+	if providerConfig == nil {
+		providerConfig = &config.ConfigurationProviders{}
+	}
+
+	endpoint, err := url.Parse(providerConfig.TemplateURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template_url for the %s config provider: %s", names.HTTP, err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid template_url for the %s config provider: scheme must be http or https", names.HTTP)
+	}
+
+	transport := httputils.CreateHTTPTransport()
+	if providerConfig.CAFile != "" {
+		caCert, err := os.ReadFile(providerConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca_file: %s", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("unable to load certificate authority from %s", providerConfig.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = caCertPool
+	}
+	if providerConfig.CertFile != "" || providerConfig.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(providerConfig.CertFile, providerConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %s", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	headers := http.Header{}
+	for name, value := range providerConfig.Headers {
+		headers.Set(name, value)
+	}
+	if providerConfig.Token != "" {
+		headers.Set("Authorization", "Bearer "+providerConfig.Token)
+	}
+	if providerConfig.Username != "" && providerConfig.Password != "" {
+		log.Infof("Using provided %s credentials (username): %s", names.HTTP, providerConfig.Username)
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(providerConfig.Username, providerConfig.Password)
+		headers.Set("Authorization", req.Header.Get("Authorization"))
+	}
+	headers.Set("Accept", "application/json, application/yaml")
+
+	return &HTTPConfigProvider{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Datadog.GetDuration("autoconf_template_url_timeout") * time.Second,
+		},
+		url:     endpoint.String(),
+		headers: headers,
+		errors:  make(map[string]ErrorMsgSet),
+	}, nil
+}
+
+// String returns a string representation of the HTTPConfigProvider
+func (p *HTTPConfigProvider) String() string {
+	return names.HTTP
+}
+
+// Collect retrieves the configs from the HTTP endpoint
+func (p *HTTPConfigProvider) Collect(ctx context.Context) ([]integration.Config, error) {
+	body := p.pendingBody
+	p.pendingBody = nil
+
+	if body == nil {
+		var err error
+		body, _, err = p.fetch(ctx, false)
+		if err != nil {
+			p.setError(err)
+			return nil, err
+		}
+	}
+
+	configs, err := p.parse(body)
+	if err != nil {
+		p.setError(err)
+		return nil, err
+	}
+
+	p.setError(nil)
+	return configs, nil
+}
+
+// IsUpToDate sends a conditional request to the HTTP endpoint to check
+// whether the configs changed since the last call to Collect.
+func (p *HTTPConfigProvider) IsUpToDate(ctx context.Context) (bool, error) {
+	body, modified, err := p.fetch(ctx, true)
+	if err != nil {
+		p.setError(err)
+		return false, err
+	}
+
+	if !modified {
+		return true, nil
+	}
+
+	p.pendingBody = body
+	return false, nil
+}
+
+// GetConfigErrors returns the error that occurred on the last request to the
+// HTTP endpoint, if any.
+func (p *HTTPConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	errors := make(map[string]ErrorMsgSet, len(p.errors))
+	for url, errset := range p.errors {
+		errors[url] = errset
+	}
+
+	return errors
+}
+
+// fetch gets the configs from the HTTP endpoint. If conditional is true, the
+// request is conditioned on the ETag of the last response and modified is
+// false if the endpoint returned the same configs as last time.
+func (p *HTTPConfigProvider) fetch(ctx context.Context, conditional bool) (body []byte, modified bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header = p.headers.Clone()
+	if conditional && p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to get configs from %s: %s", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected status code %d when getting configs from %s", resp.StatusCode, p.url)
+	}
+
+	body, err = io.ReadAll(io.LimitReader(resp.Body, httpResponseMaxSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read configs from %s: %s", p.url, err)
+	}
+	if len(body) > httpResponseMaxSize {
+		return nil, false, fmt.Errorf("configs returned by %s exceed the maximum size of %d bytes", p.url, httpResponseMaxSize)
+	}
+
+	// Servers not supporting ETags send the whole configs every time
+	modified = !bytes.Equal(body, p.lastBody)
+	p.etag = resp.Header.Get("ETag")
+	p.lastBody = body
+
+	return body, modified, nil
+}
+
+// parse builds the integration configs from the body of a response
+func (p *HTTPConfigProvider) parse(body []byte) ([]integration.Config, error) {
+	response := httpResponseFormat{}
+	if err := yaml.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse configs from %s: %s", p.url, err)
+	}
+
+	configs := make([]integration.Config, 0, len(response.Configs))
+	for idx, cf := range response.Configs {
+		if cf.Name == "" {
+			log.Warnf("Ignoring config #%d from %s: missing name", idx, p.url)
+			continue
+		}
+
+		conf, err := buildIntegrationConfig(cf.Name, fmt.Sprintf("%s:%s", names.HTTP, p.url), cf.configFormat)
+		if err != nil {
+			log.Warnf("Ignoring config %q from %s: %s", cf.Name, p.url, err)
+			continue
+		}
+		configs = append(configs, conf)
+	}
+
+	return configs, nil
+}
+
+// setError records the last error, or clears it if err is nil
+func (p *HTTPConfigProvider) setError(err error) {
+	errors := make(map[string]ErrorMsgSet)
+	if err != nil {
+		errors[p.url] = ErrorMsgSet{err.Error(): struct{}{}}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors = errors
+}
+
+func init() {
+	RegisterProvider(names.HTTPRegisterName, NewHTTPConfigProvider)
+}
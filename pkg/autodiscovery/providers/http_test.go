@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const httpTestConfigs = `{
+  "configs": [
+    {
+      "name": "postgres",
+      "init_config": {},
+      "instances": [{"host": "db.example.com", "port": 5432}]
+    },
+    {
+      "name": "redisdb",
+      "ad_identifiers": ["redis"],
+      "instances": [{"host": "%%host%%"}]
+    },
+    {
+      "instances": [{"host": "nameless"}]
+    }
+  ]
+}`
+
+const httpTestConfigsYAML = `configs:
+- name: postgres
+  instances:
+  - host: other.example.com
+`
+
+type httpTestServer struct {
+	body     string
+	etag     string
+	requests []*http.Request
+}
+
+func (s *httpTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r)
+	if s.etag != "" {
+		if r.Header.Get("If-None-Match") == s.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", s.etag)
+	}
+	w.Write([]byte(s.body))
+}
+
+func TestNewHTTPConfigProviderInvalidURL(t *testing.T) {
+	_, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: "127.0.0.1:8080"})
+	assert.Error(t, err)
+}
+
+func TestHTTPCollect(t *testing.T) {
+	server := &httpTestServer{body: httpTestConfigs}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{
+		TemplateURL: ts.URL,
+		Token:       "secret-token",
+		Headers:     map[string]string{"X-Host": "myhost"},
+	})
+	require.NoError(t, err)
+
+	configs, err := provider.(*HTTPConfigProvider).Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	assert.Equal(t, "postgres", configs[0].Name)
+	assert.Equal(t, "http:"+ts.URL, configs[0].Source)
+	assert.False(t, configs[0].IsTemplate())
+	require.Len(t, configs[0].Instances, 1)
+	assert.Equal(t, "host: db.example.com\nport: 5432\n", string(configs[0].Instances[0]))
+
+	assert.Equal(t, "redisdb", configs[1].Name)
+	assert.Equal(t, []string{"redis"}, configs[1].ADIdentifiers)
+
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "Bearer secret-token", server.requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "myhost", server.requests[0].Header.Get("X-Host"))
+	assert.Empty(t, provider.GetConfigErrors())
+}
+
+func TestHTTPIsUpToDateWithETag(t *testing.T) {
+	server := &httpTestServer{body: httpTestConfigs, etag: `"v1"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: ts.URL})
+	require.NoError(t, err)
+	p := provider.(*HTTPConfigProvider)
+	ctx := context.Background()
+
+	_, err = p.Collect(ctx)
+	require.NoError(t, err)
+
+	upToDate, err := p.IsUpToDate(ctx)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+	assert.Equal(t, `"v1"`, server.requests[1].Header.Get("If-None-Match"))
+
+	server.body = httpTestConfigsYAML
+	server.etag = `"v2"`
+	upToDate, err = p.IsUpToDate(ctx)
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	// the body fetched by IsUpToDate is used without sending another request
+	configs, err := p.Collect(ctx)
+	require.NoError(t, err)
+	assert.Len(t, server.requests, 3)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "host: other.example.com\n", string(configs[0].Instances[0]))
+}
+
+func TestHTTPIsUpToDateWithoutETag(t *testing.T) {
+	server := &httpTestServer{body: httpTestConfigs}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: ts.URL})
+	require.NoError(t, err)
+	p := provider.(*HTTPConfigProvider)
+	ctx := context.Background()
+
+	_, err = p.Collect(ctx)
+	require.NoError(t, err)
+
+	upToDate, err := p.IsUpToDate(ctx)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	server.body = httpTestConfigsYAML
+	upToDate, err = p.IsUpToDate(ctx)
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+}
+
+func TestHTTPCollectError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: ts.URL})
+	require.NoError(t, err)
+
+	_, err = provider.(*HTTPConfigProvider).Collect(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, provider.GetConfigErrors(), ts.URL)
+}
+
+// TestHTTPGetConfigErrorsConcurrently is meant to be run with -race
+func TestHTTPGetConfigErrorsConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	provider, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: ts.URL})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			provider.(*HTTPConfigProvider).Collect(context.Background())
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.Contains(t, provider.GetConfigErrors(), ts.URL)
+			return
+		default:
+			for range provider.GetConfigErrors() {
+			}
+		}
+	}
+}
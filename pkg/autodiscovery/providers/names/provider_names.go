@@ -14,6 +14,7 @@ const (
 	EndpointsChecks    = "endpoints-checks"
 	Etcd               = "etcd"
 	File               = "file"
+	HTTP               = "http"
 	KubeContainer      = "kubernetes-container-allinone"
 	Kubernetes         = "kubernetes"
 	KubeServices       = "kubernetes-services"
@@ -35,6 +36,7 @@ const (
 	ClusterChecksRegisterName      = "clusterchecks"
 	EndpointsChecksRegisterName    = "endpointschecks"
 	EtcdRegisterName               = "etcd"
+	HTTPRegisterName               = "http"
 	KubeletRegisterName            = "kubelet"
 	KubeContainerRegisterName      = "kubernetes-container-allinone"
 	KubeServicesRegisterName       = "kube_services"
@@ -57,3 +57,18 @@ func decryptConfig(conf integration.Config) (integration.Config, error) {
 
 	return conf, nil
 }
+
+// processSecretsChanges reschedules the configs using secrets whose value
+// changed during a refresh.
+func (ac *AutoConfig) processSecretsChanges(changes []secrets.Change) {
+	configNames := []string{}
+	for _, change := range changes {
+		configNames = append(configNames, change.Origins...)
+	}
+	if len(configNames) == 0 {
+		return
+	}
+
+	log.Infof("Secrets used by configs %v changed, rescheduling them", configNames)
+	ac.applyChanges(ac.cfgMgr.processSecretsRefresh(configNames))
+}
@@ -30,6 +30,7 @@ type cliParams struct {
 	GlobalParams
 
 	verboseList bool
+	diagnose    bool
 }
 
 type GlobalParams struct {
@@ -65,6 +66,7 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 	}
 
 	workloadListCommand.Flags().BoolVarP(&cliParams.verboseList, "verbose", "v", false, "print out a full dump of the workload store")
+	workloadListCommand.Flags().BoolVarP(&cliParams.diagnose, "diagnose", "d", false, "audit the env, service and version tags of the containers and processes, -v includes the entities without problems")
 
 	return workloadListCommand
 }
@@ -78,7 +80,7 @@ func workloadList(log log.Component, config config.Component, cliParams *cliPara
 		return err
 	}
 
-	url, err := workloadURL(cliParams.verboseList)
+	url, err := workloadURL(cliParams.verboseList, cliParams.diagnose)
 	if err != nil {
 		return err
 	}
@@ -92,6 +94,18 @@ func workloadList(log log.Component, config config.Component, cliParams *cliPara
 		}
 	}
 
+	if cliParams.diagnose {
+		report := workloadmeta.UnifiedServiceTaggingReport{}
+		err = json.Unmarshal(r, &report)
+		if err != nil {
+			return err
+		}
+
+		report.Write(color.Output, cliParams.verboseList)
+
+		return nil
+	}
+
 	workload := workloadmeta.WorkloadDumpResponse{}
 	err = json.Unmarshal(r, &workload)
 	if err != nil {
@@ -103,7 +117,7 @@ func workloadList(log log.Component, config config.Component, cliParams *cliPara
 	return nil
 }
 
-func workloadURL(verbose bool) (string, error) {
+func workloadURL(verbose, diagnose bool) (string, error) {
 	ipcAddress, err := pkgconfig.GetIPCAddress()
 	if err != nil {
 		return "", err
@@ -116,6 +130,10 @@ func workloadURL(verbose bool) (string, error) {
 		prefix = fmt.Sprintf("https://%v:%v/agent/workload-list", ipcAddress, pkgconfig.Datadog.GetInt("cmd_port"))
 	}
 
+	if diagnose {
+		return prefix + "/diagnose", nil
+	}
+
 	if verbose {
 		return prefix + "?verbose=true", nil
 	}
@@ -28,6 +28,24 @@ func TestCommand(t *testing.T) {
 		workloadList,
 		func(cliParams *cliParams, coreParams core.BundleParams) {
 			require.Equal(t, true, cliParams.verboseList)
+			require.Equal(t, false, cliParams.diagnose)
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestDiagnoseCommand(t *testing.T) {
+	commands := []*cobra.Command{
+		MakeCommand(func() GlobalParams {
+			return GlobalParams{}
+		}),
+	}
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"workload-list", "--diagnose"},
+		workloadList,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.Equal(t, false, cliParams.verboseList)
+			require.Equal(t, true, cliParams.diagnose)
+		})
+}
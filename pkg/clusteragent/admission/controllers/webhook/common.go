@@ -19,7 +19,8 @@ import (
 func buildLabelSelectors(useNamespaceSelector bool) (namespaceSelector, objectSelector *metav1.LabelSelector) {
 	var labelSelector metav1.LabelSelector
 
-	if config.Datadog.GetBool("admission_controller.mutate_unlabelled") {
+	// Injection policies select pods without labels, so pods are filtered by the webhooks
+	if config.Datadog.GetBool("admission_controller.mutate_unlabelled") || config.Datadog.GetBool("admission_controller.injection_policies.enabled") {
 		// Accept all, ignore pods if they're explicitly filtered-out
 		labelSelector = metav1.LabelSelector{
 			MatchExpressions: []metav1.LabelSelectorRequirement{
@@ -50,6 +51,30 @@ func buildLabelSelectors(useNamespaceSelector bool) (namespaceSelector, objectSe
 	return nil, &labelSelector
 }
 
+// buildValidationLabelSelectors returns the validating webhooks object selector.
+// All pods are validated, unless they're explicitly filtered-out.
+func buildValidationLabelSelectors(useNamespaceSelector bool) (namespaceSelector, objectSelector *metav1.LabelSelector) {
+	labelSelector := metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      common.EnabledLabelKey,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"false"},
+			},
+		},
+	}
+
+	if config.Datadog.GetBool("admission_controller.add_aks_selectors") {
+		return aksSelectors(useNamespaceSelector, labelSelector)
+	}
+
+	if useNamespaceSelector {
+		return &labelSelector, nil
+	}
+
+	return nil, &labelSelector
+}
+
 // aksSelectors takes a label selector and builds a namespace and object
 // selector adapted for AKS. AKS adds automatically some selector requirements
 // if we don't, so we need to add them to avoid conflicts when updating the
@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	datadogConfig "github.com/DataDog/datadog-agent/pkg/config"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -19,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers/admissionregistration"
+	admissioninformersv1 "k8s.io/client-go/informers/admissionregistration/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
@@ -33,8 +35,20 @@ type Controller interface {
 
 // NewController returns the adequate implementation of the Controller interface.
 func NewController(client kubernetes.Interface, secretInformer coreinformers.SecretInformer, admissionInterface admissionregistration.Interface, isLeaderFunc func() bool, isLeaderNotif <-chan struct{}, config Config) Controller {
+	validationEnabled := datadogConfig.Datadog.GetBool("admission_controller.validate_annotations.enabled")
+
 	if config.useAdmissionV1() {
-		return NewControllerV1(client, secretInformer, admissionInterface.V1().MutatingWebhookConfigurations(), isLeaderFunc, isLeaderNotif, config)
+		// The validating webhooks are only watched when enabled, so that
+		// the cluster-agent doesn't need the permissions otherwise
+		var validatingWebhookInformer admissioninformersv1.ValidatingWebhookConfigurationInformer
+		if validationEnabled {
+			validatingWebhookInformer = admissionInterface.V1().ValidatingWebhookConfigurations()
+		}
+		return NewControllerV1(client, secretInformer, admissionInterface.V1().MutatingWebhookConfigurations(), validatingWebhookInformer, isLeaderFunc, isLeaderNotif, config)
+	}
+
+	if validationEnabled {
+		log.Warn("Validating webhooks require the admissionregistration/v1 API, the annotation validation will be disabled")
 	}
 
 	return NewControllerV1beta1(client, secretInformer, admissionInterface.V1beta1().MutatingWebhookConfigurations(), isLeaderFunc, isLeaderNotif, config)
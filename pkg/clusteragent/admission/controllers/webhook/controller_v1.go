@@ -35,12 +35,19 @@ import (
 // It uses the admissionregistration/v1 API.
 type ControllerV1 struct {
 	controllerBase
-	webhooksLister   admissionlisters.MutatingWebhookConfigurationLister
-	webhookTemplates []admiv1.MutatingWebhook
+	webhooksLister             admissionlisters.MutatingWebhookConfigurationLister
+	webhookTemplates           []admiv1.MutatingWebhook
+	validatingWebhooksLister   admissionlisters.ValidatingWebhookConfigurationLister
+	validatingWebhooksSynced   cache.InformerSynced
+	validatingWebhookTemplates []admiv1.ValidatingWebhook
+	// validatingWebhookCleaned is set once the ValidatingWebhookConfiguration
+	// of a previous run is deleted while the validation is disabled
+	validatingWebhookCleaned bool
 }
 
 // NewControllerV1 returns a new Webhook Controller using admissionregistration/v1.
-func NewControllerV1(client kubernetes.Interface, secretInformer coreinformers.SecretInformer, webhookInformer admissioninformers.MutatingWebhookConfigurationInformer, isLeaderFunc func() bool, isLeaderNotif <-chan struct{}, config Config) *ControllerV1 {
+// The validating webhooks are only reconciled if validatingWebhookInformer isn't nil.
+func NewControllerV1(client kubernetes.Interface, secretInformer coreinformers.SecretInformer, webhookInformer admissioninformers.MutatingWebhookConfigurationInformer, validatingWebhookInformer admissioninformers.ValidatingWebhookConfigurationInformer, isLeaderFunc func() bool, isLeaderNotif <-chan struct{}, config Config) *ControllerV1 {
 	controller := &ControllerV1{}
 	controller.clientSet = client
 	controller.config = config
@@ -51,6 +58,16 @@ func NewControllerV1(client kubernetes.Interface, secretInformer coreinformers.S
 	controller.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "webhooks")
 	controller.isLeaderFunc = isLeaderFunc
 	controller.isLeaderNotif = isLeaderNotif
+	controller.validatingWebhooksSynced = func() bool { return true }
+	if validatingWebhookInformer != nil {
+		controller.validatingWebhooksLister = validatingWebhookInformer.Lister()
+		controller.validatingWebhooksSynced = validatingWebhookInformer.Informer().HasSynced
+		validatingWebhookInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    controller.handleWebhook,
+			UpdateFunc: controller.handleValidatingWebhookUpdate,
+			DeleteFunc: controller.handleWebhook,
+		})
+	}
 	controller.generateTemplates()
 
 	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -76,7 +93,7 @@ func (c *ControllerV1) Run(stopCh <-chan struct{}) {
 	log.Infof("Starting webhook controller for secret %s/%s and webhook %s - Using admissionregistration/v1", c.config.getSecretNs(), c.config.getSecretName(), c.config.getWebhookName())
 	defer log.Infof("Stopping webhook controller for secret %s/%s and webhook %s", c.config.getSecretNs(), c.config.getSecretName(), c.config.getWebhookName())
 
-	if ok := cache.WaitForCacheSync(stopCh, c.secretsSynced, c.webhooksSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.secretsSynced, c.webhooksSynced, c.validatingWebhooksSynced); !ok {
 		return
 	}
 
@@ -121,13 +138,48 @@ func (c *ControllerV1) handleWebhookUpdate(oldObj, newObj interface{}) {
 	c.handleWebhook(newObj)
 }
 
-// reconcile creates/updates the webhook object on new events.
+// handleValidatingWebhookUpdate handles the new validating Webhook reported in update events.
+// It can be a callback function for update events.
+func (c *ControllerV1) handleValidatingWebhookUpdate(oldObj, newObj interface{}) {
+	if !c.isLeaderFunc() {
+		return
+	}
+
+	newWebhook, ok := newObj.(*admiv1.ValidatingWebhookConfiguration)
+	if !ok {
+		log.Debugf("Expected ValidatingWebhookConfiguration object, got: %v", newObj)
+		return
+	}
+
+	oldWebhook, ok := oldObj.(*admiv1.ValidatingWebhookConfiguration)
+	if !ok {
+		log.Debugf("Expected ValidatingWebhookConfiguration object, got: %v", oldObj)
+		return
+	}
+
+	if newWebhook.ResourceVersion == oldWebhook.ResourceVersion {
+		return
+	}
+
+	c.handleWebhook(newObj)
+}
+
+// reconcile creates/updates the webhook objects on new events.
 func (c *ControllerV1) reconcile() error {
 	secret, err := c.getSecret()
 	if err != nil {
 		return err
 	}
 
+	if err := c.reconcileMutatingWebhook(secret); err != nil {
+		return err
+	}
+
+	return c.reconcileValidatingWebhook(secret)
+}
+
+// reconcileMutatingWebhook creates/updates the MutatingWebhookConfiguration object.
+func (c *ControllerV1) reconcileMutatingWebhook(secret *corev1.Secret) error {
 	webhook, err := c.webhooksLister.Get(c.config.getWebhookName())
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -143,6 +195,83 @@ func (c *ControllerV1) reconcile() error {
 	return c.updateWebhook(secret, webhook)
 }
 
+// reconcileValidatingWebhook creates/updates the ValidatingWebhookConfiguration object,
+// or deletes it when the validation is disabled.
+func (c *ControllerV1) reconcileValidatingWebhook(secret *corev1.Secret) error {
+	if c.validatingWebhooksLister == nil || len(c.validatingWebhookTemplates) == 0 {
+		return c.deleteValidatingWebhook()
+	}
+
+	webhook, err := c.validatingWebhooksLister.Get(c.config.getWebhookName())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Infof("Validating Webhook %s was not found, creating it", c.config.getWebhookName())
+			return c.createValidatingWebhook(secret)
+		}
+
+		return err
+	}
+
+	log.Debugf("The Validating Webhook %s was found, updating it", c.config.getWebhookName())
+
+	webhook = webhook.DeepCopy()
+	webhook.Webhooks = c.newValidatingWebhooks(secret)
+	_, err = c.clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(context.TODO(), webhook, metav1.UpdateOptions{})
+	return err
+}
+
+// deleteValidatingWebhook deletes the ValidatingWebhookConfiguration object created
+// when the validation was enabled. The cluster-agent may not have the permissions on
+// ValidatingWebhookConfigurations when the validation is disabled, it is only tried once.
+func (c *ControllerV1) deleteValidatingWebhook() error {
+	if c.validatingWebhookCleaned {
+		return nil
+	}
+
+	err := c.clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(context.TODO(), c.config.getWebhookName(), metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		log.Infof("Validating Webhook %s was deleted, the validation is disabled", c.config.getWebhookName())
+	case errors.IsNotFound(err):
+	case errors.IsForbidden(err):
+		log.Debugf("Cannot delete Validating Webhook %s: %v", c.config.getWebhookName(), err)
+	default:
+		return err
+	}
+
+	c.validatingWebhookCleaned = true
+	return nil
+}
+
+// createValidatingWebhook creates a new ValidatingWebhookConfiguration object.
+func (c *ControllerV1) createValidatingWebhook(secret *corev1.Secret) error {
+	webhook := &admiv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: c.config.getWebhookName(),
+		},
+		Webhooks: c.newValidatingWebhooks(secret),
+	}
+
+	_, err := c.clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(context.TODO(), webhook, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Validating Webhook %s already exists", webhook.GetName())
+		return nil
+	}
+
+	return err
+}
+
+// newValidatingWebhooks generates ValidatingWebhook objects from config templates with updated CABundle from Secret.
+func (c *ControllerV1) newValidatingWebhooks(secret *corev1.Secret) []admiv1.ValidatingWebhook {
+	webhooks := []admiv1.ValidatingWebhook{}
+	for _, tpl := range c.validatingWebhookTemplates {
+		tpl.ClientConfig.CABundle = certificate.GetCABundle(secret.Data)
+		webhooks = append(webhooks, tpl)
+	}
+
+	return webhooks
+}
+
 // createWebhook creates a new MutatingWebhookConfiguration object.
 func (c *ControllerV1) createWebhook(secret *corev1.Secret) error {
 	webhook := &admiv1.MutatingWebhookConfiguration{
@@ -202,6 +331,58 @@ func (c *ControllerV1) generateTemplates() {
 	}
 
 	c.webhookTemplates = webhooks
+
+	validatingWebhooks := []admiv1.ValidatingWebhook{}
+
+	// Autodiscovery annotations validation
+	if config.Datadog.GetBool("admission_controller.validate_annotations.enabled") {
+		webhook := c.getValidatingWebhookSkeleton("ad-annotations", config.Datadog.GetString("admission_controller.validate_annotations.endpoint"))
+		validatingWebhooks = append(validatingWebhooks, webhook)
+	}
+
+	c.validatingWebhookTemplates = validatingWebhooks
+}
+
+// getValidatingWebhookSkeleton returns a validating webhook for pod creations.
+// Validating webhooks always ignore failures, a cluster-agent outage must not block pod creations.
+func (c *ControllerV1) getValidatingWebhookSkeleton(nameSuffix, path string) admiv1.ValidatingWebhook {
+	matchPolicy := admiv1.Exact
+	sideEffects := admiv1.SideEffectClassNone
+	port := c.config.getServicePort()
+	timeout := c.config.getTimeout()
+	failurePolicy := admiv1.Ignore
+	webhook := admiv1.ValidatingWebhook{
+		Name: c.config.configName(nameSuffix),
+		ClientConfig: admiv1.WebhookClientConfig{
+			Service: &admiv1.ServiceReference{
+				Namespace: c.config.getServiceNs(),
+				Name:      c.config.getServiceName(),
+				Port:      &port,
+				Path:      &path,
+			},
+		},
+		Rules: []admiv1.RuleWithOperations{
+			{
+				Operations: []admiv1.OperationType{
+					admiv1.Create,
+				},
+				Rule: admiv1.Rule{
+					APIGroups:   []string{""},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"pods"},
+				},
+			},
+		},
+		FailurePolicy:           &failurePolicy,
+		MatchPolicy:             &matchPolicy,
+		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeout,
+		AdmissionReviewVersions: []string{"v1", "v1beta1"},
+	}
+
+	webhook.NamespaceSelector, webhook.ObjectSelector = buildValidationLabelSelectors(c.config.useNamespaceSelector())
+
+	return webhook
 }
 
 func (c *ControllerV1) getWebhookSkeleton(nameSuffix, path string) admiv1.MutatingWebhook {
@@ -119,6 +119,73 @@ func TestUpdateOutdatedWebhookV1(t *testing.T) {
 	}
 }
 
+func TestCreateValidatingWebhookV1(t *testing.T) {
+	mockConfig := config.Mock(t)
+	mockConfig.Set("admission_controller.validate_annotations.enabled", true)
+
+	f := newFixtureV1(t)
+
+	data, err := certificate.GenerateSecretData(time.Now(), time.Now().Add(365*24*time.Hour), []string{"my.svc.dns"})
+	if err != nil {
+		t.Fatalf("Failed to create the Secret: %v", err)
+	}
+
+	secret := buildSecret(data, v1Cfg)
+	f.populateSecretsCache(secret)
+
+	c := f.run(t)
+
+	webhook, err := c.validatingWebhooksLister.Get(v1Cfg.getWebhookName())
+	if err != nil {
+		t.Fatalf("Failed to get the validating Webhook: %v", err)
+	}
+
+	if assert.Len(t, webhook.Webhooks, 1) {
+		assert.Equal(t, "datadog.webhook.ad.annotations", webhook.Webhooks[0].Name)
+		assert.Equal(t, "/validateannotations", *webhook.Webhooks[0].ClientConfig.Service.Path)
+		assert.Equal(t, admiv1.Ignore, *webhook.Webhooks[0].FailurePolicy)
+		assert.Equal(t, certificate.GetCABundle(secret.Data), webhook.Webhooks[0].ClientConfig.CABundle)
+	}
+
+	if c.queue.Len() != 0 {
+		t.Fatal("Work queue isn't empty")
+	}
+}
+
+func TestDeleteValidatingWebhookV1(t *testing.T) {
+	mockConfig := config.Mock(t)
+	mockConfig.Set("admission_controller.validate_annotations.enabled", false)
+
+	f := newFixtureV1(t)
+
+	data, err := certificate.GenerateSecretData(time.Now(), time.Now().Add(365*24*time.Hour), []string{"my.svc.dns"})
+	if err != nil {
+		t.Fatalf("Failed to create the Secret: %v", err)
+	}
+
+	secret := buildSecret(data, v1Cfg)
+	f.populateSecretsCache(secret)
+
+	// Created when the validation was enabled
+	webhook := &admiv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: v1Cfg.getWebhookName(),
+		},
+	}
+	_, _ = f.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(context.TODO(), webhook, metav1.CreateOptions{})
+
+	c := f.run(t)
+
+	_, err = f.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), v1Cfg.getWebhookName(), metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Fatal("Validating Webhook should be deleted")
+	}
+
+	if c.queue.Len() != 0 {
+		t.Fatal("Work queue isn't empty")
+	}
+}
+
 func TestAdmissionControllerFailureModeIgnore(t *testing.T) {
 	f := newFixtureV1(t)
 	c, _ := f.createController()
@@ -259,6 +326,29 @@ func TestGenerateTemplatesV1(t *testing.T) {
 				return []admiv1.MutatingWebhook{webhook}
 			},
 		},
+		{
+			name: "config injection, injection policies",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.inject_config.enabled", true)
+				mockConfig.Set("admission_controller.mutate_unlabelled", false)
+				mockConfig.Set("admission_controller.injection_policies.enabled", true)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", false)
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				webhook := webhook("datadog.webhook.config", "/injectconfig", &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "admission.datadoghq.com/enabled",
+							Operator: metav1.LabelSelectorOpNotIn,
+							Values:   []string{"false"},
+						},
+					},
+				}, nil)
+				return []admiv1.MutatingWebhook{webhook}
+			},
+		},
 		{
 			name: "tags injection, mutate all",
 			setupConfig: func() {
@@ -616,6 +706,7 @@ func (f *fixtureV1) createController() (*ControllerV1, informers.SharedInformerF
 		f.client,
 		factory.Core().V1().Secrets(),
 		factory.Admissionregistration().V1().MutatingWebhookConfigurations(),
+		factory.Admissionregistration().V1().ValidatingWebhookConfigurations(),
 		func() bool { return true },
 		make(chan struct{}),
 		v1Cfg,
@@ -16,10 +16,11 @@ import (
 
 // Metric names
 const (
-	SecretControllerName   = "secrets"
-	WebhooksControllerName = "webhooks"
-	TagsMutationType       = "standard_tags"
-	ConfigMutationType     = "agent_config"
+	SecretControllerName        = "secrets"
+	WebhooksControllerName      = "webhooks"
+	TagsMutationType            = "standard_tags"
+	ConfigMutationType          = "agent_config"
+	ADAnnotationsValidationType = "ad_annotations"
 )
 
 // Telemetry metrics
@@ -77,4 +78,7 @@ var (
 	PatchErrors = telemetry.NewCounterWithOpts("admission_webhooks", "patcher_errors",
 		[]string{}, "Number of patch errors.",
 		telemetry.Options{NoDoubleUnderscoreSep: true})
+	ValidationAttempts = telemetry.NewCounterWithOpts("admission_webhooks", "validation_attempts",
+		[]string{"validation_type", "valid"}, "Number of pod validation attempts by validation type (autodiscovery annotations).",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
 )
@@ -17,6 +17,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -97,11 +98,15 @@ func initContainerName(lang language) string {
 	return fmt.Sprintf("datadog-lib-%s-init", lang)
 }
 
-func injectAutoInstrumentation(pod *corev1.Pod, _ string, _ dynamic.Interface) error {
+func injectAutoInstrumentation(pod *corev1.Pod, ns string, _ dynamic.Interface) error {
 	if pod == nil {
 		return errors.New("cannot inject lib into nil pod")
 	}
 
+	if !podInScope(pod, ns) {
+		return nil
+	}
+
 	for _, lang := range supportedLanguages {
 		if containsInitContainer(pod, initContainerName(lang)) {
 			// The admission can be reinvocated for the same pod
@@ -113,6 +118,9 @@ func injectAutoInstrumentation(pod *corev1.Pod, _ string, _ dynamic.Interface) e
 
 	containerRegistry := config.Datadog.GetString("admission_controller.auto_instrumentation.container_registry")
 	libsToInject := extractLibInfo(pod, containerRegistry)
+	if len(libsToInject) == 0 {
+		libsToInject = extractLibInfoFromPolicy(pod, ns, containerRegistry)
+	}
 	if len(libsToInject) == 0 {
 		libsToInject = injectAll(pod.Namespace, containerRegistry)
 		if len(libsToInject) == 0 {
@@ -149,6 +157,37 @@ func injectAll(ns, registry string) []libInfo {
 	return libsToInject
 }
 
+// extractLibInfoFromPolicy returns the libraries to inject
+// according to the injection policy selecting the pod
+func extractLibInfoFromPolicy(pod *corev1.Pod, ns, containerRegistry string) []libInfo {
+	p := policy.MatchPod(pod, ns)
+	if p == nil {
+		return nil
+	}
+
+	libInfoList := []libInfo{}
+	for _, lib := range p.Libraries {
+		image := lib.Image
+		if image == "" {
+			version := lib.Version
+			if version == "" {
+				version = "latest"
+			}
+			image = fmt.Sprintf(imageFormat, containerRegistry, strings.ToLower(lib.Language), version)
+		}
+		libInfoList = append(libInfoList, libInfo{
+			lang:  language(strings.ToLower(lib.Language)),
+			image: image,
+		})
+	}
+
+	if len(libInfoList) > 0 {
+		log.Debugf("Injecting libraries into pod %s according to injection policy %q", podString(pod), p.Name)
+	}
+
+	return libInfoList
+}
+
 type libInfo struct {
 	ctrName string // empty means all containers
 	lang    language
@@ -12,6 +12,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	admCommon "github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/wI2L/jsondiff"
@@ -46,6 +49,26 @@ func mutate(rawPod []byte, ns string, m mutateFunc, dc dynamic.Interface) ([]byt
 	return json.Marshal(patch)
 }
 
+// podInScope returns whether a pod sent to the webhooks should be mutated.
+// When injection policies are enabled the webhooks receive all pods, so pods
+// that match neither the usual label selector nor an injection policy are ignored.
+func podInScope(pod *corev1.Pod, ns string) bool {
+	if !config.Datadog.GetBool("admission_controller.injection_policies.enabled") {
+		return true
+	}
+
+	val := pod.GetLabels()[admCommon.EnabledLabelKey]
+	if config.Datadog.GetBool("admission_controller.mutate_unlabelled") {
+		if val != "false" {
+			return true
+		}
+	} else if val == "true" {
+		return true
+	}
+
+	return policy.MatchPod(pod, ns) != nil
+}
+
 // contains returns whether EnvVar slice contains an env var with a given name
 func contains(envs []corev1.EnvVar, name string) bool {
 	for _, env := range envs {
@@ -16,6 +16,7 @@ import (
 
 	admCommon "github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	apiCommon "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -77,7 +78,7 @@ func InjectConfig(rawPod []byte, ns string, dc dynamic.Interface) ([]byte, error
 }
 
 // injectConfig injects DD_AGENT_HOST and DD_ENTITY_ID into a pod template if needed
-func injectConfig(pod *corev1.Pod, _ string, _ dynamic.Interface) error {
+func injectConfig(pod *corev1.Pod, ns string, _ dynamic.Interface) error {
 	var injectedConfig, injectedEntity bool
 	defer func() {
 		metrics.MutationAttempts.Inc(metrics.ConfigMutationType, strconv.FormatBool(injectedConfig || injectedEntity))
@@ -88,7 +89,7 @@ func injectConfig(pod *corev1.Pod, _ string, _ dynamic.Interface) error {
 		return errors.New("cannot inject config into nil pod")
 	}
 
-	if !shouldInjectConf(pod) {
+	if !shouldInjectConf(pod, ns) {
 		return nil
 	}
 
@@ -114,8 +115,8 @@ func injectConfig(pod *corev1.Pod, _ string, _ dynamic.Interface) error {
 }
 
 // shouldInjectConf returns whether the config should be injected
-// based on the pod labels and the cluster agent config
-func shouldInjectConf(pod *corev1.Pod) bool {
+// based on the pod labels, the injection policies and the cluster agent config
+func shouldInjectConf(pod *corev1.Pod, ns string) bool {
 	if val, found := pod.GetLabels()[admCommon.EnabledLabelKey]; found {
 		switch val {
 		case "true":
@@ -127,6 +128,10 @@ func shouldInjectConf(pod *corev1.Pod) bool {
 			return false
 		}
 	}
+	if p := policy.MatchPod(pod, ns); p != nil && p.InjectConfig {
+		log.Debugf("Injecting config into pod %s according to injection policy %q", podString(pod), p.Name)
+		return true
+	}
 	return config.Datadog.GetBool("admission_controller.mutate_unlabelled")
 }
 
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/wI2L/jsondiff"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// DryRunResult is the outcome of running the enabled mutations on a pod
+// without admitting it
+type DryRunResult struct {
+	// Policy is the name of the injection policy selecting the pod, if any
+	Policy string `json:"policy,omitempty"`
+	// Patches are the JSON patches of each webhook, in the order
+	// the webhooks are called. Each one applies on top of the previous ones.
+	Patches map[string]json.RawMessage `json:"patches"`
+	// Errors are the mutation errors of each webhook
+	Errors map[string]string `json:"errors,omitempty"`
+	// Patch is the JSON patch of all the webhooks applied to the original pod
+	Patch json.RawMessage `json:"patch"`
+}
+
+type dryRunMutation struct {
+	name     string
+	enabled  string
+	mutateFn mutateFunc
+}
+
+var dryRunMutations = []dryRunMutation{
+	{name: "config", enabled: "admission_controller.inject_config.enabled", mutateFn: injectConfig},
+	{name: "tags", enabled: "admission_controller.inject_tags.enabled", mutateFn: injectTags},
+	{name: "auto-instrumentation", enabled: "admission_controller.auto_instrumentation.enabled", mutateFn: injectAutoInstrumentation},
+}
+
+// DryRun returns the JSON patches the enabled webhooks would apply to a pod
+func DryRun(rawPod []byte, ns string, dc dynamic.Interface) (*DryRunResult, error) {
+	var pod corev1.Pod
+	if err := json.Unmarshal(rawPod, &pod); err != nil {
+		return nil, fmt.Errorf("failed to decode raw object: %v", err)
+	}
+
+	// Normalize the input so that the patches don't contain
+	// changes unrelated to the mutations
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the Pod object: %v", err)
+	}
+
+	result := &DryRunResult{
+		Patches: make(map[string]json.RawMessage),
+		Errors:  make(map[string]string),
+	}
+
+	if p := policy.MatchPod(&pod, ns); p != nil {
+		result.Policy = p.Name
+	}
+
+	current := original
+	for _, m := range dryRunMutations {
+		if !config.Datadog.GetBool(m.enabled) {
+			continue
+		}
+
+		var mutated corev1.Pod
+		if err := json.Unmarshal(current, &mutated); err != nil {
+			return nil, fmt.Errorf("failed to decode the mutated Pod object: %v", err)
+		}
+
+		if err := m.mutateFn(&mutated, ns, dc); err != nil {
+			// A failing webhook doesn't mutate the pod
+			result.Errors[m.name] = err.Error()
+			result.Patches[m.name] = json.RawMessage("[]")
+			continue
+		}
+
+		next, err := json.Marshal(mutated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the mutated Pod object: %v", err)
+		}
+
+		if result.Patches[m.name], err = diff(current, next); err != nil {
+			return nil, err
+		}
+		current = next
+	}
+
+	if result.Patch, err = diff(original, current); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func diff(source, target []byte) (json.RawMessage, error) {
+	patch, err := jsondiff.CompareJSON(source, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare the JSON patch: %v", err)
+	}
+	if patch == nil {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(patch)
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+const testInjectionPolicies = `
+policies:
+  - name: payments
+    namespaces: ["payments-*"]
+    injectConfig: true
+    libraries:
+      - language: java
+        version: v1.2.3
+    tags:
+      env:
+        value: prod
+      service:
+        fromOwnerName: true
+      version:
+        fromOwnerLabel: app.kubernetes.io/version
+`
+
+func setInjectionPolicies(t *testing.T, content string) {
+	policies, err := policy.Parse([]byte(content))
+	require.NoError(t, err)
+	policy.Set(policies)
+	t.Cleanup(func() { policy.Set(nil) })
+}
+
+func podOwnedByDeployment(ns string, labels map[string]string) *corev1.Pod {
+	pod := fakePodWithContainer("my-app-547c56f566-abcde", corev1.Container{Name: "app"})
+	pod.Namespace = ns
+	pod.Labels = labels
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "ReplicaSet",
+			Name:       "my-app-547c56f566",
+		},
+	}
+	return pod
+}
+
+func TestDryRun(t *testing.T) {
+	mockConfig := config.Mock(t)
+	mockConfig.Set("admission_controller.injection_policies.enabled", true)
+	mockConfig.Set("admission_controller.mutate_unlabelled", false)
+	setInjectionPolicies(t, testInjectionPolicies)
+	defer cache.Cache.Flush()
+
+	ns := "payments-eu"
+	rs := newUnstructured("apps/v1", "ReplicaSet", ns, "my-app-547c56f566")
+	rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app"}})
+	deploy := newUnstructured("apps/v1", "Deployment", ns, "my-app")
+	deploy.SetLabels(map[string]string{"app.kubernetes.io/version": "1.0"})
+	dc := fake.NewSimpleDynamicClient(scheme, rs, deploy)
+
+	rawPod, err := json.Marshal(podOwnedByDeployment(ns, nil))
+	require.NoError(t, err)
+
+	result, err := DryRun(rawPod, ns, dc)
+	require.NoError(t, err)
+	assert.Equal(t, "payments", result.Policy)
+	assert.Empty(t, result.Errors)
+	assert.Len(t, result.Patches, 3)
+	for name, patch := range result.Patches {
+		assert.NotEqual(t, "[]", string(patch), "webhook %s didn't mutate the pod", name)
+	}
+
+	// The global patch applies to the submitted pod
+	decoded, err := jsonpatch.DecodePatch(result.Patch)
+	require.NoError(t, err)
+	patched, err := decoded.Apply(rawPod)
+	require.NoError(t, err)
+
+	var pod corev1.Pod
+	require.NoError(t, json.Unmarshal(patched, &pod))
+	env := map[string]corev1.EnvVar{}
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "prod", env["DD_ENV"].Value)
+	assert.Equal(t, "my-app", env["DD_SERVICE"].Value)
+	assert.Equal(t, "1.0", env["DD_VERSION"].Value)
+	assert.Contains(t, env, "DD_AGENT_HOST")
+	assert.Contains(t, env, "JAVA_TOOL_OPTIONS")
+	require.Len(t, pod.Spec.InitContainers, 1)
+	assert.Equal(t, "gcr.io/datadoghq/dd-lib-java-init:v1.2.3", pod.Spec.InitContainers[0].Image)
+
+	// Pods selected by no policy nor label are not mutated
+	rawPod, err = json.Marshal(podOwnedByDeployment("default", nil))
+	require.NoError(t, err)
+	result, err = DryRun(rawPod, "default", dc)
+	require.NoError(t, err)
+	assert.Empty(t, result.Policy)
+	assert.Equal(t, "[]", string(result.Patch))
+
+	// Pod labels take precedence over the policy
+	rawPod, err = json.Marshal(podOwnedByDeployment(ns, map[string]string{"tags.datadoghq.com/env": "staging"}))
+	require.NoError(t, err)
+	result, err = DryRun(rawPod, ns, dc)
+	require.NoError(t, err)
+	decoded, err = jsonpatch.DecodePatch(result.Patch)
+	require.NoError(t, err)
+	patched, err = decoded.Apply(rawPod)
+	require.NoError(t, err)
+	pod = corev1.Pod{}
+	require.NoError(t, json.Unmarshal(patched, &pod))
+	assert.Contains(t, pod.Spec.Containers[0].Env, corev1.EnvVar{Name: "DD_ENV", Value: "staging"})
+	assert.Contains(t, pod.Spec.Containers[0].Env, corev1.EnvVar{Name: "DD_SERVICE", Value: "my-app"})
+}
+
+func TestDryRunInvalidPod(t *testing.T) {
+	_, err := DryRun([]byte("not a pod"), "default", nil)
+	assert.Error(t, err)
+}
+
+func Test_podInScope(t *testing.T) {
+	setInjectionPolicies(t, testInjectionPolicies)
+
+	tests := []struct {
+		name             string
+		policiesEnabled  bool
+		mutateUnlabelled bool
+		pod              *corev1.Pod
+		want             bool
+	}{
+		{
+			name: "policies disabled",
+			pod:  fakePodWithNamespaceAndLabel("default", "", ""),
+			want: true,
+		},
+		{
+			name:            "selected by label",
+			policiesEnabled: true,
+			pod:             fakePodWithNamespaceAndLabel("default", "admission.datadoghq.com/enabled", "true"),
+			want:            true,
+		},
+		{
+			name:            "selected by policy",
+			policiesEnabled: true,
+			pod:             fakePodWithNamespaceAndLabel("payments-eu", "", ""),
+			want:            true,
+		},
+		{
+			name:            "not selected",
+			policiesEnabled: true,
+			pod:             fakePodWithNamespaceAndLabel("default", "", ""),
+			want:            false,
+		},
+		{
+			name:             "mutate unlabelled",
+			policiesEnabled:  true,
+			mutateUnlabelled: true,
+			pod:              fakePodWithNamespaceAndLabel("default", "", ""),
+			want:             true,
+		},
+		{
+			name:             "disabled by label",
+			policiesEnabled:  true,
+			mutateUnlabelled: true,
+			pod:              fakePodWithNamespaceAndLabel("payments-eu", "admission.datadoghq.com/enabled", "false"),
+			want:             false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConfig := config.Mock(t)
+			mockConfig.Set("admission_controller.injection_policies.enabled", tt.policiesEnabled)
+			mockConfig.Set("admission_controller.mutate_unlabelled", tt.mutateUnlabelled)
+			assert.Equal(t, tt.want, podInScope(tt.pod, ""))
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
@@ -73,8 +74,24 @@ func injectTags(pod *corev1.Pod, ns string, dc dynamic.Interface) error {
 		return nil
 	}
 
+	if !podInScope(pod, ns) {
+		return nil
+	}
+
 	var found bool
-	if found, injected = injectTagsFromLabels(pod.GetLabels(), pod); found {
+	found, injected = injectTagsFromLabels(pod.GetLabels(), pod)
+
+	// Tags defined by an injection policy don't override the pod's labels
+	if p := policy.MatchPod(pod, ns); p != nil {
+		injectedFromPolicy, err := injectTagsFromPolicy(p, pod, ns, dc)
+		if err != nil {
+			metrics.MutationErrors.Inc(metrics.TagsMutationType, "cannot apply injection policy")
+			return err
+		}
+		injected = injected || injectedFromPolicy
+	}
+
+	if found {
 		// Standard labels found in the pod's labels
 		// No need to lookup the pod's owner
 		return nil
@@ -102,7 +119,8 @@ func injectTags(pod *corev1.Pod, ns string, dc dynamic.Interface) error {
 	}
 
 	log.Debugf("Looking for standard labels on '%s/%s' - kind '%s' owner of pod %s", owner.GetNamespace(), owner.GetName(), owner.GetKind(), podString(pod))
-	_, injected = injectTagsFromLabels(owner.GetLabels(), pod)
+	_, injectedFromOwner := injectTagsFromLabels(owner.GetLabels(), pod)
+	injected = injected || injectedFromOwner
 
 	return nil
 }
@@ -135,6 +153,65 @@ func injectTagsFromLabels(labels map[string]string, pod *corev1.Pod) (bool, bool
 	return found, injectedAtLeastOnce
 }
 
+// injectTagsFromPolicy injects the standard tags defined by an injection policy
+// as environment variables, looking up the pod's owner if needed
+func injectTagsFromPolicy(p *policy.InjectionPolicy, pod *corev1.Pod, ns string, dc dynamic.Interface) (bool, error) {
+	var owner *unstructured.Unstructured
+	if owners := pod.GetOwnerReferences(); p.Tags.NeedsOwner() && len(owners) > 0 {
+		if ns == "" {
+			ns = pod.GetNamespace()
+		}
+		if ns == "" {
+			return false, errors.New("cannot get standard tags from parent object: empty namespace")
+		}
+
+		var err error
+		if owner, err = getOwner(owners[0], ns, dc); err != nil {
+			return false, err
+		}
+	}
+
+	injected := false
+	for envName, source := range map[string]policy.TagSource{
+		kubernetes.EnvTagEnvVar:     p.Tags.Env,
+		kubernetes.ServiceTagEnvVar: p.Tags.Service,
+		kubernetes.VersionTagEnvVar: p.Tags.Version,
+	} {
+		value := tagValueFromSource(source, owner)
+		if value == "" {
+			continue
+		}
+		if injectEnv(pod, corev1.EnvVar{Name: envName, Value: value}) {
+			injected = true
+		}
+	}
+
+	if injected {
+		log.Debugf("Injected standard tags into pod %s according to injection policy %q", podString(pod), p.Name)
+	}
+
+	return injected, nil
+}
+
+// tagValueFromSource returns the tag value described by a policy tag source
+func tagValueFromSource(source policy.TagSource, owner *unstructured.Unstructured) string {
+	if source.Value != "" {
+		return source.Value
+	}
+	if owner == nil {
+		return ""
+	}
+	if source.FromOwnerLabel != "" {
+		if value := owner.GetLabels()[source.FromOwnerLabel]; value != "" {
+			return value
+		}
+	}
+	if source.FromOwnerName {
+		return owner.GetName()
+	}
+	return ""
+}
+
 // getOwnerInfo returns the required information to get the owner object
 func getOwnerInfo(owner metav1.OwnerReference) (*ownerInfo, error) {
 	gv, err := schema.ParseGroupVersion(owner.APIVersion)
@@ -85,6 +85,12 @@ func fakePodWithLabel(k, v string) *corev1.Pod {
 	}
 }
 
+func fakePodWithNamespaceAndLabel(ns, k, v string) *corev1.Pod {
+	pod := fakePodWithLabel(k, v)
+	pod.Namespace = ns
+	return pod
+}
+
 func fakePodWithAnnotation(k, v string) *corev1.Pod {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
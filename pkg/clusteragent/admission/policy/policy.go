@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+// Package policy implements the cluster-wide injection policies of the
+// admission controller. Policies let platform teams declare, per namespace
+// or label selector, which APM library to inject into pods, whether to inject
+// the agent configuration, and how to derive the unified service tags,
+// without annotating the workloads themselves.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// Policies is the content of an injection policy file
+type Policies struct {
+	Policies []*InjectionPolicy `json:"policies"`
+}
+
+// InjectionPolicy describes what the admission controller injects
+// into the pods it selects
+type InjectionPolicy struct {
+	Name string `json:"name"`
+
+	// Namespaces is a list of glob patterns matched against the pod namespace.
+	// An empty list selects all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// PodSelector selects pods by label. A nil selector selects all pods.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// ExcludeNamespaces and ExcludePodSelector remove pods from the selection
+	ExcludeNamespaces  []string              `json:"excludeNamespaces,omitempty"`
+	ExcludePodSelector *metav1.LabelSelector `json:"excludePodSelector,omitempty"`
+
+	// InjectConfig enables the injection of the agent configuration
+	// (DD_AGENT_HOST, DD_ENTITY_ID...) into the selected pods
+	InjectConfig bool `json:"injectConfig,omitempty"`
+	// Libraries are the APM libraries injected into the selected pods
+	Libraries []Library `json:"libraries,omitempty"`
+	// Tags are the unified service tags injected into the selected pods
+	Tags Tags `json:"tags,omitempty"`
+
+	podSelector        labels.Selector
+	excludePodSelector labels.Selector
+}
+
+// Library is an APM library to inject
+type Library struct {
+	Language string `json:"language"`
+	// Version is the version of the library init image, defaults to latest
+	Version string `json:"version,omitempty"`
+	// Image overrides the library init image
+	Image string `json:"image,omitempty"`
+}
+
+// Tags holds how the unified service tags of a pod are derived
+type Tags struct {
+	Env     TagSource `json:"env,omitempty"`
+	Service TagSource `json:"service,omitempty"`
+	Version TagSource `json:"version,omitempty"`
+}
+
+// TagSource describes how a tag value is derived. Value takes precedence over
+// FromOwnerLabel, which takes precedence over FromOwnerName.
+type TagSource struct {
+	// Value is a static tag value
+	Value string `json:"value,omitempty"`
+	// FromOwnerLabel is the key of a label of the pod owner holding the value
+	FromOwnerLabel string `json:"fromOwnerLabel,omitempty"`
+	// FromOwnerName uses the name of the pod owner as the value.
+	// A ReplicaSet owned by a Deployment resolves to the Deployment.
+	FromOwnerName bool `json:"fromOwnerName,omitempty"`
+}
+
+// IsEmpty returns whether no source is defined
+func (s TagSource) IsEmpty() bool {
+	return s.Value == "" && s.FromOwnerLabel == "" && !s.FromOwnerName
+}
+
+// NeedsOwner returns whether the pod owner is needed to derive the tag value
+func (s TagSource) NeedsOwner() bool {
+	return s.Value == "" && (s.FromOwnerLabel != "" || s.FromOwnerName)
+}
+
+// NeedsOwner returns whether the pod owner is needed to derive the tags
+func (t Tags) NeedsOwner() bool {
+	return t.Env.NeedsOwner() || t.Service.NeedsOwner() || t.Version.NeedsOwner()
+}
+
+// Parse parses and validates the content of a policy file, in YAML or JSON
+func Parse(content []byte) ([]*InjectionPolicy, error) {
+	var p Policies
+	if err := yaml.UnmarshalStrict(content, &p); err != nil {
+		return nil, fmt.Errorf("cannot parse injection policies: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(p.Policies))
+	for i, policy := range p.Policies {
+		if policy == nil {
+			return nil, fmt.Errorf("injection policy #%d is empty", i)
+		}
+		if err := policy.compile(); err != nil {
+			return nil, fmt.Errorf("invalid injection policy #%d %q: %w", i, policy.Name, err)
+		}
+		if _, found := names[policy.Name]; found {
+			return nil, fmt.Errorf("duplicate injection policy name %q", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+	}
+
+	return p.Policies, nil
+}
+
+// compile validates the policy and builds its label selectors
+func (p *InjectionPolicy) compile() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+
+	for _, pattern := range append(append([]string{}, p.Namespaces...), p.ExcludeNamespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, lib := range p.Libraries {
+		if lib.Language == "" {
+			return errors.New("library language is required")
+		}
+	}
+
+	var err error
+	p.podSelector = labels.Everything()
+	if p.PodSelector != nil {
+		if p.podSelector, err = metav1.LabelSelectorAsSelector(p.PodSelector); err != nil {
+			return fmt.Errorf("invalid pod selector: %w", err)
+		}
+	}
+
+	p.excludePodSelector = labels.Nothing()
+	if p.ExcludePodSelector != nil {
+		if p.excludePodSelector, err = metav1.LabelSelectorAsSelector(p.ExcludePodSelector); err != nil {
+			return fmt.Errorf("invalid exclude pod selector: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns whether the policy selects the pod. ns is the namespace of
+// the admission request, the namespace of the pod is used if it's empty.
+func (p *InjectionPolicy) Matches(pod *corev1.Pod, ns string) bool {
+	if pod == nil {
+		return false
+	}
+
+	if ns == "" {
+		ns = pod.GetNamespace()
+	}
+
+	if len(p.Namespaces) > 0 && !matchesAny(p.Namespaces, ns) {
+		return false
+	}
+	if matchesAny(p.ExcludeNamespaces, ns) {
+		return false
+	}
+
+	podLabels := labels.Set(pod.GetLabels())
+	return p.podSelector.Matches(podLabels) && !p.excludePodSelector.Matches(podLabels)
+}
+
+// Match returns the first policy of the list selecting the pod, or nil.
+// Pods with the label admission.datadoghq.com/enabled=false are never selected.
+func Match(policies []*InjectionPolicy, pod *corev1.Pod, ns string) *InjectionPolicy {
+	if pod == nil || pod.GetLabels()[common.EnabledLabelKey] == "false" {
+		return nil
+	}
+
+	for _, p := range policies {
+		if p.Matches(pod, ns) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+	return false
+}
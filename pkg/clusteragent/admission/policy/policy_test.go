@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testPolicies = `
+policies:
+  - name: payments-batch-excluded
+    namespaces: ["payments-*"]
+    podSelector:
+      matchLabels:
+        app: batch
+  - name: payments
+    namespaces: ["payments-*"]
+    excludeNamespaces: ["payments-sandbox"]
+    excludePodSelector:
+      matchExpressions:
+        - key: tier
+          operator: In
+          values: ["legacy"]
+    injectConfig: true
+    libraries:
+      - language: java
+        version: v1.2.3
+    tags:
+      env:
+        value: prod
+      service:
+        fromOwnerName: true
+      version:
+        fromOwnerLabel: app.kubernetes.io/version
+  - name: everything
+    libraries:
+      - language: python
+`
+
+func newPod(ns string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod",
+			Namespace: ns,
+			Labels:    labels,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	policies, err := Parse([]byte(testPolicies))
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+
+	p := policies[1]
+	assert.Equal(t, "payments", p.Name)
+	assert.True(t, p.InjectConfig)
+	assert.Equal(t, []Library{{Language: "java", Version: "v1.2.3"}}, p.Libraries)
+	assert.Equal(t, TagSource{Value: "prod"}, p.Tags.Env)
+	assert.True(t, p.Tags.Service.FromOwnerName)
+	assert.Equal(t, "app.kubernetes.io/version", p.Tags.Version.FromOwnerLabel)
+	assert.True(t, p.Tags.NeedsOwner())
+	assert.False(t, policies[2].Tags.NeedsOwner())
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "unknown field",
+			content: "policies:\n  - name: foo\n    namespace: bar\n",
+		},
+		{
+			name:    "missing name",
+			content: "policies:\n  - namespaces: [bar]\n",
+		},
+		{
+			name:    "duplicate name",
+			content: "policies:\n  - name: foo\n  - name: foo\n",
+		},
+		{
+			name:    "invalid namespace pattern",
+			content: "policies:\n  - name: foo\n    namespaces: [\"[\"]\n",
+		},
+		{
+			name:    "missing library language",
+			content: "policies:\n  - name: foo\n    libraries:\n      - version: v1\n",
+		},
+		{
+			name:    "invalid selector",
+			content: "policies:\n  - name: foo\n    podSelector:\n      matchExpressions:\n        - key: app\n          operator: Foo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	policies, err := Parse([]byte(testPolicies))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		ns     string
+		policy string
+	}{
+		{
+			name:   "first matching policy",
+			pod:    newPod("payments-eu", map[string]string{"app": "batch"}),
+			policy: "payments-batch-excluded",
+		},
+		{
+			name:   "namespace pattern",
+			pod:    newPod("payments-eu", map[string]string{"app": "api"}),
+			policy: "payments",
+		},
+		{
+			name:   "request namespace",
+			pod:    newPod("", map[string]string{"app": "api"}),
+			ns:     "payments-us",
+			policy: "payments",
+		},
+		{
+			name:   "excluded namespace",
+			pod:    newPod("payments-sandbox", nil),
+			policy: "everything",
+		},
+		{
+			name:   "excluded pods",
+			pod:    newPod("payments-eu", map[string]string{"tier": "legacy"}),
+			policy: "everything",
+		},
+		{
+			name:   "disabled by label",
+			pod:    newPod("payments-eu", map[string]string{"admission.datadoghq.com/enabled": "false"}),
+			policy: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Match(policies, tt.pod, tt.ns)
+			if tt.policy == "" {
+				assert.Nil(t, p)
+				return
+			}
+			require.NotNil(t, p)
+			assert.Equal(t, tt.policy, p.Name)
+		})
+	}
+
+	assert.Nil(t, Match(nil, newPod("default", nil), ""))
+	assert.Nil(t, Match(policies, nil, ""))
+}
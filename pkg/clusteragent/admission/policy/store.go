@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package policy
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// store holds the injection policies currently in effect
+var store = struct {
+	sync.RWMutex
+	policies []*InjectionPolicy
+}{}
+
+// Set replaces the injection policies in effect
+func Set(policies []*InjectionPolicy) {
+	store.Lock()
+	defer store.Unlock()
+	store.policies = policies
+}
+
+// List returns the injection policies in effect
+func List() []*InjectionPolicy {
+	store.RLock()
+	defer store.RUnlock()
+	return store.policies
+}
+
+// MatchPod returns the first injection policy in effect selecting the pod, or nil
+func MatchPod(pod *corev1.Pod, ns string) *InjectionPolicy {
+	return Match(List(), pod, ns)
+}
+
+// FileWatcher reloads the injection policies from a file, typically
+// a ConfigMap mounted in the cluster-agent pod, when it changes
+type FileWatcher struct {
+	file         string
+	pollInterval time.Duration
+	lastContent  []byte
+}
+
+// NewFileWatcher returns a new FileWatcher
+func NewFileWatcher(file string, pollInterval time.Duration) *FileWatcher {
+	return &FileWatcher{
+		file:         file,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run loads the policies and reloads them until stopCh is closed
+func (fw *FileWatcher) Run(stopCh <-chan struct{}) {
+	log.Infof("Starting injection policy watcher: watching %s", fw.file)
+	fw.refresh()
+
+	ticker := time.NewTicker(fw.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fw.refresh()
+		case <-stopCh:
+			log.Info("Shutting down injection policy watcher")
+			return
+		}
+	}
+}
+
+// refresh reloads the policies if the file content changed. Policies in
+// effect are kept if the file can't be read or contains invalid policies.
+func (fw *FileWatcher) refresh() {
+	// Mounted ConfigMaps are updated by swapping symlinks, so the
+	// content is compared rather than the modification time
+	content, err := os.ReadFile(fw.file)
+	if err != nil {
+		log.Errorf("Cannot read injection policies from %s: %v", fw.file, err)
+		return
+	}
+
+	if fw.lastContent != nil && bytes.Equal(content, fw.lastContent) {
+		return
+	}
+
+	policies, err := Parse(content)
+	if err != nil {
+		log.Errorf("Ignoring invalid injection policies from %s: %v", fw.file, err)
+		return
+	}
+
+	fw.lastContent = content
+	Set(policies)
+	log.Infof("Loaded %d injection policies from %s", len(policies), fw.file)
+}
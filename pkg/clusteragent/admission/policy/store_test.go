@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcherRefresh(t *testing.T) {
+	defer Set(nil)
+
+	file := filepath.Join(t.TempDir(), "policies.yaml")
+	fw := NewFileWatcher(file, time.Minute)
+
+	// Missing file
+	fw.refresh()
+	assert.Empty(t, List())
+
+	require.NoError(t, os.WriteFile(file, []byte(testPolicies), 0644))
+	fw.refresh()
+	require.Len(t, List(), 3)
+	assert.Equal(t, "payments", MatchPod(newPod("payments-eu", nil), "").Name)
+
+	// Invalid policies are ignored, the policies in effect are kept
+	require.NoError(t, os.WriteFile(file, []byte("policies:\n  - namespaces: [foo]\n"), 0644))
+	fw.refresh()
+	assert.Len(t, List(), 3)
+
+	require.NoError(t, os.WriteFile(file, []byte("policies:\n  - name: foo\n    namespaces: [foo]\n"), 0644))
+	fw.refresh()
+	require.Len(t, List(), 1)
+	assert.Nil(t, MatchPod(newPod("payments-eu", nil), ""))
+	assert.Equal(t, "foo", MatchPod(newPod("foo", nil), "").Name)
+}
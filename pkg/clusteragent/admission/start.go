@@ -13,6 +13,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/controllers/secret"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/controllers/webhook"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/policy"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
@@ -81,6 +82,14 @@ func StartControllers(ctx ControllerContext) error {
 	go secretController.Run(ctx.StopCh)
 	go webhookController.Run(ctx.StopCh)
 
+	// Every replica serves the webhooks, so the policies are watched regardless of the leadership
+	if config.Datadog.GetBool("admission_controller.injection_policies.enabled") {
+		policyWatcher := policy.NewFileWatcher(
+			config.Datadog.GetString("admission_controller.injection_policies.file"),
+			config.Datadog.GetDuration("admission_controller.injection_policies.refresh_interval")*time.Second)
+		go policyWatcher.Run(ctx.StopCh)
+	}
+
 	ctx.SecretInformers.Start(ctx.StopCh)
 	ctx.WebhookInformers.Start(ctx.StopCh)
 
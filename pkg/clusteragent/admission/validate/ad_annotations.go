@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+// Package validate implements the validating admission webhooks of the cluster-agent
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/common/utils"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/configresolver"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// Validation modes
+	warnMode   = "warn"
+	rejectMode = "reject"
+
+	podTagsAnnotation                = utils.KubeAnnotationPrefix + "tags"
+	podContainerTagsAnnotationFormat = utils.KubeAnnotationPrefix + "%s.tags"
+)
+
+// ADAnnotations validates the autodiscovery annotations of a pod.
+// It returns whether the pod is allowed and the problems found in the annotations.
+// Pods with invalid annotations are only rejected in reject mode.
+func ADAnnotations(rawPod []byte, _ string, _ dynamic.Interface) (bool, []string, error) {
+	var pod corev1.Pod
+	if err := json.Unmarshal(rawPod, &pod); err != nil {
+		return true, nil, fmt.Errorf("failed to decode raw object: %v", err)
+	}
+
+	problems := validateADAnnotations(&pod)
+	metrics.ValidationAttempts.Inc(metrics.ADAnnotationsValidationType, strconv.FormatBool(len(problems) == 0))
+	if len(problems) == 0 {
+		return true, nil, nil
+	}
+
+	mode := strings.ToLower(config.Datadog.GetString("admission_controller.validate_annotations.mode"))
+	log.Debugf("Found %d problems in the autodiscovery annotations of pod %s (mode %s): %v", len(problems), podString(&pod), mode, problems)
+
+	switch mode {
+	case rejectMode:
+		return false, problems, nil
+	case warnMode:
+		return true, problems, nil
+	default:
+		log.Warnf("Unknown annotation validation mode %q - defaulting to %q", mode, warnMode)
+		return true, problems, nil
+	}
+}
+
+// validateADAnnotations returns the problems found in the autodiscovery
+// annotations of a pod, using the same parsing as the node agents
+func validateADAnnotations(pod *corev1.Pod) []string {
+	annotations := pod.GetAnnotations()
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	problems := []string{}
+	containerIdentifiers := map[string]struct{}{}
+	containerNames := map[string]struct{}{}
+
+	for _, ctr := range pod.Spec.Containers {
+		adIdentifier := ctr.Name
+		if customADID, found := utils.ExtractCheckIDFromPodAnnotations(annotations, ctr.Name); found {
+			adIdentifier = customADID
+		}
+		containerIdentifiers[adIdentifier] = struct{}{}
+		containerNames[ctr.Name] = struct{}{}
+
+		configs, errs := utils.ExtractTemplatesFromPodAnnotations(ctr.Name, annotations, adIdentifier)
+		for _, err := range errs {
+			problems = append(problems, fmt.Sprintf("invalid autodiscovery annotations for container %q: %v", ctr.Name, err))
+		}
+
+		for _, c := range configs {
+			for _, v := range unsupportedTemplateVariables(c) {
+				problems = append(problems, fmt.Sprintf("unknown template variable %s in the %s configuration of container %q", v, configName(c), ctr.Name))
+			}
+		}
+
+		tagsAnnotation := fmt.Sprintf(podContainerTagsAnnotationFormat, ctr.Name)
+		if err := validateTagsJSON(annotations, tagsAnnotation); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, err := range utils.ValidateAnnotationsMatching(annotations, containerIdentifiers, containerNames) {
+		problems = append(problems, err.Error())
+	}
+
+	if err := validateTagsJSON(annotations, podTagsAnnotation); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// unsupportedTemplateVariables returns the template variables
+// of a configuration that can't be resolved by the agents
+func unsupportedTemplateVariables(c integration.Config) []string {
+	data := [][]byte{c.InitConfig, c.LogsConfig}
+	for _, instance := range c.Instances {
+		data = append(data, instance)
+	}
+
+	unsupported := []string{}
+	seen := map[string]struct{}{}
+	for _, d := range data {
+		for _, v := range tmplvar.Parse(d) {
+			if configresolver.IsTemplateVariableSupported(string(v.Name)) {
+				continue
+			}
+			if _, found := seen[string(v.Raw)]; found {
+				continue
+			}
+			seen[string(v.Raw)] = struct{}{}
+			unsupported = append(unsupported, string(v.Raw))
+		}
+	}
+
+	return unsupported
+}
+
+// validateTagsJSON checks the format of a tags annotation,
+// a JSON object with string or list of strings values
+func validateTagsJSON(annotations map[string]string, key string) error {
+	value, found := annotations[key]
+	if !found {
+		return nil
+	}
+
+	tags := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &tags); err != nil {
+		return fmt.Errorf("annotation %s is invalid: %v", key, err)
+	}
+
+	for name, v := range tags {
+		switch tagValue := v.(type) {
+		case string:
+		case []interface{}:
+			for _, item := range tagValue {
+				if _, ok := item.(string); !ok {
+					return fmt.Errorf("annotation %s is invalid: tag %q values must be strings", key, name)
+				}
+			}
+		default:
+			return fmt.Errorf("annotation %s is invalid: tag %q must be a string or a list of strings", key, name)
+		}
+	}
+
+	return nil
+}
+
+func configName(c integration.Config) string {
+	if c.Name == "" {
+		return "logs"
+	}
+	return strconv.Quote(c.Name)
+}
+
+// podString returns a string that helps identify the pod
+func podString(pod *corev1.Pod) string {
+	if pod.GetNamespace() == "" || pod.GetName() == "" {
+		return fmt.Sprintf("with generate name %s", pod.GetGenerateName())
+	}
+	return fmt.Sprintf("%s/%s", pod.GetNamespace(), pod.GetName())
+}
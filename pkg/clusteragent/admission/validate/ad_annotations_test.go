@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func podWithAnnotations(annotations map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "redis",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+	for _, name := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name})
+	}
+	return pod
+}
+
+func TestValidateADAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		problems []string
+	}{
+		{
+			name: "no annotations",
+			pod:  podWithAnnotations(nil, "redis"),
+		},
+		{
+			name: "valid annotations",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/redis.check_names":  `["redisdb"]`,
+				"ad.datadoghq.com/redis.init_configs": `[{}]`,
+				"ad.datadoghq.com/redis.instances":    `[{"host": "%%host%%", "port": "%%port_6379%%", "password": "%%env_REDIS_PASSWORD%%"}]`,
+				"ad.datadoghq.com/redis.logs":         `[{"source": "redis"}]`,
+				"ad.datadoghq.com/redis.tags":         `{"team": "cache", "tier": ["db", "backend"]}`,
+				"ad.datadoghq.com/tags":               `{"app": "redis"}`,
+			}, "redis"),
+		},
+		{
+			name: "valid v2 annotations",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/redis.checks": `{"redisdb": {"instances": [{"host": "%%host%%"}]}}`,
+			}, "redis"),
+		},
+		{
+			name: "invalid JSON",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/redis.checks": `{"redisdb": {"instances": [{"host": "%%host%%"}]}`,
+			}, "redis"),
+			problems: []string{
+				`invalid autodiscovery annotations for container "redis": cannot parse check configuration: unexpected end of JSON input`,
+			},
+		},
+		{
+			name: "unknown template variable",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/redis.check_names":  `["redisdb"]`,
+				"ad.datadoghq.com/redis.init_configs": `[{}]`,
+				"ad.datadoghq.com/redis.instances":    `[{"host": "%%hots%%", "port": "%%port%%"}]`,
+				"ad.datadoghq.com/redis.logs":         `[{"source": "%%sevice%%"}]`,
+			}, "redis"),
+			problems: []string{
+				`unknown template variable %%hots%% in the "redisdb" configuration of container "redis"`,
+				`unknown template variable %%sevice%% in the logs configuration of container "redis"`,
+			},
+		},
+		{
+			name: "container name mismatch",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/rediss.checks": `{"redisdb": {"instances": [{"host": "%%host%%"}]}}`,
+			}, "redis"),
+			problems: []string{
+				"annotation ad.datadoghq.com/rediss.checks is invalid: rediss doesn't match a container identifier [redis]",
+			},
+		},
+		{
+			name: "custom autodiscovery identifier",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/redis.check.id":      "custom-redis",
+				"ad.datadoghq.com/custom-redis.checks": `{"redisdb": {"instances": [{"host": "%%host%%"}]}}`,
+			}, "redis"),
+		},
+		{
+			name: "invalid tags",
+			pod: podWithAnnotations(map[string]string{
+				"ad.datadoghq.com/tags":       `{"app": 1}`,
+				"ad.datadoghq.com/redis.tags": `["team:cache"]`,
+			}, "redis"),
+			problems: []string{
+				`annotation ad.datadoghq.com/redis.tags is invalid: json: cannot unmarshal array into Go value of type map[string]interface {}`,
+				`annotation ad.datadoghq.com/tags is invalid: tag "app" must be a string or a list of strings`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateADAnnotations(tt.pod)
+			if len(tt.problems) == 0 {
+				assert.Empty(t, problems)
+				return
+			}
+			assert.Equal(t, tt.problems, problems)
+		})
+	}
+}
+
+func TestADAnnotationsMode(t *testing.T) {
+	mockConfig := config.Mock(t)
+
+	invalid := podWithAnnotations(map[string]string{
+		"ad.datadoghq.com/rediss.checks": `{"redisdb": {"instances": [{"host": "%%host%%"}]}}`,
+	}, "redis")
+	rawInvalid, err := json.Marshal(invalid)
+	require.NoError(t, err)
+
+	valid := podWithAnnotations(nil, "redis")
+	rawValid, err := json.Marshal(valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mode     string
+		rawPod   []byte
+		allowed  bool
+		problems int
+	}{
+		{name: "warn mode, valid pod", mode: "warn", rawPod: rawValid, allowed: true},
+		{name: "warn mode, invalid pod", mode: "warn", rawPod: rawInvalid, allowed: true, problems: 1},
+		{name: "reject mode, valid pod", mode: "reject", rawPod: rawValid, allowed: true},
+		{name: "reject mode, invalid pod", mode: "reject", rawPod: rawInvalid, allowed: false, problems: 1},
+		{name: "unknown mode, invalid pod", mode: "block", rawPod: rawInvalid, allowed: true, problems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConfig.Set("admission_controller.validate_annotations.mode", tt.mode)
+
+			allowed, problems, err := ADAnnotations(tt.rawPod, "default", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+			assert.Len(t, problems, tt.problems)
+		})
+	}
+
+	allowed, _, err := ADAnnotations([]byte("{"), "default", nil)
+	assert.Error(t, err)
+	assert.True(t, allowed)
+}
@@ -28,8 +28,9 @@ func (d *dispatcher) getState() (types.StateResponse, error) {
 	defer d.store.RUnlock()
 
 	response := types.StateResponse{
-		Warmup:   !d.store.active,
-		Dangling: makeConfigArray(d.store.danglingConfigs),
+		Warmup:     !d.store.active,
+		Dangling:   makeConfigArray(d.store.danglingConfigs),
+		Placements: append([]types.PlacementDecision(nil), d.store.placements...),
 	}
 	for _, node := range d.store.nodes {
 		n := types.StateNodeResponse{
@@ -93,6 +94,7 @@ func (d *dispatcher) removeConfig(digest string) {
 	delete(d.store.digestToNode, digest)
 	delete(d.store.digestToConfig, digest)
 	delete(d.store.danglingConfigs, digest)
+	delete(d.store.lastMoves, digest)
 
 	for k, v := range d.store.idToDigest {
 		if v == digest {
@@ -35,6 +35,8 @@ type dispatcher struct {
 	extraTags             []string
 	clcRunnersClient      clusteragent.CLCRunnerClientInterface
 	advancedDispatching   bool
+	costAwareDispatching  bool
+	moveCooldownSeconds   int64
 }
 
 func newDispatcher() *dispatcher {
@@ -65,7 +67,11 @@ func newDispatcher() *dispatcher {
 	if err != nil {
 		log.Warnf("Cannot create CLC runners client, advanced dispatching will be disabled: %v", err)
 		d.advancedDispatching = false
+		return d
 	}
+
+	d.costAwareDispatching = config.Datadog.GetBool("cluster_checks.cost_aware_dispatching.enabled")
+	d.moveCooldownSeconds = config.Datadog.GetInt64("cluster_checks.cost_aware_dispatching.move_cooldown")
 	return d
 }
 
@@ -133,7 +139,13 @@ func (d *dispatcher) reschedule(configs []integration.Config) {
 
 // add stores and delegates a given configuration
 func (d *dispatcher) add(config integration.Config) {
-	target := d.getLeastBusyNode()
+	var target string
+	if d.costAwareDispatching {
+		target = d.getNodeWithMostHeadroom(config)
+	} else {
+		target = d.getLeastBusyNode()
+	}
+
 	if target == "" {
 		// If no node is found, store it in the danglingConfigs map for retrying later.
 		log.Warnf("No available node to dispatch %s:%s on, will retry later", config.Name, config.Digest())
@@ -142,6 +154,10 @@ func (d *dispatcher) add(config integration.Config) {
 	}
 
 	d.addConfig(config, target)
+
+	if d.costAwareDispatching && target != "" {
+		d.recordPlacement(config, "", target, placementReasonHeadroom, configCost{})
+	}
 }
 
 // remove deletes a given configuration
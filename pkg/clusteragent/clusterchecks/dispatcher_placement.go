@@ -0,0 +1,421 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build clusterchecks
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	le "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxPlacementDecisions is the number of placement decisions kept for
+	// the clusterchecks command
+	maxPlacementDecisions = 50
+
+	placementReasonHeadroom  = "most headroom"
+	placementReasonHints     = "placement hints"
+	placementReasonRebalance = "rebalancing"
+)
+
+// configCost is the measured cost of running a configuration, or the checks
+// running on a node. Only the execution time is measured per check: the
+// memory allocations of the runners can't be attributed to a check while
+// other checks run concurrently.
+type configCost struct {
+	executionTime int // ms
+	failing       bool
+}
+
+func (c *configCost) add(stats types.CLCRunnerStats) {
+	c.executionTime += stats.AverageExecutionTime
+	c.failing = c.failing || stats.LastExecFailed
+}
+
+// costScorer turns costs into scores that are relative to the average cost
+// of the checks running on a node: the score of a node with an average load
+// is 1.
+type costScorer struct {
+	averageExecutionTime float64
+}
+
+func newCostScorer(total configCost, nodeCount int) costScorer {
+	s := costScorer{}
+	if nodeCount > 0 {
+		s.averageExecutionTime = float64(total.executionTime) / float64(nodeCount)
+	}
+	return s
+}
+
+func (s costScorer) score(c configCost) float64 {
+	if s.averageExecutionTime == 0 {
+		return 0
+	}
+	return float64(c.executionTime) / s.averageExecutionTime
+}
+
+// placementHints holds the node affinity and anti-affinity of a
+// configuration, as glob patterns matching node names.
+type placementHints struct {
+	affinity     []string
+	antiAffinity []string
+}
+
+// instancePlacementHints holds the placement hints set in an instance of a
+// cluster check configuration.
+type instancePlacementHints struct {
+	NodeAffinity     []string `yaml:"cluster_check_node_affinity"`
+	NodeAntiAffinity []string `yaml:"cluster_check_node_anti_affinity"`
+}
+
+// getPlacementHints merges the placement hints of all the instances of a
+// configuration, as they are dispatched together.
+func getPlacementHints(config integration.Config) placementHints {
+	var hints placementHints
+	for _, instance := range config.Instances {
+		var instanceHints instancePlacementHints
+		if err := yaml.Unmarshal(instance, &instanceHints); err != nil {
+			log.Debugf("Cannot parse placement hints of %s:%s: %v", config.Name, config.Digest(), err)
+			continue
+		}
+		hints.affinity = append(hints.affinity, instanceHints.NodeAffinity...)
+		hints.antiAffinity = append(hints.antiAffinity, instanceHints.NodeAntiAffinity...)
+	}
+	return hints
+}
+
+// allows returns true if a configuration with these hints can run on the node.
+func (h placementHints) allows(nodeName string) bool {
+	if len(h.affinity) > 0 && !matchesAny(h.affinity, nodeName) {
+		return false
+	}
+	return !matchesAny(h.antiAffinity, nodeName)
+}
+
+// candidates returns the nodes allowed by the hints. All the nodes are
+// returned if the hints cannot be satisfied, as running the check on the
+// wrong node is better than not running it.
+func (h placementHints) candidates(nodeNames []string) []string {
+	var allowed []string
+	for _, name := range nodeNames {
+		if h.allows(name) {
+			allowed = append(allowed, name)
+		}
+	}
+	if len(allowed) == 0 {
+		return nodeNames
+	}
+	return allowed
+}
+
+func matchesAny(patterns []string, nodeName string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, nodeName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// placementItem is a configuration considered by the cost-aware rebalancing.
+type placementItem struct {
+	config   integration.Config
+	digest   string
+	node     string
+	checkIDs []string
+	cost     configCost
+	score    float64
+	hints    placementHints
+}
+
+// placementMove is a configuration move decided by the cost-aware rebalancing.
+type placementMove struct {
+	item   *placementItem
+	dest   string
+	reason string
+}
+
+// placementPlan holds the moves decided by the cost-aware rebalancing, and
+// the scores of the nodes before and after them.
+type placementPlan struct {
+	moves         []placementMove
+	scorer        costScorer
+	currentScores map[string]float64
+	plannedScores map[string]float64
+}
+
+// getNodeWithMostHeadroom returns the name of the node with the lowest load
+// allowed by the placement hints of the configuration. In case of equality,
+// the node with the fewest configurations is chosen.
+func (d *dispatcher) getNodeWithMostHeadroom(config integration.Config) string {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	nodeNames := d.store.nodeNames()
+	if len(nodeNames) == 0 {
+		return ""
+	}
+
+	loads := make(map[string]configCost, len(nodeNames))
+	configCounts := make(map[string]int, len(nodeNames))
+	var total configCost
+	for _, name := range nodeNames {
+		node := d.store.nodes[name]
+		node.RLock()
+		var load configCost
+		for _, stats := range node.clcRunnerStats {
+			load.add(stats)
+			total.add(stats)
+		}
+		loads[name] = load
+		configCounts[name] = len(node.digestToConfig)
+		node.RUnlock()
+	}
+
+	hints := getPlacementHints(config)
+	candidates := hints.candidates(nodeNames)
+	if len(hints.affinity)+len(hints.antiAffinity) > 0 && !hints.allows(candidates[0]) {
+		log.Warnf("No node matches the placement hints of %s:%s, ignoring them", config.Name, config.Digest())
+	}
+
+	scorer := newCostScorer(total, len(nodeNames))
+	target := ""
+	minScore := 0.0
+	for _, name := range candidates {
+		score := scorer.score(loads[name])
+		if target == "" || score < minScore || (score == minScore && configCounts[name] < configCounts[target]) {
+			target = name
+			minScore = score
+		}
+	}
+
+	return target
+}
+
+// planPlacements computes the moves needed to spread the cost of the cluster
+// checks on the nodes. Configurations are placed from the most expensive to
+// the cheapest onto the node with the most headroom that their placement
+// hints allow (first-fit decreasing). To favor stability, a configuration
+// only leaves its current node if the destination node load is lower by the
+// toleration margin, and configurations that were moved recently or that
+// are failing are not moved.
+func (d *dispatcher) planPlacements() placementPlan {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	nodeNames := d.store.nodeNames()
+	plan := placementPlan{
+		currentScores: make(map[string]float64, len(nodeNames)),
+		plannedScores: make(map[string]float64, len(nodeNames)),
+	}
+	if len(nodeNames) < 2 {
+		return plan
+	}
+
+	// Split the load of the nodes between the cluster checks, that can be
+	// moved, and the other checks
+	items := make(map[string]*placementItem)
+	fixedLoads := make(map[string]configCost, len(nodeNames))
+	nodeLoads := make(map[string]configCost, len(nodeNames))
+	var total configCost
+	for _, name := range nodeNames {
+		node := d.store.nodes[name]
+		node.RLock()
+		var fixed, load configCost
+		for id, stats := range node.clcRunnerStats {
+			load.add(stats)
+			total.add(stats)
+
+			digest, found := d.store.idToDigest[check.ID(id)]
+			if !found || !stats.IsClusterCheck || d.store.digestToNode[digest] != name {
+				fixed.add(stats)
+				continue
+			}
+
+			item, found := items[digest]
+			if !found {
+				config := d.store.digestToConfig[digest]
+				item = &placementItem{
+					config: config,
+					digest: digest,
+					node:   name,
+					hints:  getPlacementHints(config),
+				}
+				items[digest] = item
+			}
+			item.checkIDs = append(item.checkIDs, id)
+			item.cost.add(stats)
+		}
+		fixedLoads[name] = fixed
+		nodeLoads[name] = load
+		node.RUnlock()
+	}
+
+	plan.scorer = newCostScorer(total, len(nodeNames))
+	for _, name := range nodeNames {
+		plan.currentScores[name] = plan.scorer.score(nodeLoads[name])
+		plan.plannedScores[name] = plan.scorer.score(fixedLoads[name])
+	}
+
+	now := timestampNow()
+	movable := make([]*placementItem, 0, len(items))
+	for _, item := range items {
+		sort.Strings(item.checkIDs)
+		item.score = plan.scorer.score(item.cost)
+
+		movedRecently := now-d.store.lastMoves[item.digest] < d.moveCooldownSeconds
+		if item.hints.allows(item.node) && (item.cost.failing || movedRecently || item.score == 0) {
+			// Keep the configuration on its node
+			plan.plannedScores[item.node] += item.score
+			continue
+		}
+		movable = append(movable, item)
+	}
+
+	sort.Slice(movable, func(i, j int) bool {
+		if movable[i].score != movable[j].score {
+			return movable[i].score > movable[j].score
+		}
+		return movable[i].digest < movable[j].digest
+	})
+
+	for _, item := range movable {
+		candidates := item.hints.candidates(nodeNames)
+		best := candidates[0]
+		for _, name := range candidates[1:] {
+			if plan.plannedScores[name] < plan.plannedScores[best] {
+				best = name
+			}
+		}
+
+		dest, reason := item.node, ""
+		if !item.hints.allows(item.node) && item.hints.allows(best) {
+			dest, reason = best, placementReasonHints
+		} else if best != item.node && plan.plannedScores[best]+item.score < (plan.plannedScores[item.node]+item.score)*tolerationMargin {
+			dest, reason = best, placementReasonRebalance
+		}
+
+		plan.plannedScores[dest] += item.score
+		if dest != item.node {
+			plan.moves = append(plan.moves, placementMove{item: item, dest: dest, reason: reason})
+		}
+	}
+
+	return plan
+}
+
+// rebalanceUsingCosts moves the cluster checks according to their measured
+// execution time.
+func (d *dispatcher) rebalanceUsingCosts() []types.RebalanceResponse {
+	log.Trace("Trying to rebalance cluster checks distribution using their costs")
+
+	plan := d.planPlacements()
+	checksMoved := []types.RebalanceResponse{}
+
+	for _, move := range plan.moves {
+		item := move.item
+		rebalancingDecisions.Inc(le.JoinLeaderValue)
+
+		if err := d.moveConfig(item.digest, item.node, move.dest); err != nil {
+			log.Debugf("Cannot move configuration %s:%s: %v", item.config.Name, item.digest, err)
+			continue
+		}
+
+		successfulRebalancing.Inc(le.JoinLeaderValue)
+		log.Infof("Configuration %s:%s moved from %s to %s (%s), cost score: %.2f", item.config.Name, item.digest, item.node, move.dest, move.reason, item.score)
+		d.recordPlacement(item.config, item.node, move.dest, move.reason, item.cost)
+
+		for _, checkID := range item.checkIDs {
+			checksMoved = append(checksMoved, types.RebalanceResponse{
+				CheckID:        checkID,
+				CheckWeight:    scoreToWeight(item.score),
+				SourceNodeName: item.node,
+				SourceDiff:     scoreToWeight(plan.currentScores[item.node] - 1),
+				DestNodeName:   move.dest,
+				DestDiff:       scoreToWeight(plan.currentScores[move.dest] - 1),
+			})
+		}
+	}
+
+	return checksMoved
+}
+
+// scoreToWeight converts a cost score to the weight reported in rebalancing
+// responses, in thousandths of the average node load.
+func scoreToWeight(score float64) int {
+	return int(score * 1000)
+}
+
+// moveConfig moves a configuration and the runner stats of its checks from a
+// node to another
+func (d *dispatcher) moveConfig(digest, src, dest string) error {
+	d.store.RLock()
+	destNode, destFound := d.store.getNodeStore(dest)
+	sourceNode, srcFound := d.store.getNodeStore(src)
+	config, configFound := d.store.digestToConfig[digest]
+	var checkIDs []string
+	for id, configDigest := range d.store.idToDigest {
+		if configDigest == digest {
+			checkIDs = append(checkIDs, string(id))
+		}
+	}
+	d.store.RUnlock()
+
+	if !destFound || !srcFound {
+		return fmt.Errorf("nodes not found in store: %s, %s", src, dest)
+	}
+	if !configFound {
+		return fmt.Errorf("unknown configuration %s", digest)
+	}
+
+	for _, checkID := range checkIDs {
+		runnerStats, err := sourceNode.GetRunnerStats(checkID)
+		if err != nil {
+			continue
+		}
+		destNode.AddRunnerStats(checkID, runnerStats)
+		sourceNode.RemoveRunnerStats(checkID)
+	}
+
+	d.removeConfig(digest)
+	d.addConfig(config, dest)
+
+	d.store.Lock()
+	d.store.lastMoves[digest] = timestampNow()
+	d.store.Unlock()
+
+	return nil
+}
+
+// recordPlacement keeps a placement decision for the clusterchecks command
+func (d *dispatcher) recordPlacement(config integration.Config, src, dest, reason string, cost configCost) {
+	d.store.Lock()
+	defer d.store.Unlock()
+
+	d.store.placements = append(d.store.placements, types.PlacementDecision{
+		Timestamp:      timestampNow(),
+		CheckName:      config.Name,
+		Digest:         config.Digest(),
+		SourceNodeName: src,
+		DestNodeName:   dest,
+		Reason:         reason,
+		ExecutionTime:  cost.executionTime,
+	})
+	if len(d.store.placements) > maxPlacementDecisions {
+		d.store.placements = d.store.placements[len(d.store.placements)-maxPlacementDecisions:]
+	}
+}
@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build clusterchecks
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func newCostAwareDispatcher(nodes ...string) *dispatcher {
+	d := newDispatcher()
+	d.costAwareDispatching = true
+	d.moveCooldownSeconds = 1800
+	d.store.active = true
+	for _, node := range nodes {
+		d.store.nodes[node] = newNodeStore(node, "")
+	}
+	return d
+}
+
+func generateCostedConfig(name, instance string) integration.Config {
+	return integration.Config{
+		Name:       name,
+		Instances:  []integration.Data{integration.Data(instance)},
+		InitConfig: integration.Data(""),
+	}
+}
+
+// dispatchWithCost dispatches a configuration to a node, with the given
+// runner stats
+func dispatchWithCost(d *dispatcher, config integration.Config, node string, stats types.CLCRunnerStats) string {
+	d.addConfig(config, node)
+	id := check.BuildID(config.Name, config.FastDigest(), config.Instances[0], config.InitConfig)
+	stats.IsClusterCheck = true
+	d.store.nodes[node].clcRunnerStats[string(id)] = stats
+	return string(id)
+}
+
+func TestPlacementHints(t *testing.T) {
+	config := integration.Config{
+		Name: "snmp",
+		Instances: []integration.Data{
+			integration.Data("ip_address: 10.0.0.1\ncluster_check_node_affinity: [\"runner-snmp-*\"]"),
+			integration.Data("ip_address: 10.0.0.2\ncluster_check_node_anti_affinity: [\"runner-snmp-2\"]"),
+		},
+	}
+
+	hints := getPlacementHints(config)
+	assert.Equal(t, []string{"runner-snmp-*"}, hints.affinity)
+	assert.Equal(t, []string{"runner-snmp-2"}, hints.antiAffinity)
+
+	assert.True(t, hints.allows("runner-snmp-1"))
+	assert.False(t, hints.allows("runner-snmp-2"))
+	assert.False(t, hints.allows("runner-1"))
+
+	nodes := []string{"runner-1", "runner-snmp-1", "runner-snmp-2"}
+	assert.Equal(t, []string{"runner-snmp-1"}, hints.candidates(nodes))
+
+	// Unsatisfiable hints are ignored
+	assert.Equal(t, []string{"runner-1"}, hints.candidates([]string{"runner-1"}))
+
+	// No hints
+	assert.Equal(t, nodes, getPlacementHints(generateCostedConfig("http_check", "url: foo")).candidates(nodes))
+}
+
+func TestGetNodeWithMostHeadroom(t *testing.T) {
+	d := newCostAwareDispatcher("runner-1", "runner-2", "runner-snmp-1")
+
+	// No stats yet: the node with the fewest configurations is chosen
+	d.addConfig(generateCostedConfig("http_check", "url: a"), "runner-1")
+	d.addConfig(generateCostedConfig("http_check", "url: b"), "runner-snmp-1")
+	assert.Equal(t, "runner-2", d.getNodeWithMostHeadroom(generateCostedConfig("http_check", "url: c")))
+
+	d.store.nodes["runner-1"].clcRunnerStats = types.CLCRunnersStats{
+		"other": {AverageExecutionTime: 100},
+	}
+	d.store.nodes["runner-2"].clcRunnerStats = types.CLCRunnersStats{
+		"other": {AverageExecutionTime: 2000},
+	}
+	d.store.nodes["runner-snmp-1"].clcRunnerStats = types.CLCRunnersStats{
+		"other": {AverageExecutionTime: 3000},
+	}
+	assert.Equal(t, "runner-1", d.getNodeWithMostHeadroom(generateCostedConfig("http_check", "url: c")))
+	assert.Equal(t, "runner-snmp-1", d.getNodeWithMostHeadroom(generateCostedConfig("snmp", "cluster_check_node_affinity: [\"runner-snmp-*\"]")))
+
+	requireNotLocked(t, d.store)
+}
+
+func TestRebalanceUsingCosts(t *testing.T) {
+	d := newCostAwareDispatcher("runner-1", "runner-2")
+
+	heavy1 := generateCostedConfig("snmp", "ip_address: 10.0.0.1")
+	heavy2 := generateCostedConfig("snmp", "ip_address: 10.0.0.2")
+	light := generateCostedConfig("http_check", "url: foo")
+	failing := generateCostedConfig("postgres", "host: db")
+
+	heavy1ID := dispatchWithCost(d, heavy1, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 5000})
+	heavy2ID := dispatchWithCost(d, heavy2, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 4000})
+	dispatchWithCost(d, light, "runner-2", types.CLCRunnerStats{AverageExecutionTime: 100})
+	dispatchWithCost(d, failing, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 3000, LastExecFailed: true})
+
+	moves := d.rebalance()
+
+	// One of the heavy checks leaves runner-1, the failing check stays there
+	require.Len(t, moves, 1)
+	assert.Equal(t, "runner-1", moves[0].SourceNodeName)
+	assert.Equal(t, "runner-2", moves[0].DestNodeName)
+	assert.Contains(t, []string{heavy1ID, heavy2ID}, moves[0].CheckID)
+
+	assert.Equal(t, "runner-1", d.store.digestToNode[failing.Digest()])
+	assert.Equal(t, "runner-2", d.store.digestToNode[light.Digest()])
+	assert.NotEqual(t, d.store.digestToNode[heavy1.Digest()], d.store.digestToNode[heavy2.Digest()])
+
+	// The runner stats follow the check
+	_, err := d.store.nodes["runner-2"].GetRunnerStats(moves[0].CheckID)
+	assert.NoError(t, err)
+	_, err = d.store.nodes["runner-1"].GetRunnerStats(moves[0].CheckID)
+	assert.Error(t, err)
+
+	// The placement is stable
+	assert.Empty(t, d.rebalance())
+
+	state, err := d.getState()
+	require.NoError(t, err)
+	require.Len(t, state.Placements, 1)
+	assert.Equal(t, "snmp", state.Placements[0].CheckName)
+	assert.Equal(t, "runner-1", state.Placements[0].SourceNodeName)
+	assert.Equal(t, "runner-2", state.Placements[0].DestNodeName)
+	assert.Equal(t, placementReasonRebalance, state.Placements[0].Reason)
+
+	requireNotLocked(t, d.store)
+}
+
+func TestRebalanceUsingCostsCooldown(t *testing.T) {
+	d := newCostAwareDispatcher("runner-1", "runner-2")
+
+	heavy := generateCostedConfig("snmp", "ip_address: 10.0.0.1")
+	light := generateCostedConfig("http_check", "url: foo")
+	dispatchWithCost(d, heavy, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 5000})
+	dispatchWithCost(d, light, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 1000})
+
+	// The heavy check was just moved to runner-1: it is not moved again
+	d.store.lastMoves[heavy.Digest()] = timestampNow()
+
+	moves := d.rebalance()
+	require.Len(t, moves, 1)
+	assert.Equal(t, "runner-2", d.store.digestToNode[light.Digest()])
+	assert.Equal(t, "runner-1", d.store.digestToNode[heavy.Digest()])
+
+	requireNotLocked(t, d.store)
+}
+
+func TestRebalanceUsingCostsPlacementHints(t *testing.T) {
+	d := newCostAwareDispatcher("runner-1", "runner-snmp-1")
+
+	pinned := generateCostedConfig("snmp", "ip_address: 10.0.0.1\ncluster_check_node_affinity: [\"runner-snmp-*\"]")
+	other := generateCostedConfig("http_check", "url: foo")
+	dispatchWithCost(d, pinned, "runner-1", types.CLCRunnerStats{AverageExecutionTime: 100})
+	dispatchWithCost(d, other, "runner-snmp-1", types.CLCRunnerStats{AverageExecutionTime: 100})
+
+	// The load is balanced, but the snmp check runs on a node its hints
+	// don't allow
+	moves := d.rebalance()
+	require.Len(t, moves, 1)
+	assert.Equal(t, "runner-snmp-1", d.store.digestToNode[pinned.Digest()])
+	assert.Equal(t, "runner-snmp-1", d.store.digestToNode[other.Digest()])
+
+	state, err := d.getState()
+	require.NoError(t, err)
+	require.Len(t, state.Placements, 1)
+	assert.Equal(t, placementReasonHints, state.Placements[0].Reason)
+
+	requireNotLocked(t, d.store)
+}
+
+func TestCostAwareDispatching(t *testing.T) {
+	d := newCostAwareDispatcher("runner-1", "runner-2")
+	d.store.nodes["runner-1"].clcRunnerStats = types.CLCRunnersStats{
+		"other": {AverageExecutionTime: 5000},
+	}
+
+	config := generateCostedConfig("snmp", "ip_address: 10.0.0.1")
+	d.add(config)
+
+	assert.Equal(t, "runner-2", d.store.digestToNode[config.Digest()])
+	require.Len(t, d.store.placements, 1)
+	assert.Equal(t, "", d.store.placements[0].SourceNodeName)
+	assert.Equal(t, "runner-2", d.store.placements[0].DestNodeName)
+	assert.Equal(t, placementReasonHeadroom, d.store.placements[0].Reason)
+
+	requireNotLocked(t, d.store)
+}
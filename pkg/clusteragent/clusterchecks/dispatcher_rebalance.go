@@ -176,6 +176,10 @@ func (d *dispatcher) rebalance() []types.RebalanceResponse {
 		rebalancingDuration.Set(time.Since(start).Seconds(), le.JoinLeaderValue)
 	}()
 
+	if d.costAwareDispatching {
+		return d.rebalanceUsingCosts()
+	}
+
 	log.Trace("Trying to rebalance cluster checks distribution if needed")
 	totalAvg, err := d.calculateAvg()
 	if err != nil {
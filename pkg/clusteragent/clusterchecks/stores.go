@@ -10,6 +10,7 @@ package clusterchecks
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -31,6 +32,8 @@ type clusterStore struct {
 	danglingConfigs  map[string]integration.Config            // Configs we could not dispatch to any node
 	endpointsConfigs map[string]map[string]integration.Config // Endpoints configs to be consumed by node agents
 	idToDigest       map[check.ID]string                      // link check IDs to check configs
+	lastMoves        map[string]int64                         // Timestamp of the last move of a config by the cost-aware rebalancing
+	placements       []types.PlacementDecision                // Latest placement decisions of the cost-aware dispatching
 }
 
 func newClusterStore() *clusterStore {
@@ -48,6 +51,8 @@ func (s *clusterStore) reset() {
 	s.danglingConfigs = make(map[string]integration.Config)
 	s.endpointsConfigs = make(map[string]map[string]integration.Config)
 	s.idToDigest = make(map[check.ID]string)
+	s.lastMoves = make(map[string]int64)
+	s.placements = nil
 }
 
 // getNodeStore retrieves the store struct for a given node name, if it exists
@@ -73,6 +78,19 @@ func (s *clusterStore) getOrCreateNodeStore(nodeName, clientIP string) *nodeStor
 	return node
 }
 
+// nodeNames returns the sorted names of the nodes known to the store,
+// excluding the dummy "" node of the dangling configs
+func (s *clusterStore) nodeNames() []string {
+	names := make([]string, 0, len(s.nodes))
+	for name := range s.nodes {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // clearDangling resets the danglingConfigs map to a new empty one
 func (s *clusterStore) clearDangling() {
 	s.danglingConfigs = make(map[string]integration.Config)
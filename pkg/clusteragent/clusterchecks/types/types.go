@@ -49,6 +49,7 @@ type StateResponse struct {
 	Warmup     bool                 `json:"warmup"`
 	Nodes      []StateNodeResponse  `json:"nodes"`
 	Dangling   []integration.Config `json:"dangling"`
+	Placements []PlacementDecision  `json:"placements,omitempty"`
 }
 
 // StateNodeResponse is a chunk of StateResponse
@@ -57,6 +58,18 @@ type StateNodeResponse struct {
 	Configs []integration.Config `json:"configs"`
 }
 
+// PlacementDecision describes why the cost-aware dispatching placed a
+// configuration on a node
+type PlacementDecision struct {
+	Timestamp      int64  `json:"timestamp"`
+	CheckName      string `json:"check_name"`
+	Digest         string `json:"digest"`
+	SourceNodeName string `json:"source_node_name,omitempty"` // Empty for an initial placement
+	DestNodeName   string `json:"dest_node_name"`
+	Reason         string `json:"reason"`
+	ExecutionTime  int    `json:"execution_time"` // Average execution time of the configuration, in ms
+}
+
 // Stats holds statistics for the agent status command
 type Stats struct {
 	// Following
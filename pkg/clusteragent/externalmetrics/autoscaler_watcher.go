@@ -10,6 +10,7 @@ package externalmetrics
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -59,6 +60,17 @@ type externalMetric struct {
 	metricName           string
 	metricLabels         map[string]string
 	autoscalerReferences []string
+	// templateScopes are the autoscalers using a DatadogMetric, used if it's templated
+	templateScopes []templateScope
+	// templateInstance is the desired state of a templated DatadogMetric instance
+	templateInstance *model.DatadogMetricInternal
+}
+
+type templateScope struct {
+	scope     model.AutoscalerScope
+	reference string
+	// err is set if the metric selector of the autoscaler cannot be used to instantiate a template
+	err error
 }
 
 var gvr = schema.GroupVersionResource{
@@ -165,6 +177,7 @@ func (w *AutoscalerWatcher) processAutoscalers() {
 		log.Errorf("Unable to refresh Autoscalers state: %v", err)
 		return
 	}
+	w.addTemplateInstanceReferences(datadogMetricReferences)
 
 	// Go through all DatadogMetric and perform necessary actions
 	for _, datadogMetric := range w.store.GetAll() {
@@ -183,6 +196,11 @@ func (w *AutoscalerWatcher) processAutoscalers() {
 		// Update DatadogMetric active status
 		w.updateDatadogMetricStatus(active, autoscalerReferences, datadogMetric)
 
+		// Update the query of template instances if the templated DatadogMetric changed
+		if externalMetric != nil && externalMetric.templateInstance != nil {
+			w.updateTemplateInstance(*externalMetric.templateInstance, datadogMetric)
+		}
+
 		// Delete autogen DatadogMetrics that haven't been updated for some time
 		w.cleanupAutogenDatadogMetric(active, datadogMetric)
 
@@ -193,7 +211,12 @@ func (w *AutoscalerWatcher) processAutoscalers() {
 	// In `datadogMetricReferences` we now only have existing references that we should create
 	// Or autoscalers referencing inexisting DatadogMetrics (in this case, externalMetric is nil)
 	for datadogMetricID, externalMetric := range datadogMetricReferences {
-		if externalMetric != nil && len(externalMetric.metricName) > 0 {
+		if externalMetric != nil && externalMetric.templateInstance != nil {
+			templateInstance := *externalMetric.templateInstance
+			templateInstance.AutoscalerReferences = strings.Join(externalMetric.autoscalerReferences, autoscalerReferencesSep)
+			log.Infof("Creating DatadogMetric: %s instantiating DatadogMetric: %s, Query: %s", datadogMetricID, templateInstance.ParentID, templateInstance.Query())
+			w.store.Set(datadogMetricID, templateInstance, autoscalerWatcherStoreID)
+		} else if externalMetric != nil && len(externalMetric.metricName) > 0 {
 			autogenQuery := buildDatadogQueryForExternalMetric(externalMetric.metricName, externalMetric.metricLabels)
 			autogenDatadogMetric := model.NewDatadogMetricInternalFromExternalMetric(
 				datadogMetricID,
@@ -225,6 +248,78 @@ func (w *AutoscalerWatcher) updateDatadogMetricStatus(active bool, autoscalerRef
 	}
 }
 
+// addTemplateInstanceReferences adds the references to the instances of the templated DatadogMetrics.
+// A templated DatadogMetric has an instance per namespace, target and metric selector of the autoscalers using it.
+func (w *AutoscalerWatcher) addTemplateInstanceReferences(datadogMetricReferences map[string]*externalMetric) {
+	instanceReferences := make(map[string]*externalMetric)
+	// The first instance of each scope, to detect the autoscalers that can't be told apart when serving external metrics
+	scopeInstances := make(map[string]*externalMetric)
+
+	for datadogMetricID, extMetric := range datadogMetricReferences {
+		if extMetric == nil || len(extMetric.templateScopes) == 0 {
+			continue
+		}
+
+		parent := w.store.Get(datadogMetricID)
+		if parent == nil || !parent.Template {
+			continue
+		}
+
+		// Sort autoscalers so that conflicts are resolved consistently
+		sort.Slice(extMetric.templateScopes, func(i, j int) bool {
+			return extMetric.templateScopes[i].reference < extMetric.templateScopes[j].reference
+		})
+
+		for _, scope := range extMetric.templateScopes {
+			if scope.err != nil {
+				log.Warnf("Unable to instantiate DatadogMetric: %s for autoscaler: %s, err: %v", datadogMetricID, scope.reference, scope.err)
+				continue
+			}
+
+			instanceID := w.autogenNamespace + kubernetesNamespaceSep + getTemplateInstanceDatadogMetricName(datadogMetricID, scope.scope)
+			scopeID := getTemplateScopeID(datadogMetricID, scope.scope.Namespace, scope.scope.Labels)
+			instance, err := model.NewDatadogMetricInternalFromTemplate(instanceID, scopeID, *parent, scope.scope, datadogMetricIDToMetricName(datadogMetricID), scope.reference)
+			if err != nil {
+				log.Warnf("Unable to instantiate DatadogMetric: %s for autoscaler: %s, err: %v", datadogMetricID, scope.reference, err)
+				continue
+			}
+
+			if existing, found := instanceReferences[instanceID]; found {
+				existing.autoscalerReferences = append(existing.autoscalerReferences, scope.reference)
+				continue
+			}
+
+			extInstance := &externalMetric{
+				autoscalerReferences: []string{scope.reference},
+				templateInstance:     &instance,
+			}
+			instanceReferences[instanceID] = extInstance
+
+			if first, found := scopeInstances[scopeID]; !found {
+				scopeInstances[scopeID] = extInstance
+			} else if first.templateInstance.Query() != instance.Query() {
+				log.Warnf("Autoscalers: %s and %s use DatadogMetric: %s with the same namespace and metric selector but resolve different queries, they cannot be served. Please use distinct metric selectors", first.autoscalerReferences[0], scope.reference, datadogMetricID)
+			}
+		}
+	}
+
+	for instanceID, extMetric := range instanceReferences {
+		datadogMetricReferences[instanceID] = extMetric
+	}
+}
+
+func (w *AutoscalerWatcher) updateTemplateInstance(desired model.DatadogMetricInternal, datadogMetric model.DatadogMetricInternal) {
+	if datadogMetric.SpecEquals(desired) {
+		return
+	}
+
+	log.Infof("Updating DatadogMetric: %s instantiating DatadogMetric: %s, Query: %s", datadogMetric.ID, desired.ParentID, desired.Query())
+	if currentDatadogMetric := w.store.LockRead(datadogMetric.ID, false); currentDatadogMetric != nil {
+		currentDatadogMetric.UpdateSpecFrom(desired)
+		w.store.UnlockSet(currentDatadogMetric.ID, *currentDatadogMetric, autoscalerWatcherStoreID)
+	}
+}
+
 func (w *AutoscalerWatcher) cleanupAutogenDatadogMetric(active bool, datadogMetric model.DatadogMetricInternal) {
 	if !active && datadogMetric.Autogen && !datadogMetric.HasBeenUpdatedFor(w.autogenExpirationPeriod) {
 		log.Infof("Flagging old autogen DatadogMetric: %s for deletion - last update: %v", datadogMetric.ID, datadogMetric.UpdateTime)
@@ -239,8 +334,9 @@ func (w *AutoscalerWatcher) getAutoscalerReferences() (map[string]*externalMetri
 	datadogMetricReferences := make(map[string]*externalMetric, w.store.Count())
 
 	// Helper func to avoid some copy paste between HPA and WPA
-	addAutoscalerReference := func(datadogMetricID, autoscalerReference, metricName string, labels map[string]string) {
-		if len(datadogMetricID) == 0 {
+	addAutoscalerReference := func(datadogMetricID, autoscalerReference, metricName string, labels map[string]string, scope templateScope) {
+		isDatadogMetric := len(datadogMetricID) > 0
+		if !isDatadogMetric {
 			datadogMetricName := getAutogenDatadogMetricNameFromLabels(metricName, labels)
 			datadogMetricID = w.autogenNamespace + kubernetesNamespaceSep + datadogMetricName
 		}
@@ -256,6 +352,11 @@ func (w *AutoscalerWatcher) getAutoscalerReferences() (map[string]*externalMetri
 		} else {
 			extMetric.autoscalerReferences = append(extMetric.autoscalerReferences, autoscalerReference)
 		}
+
+		// Keep track of the autoscalers using a DatadogMetric in case it's templated
+		if isDatadogMetric {
+			extMetric.templateScopes = append(extMetric.templateScopes, scope)
+		}
 	}
 
 	if w.autoscalerLister != nil {
@@ -292,7 +393,7 @@ func (w *AutoscalerWatcher) getAutoscalerReferences() (map[string]*externalMetri
 				ref := buildAutoscalerReference(autoscalerWPAKindKey, wpa.ObjectMeta)
 				ddMetricID, metricName, labels, ok := w.extractAutoscalerReference(external.MetricName, external.MetricSelector)
 				if ok {
-					addAutoscalerReference(ddMetricID, ref, metricName, labels)
+					addAutoscalerReference(ddMetricID, ref, metricName, labels, buildTemplateScope(ref, wpa.ObjectMeta, wpa.Spec.ScaleTargetRef.Kind, wpa.Spec.ScaleTargetRef.Name, external.MetricSelector))
 				}
 			}
 		}
@@ -306,6 +407,7 @@ type addAutoscalerReferenceFn func(
 	autoscalerReference string,
 	metricName string,
 	labels map[string]string,
+	scope templateScope,
 )
 
 func (w *AutoscalerWatcher) processHPAReference(addAutoscalerReference addAutoscalerReferenceFn, obj runtime.Object) {
@@ -335,7 +437,7 @@ func (w *AutoscalerWatcher) processHPAv2beta1Reference(addAutoscalerReference ad
 		ref := buildAutoscalerReference(autoscalerHPAKindKey, hpa.ObjectMeta)
 		ddMetricID, metricName, labels, ok := w.extractAutoscalerReference(external.MetricName, external.MetricSelector)
 		if ok {
-			addAutoscalerReference(ddMetricID, ref, metricName, labels)
+			addAutoscalerReference(ddMetricID, ref, metricName, labels, buildTemplateScope(ref, hpa.ObjectMeta, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name, external.MetricSelector))
 		}
 	}
 }
@@ -354,7 +456,7 @@ func (w *AutoscalerWatcher) processHPAv2beta2Reference(addAutoscalerReference ad
 		ref := buildAutoscalerReference(autoscalerHPAKindKey, hpa.ObjectMeta)
 		ddMetricID, metricName, labels, ok := w.extractAutoscalerReference(external.Metric.Name, external.Metric.Selector)
 		if ok {
-			addAutoscalerReference(ddMetricID, ref, metricName, labels)
+			addAutoscalerReference(ddMetricID, ref, metricName, labels, buildTemplateScope(ref, hpa.ObjectMeta, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name, external.Metric.Selector))
 		}
 	}
 }
@@ -373,7 +475,7 @@ func (w *AutoscalerWatcher) processHPAv2Reference(addAutoscalerReference addAuto
 		ref := buildAutoscalerReference(autoscalerHPAKindKey, hpa.ObjectMeta)
 		ddMetricID, metricName, labels, ok := w.extractAutoscalerReference(external.Metric.Name, external.Metric.Selector)
 		if ok {
-			addAutoscalerReference(ddMetricID, ref, metricName, labels)
+			addAutoscalerReference(ddMetricID, ref, metricName, labels, buildTemplateScope(ref, hpa.ObjectMeta, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name, external.Metric.Selector))
 		}
 	}
 }
@@ -394,9 +496,10 @@ func (w *AutoscalerWatcher) extractAutoscalerReference(
 		// We were not able to parse name as DatadogMetric ID.
 		// It will be considered as a normal metricName +
 		// labels
-		var labels map[string]string
-		if externalMetricSelector != nil {
-			labels = externalMetricSelector.MatchLabels
+		labels, err := labelSelectorToLabels(externalMetricSelector)
+		if err != nil {
+			log.Warnf("Ignoring ExternalMetric: %s, err: %v", externalMetricName, err)
+			return "", "", nil, false
 		}
 
 		return "", externalMetricName, labels, true
@@ -408,3 +511,17 @@ func (w *AutoscalerWatcher) extractAutoscalerReference(
 func buildAutoscalerReference(kind string, obj metav1.ObjectMeta) string {
 	return kind + autoscalerReferencesKindSep + obj.Namespace + kubernetesNamespaceSep + obj.Name
 }
+
+func buildTemplateScope(reference string, obj metav1.ObjectMeta, targetKind, targetName string, externalMetricSelector *metav1.LabelSelector) templateScope {
+	labels, err := labelSelectorToLabels(externalMetricSelector)
+	return templateScope{
+		scope: model.AutoscalerScope{
+			Namespace:  obj.Namespace,
+			TargetKind: targetKind,
+			TargetName: targetName,
+			Labels:     labels,
+		},
+		reference: reference,
+		err:       err,
+	}
+}
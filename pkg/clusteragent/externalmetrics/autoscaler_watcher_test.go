@@ -23,6 +23,7 @@ import (
 	kube_informer "k8s.io/client-go/informers"
 	kube_fake "k8s.io/client-go/kubernetes/fake"
 
+	datadoghq "github.com/DataDog/datadog-operator/apis/datadoghq/v1alpha1"
 	"github.com/DataDog/watermarkpodautoscaler/api/v1alpha1"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
@@ -347,6 +348,111 @@ func TestCreateAutogenDatadogMetrics(t *testing.T) {
 	compareDatadogMetricInternal(t, &ddm, f.store.Get("default/dcaautogen-b6ea72b610c00aba6791b5eca1912e68dc7412"))
 }
 
+func newFakeHPAWithTarget(ns, name, targetName, metricName string, labels map[string]string) *autoscaler.HorizontalPodAutoscaler {
+	external := &autoscaler.ExternalMetricSource{
+		MetricName: metricName,
+	}
+	if labels != nil {
+		external.MetricSelector = &metav1.LabelSelector{MatchLabels: labels}
+	}
+
+	hpa := newFakeHorizontalPodAutoscaler(ns, name, []autoscaler.MetricSpec{
+		{
+			Type:     autoscaler.ExternalMetricSourceType,
+			External: external,
+		},
+	})
+	hpa.Spec.ScaleTargetRef = autoscaler.CrossVersionObjectReference{Kind: "Deployment", Name: targetName}
+	return hpa
+}
+
+func TestCreateTemplateInstances(t *testing.T) {
+	f := newAutoscalerFixture(t)
+
+	f.hpaLister = []*autoscaler.HorizontalPodAutoscaler{
+		newFakeHPAWithTarget("ns0", "hpa0", "app0", "datadogmetric@default:requests", map[string]string{"team": "a"}),
+		newFakeHPAWithTarget("ns1", "hpa1", "app1", "datadogmetric@default:requests", map[string]string{"team": "b"}),
+		// Same namespace and selector as hpa0 but another target
+		newFakeHPAWithTarget("ns0", "hpa2", "app2", "datadogmetric@default:requests", map[string]string{"team": "a"}),
+		// The label used in the query is missing
+		newFakeHPAWithTarget("ns1", "hpa3", "app3", "datadogmetric@default:requests", nil),
+		// The selector cannot be translated to labels
+		newFakeHPAWithTarget("ns1", "hpa4", "app4", "datadogmetric@default:requests", nil),
+	}
+	f.hpaLister[4].Spec.Metrics[0].External.MetricSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "team", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a"}},
+		},
+	}
+
+	parent := model.NewDatadogMetricInternal("default/requests", datadoghq.DatadogMetric{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"external-metrics.datadoghq.com/fallback-value": "5"},
+		},
+		Spec: datadoghq.DatadogMetricSpec{
+			Query:  "sum:requests{kube_namespace:%%target_namespace%%,kube_deployment:%%target_name%%,team:%%label_team%%}",
+			MaxAge: metav1.Duration{Duration: 5 * time.Minute},
+		},
+	})
+	assert.True(t, parent.Template)
+	f.store.Set(parent.ID, parent, "utest")
+
+	f.runWatcherUpdate()
+
+	instance0ID := "default/" + getTemplateInstanceDatadogMetricName("default/requests", model.AutoscalerScope{Namespace: "ns0", TargetKind: "Deployment", TargetName: "app0", Labels: map[string]string{"team": "a"}})
+	instance1ID := "default/" + getTemplateInstanceDatadogMetricName("default/requests", model.AutoscalerScope{Namespace: "ns1", TargetKind: "Deployment", TargetName: "app1", Labels: map[string]string{"team": "b"}})
+	instance2ID := "default/" + getTemplateInstanceDatadogMetricName("default/requests", model.AutoscalerScope{Namespace: "ns0", TargetKind: "Deployment", TargetName: "app2", Labels: map[string]string{"team": "a"}})
+
+	assert.Equal(t, 4, f.store.Count())
+	assert.True(t, f.store.Get("default/requests").Active)
+
+	instance0 := f.store.Get(instance0ID)
+	if assert.NotNil(t, instance0) {
+		assert.Equal(t, "sum:requests{kube_namespace:ns0,kube_deployment:app0,team:a}", instance0.Query())
+		assert.Equal(t, "default/requests", instance0.ParentID)
+		assert.Equal(t, "datadogmetric@default:requests", instance0.ExternalMetricName)
+		assert.Equal(t, "hpa:ns0/hpa0", instance0.AutoscalerReferences)
+		assert.Equal(t, 5*time.Minute, instance0.MaxAge)
+		assert.Equal(t, 5.0, *instance0.FallbackValue)
+		assert.True(t, instance0.Active)
+		assert.True(t, instance0.Autogen)
+		assert.False(t, instance0.Template)
+	}
+
+	instance1 := f.store.Get(instance1ID)
+	if assert.NotNil(t, instance1) {
+		assert.Equal(t, "sum:requests{kube_namespace:ns1,kube_deployment:app1,team:b}", instance1.Query())
+		assert.Equal(t, "hpa:ns1/hpa1", instance1.AutoscalerReferences)
+	}
+
+	instance2 := f.store.Get(instance2ID)
+	if assert.NotNil(t, instance2) {
+		assert.Equal(t, "sum:requests{kube_namespace:ns0,kube_deployment:app2,team:a}", instance2.Query())
+		assert.Equal(t, "hpa:ns0/hpa2", instance2.AutoscalerReferences)
+		assert.Equal(t, instance0.TemplateScopeID, instance2.TemplateScopeID)
+	}
+
+	// Updating the templated DatadogMetric updates its instances
+	parent.UpdateFrom(datadoghq.DatadogMetric{
+		Spec: datadoghq.DatadogMetricSpec{
+			Query: "avg:requests{kube_namespace:%%target_namespace%%,kube_deployment:%%target_name%%,team:%%label_team%%}",
+		},
+	})
+	f.store.Set(parent.ID, parent, "utest")
+
+	f.kubeObjects = []runtime.Object{}
+	f.runWatcherUpdate()
+
+	assert.Equal(t, 4, f.store.Count())
+	instance0 = f.store.Get(instance0ID)
+	if assert.NotNil(t, instance0) {
+		assert.Equal(t, "avg:requests{kube_namespace:ns0,kube_deployment:app0,team:a}", instance0.Query())
+		assert.Equal(t, time.Duration(0), instance0.MaxAge)
+		assert.Nil(t, instance0.FallbackValue)
+		assert.False(t, instance0.Valid)
+	}
+}
+
 func TestDisableDatadogMetricAutogen(t *testing.T) {
 	f := newAutoscalerFixture(t)
 
@@ -241,7 +241,17 @@ func (c *DatadogMetricController) syncDatadogMetric(ns, name, datadogMetricKey s
 		return c.deleteDatadogMetric(ns, name)
 	}
 
-	datadogMetricInternal.UpdateFrom(*datadogMetric)
+	// Template instances are generated from their templated DatadogMetric
+	// Spec source of truth is our local store
+	if datadogMetricInternal.ParentID != "" {
+		if !datadogMetricInternal.SpecEquals(model.NewDatadogMetricInternal(datadogMetricKey, *datadogMetric)) {
+			err := c.updateDatadogMetricSpec(ns, name, datadogMetricInternal, datadogMetric)
+			c.store.Unlock(datadogMetricKey)
+			return err
+		}
+	} else {
+		datadogMetricInternal.UpdateFrom(*datadogMetric)
+	}
 	defer c.store.UnlockSet(datadogMetricInternal.ID, *datadogMetricInternal, ddmControllerStoreID)
 
 	if datadogMetricInternal.IsNewerThan(datadogMetric.Status) {
@@ -259,12 +269,11 @@ func (c *DatadogMetricController) createDatadogMetric(ns, name string, datadogMe
 	datadogMetric := &datadoghq.DatadogMetric{
 		TypeMeta: metaDDM,
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: ns,
-			Name:      name,
-		},
-		Spec: datadoghq.DatadogMetricSpec{
-			Query: datadogMetricInternal.RawQuery(),
+			Namespace:   ns,
+			Name:        name,
+			Annotations: datadogMetricInternal.BuildAnnotations(),
 		},
+		Spec:   buildDatadogMetricSpec(datadogMetricInternal),
 		Status: *datadogMetricInternal.BuildStatus(nil),
 	}
 
@@ -290,6 +299,30 @@ func (c *DatadogMetricController) createDatadogMetric(ns, name string, datadogMe
 	return nil
 }
 
+func (c *DatadogMetricController) updateDatadogMetricSpec(ns, name string, datadogMetricInternal *model.DatadogMetricInternal, datadogMetric *datadoghq.DatadogMetric) error {
+	log.Infof("Updating spec of DatadogMetric: %s/%s, Query: %s", ns, name, datadogMetricInternal.RawQuery())
+	if len(datadogMetricInternal.ExternalMetricName) == 0 {
+		return fmt.Errorf("Unable to update autogen DatadogMetric %s/%s without ExternalMetricName", ns, name)
+	}
+
+	updatedDatadogMetric := datadogMetric.DeepCopy()
+	updatedDatadogMetric.TypeMeta = metaDDM
+	updatedDatadogMetric.Spec = buildDatadogMetricSpec(datadogMetricInternal)
+	updatedDatadogMetric.Spec.ExternalMetricName = datadogMetricInternal.ExternalMetricName
+	updatedDatadogMetric.Annotations = datadogMetricInternal.BuildAnnotations()
+
+	datadogMetricObj := &unstructured.Unstructured{}
+	if err := UnstructuredFromDDM(updatedDatadogMetric, datadogMetricObj); err != nil {
+		return err
+	}
+	_, err := c.clientSet.Resource(gvrDDM).Namespace(ns).Update(context.TODO(), datadogMetricObj, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to update DatadogMetric: %s/%s, err: %v", ns, name, err)
+	}
+
+	return nil
+}
+
 func (c *DatadogMetricController) updateDatadogMetric(ns, name string, datadogMetricInternal *model.DatadogMetricInternal, datadogMetric *datadoghq.DatadogMetric) error {
 	newStatus := datadogMetricInternal.BuildStatus(&datadogMetric.Status)
 	if newStatus != nil {
@@ -328,3 +361,11 @@ func (c *DatadogMetricController) deleteDatadogMetric(ns, name string) error {
 	unsetDatadogMetricTelemetry(ns, name)
 	return nil
 }
+
+func buildDatadogMetricSpec(datadogMetricInternal *model.DatadogMetricInternal) datadoghq.DatadogMetricSpec {
+	return datadoghq.DatadogMetricSpec{
+		Query:      datadogMetricInternal.RawQuery(),
+		MaxAge:     metav1.Duration{Duration: datadogMetricInternal.MaxAge},
+		TimeWindow: metav1.Duration{Duration: datadogMetricInternal.TimeWindow},
+	}
+}
@@ -761,3 +761,48 @@ func TestFollower(t *testing.T) {
 	ddm.SetQueries("metric query1")
 	assert.Equal(t, &ddm, f.store.Get("default/autogen-1"))
 }
+
+// Scenario: the leader updated the query of a template instance, we check that the spec of the
+// Kubernetes object is updated from the local store
+func TestLeaderUpdateTemplateInstanceSpec(t *testing.T) {
+	f := newFixture(t)
+
+	instanceObj, instance := newFakeDatadogMetric("default", "dcaautogen-instance", "avg:requests{team:a}", datadoghq.DatadogMetricStatus{})
+	instance.Annotations = map[string]string{"external-metrics.datadoghq.com/template": "ns/parent"}
+	instance.Spec.ExternalMetricName = "datadogmetric@ns:parent"
+	if err := UnstructuredFromDDM(instance, instanceObj); err != nil {
+		t.Fatalf("Failed to construct unstructured DDM: %v", err)
+	}
+	f.datadogMetricLister = append(f.datadogMetricLister, instanceObj)
+	f.objects = append(f.objects, instanceObj)
+
+	fallbackValue := 3.0
+	ddm := model.DatadogMetricInternal{
+		ID:                 "default/dcaautogen-instance",
+		ParentID:           "ns/parent",
+		Active:             true,
+		Autogen:            true,
+		ExternalMetricName: "datadogmetric@ns:parent",
+		FallbackValue:      &fallbackValue,
+		UpdateTime:         time.Now(),
+	}
+	ddm.SetQueries("sum:requests{team:a}")
+	f.store.Set(ddm.ID, ddm, "utest")
+
+	expected := instance.DeepCopy()
+	expected.Spec.Query = "sum:requests{team:a}"
+	expected.Annotations = map[string]string{
+		"external-metrics.datadoghq.com/template":       "ns/parent",
+		"external-metrics.datadoghq.com/fallback-value": "3",
+	}
+	expectedObj := &unstructured.Unstructured{}
+	if err := UnstructuredFromDDM(expected, expectedObj); err != nil {
+		t.Fatalf("Failed to construct unstructured DDM: %v", err)
+	}
+
+	f.actions = append(f.actions, core.NewUpdateAction(gvrDDM, "default", expectedObj))
+	f.runControllerSync(true, "default/dcaautogen-instance", nil)
+
+	// The local store is the source of truth
+	assert.Equal(t, "sum:requests{team:a}", f.store.Get("default/dcaautogen-instance").Query())
+}
@@ -10,6 +10,7 @@ package externalmetrics
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
@@ -64,7 +65,10 @@ func (mr *MetricsRetriever) Run(stopCh <-chan struct{}) {
 
 func (mr *MetricsRetriever) retrieveMetricsValues() {
 	// We only update active DatadogMetrics
-	datadogMetrics := mr.store.GetFiltered(func(datadogMetric model.DatadogMetricInternal) bool { return datadogMetric.Active })
+	// Templated DatadogMetrics are not queried, their instances are
+	datadogMetrics := mr.store.GetFiltered(func(datadogMetric model.DatadogMetricInternal) bool {
+		return datadogMetric.Active && !datadogMetric.Template
+	})
 	if len(datadogMetrics) == 0 {
 		log.Debugf("No active DatadogMetric, nothing to refresh")
 		return
@@ -139,6 +143,61 @@ func (mr *MetricsRetriever) retrieveMetricsValues() {
 
 		mr.store.UnlockSet(datadogMetric.ID, *datadogMetricFromStore, metricRetrieverStoreID)
 	}
+
+	mr.updateTemplatedMetrics(currentTime)
+}
+
+// updateTemplatedMetrics reflects the state of the instances of the templated DatadogMetrics in their status
+func (mr *MetricsRetriever) updateTemplatedMetrics(currentTime time.Time) {
+	templatedMetrics := mr.store.GetFiltered(func(datadogMetric model.DatadogMetricInternal) bool {
+		return datadogMetric.Active && datadogMetric.Template
+	})
+	if len(templatedMetrics) == 0 {
+		return
+	}
+
+	instancesByParent := make(map[string][]model.DatadogMetricInternal, len(templatedMetrics))
+	for _, instance := range mr.store.GetFiltered(func(datadogMetric model.DatadogMetricInternal) bool {
+		return datadogMetric.Active && datadogMetric.ParentID != ""
+	}) {
+		instancesByParent[instance.ParentID] = append(instancesByParent[instance.ParentID], instance)
+	}
+
+	for _, templatedMetric := range templatedMetrics {
+		datadogMetricFromStore := mr.store.LockRead(templatedMetric.ID, false)
+		if datadogMetricFromStore == nil {
+			continue
+		}
+
+		instances := instancesByParent[templatedMetric.ID]
+		sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+
+		var invalidCount int
+		var firstError error
+		var oldestDataTime time.Time
+		for _, instance := range instances {
+			if !instance.Valid {
+				invalidCount++
+				if firstError == nil {
+					firstError = fmt.Errorf("%s: %v", instance.ID, instance.Error)
+				}
+				continue
+			}
+			if oldestDataTime.IsZero() || instance.DataTime.Before(oldestDataTime) {
+				oldestDataTime = instance.DataTime
+			}
+		}
+
+		datadogMetricFromStore.Valid = len(instances) > 0 && invalidCount == 0
+		datadogMetricFromStore.Error = nil
+		if invalidCount > 0 {
+			datadogMetricFromStore.Error = fmt.Errorf("%d/%d instances are invalid, first one: %v", invalidCount, len(instances), firstError)
+		}
+		datadogMetricFromStore.DataTime = oldestDataTime
+		datadogMetricFromStore.UpdateTime = currentTime
+
+		mr.store.UnlockSet(templatedMetric.ID, *datadogMetricFromStore, metricRetrieverStoreID)
+	}
 }
 
 func MaybeAdjustTimeWindowForQuery(timeWindow time.Duration) time.Duration {
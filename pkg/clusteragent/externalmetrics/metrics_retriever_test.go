@@ -176,6 +176,101 @@ func TestRetrieveMetricsBasic(t *testing.T) {
 	}
 }
 
+func TestRetrieveMetricsTemplated(t *testing.T) {
+	defaultTestTime := time.Now().Add(time.Duration(-1) * time.Second).UTC().Truncate(time.Second)
+	defaultPreviousUpdateTime := time.Now().Add(time.Duration(-11) * time.Second).UTC().Truncate(time.Second)
+
+	fixtures := []metricsFixture{
+		{
+			maxAge: 30,
+			desc:   "Test templated DatadogMetric reflects the state of its instances",
+			storeContent: []ddmWithQuery{
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "ns/parent",
+						Active:   true,
+						Template: true,
+						Valid:    false,
+					},
+					query: "query{team:%%label_team%%}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "default/instance0",
+						ParentID: "ns/parent",
+						Active:   true,
+						DataTime: defaultPreviousUpdateTime,
+					},
+					query: "query{team:a}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "default/instance1",
+						ParentID: "ns/parent",
+						Active:   true,
+						DataTime: defaultPreviousUpdateTime,
+					},
+					query: "query{team:b}",
+				},
+			},
+			queryResults: map[string]autoscalers.Point{
+				"query{team:a}": {
+					Value:     10.0,
+					Timestamp: defaultTestTime.Unix(),
+					Valid:     true,
+				},
+				"query{team:b}": {
+					Value:     0,
+					Timestamp: defaultTestTime.Unix(),
+					Valid:     false,
+					Error:     errors.New("some err"),
+				},
+			},
+			expected: []ddmWithQuery{
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "ns/parent",
+						Active:   true,
+						Template: true,
+						Valid:    false,
+						DataTime: defaultTestTime,
+						Error:    fmt.Errorf("1/2 instances are invalid, first one: default/instance1: some err, query was: query{team:b}"),
+					},
+					query: "query{team:%%label_team%%}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "default/instance0",
+						ParentID: "ns/parent",
+						Active:   true,
+						Valid:    true,
+						Value:    10.0,
+						DataTime: defaultTestTime,
+					},
+					query: "query{team:a}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "default/instance1",
+						ParentID: "ns/parent",
+						Active:   true,
+						Valid:    false,
+						DataTime: defaultPreviousUpdateTime,
+						Error:    fmt.Errorf("some err, query was: query{team:b}"),
+					},
+					query: "query{team:b}",
+				},
+			},
+		},
+	}
+
+	for i, fixture := range fixtures {
+		t.Run(fmt.Sprintf("#%d %s", i, fixture.desc), func(t *testing.T) {
+			fixture.run(t, defaultTestTime)
+		})
+	}
+}
+
 func TestRetrieveMetricsErrorCases(t *testing.T) {
 	// At the end we'll check that update time has been updated, giving 10s to run the tests
 	// We truncate down to the second as that's the granularity we have from backend
@@ -28,6 +28,10 @@ import (
 const (
 	DatadogMetricErrorConditionReason string = "Unable to fetch data from Datadog"
 	alwaysActiveAnnotation            string = "external-metrics.datadoghq.com/always-active"
+	fallbackValueAnnotation           string = "external-metrics.datadoghq.com/fallback-value"
+	stalenessThresholdAnnotation      string = "external-metrics.datadoghq.com/staleness-threshold"
+	templateParentAnnotation          string = "external-metrics.datadoghq.com/template"
+	templateScopeAnnotation           string = "external-metrics.datadoghq.com/template-scope"
 )
 
 // DatadogMetricInternal is a flatten, easier to use, representation of `DatadogMetric` CRD
@@ -48,6 +52,18 @@ type DatadogMetricInternal struct {
 	Error                error
 	MaxAge               time.Duration
 	TimeWindow           time.Duration
+	// Template is true if the query has template variables resolved per autoscaler.
+	// Such DatadogMetrics are not queried, their instances are.
+	Template bool
+	// ParentID is the ID of the templated DatadogMetric this DatadogMetric is an instance of
+	ParentID string
+	// TemplateScopeID identifies the namespace and metric selector of the autoscalers using a template
+	// instance, which are the only autoscaler information available when serving external metrics
+	TemplateScopeID string
+	// FallbackValue is served to autoscalers instead of an error when the value is invalid or stale
+	FallbackValue *float64
+	// StalenessThreshold is the maximum age of the value served to autoscalers, 0 means no limit
+	StalenessThreshold time.Duration
 }
 
 // NewDatadogMetricInternal returns a `DatadogMetricInternal` object from a `DatadogMetric` CRD Object
@@ -58,13 +74,15 @@ func NewDatadogMetricInternal(id string, datadogMetric datadoghq.DatadogMetric)
 		query:                datadogMetric.Spec.Query,
 		Valid:                false,
 		Active:               false,
-		AlwaysActive:         hasForceActiveAnnotation(datadogMetric),
 		Deleted:              false,
 		Autogen:              false,
 		AutoscalerReferences: datadogMetric.Status.AutoscalerReferences,
 		MaxAge:               datadogMetric.Spec.MaxAge.Duration,
 		TimeWindow:           datadogMetric.Spec.TimeWindow.Duration,
+		ParentID:             datadogMetric.Annotations[templateParentAnnotation],
+		TemplateScopeID:      datadogMetric.Annotations[templateScopeAnnotation],
 	}
+	internal.setAnnotationSettings(datadogMetric)
 
 	if len(datadogMetric.Spec.ExternalMetricName) > 0 {
 		internal.Autogen = true
@@ -118,6 +136,54 @@ func hasForceActiveAnnotation(metric datadoghq.DatadogMetric) bool {
 	return false
 }
 
+// setAnnotationSettings sets the settings that are not part of the `DatadogMetric` spec
+func (d *DatadogMetricInternal) setAnnotationSettings(metric datadoghq.DatadogMetric) {
+	d.AlwaysActive = hasForceActiveAnnotation(metric)
+
+	d.FallbackValue = nil
+	if value, found := metric.Annotations[fallbackValueAnnotation]; found {
+		fallbackValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Warnf("Unable to parse value from %s annotation of DatadogMetric %s: '%s'", fallbackValueAnnotation, d.ID, value)
+		} else {
+			d.FallbackValue = &fallbackValue
+		}
+	}
+
+	d.StalenessThreshold = 0
+	if value, found := metric.Annotations[stalenessThresholdAnnotation]; found {
+		threshold, err := time.ParseDuration(value)
+		if err != nil || threshold < 0 {
+			log.Warnf("Unable to parse value from %s annotation of DatadogMetric %s: '%s'", stalenessThresholdAnnotation, d.ID, value)
+		} else {
+			d.StalenessThreshold = threshold
+		}
+	}
+}
+
+// BuildAnnotations returns the annotations holding the settings that are not part of the `DatadogMetric` spec
+// It is used to create autogen DatadogMetrics, nil is returned if there are none.
+func (d *DatadogMetricInternal) BuildAnnotations() map[string]string {
+	annotations := make(map[string]string)
+	if d.ParentID != "" {
+		annotations[templateParentAnnotation] = d.ParentID
+	}
+	if d.TemplateScopeID != "" {
+		annotations[templateScopeAnnotation] = d.TemplateScopeID
+	}
+	if d.FallbackValue != nil {
+		annotations[fallbackValueAnnotation] = formatDatadogMetricValue(*d.FallbackValue)
+	}
+	if d.StalenessThreshold > 0 {
+		annotations[stalenessThresholdAnnotation] = d.StalenessThreshold.String()
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
+
 // NewDatadogMetricInternalFromExternalMetric returns a `DatadogMetricInternal` object
 // that is auto-generated from a standard ExternalMetric query (non-DatadogMetric reference)
 func NewDatadogMetricInternalFromExternalMetric(id, query, metricName, autoscalerReference string) DatadogMetricInternal {
@@ -158,7 +224,7 @@ func (d *DatadogMetricInternal) UpdateFrom(current datadoghq.DatadogMetric) {
 	d.query = currentSpec.Query
 	d.MaxAge = currentSpec.MaxAge.Duration
 	d.TimeWindow = currentSpec.TimeWindow.Duration
-	d.AlwaysActive = hasForceActiveAnnotation(current)
+	d.setAnnotationSettings(current)
 }
 
 // GetTimeWindow gets the time window for the metric, if unset defaults to max age.
@@ -236,12 +302,22 @@ func (d *DatadogMetricInternal) BuildStatus(currentStatus *datadoghq.DatadogMetr
 }
 
 // ToExternalMetricFormat returns the current DatadogMetric in the format used by Kubernetes
+// If the value is invalid or stale, the fallback value is returned if it's set.
 func (d *DatadogMetricInternal) ToExternalMetricFormat(externalMetricName string) (*external_metrics.ExternalMetricValue, error) {
-	if !d.Valid {
-		return nil, fmt.Errorf("DatadogMetric is invalid, err: %v", d.Error)
+	value := d.Value
+	dataTime := d.DataTime
+
+	if err := d.servingError(time.Now().UTC()); err != nil {
+		if d.FallbackValue == nil {
+			return nil, err
+		}
+
+		log.Debugf("Serving fallback value %v for DatadogMetric %s: %v", *d.FallbackValue, d.ID, err)
+		value = *d.FallbackValue
+		dataTime = time.Now().UTC()
 	}
 
-	quantity, err := resource.ParseQuantity(fmt.Sprintf("%v", d.Value))
+	quantity, err := resource.ParseQuantity(fmt.Sprintf("%v", value))
 	if err != nil {
 		return nil, err
 	}
@@ -250,10 +326,23 @@ func (d *DatadogMetricInternal) ToExternalMetricFormat(externalMetricName string
 		MetricName:   externalMetricName,
 		MetricLabels: nil,
 		Value:        quantity,
-		Timestamp:    metav1.NewTime(d.DataTime),
+		Timestamp:    metav1.NewTime(dataTime),
 	}, nil
 }
 
+// servingError returns why the value can't be served to autoscalers, nil if it can
+func (d *DatadogMetricInternal) servingError(now time.Time) error {
+	if !d.Valid {
+		return fmt.Errorf("DatadogMetric is invalid, err: %v", d.Error)
+	}
+
+	if d.StalenessThreshold > 0 && now.Sub(d.DataTime) > d.StalenessThreshold {
+		return fmt.Errorf("DatadogMetric is stale, last value is from %v, staleness threshold is %v", d.DataTime, d.StalenessThreshold)
+	}
+
+	return nil
+}
+
 func (d *DatadogMetricInternal) newCondition(status bool, updateTime metav1.Time, conditionType datadoghq.DatadogMetricConditionType, prevCondition *datadoghq.DatadogMetricCondition) datadoghq.DatadogMetricCondition {
 	condition := datadoghq.DatadogMetricCondition{
 		Type:           conditionType,
@@ -285,6 +374,7 @@ func (d *DatadogMetricInternal) resolveQuery(query string) {
 		d.resolvedQuery = nil
 		return
 	}
+	d.Template = hasAutoscalerTemplateVariables(query)
 	if resolvedQuery != "" {
 		log.Infof("DatadogMetric query %q was resolved successfully, new query: %q", query, resolvedQuery)
 		d.resolvedQuery = &resolvedQuery
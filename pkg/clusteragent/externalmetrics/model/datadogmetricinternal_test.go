@@ -296,3 +296,94 @@ func TestDatadogMetricInternal_resolveQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestDatadogMetricInternal_AnnotationSettings(t *testing.T) {
+	ddm := datadoghq.DatadogMetric{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "instance",
+			Namespace: "default",
+			Annotations: map[string]string{
+				templateParentAnnotation:     "ns/parent",
+				templateScopeAnnotation:      "scope",
+				fallbackValueAnnotation:      "2.5",
+				stalenessThresholdAnnotation: "10m0s",
+			},
+		},
+		Spec: datadoghq.DatadogMetricSpec{Query: simpleQuery},
+	}
+
+	internal := NewDatadogMetricInternal("default/instance", ddm)
+	assert.Equal(t, "ns/parent", internal.ParentID)
+	assert.Equal(t, "scope", internal.TemplateScopeID)
+	if assert.NotNil(t, internal.FallbackValue) {
+		assert.Equal(t, 2.5, *internal.FallbackValue)
+	}
+	assert.Equal(t, 10*time.Minute, internal.StalenessThreshold)
+	assert.Equal(t, ddm.Annotations, internal.BuildAnnotations())
+
+	// Invalid values are ignored
+	ddm.Annotations[fallbackValueAnnotation] = "foo"
+	ddm.Annotations[stalenessThresholdAnnotation] = "-1m"
+	internal.UpdateFrom(ddm)
+	assert.Nil(t, internal.FallbackValue)
+	assert.Equal(t, time.Duration(0), internal.StalenessThreshold)
+	assert.Equal(t, map[string]string{templateParentAnnotation: "ns/parent", templateScopeAnnotation: "scope"}, internal.BuildAnnotations())
+
+	assert.Nil(t, (&DatadogMetricInternal{}).BuildAnnotations())
+}
+
+func TestDatadogMetricInternal_ToExternalMetricFormat(t *testing.T) {
+	fallbackValue := 5.0
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name          string
+		ddmInternal   DatadogMetricInternal
+		expectedValue float64
+		expectedErr   bool
+	}{
+		{
+			name:          "valid value",
+			ddmInternal:   DatadogMetricInternal{Valid: true, Value: 10, DataTime: now},
+			expectedValue: 10,
+		},
+		{
+			name:        "invalid value without fallback",
+			ddmInternal: DatadogMetricInternal{Valid: false, Value: 10, DataTime: now, Error: errors.New("query failed")},
+			expectedErr: true,
+		},
+		{
+			name:          "invalid value with fallback",
+			ddmInternal:   DatadogMetricInternal{Valid: false, Value: 10, DataTime: now, Error: errors.New("query failed"), FallbackValue: &fallbackValue},
+			expectedValue: 5,
+		},
+		{
+			name:        "stale value without fallback",
+			ddmInternal: DatadogMetricInternal{Valid: true, Value: 10, DataTime: now.Add(-time.Hour), StalenessThreshold: 10 * time.Minute},
+			expectedErr: true,
+		},
+		{
+			name:          "stale value with fallback",
+			ddmInternal:   DatadogMetricInternal{Valid: true, Value: 10, DataTime: now.Add(-time.Hour), StalenessThreshold: 10 * time.Minute, FallbackValue: &fallbackValue},
+			expectedValue: 5,
+		},
+		{
+			name:          "value within staleness threshold",
+			ddmInternal:   DatadogMetricInternal{Valid: true, Value: 10, DataTime: now.Add(-time.Minute), StalenessThreshold: 10 * time.Minute, FallbackValue: &fallbackValue},
+			expectedValue: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.ddmInternal.ToExternalMetricFormat("metric")
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "metric", value.MetricName)
+			assert.Equal(t, tt.expectedValue, value.Value.AsApproximateFloat64())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+)
+
+// Template variables resolved with the autoscaler using a DatadogMetric
+// %%target_namespace%%, %%target_kind%%, %%target_name%% refer to the scaled object
+// %%label_<key>%% refers to a label of the external metric selector of the autoscaler
+const (
+	targetTemplateVariable string = "target"
+	labelTemplateVariable  string = "label"
+)
+
+// AutoscalerScope holds the autoscaler information used to resolve templated DatadogMetric queries
+type AutoscalerScope struct {
+	Namespace  string
+	TargetKind string
+	TargetName string
+	Labels     map[string]string
+}
+
+// isAutoscalerTemplateVariable returns whether a template variable is resolved per autoscaler
+func isAutoscalerTemplateVariable(name string) bool {
+	return name == targetTemplateVariable || name == labelTemplateVariable
+}
+
+// hasAutoscalerTemplateVariables returns whether the query has template variables resolved per autoscaler
+func hasAutoscalerTemplateVariables(q string) bool {
+	for _, tplVar := range tmplvar.ParseString(q) {
+		if isAutoscalerTemplateVariable(string(tplVar.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAutoscalerQuery replaces the template variables resolved per autoscaler in the query
+func resolveAutoscalerQuery(q string, scope AutoscalerScope) (string, error) {
+	result := q
+	for _, tplVar := range tmplvar.ParseString(q) {
+		var value string
+		switch string(tplVar.Name) {
+		case targetTemplateVariable:
+			switch string(tplVar.Key) {
+			case "namespace":
+				value = scope.Namespace
+			case "kind":
+				value = strings.ToLower(scope.TargetKind)
+			case "name":
+				value = scope.TargetName
+			default:
+				return "", fmt.Errorf("template variable %q is unknown", tplVar.Raw)
+			}
+		case labelTemplateVariable:
+			var found bool
+			if value, found = scope.Labels[string(tplVar.Key)]; !found {
+				return "", fmt.Errorf("cannot resolve template variable %q: label %q is not in the metric selector", tplVar.Raw, tplVar.Key)
+			}
+		default:
+			continue
+		}
+
+		if value == "" {
+			return "", fmt.Errorf("cannot resolve template variable %q: value is empty", tplVar.Raw)
+		}
+		result = strings.ReplaceAll(result, string(tplVar.Raw), value)
+	}
+
+	return result, nil
+}
+
+// NewDatadogMetricInternalFromTemplate returns an autogen `DatadogMetricInternal` object
+// that is the instance of a templated DatadogMetric for an autoscaler
+func NewDatadogMetricInternalFromTemplate(id, scopeID string, parent DatadogMetricInternal, scope AutoscalerScope, metricName, autoscalerReference string) (DatadogMetricInternal, error) {
+	query, err := resolveAutoscalerQuery(parent.Query(), scope)
+	if err != nil {
+		return DatadogMetricInternal{}, err
+	}
+
+	return DatadogMetricInternal{
+		ID:                   id,
+		ParentID:             parent.ID,
+		TemplateScopeID:      scopeID,
+		query:                query,
+		resolvedQuery:        &query,
+		Valid:                false,
+		Active:               true,
+		AlwaysActive:         false,
+		Deleted:              false,
+		Autogen:              true,
+		ExternalMetricName:   metricName,
+		AutoscalerReferences: autoscalerReference,
+		UpdateTime:           time.Now().UTC(),
+		MaxAge:               parent.MaxAge,
+		TimeWindow:           parent.TimeWindow,
+		FallbackValue:        parent.FallbackValue,
+		StalenessThreshold:   parent.StalenessThreshold,
+	}, nil
+}
+
+// SpecEquals returns whether two `DatadogMetricInternal` have the same query and settings
+func (d *DatadogMetricInternal) SpecEquals(other DatadogMetricInternal) bool {
+	fallbackEquals := (d.FallbackValue == nil && other.FallbackValue == nil) ||
+		(d.FallbackValue != nil && other.FallbackValue != nil && *d.FallbackValue == *other.FallbackValue)
+
+	return d.query == other.query &&
+		d.MaxAge == other.MaxAge &&
+		d.TimeWindow == other.TimeWindow &&
+		d.StalenessThreshold == other.StalenessThreshold &&
+		fallbackEquals
+}
+
+// UpdateSpecFrom updates the query and settings of a template instance from its desired state
+func (d *DatadogMetricInternal) UpdateSpecFrom(desired DatadogMetricInternal) {
+	d.query = desired.query
+	d.resolvedQuery = desired.resolvedQuery
+	d.MaxAge = desired.MaxAge
+	d.TimeWindow = desired.TimeWindow
+	d.FallbackValue = desired.FallbackValue
+	d.StalenessThreshold = desired.StalenessThreshold
+	// The previous value was computed with another query
+	d.Valid = false
+	d.UpdateTime = time.Now().UTC()
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveAutoscalerQuery(t *testing.T) {
+	scope := AutoscalerScope{
+		Namespace:  "payments",
+		TargetKind: "Deployment",
+		TargetName: "checkout",
+		Labels:     map[string]string{"team": "web"},
+	}
+
+	tests := []struct {
+		name    string
+		q       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no template variable",
+			q:    "avg:requests{*}",
+			want: "avg:requests{*}",
+		},
+		{
+			name: "target and label",
+			q:    "sum:requests{kube_namespace:%%target_namespace%%,kube_%%target_kind%%:%%target_name%%,team:%%label_team%%}.rollup(60)",
+			want: "sum:requests{kube_namespace:payments,kube_deployment:checkout,team:web}.rollup(60)",
+		},
+		{
+			name: "other template variables are kept",
+			q:    "sum:requests{kube_namespace:%%target_namespace%%,kube_cluster_name:%%tag_kube_cluster_name%%}",
+			want: "sum:requests{kube_namespace:payments,kube_cluster_name:%%tag_kube_cluster_name%%}",
+		},
+		{
+			name:    "unknown target variable",
+			q:       "sum:requests{owner:%%target_owner%%}",
+			wantErr: true,
+		},
+		{
+			name:    "missing label",
+			q:       "sum:requests{service:%%label_service%%}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAutoscalerQuery(tt.q, scope)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewDatadogMetricInternalFromTemplate(t *testing.T) {
+	fallbackValue := 1.5
+	parent := DatadogMetricInternal{
+		ID:                 "ns/parent",
+		Template:           true,
+		MaxAge:             time.Minute,
+		TimeWindow:         5 * time.Minute,
+		FallbackValue:      &fallbackValue,
+		StalenessThreshold: 10 * time.Minute,
+	}
+	parent.SetQueries("sum:requests{kube_deployment:%%target_name%%}")
+
+	scope := AutoscalerScope{Namespace: "ns0", TargetKind: "Deployment", TargetName: "app"}
+	instance, err := NewDatadogMetricInternalFromTemplate("default/instance", "scope", parent, scope, "datadogmetric@ns:parent", "hpa:ns0/app")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "default/instance", instance.ID)
+	assert.Equal(t, "ns/parent", instance.ParentID)
+	assert.Equal(t, "scope", instance.TemplateScopeID)
+	assert.Equal(t, "sum:requests{kube_deployment:app}", instance.Query())
+	assert.Equal(t, "sum:requests{kube_deployment:app}", instance.RawQuery())
+	assert.Equal(t, "datadogmetric@ns:parent", instance.ExternalMetricName)
+	assert.Equal(t, "hpa:ns0/app", instance.AutoscalerReferences)
+	assert.True(t, instance.Active)
+	assert.True(t, instance.Autogen)
+	assert.False(t, instance.Template)
+	assert.False(t, instance.Valid)
+	assert.Equal(t, time.Minute, instance.MaxAge)
+	assert.Equal(t, 5*time.Minute, instance.TimeWindow)
+	assert.Equal(t, 10*time.Minute, instance.StalenessThreshold)
+	assert.Equal(t, &fallbackValue, instance.FallbackValue)
+
+	assert.True(t, instance.SpecEquals(instance))
+	other := instance
+	otherFallbackValue := 2.0
+	other.FallbackValue = &otherFallbackValue
+	assert.False(t, instance.SpecEquals(other))
+
+	_, err = NewDatadogMetricInternalFromTemplate("default/instance", "scope", parent, AutoscalerScope{Namespace: "ns0"}, "datadogmetric@ns:parent", "hpa:ns0/app")
+	assert.Error(t, err)
+}
@@ -47,6 +47,7 @@ var templatedTags = map[string]tagGetter{
 // resolveQuery replaces the template variables in the query
 // The supported template variable types are %%tag_<tag_name>%% and %%env_<ENV_VAR>%%
 // The only supported <tag_name> in %%tag_<tag_name>%% is kube_cluster_name
+// Template variables resolved per autoscaler are kept as is.
 func resolveQuery(q string) (string, error) {
 	vars := tmplvar.ParseString(q)
 	if len(vars) == 0 {
@@ -55,6 +56,10 @@ func resolveQuery(q string) (string, error) {
 
 	result := q
 	for _, tplVar := range vars {
+		if isAutoscalerTemplateVariable(string(tplVar.Name)) {
+			continue
+		}
+
 		switch string(tplVar.Name) {
 		case "tag":
 			tagGetter, found := templatedTags[string(tplVar.Key)]
@@ -35,6 +35,14 @@ func Test_resolveQuery(t *testing.T) {
 			want:          "",
 			wantErr:       false,
 		},
+		{
+			name:          "autoscaler template variables are kept",
+			q:             "avg:nginx.net.request_per_s{kube_cluster_name:%%tag_kube_cluster_name%%,kube_deployment:%%target_name%%,team:%%label_team%%}.rollup(60)",
+			templatedTags: templatedTagsStub,
+			loadFunc:      func(*testing.T) {},
+			want:          "avg:nginx.net.request_per_s{kube_cluster_name:cluster-foo,kube_deployment:%%target_name%%,team:%%label_team%%}.rollup(60)",
+			wantErr:       false,
+		},
 		{
 			name:          "1 tag",
 			q:             "avg:nginx.net.request_per_s{kube_container_name:nginx,kube_cluster_name:%%tag_kube_cluster_name%%}.rollup(60)",
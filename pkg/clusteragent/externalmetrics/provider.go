@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"k8s.io/metrics/pkg/apis/external_metrics"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
 
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
@@ -138,7 +140,11 @@ func (p *datadogMetricProvider) getExternalMetric(namespace string, metricSelect
 	// If the metric name is already prefixed, we can directly look up metrics in store
 	datadogMetricID, parsed, hasPrefix := metricNameToDatadogMetricID(info.Metric)
 	if !hasPrefix {
-		datadogMetricID = p.autogenNamespace + kubernetesNamespaceSep + getAutogenDatadogMetricNameFromSelector(info.Metric, metricSelector)
+		datadogMetricName, err := getAutogenDatadogMetricNameFromSelector(info.Metric, metricSelector)
+		if err != nil {
+			return nil, log.Warnf("Cannot serve ExternalMetric: %s, err: %v", info.Metric, err)
+		}
+		datadogMetricID = p.autogenNamespace + kubernetesNamespaceSep + datadogMetricName
 		parsed = true
 	}
 	if !parsed {
@@ -152,6 +158,14 @@ func (p *datadogMetricProvider) getExternalMetric(namespace string, metricSelect
 		return nil, log.Warnf("DatadogMetric not found for metric name: %s, datadogmetricid: %s", info.Metric, datadogMetricID)
 	}
 
+	// Templated DatadogMetrics are served by their instance for the autoscaler namespace and metric selector
+	if datadogMetric.Template {
+		var err error
+		if datadogMetric, err = p.getTemplateInstance(datadogMetricID, namespace, metricSelector); err != nil {
+			return nil, log.Warn(err)
+		}
+	}
+
 	externalMetric, err := datadogMetric.ToExternalMetricFormat(info.Metric)
 	if err != nil {
 		return nil, err
@@ -162,6 +176,31 @@ func (p *datadogMetricProvider) getExternalMetric(namespace string, metricSelect
 	}, nil
 }
 
+// getTemplateInstance returns the instance of a templated DatadogMetric serving the autoscalers of a namespace
+// with the given metric selector. The scaled target is not part of external metrics requests: the autoscalers
+// sharing a namespace and metric selector can only be served if their instances resolve the same query.
+func (p *datadogMetricProvider) getTemplateInstance(parentID, namespace string, metricSelector labels.Selector) (*model.DatadogMetricInternal, error) {
+	selectorLabels, err := selectorToLabels(metricSelector)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot serve DatadogMetric: %s for namespace: %s, err: %v", parentID, namespace, err)
+	}
+
+	scopeID := getTemplateScopeID(parentID, namespace, selectorLabels)
+	instances := p.store.GetTemplateInstances(scopeID)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("DatadogMetric: %s is templated but has no instance for namespace: %s, selector: %s yet, scope id: %s", parentID, namespace, metricSelector.String(), scopeID)
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	for _, instance := range instances[1:] {
+		if instance.Query() != instances[0].Query() {
+			return nil, fmt.Errorf("DatadogMetric: %s resolves different queries for the autoscalers: %s and %s, which use the same namespace: %s and selector: %s. Please use distinct metric selectors", parentID, instances[0].AutoscalerReferences, instance.AutoscalerReferences, namespace, metricSelector.String())
+		}
+	}
+
+	return &instances[0], nil
+}
+
 func (p *datadogMetricProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
 	startTime := time.Now()
 	datadogMetrics := p.store.GetAll()
@@ -170,6 +209,11 @@ func (p *datadogMetricProvider) ListAllExternalMetrics() []provider.ExternalMetr
 	autogenMetricNames := make(map[string]struct{})
 
 	for _, datadogMetric := range datadogMetrics {
+		if datadogMetric.ParentID != "" {
+			// Template instances are served under the name of their templated DatadogMetric
+			continue
+		}
+
 		if datadogMetric.Autogen {
 			autogenMetricNames[datadogMetric.ExternalMetricName] = struct{}{}
 		} else {
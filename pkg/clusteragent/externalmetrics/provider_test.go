@@ -159,6 +159,105 @@ func TestGetExternalMetrics(t *testing.T) {
 			expectedExternalMetrics: nil,
 			expectedError:           fmt.Errorf("DatadogMetric not found for metric name: datadogmetric@ns:metric1, datadogmetricid: ns/metric1"),
 		},
+		{
+			desc: "Test templated DatadogMetric is served by its instance",
+			storeContent: []ddmWithQuery{
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "ns/metric0",
+						Template: true,
+						Valid:    true,
+					},
+					query: "query-metric0{kube_namespace:%%target_namespace%%,team:%%label_team%%}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:                 "default/" + getTemplateInstanceDatadogMetricName("ns/metric0", model.AutoscalerScope{Namespace: "ns0", TargetKind: "Deployment", TargetName: "app0", Labels: map[string]string{"team": "a"}}),
+						ParentID:           "ns/metric0",
+						TemplateScopeID:    getTemplateScopeID("ns/metric0", "ns0", map[string]string{"team": "a"}),
+						Autogen:            true,
+						ExternalMetricName: "datadogmetric@ns:metric0",
+						DataTime:           defaultUpdateTime,
+						Valid:              true,
+						Value:              21.0,
+					},
+					query: "query-metric0{kube_namespace:ns0,team:a}",
+				},
+			},
+			queryNamespace:  "ns0",
+			queryMetricName: "datadogmetric@ns:metric0",
+			querySelector:   map[string]string{"team": "a"},
+			expectedExternalMetrics: []external_metrics.ExternalMetricValue{
+				{
+					MetricName:   "datadogmetric@ns:metric0",
+					MetricLabels: nil,
+					Timestamp:    defaultMetaUpdateTime,
+					Value:        resource.MustParse(fmt.Sprintf("%v", 21.0)),
+				},
+			},
+		},
+		{
+			desc: "Test templated DatadogMetric with ambiguous instances",
+			storeContent: []ddmWithQuery{
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "ns/metric0",
+						Template: true,
+						Valid:    true,
+					},
+					query: "query-metric0{kube_deployment:%%target_name%%,team:%%label_team%%}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:                   "default/instance0",
+						ParentID:             "ns/metric0",
+						TemplateScopeID:      getTemplateScopeID("ns/metric0", "ns0", map[string]string{"team": "a"}),
+						AutoscalerReferences: "hpa:ns0/hpa0",
+						Autogen:              true,
+						DataTime:             defaultUpdateTime,
+						Valid:                true,
+						Value:                21.0,
+					},
+					query: "query-metric0{kube_deployment:app0,team:a}",
+				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:                   "default/instance1",
+						ParentID:             "ns/metric0",
+						TemplateScopeID:      getTemplateScopeID("ns/metric0", "ns0", map[string]string{"team": "a"}),
+						AutoscalerReferences: "hpa:ns0/hpa1",
+						Autogen:              true,
+						DataTime:             defaultUpdateTime,
+						Valid:                true,
+						Value:                42.0,
+					},
+					query: "query-metric0{kube_deployment:app1,team:a}",
+				},
+			},
+			queryNamespace:          "ns0",
+			queryMetricName:         "datadogmetric@ns:metric0",
+			querySelector:           map[string]string{"team": "a"},
+			expectedExternalMetrics: nil,
+			expectedError:           fmt.Errorf("DatadogMetric: ns/metric0 resolves different queries for the autoscalers: hpa:ns0/hpa0 and hpa:ns0/hpa1, which use the same namespace: ns0 and selector: team=a. Please use distinct metric selectors"),
+		},
+		{
+			desc: "Test templated DatadogMetric without instance",
+			storeContent: []ddmWithQuery{
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:       "ns/metric0",
+						Template: true,
+						Valid:    true,
+					},
+					query: "query-metric0{kube_namespace:%%target_namespace%%,team:%%label_team%%}",
+				},
+			},
+			queryNamespace:          "ns1",
+			queryMetricName:         "datadogmetric@ns:metric0",
+			querySelector:           map[string]string{"team": "a"},
+			expectedExternalMetrics: nil,
+			expectedError:           fmt.Errorf("DatadogMetric: ns/metric0 is templated but has no instance for namespace: ns1, selector: team=a yet, scope id: %s", getTemplateScopeID("ns/metric0", "ns1", map[string]string{"team": "a"})),
+		},
 		{
 			desc: "Test ExternalMetric use wrong DatadogMetric format",
 			storeContent: []ddmWithQuery{
@@ -262,6 +361,18 @@ func TestListAllExternalMetrics(t *testing.T) {
 					},
 					query: "query-metric3",
 				},
+				{
+					ddm: model.DatadogMetricInternal{
+						ID:                 "autogen-baz",
+						ParentID:           "ns/metric0",
+						DataTime:           defaultUpdateTime,
+						ExternalMetricName: "datadogmetric@ns:metric0",
+						Autogen:            true,
+						Valid:              true,
+						Value:              42.0,
+					},
+					query: "query-metric0",
+				},
 			},
 			expectedExternalMetricInfo: []provider.ExternalMetricInfo{
 				{Metric: "datadogmetric@ns:metric0"},
@@ -34,6 +34,9 @@ type DatadogMetricsInternalStore struct {
 	lock          sync.RWMutex
 	observers     map[storeOperation][]DatadogMetricInternalObserverFunc
 	observersLock sync.RWMutex
+
+	// templateInstances indexes the IDs of the template instances by TemplateScopeID, protected by lock
+	templateInstances map[string]map[string]struct{}
 }
 
 type storeOperation int
@@ -41,7 +44,8 @@ type storeOperation int
 // NewDatadogMetricsInternalStore creates a new NewDatadogMetricsInternalStore
 func NewDatadogMetricsInternalStore() DatadogMetricsInternalStore {
 	return DatadogMetricsInternalStore{
-		store: make(map[string]model.DatadogMetricInternal),
+		store:             make(map[string]model.DatadogMetricInternal),
+		templateInstances: make(map[string]map[string]struct{}),
 		observers: map[storeOperation][]DatadogMetricInternalObserverFunc{
 			setOperation:    make([]DatadogMetricInternalObserverFunc, 0),
 			deleteOperation: make([]DatadogMetricInternalObserverFunc, 0),
@@ -100,6 +104,20 @@ func (ds *DatadogMetricsInternalStore) GetFiltered(filter func(model.DatadogMetr
 	return datadogMetrics
 }
 
+// GetTemplateInstances returns a copy of the instances of templated DatadogMetrics with the given TemplateScopeID
+func (ds *DatadogMetricsInternalStore) GetTemplateInstances(scopeID string) []model.DatadogMetricInternal {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	ids := ds.templateInstances[scopeID]
+	datadogMetrics := make([]model.DatadogMetricInternal, 0, len(ids))
+	for id := range ids {
+		datadogMetrics = append(datadogMetrics, ds.store[id])
+	}
+
+	return datadogMetrics
+}
+
 // Count returns number of elements in store
 func (ds *DatadogMetricsInternalStore) Count() int {
 	ds.lock.RLock()
@@ -111,7 +129,7 @@ func (ds *DatadogMetricsInternalStore) Count() int {
 // Set `DatadogMetricInternal` for id
 func (ds *DatadogMetricsInternalStore) Set(id string, datadogMetric model.DatadogMetricInternal, sender string) {
 	ds.lock.Lock()
-	ds.store[id] = datadogMetric
+	ds.set(id, datadogMetric)
 	ds.lock.Unlock()
 
 	ds.notify(setOperation, id, sender)
@@ -120,8 +138,7 @@ func (ds *DatadogMetricsInternalStore) Set(id string, datadogMetric model.Datado
 // Delete `DatadogMetricInternal` corresponding to id if present
 func (ds *DatadogMetricsInternalStore) Delete(id, sender string) {
 	ds.lock.Lock()
-	_, exists := ds.store[id]
-	delete(ds.store, id)
+	exists := ds.delete(id)
 	ds.lock.Unlock()
 
 	if exists {
@@ -154,7 +171,7 @@ func (ds *DatadogMetricsInternalStore) Unlock(id string) {
 
 // UnlockSet sets the new DatadogMetricInternal value and releases the lock (previously acquired by `LockRead`)
 func (ds *DatadogMetricsInternalStore) UnlockSet(id string, datadogMetric model.DatadogMetricInternal, sender string) {
-	ds.store[id] = datadogMetric
+	ds.set(id, datadogMetric)
 	ds.lock.Unlock()
 
 	ds.notify(setOperation, id, sender)
@@ -162,9 +179,7 @@ func (ds *DatadogMetricsInternalStore) UnlockSet(id string, datadogMetric model.
 
 // UnlockDelete deletes a DatadogMetricInternal and releases the lock (previously acquired by `LockRead`)
 func (ds *DatadogMetricsInternalStore) UnlockDelete(id, sender string) {
-	_, exists := ds.store[id]
-
-	delete(ds.store, id)
+	exists := ds.delete(id)
 	ds.lock.Unlock()
 
 	if exists {
@@ -172,6 +187,43 @@ func (ds *DatadogMetricsInternalStore) UnlockDelete(id, sender string) {
 	}
 }
 
+// set stores a DatadogMetricInternal and indexes it if it's a template instance, the lock must be held
+func (ds *DatadogMetricsInternalStore) set(id string, datadogMetric model.DatadogMetricInternal) {
+	ds.unindex(id)
+	ds.store[id] = datadogMetric
+
+	if datadogMetric.TemplateScopeID != "" {
+		ids, found := ds.templateInstances[datadogMetric.TemplateScopeID]
+		if !found {
+			ids = make(map[string]struct{})
+			ds.templateInstances[datadogMetric.TemplateScopeID] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// delete removes a DatadogMetricInternal and returns whether it existed, the lock must be held
+func (ds *DatadogMetricsInternalStore) delete(id string) bool {
+	_, exists := ds.store[id]
+	ds.unindex(id)
+	delete(ds.store, id)
+	return exists
+}
+
+// unindex removes a stored DatadogMetricInternal from the template instances index, the lock must be held
+func (ds *DatadogMetricsInternalStore) unindex(id string) {
+	previous, found := ds.store[id]
+	if !found || previous.TemplateScopeID == "" {
+		return
+	}
+
+	ids := ds.templateInstances[previous.TemplateScopeID]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(ds.templateInstances, previous.TemplateScopeID)
+	}
+}
+
 // It's a very simple implementation of a notify process, but it's enough in our case as we aim at only 1 or 2 observers
 func (ds *DatadogMetricsInternalStore) notify(operationType storeOperation, key, sender string) {
 	ds.observersLock.RLock()
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package externalmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
+)
+
+func TestStoreTemplateInstancesIndex(t *testing.T) {
+	store := NewDatadogMetricsInternalStore()
+	store.Set("default/template", model.DatadogMetricInternal{ID: "default/template", Template: true}, "")
+	store.Set("default/instance-a", model.DatadogMetricInternal{ID: "default/instance-a", ParentID: "default/template", TemplateScopeID: "scope-1"}, "")
+	store.Set("default/instance-b", model.DatadogMetricInternal{ID: "default/instance-b", ParentID: "default/template", TemplateScopeID: "scope-1"}, "")
+
+	ids := func(scopeID string) []string {
+		result := []string{}
+		for _, instance := range store.GetTemplateInstances(scopeID) {
+			result = append(result, instance.ID)
+		}
+		return result
+	}
+
+	assert.ElementsMatch(t, []string{"default/instance-a", "default/instance-b"}, ids("scope-1"))
+	assert.Empty(t, ids("scope-2"))
+
+	// moving an instance to another scope updates the index
+	instance := store.LockRead("default/instance-b", false)
+	instance.TemplateScopeID = "scope-2"
+	store.UnlockSet("default/instance-b", *instance, "")
+	assert.Equal(t, []string{"default/instance-a"}, ids("scope-1"))
+	assert.Equal(t, []string{"default/instance-b"}, ids("scope-2"))
+
+	store.Delete("default/instance-a", "")
+	store.LockRead("default/instance-b", false)
+	store.UnlockDelete("default/instance-b", "")
+	assert.Empty(t, ids("scope-1"))
+	assert.Empty(t, ids("scope-2"))
+	assert.Empty(t, store.templateInstances)
+}
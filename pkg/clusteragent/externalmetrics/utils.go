@@ -16,10 +16,13 @@ import (
 	"sort"
 	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
 	"github.com/DataDog/datadog-operator/apis/datadoghq/v1alpha1"
 )
 
@@ -56,19 +59,76 @@ func getAutogenDatadogMetricNameFromLabels(metricName string, labels map[string]
 	return getAutogenDatadogMetricName(buildDatadogQueryForExternalMetric(metricName, labels))
 }
 
-func getAutogenDatadogMetricNameFromSelector(metricName string, labels labels.Selector) string {
-	strPairs := strings.Split(labels.String(), ",")
-	mapLabels := make(map[string]string, len(strPairs))
-	for _, pair := range strPairs {
-		kv := strings.Split(pair, "=")
-		if len(kv) != 2 {
-			continue
+func getAutogenDatadogMetricNameFromSelector(metricName string, selector labels.Selector) (string, error) {
+	labels, err := selectorToLabels(selector)
+	if err != nil {
+		return "", err
+	}
+	return getAutogenDatadogMetricName(buildDatadogQueryForExternalMetric(metricName, labels)), nil
+}
+
+// selectorToLabels returns the labels of an external metric selector received by the provider.
+// Only equality requirements can be translated to Datadog tags, other requirements are rejected.
+func selectorToLabels(selector labels.Selector) (map[string]string, error) {
+	requirements, _ := selector.Requirements()
+	mapLabels := make(map[string]string, len(requirements))
+	for _, req := range requirements {
+		values := req.Values().List()
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if len(values) != 1 {
+				return nil, fmt.Errorf("unsupported metric selector requirement: %s, only equality is supported", req.String())
+			}
+			mapLabels[req.Key()] = values[0]
+		default:
+			return nil, fmt.Errorf("unsupported metric selector requirement: %s, only equality is supported", req.String())
 		}
+	}
+
+	return mapLabels, nil
+}
+
+// labelSelectorToLabels returns the labels of an external metric selector of an autoscaler.
+// It is consistent with selectorToLabels: expressions other than an `In` with a single value are rejected.
+func labelSelectorToLabels(selector *metav1.LabelSelector) (map[string]string, error) {
+	if selector == nil {
+		return nil, nil
+	}
 
-		mapLabels[kv[0]] = kv[1]
+	mapLabels := make(map[string]string, len(selector.MatchLabels)+len(selector.MatchExpressions))
+	for key, val := range selector.MatchLabels {
+		mapLabels[key] = val
+	}
+	for _, expr := range selector.MatchExpressions {
+		if expr.Operator != metav1.LabelSelectorOpIn || len(expr.Values) != 1 {
+			return nil, fmt.Errorf("unsupported metric selector expression: %s %s %v, only equality is supported", expr.Key, expr.Operator, expr.Values)
+		}
+		mapLabels[expr.Key] = expr.Values[0]
 	}
 
-	return getAutogenDatadogMetricName(buildDatadogQueryForExternalMetric(metricName, mapLabels))
+	return mapLabels, nil
+}
+
+// getTemplateScopeID identifies the namespace and metric selector labels of the autoscalers using a templated
+// DatadogMetric. These are the only autoscaler information available when serving external metrics.
+func getTemplateScopeID(parentID, namespace string, labels map[string]string) string {
+	return getAutogenDatadogMetricName(parentID + "|" + namespace + "|" + joinLabels(labels))
+}
+
+// getTemplateInstanceDatadogMetricName returns the name of the autogen DatadogMetric instantiating
+// a templated DatadogMetric for an autoscaler. Autoscalers sharing a namespace and metric selector
+// but scaling different targets get different instances.
+func getTemplateInstanceDatadogMetricName(parentID string, scope model.AutoscalerScope) string {
+	return getAutogenDatadogMetricName(parentID + "|" + scope.Namespace + "|" + scope.TargetKind + "/" + scope.TargetName + "|" + joinLabels(scope.Labels))
+}
+
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, val := range labels {
+		pairs = append(pairs, key+"="+val)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
 }
 
 // We use query and not metricName + labels as key. It ensures we'll handle changes of config parameters.
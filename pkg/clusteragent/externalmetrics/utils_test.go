@@ -11,9 +11,11 @@ package externalmetrics
 import (
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetricNameToDatadogMetricID(t *testing.T) {
@@ -64,7 +66,8 @@ func TestDatadogMetricNameGeneration(t *testing.T) {
 	// Reference
 	idRef := getAutogenDatadogMetricName("avg:metricName1{Alabel2:bar,Dlabel3:baz,Zlabel1:foo}.rollup(30)")
 	idFromMap := getAutogenDatadogMetricNameFromLabels(testMetricName, testLabels)
-	idFromSelector := getAutogenDatadogMetricNameFromSelector(testMetricName, labels.Set(testLabels).AsSelector())
+	idFromSelector, err := getAutogenDatadogMetricNameFromSelector(testMetricName, labels.Set(testLabels).AsSelector())
+	require.NoError(t, err)
 
 	assert.Equal(t, "dcaautogen-595b170252cd5c77580b802084753c17ed1a18", idRef)
 	assert.Equal(t, idRef, idFromMap)
@@ -78,7 +81,8 @@ func TestDatadogMetricNameGenerationNoLabels(t *testing.T) {
 	// Reference
 	idRef := getAutogenDatadogMetricName("avg:metricName1{*}.rollup(30)")
 	idFromMap := getAutogenDatadogMetricNameFromLabels(testMetricName, testLabels)
-	idFromSelector := getAutogenDatadogMetricNameFromSelector(testMetricName, labels.Set(testLabels).AsSelector())
+	idFromSelector, err := getAutogenDatadogMetricNameFromSelector(testMetricName, labels.Set(testLabels).AsSelector())
+	require.NoError(t, err)
 
 	assert.Equal(t, "dcaautogen-cb3c76c6adbd97b438d75e29a6a8efc4cefa81", idRef)
 	assert.Equal(t, idRef, idFromMap)
@@ -98,3 +102,42 @@ func TestBuildDatadogQueryForExternalMetric(t *testing.T) {
 	testLabels = nil
 	assert.Equal(t, "avg:metricName1{*}.rollup(30)", buildDatadogQueryForExternalMetric(testMetricName, testLabels))
 }
+
+func TestSelectorToLabels(t *testing.T) {
+	selector, err := labels.Parse("app=foo,team in (a)")
+	require.NoError(t, err)
+	selectorLabels, err := selectorToLabels(selector)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "foo", "team": "a"}, selectorLabels)
+
+	for _, unsupported := range []string{"app!=foo", "team in (a,b)", "team notin (a)", "app", "!app"} {
+		selector, err = labels.Parse(unsupported)
+		require.NoError(t, err)
+		_, err = selectorToLabels(selector)
+		assert.Error(t, err, unsupported)
+	}
+}
+
+func TestLabelSelectorToLabels(t *testing.T) {
+	selectorLabels, err := labelSelectorToLabels(nil)
+	require.NoError(t, err)
+	assert.Nil(t, selectorLabels)
+
+	selectorLabels, err = labelSelectorToLabels(&metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "foo"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"a"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "foo", "team": "a"}, selectorLabels)
+
+	for _, unsupported := range []metav1.LabelSelectorRequirement{
+		{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+		{Key: "team", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a"}},
+		{Key: "team", Operator: metav1.LabelSelectorOpExists},
+	} {
+		_, err = labelSelectorToLabels(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{unsupported}})
+		assert.Error(t, err, unsupported.String())
+	}
+}
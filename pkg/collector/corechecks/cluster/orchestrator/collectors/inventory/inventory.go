@@ -32,18 +32,25 @@ func NewCollectorInventory() *CollectorInventory {
 			k8sCollectors.NewCronJobCollectorVersions(),
 			k8sCollectors.NewDaemonSetCollectorVersions(),
 			k8sCollectors.NewDeploymentCollectorVersions(),
+			k8sCollectors.NewEndpointSliceCollectorVersions(),
+			k8sCollectors.NewHorizontalPodAutoscalerCollectorVersions(),
 			k8sCollectors.NewIngressCollectorVersions(),
 			k8sCollectors.NewJobCollectorVersions(),
+			k8sCollectors.NewLimitRangeCollectorVersions(),
 			k8sCollectors.NewNamespaceCollectorVersions(),
+			k8sCollectors.NewNetworkPolicyCollectorVersions(),
 			k8sCollectors.NewNodeCollectorVersions(),
 			k8sCollectors.NewPersistentVolumeCollectorVersions(),
 			k8sCollectors.NewPersistentVolumeClaimCollectorVersions(),
+			k8sCollectors.NewPodDisruptionBudgetCollectorVersions(),
 			k8sCollectors.NewReplicaSetCollectorVersions(),
+			k8sCollectors.NewResourceQuotaCollectorVersions(),
 			k8sCollectors.NewRoleCollectorVersions(),
 			k8sCollectors.NewRoleBindingCollectorVersions(),
 			k8sCollectors.NewServiceCollectorVersions(),
 			k8sCollectors.NewServiceAccountCollectorVersions(),
 			k8sCollectors.NewStatefulSetCollectorVersions(),
+			k8sCollectors.NewStorageClassCollectorVersions(),
 			k8sCollectors.NewUnassignedPodCollectorVersions(),
 			k8sCollectors.NewVerticalPodAutoscalerCollectorVersions(),
 		},
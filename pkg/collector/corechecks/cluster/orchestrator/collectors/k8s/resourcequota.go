@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver && orchestrator
+// +build kubeapiserver,orchestrator
+
+package k8s
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/collectors"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors"
+	k8sProcessors "github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors/k8s"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator"
+
+	"k8s.io/apimachinery/pkg/labels"
+	corev1Informers "k8s.io/client-go/informers/core/v1"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NewResourceQuotaCollectorVersions builds the group of collector versions.
+func NewResourceQuotaCollectorVersions() collectors.CollectorVersions {
+	return collectors.NewCollectorVersions(
+		NewResourceQuotaCollector(),
+	)
+}
+
+// ResourceQuotaCollector is a collector for Kubernetes ResourceQuotas.
+type ResourceQuotaCollector struct {
+	informer  corev1Informers.ResourceQuotaInformer
+	lister    corev1Listers.ResourceQuotaLister
+	metadata  *collectors.CollectorMetadata
+	processor *processors.Processor
+}
+
+// NewResourceQuotaCollector creates a new collector for the Kubernetes ResourceQuota
+// resource.
+func NewResourceQuotaCollector() *ResourceQuotaCollector {
+	return &ResourceQuotaCollector{
+		metadata: &collectors.CollectorMetadata{
+			IsDefaultVersion:          true,
+			IsStable:                  false,
+			IsManifestProducer:        true,
+			IsMetadataProducer:        false,
+			SupportsManifestBuffering: true,
+			Name:                      "resourcequotas",
+			NodeType:                  orchestrator.K8sResourceQuota,
+			Version:                   "v1",
+		},
+		processor: processors.NewProcessor(new(k8sProcessors.ResourceQuotaHandlers)),
+	}
+}
+
+// Informer returns the shared informer.
+func (c *ResourceQuotaCollector) Informer() cache.SharedInformer {
+	return c.informer.Informer()
+}
+
+// Init is used to initialize the collector.
+func (c *ResourceQuotaCollector) Init(rcfg *collectors.CollectorRunConfig) {
+	c.informer = rcfg.APIClient.InformerFactory.Core().V1().ResourceQuotas()
+	c.lister = c.informer.Lister()
+}
+
+// IsAvailable returns whether the collector is available.
+func (c *ResourceQuotaCollector) IsAvailable() bool { return true }
+
+// Metadata is used to access information about the collector.
+func (c *ResourceQuotaCollector) Metadata() *collectors.CollectorMetadata {
+	return c.metadata
+}
+
+// Run triggers the collection process.
+func (c *ResourceQuotaCollector) Run(rcfg *collectors.CollectorRunConfig) (*collectors.CollectorRunResult, error) {
+	list, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return nil, collectors.NewListingError(err)
+	}
+
+	ctx := collectors.NewProcessorContext(rcfg, c.metadata)
+
+	processResult, processed := c.processor.Process(ctx, list)
+
+	if processed == -1 {
+		return nil, collectors.ErrProcessingPanic
+	}
+
+	result := &collectors.CollectorRunResult{
+		Result:             processResult,
+		ResourcesListed:    len(list),
+		ResourcesProcessed: processed,
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build orchestrator
+// +build orchestrator
+
+package k8s
+
+import (
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator/redact"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// EndpointSliceHandlers implements the Handlers interface for Kubernetes EndpointSlices.
+// There is no dedicated payload model for this resource, so only manifests
+// are produced.
+type EndpointSliceHandlers struct {
+	BaseHandlers
+}
+
+// AfterMarshalling is a handler called after resource marshalling.
+func (h *EndpointSliceHandlers) AfterMarshalling(ctx *processors.ProcessorContext, resource, resourceModel interface{}, yaml []byte) (skip bool) {
+	return
+}
+
+// BuildMessageBody is a handler called to build a message body out of a list of
+// extracted resources.
+func (h *EndpointSliceHandlers) BuildMessageBody(ctx *processors.ProcessorContext, resourceModels []interface{}, groupSize int) model.MessageBody {
+	return nil
+}
+
+// ExtractResource is a handler called to extract the resource model out of a raw resource.
+func (h *EndpointSliceHandlers) ExtractResource(ctx *processors.ProcessorContext, resource interface{}) (resourceModel interface{}) {
+	return nil
+}
+
+// ResourceList is a handler called to convert a list passed as a generic
+// interface to a list of generic interfaces.
+func (h *EndpointSliceHandlers) ResourceList(ctx *processors.ProcessorContext, list interface{}) (resources []interface{}) {
+	resourceList := list.([]*discoveryv1.EndpointSlice)
+	resources = make([]interface{}, 0, len(resourceList))
+
+	for _, resource := range resourceList {
+		resources = append(resources, resource)
+	}
+
+	return resources
+}
+
+// ResourceUID is a handler called to retrieve the resource UID.
+func (h *EndpointSliceHandlers) ResourceUID(ctx *processors.ProcessorContext, resource interface{}) types.UID {
+	return resource.(*discoveryv1.EndpointSlice).UID
+}
+
+// ResourceVersion is a handler called to retrieve the resource version.
+func (h *EndpointSliceHandlers) ResourceVersion(ctx *processors.ProcessorContext, resource, resourceModel interface{}) string {
+	return resource.(*discoveryv1.EndpointSlice).ResourceVersion
+}
+
+// ScrubBeforeExtraction is a handler called to redact the raw resource before
+// it is extracted as an internal resource model.
+func (h *EndpointSliceHandlers) ScrubBeforeExtraction(ctx *processors.ProcessorContext, resource interface{}) {
+	r := resource.(*discoveryv1.EndpointSlice)
+	redact.RemoveLastAppliedConfigurationAnnotation(r.Annotations)
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build orchestrator
+// +build orchestrator
+
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator/config"
+)
+
+func TestManifestOnlyProcessor(t *testing.T) {
+	minAvailable := intstr.FromInt(1)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "redis",
+			Namespace:       "default",
+			UID:             "3f2b9c4e-pdb-manifest-only",
+			ResourceVersion: "1234",
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "secret",
+				"team": "storage",
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+		},
+	}
+
+	cfg := config.NewDefaultOrchestratorConfig()
+	cfg.KubeClusterName = "test-cluster"
+	ctx := &processors.ProcessorContext{
+		Cfg:        cfg,
+		ClusterID:  "cluster-id",
+		MsgGroupID: 1,
+		NodeType:   orchestrator.K8sPodDisruptionBudget,
+	}
+
+	p := processors.NewProcessor(new(PodDisruptionBudgetHandlers))
+	result, processed := p.Process(ctx, []*policyv1.PodDisruptionBudget{pdb})
+	require.Equal(t, 1, processed)
+
+	for _, m := range result.MetadataMessages {
+		assert.Nil(t, m)
+	}
+
+	require.Len(t, result.ManifestMessages, 1)
+	cm, ok := result.ManifestMessages[0].(*model.CollectorManifest)
+	require.True(t, ok, "manifests must be bufferable")
+	assert.Equal(t, "test-cluster", cm.ClusterName)
+	require.Len(t, cm.Manifests, 1)
+
+	manifest := cm.Manifests[0]
+	assert.Equal(t, int32(orchestrator.K8sPodDisruptionBudget), manifest.Type)
+	assert.Equal(t, "3f2b9c4e-pdb-manifest-only", manifest.Uid)
+	assert.Equal(t, "1234", manifest.ResourceVersion)
+
+	var content policyv1.PodDisruptionBudget
+	require.NoError(t, json.Unmarshal(manifest.Content, &content))
+	assert.Equal(t, "-", content.Annotations["kubectl.kubernetes.io/last-applied-configuration"])
+	assert.Equal(t, "storage", content.Annotations["team"])
+	assert.Equal(t, &minAvailable, content.Spec.MinAvailable)
+
+	// unchanged resources are not sent again
+	_, processed = p.Process(ctx, []*policyv1.PodDisruptionBudget{pdb})
+	assert.Equal(t, 0, processed)
+}
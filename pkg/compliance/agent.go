@@ -57,12 +57,18 @@ type AgentOptions struct {
 	// EvalThrottling is the time that space out rule evaluation to avoid CPU
 	// spikes.
 	EvalThrottling time.Duration
+
+	// WaiversFile is the path of the file defining the accepted exceptions to
+	// the rules. The failed events matching a waiver are reported as waived.
+	// See LoadWaivers.
+	WaiversFile string
 }
 
 type Agent struct {
 	opts AgentOptions
 
 	telemetry  *common.ContainersTelemetry
+	waivers    *Waivers
 	statuses   map[string]*CheckStatus
 	statusesMu sync.RWMutex
 
@@ -139,6 +145,16 @@ func (a *Agent) Start() error {
 		return err
 	}
 
+	if a.opts.WaiversFile != "" {
+		waivers, err := LoadWaivers(a.opts.WaiversFile)
+		if err != nil {
+			log.Errorf("could not load compliance waivers from %s: %v", a.opts.WaiversFile, err)
+		} else {
+			log.Infof("loaded %d compliance waivers from %s", len(waivers.Waivers), a.opts.WaiversFile)
+			a.waivers = waivers
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	a.telemetry = telemetry
 	a.cancel = cancel
@@ -278,7 +294,11 @@ func (a *Agent) runXCCDFBenchmarks(ctx context.Context) {
 }
 
 func (a *Agent) reportEvents(ctx context.Context, benchmark *Benchmark, events []*CheckEvent) {
+	now := time.Now()
 	for _, event := range events {
+		if a.waivers.Apply(event, a.opts.Hostname, now) {
+			log.Debugf("waived event from benchmark=%s rule=%s: %s", benchmark.FrameworkID, event.RuleID, event.Waiver.Justification)
+		}
 		a.updateEvent(event)
 		if event.Result == CheckSkipped {
 			continue
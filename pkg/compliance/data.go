@@ -41,6 +41,9 @@ const (
 	CheckError CheckResult = "error"
 	// CheckSkipped is used to report result of a rule that is being skipped.
 	CheckSkipped CheckResult = "skipped"
+	// CheckWaived is used to report unsuccessful result of a rule check
+	// matching an accepted exception (see Waiver)
+	CheckWaived CheckResult = "waived"
 )
 
 type CheckStatus struct {
@@ -68,6 +71,8 @@ type CheckEvent struct {
 	ResourceID   string                 `json:"resource_id,omitempty"`
 	Tags         []string               `json:"tags"`
 	Data         map[string]interface{} `json:"data"`
+	Remediation  *RuleRemediation       `json:"remediation,omitempty"`
+	Waiver       *WaiverInfo            `json:"waiver,omitempty"`
 
 	errReason error `json:"-"`
 }
@@ -119,7 +124,7 @@ func NewCheckEvent(
 	benchmark *Benchmark,
 ) *CheckEvent {
 	expireAt := time.Now().Add(1 * time.Hour).UTC().Truncate(1 * time.Second)
-	return &CheckEvent{
+	event := &CheckEvent{
 		AgentVersion: version.AgentVersion,
 		RuleID:       rule.ID,
 		FrameworkID:  benchmark.FrameworkID,
@@ -130,6 +135,10 @@ func NewCheckEvent(
 		Result:       result,
 		Data:         data,
 	}
+	if result == CheckFailed {
+		event.Remediation = rule.Remediation
+	}
+	return event
 }
 
 func NewCheckSkipped(
@@ -175,6 +184,15 @@ type Rule struct {
 	Imports     []string     `yaml:"imports,omitempty" json:"imports,omitempty"`
 	Period      string       `yaml:"period,omitempty" json:"period,omitempty"`
 	Filters     []string     `yaml:"filters,omitempty" json:"filters,omitempty"`
+
+	Remediation *RuleRemediation `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+}
+
+// RuleRemediation describes how to fix the resources failing a rule. It is
+// attached to the failed events of the rule.
+type RuleRemediation struct {
+	Text   string `yaml:"text,omitempty" json:"text,omitempty"`
+	Script string `yaml:"script,omitempty" json:"script,omitempty"`
 }
 
 type (
@@ -190,6 +208,12 @@ type (
 		XCCDF         *InputSpecXCCDF         `yaml:"xccdf,omitempty" json:"xccdf,omitempty"`
 		Constants     *InputSpecConstants     `yaml:"constants,omitempty" json:"constants,omitempty"`
 
+		Package         *InputSpecPackage         `yaml:"package,omitempty" json:"package,omitempty"`
+		Sysctl          *InputSpecSysctl          `yaml:"sysctl,omitempty" json:"sysctl,omitempty"`
+		KernelModule    *InputSpecKernelModule    `yaml:"kernelModule,omitempty" json:"kernelModule,omitempty"`
+		Systemd         *InputSpecSystemd         `yaml:"systemd,omitempty" json:"systemd,omitempty"`
+		ListeningSocket *InputSpecListeningSocket `yaml:"listeningSocket,omitempty" json:"listeningSocket,omitempty"`
+
 		TagName string `yaml:"tag,omitempty" json:"tag,omitempty"`
 		Type    string `yaml:"type,omitempty" json:"type,omitempty"`
 	}
@@ -238,6 +262,28 @@ type (
 	}
 
 	InputSpecConstants map[string]interface{}
+
+	InputSpecPackage struct {
+		Name string `yaml:"name" json:"name"`
+	}
+
+	InputSpecSysctl struct {
+		Name string `yaml:"name" json:"name"`
+	}
+
+	InputSpecKernelModule struct {
+		Name string `yaml:"name" json:"name"`
+	}
+
+	InputSpecSystemd struct {
+		Unit       string   `yaml:"unit" json:"unit"`
+		Properties []string `yaml:"properties,omitempty" json:"properties,omitempty"`
+	}
+
+	InputSpecListeningSocket struct {
+		Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+		Port     uint16 `yaml:"port,omitempty" json:"port,omitempty"`
+	}
 )
 
 // ResolvedInputs is the generic data structure that is returned by a Resolver.
@@ -281,9 +327,9 @@ func (i *InputSpec) Valid() error {
 	// NOTE(jinroh): the current semantics allow to specify the result type as
 	// an "array". Here we enforce that the specified result type is
 	// constrained to a specific input type.
-	if i.KubeApiserver != nil || i.Docker != nil || i.Audit != nil {
+	if i.KubeApiserver != nil || i.Docker != nil || i.Audit != nil || i.ListeningSocket != nil {
 		if i.Type != "array" {
-			return fmt.Errorf("input of types kubeApiserver docker and audit have to be arrays")
+			return fmt.Errorf("input of types kubeApiserver docker audit and listeningSocket have to be arrays")
 		}
 	} else if i.Type == "array" {
 		if i.File == nil {
@@ -15,6 +15,13 @@ import (
 	"syscall"
 )
 
+func getFileOwner(fi os.FileInfo) (string, string, bool) {
+	if statt, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return strconv.Itoa(int(statt.Uid)), strconv.Itoa(int(statt.Gid)), true
+	}
+	return "", "", false
+}
+
 func getFileUser(fi os.FileInfo) string {
 	if statt, ok := fi.Sys().(*syscall.Stat_t); ok {
 		u := strconv.Itoa(int(statt.Uid))
@@ -10,6 +10,10 @@ package compliance
 
 import "os"
 
+func getFileOwner(fi os.FileInfo) (string, string, bool) {
+	return "", "", false
+}
+
 func getFileUser(fi os.FileInfo) string {
 	return ""
 }
@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	dpkgStatusPath   = "/var/lib/dpkg/status"
+	apkInstalledPath = "/lib/apk/db/installed"
+)
+
+type packageInfo struct {
+	name    string
+	version string
+	arch    string
+	manager string
+}
+
+func (r *defaultResolver) resolvePackage(ctx context.Context, spec InputSpecPackage) (interface{}, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("missing package name")
+	}
+	pkgs, err := r.getPackages()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range pkgs {
+		if pkg.name == spec.Name {
+			return map[string]interface{}{
+				"name":    pkg.name,
+				"version": pkg.version,
+				"arch":    pkg.arch,
+				"manager": pkg.manager,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// getPackages returns the packages installed on the host, from the databases
+// of all the package managers that are found.
+func (r *defaultResolver) getPackages() ([]*packageInfo, error) {
+	if r.packagesCache != nil {
+		return r.packagesCache, nil
+	}
+
+	pkgs := make([]*packageInfo, 0)
+	for _, db := range []struct {
+		path  string
+		parse func(io.Reader) ([]*packageInfo, error)
+	}{
+		{path: dpkgStatusPath, parse: parseDpkgStatus},
+		{path: apkInstalledPath, parse: parseApkInstalled},
+	} {
+		found, err := r.parsePackagesFile(db.path, db.parse)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse packages database %s: %w", db.path, err)
+		}
+		pkgs = append(pkgs, found...)
+	}
+
+	found, err := r.listRpmPackages()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse rpm packages database: %w", err)
+	}
+	pkgs = append(pkgs, found...)
+
+	r.packagesCache = pkgs
+	return pkgs, nil
+}
+
+func (r *defaultResolver) parsePackagesFile(path string, parse func(io.Reader) ([]*packageInfo, error)) ([]*packageInfo, error) {
+	f, err := os.Open(r.pathResolveInHostRoot(path))
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parseDpkgStatus parses the status file of dpkg, made of paragraphs of
+// "Field: value" lines, and returns the installed packages.
+func parseDpkgStatus(reader io.Reader) ([]*packageInfo, error) {
+	var pkgs []*packageInfo
+	var pkg packageInfo
+	var installed bool
+	flush := func() {
+		if installed && pkg.name != "" {
+			p := pkg
+			p.manager = "dpkg"
+			pkgs = append(pkgs, &p)
+		}
+		pkg, installed = packageInfo{}, false
+	}
+
+	s := bufio.NewScanner(reader)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+		// continuation of a multiline field
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			pkg.name = value
+		case "Version":
+			pkg.version = value
+		case "Architecture":
+			pkg.arch = value
+		case "Status":
+			fields := strings.Fields(value)
+			installed = len(fields) == 3 && fields[2] == "installed"
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return pkgs, nil
+}
+
+// parseApkInstalled parses the database of apk, made of paragraphs of
+// "K:value" lines, and returns the installed packages.
+func parseApkInstalled(reader io.Reader) ([]*packageInfo, error) {
+	var pkgs []*packageInfo
+	var pkg packageInfo
+	flush := func() {
+		if pkg.name != "" {
+			p := pkg
+			p.manager = "apk"
+			pkgs = append(pkgs, &p)
+		}
+		pkg = packageInfo{}
+	}
+
+	s := bufio.NewScanner(reader)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			pkg.name = value
+		case 'V':
+			pkg.version = value
+		case 'A':
+			pkg.arch = value
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return pkgs, nil
+}
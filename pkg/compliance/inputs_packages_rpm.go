@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package compliance
+
+import (
+	"fmt"
+	"os"
+
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
+)
+
+// rpmDatabasePaths lists the locations of the rpm database, in its sqlite,
+// ndb and berkeley db formats.
+var rpmDatabasePaths = []string{
+	"/var/lib/rpm/rpmdb.sqlite",
+	"/var/lib/rpm/Packages.db",
+	"/var/lib/rpm/Packages",
+	"/usr/lib/sysimage/rpm/rpmdb.sqlite",
+	"/usr/lib/sysimage/rpm/Packages.db",
+	"/usr/lib/sysimage/rpm/Packages",
+}
+
+func (r *defaultResolver) listRpmPackages() ([]*packageInfo, error) {
+	for _, path := range rpmDatabasePaths {
+		path = r.pathResolveInHostRoot(path)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		db, err := rpmdb.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		list, err := db.ListPackages()
+		if err != nil {
+			return nil, err
+		}
+
+		pkgs := make([]*packageInfo, 0, len(list))
+		for _, p := range list {
+			version := p.Version
+			if p.Release != "" {
+				version += "-" + p.Release
+			}
+			if p.Epoch != nil && *p.Epoch != 0 {
+				version = fmt.Sprintf("%d:%s", *p.Epoch, version)
+			}
+			pkgs = append(pkgs, &packageInfo{
+				name:    p.Name,
+				version: version,
+				arch:    p.Arch,
+				manager: "rpm",
+			})
+		}
+		return pkgs, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tcpListenState is the state of listening TCP sockets in /proc/net/tcp
+	tcpListenState = "0A"
+	// udpUnconnectedState is the state of bound and unconnected UDP sockets in /proc/net/udp
+	udpUnconnectedState = "07"
+)
+
+func (r *defaultResolver) resolveSysctl(ctx context.Context, spec InputSpecSysctl) (interface{}, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("missing sysctl name")
+	}
+	// as with sysctl(8), dots are the separators of the name and slashes the
+	// dots of its components, like in net.ipv4.conf.eth0/1.forwarding
+	path := strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, spec.Name)
+
+	data, err := os.ReadFile(r.pathNormalizeToHostRoot(filepath.Join("/proc/sys", path)))
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"name":  spec.Name,
+		"value": strings.TrimSpace(string(data)),
+	}, nil
+}
+
+func (r *defaultResolver) resolveKernelModule(ctx context.Context, spec InputSpecKernelModule) (interface{}, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("missing kernel module name")
+	}
+	f, err := os.Open(r.pathNormalizeToHostRoot("/proc/modules"))
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// the kernel reports the names of the modules with underscores
+	name := strings.ReplaceAll(spec.Name, "-", "_")
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// name size refcount dependencies state address
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 || fields[0] != name {
+			continue
+		}
+		size, _ := strconv.Atoi(fields[1])
+		refCount, _ := strconv.Atoi(fields[2])
+		usedBy := make([]string, 0)
+		for _, dep := range strings.Split(fields[3], ",") {
+			if dep != "" && dep != "-" {
+				usedBy = append(usedBy, dep)
+			}
+		}
+		return map[string]interface{}{
+			"name":     name,
+			"size":     size,
+			"refCount": refCount,
+			"usedBy":   usedBy,
+			"state":    strings.ToLower(fields[4]),
+		}, nil
+	}
+	return nil, s.Err()
+}
+
+func (r *defaultResolver) resolveListeningSocket(ctx context.Context, spec InputSpecListeningSocket) (interface{}, error) {
+	if spec.Protocol != "" && spec.Protocol != "tcp" && spec.Protocol != "udp" {
+		return nil, fmt.Errorf("unsupported socket protocol %q", spec.Protocol)
+	}
+
+	var resolved []interface{}
+	for _, table := range []struct {
+		name     string
+		protocol string
+		family   string
+		state    string
+	}{
+		{name: "tcp", protocol: "tcp", family: "ipv4", state: tcpListenState},
+		{name: "tcp6", protocol: "tcp", family: "ipv6", state: tcpListenState},
+		{name: "udp", protocol: "udp", family: "ipv4", state: udpUnconnectedState},
+		{name: "udp6", protocol: "udp", family: "ipv6", state: udpUnconnectedState},
+	} {
+		if spec.Protocol != "" && spec.Protocol != table.protocol {
+			continue
+		}
+
+		// the sockets of the network namespace of the host are the ones of its init process
+		f, err := os.Open(r.pathNormalizeToHostRoot(filepath.Join("/proc/1/net", table.name)))
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sockets, err := parseProcNetSockets(f, table.state)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse sockets table %s: %w", table.name, err)
+		}
+
+		for _, sock := range sockets {
+			if spec.Port != 0 && sock.port != spec.Port {
+				continue
+			}
+			resolved = append(resolved, map[string]interface{}{
+				"protocol": table.protocol,
+				"family":   table.family,
+				"address":  sock.ip.String(),
+				"port":     sock.port,
+				"uid":      sock.uid,
+				"inode":    sock.inode,
+			})
+		}
+	}
+	return resolved, nil
+}
+
+type procNetSocket struct {
+	ip    net.IP
+	port  uint16
+	uid   int
+	inode uint64
+}
+
+// parseProcNetSockets parses a sockets table of /proc/net and returns the
+// sockets in the given state.
+func parseProcNetSockets(reader io.Reader, state string) ([]*procNetSocket, error) {
+	var sockets []*procNetSocket
+	s := bufio.NewScanner(reader)
+	// skip the header
+	s.Scan()
+	for s.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+		ip, port, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		uid, _ := strconv.Atoi(fields[7])
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+		sockets = append(sockets, &procNetSocket{
+			ip:    ip,
+			port:  port,
+			uid:   uid,
+			inode: inode,
+		})
+	}
+	return sockets, s.Err()
+}
+
+// parseProcNetAddress parses an address of /proc/net, like 0100007F:0035. The
+// IP is made of 32 bits words in host byte order, little endian on the
+// supported architectures.
+func parseProcNetAddress(addr string) (net.IP, uint16, error) {
+	hexIP, hexPort, ok := strings.Cut(addr, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("malformed address %q", addr)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed address %q: %w", addr, err)
+	}
+	b, err := hex.DecodeString(hexIP)
+	if err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("malformed address %q", addr)
+	}
+	ip := make(net.IP, len(b))
+	for i := 0; i < len(b); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(b[i:]))
+	}
+	return ip, uint16(port), nil
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+// +build systemd
+
+package compliance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/coreos/go-systemd/dbus"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	systemdutil "github.com/DataDog/datadog-agent/pkg/util/systemd"
+)
+
+const systemdPrivateSocket = "/run/systemd/private"
+
+func newSystemdClient(ctx context.Context) (SystemdClient, error) {
+	var conn *dbus.Conn
+	var err error
+	if config.IsContainerized() {
+		hostRoot := os.Getenv("HOST_ROOT")
+		if hostRoot == "" {
+			hostRoot = "/host"
+		}
+		conn, err = systemdutil.NewSystemdConnection(filepath.Join(hostRoot, systemdPrivateSocket))
+	} else {
+		conn, err = dbus.NewSystemConnection()
+		if err != nil {
+			conn, err = systemdutil.NewSystemdConnection(systemdPrivateSocket)
+		}
+	}
+	if err != nil {
+		return nil, ErrIncompatibleEnvironment
+	}
+	return conn, nil
+}
@@ -0,0 +1,280 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// OfflineOptions holds the options of an offline evaluation of benchmarks.
+type OfflineOptions struct {
+	// RootPath is the root filesystem to evaluate the rules against. It can
+	// be a directory, an OCI image layout directory, an image tarball or a
+	// tarball of a root filesystem. See OpenRootFS.
+	RootPath string
+
+	// Hostname is reported as the hostname in the context of the rules
+	// inputs. By default, it is the root path.
+	Hostname string
+
+	// Waivers are the accepted exceptions to the rules, applied to the failed
+	// events of the evaluation.
+	Waivers *Waivers
+}
+
+// OfflineReport is the report of an offline evaluation of benchmarks.
+type OfflineReport struct {
+	RootPath   string         `json:"root_path"`
+	Summary    OfflineSummary `json:"summary"`
+	Events     []*CheckEvent  `json:"events"`
+	Benchmarks []*Benchmark   `json:"-"`
+	rules      map[string]*Rule
+}
+
+// OfflineSummary counts the results of an offline evaluation.
+type OfflineSummary struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Error   int `json:"error"`
+	Skipped int `json:"skipped"`
+	Waived  int `json:"waived"`
+}
+
+// EvaluateOffline evaluates the rego rules of the given benchmarks against a
+// root filesystem rather than the live host. Only the rules with inputs that
+// depend on the filesystem alone, like files, packages or groups, are
+// evaluated: the other ones, along with the XCCDF rules, are reported as
+// skipped.
+func EvaluateOffline(ctx context.Context, opts OfflineOptions, benchmarks []*Benchmark) (*OfflineReport, error) {
+	rootFS, err := OpenRootFS(opts.RootPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open root filesystem %s: %w", opts.RootPath, err)
+	}
+	defer rootFS.Close()
+
+	hostname := opts.Hostname
+	if hostname == "" {
+		hostname = opts.RootPath
+	}
+
+	report := &OfflineReport{
+		RootPath:   opts.RootPath,
+		Events:     make([]*CheckEvent, 0),
+		Benchmarks: benchmarks,
+		rules:      make(map[string]*Rule),
+	}
+
+	resolver := NewResolver(ctx, ResolverOptions{
+		Hostname:   hostname,
+		HostRoot:   rootFS.Path,
+		Offline:    true,
+		FileOwners: rootFS.Owners,
+	})
+	defer resolver.Close()
+
+	now := time.Now()
+	for _, benchmark := range benchmarks {
+		for _, rule := range benchmark.Rules {
+			report.rules[rule.ID] = rule
+
+			var events []*CheckEvent
+			if rule.IsRego() {
+				log.Debugf("running offline check for rule=%s on %s", rule.ID, opts.RootPath)
+				events = ResolveAndEvaluateRegoRule(ctx, resolver, benchmark, rule)
+			} else {
+				events = []*CheckEvent{NewCheckSkipped(RegoEvaluator, fmt.Errorf("rule %s can't be evaluated offline", rule.ID), "", "", rule, benchmark)}
+			}
+			for _, event := range events {
+				opts.Waivers.Apply(event, hostname, now)
+				report.addEvent(event)
+			}
+		}
+	}
+	return report, nil
+}
+
+func (r *OfflineReport) addEvent(event *CheckEvent) {
+	switch event.Result {
+	case CheckPassed:
+		r.Summary.Passed++
+	case CheckFailed:
+		r.Summary.Failed++
+	case CheckError:
+		r.Summary.Error++
+	case CheckSkipped:
+		r.Summary.Skipped++
+	case CheckWaived:
+		r.Summary.Waived++
+	}
+	r.Events = append(r.Events, event)
+}
+
+// HasFailures returns whether some of the rules failed
+func (r *OfflineReport) HasFailures() bool {
+	return r.Summary.Failed > 0
+}
+
+// WriteJSON writes the report as JSON
+func (r *OfflineReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string            `json:"id"`
+	ShortDescription sarifMessage      `json:"shortDescription"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Kind         string             `json:"kind"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// WriteSARIF writes the report in the SARIF format, the failed rules being
+// reported as errors and the waived ones as suppressed errors
+func (r *OfflineReport) WriteSARIF(w io.Writer) error {
+	driver := sarifDriver{
+		Name:    "datadog-agent-compliance",
+		Version: version.AgentVersion,
+		Rules:   make([]sarifRule, 0, len(r.rules)),
+	}
+	for _, benchmark := range r.Benchmarks {
+		for _, rule := range benchmark.Rules {
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               rule.ID,
+				ShortDescription: sarifMessage{Text: rule.Description},
+				Properties: map[string]string{
+					"framework": benchmark.FrameworkID,
+					"version":   benchmark.Version,
+				},
+			})
+		}
+	}
+	sort.SliceStable(driver.Rules, func(i, j int) bool {
+		return driver.Rules[i].ID < driver.Rules[j].ID
+	})
+
+	results := make([]sarifResult, 0, len(r.Events))
+	for _, event := range r.Events {
+		result := sarifResult{
+			RuleID:  event.RuleID,
+			Message: sarifMessage{Text: r.eventMessage(event)},
+		}
+		switch event.Result {
+		case CheckPassed:
+			result.Kind, result.Level = "pass", "none"
+		case CheckFailed:
+			result.Kind, result.Level = "fail", "error"
+		case CheckWaived:
+			result.Kind, result.Level = "fail", "error"
+			result.Suppressions = []sarifSuppression{{
+				Kind:          "external",
+				Justification: event.Waiver.Justification,
+			}}
+		case CheckError:
+			result.Kind, result.Level = "review", "warning"
+		default:
+			result.Kind, result.Level = "notApplicable", "none"
+		}
+		if event.ResourceID != "" {
+			result.Locations = []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name: event.ResourceID,
+					Kind: event.ResourceType,
+				}},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	})
+}
+
+func (r *OfflineReport) eventMessage(event *CheckEvent) string {
+	description := event.RuleID
+	if rule, ok := r.rules[event.RuleID]; ok && rule.Description != "" {
+		description = rule.Description
+	}
+	switch event.Result {
+	case CheckError, CheckSkipped:
+		if event.errReason != nil {
+			return fmt.Sprintf("%s: %s", description, event.errReason)
+		}
+	case CheckWaived:
+		return fmt.Sprintf("%s: waived until %s: %s", description, event.Waiver.ExpireAt.Format(time.RFC3339), event.Waiver.Justification)
+	}
+	if event.ResourceID != "" {
+		return fmt.Sprintf("%s: %s on %s %s", description, event.Result, event.ResourceType, event.ResourceID)
+	}
+	return fmt.Sprintf("%s: %s", description, event.Result)
+}
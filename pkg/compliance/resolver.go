@@ -25,6 +25,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/version"
 	"github.com/DataDog/datadog-go/v5/statsd"
 
+	securejoin "github.com/cyphar/filepath-securejoin"
+
 	dockertypes "github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
 
@@ -52,12 +54,18 @@ var ErrIncompatibleEnvironment = errors.New("environment not compatible this typ
 type DockerProvider func(context.Context) (docker.CommonAPIClient, error)
 type KubernetesProvider func(context.Context) (kubedynamic.Interface, error)
 type LinuxAuditProvider func(context.Context) (LinuxAuditClient, error)
+type SystemdProvider func(context.Context) (SystemdClient, error)
 
 type LinuxAuditClient interface {
 	GetFileWatchRules() ([]*auditrule.FileWatchRule, error)
 	Close() error
 }
 
+type SystemdClient interface {
+	GetUnitProperties(unit string) (map[string]interface{}, error)
+	Close()
+}
+
 func DefaultDockerProvider(ctx context.Context) (docker.CommonAPIClient, error) {
 	return newDockerClient(ctx)
 }
@@ -66,14 +74,28 @@ func DefaultLinuxAuditProvider(ctx context.Context) (LinuxAuditClient, error) {
 	return newLinuxAuditClient()
 }
 
+func DefaultSystemdProvider(ctx context.Context) (SystemdClient, error) {
+	return newSystemdClient(ctx)
+}
+
 type ResolverOptions struct {
 	Hostname     string
 	HostRoot     string
 	StatsdClient statsd.ClientInterface
 
+	// Offline restricts the resolution to the inputs that only depend on the
+	// filesystem at HostRoot, the rules with inputs depending on the live
+	// host, like processes or audit rules, are skipped. The symlinks are
+	// resolved inside HostRoot.
+	Offline bool
+	// FileOwners overrides the ownership of the files of HostRoot in offline
+	// mode, by absolute path in HostRoot. See RootFS.
+	FileOwners map[string]FileOwnership
+
 	DockerProvider
 	KubernetesProvider
 	LinuxAuditProvider
+	SystemdProvider
 }
 
 // Resolver interface defines a generic method to resolve the inputs
@@ -89,11 +111,15 @@ type defaultResolver struct {
 
 	procsCache         []*process.Process
 	filesCache         []fileMeta
+	packagesCache      []*packageInfo
+	usersCache         map[string]string
+	groupsCache        map[string]string
 	kubeClusterIDCache string
 
 	dockerCl     docker.CommonAPIClient
 	kubernetesCl kubedynamic.Interface
 	linuxAuditCl LinuxAuditClient
+	systemdCl    SystemdClient
 }
 
 type fileMeta struct {
@@ -122,6 +148,9 @@ func NewResolver(ctx context.Context, opts ResolverOptions) Resolver {
 	if opts.LinuxAuditProvider != nil {
 		r.linuxAuditCl, _ = opts.LinuxAuditProvider(ctx)
 	}
+	if opts.SystemdProvider != nil {
+		r.systemdCl, _ = opts.SystemdProvider(ctx)
+	}
 	return r
 }
 
@@ -134,10 +163,17 @@ func (r *defaultResolver) Close() {
 		r.linuxAuditCl.Close()
 		r.linuxAuditCl = nil
 	}
+	if r.systemdCl != nil {
+		r.systemdCl.Close()
+		r.systemdCl = nil
+	}
 	r.kubernetesCl = nil
 
 	r.procsCache = nil
 	r.filesCache = nil
+	r.packagesCache = nil
+	r.usersCache = nil
+	r.groupsCache = nil
 	r.kubeClusterIDCache = ""
 }
 
@@ -165,6 +201,13 @@ func (r *defaultResolver) ResolveInputs(ctx_ context.Context, rule *Rule) (Resol
 	if len(rule.InputSpecs) == 0 {
 		return nil, fmt.Errorf("no inputs for rule %s", rule.ID)
 	}
+	if r.opts.Offline {
+		for _, spec := range rule.InputSpecs {
+			if !isResolvableOffline(spec) {
+				return nil, ErrIncompatibleEnvironment
+			}
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(ctx_, inputsResolveTimeout)
 	defer cancel()
@@ -198,6 +241,21 @@ func (r *defaultResolver) ResolveInputs(ctx_ context.Context, rule *Rule) (Resol
 			resultType = "kubernetes"
 			result, err = r.resolveKubeApiserver(ctx, *spec.KubeApiserver)
 			kubernetesCluster = r.resolveKubeClusterID(ctx)
+		case spec.Package != nil:
+			resultType = "package"
+			result, err = r.resolvePackage(ctx, *spec.Package)
+		case spec.Sysctl != nil:
+			resultType = "sysctl"
+			result, err = r.resolveSysctl(ctx, *spec.Sysctl)
+		case spec.KernelModule != nil:
+			resultType = "kernelModule"
+			result, err = r.resolveKernelModule(ctx, *spec.KernelModule)
+		case spec.Systemd != nil:
+			resultType = "systemd"
+			result, err = r.resolveSystemd(ctx, *spec.Systemd)
+		case spec.ListeningSocket != nil:
+			resultType = "listeningSocket"
+			result, err = r.resolveListeningSocket(ctx, *spec.ListeningSocket)
 		case spec.Constants != nil:
 			resultType = "constants"
 			result = *spec.Constants
@@ -281,6 +339,18 @@ func (r *defaultResolver) pathNormalizeToHostRoot(path string) string {
 	return path
 }
 
+// pathResolveInHostRoot returns the path on the host of the given path of the
+// host root. In offline mode, its symlinks are resolved inside the host root,
+// as the absolute ones are relative to it rather than to the host.
+func (r *defaultResolver) pathResolveInHostRoot(path string) string {
+	if r.opts.Offline && r.opts.HostRoot != "" {
+		if resolved, err := securejoin.SecureJoin(r.opts.HostRoot, path); err == nil {
+			return resolved
+		}
+	}
+	return r.pathNormalizeToHostRoot(path)
+}
+
 func (r *defaultResolver) pathRelativeToHostRoot(path string) string {
 	if r.opts.HostRoot != "" {
 		p, err := filepath.Rel(r.opts.HostRoot, path)
@@ -299,14 +369,18 @@ func (r *defaultResolver) getFileMeta(path string) (*fileMeta, error) {
 			return &f, nil
 		}
 	}
-	info, err := os.Stat(path)
+	hostPath := path
+	if r.opts.Offline {
+		hostPath = r.pathResolveInHostRoot(r.pathRelativeToHostRoot(path))
+	}
+	info, err := os.Stat(hostPath)
 	if err != nil {
 		return nil, err
 	}
 	perms := uint64(info.Mode() & os.ModePerm)
 	var data []byte
 	if !info.IsDir() {
-		data, err = os.ReadFile(path)
+		data, err = os.ReadFile(hostPath)
 		if err != nil {
 			return nil, err
 		}
@@ -315,8 +389,22 @@ func (r *defaultResolver) getFileMeta(path string) (*fileMeta, error) {
 		path:  path,
 		data:  data,
 		perms: perms,
-		user:  getFileUser(info),
-		group: getFileGroup(info),
+	}
+	if r.opts.Offline {
+		uid, gid, ok := getFileOwner(info)
+		if owner, found := r.opts.FileOwners[r.pathRelativeToHostRoot(hostPath)]; found {
+			// the files extracted from tarballs may not have their owners
+			uid, gid, ok = strconv.Itoa(owner.UID), strconv.Itoa(owner.GID), true
+			file.perms = uint64(owner.Perms & os.ModePerm)
+		}
+		// the names of the owners are the ones of the root filesystem, not of the host
+		if ok {
+			file.user = r.lookupName(&r.usersCache, "/etc/passwd", uid)
+			file.group = r.lookupName(&r.groupsCache, "/etc/group", gid)
+		}
+	} else {
+		file.user = getFileUser(info)
+		file.group = getFileGroup(info)
 	}
 	r.filesCache = append(r.filesCache, *file)
 	if len(r.filesCache) > maxFilesCached {
@@ -327,6 +415,46 @@ func (r *defaultResolver) getFileMeta(path string) (*fileMeta, error) {
 
 var processFlagBuiltinReg = regexp.MustCompile(`process\.flag\("(\S+)",\s*"(\S+)"\)`)
 
+// isResolvableOffline returns whether an input only depends on the filesystem
+func isResolvableOffline(spec *InputSpec) bool {
+	switch {
+	case spec.File != nil:
+		return !processFlagBuiltinReg.MatchString(spec.File.Path)
+	case spec.Group != nil, spec.Package != nil, spec.Constants != nil:
+		return true
+	}
+	return false
+}
+
+// lookupName returns the name of a user or group from its ID, as defined in
+// the passwd or group file of the host root.
+func (r *defaultResolver) lookupName(cache *map[string]string, path string, id string) string {
+	if *cache == nil {
+		*cache = make(map[string]string)
+		f, err := os.Open(r.pathResolveInHostRoot(path))
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if len(line) == 0 || line[0] == '#' {
+				continue
+			}
+			// name:password:id:...
+			parts := strings.SplitN(line, ":", 4)
+			if len(parts) < 3 {
+				continue
+			}
+			if _, ok := (*cache)[parts[2]]; !ok {
+				(*cache)[parts[2]] = parts[0]
+			}
+		}
+	}
+	return (*cache)[id]
+}
+
 func (r *defaultResolver) resolveFile(ctx context.Context, spec InputSpecFile) (result interface{}, err error) {
 	path := strings.TrimSpace(spec.Path)
 	if matches := processFlagBuiltinReg.FindStringSubmatch(path); len(matches) == 3 {
@@ -473,7 +601,7 @@ func (r *defaultResolver) getProcs(ctx context.Context) ([]*process.Process, err
 }
 
 func (r *defaultResolver) resolveGroup(ctx context.Context, spec InputSpecGroup) (interface{}, error) {
-	f, err := os.Open("/etc/group")
+	f, err := os.Open(r.pathResolveInHostRoot("/etc/group"))
 	if err != nil {
 		return nil, err
 	}
@@ -546,6 +674,38 @@ func (r *defaultResolver) resolveAudit(ctx context.Context, spec InputSpecAudit)
 	return resolved, nil
 }
 
+func (r *defaultResolver) resolveSystemd(ctx context.Context, spec InputSpecSystemd) (interface{}, error) {
+	cl := r.systemdCl
+	if cl == nil {
+		return nil, ErrIncompatibleEnvironment
+	}
+	if spec.Unit == "" {
+		return nil, fmt.Errorf("missing systemd unit name")
+	}
+	unit := spec.Unit
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	props, err := cl.GetUnitProperties(unit)
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]interface{}, len(spec.Properties))
+	for _, name := range spec.Properties {
+		if value, ok := props[name]; ok {
+			properties[name] = value
+		}
+	}
+	return map[string]interface{}{
+		"name":          unit,
+		"loadState":     props["LoadState"],
+		"activeState":   props["ActiveState"],
+		"subState":      props["SubState"],
+		"unitFileState": props["UnitFileState"],
+		"properties":    properties,
+	}, nil
+}
+
 func (r *defaultResolver) resolveDocker(ctx context.Context, spec InputSpecDocker) (interface{}, error) {
 	cl := r.dockerCl
 	if cl == nil {
@@ -0,0 +1,540 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// whiteoutPrefix marks the files removed by a layer of an image
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaque marks the directories whose content is replaced by a layer of an image
+	whiteoutOpaque = ".wh..wh..opq"
+
+	ociLayoutFile      = "oci-layout"
+	ociIndexFile       = "index.json"
+	dockerManifestFile = "manifest.json"
+
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+	dockerListMediaType  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+)
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type dockerManifest struct {
+	Layers []string `json:"Layers"`
+}
+
+// FileOwnership holds the owners and the permissions of a file of a root
+// filesystem, as recorded in its tarballs.
+type FileOwnership struct {
+	UID   int
+	GID   int
+	Perms os.FileMode
+}
+
+// RootFS is a root filesystem opened by OpenRootFS.
+type RootFS struct {
+	// Path is the directory holding the root filesystem.
+	Path string
+	// Owners holds the ownership of the files extracted from tarballs, by
+	// absolute path in the root filesystem, as it can't be kept when they
+	// are extracted without privileges. It is nil for directories.
+	Owners map[string]FileOwnership
+
+	cleanup func()
+}
+
+// Close removes the files extracted from tarballs.
+func (fs *RootFS) Close() {
+	if fs.cleanup != nil {
+		fs.cleanup()
+	}
+}
+
+// OpenRootFS returns the root filesystem at the given path, which can be a
+// directory holding the root filesystem, an OCI image layout directory, an
+// image tarball as created by `docker save` or a tarball of a root filesystem.
+// The images are flattened in a temporary directory removed by Close.
+func OpenRootFS(rootPath string) (*RootFS, error) {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() && !isFile(filepath.Join(rootPath, ociLayoutFile)) {
+		return &RootFS{Path: rootPath}, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "compliance-rootfs-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	imageDir := rootPath
+	var imageOwners map[string]FileOwnership
+	if !info.IsDir() {
+		imageDir = filepath.Join(tmpDir, "image")
+		ex := newTarExtractor(imageDir, false)
+		if err := ex.extract(rootPath); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("could not extract %s: %w", rootPath, err)
+		}
+		ex.finish()
+		imageOwners = ex.owners
+	}
+
+	var layers []string
+	switch {
+	case isFile(filepath.Join(imageDir, ociLayoutFile)):
+		layers, err = ociImageLayers(imageDir)
+	case isFile(filepath.Join(imageDir, dockerManifestFile)):
+		layers, err = dockerImageLayers(imageDir)
+	default:
+		// a tarball of a root filesystem
+		return &RootFS{Path: imageDir, Owners: imageOwners, cleanup: cleanup}, nil
+	}
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("could not read image %s: %w", rootPath, err)
+	}
+
+	rootFS := filepath.Join(tmpDir, "rootfs")
+	ex := newTarExtractor(rootFS, true)
+	for _, layer := range layers {
+		if err := ex.extract(layer); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("could not extract layer %s: %w", layer, err)
+		}
+	}
+	ex.finish()
+	return &RootFS{Path: rootFS, Owners: ex.owners, cleanup: cleanup}, nil
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ociImageLayers returns the paths of the layers of the image of an OCI image
+// layout, from the lowest to the uppermost one. With multi-platform images,
+// the image for the linux platform of the agent is selected.
+func ociImageLayers(dir string) ([]string, error) {
+	var index ociIndex
+	if err := readJSONFile(filepath.Join(dir, ociIndexFile), &index); err != nil {
+		return nil, err
+	}
+	for {
+		desc, err := selectManifest(index.Manifests)
+		if err != nil {
+			return nil, err
+		}
+		blob, err := ociBlobPath(dir, desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		switch desc.MediaType {
+		case ociIndexMediaType, dockerListMediaType:
+			index = ociIndex{}
+			if err := readJSONFile(blob, &index); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var manifest ociManifest
+		if err := readJSONFile(blob, &manifest); err != nil {
+			return nil, err
+		}
+		layers := make([]string, 0, len(manifest.Layers))
+		for _, layer := range manifest.Layers {
+			path, err := ociBlobPath(dir, layer.Digest)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, path)
+		}
+		return layers, nil
+	}
+}
+
+func selectManifest(manifests []ociDescriptor) (*ociDescriptor, error) {
+	if len(manifests) == 0 {
+		return nil, errors.New("no image manifest found")
+	}
+	if len(manifests) == 1 {
+		return &manifests[0], nil
+	}
+	for i, desc := range manifests {
+		if desc.Platform != nil && desc.Platform.OS == "linux" && desc.Platform.Architecture == runtime.GOARCH {
+			return &manifests[i], nil
+		}
+	}
+	for i, desc := range manifests {
+		if desc.MediaType == ociManifestMediaType || desc.MediaType == "" {
+			return &manifests[i], nil
+		}
+	}
+	return &manifests[0], nil
+}
+
+func ociBlobPath(dir, digest string) (string, error) {
+	algorithm, hash, ok := strings.Cut(digest, ":")
+	if !ok || strings.ContainsAny(algorithm+hash, `/\.`) {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return filepath.Join(dir, "blobs", algorithm, hash), nil
+}
+
+// dockerImageLayers returns the paths of the layers of the image of a docker
+// image tarball, from the lowest to the uppermost one.
+func dockerImageLayers(dir string) ([]string, error) {
+	var manifests []dockerManifest
+	if err := readJSONFile(filepath.Join(dir, dockerManifestFile), &manifests); err != nil {
+		return nil, err
+	}
+	if len(manifests) == 0 {
+		return nil, errors.New("no image manifest found")
+	}
+	layers := make([]string, 0, len(manifests[0].Layers))
+	for _, layer := range manifests[0].Layers {
+		name, err := securePath(dir, layer)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, name)
+	}
+	return layers, nil
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// tarExtractor extracts tarballs in a directory. When extracting the layers
+// of an image, the whiteout files remove the files of the lower layers.
+type tarExtractor struct {
+	dir   string
+	layer bool
+	// the modes of the directories are applied once all the files are extracted
+	dirModes map[string]os.FileMode
+	// owners holds the ownership of the extracted files by absolute path in
+	// the extracted tree
+	owners map[string]FileOwnership
+}
+
+func newTarExtractor(dir string, layer bool) *tarExtractor {
+	return &tarExtractor{
+		dir:      dir,
+		layer:    layer,
+		dirModes: make(map[string]os.FileMode),
+		owners:   make(map[string]FileOwnership),
+	}
+}
+
+// extract extracts a tarball, compressed with gzip or not
+func (ex *tarExtractor) extract(tarPath string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var reader io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	if err := os.MkdirAll(ex.dir, 0o755); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		target, err := securePath(ex.dir, name)
+		if err != nil {
+			// a single entry doesn't fail the whole extraction
+			log.Debugf("skipping %s of %s: %v", hdr.Name, tarPath, err)
+			continue
+		}
+
+		if ex.layer {
+			base := path.Base(name)
+			if base == whiteoutOpaque {
+				if err := removeDirContent(filepath.Dir(target)); err != nil {
+					return err
+				}
+				ex.removeOwners(path.Dir(name), false)
+				continue
+			}
+			if strings.HasPrefix(base, whiteoutPrefix) {
+				removed := filepath.Join(filepath.Dir(target), strings.TrimPrefix(base, whiteoutPrefix))
+				if err := os.RemoveAll(removed); err != nil {
+					return err
+				}
+				ex.removeOwners(path.Join(path.Dir(name), strings.TrimPrefix(base, whiteoutPrefix)), true)
+				continue
+			}
+		}
+
+		if err := ex.extractEntry(tr, hdr, name, target); err != nil {
+			return fmt.Errorf("could not extract %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func (ex *tarExtractor) extractEntry(tr *tar.Reader, hdr *tar.Header, name, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(hdr.Mode).Perm() | tarModeBits(hdr.Mode)
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+		ex.dirModes[target] = mode
+	case tar.TypeReg:
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		ex.removeOwners(name, true)
+		return os.Symlink(rootRelativeLink(name, hdr.Linkname), target)
+	case tar.TypeLink:
+		source, err := securePath(ex.dir, hdr.Linkname)
+		if err != nil {
+			log.Debugf("skipping the hard link %s: %v", hdr.Name, err)
+			return nil
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Link(source, target); err != nil {
+			return err
+		}
+	default:
+		// devices, fifos and others are skipped
+		return nil
+	}
+
+	// the owners are recorded rather than set on the extracted files, as
+	// setting them requires privileges
+	ex.owners[name] = FileOwnership{UID: hdr.Uid, GID: hdr.Gid, Perms: mode}
+	if hdr.Typeflag == tar.TypeReg {
+		return os.Chmod(target, mode)
+	}
+	return nil
+}
+
+// removeOwners forgets the ownership of the files under the given directory,
+// and of the directory itself if withDir is set
+func (ex *tarExtractor) removeOwners(dir string, withDir bool) {
+	if withDir {
+		delete(ex.owners, dir)
+	}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for name := range ex.owners {
+		if strings.HasPrefix(name, prefix) {
+			delete(ex.owners, name)
+		}
+	}
+}
+
+// rootRelativeLink returns the target of the symlink at the given absolute
+// path of the root filesystem, relative to the directory of the symlink. The
+// absolute targets are relative to the root filesystem rather than the host,
+// and the relative ones can't go above the root filesystem either.
+func rootRelativeLink(name, linkname string) string {
+	dir := path.Dir(name)
+	target := path.Join(dir, linkname)
+	if path.IsAbs(linkname) {
+		target = path.Clean(linkname)
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(target))
+	if err != nil {
+		return linkname
+	}
+	return rel
+}
+
+// finish applies the modes of the directories, the deepest ones first
+func (ex *tarExtractor) finish() {
+	dirs := make([]string, 0, len(ex.dirModes))
+	for dir := range ex.dirModes {
+		dirs = append(dirs, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if err := os.Chmod(dir, ex.dirModes[dir]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Debugf("could not set the mode of %s: %v", dir, err)
+		}
+	}
+}
+
+// tarModeBits converts the setuid, setgid and sticky bits of a tar mode
+func tarModeBits(mode int64) os.FileMode {
+	var m os.FileMode
+	if mode&04000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// maxSymlinks is the maximum number of symlinks followed to resolve a path,
+// as on Linux
+const maxSymlinks = 40
+
+// securePath returns the path of name in dir. The symlinks of the parent
+// directories of name are resolved as if dir was the root directory, so that
+// the path never escapes dir, either with `..` or through a symlink.
+func securePath(dir, name string) (string, error) {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	parent, err := resolveInRoot(dir, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(path.Join(parent, path.Base(name)))), nil
+}
+
+// resolveInRoot resolves the symlinks of the absolute path name of the root
+// filesystem extracted in dir, and returns the resolved absolute path.
+func resolveInRoot(dir, name string) (string, error) {
+	resolved := "/"
+	remaining := strings.Split(name, "/")
+	links := 0
+	for len(remaining) > 0 {
+		part := remaining[0]
+		remaining = remaining[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		current := path.Join(resolved, part)
+		hostPath := filepath.Join(dir, filepath.FromSlash(current))
+		info, err := os.Lstat(hostPath)
+		if errors.Is(err, os.ErrNotExist) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			resolved = current
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links in %s", name)
+		}
+		link, err := os.Readlink(hostPath)
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(filepath.ToSlash(link)) {
+			resolved = "/"
+		}
+		remaining = append(strings.Split(filepath.ToSlash(link), "/"), remaining...)
+	}
+	return resolved, nil
+}
+
+func removeDirContent(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
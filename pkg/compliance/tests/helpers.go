@@ -27,11 +27,13 @@ import (
 type suite struct {
 	t        *testing.T
 	hostname string
+	hostRoot string
 	rootDir  string
 
-	dockerClient docker.CommonAPIClient
-	auditClient  compliance.LinuxAuditClient
-	kubeClient   dynamic.Interface
+	dockerClient  docker.CommonAPIClient
+	auditClient   compliance.LinuxAuditClient
+	kubeClient    dynamic.Interface
+	systemdClient compliance.SystemdClient
 
 	rules []*assertedRule
 }
@@ -65,6 +67,11 @@ func (s *suite) WithHostname(hostname string) *suite {
 	return s
 }
 
+func (s *suite) WithHostRoot(hostRoot string) *suite {
+	s.hostRoot = hostRoot
+	return s
+}
+
 func (s *suite) WithDockerClient(cl docker.CommonAPIClient) *suite {
 	s.dockerClient = cl
 	return s
@@ -80,6 +87,11 @@ func (s *suite) WithKubeClient(cl dynamic.Interface) *suite {
 	return s
 }
 
+func (s *suite) WithSystemdClient(cl compliance.SystemdClient) *suite {
+	s.systemdClient = cl
+	return s
+}
+
 func (s *suite) AddRule(name string) *assertedRule {
 	for _, rule := range s.rules {
 		if rule.name == name {
@@ -103,6 +115,7 @@ func (s *suite) Run() {
 		s.t.Run(c.name, func(t *testing.T) {
 			options := compliance.ResolverOptions{
 				Hostname: s.hostname,
+				HostRoot: s.hostRoot,
 			}
 			if s.auditClient != nil {
 				options.LinuxAuditProvider = func(ctx context.Context) (compliance.LinuxAuditClient, error) { return s.auditClient, nil }
@@ -113,6 +126,9 @@ func (s *suite) Run() {
 			if s.kubeClient != nil {
 				options.KubernetesProvider = func(ctx context.Context) (dynamic.Interface, error) { return s.kubeClient, nil }
 			}
+			if s.systemdClient != nil {
+				options.SystemdProvider = func(ctx context.Context) (compliance.SystemdClient, error) { return s.systemdClient, nil }
+			}
 			c.run(t, options)
 		})
 	}
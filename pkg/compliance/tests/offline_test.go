@@ -0,0 +1,443 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package tests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const offlineSuite = `schema:
+  version: 1.0.0
+name: offline
+framework: offline
+version: 1.0.0
+rules:
+  - id: shadow_permissions
+    description: shadow file is not world readable
+    input:
+      - file:
+          path: /etc/shadow
+  - id: telnet_removed
+    description: telnet is not installed
+    input:
+      - package:
+          name: telnet
+  - id: sshd_config_removed
+    description: sshd configuration is not present
+    input:
+      - file:
+          path: /etc/ssh/sshd_config
+  - id: process_running
+    description: the process runs with a flag
+    input:
+      - process:
+          name: sshd
+`
+
+const offlineShadowRego = `package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.file.permissions == 416
+	input.file.user == "imageuser"
+	input.file.group == "imagegroup"
+	f := dd.passed_finding("file", input.file.path, {})
+}
+
+findings[f] {
+	input.file.permissions != 416
+	f := dd.failing_finding("file", input.file.path, {})
+}
+`
+
+const offlineTelnetRego = `package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	not has_key(input, "package")
+	f := dd.passed_finding("package", "telnet", {})
+}
+
+findings[f] {
+	has_key(input, "package")
+	f := dd.failing_finding("package", "telnet", {})
+}
+`
+
+const offlineSshdRego = `package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	not has_key(input, "file")
+	f := dd.passed_finding("file", "/etc/ssh/sshd_config", {})
+}
+
+findings[f] {
+	has_key(input, "file")
+	f := dd.failing_finding("file", "/etc/ssh/sshd_config", {})
+}
+`
+
+const offlineProcessRego = `package datadog
+import data.datadog as dd
+
+findings[f] {
+	f := dd.passed_finding("process", "sshd", {})
+}
+`
+
+type tarEntry struct {
+	name     string
+	data     string
+	mode     int64
+	typeflag byte
+	linkname string
+}
+
+// offlineOwnerID is the owner of the files of the test images. It is root,
+// which can't be set on the extracted files by the users running the tests,
+// and is named differently in the images so that the names are resolved from
+// the images and not the host.
+const offlineOwnerID = 0
+
+func writeTar(t *testing.T, path string, compress bool, entries []tarEntry) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{
+			Name:     e.name,
+			Mode:     e.mode,
+			Typeflag: typeflag,
+			Linkname: e.linkname,
+			Uid:      offlineOwnerID,
+			Gid:      offlineOwnerID,
+		}
+		if typeflag == tar.TypeReg {
+			hdr.Size = int64(len(e.data))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(e.data))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	if compress {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		_, err := zw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		data = zbuf.Bytes()
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func loadOfflineBenchmarks(t *testing.T) []*compliance.Benchmark {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"offline.yaml":             offlineSuite,
+		"shadow_permissions.rego":  offlineShadowRego,
+		"telnet_removed.rego":      offlineTelnetRego,
+		"sshd_config_removed.rego": offlineSshdRego,
+		"process_running.rego":     offlineProcessRego,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+	}
+	benchmarks, err := compliance.LoadBenchmarks(dir, "offline.yaml", nil)
+	require.NoError(t, err)
+	require.Len(t, benchmarks, 1)
+	return benchmarks
+}
+
+func offlineBaseLayer() []tarEntry {
+	return []tarEntry{
+		{name: "etc/", mode: 0o755, typeflag: tar.TypeDir},
+		{name: "etc/passwd", mode: 0o644, data: fmt.Sprintf("imageuser:x:%d:%d::/root:/bin/sh\n", offlineOwnerID, offlineOwnerID)},
+		{name: "etc/group", mode: 0o644, data: fmt.Sprintf("imagegroup:x:%d:\n", offlineOwnerID)},
+		{name: "etc/shadow", mode: 0o640, data: "root:*:19000:0:99999:7:::\n"},
+		{name: "etc/ssh/", mode: 0o755, typeflag: tar.TypeDir},
+		{name: "etc/ssh/sshd_config", mode: 0o644, data: "PermitRootLogin yes\n"},
+		{name: "var/lib/dpkg/", mode: 0o755, typeflag: tar.TypeDir},
+		{name: "var/lib/dpkg/status", mode: 0o644, data: testDpkgStatus},
+	}
+}
+
+func assertOfflineReport(t *testing.T, report *compliance.OfflineReport, sshdRemoved bool) {
+	results := make(map[string]string)
+	for _, event := range report.Events {
+		results[event.RuleID] = string(event.Result)
+	}
+	assert.Equal(t, "passed", results["shadow_permissions"])
+	assert.Equal(t, "passed", results["telnet_removed"])
+	assert.Equal(t, "skipped", results["process_running"])
+	if sshdRemoved {
+		assert.Equal(t, "passed", results["sshd_config_removed"])
+		assert.Equal(t, compliance.OfflineSummary{Passed: 3, Skipped: 1}, report.Summary)
+		assert.False(t, report.HasFailures())
+	} else {
+		assert.Equal(t, "failed", results["sshd_config_removed"])
+		assert.Equal(t, compliance.OfflineSummary{Passed: 2, Failed: 1, Skipped: 1}, report.Summary)
+		assert.True(t, report.HasFailures())
+	}
+}
+
+func TestOfflineRootFSDirectory(t *testing.T) {
+	rootFS := writeHostFiles(t, map[string]string{
+		"/etc/passwd":          fmt.Sprintf("imageuser:x:%d:%d::/home:/bin/sh\n", os.Geteuid(), os.Getegid()),
+		"/etc/group":           fmt.Sprintf("imagegroup:x:%d:\n", os.Getegid()),
+		"/etc/shadow":          "root:*:19000:0:99999:7:::\n",
+		"/etc/ssh/sshd_config": "PermitRootLogin yes\n",
+		"/var/lib/dpkg/status": testDpkgStatus,
+	})
+	require.NoError(t, os.Chmod(filepath.Join(rootFS, "etc/shadow"), 0o640))
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: rootFS}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assert.Equal(t, rootFS, report.RootPath)
+	assertOfflineReport(t, report, false)
+}
+
+func TestOfflineRootFSTarball(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	writeTar(t, tarPath, true, offlineBaseLayer())
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: tarPath}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assertOfflineReport(t, report, false)
+}
+
+func TestOfflineDockerImage(t *testing.T) {
+	imageDir := t.TempDir()
+	writeTar(t, filepath.Join(imageDir, "base/layer.tar"), false, offlineBaseLayer())
+	writeTar(t, filepath.Join(imageDir, "top/layer.tar"), false, []tarEntry{
+		{name: "etc/ssh/.wh.sshd_config", mode: 0o644},
+	})
+	manifest := `[{"Config":"config.json","RepoTags":["test:latest"],"Layers":["base/layer.tar","top/layer.tar"]}]`
+	require.NoError(t, os.WriteFile(filepath.Join(imageDir, "manifest.json"), []byte(manifest), 0o644))
+
+	var entries []tarEntry
+	for _, name := range []string{"manifest.json", "base/layer.tar", "top/layer.tar"} {
+		data, err := os.ReadFile(filepath.Join(imageDir, name))
+		require.NoError(t, err)
+		entries = append(entries, tarEntry{name: name, mode: 0o644, data: string(data)})
+	}
+	imagePath := filepath.Join(t.TempDir(), "image.tar")
+	writeTar(t, imagePath, false, entries)
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: imagePath}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assertOfflineReport(t, report, true)
+}
+
+func TestOfflineOCIImage(t *testing.T) {
+	layoutDir := t.TempDir()
+	blobsDir := filepath.Join(layoutDir, "blobs", "sha256")
+	writeBlob := func(name string, data []byte) string {
+		path := filepath.Join(blobsDir, name)
+		require.NoError(t, os.MkdirAll(blobsDir, 0o755))
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return "sha256:" + name
+	}
+
+	writeTar(t, filepath.Join(blobsDir, "base"), true, offlineBaseLayer())
+	writeTar(t, filepath.Join(blobsDir, "top"), true, []tarEntry{
+		{name: "etc/ssh/.wh..wh..opq", mode: 0o644},
+	})
+	manifest := writeBlob("manifest", []byte(`{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"layers": [
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:base"},
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:top"}
+		]
+	}`))
+	index, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests": []map[string]interface{}{{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest":    manifest,
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(layoutDir, "index.json"), index, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(layoutDir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644))
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: layoutDir}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assertOfflineReport(t, report, true)
+}
+
+func TestOfflineRootFSEscape(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar")
+	outsideDir := t.TempDir()
+	writeTar(t, tarPath, false, []tarEntry{
+		{name: "link", typeflag: tar.TypeSymlink, linkname: outsideDir},
+		{name: "link/escaped", mode: 0o644, data: "foo"},
+	})
+
+	_, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: tarPath}, loadOfflineBenchmarks(t))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(outsideDir, "escaped"))
+}
+
+func TestOfflineAbsoluteSymlinks(t *testing.T) {
+	// a file of the host, which must not be read through the symlinks of the
+	// image
+	hostFile := filepath.Join(t.TempDir(), "sshd_config")
+	require.NoError(t, os.WriteFile(hostFile, []byte("PermitRootLogin yes\n"), 0o644))
+
+	var entries []tarEntry
+	for _, e := range offlineBaseLayer() {
+		switch e.name {
+		case "etc/shadow":
+			entries = append(entries,
+				tarEntry{name: "etc/shadow", typeflag: tar.TypeSymlink, linkname: "/etc/alternatives/shadow"},
+				tarEntry{name: "etc/alternatives/", mode: 0o755, typeflag: tar.TypeDir},
+				tarEntry{name: "etc/alternatives/shadow", typeflag: tar.TypeSymlink, linkname: "../../../../etc/shadow.real"},
+				tarEntry{name: "etc/shadow.real", mode: e.mode, data: e.data},
+			)
+		case "etc/ssh/sshd_config":
+			entries = append(entries, tarEntry{name: e.name, typeflag: tar.TypeSymlink, linkname: hostFile})
+		default:
+			entries = append(entries, e)
+		}
+	}
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar")
+	writeTar(t, tarPath, false, entries)
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: tarPath}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assertOfflineReport(t, report, true)
+}
+
+func TestOfflineSARIF(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar")
+	writeTar(t, tarPath, false, offlineBaseLayer())
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{RootPath: tarPath}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&buf))
+
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Kind   string `json:"kind"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &sarif))
+	assert.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs, 1)
+	run := sarif.Runs[0]
+	assert.Equal(t, "datadog-agent-compliance", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 4)
+
+	levels := make(map[string]string)
+	for _, result := range run.Results {
+		levels[result.RuleID] = result.Kind + "/" + result.Level
+	}
+	assert.Equal(t, "pass/none", levels["shadow_permissions"])
+	assert.Equal(t, "fail/error", levels["sshd_config_removed"])
+	assert.Equal(t, "notApplicable/none", levels["process_running"])
+
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(&buf))
+	var jsonReport compliance.OfflineReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &jsonReport))
+	assert.Equal(t, report.Summary, jsonReport.Summary)
+}
+
+func TestOfflineWaivers(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar")
+	writeTar(t, tarPath, false, offlineBaseLayer())
+
+	waivers, err := compliance.LoadWaivers(writeWaivers(t, `waivers:
+  - rule_id: sshd_config_removed
+    resource_id: /etc/ssh/*
+    expires: 2099-01-01
+    justification: the image is only run in an isolated network
+`))
+	require.NoError(t, err)
+
+	report, err := compliance.EvaluateOffline(context.Background(), compliance.OfflineOptions{
+		RootPath: tarPath,
+		Waivers:  waivers,
+	}, loadOfflineBenchmarks(t))
+	require.NoError(t, err)
+	assert.Equal(t, compliance.OfflineSummary{Passed: 2, Skipped: 1, Waived: 1}, report.Summary)
+	assert.False(t, report.HasFailures())
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&buf))
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				RuleID       string `json:"ruleId"`
+				Kind         string `json:"kind"`
+				Suppressions []struct {
+					Kind          string `json:"kind"`
+					Justification string `json:"justification"`
+				} `json:"suppressions"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &sarif))
+	require.Len(t, sarif.Runs, 1)
+	for _, result := range sarif.Runs[0].Results {
+		if result.RuleID != "sshd_config_removed" {
+			assert.Empty(t, result.Suppressions)
+			continue
+		}
+		assert.Equal(t, "fail", result.Kind)
+		require.Len(t, result.Suppressions, 1)
+		assert.Equal(t, "external", result.Suppressions[0].Kind)
+		assert.Equal(t, "the image is only run in an isolated network", result.Suppressions[0].Justification)
+	}
+}
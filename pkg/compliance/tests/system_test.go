@@ -0,0 +1,461 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeHostFiles(t *testing.T, files map[string]string) string {
+	hostRoot := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(hostRoot, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hostRoot
+}
+
+const testDpkgStatus = `Package: openssh-server
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Version: 1:8.9p1-3ubuntu0.1
+Description: secure shell (SSH) server, for secure access from remote machines
+ This is the portable version of OpenSSH.
+
+Package: telnet
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 0.17-44build1
+`
+
+const testApkInstalled = `C:Q1abc=
+P:busybox
+V:1.36.0-r9
+A:x86_64
+
+P:musl
+V:1.2.3-r4
+A:x86_64
+`
+
+func TestPackageInput(t *testing.T) {
+	hostRoot := writeHostFiles(t, map[string]string{
+		"/var/lib/dpkg/status":  testDpkgStatus,
+		"/lib/apk/db/installed": testApkInstalled,
+	})
+
+	b := NewTestBench(t).WithHostRoot(hostRoot)
+	defer b.Run()
+
+	b.AddRule("DpkgInstalled").
+		WithInput(`
+- package:
+		name: openssh-server
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input["package"].name == "openssh-server"
+	input["package"].version == "1:8.9p1-3ubuntu0.1"
+	input["package"].arch == "amd64"
+	input["package"].manager == "dpkg"
+	f := dd.passed_finding(
+		"package",
+		"openssh-server",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("DpkgRemoved").
+		WithInput(`
+- package:
+		name: telnet
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	not has_key(input, "package")
+	f := dd.passed_finding(
+		"package",
+		"telnet",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("ApkInstalled").
+		WithInput(`
+- package:
+		name: busybox
+	tag: busybox
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.busybox.version == "1.36.0-r9"
+	input.busybox.manager == "apk"
+	f := dd.passed_finding(
+		"package",
+		"busybox",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+}
+
+func TestSysctlInput(t *testing.T) {
+	hostRoot := writeHostFiles(t, map[string]string{
+		"/proc/sys/net/ipv4/ip_forward":              "0\n",
+		"/proc/sys/net/ipv4/conf/eth0.1/forwarding":  "1\n",
+		"/proc/sys/net/ipv4/tcp_rmem":                "4096\t131072\t6291456\n",
+		"/proc/sys/kernel/randomize_va_space":        "2\n",
+		"/proc/sys/net/ipv4/conf/all/send_redirects": "0\n",
+	})
+
+	b := NewTestBench(t).WithHostRoot(hostRoot)
+	defer b.Run()
+
+	b.AddRule("SysctlValue").
+		WithInput(`
+- sysctl:
+		name: net.ipv4.ip_forward
+	tag: forward
+- sysctl:
+		name: net.ipv4.conf.eth0/1.forwarding
+	tag: vlan
+- sysctl:
+		name: net.ipv4.tcp_rmem
+	tag: rmem
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.forward.name == "net.ipv4.ip_forward"
+	input.forward.value == "0"
+	input.vlan.value == "1"
+	input.rmem.value == "4096\t131072\t6291456"
+	f := dd.passed_finding(
+		"sysctl",
+		"net.ipv4.ip_forward",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("SysctlNotExist").
+		WithInput(`
+- sysctl:
+		name: net.ipv4.foo
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	not has_key(input, "sysctl")
+	f := dd.passed_finding(
+		"sysctl",
+		"net.ipv4.foo",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+}
+
+func TestKernelModuleInput(t *testing.T) {
+	hostRoot := writeHostFiles(t, map[string]string{
+		"/proc/modules": `nf_conntrack 172032 2 nf_nat,xt_conntrack, Live 0x0000000000000000
+overlay 151552 0 - Live 0x0000000000000000
+usb_storage 81920 1 uas, Loading 0x0000000000000000
+`,
+	})
+
+	b := NewTestBench(t).WithHostRoot(hostRoot)
+	defer b.Run()
+
+	b.AddRule("KernelModuleLoaded").
+		WithInput(`
+- kernelModule:
+		name: nf_conntrack
+	tag: conntrack
+- kernelModule:
+		name: usb-storage
+	tag: usb
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.conntrack.state == "live"
+	input.conntrack.refCount == 2
+	input.conntrack.usedBy == ["nf_nat", "xt_conntrack"]
+	input.usb.name == "usb_storage"
+	input.usb.state == "loading"
+	f := dd.passed_finding(
+		"kernel_module",
+		"nf_conntrack",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("KernelModuleNotLoaded").
+		WithInput(`
+- kernelModule:
+		name: cramfs
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	not has_key(input, "kernelModule")
+	f := dd.passed_finding(
+		"kernel_module",
+		"cramfs",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+}
+
+const testProcNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21987 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 34567 1 0000000000000000 100 0 0 10 0
+   2: 0F02000A:0016 0202000A:C4B6 01 00000000:00000000 02:0009A3C2 00000000     0        0 45678 2 0000000000000000 20 4 31 10 -1
+`
+
+const testProcNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000    33        0 23456 1 0000000000000000 100 0 0 10 0
+`
+
+const testProcNetUDP = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  512: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 19876 2 0000000000000000 0
+`
+
+func TestListeningSocketInput(t *testing.T) {
+	hostRoot := writeHostFiles(t, map[string]string{
+		"/proc/1/net/tcp":  testProcNetTCP,
+		"/proc/1/net/tcp6": testProcNetTCP6,
+		"/proc/1/net/udp":  testProcNetUDP,
+	})
+
+	b := NewTestBench(t).WithHostRoot(hostRoot)
+	defer b.Run()
+
+	b.AddRule("ListeningSockets").
+		WithInput(`
+- listeningSocket: {}
+	type: array
+	tag: sockets
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+valid(s) {
+	s.protocol == "tcp"
+	s.family == "ipv4"
+	s.address == "0.0.0.0"
+	s.port == 22
+	s.uid == 0
+	s.inode == 21987
+}
+
+findings[f] {
+	count(input.sockets) == 4
+	valid(input.sockets[0])
+	input.sockets[1].address == "127.0.0.1"
+	input.sockets[1].port == 3306
+	input.sockets[2].family == "ipv6"
+	input.sockets[2].address == "::"
+	input.sockets[2].port == 80
+	input.sockets[3].protocol == "udp"
+	input.sockets[3].address == "127.0.0.53"
+	input.sockets[3].port == 53
+	f := dd.passed_finding(
+		"listening_socket",
+		"all",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("ListeningSocketsFiltered").
+		WithInput(`
+- listeningSocket:
+		protocol: tcp
+		port: 22
+	type: array
+	tag: ssh
+- listeningSocket:
+		protocol: udp
+		port: 22
+	type: array
+	tag: udp
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+has_key(o, k) {
+	_ := o[k]
+}
+
+findings[f] {
+	count(input.ssh) == 1
+	input.ssh[0].port == 22
+	not has_key(input, "udp")
+	f := dd.passed_finding(
+		"listening_socket",
+		"ssh",
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("ListeningSocketsNotArray").
+		WithInput(`
+- listeningSocket:
+		port: 22
+`).
+		WithRego(`
+package datadog
+`).
+		AssertError()
+}
+
+type fakeSystemdClient struct {
+	units map[string]map[string]interface{}
+}
+
+func (c *fakeSystemdClient) GetUnitProperties(unit string) (map[string]interface{}, error) {
+	props, ok := c.units[unit]
+	if !ok {
+		return nil, fmt.Errorf("unit %s not found", unit)
+	}
+	return props, nil
+}
+
+func (c *fakeSystemdClient) Close() {}
+
+func TestSystemdInput(t *testing.T) {
+	cl := &fakeSystemdClient{
+		units: map[string]map[string]interface{}{
+			"sshd.service": {
+				"LoadState":     "loaded",
+				"ActiveState":   "active",
+				"SubState":      "running",
+				"UnitFileState": "enabled",
+				"User":          "root",
+				"Restart":       "on-failure",
+			},
+			"tmp.mount": {
+				"LoadState":     "loaded",
+				"ActiveState":   "active",
+				"SubState":      "mounted",
+				"UnitFileState": "static",
+				"Options":       "rw,nosuid,nodev,noexec",
+			},
+		},
+	}
+
+	b := NewTestBench(t).WithSystemdClient(cl)
+	defer b.Run()
+
+	b.AddRule("SystemdService").
+		WithInput(`
+- systemd:
+		unit: sshd
+		properties:
+			- Restart
+			- Unknown
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.systemd.name == "sshd.service"
+	input.systemd.activeState == "active"
+	input.systemd.unitFileState == "enabled"
+	input.systemd.properties == {"Restart": "on-failure"}
+	f := dd.passed_finding(
+		"systemd_unit",
+		input.systemd.name,
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+
+	b.AddRule("SystemdMount").
+		WithInput(`
+- systemd:
+		unit: tmp.mount
+		properties:
+			- Options
+	tag: tmp
+`).
+		WithRego(`
+package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.tmp.subState == "mounted"
+	contains(input.tmp.properties.Options, "noexec")
+	f := dd.passed_finding(
+		"systemd_unit",
+		input.tmp.name,
+		{},
+	)
+}
+`).
+		AssertPassedEvent(nil)
+}
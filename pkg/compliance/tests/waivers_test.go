@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWaivers = `waivers:
+  - rule_id: docker_privileged
+    resource_type: docker_container
+    resource_id: 3a5f*
+    expires: 2030-06-30
+    justification: privileged container required by the storage driver
+  - rule_id: file_permissions
+    hostname: build-*
+    resource_id: /etc/ssh/*
+    expires: 2030-01-01T12:00:00Z
+    justification: build hosts are ephemeral
+  - rule_id: file_permissions
+    expires: 2020-01-01
+    justification: expired waiver
+`
+
+func writeWaivers(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	return path
+}
+
+func newTestEvent(ruleID string, result compliance.CheckResult, resourceType, resourceID string) *compliance.CheckEvent {
+	return &compliance.CheckEvent{
+		RuleID:       ruleID,
+		Result:       result,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+}
+
+func TestWaivers(t *testing.T) {
+	waivers, err := compliance.LoadWaivers(writeWaivers(t, testWaivers))
+	require.NoError(t, err)
+	require.Len(t, waivers.Waivers, 3)
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	event := newTestEvent("docker_privileged", compliance.CheckFailed, "docker_container", "3a5f0123")
+	assert.True(t, waivers.Apply(event, "host", now))
+	assert.Equal(t, compliance.CheckWaived, event.Result)
+	require.NotNil(t, event.Waiver)
+	assert.Equal(t, "privileged container required by the storage driver", event.Waiver.Justification)
+	assert.Equal(t, time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC), event.Waiver.ExpireAt)
+
+	event = newTestEvent("docker_privileged", compliance.CheckFailed, "docker_container", "b2c3d4")
+	assert.False(t, waivers.Apply(event, "host", now))
+	assert.Equal(t, compliance.CheckFailed, event.Result)
+	assert.Nil(t, event.Waiver)
+
+	event = newTestEvent("docker_privileged", compliance.CheckFailed, "docker_image", "3a5f0123")
+	assert.False(t, waivers.Apply(event, "host", now))
+
+	event = newTestEvent("docker_privileged", compliance.CheckPassed, "docker_container", "3a5f0123")
+	assert.False(t, waivers.Apply(event, "host", now))
+	assert.Equal(t, compliance.CheckPassed, event.Result)
+
+	event = newTestEvent("file_permissions", compliance.CheckFailed, "file", "/etc/ssh/sshd_config")
+	assert.True(t, waivers.Apply(event, "build-42", now))
+	assert.Equal(t, time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC), event.Waiver.ExpireAt)
+
+	event = newTestEvent("file_permissions", compliance.CheckFailed, "file", "/etc/ssh/sshd_config")
+	assert.False(t, waivers.Apply(event, "prod-1", now))
+
+	event = newTestEvent("file_permissions", compliance.CheckFailed, "file", "/etc/ssh/sshd_config")
+	assert.False(t, waivers.Apply(event, "build-42", time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	var noWaivers *compliance.Waivers
+	event = newTestEvent("docker_privileged", compliance.CheckFailed, "docker_container", "3a5f0123")
+	assert.False(t, noWaivers.Apply(event, "host", now))
+}
+
+func TestWaiversInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing rule": `waivers:
+  - expires: 2030-01-01
+    justification: foo
+`,
+		"missing justification": `waivers:
+  - rule_id: foo
+    expires: 2030-01-01
+`,
+		"missing expiry": `waivers:
+  - rule_id: foo
+    justification: foo
+`,
+		"bad expiry": `waivers:
+  - rule_id: foo
+    expires: next year
+    justification: foo
+`,
+		"bad pattern": `waivers:
+  - rule_id: foo
+    resource_id: "[a-"
+    expires: 2030-01-01
+    justification: foo
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := compliance.LoadWaivers(writeWaivers(t, data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+const remediationSuite = `schema:
+  version: 1.0.0
+name: remediation
+framework: remediation
+version: 1.0.0
+rules:
+  - id: remediation_rule
+    remediation:
+      text: Run chmod 600 on the file
+      script: scripts/fix_permissions.sh
+    input:
+      - constants:
+          permissions: 420
+`
+
+const remediationRego = `package datadog
+import data.datadog as dd
+
+findings[f] {
+	input.constants.permissions == 420
+	f := dd.failing_finding("file", "/etc/foo", {})
+}
+
+findings[f] {
+	f := dd.passed_finding("file", "/etc/bar", {})
+}
+`
+
+func TestRemediation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "remediation.yaml"), []byte(remediationSuite), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "remediation_rule.rego"), []byte(remediationRego), 0o644))
+
+	benchmarks, err := compliance.LoadBenchmarks(dir, "remediation.yaml", nil)
+	require.NoError(t, err)
+	require.Len(t, benchmarks, 1)
+	rule := benchmarks[0].Rules[0]
+	require.NotNil(t, rule.Remediation)
+
+	ctx := context.Background()
+	resolver := compliance.NewResolver(ctx, compliance.ResolverOptions{})
+	defer resolver.Close()
+	events := compliance.ResolveAndEvaluateRegoRule(ctx, resolver, benchmarks[0], rule)
+	require.Len(t, events, 2)
+	for _, event := range events {
+		switch event.Result {
+		case compliance.CheckFailed:
+			require.NotNil(t, event.Remediation)
+			assert.Equal(t, "Run chmod 600 on the file", event.Remediation.Text)
+			assert.Equal(t, "scripts/fix_permissions.sh", event.Remediation.Script)
+		case compliance.CheckPassed:
+			assert.Nil(t, event.Remediation)
+		default:
+			t.Fatalf("unexpected event %s", event)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// waiverDateLayout is the layout of the expiry dates given without a time,
+// in which case the waiver expires at the end of that day, UTC.
+const waiverDateLayout = "2006-01-02"
+
+// Waiver is an accepted exception to a rule: the failed findings of the rule
+// matching the waiver are reported as waived instead of failed, until the
+// waiver expires.
+type Waiver struct {
+	// RuleID is the ID of the waived rule. It is required.
+	RuleID string `yaml:"rule_id" json:"rule_id"`
+	// Hostname restricts the waiver to the hosts matching this pattern.
+	Hostname string `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	// ResourceType restricts the waiver to the findings of this type of
+	// resource, like "docker_container" or "file".
+	ResourceType string `yaml:"resource_type,omitempty" json:"resource_type,omitempty"`
+	// ResourceID restricts the waiver to the findings of the resources
+	// matching this pattern, like the ID of a container or the path of a
+	// file.
+	ResourceID string `yaml:"resource_id,omitempty" json:"resource_id,omitempty"`
+	// Expires is the date, as YYYY-MM-DD or RFC3339, at which the waiver
+	// stops applying. It is required.
+	Expires string `yaml:"expires" json:"expires"`
+	// Justification explains why the exception is accepted. It is required.
+	Justification string `yaml:"justification" json:"justification"`
+
+	expiresAt time.Time
+}
+
+// Waivers is a set of waivers, usually loaded from a file with LoadWaivers.
+type Waivers struct {
+	Waivers []*Waiver `yaml:"waivers" json:"waivers"`
+}
+
+// WaiverInfo describes the waiver applied to a finding.
+type WaiverInfo struct {
+	Justification string    `json:"justification"`
+	ExpireAt      time.Time `json:"expire_at"`
+}
+
+// LoadWaivers reads and validates the waivers defined in the given YAML or
+// JSON file.
+func LoadWaivers(filename string) (*Waivers, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var waivers Waivers
+	switch filepath.Ext(filename) {
+	case ".json":
+		err = json.Unmarshal(b, &waivers)
+	default:
+		err = yaml.Unmarshal(b, &waivers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range waivers.Waivers {
+		if err := w.init(); err != nil {
+			return nil, fmt.Errorf("bad waiver %d: %w", i, err)
+		}
+	}
+	return &waivers, nil
+}
+
+func (w *Waiver) init() error {
+	if w.RuleID == "" {
+		return fmt.Errorf("missing rule_id")
+	}
+	if w.Justification == "" {
+		return fmt.Errorf("missing justification for rule %s", w.RuleID)
+	}
+	if w.Expires == "" {
+		return fmt.Errorf("missing expiry date for rule %s", w.RuleID)
+	}
+	for _, pattern := range []string{w.Hostname, w.ResourceID} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q for rule %s: %w", pattern, w.RuleID, err)
+		}
+	}
+	if t, err := time.Parse(waiverDateLayout, w.Expires); err == nil {
+		w.expiresAt = t.AddDate(0, 0, 1)
+	} else if t, err := time.Parse(time.RFC3339, w.Expires); err == nil {
+		w.expiresAt = t
+	} else {
+		return fmt.Errorf("bad expiry date %q for rule %s", w.Expires, w.RuleID)
+	}
+	return nil
+}
+
+// Expired returns whether the waiver no longer applies at the given time.
+func (w *Waiver) Expired(now time.Time) bool {
+	return !now.Before(w.expiresAt)
+}
+
+func (w *Waiver) matches(event *CheckEvent, hostname string) bool {
+	if w.RuleID != event.RuleID {
+		return false
+	}
+	if w.ResourceType != "" && w.ResourceType != event.ResourceType {
+		return false
+	}
+	return matchPattern(w.Hostname, hostname) && matchPattern(w.ResourceID, event.ResourceID)
+}
+
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, value)
+	return matched
+}
+
+// Match returns the first waiver that is not expired and matches the rule and
+// the resource of the given event, evaluated on the given host.
+func (ws *Waivers) Match(event *CheckEvent, hostname string, now time.Time) *Waiver {
+	if ws == nil {
+		return nil
+	}
+	for _, w := range ws.Waivers {
+		if !w.Expired(now) && w.matches(event, hostname) {
+			return w
+		}
+	}
+	return nil
+}
+
+// Apply reports the given event as waived when it failed and a waiver
+// matches it. It returns whether the event was waived.
+func (ws *Waivers) Apply(event *CheckEvent, hostname string, now time.Time) bool {
+	if event.Result != CheckFailed {
+		return false
+	}
+	w := ws.Match(event, hostname, now)
+	if w == nil {
+		return false
+	}
+	event.Result = CheckWaived
+	event.Waiver = &WaiverInfo{
+		Justification: w.Justification,
+		ExpireAt:      w.expiresAt.UTC(),
+	}
+	return true
+}
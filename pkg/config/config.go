@@ -139,20 +139,21 @@ type MetadataProviders struct {
 
 // ConfigurationProviders helps unmarshalling `config_providers` config param
 type ConfigurationProviders struct {
-	Name                    string `mapstructure:"name"`
-	Polling                 bool   `mapstructure:"polling"`
-	PollInterval            string `mapstructure:"poll_interval"`
-	TemplateURL             string `mapstructure:"template_url"`
-	TemplateDir             string `mapstructure:"template_dir"`
-	Username                string `mapstructure:"username"`
-	Password                string `mapstructure:"password"`
-	CAFile                  string `mapstructure:"ca_file"`
-	CAPath                  string `mapstructure:"ca_path"`
-	CertFile                string `mapstructure:"cert_file"`
-	KeyFile                 string `mapstructure:"key_file"`
-	Token                   string `mapstructure:"token"`
-	GraceTimeSeconds        int    `mapstructure:"grace_time_seconds"`
-	DegradedDeadlineMinutes int    `mapstructure:"degraded_deadline_minutes"`
+	Name                    string            `mapstructure:"name"`
+	Polling                 bool              `mapstructure:"polling"`
+	PollInterval            string            `mapstructure:"poll_interval"`
+	TemplateURL             string            `mapstructure:"template_url"`
+	TemplateDir             string            `mapstructure:"template_dir"`
+	Username                string            `mapstructure:"username"`
+	Password                string            `mapstructure:"password"`
+	CAFile                  string            `mapstructure:"ca_file"`
+	CAPath                  string            `mapstructure:"ca_path"`
+	CertFile                string            `mapstructure:"cert_file"`
+	KeyFile                 string            `mapstructure:"key_file"`
+	Token                   string            `mapstructure:"token"`
+	Headers                 map[string]string `mapstructure:"headers"`
+	GraceTimeSeconds        int               `mapstructure:"grace_time_seconds"`
+	DegradedDeadlineMinutes int               `mapstructure:"degraded_deadline_minutes"`
 }
 
 // Listeners helps unmarshalling `listeners` config param
@@ -277,6 +278,12 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("allow_arbitrary_tags", false)
 	config.BindEnvAndSetDefault("use_proxy_for_cloud_metadata", false)
 	config.BindEnvAndSetDefault("remote_tagger_timeout_seconds", 30)
+	// Tagger snapshots, to serve the tags of the previous run while the collectors start
+	config.BindEnvAndSetDefault("tagger_snapshot.enabled", false)
+	config.BindEnvAndSetDefault("tagger_snapshot.path", "")
+	config.BindEnvAndSetDefault("tagger_snapshot.interval", 60)
+	config.BindEnvAndSetDefault("tagger_snapshot.max_age", 600)
+	config.BindEnvAndSetDefault("tagger_snapshot.reconcile_timeout", 120)
 
 	// Fips
 	config.BindEnvAndSetDefault("fips.enabled", false)
@@ -375,6 +382,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	config.BindEnvAndSetDefault("secret_backend_skip_checks", false)
 	config.BindEnvAndSetDefault("secret_backend_remove_trailing_line_break", false)
+	config.BindEnvAndSetDefault("secret_refresh_interval", 0)
 
 	// Use to output logs in JSON format
 	config.BindEnvAndSetDefault("log_format_json", false)
@@ -480,6 +488,33 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_recovery_interval", DefaultForwarderRecoveryInterval)
 	config.BindEnvAndSetDefault("forwarder_recovery_reset", false)
 
+	// Forwarder failover
+	config.BindEnvAndSetDefault("forwarder_failover.enabled", false)
+	config.BindEnvAndSetDefault("forwarder_failover.secondary_url", "")
+	config.BindEnvAndSetDefault("forwarder_failover.secondary_api_key", "")
+	config.BindEnvAndSetDefault("forwarder_failover.failure_threshold", 5)
+	config.BindEnvAndSetDefault("forwarder_failover.latency_threshold", 0)   // in seconds, 0 means disabled
+	config.BindEnvAndSetDefault("forwarder_failover.failback_interval", 300) // in seconds
+
+	// Forwarder archiving
+	config.BindEnvAndSetDefault("forwarder_archive.enabled", false)
+	config.BindEnvAndSetDefault("forwarder_archive.sample_rate", 1.0)
+	config.BindEnvAndSetDefault("forwarder_archive.paths", []string{}) // path prefixes, empty means all
+	config.BindEnvAndSetDefault("forwarder_archive.decompress", true)
+	config.BindEnvAndSetDefault("forwarder_archive.max_payload_size", 10*1024*1024) // 0 means no limit
+	config.BindEnvAndSetDefault("forwarder_archive.queue_size", 100)
+	config.BindEnvAndSetDefault("forwarder_archive.flush_interval", 60) // in seconds
+	config.BindEnvAndSetDefault("forwarder_archive.file.path", "")
+	config.BindEnvAndSetDefault("forwarder_archive.file.max_file_size", 10*1024*1024)
+	config.BindEnvAndSetDefault("forwarder_archive.file.max_total_size", 100*1024*1024) // 0 means no limit
+	config.BindEnvAndSetDefault("forwarder_archive.s3.endpoint", "")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.region", "us-east-1")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.bucket", "")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.prefix", "")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.access_key_id", "")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.secret_access_key", "")
+	config.BindEnvAndSetDefault("forwarder_archive.s3.max_object_size", 5*1024*1024)
+
 	// Forwarder storage on disk
 	config.BindEnvAndSetDefault("forwarder_storage_path", "")
 	config.BindEnvAndSetDefault("forwarder_outdated_file_in_days", 10)
@@ -487,6 +522,14 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0)                // 0 means disabled. This is a BETA feature.
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.80)                // Do not store transactions on disk when the disk usage exceeds 80% of the disk capacity. Use 80% as some applications do not behave well when the disk space is very small.
 	config.BindEnvAndSetDefault("forwarder_retry_queue_capacity_time_interval_sec", 900) // 15 mins
+	config.BindEnv("forwarder_storage_classes")
+	config.SetEnvKeyTransformer("forwarder_storage_classes", func(in string) interface{} {
+		var classes []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &classes); err != nil {
+			log.Errorf(`"forwarder_storage_classes" can not be parsed: %v`, err)
+		}
+		return classes
+	})
 
 	// Forwarder channels buffer size
 	config.BindEnvAndSetDefault("forwarder_high_prio_buffer_size", 100)
@@ -609,6 +652,14 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("kubernetes_node_annotations_as_host_aliases", []string{"cluster.k8s.io/machine"})
 	config.BindEnvAndSetDefault("kubernetes_node_label_as_cluster_name", "")
 	config.BindEnvAndSetDefault("kubernetes_namespace_labels_as_tags", map[string]string{})
+	config.BindEnv("tagger_extraction_rules")
+	config.SetEnvKeyTransformer("tagger_extraction_rules", func(in string) interface{} {
+		var rules []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"tagger_extraction_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	config.BindEnvAndSetDefault("container_cgroup_prefix", "")
 
 	// CRI
@@ -626,6 +677,11 @@ func InitConfig(config Config) {
 	// Podman
 	config.BindEnvAndSetDefault("podman_db_path", "/var/lib/containers/storage/libpod/bolt_state.db")
 
+	// Nomad
+	config.BindEnvAndSetDefault("nomad.agent_url", "") // empty means NOMAD_ADDR or the default local agent address
+	config.BindEnvAndSetDefault("nomad.token", "")     // empty means NOMAD_TOKEN
+	config.BindEnvAndSetDefault("nomad.tls_verify", true)
+
 	// Kubernetes
 	config.BindEnvAndSetDefault("kubernetes_kubelet_host", "")
 	config.BindEnvAndSetDefault("kubernetes_kubelet_nodename", "")
@@ -997,6 +1053,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
+	config.BindEnvAndSetDefault("cluster_checks.cost_aware_dispatching.enabled", false)
+	config.BindEnvAndSetDefault("cluster_checks.cost_aware_dispatching.move_cooldown", 1800) // value in seconds
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
 	config.BindEnvAndSetDefault("clc_runner_id", "")
@@ -1038,6 +1096,12 @@ func InitConfig(config Config) {
 	config.BindEnv("admission_controller.auto_instrumentation.init_resources.cpu")
 	config.BindEnv("admission_controller.auto_instrumentation.init_resources.memory")
 	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.inject_all.namespaces", []string{})
+	config.BindEnvAndSetDefault("admission_controller.injection_policies.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.injection_policies.file", "/etc/datadog-agent/injection-policies/policies.yaml")
+	config.BindEnvAndSetDefault("admission_controller.injection_policies.refresh_interval", 15) // in seconds
+	config.BindEnvAndSetDefault("admission_controller.validate_annotations.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.validate_annotations.endpoint", "/validateannotations")
+	config.BindEnvAndSetDefault("admission_controller.validate_annotations.mode", "warn") // warn or reject
 
 	// Telemetry
 	// Enable telemetry metrics on the internals of the Agent.
@@ -1111,6 +1175,10 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("inventories_max_interval", DefaultInventoriesMaxInterval) // integer seconds
 	config.BindEnvAndSetDefault("inventories_min_interval", DefaultInventoriesMinInterval) // integer seconds
 
+	// workloadmeta_process_collection
+	config.BindEnvAndSetDefault("workloadmeta_process_collection.enabled", false)
+	config.BindEnvAndSetDefault("workloadmeta_process_collection.interval", 30) // Integer seconds
+
 	// container_image_collection
 	config.BindEnvAndSetDefault("container_image_collection.metadata.enabled", false)
 	config.BindEnvAndSetDefault("container_image_collection.sbom.enabled", false)
@@ -1145,6 +1213,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("compliance_config.check_interval", 20*time.Minute)
 	config.BindEnvAndSetDefault("compliance_config.check_max_events_per_run", 100)
 	config.BindEnvAndSetDefault("compliance_config.dir", "/etc/datadog-agent/compliance.d")
+	config.BindEnvAndSetDefault("compliance_config.waivers_file", "")
 	config.BindEnvAndSetDefault("compliance_config.run_path", defaultRunPath)
 	config.BindEnv("compliance_config.run_commands_as")
 	bindEnvAndSetLogsConfigKeys(config, "compliance_config.endpoints.")
@@ -27,4 +27,6 @@ const (
 	CloudFoundry Feature = "cloudfoundry"
 	// Podman containers storage path accessible
 	Podman Feature = "podman"
+	// Nomad environment
+	Nomad Feature = "nomad"
 )
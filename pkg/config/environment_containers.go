@@ -44,6 +44,7 @@ func init() {
 	registerFeature(KubeOrchestratorExplorer)
 	registerFeature(CloudFoundry)
 	registerFeature(Podman)
+	registerFeature(Nomad)
 }
 
 // IsAnyContainerFeaturePresent checks if any of known container features is present
@@ -66,6 +67,7 @@ func detectContainerFeatures(features FeatureMap) {
 	detectAWSEnvironments(features)
 	detectCloudFoundry(features)
 	detectPodman(features)
+	detectNomad(features)
 }
 
 func detectKubernetes(features FeatureMap) {
@@ -189,6 +191,13 @@ func detectPodman(features FeatureMap) {
 	}
 }
 
+func detectNomad(features FeatureMap) {
+	// NOMAD_ALLOC_ID is set in the environment of the tasks run by Nomad
+	if _, found := os.LookupEnv("NOMAD_ALLOC_ID"); found || Datadog.GetString("nomad.agent_url") != "" {
+		features[Nomad] = struct{}{}
+	}
+}
+
 func getHostMountPrefixes() []string {
 	if IsContainerized() {
 		return []string{"", defaultHostMountPrefix}
@@ -9,6 +9,8 @@
 package resolver
 
 import (
+	"sync"
+
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/endpoints"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
 )
@@ -36,19 +38,22 @@ type DomainResolver interface {
 	GetAlternateDomains() []string
 	// SetBaseDomain sets the base domain to a new value
 	SetBaseDomain(domain string)
+	// UpdateAPIKey replaces oldKey by newKey in the list of API Keys associated with this `DomainResolver`
+	UpdateAPIKey(oldKey, newKey string)
 }
 
 // SingleDomainResolver will always return the same host
 type SingleDomainResolver struct {
-	domain  string
-	apiKeys []string
+	domain      string
+	apiKeys     []string
+	apiKeysLock sync.RWMutex
 }
 
 // NewSingleDomainResolver creates a SingleDomainResolver with its destination domain & API keys
 func NewSingleDomainResolver(domain string, apiKeys []string) *SingleDomainResolver {
 	return &SingleDomainResolver{
-		domain,
-		apiKeys,
+		domain:  domain,
+		apiKeys: apiKeys,
 	}
 }
 
@@ -73,9 +78,18 @@ func (r *SingleDomainResolver) GetBaseDomain() string {
 
 // GetAPIKeys returns the slice of API keys associated with this SingleDomainResolver
 func (r *SingleDomainResolver) GetAPIKeys() []string {
+	r.apiKeysLock.RLock()
+	defer r.apiKeysLock.RUnlock()
 	return r.apiKeys
 }
 
+// UpdateAPIKey replaces oldKey by newKey in the API keys associated with this SingleDomainResolver
+func (r *SingleDomainResolver) UpdateAPIKey(oldKey, newKey string) {
+	r.apiKeysLock.Lock()
+	defer r.apiKeysLock.Unlock()
+	r.apiKeys = replaceAPIKey(r.apiKeys, oldKey, newKey)
+}
+
 // SetBaseDomain sets the only destination available for a SingleDomainResolver
 func (r *SingleDomainResolver) SetBaseDomain(domain string) {
 	r.domain = domain
@@ -95,6 +109,7 @@ type destination struct {
 type MultiDomainResolver struct {
 	baseDomain          string
 	apiKeys             []string
+	apiKeysLock         sync.RWMutex
 	overrides           map[string]destination
 	alternateDomainList []string
 }
@@ -102,18 +117,27 @@ type MultiDomainResolver struct {
 // NewMultiDomainResolver initializes a MultiDomainResolver with its API keys and base destination
 func NewMultiDomainResolver(baseDomain string, apiKeys []string) *MultiDomainResolver {
 	return &MultiDomainResolver{
-		baseDomain,
-		apiKeys,
-		make(map[string]destination),
-		[]string{},
+		baseDomain:          baseDomain,
+		apiKeys:             apiKeys,
+		overrides:           make(map[string]destination),
+		alternateDomainList: []string{},
 	}
 }
 
 // GetAPIKeys returns the slice of API keys associated with this SingleDomainResolver
 func (r *MultiDomainResolver) GetAPIKeys() []string {
+	r.apiKeysLock.RLock()
+	defer r.apiKeysLock.RUnlock()
 	return r.apiKeys
 }
 
+// UpdateAPIKey replaces oldKey by newKey in the API keys associated with this MultiDomainResolver
+func (r *MultiDomainResolver) UpdateAPIKey(oldKey, newKey string) {
+	r.apiKeysLock.Lock()
+	defer r.apiKeysLock.Unlock()
+	r.apiKeys = replaceAPIKey(r.apiKeys, oldKey, newKey)
+}
+
 // Resolve returns the destiation for a given request endpoint
 func (r *MultiDomainResolver) Resolve(endpoint transaction.Endpoint) (string, DestinationType) {
 	if d, ok := r.overrides[endpoint.Name]; ok {
@@ -162,3 +186,16 @@ func NewDomainResolverWithMetricToVector(mainEndpoint string, apiKeys []string,
 	r.RegisterAlternateDestination(vectorEndpoint, endpoints.SketchSeriesEndpoint.Name, Vector)
 	return r
 }
+
+// replaceAPIKey returns a copy of apiKeys where oldKey is replaced by newKey. A copy is made so callers of
+// GetAPIKeys can keep iterating over the slice they got.
+func replaceAPIKey(apiKeys []string, oldKey, newKey string) []string {
+	replaced := make([]string, 0, len(apiKeys))
+	for _, key := range apiKeys {
+		if key == oldKey {
+			key = newKey
+		}
+		replaced = append(replaced, key)
+	}
+	return replaced
+}
@@ -183,8 +183,15 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_http_stats_by_status_code"), false)
 
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_gateway_lookup"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_GATEWAY_LOOKUP")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tls_metadata"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_TLS_METADATA")
 	cfg.BindEnvAndSetDefault(join(netNS, "max_http_stats_buffered"), 100000, "DD_SYSTEM_PROBE_NETWORK_MAX_HTTP_STATS_BUFFERED")
 	cfg.BindEnvAndSetDefault(join(smNS, "max_kafka_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_database_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "database_monitoring.postgres_ports"), []int{5432})
+	cfg.BindEnvAndSetDefault(join(smNS, "database_monitoring.mysql_ports"), []int{3306})
+	cfg.BindEnvAndSetDefault(join(smNS, "database_monitoring.redis_ports"), []int{6379})
+	cfg.BindEnvAndSetDefault(join(smNS, "database_monitoring.mongodb_ports"), []int{27017})
+	cfg.BindEnvAndSetDefault(join(smNS, "max_database_stats_buffered"), 100000)
 	httpRules := join(netNS, "http_replace_rules")
 	cfg.BindEnv(httpRules, "DD_SYSTEM_PROBE_NETWORK_HTTP_REPLACE_RULES")
 	cfg.SetEnvKeyTransformer(httpRules, func(in string) interface{} {
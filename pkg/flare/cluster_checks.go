@@ -11,6 +11,7 @@ import (
 	"io"
 	"sort"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 
@@ -105,9 +106,33 @@ func GetClusterChecks(w io.Writer, checkName string) error {
 		}
 	}
 
+	// Print recent placement decisions
+	printPlacements(w, cr.Placements, checkName)
+
 	return nil
 }
 
+func printPlacements(w io.Writer, placements []types.PlacementDecision, checkName string) {
+	if len(placements) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\n===== Placement decisions =====")
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "\nTime\tCheck\tFrom\tTo\tReason\tExec time (ms)")
+	for _, p := range placements {
+		if checkName != "" && p.CheckName != checkName {
+			continue
+		}
+		source := p.SourceNodeName
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			time.Unix(p.Timestamp, 0).UTC().Format(time.RFC3339), p.CheckName, source, p.DestNodeName, p.Reason, p.ExecutionTime)
+	}
+	table.Flush()
+}
+
 // GetEndpointsChecks dumps the endpointschecks dispatching state to the writer
 func GetEndpointsChecks(w io.Writer, checkName string) error {
 	if !endpointschecksEnabled() {
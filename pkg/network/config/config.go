@@ -6,10 +6,13 @@
 package config
 
 import (
+	"math"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/spf13/cast"
+
 	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
@@ -89,6 +92,16 @@ type Config struct {
 	// EnableKafkaMonitoring specifies whether the tracer should monitor Kafka traffic
 	EnableKafkaMonitoring bool
 
+	// EnableDatabaseMonitoring specifies whether the tracer should monitor the traffic of the databases, decoded
+	// in userspace from the TCP segments sent to or from their ports
+	EnableDatabaseMonitoring bool
+
+	// PostgresPorts, MySQLPorts, RedisPorts and MongoDBPorts are the server ports of the monitored databases
+	PostgresPorts []uint16
+	MySQLPorts    []uint16
+	RedisPorts    []uint16
+	MongoDBPorts  []uint16
+
 	// EnableHTTPSMonitoring specifies whether the tracer should monitor HTTPS traffic
 	// Supported libraries: OpenSSL
 	EnableHTTPSMonitoring bool
@@ -168,6 +181,10 @@ type Config struct {
 	// get flushed on every client request (default 30s check interval)
 	MaxKafkaStatsBuffered int
 
+	// MaxDatabaseStatsBuffered represents the maximum number of database stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxDatabaseStatsBuffered int
+
 	// MaxConnectionsStateBuffered represents the maximum number of state objects that we'll store in memory. These state objects store
 	// the stats for a connection so we can accurately determine traffic change between client requests.
 	MaxConnectionsStateBuffered int
@@ -224,6 +241,10 @@ type Config struct {
 	// EnableGatewayLookup enables looking up gateway information for connection destinations
 	EnableGatewayLookup bool
 
+	// EnableTLSMetadata enables decoding the TLS handshakes to report the version, cipher suite,
+	// server name and certificate expiry of the connections
+	EnableTLSMetadata bool
+
 	// RecordedQueryTypes enables specific DNS query types to be recorded
 	RecordedQueryTypes []string
 
@@ -307,6 +328,13 @@ func New() *Config {
 		MaxHTTPStatsBuffered:  cfg.GetInt(join(netNS, "max_http_stats_buffered")),
 		MaxKafkaStatsBuffered: cfg.GetInt(join(smNS, "max_kafka_stats_buffered")),
 
+		EnableDatabaseMonitoring: cfg.GetBool(join(smNS, "enable_database_monitoring")),
+		PostgresPorts:            getPorts(cfg, join(smNS, "database_monitoring.postgres_ports")),
+		MySQLPorts:               getPorts(cfg, join(smNS, "database_monitoring.mysql_ports")),
+		RedisPorts:               getPorts(cfg, join(smNS, "database_monitoring.redis_ports")),
+		MongoDBPorts:             getPorts(cfg, join(smNS, "database_monitoring.mongodb_ports")),
+		MaxDatabaseStatsBuffered: cfg.GetInt(join(smNS, "max_database_stats_buffered")),
+
 		MaxTrackedHTTPConnections: cfg.GetInt64(join(netNS, "max_tracked_http_connections")),
 		HTTPNotificationThreshold: cfg.GetInt64(join(netNS, "http_notification_threshold")),
 		HTTPMaxRequestFragment:    cfg.GetInt64(join(netNS, "http_max_request_fragment")),
@@ -323,6 +351,8 @@ func New() *Config {
 
 		EnableGatewayLookup: cfg.GetBool(join(netNS, "enable_gateway_lookup")),
 
+		EnableTLSMetadata: cfg.GetBool(join(netNS, "enable_tls_metadata")),
+
 		EnableMonotonicCount: cfg.GetBool(join(spNS, "windows.enable_monotonic_count")),
 
 		RecordedQueryTypes: cfg.GetStringSlice(join(netNS, "dns_recorded_query_types")),
@@ -459,3 +489,16 @@ func New() *Config {
 
 	return c
 }
+
+// getPorts returns the valid ports of a list
+func getPorts(cfg ddconfig.Config, key string) []uint16 {
+	var ports []uint16
+	for _, port := range cast.ToIntSlice(cfg.Get(key)) {
+		if port <= 0 || port > math.MaxUint16 {
+			log.Warnf("ignoring invalid port %d of %s", port, key)
+			continue
+		}
+		ports = append(ports, uint16(port))
+	}
+	return ports
+}
@@ -252,6 +252,62 @@ func TestDisableGatewayLookup(t *testing.T) {
 	})
 }
 
+func TestEnableTLSMetadata(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		newConfig(t)
+		// default config
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableTLSMetadata)
+
+		newConfig(t)
+		_, err = sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableTLSMetadata.yaml")
+		require.NoError(t, err)
+		cfg = New()
+
+		assert.True(t, cfg.EnableTLSMetadata)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_TLS_METADATA", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableTLSMetadata)
+	})
+}
+
+func TestEnableDatabaseMonitoring(t *testing.T) {
+	newConfig(t)
+	// default config
+	_, err := sysconfig.New("")
+	require.NoError(t, err)
+	cfg := New()
+
+	assert.False(t, cfg.EnableDatabaseMonitoring)
+	assert.Equal(t, []uint16{5432}, cfg.PostgresPorts)
+	assert.Equal(t, []uint16{3306}, cfg.MySQLPorts)
+	assert.Equal(t, []uint16{6379}, cfg.RedisPorts)
+	assert.Equal(t, []uint16{27017}, cfg.MongoDBPorts)
+
+	newConfig(t)
+	_, err = sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableDatabaseMonitoring.yaml")
+	require.NoError(t, err)
+	cfg = New()
+
+	assert.True(t, cfg.EnableDatabaseMonitoring)
+	assert.Equal(t, []uint16{5432, 6432}, cfg.PostgresPorts)
+	// invalid ports are ignored
+	assert.Equal(t, []uint16{3307}, cfg.MySQLPorts)
+	// the monitoring of a database is disabled by an empty list
+	assert.Empty(t, cfg.RedisPorts)
+	assert.Equal(t, []uint16{27017, 27018}, cfg.MongoDBPorts)
+}
+
 func TestIgnoreConntrackInitFailure(t *testing.T) {
 	t.Run("via YAML", func(t *testing.T) {
 		newConfig(t)
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package encoding
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// databaseEncoder encodes the stats of the database transactions. The connections payload
+// has no dedicated field for them: the database management system is reported in the
+// protocol stack, and the stats as connection tags, e.g., db.system:postgresql
+type databaseEncoder struct {
+	aggregations  map[types.ConnectionKey]*databaseAggregation
+	orphanEntries int
+}
+
+// databaseAggregation holds the stats of the database transactions of a connection
+type databaseAggregation struct {
+	dbms       string
+	operations map[string]struct{}
+	resources  map[string]struct{}
+	count      int
+	errorCount int
+	tags       []string
+
+	// we keep track of the source and destination ports of the first
+	// `ConnectionStats` to claim this aggregation, see kafkaAggregationWrapper
+	sport, dport uint16
+}
+
+func (a *databaseAggregation) valueFor(c network.ConnectionStats) *databaseAggregation {
+	if a == nil {
+		return nil
+	}
+
+	if a.sport == 0 && a.dport == 0 {
+		a.sport = c.SPort
+		a.dport = c.DPort
+		return a
+	}
+
+	// the opposite ends of the same connection share the aggregation, other
+	// connections with the same key but different PIDs would overcount
+	if c.SPort == a.dport && c.DPort == a.sport {
+		return a
+	}
+
+	return nil
+}
+
+// buildTags returns the tags of the aggregation
+func (a *databaseAggregation) buildTags() []string {
+	tags := make([]string, 0, len(a.operations)+len(a.resources)+3)
+	tags = append(tags,
+		"db.system:"+a.dbms,
+		"db.requests:"+strconv.Itoa(a.count),
+		"db.errors:"+strconv.Itoa(a.errorCount),
+	)
+	for operation := range a.operations {
+		tags = append(tags, "db.operation:"+operation)
+	}
+	for resource := range a.resources {
+		tags = append(tags, "db.resource:"+resource)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func newDatabaseEncoder(payload *network.Connections) *databaseEncoder {
+	if len(payload.Database) == 0 {
+		return nil
+	}
+
+	encoder := &databaseEncoder{
+		aggregations: make(map[types.ConnectionKey]*databaseAggregation, len(payload.Conns)),
+	}
+
+	// pre-populate aggregation map with keys for all existent connections
+	// this allows us to skip encoding orphan database stats that can't be matched to a connection
+	for _, conn := range payload.Conns {
+		for _, key := range network.ConnectionKeysFromConnectionStats(conn) {
+			encoder.aggregations[key] = nil
+		}
+	}
+	encoder.buildAggregations(payload)
+	return encoder
+}
+
+// GetDatabaseTagsAndProtocol returns the tags describing the database transactions of a
+// connection, and the protocol of its database management system
+func (e *databaseEncoder) GetDatabaseTagsAndProtocol(c network.ConnectionStats) ([]string, protocols.ProtocolType) {
+	if e == nil {
+		return nil, protocols.Unknown
+	}
+
+	for _, key := range network.ConnectionKeysFromConnectionStats(c) {
+		if aggregation := e.aggregations[key].valueFor(c); aggregation != nil {
+			return aggregation.tags, databaseProtocol(aggregation.dbms)
+		}
+	}
+	return nil, protocols.Unknown
+}
+
+func (e *databaseEncoder) buildAggregations(payload *network.Connections) {
+	for key, stats := range payload.Database {
+		aggregation, ok := e.aggregations[key.ConnectionKey]
+		if !ok {
+			// if there is no matching connection don't even bother to serialize database data
+			log.Tracef("Found database orphan connection %v", key.ConnectionKey)
+			e.orphanEntries++
+			continue
+		}
+
+		if aggregation == nil {
+			aggregation = &databaseAggregation{
+				dbms:       key.DBMS,
+				operations: make(map[string]struct{}),
+				resources:  make(map[string]struct{}),
+			}
+			e.aggregations[key.ConnectionKey] = aggregation
+		}
+
+		if operation := databaseOperation(key); operation != "" {
+			aggregation.operations[operation] = struct{}{}
+		}
+		if key.Resource != "" {
+			aggregation.resources[key.Resource] = struct{}{}
+		}
+		aggregation.count += stats.Count
+		aggregation.errorCount += stats.ErrorCount
+	}
+
+	for _, aggregation := range e.aggregations {
+		if aggregation != nil {
+			aggregation.tags = aggregation.buildTags()
+		}
+	}
+}
+
+// databaseOperation returns the operation of the transactions of a key: their command,
+// e.g., GET or find, or else the verb of their obfuscated query, e.g., SELECT
+func databaseOperation(key database.Key) string {
+	if key.Command != "" {
+		return key.Command
+	}
+	verb, _, _ := strings.Cut(strings.TrimSpace(key.Query), " ")
+	return strings.ToUpper(verb)
+}
+
+// databaseProtocol returns the protocol of a database management system
+func databaseProtocol(dbms string) protocols.ProtocolType {
+	switch dbms {
+	case database.DBMSPostgres:
+		return protocols.Postgres
+	case database.DBMSMySQL:
+		return protocols.MySQL
+	case database.DBMSRedis:
+		return protocols.Redis
+	case database.DBMSMongoDB:
+		return protocols.Mongo
+	default:
+		return protocols.Unknown
+	}
+}
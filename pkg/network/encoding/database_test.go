@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func newDatabaseKey(clientPort, serverPort uint16, dbms, query string) database.Key {
+	key := database.NewKey(
+		util.AddressFromString("10.0.0.1"),
+		util.AddressFromString("10.0.0.2"),
+		clientPort,
+		serverPort,
+		query,
+	)
+	key.DBMS = dbms
+	return key
+}
+
+func connectionTags(payload *model.Connections, conn *model.Connection) []string {
+	tags := make([]string, 0, len(conn.Tags))
+	for _, idx := range conn.Tags {
+		tags = append(tags, payload.Tags[idx])
+	}
+	return tags
+}
+
+func TestDatabaseEncoding(t *testing.T) {
+	newConfig(t)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				{
+					Source: util.AddressFromString("10.0.0.1"),
+					Dest:   util.AddressFromString("10.0.0.2"),
+					SPort:  60000,
+					DPort:  5432,
+				},
+				{
+					Source: util.AddressFromString("10.0.0.1"),
+					Dest:   util.AddressFromString("10.0.0.3"),
+					SPort:  60001,
+					DPort:  80,
+				},
+			},
+		},
+		Database: map[database.Key]*database.RequestStat{
+			newDatabaseKey(60000, 5432, database.DBMSPostgres, "SELECT * FROM users WHERE id = ?"): {Count: 3, ErrorCount: 1},
+			newDatabaseKey(60000, 5432, database.DBMSPostgres, "select name FROM users"):           {Count: 2},
+			newDatabaseKey(60000, 5432, database.DBMSPostgres, "INSERT INTO users VALUES ( ? )"):   {Count: 1},
+			// orphan stats, without a matching connection
+			newDatabaseKey(60002, 3306, database.DBMSMySQL, "SELECT ?"): {Count: 1},
+		},
+	}
+
+	payload := modelConnections(in)
+	require.Len(t, payload.Conns, 2)
+
+	assert.Equal(t, []model.ProtocolType{model.ProtocolType_protocolPostgres}, payload.Conns[0].Protocol.Stack)
+	assert.ElementsMatch(t, []string{
+		"db.system:postgresql",
+		"db.requests:6",
+		"db.errors:1",
+		"db.operation:SELECT",
+		"db.operation:INSERT",
+	}, connectionTags(payload, payload.Conns[0]))
+
+	assert.Empty(t, payload.Conns[1].Protocol.Stack)
+	assert.Empty(t, payload.Conns[1].Tags)
+}
+
+func newDatabaseCommandKey(clientPort, serverPort uint16, dbms, command, resource string) database.Key {
+	key := newDatabaseKey(clientPort, serverPort, dbms, "")
+	key.Command = command
+	key.Resource = resource
+	return key
+}
+
+func TestDatabaseSerialization(t *testing.T) {
+	newConfig(t)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				{
+					Source: util.AddressFromString("10.0.0.1"),
+					Dest:   util.AddressFromString("10.0.0.2"),
+					SPort:  60000,
+					DPort:  6379,
+				},
+				{
+					Source: util.AddressFromString("10.0.0.1"),
+					Dest:   util.AddressFromString("10.0.0.2"),
+					SPort:  60001,
+					DPort:  27017,
+				},
+			},
+		},
+		Database: map[database.Key]*database.RequestStat{
+			newDatabaseCommandKey(60000, 6379, database.DBMSRedis, "GET", "user:*"):    {Count: 2, ErrorCount: 1},
+			newDatabaseCommandKey(60000, 6379, database.DBMSRedis, "SET", "user:*"):    {Count: 1},
+			newDatabaseCommandKey(60001, 27017, database.DBMSMongoDB, "find", "users"): {Count: 4},
+		},
+	}
+
+	marshaler := GetMarshaler("application/protobuf")
+	blob, err := marshaler.Marshal(in)
+	require.NoError(t, err)
+
+	unmarshaler := GetUnmarshaler("application/protobuf")
+	result, err := unmarshaler.Unmarshal(blob)
+	require.NoError(t, err)
+	require.Len(t, result.Conns, 2)
+
+	assert.Equal(t, []model.ProtocolType{model.ProtocolType_protocolRedis}, result.Conns[0].Protocol.Stack)
+	assert.ElementsMatch(t, []string{
+		"db.system:redis",
+		"db.requests:3",
+		"db.errors:1",
+		"db.operation:GET",
+		"db.operation:SET",
+		"db.resource:user:*",
+	}, connectionTags(result, result.Conns[0]))
+
+	assert.Equal(t, []model.ProtocolType{model.ProtocolType_protocolMongo}, result.Conns[1].Protocol.Stack)
+	assert.ElementsMatch(t, []string{
+		"db.system:mongodb",
+		"db.requests:4",
+		"db.errors:0",
+		"db.operation:find",
+		"db.resource:users",
+	}, connectionTags(result, result.Conns[1]))
+}
@@ -74,13 +74,14 @@ func modelConnections(conns *network.Connections) *model.Connections {
 	httpEncoder := newHTTPEncoder(conns)
 	defer httpEncoder.Close()
 	kafkaEncoder := newKafkaEncoder(conns)
+	databaseEncoder := newDatabaseEncoder(conns)
 	http2Encoder := newHTTP2Encoder(conns)
 	ipc := make(ipCache, len(conns.Conns)/2)
 	dnsFormatter := newDNSFormatter(conns, ipc)
 	tagsSet := network.NewTagsSet()
 
 	for i, conn := range conns.Conns {
-		agentConns[i] = FormatConnection(conn, routeIndex, httpEncoder, http2Encoder, kafkaEncoder, dnsFormatter, ipc, tagsSet)
+		agentConns[i] = FormatConnection(conn, routeIndex, httpEncoder, http2Encoder, kafkaEncoder, databaseEncoder, dnsFormatter, ipc, tagsSet)
 	}
 
 	if http2Encoder != nil && http2Encoder.orphanEntries > 0 {
@@ -111,6 +112,20 @@ func modelConnections(conns *network.Connections) *model.Connections {
 		).Add(int64(kafkaEncoder.orphanEntries))
 	}
 
+	if databaseEncoder != nil && databaseEncoder.orphanEntries > 0 {
+		log.Debugf(
+			"detected orphan database aggregations. this may be caused by conntrack sampling or missed tcp close events. count=%d",
+			databaseEncoder.orphanEntries,
+		)
+
+		telemetry.NewMetric(
+			"usm.database.orphan_aggregations",
+			telemetry.OptMonotonic,
+			telemetry.OptExpvar,
+			telemetry.OptStatsd,
+		).Add(int64(databaseEncoder.orphanEntries))
+	}
+
 	routes := make([]*model.Route, len(routeIndex))
 	for _, v := range routeIndex {
 		routes[v.Idx] = &v.Route
@@ -16,6 +16,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -52,6 +53,7 @@ func FormatConnection(
 	httpEncoder *httpEncoder,
 	http2Encoder *http2Encoder,
 	kafkaEncoder *kafkaEncoder,
+	databaseEncoder *databaseEncoder,
 	dnsFormatter *dnsFormatter,
 	ipc ipCache,
 	tagsSet *network.TagsSet,
@@ -81,6 +83,11 @@ func FormatConnection(
 	c.IntraHost = conn.IntraHost
 	c.LastTcpEstablished = conn.Last.TCPEstablished
 	c.LastTcpClosed = conn.Last.TCPClosed
+
+	databaseTags, databaseProtocol := databaseEncoder.GetDatabaseTagsAndProtocol(conn)
+	if conn.ProtocolStack.Application == protocols.Unknown {
+		conn.ProtocolStack.Application = databaseProtocol
+	}
 	c.Protocol = formatProtocolStack(conn.ProtocolStack, conn.StaticTags)
 
 	c.RouteIdx = formatRouteIdx(conn.Via, routes)
@@ -98,6 +105,15 @@ func FormatConnection(
 		c.DataStreamsAggregations, _ = proto.Marshal(kafkaStats)
 	}
 
+	if len(databaseTags) > 0 {
+		if dynamicTags == nil {
+			dynamicTags = make(map[string]struct{}, len(databaseTags))
+		}
+		for _, tag := range databaseTags {
+			dynamicTags[tag] = struct{}{}
+		}
+	}
+
 	conn.StaticTags |= staticTags
 	c.Tags, c.TagsChecksum = formatTags(tagsSet, conn, dynamicTags)
 
@@ -285,6 +301,14 @@ func formatTags(tagsSet *network.TagsSet, c network.ConnectionStats, connDynamic
 		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
 	}
 
+	// TLS handshake metadata, e.g., tls.version:tls1.2
+	for _, tag := range c.TLSMetadata.Tags() {
+		mm.Reset()
+		_, _ = mm.Write(unsafeStringSlice(tag))
+		checksum ^= mm.Sum32()
+		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
+	}
+
 	return
 }
 
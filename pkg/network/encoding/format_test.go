@@ -6,13 +6,16 @@
 package encoding
 
 import (
+	"crypto/tls"
 	"runtime"
 	"testing"
 
 	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/tls/handshake"
 )
 
 func TestFormatRouteIdx(t *testing.T) {
@@ -81,6 +84,34 @@ func TestFormatRouteIdx(t *testing.T) {
 	}
 }
 
+func TestFormatTLSMetadataTags(t *testing.T) {
+	tagsSet := network.NewTagsSet()
+	c := network.ConnectionStats{
+		TLSMetadata: &handshake.Metadata{
+			Version:     tls.VersionTLS12,
+			CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			ServerName:  "api.example.com",
+		},
+	}
+	tagsIdx, checksum := formatTags(tagsSet, c, nil)
+
+	var tags []string
+	for _, idx := range tagsIdx {
+		tags = append(tags, tagsSet.GetStrings()[idx])
+	}
+	assert.ElementsMatch(t, []string{
+		"tls.version:tls1.2",
+		"tls.cipher_suite:TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"tls.server_name:api.example.com",
+	}, tags)
+	assert.NotZero(t, checksum)
+
+	// connections without metadata have no tls tags
+	tagsIdx, checksum = formatTags(tagsSet, network.ConnectionStats{}, nil)
+	assert.Empty(t, tagsIdx)
+	assert.Zero(t, checksum)
+}
+
 func BenchmarkConnectionReset(b *testing.B) {
 	c := new(model.Connection)
 	b.ReportAllocs()
@@ -15,8 +15,10 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/tls/handshake"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -136,7 +138,10 @@ type Connections struct {
 	HTTP                        map[http.Key]*http.RequestStats
 	HTTP2                       map[http.Key]*http.RequestStats
 	Kafka                       map[kafka.Key]*kafka.RequestStat
-	DNSStats                    dns.StatsByKeyByNameByType
+	// Database holds the stats of the database transactions. They are
+	// encoded in the connections payload as connection tags.
+	Database map[database.Key]*database.RequestStat
+	DNSStats dns.StatsByKeyByNameByType
 }
 
 // ConnTelemetryType enumerates the connection telemetry gathered by the system-probe
@@ -227,6 +232,8 @@ type ConnectionStats struct {
 
 	IPTranslation *IPTranslation
 	Via           *Via
+	// TLSMetadata describes the TLS handshake of the connection, when it was decoded
+	TLSMetadata *handshake.Metadata
 
 	Monotonic StatCounters
 
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// RequestSummary represents a (debug-friendly) aggregated view of the
+// transactions matching a (client, server, query or command and resource)
+// tuple
+type RequestSummary struct {
+	Client     Address
+	Server     Address
+	DBMS       string
+	Query      string `json:",omitempty"`
+	Command    string `json:",omitempty"`
+	Resource   string `json:",omitempty"`
+	Count      int
+	ErrorCount int
+}
+
+// Address represents represents a IP:Port
+type Address struct {
+	IP   string
+	Port uint16
+}
+
+// Database returns a debug-friendly representation of map[database.Key]database.RequestStat
+func Database(stats map[database.Key]*database.RequestStat) []RequestSummary {
+	all := make([]RequestSummary, 0, len(stats))
+
+	for key, requestStat := range stats {
+		clientAddr := formatIP(key.SrcIPLow, key.SrcIPHigh)
+		serverAddr := formatIP(key.DstIPLow, key.DstIPHigh)
+
+		all = append(all, RequestSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: key.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: key.DstPort,
+			},
+			DBMS:       key.DBMS,
+			Query:      key.Query,
+			Command:    key.Command,
+			Resource:   key.Resource,
+			Count:      requestStat.Count,
+			ErrorCount: requestStat.ErrorCount,
+		})
+	}
+
+	return all
+}
+
+func formatIP(low, high uint64) util.Address {
+	// The socket family isn't part of the key, so it is assumed to be IPv6
+	// only if higher order bits are set, which is fine for debugging code.
+	if high > 0 || (low>>32) > 0 {
+		return util.V6Address(low, high)
+	}
+
+	return util.V4Address(uint32(low))
+}
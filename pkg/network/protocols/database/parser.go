@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package database
+
+// Parser decodes the messages exchanged on a connection of a database
+// protocol and matches the queries or commands with their responses
+type Parser interface {
+	// Feed decodes the given segment of the connection, sent by the client or
+	// the server at the given timestamp in nanoseconds. It returns the
+	// transactions completed by the segment.
+	Feed(data []byte, fromClient bool, timestamp uint64) []*Transaction
+}
@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package snooper
+
+import (
+	"fmt"
+
+	"golang.org/x/net/bpf"
+)
+
+// maxPorts bounds the ports checked by the filter, as the conditional jumps
+// of classic BPF can't skip more than 255 instructions
+const maxPorts = 48
+
+const (
+	labelIPv4    = "ipv4"
+	labelDrop    = "drop"
+	labelCapture = "capture"
+)
+
+// jump is a jump whose targets are resolved once the program is complete
+type jump struct {
+	index int
+	// always is set for the unconditional jumps to ifTrue
+	always          bool
+	cond            bpf.JumpTest
+	val             uint32
+	ifTrue, ifFalse string
+}
+
+type assembler struct {
+	instructions []bpf.Instruction
+	jumps        []jump
+	labels       map[string]int
+}
+
+func (a *assembler) add(instruction bpf.Instruction) {
+	a.instructions = append(a.instructions, instruction)
+}
+
+func (a *assembler) label(name string) {
+	a.labels[name] = len(a.instructions)
+}
+
+// jumpIf adds a conditional jump to the given labels, an empty label being
+// the next instruction
+func (a *assembler) jumpIf(cond bpf.JumpTest, val uint32, ifTrue, ifFalse string) {
+	a.jumps = append(a.jumps, jump{index: len(a.instructions), cond: cond, val: val, ifTrue: ifTrue, ifFalse: ifFalse})
+	// placeholder replaced by resolve
+	a.add(bpf.JumpIf{})
+}
+
+// jump adds an unconditional jump to the given label
+func (a *assembler) jump(label string) {
+	a.jumps = append(a.jumps, jump{index: len(a.instructions), always: true, ifTrue: label})
+	// placeholder replaced by resolve
+	a.add(bpf.Jump{})
+}
+
+// checkPorts adds the instructions capturing the packet when the loaded port
+// is one of the given ports
+func (a *assembler) checkPorts(ports []uint16) {
+	for _, port := range ports {
+		a.jumpIf(bpf.JumpEqual, uint32(port), labelCapture, "")
+	}
+}
+
+func (a *assembler) skip(index int, label string) (uint8, error) {
+	if label == "" {
+		return 0, nil
+	}
+	target, ok := a.labels[label]
+	if !ok {
+		return 0, fmt.Errorf("unknown label %s", label)
+	}
+	skip := target - index - 1
+	if skip < 0 || skip > 255 {
+		return 0, fmt.Errorf("jump to %s out of range: %d", label, skip)
+	}
+	return uint8(skip), nil
+}
+
+func (a *assembler) resolve() ([]bpf.RawInstruction, error) {
+	for _, j := range a.jumps {
+		skipTrue, err := a.skip(j.index, j.ifTrue)
+		if err != nil {
+			return nil, err
+		}
+		if j.always {
+			a.instructions[j.index] = bpf.Jump{Skip: uint32(skipTrue)}
+			continue
+		}
+		skipFalse, err := a.skip(j.index, j.ifFalse)
+		if err != nil {
+			return nil, err
+		}
+		a.instructions[j.index] = bpf.JumpIf{Cond: j.cond, Val: j.val, SkipTrue: skipTrue, SkipFalse: skipFalse}
+	}
+	return bpf.Assemble(a.instructions)
+}
+
+// generateBPFFilter returns a classic BPF filter capturing the TCP segments
+// sent from or to one of the given ports
+func generateBPFFilter(ports []uint16) ([]bpf.RawInstruction, error) {
+	if len(ports) == 0 || len(ports) > maxPorts {
+		return nil, fmt.Errorf("invalid number of ports: %d", len(ports))
+	}
+
+	a := &assembler{labels: make(map[string]int)}
+	// load Ethertype, if IPv6 go next, else check IPv4
+	a.add(bpf.LoadAbsolute{Size: 2, Off: 12})
+	a.jumpIf(bpf.JumpEqual, 0x86dd, "", labelIPv4)
+	// IPv6 Next Header: if TCP go next, else drop
+	a.add(bpf.LoadAbsolute{Size: 1, Off: 20})
+	a.jumpIf(bpf.JumpEqual, 0x6, "", labelDrop)
+	// TCP source and destination ports
+	a.add(bpf.LoadAbsolute{Size: 2, Off: 54})
+	a.checkPorts(ports)
+	a.add(bpf.LoadAbsolute{Size: 2, Off: 56})
+	a.checkPorts(ports)
+	a.jump(labelDrop)
+
+	a.label(labelIPv4)
+	a.jumpIf(bpf.JumpEqual, 0x800, "", labelDrop)
+	// IPv4 Protocol: if TCP go next, else drop
+	a.add(bpf.LoadAbsolute{Size: 1, Off: 23})
+	a.jumpIf(bpf.JumpEqual, 0x6, "", labelDrop)
+	// use 0x1fff as mask for fragment offset, if != 0, drop
+	a.add(bpf.LoadAbsolute{Size: 2, Off: 20})
+	a.jumpIf(bpf.JumpBitsSet, 0x1fff, labelDrop, "")
+	// x = IP header length, then TCP source and destination ports
+	a.add(bpf.LoadMemShift{Off: 14})
+	a.add(bpf.LoadIndirect{Size: 2, Off: 14})
+	a.checkPorts(ports)
+	a.add(bpf.LoadIndirect{Size: 2, Off: 16})
+	a.checkPorts(ports)
+
+	a.label(labelDrop)
+	a.add(bpf.RetConstant{Val: 0})
+	a.label(labelCapture)
+	a.add(bpf.RetConstant{Val: 262144})
+	return a.resolve()
+}
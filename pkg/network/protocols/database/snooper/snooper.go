@@ -0,0 +1,333 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+// Package snooper decodes the traffic of the databases captured by a raw
+// socket, and aggregates the stats of their transactions
+package snooper
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	databaseModuleName = "network_tracer__database"
+
+	// maxConnections bounds the connections being decoded
+	maxConnections = 10000
+	// connectionTimeout is the time after which the state of a connection
+	// without traffic is dropped
+	connectionTimeout = 2 * time.Minute
+)
+
+// Telemetry
+var snooperTelemetry = struct {
+	decodingErrors *telemetry.StatCounterWrapper
+	dropped        *telemetry.StatCounterWrapper
+	gaps           *telemetry.StatCounterWrapper
+}{
+	telemetry.NewStatCounterWrapper(databaseModuleName, "decoding_errors", []string{}, "Counter measuring the number of packets that couldn't be decoded"),
+	telemetry.NewStatCounterWrapper(databaseModuleName, "dropped", []string{}, "Counter measuring the number of connections dropped because of the capacity limits"),
+	telemetry.NewStatCounterWrapper(databaseModuleName, "gaps", []string{}, "Counter measuring the number of connections whose segments weren't all captured"),
+}
+
+// packetSource reads raw packet data
+type packetSource interface {
+	// VisitPackets reads all new raw packets that are available, invoking the given callback for each packet.
+	VisitPackets(cancel <-chan struct{}, visitor func(data []byte, timestamp time.Time) error) error
+
+	// PacketType returns the type of packet this source reads
+	PacketType() gopacket.LayerType
+
+	// Close closes the packet source
+	Close()
+}
+
+// protocol is a monitored database management system
+type protocol struct {
+	statKeeper *database.StatKeeper
+	telemetry  *database.Telemetry
+	newParser  func() database.Parser
+}
+
+type connectionState struct {
+	parser   database.Parser
+	lastSeen time.Time
+	// the next sequence numbers expected from the client and the server
+	clientSeq  uint32
+	serverSeq  uint32
+	clientSeen bool
+	serverSeen bool
+}
+
+// Snooper decodes the traffic of the databases listening on the configured
+// ports, captured by a raw socket. The connections are keyed from the client
+// to the server.
+type Snooper struct {
+	source  packetSource
+	decoder *gopacket.DecodingLayerParser
+	eth     layers.Ethernet
+	ipv4    layers.IPv4
+	ipv6    layers.IPv6
+	tcp     layers.TCP
+	payload gopacket.Payload
+	layers  []gopacket.LayerType
+
+	// protocols are the monitored databases by server port
+	protocols map[uint16]*protocol
+	// connections is only accessed by the goroutine reading the packets
+	connections map[types.ConnectionKey]*connectionState
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSnooper returns a Snooper of the databases of the root network namespace
+func NewSnooper(cfg *config.Config) (*Snooper, error) {
+	protocols := newProtocols(cfg)
+	ports := make([]uint16, 0, len(protocols))
+	for port := range protocols {
+		ports = append(ports, port)
+	}
+	bpfFilter, err := generateBPFFilter(ports)
+	if err != nil {
+		return nil, fmt.Errorf("error creating bpf classic filter: %w", err)
+	}
+
+	// Create the RAW_SOCKET inside the root network namespace
+	var (
+		packetSrc *filterpkg.AFPacketSource
+		srcErr    error
+		ns        netns.NsHandle
+	)
+	if ns, err = cfg.GetRootNetNs(); err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	err = util.WithNS(ns, func() error {
+		packetSrc, srcErr = filterpkg.NewPacketSource(nil, bpfFilter)
+		return srcErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSnooper(packetSrc, protocols), nil
+}
+
+// newProtocols returns the monitored databases by server port
+func newProtocols(cfg *config.Config) map[uint16]*protocol {
+	protocols := make(map[uint16]*protocol)
+	add := func(dbms string, ports []uint16, newParser func(*database.Telemetry) database.Parser) {
+		if len(ports) == 0 {
+			return
+		}
+		dbmsTelemetry := database.NewTelemetry(dbms)
+		p := &protocol{
+			statKeeper: database.NewStatKeeper(dbms, cfg.MaxDatabaseStatsBuffered, dbmsTelemetry),
+			telemetry:  dbmsTelemetry,
+			newParser:  func() database.Parser { return newParser(dbmsTelemetry) },
+		}
+		for _, port := range ports {
+			if _, ok := protocols[port]; ok {
+				log.Warnf("database port %d is configured more than once, ignoring it for %s", port, dbms)
+				continue
+			}
+			protocols[port] = p
+		}
+	}
+	add(database.DBMSPostgres, cfg.PostgresPorts, func(t *database.Telemetry) database.Parser { return postgres.NewParser(t) })
+	add(database.DBMSMySQL, cfg.MySQLPorts, func(*database.Telemetry) database.Parser { return mysql.NewParser() })
+	add(database.DBMSRedis, cfg.RedisPorts, func(t *database.Telemetry) database.Parser { return redis.NewParser(t) })
+	add(database.DBMSMongoDB, cfg.MongoDBPorts, func(t *database.Telemetry) database.Parser { return mongo.NewParser(t) })
+	return protocols
+}
+
+func newSnooper(source packetSource, protocols map[uint16]*protocol) *Snooper {
+	s := &Snooper{
+		source:      source,
+		protocols:   protocols,
+		connections: make(map[types.ConnectionKey]*connectionState),
+		exit:        make(chan struct{}),
+	}
+	s.decoder = gopacket.NewDecodingLayerParser(source.PacketType(), &s.eth, &s.ipv4, &s.ipv6, &s.tcp, &s.payload)
+	s.decoder.IgnoreUnsupported = true
+	return s
+}
+
+// Start starts reading the packets
+func (s *Snooper) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.pollPackets()
+	}()
+}
+
+// Close stops reading the packets and closes the underlying socket
+func (s *Snooper) Close() {
+	close(s.exit)
+	s.wg.Wait()
+	s.source.Close()
+}
+
+// GetAndResetAllStats returns the stats of all the monitored databases
+// aggregated since the last call
+func (s *Snooper) GetAndResetAllStats() map[database.Key]*database.RequestStat {
+	ret := make(map[database.Key]*database.RequestStat)
+	seen := make(map[*protocol]struct{})
+	for _, p := range s.protocols {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		p.telemetry.Log()
+		for key, stats := range p.statKeeper.GetAndResetAllStats() {
+			ret[key] = stats
+		}
+	}
+	return ret
+}
+
+func (s *Snooper) pollPackets() {
+	for {
+		err := s.source.VisitPackets(s.exit, s.processPacket)
+		if err != nil {
+			log.Warnf("error reading packet: %s", err)
+		}
+
+		// Properly synchronizes termination process
+		select {
+		case <-s.exit:
+			return
+		default:
+		}
+
+		s.expire(time.Now())
+		// Sleep briefly and try again
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// processPacket decodes the database messages of a captured packet. The
+// packet data can't be referenced after this call, as it is reused by the
+// packet source.
+func (s *Snooper) processPacket(data []byte, ts time.Time) error {
+	if err := s.decoder.DecodeLayers(data, &s.layers); err != nil {
+		snooperTelemetry.decodingErrors.Inc()
+		return nil
+	}
+
+	var (
+		saddr, daddr util.Address
+		payloadLen   int
+		hasTCP       bool
+	)
+	for _, layer := range s.layers {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			saddr, daddr = util.AddressFromNetIP(s.ipv4.SrcIP), util.AddressFromNetIP(s.ipv4.DstIP)
+			payloadLen = int(s.ipv4.Length) - int(s.ipv4.IHL)*4
+		case layers.LayerTypeIPv6:
+			saddr, daddr = util.AddressFromNetIP(s.ipv6.SrcIP), util.AddressFromNetIP(s.ipv6.DstIP)
+			payloadLen = int(s.ipv6.Length)
+		case layers.LayerTypeTCP:
+			hasTCP = true
+		}
+	}
+	if !hasTCP || len(s.tcp.Payload) == 0 {
+		return nil
+	}
+	payload := s.tcp.Payload
+	// the length of the segment, which may be longer than the captured
+	// payload
+	segmentLen := payloadLen - int(s.tcp.DataOffset)*4
+	if segmentLen < len(payload) {
+		segmentLen = len(payload)
+	}
+
+	sport, dport := uint16(s.tcp.SrcPort), uint16(s.tcp.DstPort)
+	var key types.ConnectionKey
+	fromClient := true
+	p, ok := s.protocols[dport]
+	if ok {
+		key = types.NewConnectionKey(saddr, daddr, sport, dport)
+	} else if p, ok = s.protocols[sport]; ok {
+		key = types.NewConnectionKey(daddr, saddr, dport, sport)
+		fromClient = false
+	} else {
+		return nil
+	}
+
+	state, ok := s.connections[key]
+	if !ok {
+		if len(s.connections) >= maxConnections {
+			snooperTelemetry.dropped.Inc()
+			return nil
+		}
+		state = &connectionState{parser: p.newParser()}
+		s.connections[key] = state
+	}
+	state.lastSeen = ts
+
+	seq := s.tcp.Seq
+	expected, seen := &state.clientSeq, &state.clientSeen
+	if !fromClient {
+		expected, seen = &state.serverSeq, &state.serverSeen
+	}
+	if !*seen {
+		*seen = true
+		*expected = seq
+	}
+	if diff := int32(seq - *expected); diff < 0 {
+		// a retransmission
+		return nil
+	} else if diff > 0 {
+		// some segments weren't captured, the parser starts over as if it
+		// was the middle of the connection
+		snooperTelemetry.gaps.Inc()
+		state.parser = p.newParser()
+	}
+	*expected = seq + uint32(segmentLen)
+
+	for _, tx := range state.parser.Feed(payload, fromClient, uint64(ts.UnixNano())) {
+		p.statKeeper.Process(key, tx)
+	}
+	if len(payload) < segmentLen {
+		// the end of the segment wasn't captured
+		snooperTelemetry.gaps.Inc()
+		state.parser = p.newParser()
+	}
+	return nil
+}
+
+// expire drops the state of the connections without traffic
+func (s *Snooper) expire(now time.Time) {
+	for key, state := range s.connections {
+		if now.Sub(state.lastSeen) > connectionTimeout {
+			delete(s.connections, key)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package snooper
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/bpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+type testPacketSource struct{}
+
+func (testPacketSource) VisitPackets(<-chan struct{}, func([]byte, time.Time) error) error {
+	return nil
+}
+
+func (testPacketSource) PacketType() gopacket.LayerType {
+	return layers.LayerTypeEthernet
+}
+
+func (testPacketSource) Close() {}
+
+// frame returns an Ethernet frame of a TCP segment
+func frame(t *testing.T, src, dst string, sport, dport uint16, seq uint32, payload []byte) []byte {
+	eth := &layers.Ethernet{
+		SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6},
+	}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(sport), DstPort: layers.TCPPort(dport), Seq: seq, ACK: true, PSH: true, Window: 1024}
+	var network gopacket.NetworkLayer
+	if ip := net.ParseIP(src); ip.To4() != nil {
+		eth.EthernetType = layers.EthernetTypeIPv4
+		ipv4 := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: ip, DstIP: net.ParseIP(dst)}
+		network = ipv4
+	} else {
+		eth.EthernetType = layers.EthernetTypeIPv6
+		ipv6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolTCP, SrcIP: ip, DstIP: net.ParseIP(dst)}
+		network = ipv6
+	}
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(network))
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, eth, network.(gopacket.SerializableLayer), tcp, gopacket.Payload(payload)))
+	return buf.Bytes()
+}
+
+// message returns a PostgreSQL message
+func message(typ byte, body string) []byte {
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(body)+4))
+	return append(msg, body...)
+}
+
+func query(q string) []byte {
+	return message('Q', q+"\x00")
+}
+
+var response = append(message('C', "SELECT 1\x00"), message('Z', "I")...)
+
+func testConfig() *config.Config {
+	cfg := config.New()
+	cfg.PostgresPorts = []uint16{5432}
+	cfg.MySQLPorts = []uint16{3306}
+	cfg.RedisPorts = []uint16{6379}
+	cfg.MongoDBPorts = []uint16{27017}
+	cfg.MaxDatabaseStatsBuffered = 1000
+	return cfg
+}
+
+func TestBPFFilter(t *testing.T) {
+	instructions, err := generateBPFFilter([]uint16{5432, 3306})
+	require.NoError(t, err)
+	var disassembled []bpf.Instruction
+	for _, raw := range instructions {
+		disassembled = append(disassembled, raw.Disassemble())
+	}
+	vm, err := bpf.NewVM(disassembled)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		captured bool
+	}{
+		{"ipv4 to server", frame(t, "10.0.0.1", "10.0.0.2", 45678, 5432, 1, []byte("x")), true},
+		{"ipv4 from server", frame(t, "10.0.0.2", "10.0.0.1", 3306, 45678, 1, []byte("x")), true},
+		{"ipv6 to server", frame(t, "fd00::1", "fd00::2", 45678, 3306, 1, []byte("x")), true},
+		{"ipv6 from server", frame(t, "fd00::2", "fd00::1", 5432, 45678, 1, []byte("x")), true},
+		{"ipv4 other port", frame(t, "10.0.0.1", "10.0.0.2", 45678, 443, 1, []byte("x")), false},
+		{"ipv6 other port", frame(t, "fd00::1", "fd00::2", 45678, 6379, 1, []byte("x")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := vm.Run(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.captured, n > 0)
+		})
+	}
+
+	_, err = generateBPFFilter(nil)
+	assert.Error(t, err)
+	_, err = generateBPFFilter(make([]uint16, maxPorts+1))
+	assert.Error(t, err)
+}
+
+func TestSnooper(t *testing.T) {
+	s := newSnooper(testPacketSource{}, newProtocols(testConfig()))
+	now := time.Now()
+
+	client, server := "10.0.0.1", "10.0.0.2"
+	q1 := query("SELECT * FROM users WHERE id = 1")
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100, q1), now))
+	// a retransmission is ignored
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100, q1), now))
+	require.NoError(t, s.processPacket(frame(t, server, client, 5432, 45678, 900, response), now.Add(time.Millisecond)))
+
+	q2 := query("SELECT * FROM users WHERE id = 2")
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100+uint32(len(q1)), q2), now.Add(2*time.Millisecond)))
+	require.NoError(t, s.processPacket(frame(t, server, client, 5432, 45678, 900+uint32(len(response)), response), now.Add(3*time.Millisecond)))
+
+	// traffic on other ports is ignored
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 443, 1, q1), now))
+	assert.Len(t, s.connections, 1)
+
+	stats := s.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	key := database.Key{
+		Query:         "SELECT * FROM users WHERE id = ?",
+		DBMS:          database.DBMSPostgres,
+		ConnectionKey: types.NewConnectionKey(util.AddressFromString(client), util.AddressFromString(server), 45678, 5432),
+	}
+	require.Contains(t, stats, key)
+	assert.Equal(t, 2, stats[key].Count)
+	assert.Equal(t, 0, stats[key].ErrorCount)
+	assert.Empty(t, s.GetAndResetAllStats())
+
+	// the state of the connections without traffic expires
+	s.expire(now.Add(connectionTimeout / 2))
+	assert.Len(t, s.connections, 1)
+	s.expire(now.Add(2 * connectionTimeout))
+	assert.Empty(t, s.connections)
+}
+
+func TestSnooperGap(t *testing.T) {
+	s := newSnooper(testPacketSource{}, newProtocols(testConfig()))
+	now := time.Now()
+
+	client, server := "fd00::1", "fd00::2"
+	q := query("SELECT 1")
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100, q), now))
+	// the end of the responses to the first queries was lost, so they can't
+	// be matched with their responses
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100+uint32(len(q)), q), now))
+	require.NoError(t, s.processPacket(frame(t, server, client, 5432, 45678, 900, response[:len(response)-1]), now))
+	require.NoError(t, s.processPacket(frame(t, server, client, 5432, 45678, 900+uint32(len(response))+5, response), now))
+
+	// the parser starts over after the gap
+	q2 := query("SELECT name FROM users")
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 5432, 100+2*uint32(len(q)), q2), now))
+	require.NoError(t, s.processPacket(frame(t, server, client, 5432, 45678, 900+2*uint32(len(response))+5, response), now))
+
+	stats := s.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for key, stat := range stats {
+		assert.Equal(t, "SELECT name FROM users", key.Query)
+		assert.Equal(t, 1, stat.Count)
+	}
+}
+
+func TestSnooperRedis(t *testing.T) {
+	s := newSnooper(testPacketSource{}, newProtocols(testConfig()))
+	now := time.Now()
+
+	client, server := "10.0.0.1", "10.0.0.2"
+	get := []byte("*2\r\n$3\r\nGET\r\n$18\r\nuser:1234:password\r\n")
+	require.NoError(t, s.processPacket(frame(t, client, server, 45678, 6379, 100, get), now))
+	require.NoError(t, s.processPacket(frame(t, server, client, 6379, 45678, 900, []byte("$-1\r\n")), now.Add(time.Millisecond)))
+
+	stats := s.GetAndResetAllStats()
+	key := database.Key{
+		Command:       "GET",
+		Resource:      "user:*",
+		DBMS:          database.DBMSRedis,
+		ConnectionKey: types.NewConnectionKey(util.AddressFromString(client), util.AddressFromString(server), 45678, 6379),
+	}
+	require.Contains(t, stats, key)
+	assert.Equal(t, 1, stats[key].Count)
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package database
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+const (
+	// DBMSPostgres, DBMSMySQL, DBMSRedis and DBMSMongoDB are the supported
+	// database management systems
+	DBMSPostgres = obfuscate.DBMSPostgres
+	DBMSMySQL    = "mysql"
+	DBMSRedis    = "redis"
+	DBMSMongoDB  = "mongodb"
+
+	// maxSignatures is the number of obfuscated queries we cache, since the
+	// same queries are usually run over and over
+	maxSignatures = 1024
+)
+
+// StatKeeper aggregates the transactions of a database protocol by
+// connection and obfuscated query, or command and resource
+type StatKeeper struct {
+	dbms       string
+	stats      map[Key]*RequestStat
+	statsMutex sync.RWMutex
+	maxEntries int
+	telemetry  *Telemetry
+
+	obfuscator *obfuscate.Obfuscator
+	sqlConfig  *obfuscate.SQLConfig
+	signatures map[string]string
+}
+
+// NewStatKeeper returns a StatKeeper of the transactions of the given
+// database management system, keeping at most maxEntries stats
+func NewStatKeeper(dbms string, maxEntries int, telemetry *Telemetry) *StatKeeper {
+	statKeeper := &StatKeeper{
+		dbms:       dbms,
+		stats:      make(map[Key]*RequestStat),
+		maxEntries: maxEntries,
+		telemetry:  telemetry,
+	}
+	switch dbms {
+	case DBMSPostgres, DBMSMySQL:
+		statKeeper.obfuscator = obfuscate.NewObfuscator(obfuscate.Config{})
+		statKeeper.sqlConfig = &obfuscate.SQLConfig{DBMS: dbms}
+		statKeeper.signatures = make(map[string]string)
+	}
+	return statKeeper
+}
+
+// Process adds a transaction of the given connection to the stats. The
+// transactions with a query that can't be obfuscated are aggregated with an
+// empty query. The resources of the transactions of the databases that aren't
+// SQL ones are expected to be obfuscated by their parser.
+func (statKeeper *StatKeeper) Process(conn types.ConnectionKey, tx *Transaction) {
+	statKeeper.telemetry.totalHits.Add(1)
+	if tx.Error {
+		statKeeper.telemetry.errors.Add(1)
+	}
+
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+
+	key := Key{
+		Query:         statKeeper.signature(tx.Query),
+		Command:       tx.Command,
+		Resource:      tx.Resource,
+		DBMS:          statKeeper.dbms,
+		ConnectionKey: conn,
+	}
+	requestStats, ok := statKeeper.stats[key]
+	if !ok {
+		if len(statKeeper.stats) >= statKeeper.maxEntries {
+			statKeeper.telemetry.dropped.Add(1)
+			return
+		}
+		requestStats = new(RequestStat)
+		statKeeper.stats[key] = requestStats
+	}
+	requestStats.AddRequest(tx.Latency(), tx.Error)
+}
+
+// signature returns the obfuscated query. The lock must be held.
+func (statKeeper *StatKeeper) signature(query string) string {
+	if query == "" || statKeeper.sqlConfig == nil {
+		// the query of a statement prepared before the connection was
+		// decoded, or a transaction of a database that isn't a SQL one
+		return ""
+	}
+	if signature, ok := statKeeper.signatures[query]; ok {
+		return signature
+	}
+	var signature string
+	if oq, err := statKeeper.obfuscator.ObfuscateSQLStringWithOptions(query, statKeeper.sqlConfig); err != nil {
+		statKeeper.telemetry.obfuscationErrors.Add(1)
+	} else {
+		signature = oq.Query
+	}
+	if len(statKeeper.signatures) >= maxSignatures {
+		statKeeper.signatures = make(map[string]string)
+	}
+	statKeeper.signatures[query] = signature
+	return signature
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (statKeeper *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+	ret := statKeeper.stats // No deep copy needed since `statKeeper.stats` gets reset
+	statKeeper.stats = make(map[Key]*RequestStat)
+	return ret
+}
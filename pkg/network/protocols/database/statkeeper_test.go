@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestStatKeeperAggregatesBySignature(t *testing.T) {
+	sk := NewStatKeeper(DBMSPostgres, 1000, NewTelemetry("postgres"))
+	conn := NewKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 45678, 5432, "").ConnectionKey
+
+	sk.Process(conn, &Transaction{Query: "SELECT * FROM users WHERE id = 1", RequestStarted: 0, ResponseLastSeen: 1000})
+	sk.Process(conn, &Transaction{Query: "SELECT * FROM users WHERE id = 42", RequestStarted: 0, ResponseLastSeen: 3000})
+	sk.Process(conn, &Transaction{Query: "SELECT * FROM users WHERE id = 7", RequestStarted: 0, ResponseLastSeen: 2000, Error: true})
+	sk.Process(conn, &Transaction{Query: "DELETE FROM users WHERE id = $1", RequestStarted: 10, ResponseLastSeen: 20})
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	selectStats := stats[Key{Query: "SELECT * FROM users WHERE id = ?", DBMS: DBMSPostgres, ConnectionKey: conn}]
+	require.NotNil(t, selectStats)
+	assert.Equal(t, 3, selectStats.Count)
+	assert.Equal(t, 1, selectStats.ErrorCount)
+	require.NotNil(t, selectStats.Latencies)
+	assert.Equal(t, float64(3), selectStats.Latencies.GetCount())
+	p50, err := selectStats.Latencies.GetValueAtQuantile(0.5)
+	require.NoError(t, err)
+	assert.InEpsilon(t, 2000, p50, 0.02)
+
+	deleteStats := stats[Key{Query: "DELETE FROM users WHERE id = ?", DBMS: DBMSPostgres, ConnectionKey: conn}]
+	require.NotNil(t, deleteStats)
+	assert.Equal(t, 1, deleteStats.Count)
+	assert.Nil(t, deleteStats.Latencies)
+	assert.Equal(t, float64(10), deleteStats.FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperAggregatesByCommand(t *testing.T) {
+	sk := NewStatKeeper(DBMSRedis, 1000, NewTelemetry("redis"))
+	conn := NewKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 45678, 6379, "").ConnectionKey
+
+	sk.Process(conn, &Transaction{Command: "GET", Resource: "user:*", RequestStarted: 0, ResponseLastSeen: 1000})
+	sk.Process(conn, &Transaction{Command: "GET", Resource: "user:*", RequestStarted: 0, ResponseLastSeen: 2000, Error: true})
+	sk.Process(conn, &Transaction{Command: "SET", Resource: "user:*", RequestStarted: 0, ResponseLastSeen: 500})
+	// queries are ignored for the databases that aren't SQL ones
+	sk.Process(conn, &Transaction{Query: "SELECT 1", Command: "SET", Resource: "user:*", RequestStarted: 0, ResponseLastSeen: 500})
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	getStats := stats[Key{Command: "GET", Resource: "user:*", DBMS: DBMSRedis, ConnectionKey: conn}]
+	require.NotNil(t, getStats)
+	assert.Equal(t, 2, getStats.Count)
+	assert.Equal(t, 1, getStats.ErrorCount)
+
+	setStats := stats[Key{Command: "SET", Resource: "user:*", DBMS: DBMSRedis, ConnectionKey: conn}]
+	require.NotNil(t, setStats)
+	assert.Equal(t, 2, setStats.Count)
+	assert.Equal(t, 0, setStats.ErrorCount)
+}
+
+func TestStatKeeperMaxEntries(t *testing.T) {
+	sk := NewStatKeeper(DBMSMySQL, 2, NewTelemetry("mysql"))
+	conn := NewKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 45678, 3306, "").ConnectionKey
+
+	sk.Process(conn, &Transaction{Query: "SELECT a FROM t"})
+	sk.Process(conn, &Transaction{Query: "SELECT b FROM t"})
+	sk.Process(conn, &Transaction{Query: "SELECT c FROM t"})
+	sk.Process(conn, &Transaction{Query: "SELECT a FROM t WHERE x = 1"})
+
+	stats := sk.GetAndResetAllStats()
+	assert.Len(t, stats, 2)
+	assert.Contains(t, stats, Key{Query: "SELECT a FROM t", DBMS: DBMSMySQL, ConnectionKey: conn})
+	assert.Contains(t, stats, Key{Query: "SELECT b FROM t", DBMS: DBMSMySQL, ConnectionKey: conn})
+}
+
+func TestRequestStatCombineWith(t *testing.T) {
+	single := new(RequestStat)
+	single.AddRequest(100, true)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(200, false)
+	multiple.AddRequest(300, true)
+
+	combined := new(RequestStat)
+	combined.CombineWith(single)
+	assert.Equal(t, 1, combined.Count)
+	assert.Equal(t, 1, combined.ErrorCount)
+	assert.Nil(t, combined.Latencies)
+
+	combined.CombineWith(multiple)
+	assert.Equal(t, 3, combined.Count)
+	assert.Equal(t, 2, combined.ErrorCount)
+	require.NotNil(t, combined.Latencies)
+	assert.Equal(t, float64(3), combined.Latencies.GetCount())
+
+	combined.CombineWith(multiple)
+	assert.Equal(t, 5, combined.Count)
+	assert.Equal(t, 3, combined.ErrorCount)
+	assert.Equal(t, float64(5), combined.Latencies.GetCount())
+}
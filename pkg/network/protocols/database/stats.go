@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package database holds the aggregation of the transactions of the database
+// protocols, like PostgreSQL, MySQL, Redis and MongoDB, that are decoded in
+// userspace.
+package database
+
+import (
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+// For example, if the actual value at p50 is 100, with a relative accuracy of 0.01 the value calculated
+// will be between 99 and 101
+const RelativeAccuracy = 0.01
+
+// Transaction is a query and its response, as decoded by a protocol parser
+type Transaction struct {
+	// Query is the raw query sent by the client, for the SQL databases
+	Query string
+	// Command is the name of the command or operation, like GET or find, and
+	// Resource its obfuscated target, like a key prefix or a collection, for
+	// the other databases
+	Command  string
+	Resource string
+	// RequestStarted and ResponseLastSeen are timestamps in nanoseconds
+	RequestStarted   uint64
+	ResponseLastSeen uint64
+	// Error is set when the server responded with an error
+	Error bool
+}
+
+// Latency returns the duration of the transaction, in nanoseconds
+func (tx *Transaction) Latency() float64 {
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		return 0
+	}
+	return float64(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+// Key is an identifier for a group of database transactions
+type Key struct {
+	// this field order is intentional to help the GC pointer tracking
+	// Query is the obfuscated query, the signature of the transactions
+	Query    string
+	Command  string
+	Resource string
+	// DBMS is the database management system of the transactions, like DBMSPostgres
+	DBMS string
+	types.ConnectionKey
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, query string) Key {
+	return Key{
+		ConnectionKey: types.NewConnectionKey(saddr, daddr, sport, dport),
+		Query:         query,
+	}
+}
+
+// RequestStat stores stats for the transactions of a particular key
+type RequestStat struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	// Count is the number of transactions, kept apart from the sketch since
+	// it may discard values outside of its range
+	Count int
+	// ErrorCount is the number of transactions resulting in an error
+	ErrorCount int
+	// FirstLatencySample holds the latency of the first transaction, in
+	// nanoseconds, to avoid creating sketches with a single value
+	FirstLatencySample float64
+}
+
+func (r *RequestStat) initSketch() (err error) {
+	r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+	if err != nil {
+		log.Debugf("error recording database transaction latency: could not create new ddsketch: %v", err)
+	}
+	return
+}
+
+// AddRequest adds the latency, in nanoseconds, and the result of a
+// transaction to the stats
+func (r *RequestStat) AddRequest(latency float64, isError bool) {
+	if isError {
+		r.ErrorCount++
+	}
+	r.Count++
+	if r.Count == 1 {
+		// We postpone the creation of histograms when we have only one latency sample
+		r.FirstLatencySample = latency
+		return
+	}
+	if r.Latencies == nil {
+		if err := r.initSketch(); err != nil {
+			return
+		}
+		// Add the deferred latency sample
+		if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+			log.Debugf("could not add database transaction latency to ddsketch: %v", err)
+		}
+	}
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add database transaction latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	if newStats.Count == 0 {
+		return
+	}
+	if newStats.Count == 1 {
+		// The other stats have a single latency sample, so we "manually" add it
+		r.AddRequest(newStats.FirstLatencySample, newStats.ErrorCount > 0)
+		return
+	}
+
+	// The other stats have multiple samples and therefore a DDSketch object
+	if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+		// If we have a latency sample in these stats we now add it to the DDSketch
+		if r.Count == 1 {
+			if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+				log.Debugf("could not add database transaction latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging database transactions: %v", err)
+	}
+	r.Count += newStats.Count
+	r.ErrorCount += newStats.ErrorCount
+}
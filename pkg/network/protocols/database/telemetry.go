@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package database
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Telemetry holds the metrics of the stat keeper of a database protocol
+type Telemetry struct {
+	protocol string
+	then     *atomic.Int64
+
+	totalHits          *libtelemetry.Metric
+	errors             *libtelemetry.Metric
+	obfuscationErrors  *libtelemetry.Metric
+	dropped            *libtelemetry.Metric // this happens when StatKeeper reaches capacity
+	malformedResponses *libtelemetry.Metric
+}
+
+// NewTelemetry returns the telemetry of the given protocol, like "postgres"
+func NewTelemetry(protocol string) *Telemetry {
+	metricGroup := libtelemetry.NewMetricGroup(
+		"usm."+protocol,
+		libtelemetry.OptExpvar,
+		libtelemetry.OptMonotonic,
+	)
+
+	return &Telemetry{
+		protocol: protocol,
+		then:     atomic.NewInt64(time.Now().Unix()),
+
+		// these metrics are also exported as statsd metrics
+		totalHits:          metricGroup.NewMetric("total_hits", libtelemetry.OptStatsd),
+		errors:             metricGroup.NewMetric("errors", libtelemetry.OptStatsd),
+		obfuscationErrors:  metricGroup.NewMetric("obfuscation_errors", libtelemetry.OptStatsd),
+		dropped:            metricGroup.NewMetric("dropped", libtelemetry.OptStatsd),
+		malformedResponses: metricGroup.NewMetric("malformed", libtelemetry.OptStatsd),
+	}
+}
+
+// Malformed counts the messages that a parser couldn't decode
+func (t *Telemetry) Malformed() {
+	t.malformedResponses.Add(1)
+}
+
+// Log logs a summary of the telemetry since the last call
+func (t *Telemetry) Log() {
+	now := time.Now().Unix()
+	then := t.then.Swap(now)
+
+	totalRequests := t.totalHits.Delta()
+	errors := t.errors.Delta()
+	dropped := t.dropped.Delta()
+	obfuscationErrors := t.obfuscationErrors.Delta()
+	malformed := t.malformedResponses.Delta()
+	elapsed := now - then
+
+	log.Debugf(
+		"%s stats summary: requests_processed=%d(%.2f/s) requests_errors=%d(%.2f/s) requests_dropped=%d(%.2f/s) obfuscation_errors=%d malformed=%d",
+		t.protocol,
+		totalRequests,
+		float64(totalRequests)/float64(elapsed),
+		errors,
+		float64(errors)/float64(elapsed),
+		dropped,
+		float64(dropped)/float64(elapsed),
+		obfuscationErrors,
+		malformed,
+	)
+}
@@ -0,0 +1,311 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mongo
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+const (
+	headerLength = 16
+	// maxMessageLength is the length above which a message is considered
+	// malformed, as MongoDB limits messages to 48MB
+	maxMessageLength = 48 * 1000 * 1000
+	// maxMessageSize is the number of bytes of a message that are kept. The
+	// commands and replies are decoded as far as they were kept.
+	maxMessageSize = 16 * 1024
+	// maxPendingRequests bounds the memory used by the parser of a connection
+	maxPendingRequests = 128
+)
+
+// Operation codes
+const (
+	opReply      = 1
+	opQuery      = 2004
+	opCompressed = 2012
+	opMsg        = 2013
+)
+
+// OP_MSG flags and sections
+const (
+	moreToCome = 1 << 1
+
+	bodySection             = 0
+	documentSequenceSection = 1
+)
+
+var errMalformed = errors.New("malformed mongodb message")
+
+type header struct {
+	length     int
+	requestID  uint32
+	responseTo uint32
+	opCode     uint32
+}
+
+type pendingRequest struct {
+	command  string
+	resource string
+	started  uint64
+}
+
+// Parser decodes the OP_MSG messages exchanged on a MongoDB connection and
+// matches the commands with their replies. The legacy OP_QUERY messages, only
+// sent by the drivers for the initial handshake, and the compressed messages
+// are skipped.
+type Parser struct {
+	client stream
+	server stream
+
+	pending map[uint32]*pendingRequest
+	// stopped is set when the messages can't be decoded anymore
+	stopped bool
+
+	telemetry *database.Telemetry
+	completed []*database.Transaction
+}
+
+// NewParser returns a parser of a MongoDB connection
+func NewParser(telemetry *database.Telemetry) *Parser {
+	return &Parser{
+		pending:   make(map[uint32]*pendingRequest),
+		telemetry: telemetry,
+	}
+}
+
+// Feed decodes the given segment of the connection, sent by the client or
+// the server at the given timestamp in nanoseconds. It returns the
+// transactions completed by the segment.
+func (p *Parser) Feed(data []byte, fromClient bool, timestamp uint64) []*database.Transaction {
+	if p.stopped {
+		return nil
+	}
+	p.completed = nil
+
+	var err error
+	if fromClient {
+		err = p.client.feed(data, func(h header, body []byte) {
+			p.handleRequest(h, body, timestamp)
+		})
+	} else {
+		err = p.server.feed(data, func(h header, body []byte) {
+			p.handleReply(h, body, timestamp)
+		})
+	}
+	if err != nil {
+		p.stopped = true
+		if p.telemetry != nil {
+			p.telemetry.Malformed()
+		}
+	}
+	return p.completed
+}
+
+func (p *Parser) handleRequest(h header, body []byte, timestamp uint64) {
+	if h.opCode != opMsg {
+		return
+	}
+	flags, doc, ok := readMsg(body)
+	if !ok || flags&moreToCome != 0 {
+		// no reply is sent for the requests with the moreToCome flag, like
+		// the unacknowledged writes
+		return
+	}
+	if len(p.pending) >= maxPendingRequests {
+		return
+	}
+
+	req := &pendingRequest{started: timestamp}
+	var db, collection string
+	for i, elem := range elements(doc) {
+		key, value := elem.Key(), elem.Value()
+		switch {
+		case i == 0:
+			req.command = key
+			collection, _ = value.StringValueOK()
+		case key == "collection" && req.command == "getMore":
+			collection, _ = value.StringValueOK()
+		case key == "$db":
+			db, _ = value.StringValueOK()
+		}
+	}
+	if req.command == "" {
+		return
+	}
+	switch {
+	case db == "":
+		// the database wasn't kept
+		req.resource = collection
+	case collection == "":
+		req.resource = db
+	default:
+		req.resource = db + "." + collection
+	}
+	p.pending[h.requestID] = req
+}
+
+func (p *Parser) handleReply(h header, body []byte, timestamp uint64) {
+	req, ok := p.pending[h.responseTo]
+	if !ok {
+		// the replies to the skipped requests, or the following replies
+		// of an exhaust cursor
+		return
+	}
+	delete(p.pending, h.responseTo)
+
+	var isError bool
+	if h.opCode == opMsg {
+		if _, doc, ok := readMsg(body); ok {
+			isError = replyError(doc)
+		}
+	}
+	p.completed = append(p.completed, &database.Transaction{
+		Command:          req.command,
+		Resource:         req.resource,
+		RequestStarted:   req.started,
+		ResponseLastSeen: timestamp,
+		Error:            isError,
+	})
+}
+
+// replyError returns whether the reply reports a failed command or some
+// failed writes
+func replyError(doc []byte) bool {
+	for _, elem := range elements(doc) {
+		switch elem.Key() {
+		case "ok":
+			value := elem.Value()
+			if b, ok := value.BooleanOK(); ok {
+				if !b {
+					return true
+				}
+			} else if n, ok := value.AsInt64OK(); ok && n == 0 {
+				return true
+			}
+		case "writeErrors", "writeConcernError":
+			return true
+		}
+	}
+	return false
+}
+
+// readMsg returns the flags and the body document of an OP_MSG message
+func readMsg(body []byte) (uint32, []byte, bool) {
+	if len(body) < 5 {
+		return 0, nil, false
+	}
+	flags := binary.LittleEndian.Uint32(body)
+	sections := body[4:]
+	for len(sections) > 0 {
+		kind := sections[0]
+		sections = sections[1:]
+		switch kind {
+		case bodySection:
+			return flags, sections, true
+		case documentSequenceSection:
+			if len(sections) < 4 {
+				return 0, nil, false
+			}
+			size := int(binary.LittleEndian.Uint32(sections))
+			if size < 4 || size > len(sections) {
+				return 0, nil, false
+			}
+			sections = sections[size:]
+		default:
+			return 0, nil, false
+		}
+	}
+	return 0, nil, false
+}
+
+// elements returns the elements of a BSON document, as far as it can be
+// decoded when it was truncated
+func elements(doc []byte) []bsoncore.Element {
+	if len(doc) < 4 {
+		return nil
+	}
+	var elems []bsoncore.Element
+	rem := doc[4:]
+	for len(rem) > 0 && rem[0] != 0 {
+		elem, next, ok := bsoncore.ReadElement(rem)
+		if !ok {
+			break
+		}
+		elems = append(elems, elem)
+		rem = next
+	}
+	return elems
+}
+
+// stream reassembles the messages sent in one direction of a connection. A
+// message is made of a 16-bytes header starting with its length.
+type stream struct {
+	header    []byte
+	current   header
+	body      []byte
+	read      int
+	inMessage bool
+}
+
+func (s *stream) feed(data []byte, handle func(h header, body []byte)) error {
+	for len(data) > 0 {
+		if !s.inMessage {
+			n := headerLength - len(s.header)
+			if n > len(data) {
+				n = len(data)
+			}
+			s.header = append(s.header, data[:n]...)
+			data = data[n:]
+			if len(s.header) < headerLength {
+				return nil
+			}
+
+			s.current = header{
+				length:     int(binary.LittleEndian.Uint32(s.header)),
+				requestID:  binary.LittleEndian.Uint32(s.header[4:]),
+				responseTo: binary.LittleEndian.Uint32(s.header[8:]),
+				opCode:     binary.LittleEndian.Uint32(s.header[12:]),
+			}
+			s.header = s.header[:0]
+			if s.current.length < headerLength || s.current.length > maxMessageLength {
+				return errMalformed
+			}
+			switch s.current.opCode {
+			case opReply, opQuery, opCompressed, opMsg:
+			default:
+				// the other operations were removed in MongoDB 5.1
+				return errMalformed
+			}
+			s.read = 0
+			s.body = s.body[:0]
+			s.inMessage = true
+		}
+
+		bodyLen := s.current.length - headerLength
+		n := bodyLen - s.read
+		if n > len(data) {
+			n = len(data)
+		}
+		if kept := maxMessageSize - len(s.body); kept > 0 {
+			if kept > n {
+				kept = n
+			}
+			s.body = append(s.body, data[:kept]...)
+		}
+		s.read += n
+		data = data[n:]
+		if s.read == bodyLen {
+			s.inMessage = false
+			handle(s.current, s.body)
+		}
+	}
+	return nil
+}
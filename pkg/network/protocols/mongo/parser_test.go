@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mongo
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func feedAll(p *Parser, segments []testutil.TCPSegment, chunkSize int) []*database.Transaction {
+	var txs []*database.Transaction
+	for _, s := range segments {
+		data := s.Payload
+		for len(data) > 0 {
+			n := chunkSize
+			if n <= 0 || n > len(data) {
+				n = len(data)
+			}
+			txs = append(txs, p.Feed(data[:n], s.FromClient, s.Timestamp)...)
+			data = data[n:]
+		}
+	}
+	return txs
+}
+
+func message(t *testing.T, requestID, responseTo uint32, doc bson.D) []byte {
+	b, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	msg := make([]byte, 21, 21+len(b))
+	binary.LittleEndian.PutUint32(msg, uint32(21+len(b)))
+	binary.LittleEndian.PutUint32(msg[4:], requestID)
+	binary.LittleEndian.PutUint32(msg[8:], responseTo)
+	binary.LittleEndian.PutUint32(msg[12:], opMsg)
+	return append(msg, b...)
+}
+
+func TestParserCapture(t *testing.T) {
+	// a legacy handshake, then find, insert failing with a write error,
+	// getMore, an unacknowledged insert pipelined with an unknown command
+	// and ping
+	segments := testutil.ReadTCPSegments(t, "testdata/mongo.pcap", 27017)
+	require.NotEmpty(t, segments)
+
+	type expected struct {
+		command  string
+		resource string
+		latency  time.Duration
+		error    bool
+	}
+	expectedTxs := []expected{
+		{"find", "app.users", 5 * time.Millisecond, false},
+		{"insert", "app.orders", 3 * time.Millisecond, true},
+		{"getMore", "app.users", time.Millisecond, false},
+		{"badCommand", "app", 2 * time.Millisecond, true},
+		{"ping", "admin", time.Millisecond, false},
+	}
+
+	// the segments are decoded the same way when split in chunks
+	for _, chunkSize := range []int{0, 1, 3, 7} {
+		p := NewParser(nil)
+		txs := feedAll(p, segments, chunkSize)
+		require.Len(t, txs, len(expectedTxs), "chunk size %d", chunkSize)
+		for i, e := range expectedTxs {
+			assert.Equal(t, e.command, txs[i].Command)
+			assert.Equal(t, e.resource, txs[i].Resource)
+			assert.Equal(t, float64(e.latency), txs[i].Latency(), e.command)
+			assert.Equal(t, e.error, txs[i].Error, e.command)
+		}
+		assert.Empty(t, p.pending)
+		assert.False(t, p.stopped)
+	}
+}
+
+func TestParserTruncatedCommand(t *testing.T) {
+	// the database is at the end of the command, beyond the bytes kept
+	p := NewParser(nil)
+	txs := p.Feed(message(t, 1, 0, bson.D{
+		{Key: "aggregate", Value: "events"},
+		{Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: bson.D{{Key: "payload", Value: strings.Repeat("x", 2*maxMessageSize)}}}}}},
+		{Key: "$db", Value: "app"},
+	}), true, 10)
+	assert.Empty(t, txs)
+	txs = p.Feed(message(t, 2, 1, bson.D{{Key: "ok", Value: false}}), false, 15)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "aggregate", txs[0].Command)
+	assert.Equal(t, "events", txs[0].Resource)
+	assert.True(t, txs[0].Error)
+}
+
+func TestParserMalformed(t *testing.T) {
+	telemetry := database.NewTelemetry("mongodb")
+	p := NewParser(telemetry)
+	msg := message(t, 1, 0, bson.D{{Key: "ping", Value: 1}})
+	// an unknown operation code
+	binary.LittleEndian.PutUint32(msg[12:], 42)
+	assert.Empty(t, p.Feed(msg, true, 1))
+	assert.True(t, p.stopped)
+	assert.Empty(t, p.Feed(message(t, 2, 0, bson.D{{Key: "ping", Value: 1}}), true, 2))
+}
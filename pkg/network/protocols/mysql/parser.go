@@ -0,0 +1,415 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+const (
+	// maxPacketSize is the number of bytes of a packet that are kept, longer
+	// queries are truncated
+	maxPacketSize = 8192
+	// maxPayloadLength is the length of the packets followed by a
+	// continuation packet
+	maxPayloadLength = 0xffffff
+	// maxStatements bounds the memory used by the parser of a connection
+	maxStatements = 1024
+
+	// protocolVersion10 is the first byte of the handshake of the server
+	protocolVersion10 = 10
+)
+
+// Command packets
+const (
+	comQuit             = 0x01
+	comQuery            = 0x03
+	comStmtPrepare      = 0x16
+	comStmtExecute      = 0x17
+	comStmtSendLongData = 0x18
+	comStmtClose        = 0x19
+	comResetConnection  = 0x1f
+)
+
+// Response packets
+const (
+	okPacket          = 0x00
+	localInfilePacket = 0xfb
+	eofPacket         = 0xfe
+	errPacket         = 0xff
+)
+
+// Capability flags
+const (
+	clientSSL             = 0x00000800
+	clientDeprecateEOF    = 0x01000000
+	clientQueryAttributes = 0x08000000
+
+	serverMoreResultsExists = 0x0008
+)
+
+type connectionPhase uint8
+
+const (
+	commandPhase connectionPhase = iota
+	handshakePhase
+)
+
+type responseState uint8
+
+const (
+	responseFirstPacket responseState = iota
+	responseColumns
+	responseColumnsEOF
+	responseRows
+	responseLocalInfile
+)
+
+type pendingCommand struct {
+	command byte
+	query   string
+	started uint64
+	// lastSeen is the timestamp of the last packet of the response
+	lastSeen uint64
+	state    responseState
+	columns  uint64
+}
+
+// Parser decodes the MySQL packets exchanged on a connection and matches the
+// queries and prepared statement executions with their responses. It can
+// start decoding in the middle of a connection.
+type Parser struct {
+	client stream
+	server stream
+
+	phase        connectionPhase
+	capabilities uint32
+	capsKnown    bool
+	statements   map[uint32]string
+	pending      *pendingCommand
+	// stopped is set when the connection is encrypted
+	stopped bool
+
+	completed []*database.Transaction
+}
+
+// NewParser returns a parser of a MySQL connection
+func NewParser() *Parser {
+	return &Parser{
+		statements: make(map[uint32]string),
+	}
+}
+
+// Feed decodes the given segment of the connection, sent by the client or
+// the server at the given timestamp in nanoseconds. It returns the
+// transactions completed by the segment.
+func (p *Parser) Feed(data []byte, fromClient bool, timestamp uint64) []*database.Transaction {
+	if p.stopped {
+		return nil
+	}
+	p.completed = nil
+	if fromClient {
+		p.client.feed(data, func(seq uint8, length int, body []byte) {
+			p.handleClientPacket(seq, length, body, timestamp)
+		})
+	} else {
+		p.server.feed(data, func(seq uint8, length int, body []byte) {
+			p.handleServerPacket(seq, length, body, timestamp)
+		})
+	}
+	return p.completed
+}
+
+func (p *Parser) handleClientPacket(seq uint8, length int, body []byte, timestamp uint64) {
+	if p.phase == handshakePhase {
+		if seq == 1 && len(body) >= 4 {
+			p.capabilities = binary.LittleEndian.Uint32(body)
+			p.capsKnown = true
+			// a short handshake response with the SSL flag asks to switch
+			// to TLS
+			if p.capabilities&clientSSL != 0 && length == 32 {
+				p.stopped = true
+			}
+		}
+		return
+	}
+
+	// the commands start a new sequence, the other packets are sent by the
+	// client during a command, like the content of a file for LOAD DATA
+	if seq != 0 || len(body) == 0 {
+		return
+	}
+
+	if p.pending != nil {
+		// the end of the previous response wasn't decoded
+		if p.pending.lastSeen != 0 {
+			p.complete(false)
+		}
+		p.pending = nil
+	}
+
+	command := body[0]
+	body = body[1:]
+	switch command {
+	case comQuery:
+		query, ok := p.readQuery(body)
+		if !ok {
+			query = ""
+		}
+		p.pending = &pendingCommand{command: command, query: query, started: timestamp}
+	case comStmtPrepare:
+		p.pending = &pendingCommand{command: command, query: string(body), started: timestamp}
+	case comStmtExecute:
+		var query string
+		if len(body) >= 4 {
+			query = p.statements[binary.LittleEndian.Uint32(body)]
+		}
+		p.pending = &pendingCommand{command: command, query: query, started: timestamp}
+	case comStmtClose:
+		if len(body) >= 4 {
+			delete(p.statements, binary.LittleEndian.Uint32(body))
+		}
+	case comQuit, comStmtSendLongData:
+		// no response
+	case comResetConnection:
+		p.statements = make(map[uint32]string)
+		p.pending = &pendingCommand{command: command, started: timestamp}
+	default:
+		p.pending = &pendingCommand{command: command, started: timestamp}
+	}
+}
+
+// readQuery returns the query of a COM_QUERY packet, skipping the query
+// attributes when they are enabled. Queries with attributes aren't supported.
+func (p *Parser) readQuery(body []byte) (string, bool) {
+	if p.capabilities&clientQueryAttributes == 0 {
+		return string(body), true
+	}
+	paramCount, n := readLengthEncodedInteger(body)
+	if n == 0 || paramCount != 0 {
+		return "", false
+	}
+	body = body[n:]
+	// parameter_set_count
+	if _, n = readLengthEncodedInteger(body); n == 0 {
+		return "", false
+	}
+	return string(body[n:]), true
+}
+
+func (p *Parser) handleServerPacket(seq uint8, length int, body []byte, timestamp uint64) {
+	if len(body) == 0 {
+		return
+	}
+
+	if seq == 0 && body[0] == protocolVersion10 {
+		// the initial handshake of the server
+		p.phase = handshakePhase
+		p.pending = nil
+		return
+	}
+	if p.phase == handshakePhase {
+		switch body[0] {
+		case okPacket:
+			p.phase = commandPhase
+		case errPacket:
+			p.stopped = true
+		}
+		return
+	}
+
+	cmd := p.pending
+	if cmd == nil {
+		return
+	}
+	cmd.lastSeen = timestamp
+
+	switch cmd.state {
+	case responseFirstPacket:
+		switch body[0] {
+		case okPacket:
+			if cmd.command == comStmtPrepare && len(body) >= 5 {
+				if len(p.statements) < maxStatements {
+					p.statements[binary.LittleEndian.Uint32(body[1:])] = cmd.query
+				}
+			}
+			if okStatus(body)&serverMoreResultsExists == 0 || cmd.command == comStmtPrepare {
+				p.complete(false)
+			}
+		case errPacket:
+			p.complete(true)
+		case eofPacket:
+			p.complete(false)
+		case localInfilePacket:
+			cmd.state = responseLocalInfile
+		default:
+			columns, n := readLengthEncodedInteger(body)
+			if n == 0 || columns == 0 {
+				p.complete(false)
+				return
+			}
+			cmd.columns = columns
+			cmd.state = responseColumns
+		}
+	case responseColumns:
+		cmd.columns--
+		if cmd.columns == 0 {
+			if p.capsKnown && p.capabilities&clientDeprecateEOF != 0 {
+				cmd.state = responseRows
+			} else {
+				cmd.state = responseColumnsEOF
+			}
+		}
+	case responseColumnsEOF:
+		cmd.state = responseRows
+	case responseRows:
+		switch {
+		case body[0] == errPacket:
+			p.complete(true)
+		case body[0] == eofPacket && length < maxPayloadLength:
+			// the rows can start with 0xfe only for values longer than the
+			// maximum payload length
+			var status uint16
+			if length < 9 && len(body) >= 5 {
+				status = binary.LittleEndian.Uint16(body[3:])
+			} else {
+				status = okStatus(body)
+			}
+			if status&serverMoreResultsExists != 0 {
+				cmd.state = responseFirstPacket
+			} else {
+				p.complete(false)
+			}
+		}
+	case responseLocalInfile:
+		switch body[0] {
+		case okPacket:
+			p.complete(false)
+		case errPacket:
+			p.complete(true)
+		}
+	}
+}
+
+// complete reports the pending command as completed
+func (p *Parser) complete(isError bool) {
+	cmd := p.pending
+	p.pending = nil
+	if cmd.command != comQuery && cmd.command != comStmtExecute {
+		return
+	}
+	p.completed = append(p.completed, &database.Transaction{
+		Query:            cmd.query,
+		RequestStarted:   cmd.started,
+		ResponseLastSeen: cmd.lastSeen,
+		Error:            isError,
+	})
+}
+
+// okStatus returns the status flags of an OK packet
+func okStatus(body []byte) uint16 {
+	body = body[1:]
+	for i := 0; i < 2; i++ {
+		// affected rows and last insert id
+		_, n := readLengthEncodedInteger(body)
+		if n == 0 {
+			return 0
+		}
+		body = body[n:]
+	}
+	if len(body) < 2 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(body)
+}
+
+// readLengthEncodedInteger returns a length-encoded integer and its size, which
+// is zero when the buffer is too short
+func readLengthEncodedInteger(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	switch b[0] {
+	case 0xfc:
+		if len(b) < 3 {
+			return 0, 0
+		}
+		return uint64(binary.LittleEndian.Uint16(b[1:])), 3
+	case 0xfd:
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return uint64(b[1]) | uint64(b[2])<<8 | uint64(b[3])<<16, 4
+	case 0xfe:
+		if len(b) < 9 {
+			return 0, 0
+		}
+		return binary.LittleEndian.Uint64(b[1:]), 9
+	case 0xfb, 0xff:
+		return 0, 0
+	default:
+		return uint64(b[0]), 1
+	}
+}
+
+// stream reassembles the packets sent in one direction of a connection. A
+// packet is made of a 3-bytes length, a sequence number and a payload.
+type stream struct {
+	header   []byte
+	body     []byte
+	seq      uint8
+	bodyLen  int
+	read     int
+	inPacket bool
+	// continuation is set when the current packet continues the payload of
+	// the previous one, which isn't decoded
+	continuation bool
+}
+
+func (s *stream) feed(data []byte, handle func(seq uint8, length int, body []byte)) {
+	for len(data) > 0 {
+		if !s.inPacket {
+			n := 4 - len(s.header)
+			if n > len(data) {
+				n = len(data)
+			}
+			s.header = append(s.header, data[:n]...)
+			data = data[n:]
+			if len(s.header) < 4 {
+				return
+			}
+
+			s.bodyLen = int(s.header[0]) | int(s.header[1])<<8 | int(s.header[2])<<16
+			s.seq = s.header[3]
+			s.header = s.header[:0]
+			s.read = 0
+			s.body = s.body[:0]
+			s.inPacket = true
+		}
+
+		n := s.bodyLen - s.read
+		if n > len(data) {
+			n = len(data)
+		}
+		if kept := maxPacketSize - len(s.body); kept > 0 {
+			if kept > n {
+				kept = n
+			}
+			s.body = append(s.body, data[:kept]...)
+		}
+		s.read += n
+		data = data[n:]
+		if s.read == s.bodyLen {
+			s.inPacket = false
+			if !s.continuation {
+				handle(s.seq, s.bodyLen, s.body)
+			}
+			s.continuation = s.bodyLen == maxPayloadLength
+		}
+	}
+}
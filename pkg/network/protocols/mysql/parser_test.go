@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+func packet(seq uint8, fields ...[]byte) []byte {
+	var body []byte
+	for _, f := range fields {
+		body = append(body, f...)
+	}
+	header := []byte{byte(len(body)), byte(len(body) >> 8), byte(len(body) >> 16), seq}
+	return append(header, body...)
+}
+
+func uint32Field(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func concat(packets ...[]byte) []byte {
+	var b []byte
+	for _, p := range packets {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func okResponse(seq uint8, status uint16) []byte {
+	return packet(seq, []byte{okPacket, 1, 0, byte(status), byte(status >> 8), 0, 0})
+}
+
+func eofResponse(seq uint8, status uint16) []byte {
+	return packet(seq, []byte{eofPacket, 0, 0, byte(status), byte(status >> 8)})
+}
+
+func errResponse(seq uint8) []byte {
+	return packet(seq, []byte{errPacket, 0x7a, 0x04, '#'}, []byte("42S02Table 'testdb.missing' doesn't exist"))
+}
+
+func columnDef(seq uint8, name string) []byte {
+	return packet(seq, []byte{3}, []byte("def"), []byte{byte(len(name))}, []byte(name), make([]byte, 13))
+}
+
+// resultSet returns the response of a query returning a column and two rows
+func resultSet(deprecateEOF bool) []byte {
+	packets := [][]byte{packet(1, []byte{1}), columnDef(2, "id")}
+	seq := uint8(3)
+	if !deprecateEOF {
+		packets = append(packets, eofResponse(seq, 0))
+		seq++
+	}
+	packets = append(packets, packet(seq, []byte{1, '1'}), packet(seq+1, []byte{1, '2'}))
+	if deprecateEOF {
+		packets = append(packets, packet(seq+2, []byte{eofPacket, 0, 0, 2, 0, 0, 0}))
+	} else {
+		packets = append(packets, eofResponse(seq+2, 0))
+	}
+	return concat(packets...)
+}
+
+func handshake(capabilities uint32) []segment {
+	return []segment{
+		{data: packet(0, []byte{protocolVersion10}, []byte("8.0.32\x00"), make([]byte, 40)), timestamp: 1},
+		{data: packet(1, uint32Field(capabilities), uint32Field(1<<24), []byte{255}, make([]byte, 23), []byte("root\x00")), fromClient: true, timestamp: 2},
+		{data: okResponse(2, 2), timestamp: 3},
+	}
+}
+
+type segment struct {
+	data       []byte
+	fromClient bool
+	timestamp  uint64
+}
+
+func feedAll(p *Parser, segments []segment, chunkSize int) []*database.Transaction {
+	var txs []*database.Transaction
+	for _, s := range segments {
+		data := s.data
+		for len(data) > 0 {
+			n := chunkSize
+			if n <= 0 || n > len(data) {
+				n = len(data)
+			}
+			txs = append(txs, p.Feed(data[:n], s.fromClient, s.timestamp)...)
+			data = data[n:]
+		}
+	}
+	return txs
+}
+
+func TestParserQueries(t *testing.T) {
+	for _, deprecateEOF := range []bool{false, true} {
+		var capabilities uint32 = 0x000fa20f
+		if deprecateEOF {
+			capabilities |= clientDeprecateEOF
+		}
+		segments := append(handshake(capabilities),
+			segment{data: packet(0, []byte{comQuery}, []byte("SELECT id FROM dummy WHERE foo = 'bar'")), fromClient: true, timestamp: 100},
+			segment{data: resultSet(deprecateEOF), timestamp: 150},
+			segment{data: packet(0, []byte{comQuery}, []byte("INSERT INTO dummy (foo) VALUES ('baz')")), fromClient: true, timestamp: 200},
+			segment{data: okResponse(1, 2), timestamp: 220},
+			segment{data: packet(0, []byte{comQuery}, []byte("SELECT * FROM missing")), fromClient: true, timestamp: 300},
+			segment{data: errResponse(1), timestamp: 400},
+			segment{data: packet(0, []byte{0x0e}), fromClient: true, timestamp: 500},
+			segment{data: okResponse(1, 2), timestamp: 501},
+			segment{data: packet(0, []byte{comQuit}), fromClient: true, timestamp: 600},
+		)
+
+		for _, chunkSize := range []int{0, 1, 3} {
+			txs := feedAll(NewParser(), segments, chunkSize)
+			require.Len(t, txs, 3)
+			assert.Equal(t, "SELECT id FROM dummy WHERE foo = 'bar'", txs[0].Query)
+			assert.Equal(t, float64(50), txs[0].Latency())
+			assert.False(t, txs[0].Error)
+			assert.Equal(t, "INSERT INTO dummy (foo) VALUES ('baz')", txs[1].Query)
+			assert.Equal(t, float64(20), txs[1].Latency())
+			assert.Equal(t, "SELECT * FROM missing", txs[2].Query)
+			assert.Equal(t, float64(100), txs[2].Latency())
+			assert.True(t, txs[2].Error)
+		}
+	}
+}
+
+func TestParserPreparedStatements(t *testing.T) {
+	segments := append(handshake(0x000fa20f|clientDeprecateEOF),
+		segment{data: packet(0, []byte{comStmtPrepare}, []byte("SELECT foo FROM dummy WHERE id = ?")), fromClient: true, timestamp: 100},
+		segment{data: concat(
+			packet(1, []byte{okPacket}, uint32Field(7), []byte{1, 0, 1, 0, 0, 0, 0}),
+			columnDef(2, "?"),
+			columnDef(3, "foo"),
+		), timestamp: 110},
+		segment{data: packet(0, []byte{comStmtExecute}, uint32Field(7), []byte{0}, uint32Field(1), []byte{0, 1, 8, 0}, make([]byte, 8)), fromClient: true, timestamp: 200},
+		segment{data: concat(
+			packet(1, []byte{1}),
+			columnDef(2, "foo"),
+			packet(3, []byte{0, 0, 3}, []byte("bar")),
+			packet(4, []byte{eofPacket, 0, 0, 2, 0, 0, 0}),
+		), timestamp: 230},
+		segment{data: packet(0, []byte{comStmtClose}, uint32Field(7)), fromClient: true, timestamp: 300},
+		// the statement was closed
+		segment{data: packet(0, []byte{comStmtExecute}, uint32Field(7), []byte{0}, uint32Field(1)), fromClient: true, timestamp: 400},
+		segment{data: errResponse(1), timestamp: 410},
+	)
+
+	p := NewParser()
+	txs := feedAll(p, segments, 0)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "SELECT foo FROM dummy WHERE id = ?", txs[0].Query)
+	assert.Equal(t, float64(30), txs[0].Latency())
+	assert.False(t, txs[0].Error)
+	assert.Equal(t, "", txs[1].Query)
+	assert.True(t, txs[1].Error)
+	assert.Empty(t, p.statements)
+}
+
+func TestParserMultipleResults(t *testing.T) {
+	segments := append(handshake(0x000fa20f),
+		segment{data: packet(0, []byte{comQuery}, []byte("CALL get_dummies()")), fromClient: true, timestamp: 100},
+		segment{data: concat(
+			packet(1, []byte{1}),
+			columnDef(2, "id"),
+			eofResponse(3, 0),
+			packet(4, []byte{1, '1'}),
+			eofResponse(5, serverMoreResultsExists),
+		), timestamp: 110},
+		segment{data: okResponse(6, 2), timestamp: 120},
+	)
+
+	txs := feedAll(NewParser(), segments, 0)
+	require.Len(t, txs, 1)
+	assert.Equal(t, float64(20), txs[0].Latency())
+}
+
+func TestParserMidConnection(t *testing.T) {
+	// the capabilities are unknown, and the response isn't fully decoded as
+	// the end of the columns is ambiguous: the transaction is completed by
+	// the next command
+	txs := feedAll(NewParser(), []segment{
+		{data: packet(0, []byte{comQuery}, []byte("SELECT id FROM dummy")), fromClient: true, timestamp: 100},
+		{data: concat(packet(1, []byte{1}), columnDef(2, "id"), packet(3, []byte{eofPacket, 0, 0, 2, 0, 0, 0})), timestamp: 120},
+		{data: packet(0, []byte{comQuery}, []byte("SELECT 1")), fromClient: true, timestamp: 200},
+		{data: okResponse(1, 2), timestamp: 210},
+	}, 0)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "SELECT id FROM dummy", txs[0].Query)
+	assert.Equal(t, float64(20), txs[0].Latency())
+	assert.Equal(t, "SELECT 1", txs[1].Query)
+}
+
+func TestParserQueryAttributes(t *testing.T) {
+	segments := append(handshake(0x000fa20f|clientQueryAttributes),
+		segment{data: packet(0, []byte{comQuery, 0, 1}, []byte("SELECT 1")), fromClient: true, timestamp: 100},
+		segment{data: okResponse(1, 2), timestamp: 110},
+	)
+	txs := feedAll(NewParser(), segments, 0)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "SELECT 1", txs[0].Query)
+}
+
+func TestParserEncrypted(t *testing.T) {
+	p := NewParser()
+	txs := feedAll(p, []segment{
+		{data: packet(0, []byte{protocolVersion10}, []byte("8.0.32\x00"), make([]byte, 40)), timestamp: 1},
+		{data: packet(1, uint32Field(0x000fa20f|clientSSL), uint32Field(1<<24), []byte{255}, make([]byte, 23)), fromClient: true, timestamp: 2},
+		{data: []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}, fromClient: true, timestamp: 3},
+	}, 0)
+	assert.Empty(t, txs)
+	assert.True(t, p.stopped)
+}
@@ -0,0 +1,336 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+const (
+	// maxMessageSize is the number of bytes of a message that are kept,
+	// longer queries are truncated
+	maxMessageSize = 8192
+	// maxMessageLength is the length above which a message is considered
+	// malformed, as PostgreSQL limits messages to 1GB
+	maxMessageLength = 1 << 30
+	// maxPendingRequests and maxStatements bound the memory used by the
+	// parser of a connection
+	maxPendingRequests = 128
+	maxStatements      = 1024
+
+	// codes of the untyped messages sent by the client before the startup
+	sslRequestCode    = 80877103
+	gssencRequestCode = 80877104
+)
+
+// Frontend (client) message types
+const (
+	queryMessage     = 'Q'
+	parseMessage     = 'P'
+	bindMessage      = 'B'
+	executeMessage   = 'E'
+	syncMessage      = 'S'
+	closeMessage     = 'C'
+	untypedMessage   = 0
+	closeStatement   = 'S'
+	closePortal      = 'P'
+	encryptedConnect = 'S'
+	gssencConnect    = 'G'
+)
+
+// Backend (server) message types
+const (
+	commandCompleteMessage    = 'C'
+	emptyQueryResponseMessage = 'I'
+	portalSuspendedMessage    = 's'
+	errorResponseMessage      = 'E'
+	readyForQueryMessage      = 'Z'
+)
+
+type pendingRequest struct {
+	query   string
+	started uint64
+	// simple is set for the requests of the simple query protocol, which
+	// are complete once the server is ready for the next query
+	simple bool
+	// sync marks the end of a batch of requests of the extended query protocol
+	sync  bool
+	error bool
+}
+
+// Parser decodes the PostgreSQL messages exchanged on a connection and
+// matches the queries with their responses. It supports both the simple and
+// extended query protocols, along with pipelining, and can start decoding in
+// the middle of a connection.
+type Parser struct {
+	client stream
+	server stream
+
+	pending    []*pendingRequest
+	statements map[string]string
+	portals    map[string]string
+
+	// sslRequested is set while waiting for the single byte response of the
+	// server to a SSL or GSSAPI encryption request
+	sslRequested bool
+	// stopped is set when the connection is encrypted or the messages can't
+	// be decoded anymore
+	stopped bool
+
+	telemetry *database.Telemetry
+	completed []*database.Transaction
+}
+
+// NewParser returns a parser of a PostgreSQL connection
+func NewParser(telemetry *database.Telemetry) *Parser {
+	p := &Parser{
+		statements: make(map[string]string),
+		portals:    make(map[string]string),
+		telemetry:  telemetry,
+	}
+	p.client.keep = func(typ byte) bool {
+		switch typ {
+		case queryMessage, parseMessage, bindMessage, executeMessage, closeMessage, untypedMessage:
+			return true
+		}
+		return false
+	}
+	p.server.keep = func(typ byte) bool { return false }
+	return p
+}
+
+// Feed decodes the given segment of the connection, sent by the client or
+// the server at the given timestamp in nanoseconds. It returns the
+// transactions completed by the segment.
+func (p *Parser) Feed(data []byte, fromClient bool, timestamp uint64) []*database.Transaction {
+	if p.stopped {
+		return nil
+	}
+	p.completed = nil
+
+	var err error
+	if fromClient {
+		err = p.client.feed(data, true, func(typ byte, body []byte) {
+			p.handleClientMessage(typ, body, timestamp)
+		})
+	} else {
+		if p.sslRequested && len(data) > 0 && p.server.atBoundary() {
+			p.sslRequested = false
+			if data[0] == encryptedConnect || data[0] == gssencConnect {
+				p.stopped = true
+				return nil
+			}
+			data = data[1:]
+		}
+		err = p.server.feed(data, false, func(typ byte, _ []byte) {
+			p.handleServerMessage(typ, timestamp)
+		})
+	}
+	if err != nil {
+		p.stopped = true
+		if p.telemetry != nil {
+			p.telemetry.Malformed()
+		}
+	}
+	return p.completed
+}
+
+func (p *Parser) handleClientMessage(typ byte, body []byte, timestamp uint64) {
+	switch typ {
+	case untypedMessage:
+		if len(body) >= 4 {
+			switch binary.BigEndian.Uint32(body) {
+			case sslRequestCode, gssencRequestCode:
+				p.sslRequested = true
+			}
+		}
+	case queryMessage:
+		p.push(&pendingRequest{query: readString(&body), started: timestamp, simple: true})
+	case parseMessage:
+		name := readString(&body)
+		query := readString(&body)
+		if _, ok := p.statements[name]; ok || len(p.statements) < maxStatements {
+			p.statements[name] = query
+		}
+	case bindMessage:
+		portal := readString(&body)
+		statement := readString(&body)
+		if _, ok := p.portals[portal]; ok || len(p.portals) < maxStatements {
+			p.portals[portal] = p.statements[statement]
+		}
+	case executeMessage:
+		p.push(&pendingRequest{query: p.portals[readString(&body)], started: timestamp})
+	case syncMessage:
+		p.push(&pendingRequest{sync: true})
+	case closeMessage:
+		if len(body) == 0 {
+			return
+		}
+		kind := body[0]
+		body = body[1:]
+		switch kind {
+		case closeStatement:
+			delete(p.statements, readString(&body))
+		case closePortal:
+			delete(p.portals, readString(&body))
+		}
+	}
+}
+
+func (p *Parser) handleServerMessage(typ byte, timestamp uint64) {
+	switch typ {
+	case commandCompleteMessage, emptyQueryResponseMessage, portalSuspendedMessage:
+		// the simple queries are complete once the server is ready for
+		// the next one, as they can be made of several statements
+		if req := p.head(); req != nil && !req.simple && !req.sync {
+			p.complete(req, timestamp)
+		}
+	case errorResponseMessage:
+		req := p.head()
+		if req == nil || req.sync {
+			// an error outside of a query, like an authentication error
+			return
+		}
+		req.error = true
+		if !req.simple {
+			// the server then skips the requests until the next sync
+			p.complete(req, timestamp)
+		}
+	case readyForQueryMessage:
+		for len(p.pending) > 0 {
+			req := p.pending[0]
+			if req.simple {
+				p.complete(req, timestamp)
+				return
+			}
+			// the executions of the batch not yet completed were skipped
+			// because of an error
+			p.pending = p.pending[1:]
+			if req.sync {
+				return
+			}
+		}
+	}
+}
+
+func (p *Parser) push(req *pendingRequest) {
+	if len(p.pending) >= maxPendingRequests {
+		return
+	}
+	p.pending = append(p.pending, req)
+}
+
+func (p *Parser) head() *pendingRequest {
+	if len(p.pending) == 0 {
+		return nil
+	}
+	return p.pending[0]
+}
+
+// complete reports the request at the head of the queue as completed
+func (p *Parser) complete(req *pendingRequest, timestamp uint64) {
+	p.pending = p.pending[1:]
+	p.completed = append(p.completed, &database.Transaction{
+		Query:            req.query,
+		RequestStarted:   req.started,
+		ResponseLastSeen: timestamp,
+		Error:            req.error,
+	})
+}
+
+// readString reads a null terminated string, the whole buffer being read when
+// it was truncated
+func readString(b *[]byte) string {
+	buf := *b
+	i := bytes.IndexByte(buf, 0)
+	if i < 0 {
+		*b = nil
+		return string(buf)
+	}
+	*b = buf[i+1:]
+	return string(buf[:i])
+}
+
+// stream reassembles the messages sent in one direction of a connection. A
+// message is made of a type byte and a 4-bytes length, except for the
+// untyped messages sent by the client before the startup.
+type stream struct {
+	header    []byte
+	body      []byte
+	typ       byte
+	bodyLen   int
+	read      int
+	inMessage bool
+	keep      func(typ byte) bool
+}
+
+type malformedMessageError struct{}
+
+func (malformedMessageError) Error() string { return "malformed postgres message" }
+
+func (s *stream) atBoundary() bool {
+	return !s.inMessage && len(s.header) == 0
+}
+
+func (s *stream) feed(data []byte, fromClient bool, handle func(typ byte, body []byte)) error {
+	for len(data) > 0 {
+		if !s.inMessage {
+			headerLen := 5
+			// the untyped messages start with their length, which begins with
+			// a null byte given their size, while no message type is null
+			if fromClient && (len(s.header) > 0 && s.header[0] == untypedMessage || len(s.header) == 0 && data[0] == untypedMessage) {
+				headerLen = 4
+			}
+			n := headerLen - len(s.header)
+			if n > len(data) {
+				n = len(data)
+			}
+			s.header = append(s.header, data[:n]...)
+			data = data[n:]
+			if len(s.header) < headerLen {
+				return nil
+			}
+
+			var length uint32
+			if headerLen == 4 {
+				s.typ = untypedMessage
+				length = binary.BigEndian.Uint32(s.header)
+			} else {
+				s.typ = s.header[0]
+				length = binary.BigEndian.Uint32(s.header[1:])
+			}
+			s.header = s.header[:0]
+			if length < 4 || length > maxMessageLength {
+				return malformedMessageError{}
+			}
+			s.bodyLen = int(length) - 4
+			s.read = 0
+			s.body = s.body[:0]
+			s.inMessage = true
+		}
+
+		n := s.bodyLen - s.read
+		if n > len(data) {
+			n = len(data)
+		}
+		if kept := maxMessageSize - len(s.body); kept > 0 && s.keep(s.typ) {
+			if kept > n {
+				kept = n
+			}
+			s.body = append(s.body, data[:kept]...)
+		}
+		s.read += n
+		data = data[n:]
+		if s.read == s.bodyLen {
+			s.inMessage = false
+			handle(s.typ, s.body)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+func message(typ byte, fields ...[]byte) []byte {
+	var body []byte
+	for _, f := range fields {
+		body = append(body, f...)
+	}
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(body)+4))
+	return append(msg, body...)
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+func int32Field(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func untyped(code uint32, fields ...[]byte) []byte {
+	body := int32Field(code)
+	for _, f := range fields {
+		body = append(body, f...)
+	}
+	return append(int32Field(uint32(len(body)+4)), body...)
+}
+
+func concat(msgs ...[]byte) []byte {
+	var b []byte
+	for _, m := range msgs {
+		b = append(b, m...)
+	}
+	return b
+}
+
+var (
+	startup       = untyped(196608, cstring("user"), cstring("admin"), cstring("database"), cstring("testdb"), []byte{0})
+	authOK        = message('R', int32Field(0))
+	readyForQuery = message('Z', []byte{'I'})
+	rowDesc       = message('T', []byte{0, 1}, cstring("id"), make([]byte, 18))
+	dataRow       = message('D', []byte{0, 1}, int32Field(1), []byte("1"))
+)
+
+func commandComplete(tag string) []byte {
+	return message('C', cstring(tag))
+}
+
+func errorResponse(code string) []byte {
+	return message('E', []byte{'S'}, cstring("ERROR"), []byte{'C'}, cstring(code), []byte{0})
+}
+
+type segment struct {
+	data       []byte
+	fromClient bool
+	timestamp  uint64
+}
+
+func feedAll(p *Parser, segments []segment, chunkSize int) []*database.Transaction {
+	var txs []*database.Transaction
+	for _, s := range segments {
+		data := s.data
+		for len(data) > 0 {
+			n := chunkSize
+			if n <= 0 || n > len(data) {
+				n = len(data)
+			}
+			txs = append(txs, p.Feed(data[:n], s.fromClient, s.timestamp)...)
+			data = data[n:]
+		}
+	}
+	return txs
+}
+
+func TestParserSimpleQuery(t *testing.T) {
+	segments := []segment{
+		{data: startup, fromClient: true, timestamp: 1},
+		{data: concat(authOK, message('S', cstring("server_version"), cstring("15.2")), readyForQuery), timestamp: 2},
+		{data: message('Q', cstring("SELECT id FROM dummy WHERE foo = 'bar'")), fromClient: true, timestamp: 100},
+		{data: concat(rowDesc, dataRow, dataRow, commandComplete("SELECT 2"), readyForQuery), timestamp: 150},
+		{data: message('Q', cstring("UPDATE dummy SET foo = 'baz'; SELECT * FROM missing")), fromClient: true, timestamp: 200},
+		{data: concat(commandComplete("UPDATE 1"), errorResponse("42P01"), readyForQuery), timestamp: 300},
+		{data: message('X'), fromClient: true, timestamp: 400},
+	}
+
+	// the segments are decoded the same way when split in chunks
+	for _, chunkSize := range []int{0, 1, 3, 7} {
+		txs := feedAll(NewParser(nil), segments, chunkSize)
+		require.Len(t, txs, 2)
+		assert.Equal(t, "SELECT id FROM dummy WHERE foo = 'bar'", txs[0].Query)
+		assert.Equal(t, float64(50), txs[0].Latency())
+		assert.False(t, txs[0].Error)
+		assert.Equal(t, "UPDATE dummy SET foo = 'baz'; SELECT * FROM missing", txs[1].Query)
+		assert.Equal(t, float64(100), txs[1].Latency())
+		assert.True(t, txs[1].Error)
+	}
+}
+
+func TestParserExtendedQuery(t *testing.T) {
+	segments := []segment{
+		// a pipeline of two executions of the same statement, plus a
+		// failing one in another batch
+		{data: concat(
+			message('P', cstring("stmt1"), cstring("SELECT foo FROM dummy WHERE id = $1"), []byte{0, 0}),
+			message('B', cstring(""), cstring("stmt1"), []byte{0, 0, 0, 1}, int32Field(1), []byte("1"), []byte{0, 0}),
+			message('D', []byte{'P'}, cstring("")),
+			message('E', cstring(""), int32Field(0)),
+			message('B', cstring("portal"), cstring("stmt1"), []byte{0, 0, 0, 1}, int32Field(1), []byte("2"), []byte{0, 0}),
+			message('E', cstring("portal"), int32Field(0)),
+			message('S'),
+		), fromClient: true, timestamp: 1000},
+		{data: concat(message('1'), message('2'), rowDesc, dataRow, commandComplete("SELECT 1")), timestamp: 1010},
+		{data: concat(message('2'), commandComplete("SELECT 0"), readyForQuery), timestamp: 1030},
+		{data: concat(
+			message('P', cstring(""), cstring("INSERT INTO dummy (foo) VALUES ($1)"), []byte{0, 0}),
+			message('B', cstring(""), cstring(""), []byte{0, 0, 0, 1}, int32Field(3), []byte("bar"), []byte{0, 0}),
+			message('E', cstring(""), int32Field(0)),
+			message('B', cstring(""), cstring("stmt1"), []byte{0, 0, 0, 1}, int32Field(1), []byte("3"), []byte{0, 0}),
+			message('E', cstring(""), int32Field(0)),
+			message('S'),
+		), fromClient: true, timestamp: 2000},
+		// the second execution is skipped after the error
+		{data: concat(message('1'), message('2'), errorResponse("23505"), readyForQuery), timestamp: 2100},
+		{data: concat(message('C', []byte{'S'}, cstring("stmt1")), message('S')), fromClient: true, timestamp: 3000},
+		{data: concat(message('3'), readyForQuery), timestamp: 3001},
+	}
+
+	for _, chunkSize := range []int{0, 1, 5} {
+		p := NewParser(nil)
+		txs := feedAll(p, segments, chunkSize)
+		require.Len(t, txs, 3)
+		assert.Equal(t, "SELECT foo FROM dummy WHERE id = $1", txs[0].Query)
+		assert.Equal(t, float64(10), txs[0].Latency())
+		assert.Equal(t, "SELECT foo FROM dummy WHERE id = $1", txs[1].Query)
+		assert.Equal(t, float64(30), txs[1].Latency())
+		assert.Equal(t, "INSERT INTO dummy (foo) VALUES ($1)", txs[2].Query)
+		assert.Equal(t, float64(100), txs[2].Latency())
+		assert.True(t, txs[2].Error)
+		assert.NotContains(t, p.statements, "stmt1")
+		assert.Empty(t, p.pending)
+	}
+}
+
+func TestParserMidConnection(t *testing.T) {
+	// the statement was prepared before the connection was captured
+	txs := feedAll(NewParser(nil), []segment{
+		{data: concat(message('B', cstring(""), cstring("stmt1"), []byte{0, 0, 0, 0, 0, 0}), message('E', cstring(""), int32Field(0)), message('S')), fromClient: true, timestamp: 10},
+		{data: concat(message('2'), commandComplete("DELETE 3"), readyForQuery), timestamp: 15},
+		{data: message('Q', cstring("SELECT 1")), fromClient: true, timestamp: 20},
+		{data: concat(rowDesc, dataRow, commandComplete("SELECT 1"), readyForQuery), timestamp: 22},
+	}, 0)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "", txs[0].Query)
+	assert.Equal(t, float64(5), txs[0].Latency())
+	assert.Equal(t, "SELECT 1", txs[1].Query)
+}
+
+func TestParserEncrypted(t *testing.T) {
+	p := NewParser(nil)
+	txs := feedAll(p, []segment{
+		{data: untyped(sslRequestCode), fromClient: true, timestamp: 1},
+		{data: []byte{'S'}, timestamp: 2},
+		{data: []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}, fromClient: true, timestamp: 3},
+	}, 0)
+	assert.Empty(t, txs)
+	assert.True(t, p.stopped)
+
+	// the server may refuse the encryption
+	txs = feedAll(NewParser(nil), []segment{
+		{data: untyped(sslRequestCode), fromClient: true, timestamp: 1},
+		{data: concat([]byte{'N'}, authOK, readyForQuery), timestamp: 2},
+		{data: concat(startup, message('Q', cstring("SELECT 1"))), fromClient: true, timestamp: 3},
+		{data: concat(rowDesc, dataRow, commandComplete("SELECT 1"), readyForQuery), timestamp: 4},
+	}, 0)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "SELECT 1", txs[0].Query)
+}
+
+func TestParserMalformed(t *testing.T) {
+	telemetry := database.NewTelemetry("postgres")
+	p := NewParser(telemetry)
+	txs := p.Feed([]byte{'Q', 0, 0, 0, 1, 'x'}, true, 1)
+	assert.Empty(t, txs)
+	assert.True(t, p.stopped)
+	assert.Empty(t, p.Feed(message('Q', cstring("SELECT 1")), true, 2))
+}
+
+func TestParserTruncatedQuery(t *testing.T) {
+	query := "SELECT * FROM dummy WHERE foo IN ("
+	for len(query) < 2*maxMessageSize {
+		query += "'bar', "
+	}
+	query += "'baz')"
+
+	txs := feedAll(NewParser(nil), []segment{
+		{data: message('Q', cstring(query)), fromClient: true, timestamp: 1},
+		{data: concat(commandComplete("SELECT 0"), readyForQuery), timestamp: 2},
+	}, 1000)
+	require.Len(t, txs, 1)
+	assert.Equal(t, query[:maxMessageSize], txs[0].Query)
+}
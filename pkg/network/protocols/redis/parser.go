@@ -0,0 +1,358 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+)
+
+const (
+	// maxLineLength bounds the length of the header lines of the values and
+	// of the inline commands
+	maxLineLength = 64 * 1024
+	// maxBulkLength is the length above which a bulk string is considered
+	// malformed, as Redis limits them to 512MB
+	maxBulkLength = 512 * 1024 * 1024
+	// maxArgumentSize is the number of bytes of the command name and key
+	// that are kept
+	maxArgumentSize = 256
+	// maxDepth bounds the nesting of the aggregate values
+	maxDepth = 32
+	// maxPendingCommands bounds the memory used by the parser of a connection
+	maxPendingCommands = 128
+)
+
+// RESP2 and RESP3 types
+const (
+	simpleStringType   = '+'
+	simpleErrorType    = '-'
+	integerType        = ':'
+	bulkStringType     = '$'
+	arrayType          = '*'
+	nullType           = '_'
+	booleanType        = '#'
+	doubleType         = ','
+	bigNumberType      = '('
+	bulkErrorType      = '!'
+	verbatimStringType = '='
+	mapType            = '%'
+	setType            = '~'
+	attributeType      = '|'
+	pushType           = '>'
+)
+
+var errMalformed = errors.New("malformed redis value")
+
+// keylessCommands are the commands whose first argument isn't a key
+var keylessCommands = map[string]struct{}{
+	"ACL": {}, "AUTH": {}, "BGREWRITEAOF": {}, "BGSAVE": {}, "CLIENT": {}, "CLUSTER": {},
+	"COMMAND": {}, "CONFIG": {}, "DBSIZE": {}, "DEBUG": {}, "DISCARD": {}, "ECHO": {},
+	"EVAL": {}, "EVALSHA": {}, "EVAL_RO": {}, "EVALSHA_RO": {}, "EXEC": {}, "FCALL": {},
+	"FCALL_RO": {}, "FLUSHALL": {}, "FLUSHDB": {}, "FUNCTION": {}, "HELLO": {}, "INFO": {},
+	"KEYS": {}, "LASTSAVE": {}, "LATENCY": {}, "MEMORY": {}, "MODULE": {}, "MULTI": {},
+	"OBJECT": {}, "PING": {}, "PUBLISH": {}, "PUBSUB": {}, "QUIT": {}, "RANDOMKEY": {},
+	"READONLY": {}, "READWRITE": {}, "RESET": {}, "ROLE": {}, "SAVE": {}, "SCAN": {},
+	"SCRIPT": {}, "SELECT": {}, "SHUTDOWN": {}, "SLOWLOG": {}, "SPUBLISH": {}, "SWAPDB": {},
+	"TIME": {}, "UNWATCH": {}, "WAIT": {}, "WAITAOF": {},
+}
+
+type pendingCommand struct {
+	command  string
+	resource string
+	started  uint64
+}
+
+// Parser decodes the RESP2 and RESP3 values exchanged on a Redis connection
+// and matches the commands with their replies. It supports pipelining, along
+// with the push values of RESP3. The connections switching to the RESP2
+// publish/subscribe mode, where messages can't be told apart from replies,
+// aren't decoded anymore.
+type Parser struct {
+	client stream
+	server stream
+
+	pending []*pendingCommand
+	// stopped is set when the values can't be decoded or matched anymore
+	stopped bool
+
+	telemetry *database.Telemetry
+	completed []*database.Transaction
+}
+
+// NewParser returns a parser of a Redis connection
+func NewParser(telemetry *database.Telemetry) *Parser {
+	return &Parser{
+		// the command name and the key
+		client:    stream{capturedArguments: 2, inlineCommands: true},
+		telemetry: telemetry,
+	}
+}
+
+// Feed decodes the given segment of the connection, sent by the client or
+// the server at the given timestamp in nanoseconds. It returns the
+// transactions completed by the segment.
+func (p *Parser) Feed(data []byte, fromClient bool, timestamp uint64) []*database.Transaction {
+	if p.stopped {
+		return nil
+	}
+	p.completed = nil
+
+	var err error
+	if fromClient {
+		err = p.client.feed(data, func(typ byte, args [][]byte) {
+			p.handleCommand(args, timestamp)
+		})
+	} else {
+		err = p.server.feed(data, func(typ byte, _ [][]byte) {
+			p.handleReply(typ, timestamp)
+		})
+	}
+	if err != nil {
+		p.stopped = true
+		if p.telemetry != nil {
+			p.telemetry.Malformed()
+		}
+	}
+	return p.completed
+}
+
+func (p *Parser) handleCommand(args [][]byte, timestamp uint64) {
+	if p.stopped || len(args) == 0 {
+		return
+	}
+	command := strings.ToUpper(string(args[0]))
+	switch command {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "MONITOR":
+		p.stopped = true
+		return
+	}
+	if len(p.pending) >= maxPendingCommands {
+		return
+	}
+	cmd := &pendingCommand{command: command, started: timestamp}
+	if _, ok := keylessCommands[command]; !ok && len(args) > 1 {
+		cmd.resource = KeyPrefix(string(args[1]))
+	}
+	p.pending = append(p.pending, cmd)
+}
+
+func (p *Parser) handleReply(typ byte, timestamp uint64) {
+	if typ == pushType || len(p.pending) == 0 {
+		// an out of band value, like an invalidation message
+		return
+	}
+	cmd := p.pending[0]
+	p.pending = p.pending[1:]
+	p.completed = append(p.completed, &database.Transaction{
+		Command:          cmd.command,
+		Resource:         cmd.resource,
+		RequestStarted:   cmd.started,
+		ResponseLastSeen: timestamp,
+		Error:            typ == simpleErrorType || typ == bulkErrorType,
+	})
+}
+
+// KeyPrefix obfuscates a key, keeping only its first segment when keys are
+// separated by colons: "user:1234:profile" becomes "user:*". The first segment
+// is replaced with a wildcard too when it holds a digit, as keys like session
+// identifiers may not have a prefix.
+func KeyPrefix(key string) string {
+	prefix, _, found := strings.Cut(key, ":")
+	if strings.ContainsAny(prefix, "0123456789") {
+		return "*"
+	}
+	if !found {
+		return prefix
+	}
+	return prefix + ":*"
+}
+
+// frame is an aggregate value being decoded
+type frame struct {
+	elements  int
+	remaining int
+	// attribute is set for the attributes of RESP3, which precede the value
+	// they describe instead of being a value
+	attribute bool
+}
+
+// stream decodes the values sent in one direction of a connection, without
+// keeping them apart from the first elements of the top-level arrays.
+type stream struct {
+	line  []byte
+	stack []frame
+	// typ is the type of the top-level value being decoded
+	typ byte
+	// skip is the number of bytes of the current bulk string left to read,
+	// including its trailing CRLF
+	skip      int
+	capturing bool
+	current   []byte
+	args      [][]byte
+
+	// capturedArguments is the number of elements of the top-level arrays
+	// that are kept
+	capturedArguments int
+	// inlineCommands is set when the values can be inline commands, as sent
+	// by the clients like telnet
+	inlineCommands bool
+}
+
+func (s *stream) feed(data []byte, handle func(typ byte, args [][]byte)) error {
+	for len(data) > 0 {
+		if s.skip > 0 {
+			n := s.skip
+			if n > len(data) {
+				n = len(data)
+			}
+			if s.capturing {
+				// the bytes of the string itself, without the CRLF
+				kept := s.skip - 2
+				if kept > n {
+					kept = n
+				}
+				if room := maxArgumentSize - len(s.current); kept > room {
+					kept = room
+				}
+				if kept > 0 {
+					s.current = append(s.current, data[:kept]...)
+				}
+			}
+			s.skip -= n
+			data = data[n:]
+			if s.skip == 0 {
+				if s.capturing {
+					s.args = append(s.args, s.current)
+					s.current = nil
+					s.capturing = false
+				}
+				s.valueDone(handle)
+			}
+			continue
+		}
+
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if len(s.line)+len(data) > maxLineLength {
+				return errMalformed
+			}
+			s.line = append(s.line, data...)
+			return nil
+		}
+		var line []byte
+		if len(s.line) > 0 {
+			line = append(s.line, data[:i]...)
+			s.line = s.line[:0]
+		} else {
+			line = data[:i]
+		}
+		data = data[i+1:]
+		if err := s.handleLine(bytes.TrimSuffix(line, []byte{'\r'}), handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stream) handleLine(line []byte, handle func(typ byte, args [][]byte)) error {
+	if len(line) == 0 {
+		if s.inlineCommands && len(s.stack) == 0 {
+			return nil
+		}
+		return errMalformed
+	}
+
+	typ := line[0]
+	if len(s.stack) == 0 && typ != attributeType {
+		s.typ = typ
+	}
+	switch typ {
+	case simpleStringType, simpleErrorType, integerType, nullType, booleanType, doubleType, bigNumberType:
+		s.valueDone(handle)
+	case bulkStringType, bulkErrorType, verbatimStringType:
+		length, err := strconv.Atoi(string(line[1:]))
+		if err != nil || length < -1 || length > maxBulkLength {
+			// including the streamed strings of RESP3
+			return errMalformed
+		}
+		if length == -1 {
+			// the null bulk string of RESP2
+			s.valueDone(handle)
+			return nil
+		}
+		s.skip = length + 2
+		s.capturing = s.captured()
+	case arrayType, setType, pushType, mapType, attributeType:
+		elements, err := strconv.Atoi(string(line[1:]))
+		if err != nil || elements < -1 {
+			return errMalformed
+		}
+		if typ == mapType || typ == attributeType {
+			elements *= 2
+		}
+		if elements <= 0 {
+			if typ != attributeType {
+				// an empty aggregate, or the null array of RESP2
+				s.valueDone(handle)
+			}
+			return nil
+		}
+		if len(s.stack) >= maxDepth {
+			return errMalformed
+		}
+		s.stack = append(s.stack, frame{elements: elements, remaining: elements, attribute: typ == attributeType})
+	default:
+		if !s.inlineCommands || len(s.stack) > 0 {
+			return errMalformed
+		}
+		// an inline command, made of space separated arguments
+		for _, field := range bytes.Fields(line) {
+			if len(s.args) == s.capturedArguments {
+				break
+			}
+			if len(field) > maxArgumentSize {
+				field = field[:maxArgumentSize]
+			}
+			s.args = append(s.args, append([]byte(nil), field...))
+		}
+		s.valueDone(handle)
+	}
+	return nil
+}
+
+// captured returns whether the bulk string being decoded is one of the kept
+// elements of a top-level array
+func (s *stream) captured() bool {
+	if len(s.stack) != 1 || s.typ != arrayType {
+		return false
+	}
+	f := s.stack[0]
+	return !f.attribute && f.elements-f.remaining < s.capturedArguments
+}
+
+// valueDone is called once a value is fully decoded, to complete the values
+// holding it
+func (s *stream) valueDone(handle func(typ byte, args [][]byte)) {
+	for len(s.stack) > 0 {
+		top := &s.stack[len(s.stack)-1]
+		top.remaining--
+		if top.remaining > 0 {
+			return
+		}
+		attribute := top.attribute
+		s.stack = s.stack[:len(s.stack)-1]
+		if attribute {
+			return
+		}
+	}
+	handle(s.typ, s.args)
+	s.args = nil
+}
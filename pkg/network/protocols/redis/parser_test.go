@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func feedAll(p *Parser, segments []testutil.TCPSegment, chunkSize int) []*database.Transaction {
+	var txs []*database.Transaction
+	for _, s := range segments {
+		data := s.Payload
+		for len(data) > 0 {
+			n := chunkSize
+			if n <= 0 || n > len(data) {
+				n = len(data)
+			}
+			txs = append(txs, p.Feed(data[:n], s.FromClient, s.Timestamp)...)
+			data = data[n:]
+		}
+	}
+	return txs
+}
+
+func TestParserCapture(t *testing.T) {
+	// HELLO 3 then SET and GET of a large value, a pipeline of three commands,
+	// a command whose reply follows a push message and has attributes, an
+	// inline PING and a failing EVAL
+	segments := testutil.ReadTCPSegments(t, "testdata/redis.pcap", 6379)
+	require.NotEmpty(t, segments)
+
+	type expected struct {
+		command  string
+		resource string
+		latency  time.Duration
+		error    bool
+	}
+	expectedTxs := []expected{
+		{"HELLO", "", time.Millisecond, false},
+		{"SET", "user:*", 2 * time.Millisecond, false},
+		{"GET", "user:*", 3 * time.Millisecond, false},
+		{"GET", "session:*", 4 * time.Millisecond, false},
+		{"INCR", "counter:*", 4 * time.Millisecond, false},
+		{"LPUSH", "user:*", 4 * time.Millisecond, true},
+		{"HGETALL", "config:*", 5 * time.Millisecond, false},
+		{"PING", "", time.Millisecond, false},
+		{"EVAL", "", 6 * time.Millisecond, true},
+	}
+
+	// the segments are decoded the same way when split in chunks
+	for _, chunkSize := range []int{0, 1, 3, 7} {
+		p := NewParser(nil)
+		txs := feedAll(p, segments, chunkSize)
+		require.Len(t, txs, len(expectedTxs), "chunk size %d", chunkSize)
+		for i, e := range expectedTxs {
+			assert.Equal(t, e.command, txs[i].Command)
+			assert.Equal(t, e.resource, txs[i].Resource)
+			assert.Equal(t, float64(e.latency), txs[i].Latency(), e.command)
+			assert.Equal(t, e.error, txs[i].Error, e.command)
+		}
+		assert.Empty(t, p.pending)
+		assert.False(t, p.stopped)
+	}
+}
+
+func TestParserPubSub(t *testing.T) {
+	p := NewParser(nil)
+	txs := p.Feed([]byte("*2\r\n$9\r\nSUBSCRIBE\r\n$4\r\nnews\r\n"), true, 1)
+	assert.Empty(t, txs)
+	// the messages can't be told apart from replies in RESP2
+	txs = p.Feed([]byte("*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n"), false, 2)
+	assert.Empty(t, txs)
+	assert.True(t, p.stopped)
+}
+
+func TestParserMalformed(t *testing.T) {
+	telemetry := database.NewTelemetry("redis")
+	p := NewParser(telemetry)
+	txs := p.Feed([]byte("*1\r\n$3\r\nGET\r\n"), true, 1)
+	assert.Empty(t, txs)
+	txs = p.Feed([]byte("$abc\r\n"), false, 2)
+	assert.Empty(t, txs)
+	assert.True(t, p.stopped)
+	assert.Empty(t, p.Feed([]byte("+OK\r\n"), false, 3))
+}
+
+func TestKeyPrefix(t *testing.T) {
+	for key, prefix := range map[string]string{
+		"":                           "",
+		"config":                     "config",
+		"config:feature_flags":       "config:*",
+		"user:1234":                  "user:*",
+		"user:1234:profile":          "user:*",
+		"app:user:42:sessions:9":     "app:*",
+		"customer:alice@example.com": "customer:*",
+		"1234":                       "*",
+		"sess4f2a:data":              "*",
+		"cache:9f8e7d6c-session:ab":  "cache:*",
+	} {
+		assert.Equal(t, prefix, KeyPrefix(key), key)
+	}
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+)
+
+// TCPSegment is the payload of a TCP packet read from a capture
+type TCPSegment struct {
+	Payload []byte
+	// FromClient is set for the packets sent to the server port
+	FromClient bool
+	// Timestamp is the capture time of the packet, in nanoseconds
+	Timestamp uint64
+}
+
+// ReadTCPSegments returns the non-empty TCP payloads sent to or from the given
+// server port, read from a pcap file of Ethernet frames, in capture order.
+// This allows testing the protocol parsers without a kernel.
+func ReadTCPSegments(t testing.TB, path string, serverPort uint16) []TCPSegment {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+
+	var segments []TCPSegment
+	for {
+		data, ci, err := r.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		packet := gopacket.NewPacket(data, r.LinkType(), gopacket.Default)
+		tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		if !ok || len(tcp.Payload) == 0 {
+			continue
+		}
+		var fromClient bool
+		switch uint16(tcp.DstPort) {
+		case serverPort:
+			fromClient = true
+		default:
+			if uint16(tcp.SrcPort) != serverPort {
+				continue
+			}
+		}
+		segments = append(segments, TCPSegment{
+			Payload:    tcp.Payload,
+			FromClient: fromClient,
+			Timestamp:  uint64(ci.Timestamp.UnixNano()),
+		})
+	}
+	return segments
+}
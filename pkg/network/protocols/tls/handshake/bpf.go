@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package handshake
+
+import (
+	"golang.org/x/net/bpf"
+)
+
+// recordPrefix is the beginning of the TLS handshake records: their type
+// followed by the major version
+const recordPrefix = handshakeRecord<<8 | 3
+
+// generateBPFFilter returns a classic BPF filter capturing the TCP segments
+// starting with a TLS handshake record
+func generateBPFFilter() ([]bpf.RawInstruction, error) {
+	return bpf.Assemble([]bpf.Instruction{
+		//(000) ldh      [12] -- load Ethertype
+		bpf.LoadAbsolute{Size: 2, Off: 12},
+		//(001) jeq      #0x86dd          jt 2	jf 10 -- if IPv6, goto 2, else 10
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd, SkipTrue: 0, SkipFalse: 8},
+		//(002) ldb      [20] -- load IPv6 Next Header
+		bpf.LoadAbsolute{Size: 1, Off: 20},
+		//(003) jeq      #0x6             jt 4	jf 24 -- IPv6 Next Header: if TCP, goto 4, else drop
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6, SkipTrue: 0, SkipFalse: 20},
+		//(004) ldb      [66] -- load TCP data offset
+		bpf.LoadAbsolute{Size: 1, Off: 66},
+		//(005) and      #0xf0
+		bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0xf0},
+		//(006) rsh      #2 -- a = TCP header length
+		bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 2},
+		//(007) tax
+		bpf.TAX{},
+		//(008) ldh      [x + 54] -- load the beginning of the TCP payload
+		bpf.LoadIndirect{Size: 2, Off: 54},
+		//(009) ja       22 -- check the record prefix
+		bpf.Jump{Skip: 12},
+		//(010) jeq      #0x800           jt 11	jf 24 -- if IPv4, go next, else drop
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x800, SkipTrue: 0, SkipFalse: 13},
+		//(011) ldb      [23] -- load IPv4 Protocol
+		bpf.LoadAbsolute{Size: 1, Off: 23},
+		//(012) jeq      #0x6             jt 13	jf 24 -- if TCP, go next, else drop
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6, SkipTrue: 0, SkipFalse: 11},
+		//(013) ldh      [20] -- load Fragment Offset
+		bpf.LoadAbsolute{Size: 2, Off: 20},
+		//(014) jset     #0x1fff          jt 24	jf 15 -- use 0x1fff as mask for fragment offset, if != 0, drop
+		bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: 9, SkipFalse: 0},
+		//(015) ldxb     4*([14]&0xf) -- x = IP header length
+		bpf.LoadMemShift{Off: 14},
+		//(016) ldb      [x + 26] -- load TCP data offset
+		bpf.LoadIndirect{Size: 1, Off: 26},
+		//(017) and      #0xf0
+		bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0xf0},
+		//(018) rsh      #2 -- a = TCP header length
+		bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 2},
+		//(019) add      x -- a = IP and TCP headers length
+		bpf.ALUOpX{Op: bpf.ALUOpAdd},
+		//(020) tax
+		bpf.TAX{},
+		//(021) ldh      [x + 14] -- load the beginning of the TCP payload
+		bpf.LoadIndirect{Size: 2, Off: 14},
+		//(022) jeq      #0x1603          jt 23	jf 24 -- if a handshake record, capture, else drop
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: recordPrefix, SkipTrue: 0, SkipFalse: 1},
+		//(023) ret      #262144 -- capture
+		bpf.RetConstant{Val: 262144},
+		//(024) ret      #0 -- drop
+		bpf.RetConstant{Val: 0},
+	})
+}
@@ -0,0 +1,424 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package handshake decodes the handshakes of TLS connections to report their
+// negotiated version and cipher suite, the server name requested by the client
+// and the expiry date of the certificate of the server.
+package handshake
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+const (
+	// maxBufferedSize bounds the handshake bytes buffered in each direction,
+	// which is enough for the usual certificate chains
+	maxBufferedSize = 64 * 1024
+	// maxRecordLength is the length above which a record is considered
+	// malformed
+	maxRecordLength = 1<<14 + 2048
+
+	recordHeaderLength    = 5
+	handshakeHeaderLength = 4
+)
+
+// handshakeRecord is the type of the records holding handshake messages,
+// the others being the change cipher spec, alert and application data ones
+const handshakeRecord = 22
+
+// Handshake message types
+const (
+	clientHelloMessage     = 1
+	serverHelloMessage     = 2
+	certificateMessage     = 11
+	serverHelloDoneMessage = 14
+)
+
+// Extensions
+const (
+	serverNameExtension        = 0
+	supportedVersionsExtension = 43
+	hostNameType               = 0
+)
+
+// Metadata describes the handshake of a TLS connection
+type Metadata struct {
+	// Version is the negotiated version, like tls.VersionTLS12
+	Version uint16
+	// CipherSuite is the negotiated cipher suite, like
+	// tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	CipherSuite uint16
+	// ServerName is the name requested by the client with the SNI extension
+	ServerName string
+	// CertNotAfter is the expiry date of the leaf certificate of the server.
+	// It is zero when the certificate isn't visible, like with TLS 1.3 where
+	// it is encrypted.
+	CertNotAfter time.Time
+}
+
+// Tags returns the tags describing the handshake, like "tls.version:tls1.2"
+func (m *Metadata) Tags() []string {
+	if m == nil {
+		return nil
+	}
+	var tags []string
+	if m.Version != 0 {
+		tags = append(tags, "tls.version:"+VersionName(m.Version))
+	}
+	if m.CipherSuite != 0 {
+		tags = append(tags, "tls.cipher_suite:"+tls.CipherSuiteName(m.CipherSuite))
+	}
+	if m.ServerName != "" {
+		tags = append(tags, "tls.server_name:"+m.ServerName)
+	}
+	if !m.CertNotAfter.IsZero() {
+		tags = append(tags, "tls.cert_not_after:"+m.CertNotAfter.UTC().Format("2006-01-02"))
+	}
+	return tags
+}
+
+// VersionName returns the name of a TLS version, like "tls1.2"
+func VersionName(version uint16) string {
+	switch version {
+	case tls.VersionSSL30: //nolint:staticcheck
+		return "ssl3.0"
+	case tls.VersionTLS10:
+		return "tls1.0"
+	case tls.VersionTLS11:
+		return "tls1.1"
+	case tls.VersionTLS12:
+		return "tls1.2"
+	case tls.VersionTLS13:
+		return "tls1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// Parser decodes the handshake of a TLS connection, from the records sent by
+// the client and the server in order.
+type Parser struct {
+	client stream
+	server stream
+
+	metadata    Metadata
+	clientHello bool
+	serverHello bool
+	// clientDone and serverDone are set once the messages of interest were
+	// decoded, or can't be decoded anymore
+	clientDone bool
+	serverDone bool
+}
+
+// NewParser returns a parser of the handshake of a TLS connection
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Feed decodes the given segment of the connection, sent by the client or the
+// server. It returns whether the handshake is over, or can't be decoded
+// anymore.
+func (p *Parser) Feed(data []byte, fromClient bool) bool {
+	if fromClient && !p.clientDone {
+		p.clientDone = p.client.feed(data, p.handleClientMessage)
+	} else if !fromClient && !p.serverDone {
+		p.serverDone = p.server.feed(data, p.handleServerMessage)
+	}
+	return p.Done()
+}
+
+// Resync drops the partial records of the given direction, when some segments
+// were not seen. The decoding resumes at the next segment, expected to start
+// with a record.
+func (p *Parser) Resync(fromClient bool) {
+	if fromClient {
+		p.client.reset()
+	} else {
+		p.server.reset()
+	}
+}
+
+// Done returns whether the handshake is over, or can't be decoded anymore
+func (p *Parser) Done() bool {
+	return p.clientDone && p.serverDone
+}
+
+// Metadata returns the metadata decoded so far, or nil until the hello
+// message of the server was decoded
+func (p *Parser) Metadata() *Metadata {
+	if !p.serverHello {
+		return nil
+	}
+	m := p.metadata
+	return &m
+}
+
+// handleClientMessage returns whether the decoding of the messages of the
+// client is over
+func (p *Parser) handleClientMessage(typ byte, body []byte, complete bool) bool {
+	if typ != clientHelloMessage {
+		return p.clientHello
+	}
+	if !complete {
+		return false
+	}
+	p.clientHello = true
+	p.metadata.ServerName = parseClientHello(body)
+	return true
+}
+
+// handleServerMessage returns whether the decoding of the messages of the
+// server is over
+func (p *Parser) handleServerMessage(typ byte, body []byte, complete bool) bool {
+	switch typ {
+	case serverHelloMessage:
+		if !complete {
+			return false
+		}
+		version, cipherSuite, ok := parseServerHello(body)
+		if !ok {
+			return true
+		}
+		p.serverHello = true
+		p.metadata.Version = version
+		p.metadata.CipherSuite = cipherSuite
+		// the following messages are encrypted since TLS 1.3
+		return version >= tls.VersionTLS13
+	case certificateMessage:
+		// the leaf certificate can be decoded before the whole chain
+		if notAfter, ok := parseLeafCertificate(body); ok {
+			p.metadata.CertNotAfter = notAfter
+			return true
+		}
+		return complete
+	case serverHelloDoneMessage:
+		return true
+	}
+	return false
+}
+
+// parseClientHello returns the server name requested by a ClientHello
+func parseClientHello(body []byte) string {
+	r := reader(body)
+	// legacy version and random
+	r.skip(2 + 32)
+	r.vector(1) // session id
+	r.vector(2) // cipher suites
+	r.vector(1) // compression methods
+	extensions := r.vector(2)
+	for len(extensions) > 0 {
+		typ := extensions.uint16()
+		data := extensions.vector(2)
+		if typ != serverNameExtension {
+			continue
+		}
+		names := data.vector(2)
+		for len(names) > 0 {
+			nameType := names.uint8()
+			name := names.vector(2)
+			if nameType == hostNameType {
+				return string(name)
+			}
+		}
+	}
+	return ""
+}
+
+// parseServerHello returns the version and cipher suite selected by a
+// ServerHello
+func parseServerHello(body []byte) (uint16, uint16, bool) {
+	r := reader(body)
+	if len(r) < 2+32+1 {
+		return 0, 0, false
+	}
+	version := r.uint16()
+	r.skip(32)  // random
+	r.vector(1) // session id
+	if len(r) < 3 {
+		return 0, 0, false
+	}
+	cipherSuite := r.uint16()
+	r.skip(1) // compression method
+	extensions := r.vector(2)
+	for len(extensions) > 0 {
+		typ := extensions.uint16()
+		data := extensions.vector(2)
+		if typ == supportedVersionsExtension && len(data) == 2 {
+			version = data.uint16()
+		}
+	}
+	return version, cipherSuite, true
+}
+
+// parseLeafCertificate returns the expiry date of the first certificate of
+// a Certificate message, which may be truncated after it
+func parseLeafCertificate(body []byte) (time.Time, bool) {
+	r := reader(body)
+	// the length of the chain
+	r.skip(3)
+	if len(r) < 3 {
+		return time.Time{}, false
+	}
+	length := r.uint24()
+	if length == 0 || length > len(r) {
+		return time.Time{}, false
+	}
+	cert, err := x509.ParseCertificate(r[:length])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return cert.NotAfter, true
+}
+
+// reader reads the fields of a handshake message, returning zero values once
+// the message is truncated
+type reader []byte
+
+func (r *reader) skip(n int) {
+	if n > len(*r) {
+		n = len(*r)
+	}
+	*r = (*r)[n:]
+}
+
+func (r *reader) uint8() byte {
+	if len(*r) < 1 {
+		return 0
+	}
+	v := (*r)[0]
+	r.skip(1)
+	return v
+}
+
+func (r *reader) uint16() uint16 {
+	if len(*r) < 2 {
+		r.skip(2)
+		return 0
+	}
+	v := uint16((*r)[0])<<8 | uint16((*r)[1])
+	r.skip(2)
+	return v
+}
+
+func (r *reader) uint24() int {
+	if len(*r) < 3 {
+		r.skip(3)
+		return 0
+	}
+	v := int((*r)[0])<<16 | int((*r)[1])<<8 | int((*r)[2])
+	r.skip(3)
+	return v
+}
+
+// vector reads a vector prefixed with a length of the given size
+func (r *reader) vector(lengthSize int) reader {
+	var length int
+	switch lengthSize {
+	case 1:
+		length = int(r.uint8())
+	case 2:
+		length = int(r.uint16())
+	}
+	if length > len(*r) {
+		length = len(*r)
+	}
+	v := (*r)[:length]
+	r.skip(length)
+	return v
+}
+
+// stream reassembles the handshake messages sent in one direction of a
+// connection, from the records holding them.
+type stream struct {
+	header    []byte
+	remaining int
+	inRecord  bool
+	handshake []byte
+}
+
+func (s *stream) reset() {
+	s.header = s.header[:0]
+	s.remaining = 0
+	s.inRecord = false
+	s.handshake = s.handshake[:0]
+}
+
+// feed decodes the records of the segment, calling handle with each handshake
+// message, and with the truncated message that follows them. It returns
+// whether the decoding is over, as reported by handle or because the
+// handshake is over or malformed.
+func (s *stream) feed(data []byte, handle func(typ byte, body []byte, complete bool) bool) bool {
+	for len(data) > 0 {
+		if !s.inRecord {
+			n := recordHeaderLength - len(s.header)
+			if n > len(data) {
+				n = len(data)
+			}
+			s.header = append(s.header, data[:n]...)
+			data = data[n:]
+			if len(s.header) < recordHeaderLength {
+				return false
+			}
+
+			typ := s.header[0]
+			length := int(s.header[3])<<8 | int(s.header[4])
+			s.header = s.header[:0]
+			if typ != handshakeRecord {
+				// the handshake is over, the messages are encrypted or
+				// this isn't TLS
+				return true
+			}
+			if length > maxRecordLength {
+				return true
+			}
+			s.remaining = length
+			s.inRecord = true
+		}
+
+		n := s.remaining
+		if n > len(data) {
+			n = len(data)
+		}
+		if len(s.handshake)+n > maxBufferedSize {
+			return true
+		}
+		s.handshake = append(s.handshake, data[:n]...)
+		data = data[n:]
+		s.remaining -= n
+		if s.remaining == 0 {
+			s.inRecord = false
+		}
+		if s.handleMessages(handle) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMessages calls handle with the complete handshake messages, which are
+// removed from the buffer, and with the truncated message that follows them.
+func (s *stream) handleMessages(handle func(typ byte, body []byte, complete bool) bool) bool {
+	buf := s.handshake
+	defer func() {
+		s.handshake = append(s.handshake[:0], buf...)
+	}()
+	for len(buf) >= handshakeHeaderLength {
+		typ := buf[0]
+		length := int(buf[1])<<16 | int(buf[2])<<8 | int(buf[3])
+		if length > maxBufferedSize {
+			return true
+		}
+		if len(buf)-handshakeHeaderLength < length {
+			return handle(typ, buf[handshakeHeaderLength:], false)
+		}
+		if handle(typ, buf[handshakeHeaderLength:handshakeHeaderLength+length], true) {
+			return true
+		}
+		buf = buf[handshakeHeaderLength+length:]
+	}
+	return false
+}
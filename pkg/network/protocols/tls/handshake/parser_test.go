@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package handshake
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func feedAll(p *Parser, segments []testutil.TCPSegment, chunkSize int) {
+	for _, s := range segments {
+		data := s.Payload
+		for len(data) > 0 {
+			n := chunkSize
+			if n <= 0 || n > len(data) {
+				n = len(data)
+			}
+			p.Feed(data[:n], s.FromClient)
+			data = data[n:]
+		}
+	}
+}
+
+func TestParserCapture(t *testing.T) {
+	for _, test := range []struct {
+		file     string
+		expected Metadata
+		tags     []string
+	}{
+		{
+			file: "testdata/tls10.pcap",
+			expected: Metadata{
+				Version:      tls.VersionTLS10,
+				CipherSuite:  tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+				ServerName:   "legacy.example.com",
+				CertNotAfter: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			tags: []string{
+				"tls.version:tls1.0",
+				"tls.cipher_suite:TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+				"tls.server_name:legacy.example.com",
+				"tls.cert_not_after:2024-03-01",
+			},
+		},
+		{
+			file: "testdata/tls12.pcap",
+			expected: Metadata{
+				Version:      tls.VersionTLS12,
+				CipherSuite:  tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+				ServerName:   "api.example.com",
+				CertNotAfter: time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+			},
+			tags: []string{
+				"tls.version:tls1.2",
+				"tls.cipher_suite:TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+				"tls.server_name:api.example.com",
+				"tls.cert_not_after:2025-01-15",
+			},
+		},
+		{
+			// the certificate is encrypted
+			file: "testdata/tls13.pcap",
+			expected: Metadata{
+				Version:     tls.VersionTLS13,
+				CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+				ServerName:  "www.example.com",
+			},
+			tags: []string{
+				"tls.version:tls1.3",
+				"tls.cipher_suite:TLS_AES_128_GCM_SHA256",
+				"tls.server_name:www.example.com",
+			},
+		},
+	} {
+		t.Run(test.file, func(t *testing.T) {
+			segments := testutil.ReadTCPSegments(t, test.file, 443)
+			require.NotEmpty(t, segments)
+
+			// the segments are decoded the same way when split in chunks
+			for _, chunkSize := range []int{0, 1, 3, 100} {
+				p := NewParser()
+				feedAll(p, segments, chunkSize)
+				assert.True(t, p.Done())
+				m := p.Metadata()
+				require.NotNil(t, m)
+				assert.Equal(t, test.expected.Version, m.Version)
+				assert.Equal(t, test.expected.CipherSuite, m.CipherSuite)
+				assert.Equal(t, test.expected.ServerName, m.ServerName)
+				assert.True(t, test.expected.CertNotAfter.Equal(m.CertNotAfter), m.CertNotAfter)
+				assert.ElementsMatch(t, test.tags, m.Tags())
+			}
+		})
+	}
+}
+
+func TestParserTruncatedCertificate(t *testing.T) {
+	segments := testutil.ReadTCPSegments(t, "testdata/tls12.pcap", 443)
+
+	// the flight of the server is cut after the leaf certificate, as when
+	// the following segments aren't captured
+	p := NewParser()
+	for _, s := range segments {
+		if s.FromClient {
+			p.Feed(s.Payload, true)
+			continue
+		}
+		// the server hello, then the leaf certificate of a chain of two
+		// identical certificates
+		p.Feed(s.Payload[:len(s.Payload)*2/3], false)
+		break
+	}
+	m := p.Metadata()
+	require.NotNil(t, m)
+	assert.True(t, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC).Equal(m.CertNotAfter))
+	assert.True(t, p.Done())
+}
+
+func TestParserNotTLS(t *testing.T) {
+	p := NewParser()
+	assert.False(t, p.Feed([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), true))
+	assert.True(t, p.Feed([]byte("HTTP/1.1 200 OK\r\n\r\n"), false))
+	assert.Nil(t, p.Metadata())
+}
+
+func TestVersionName(t *testing.T) {
+	assert.Equal(t, "tls1.1", VersionName(tls.VersionTLS11))
+	assert.Equal(t, "0x7f1c", VersionName(0x7f1c))
+}
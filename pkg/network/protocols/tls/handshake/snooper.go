@@ -0,0 +1,332 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package handshake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/network/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	tlsModuleName = "network_tracer__tls"
+
+	// maxHandshakes bounds the handshakes being decoded
+	maxHandshakes = 10000
+	// maxConnections bounds the connections whose metadata is kept
+	maxConnections = 100000
+	// handshakeTimeout is the time after which a handshake that isn't over
+	// is dropped, keeping the metadata decoded so far
+	handshakeTimeout = 10 * time.Second
+	// metadataExpiry is the time after which the metadata of a connection
+	// that wasn't looked up is dropped
+	metadataExpiry = 10 * time.Minute
+)
+
+// Telemetry
+var snooperTelemetry = struct {
+	handshakes     *telemetry.StatCounterWrapper
+	decodingErrors *telemetry.StatCounterWrapper
+	dropped        *telemetry.StatCounterWrapper
+}{
+	telemetry.NewStatCounterWrapper(tlsModuleName, "handshakes", []string{}, "Counter measuring the number of decoded TLS handshakes"),
+	telemetry.NewStatCounterWrapper(tlsModuleName, "decoding_errors", []string{}, "Counter measuring the number of packets that couldn't be decoded"),
+	telemetry.NewStatCounterWrapper(tlsModuleName, "dropped", []string{}, "Counter measuring the number of handshakes dropped because of the capacity limits"),
+}
+
+// packetSource reads raw packet data
+type packetSource interface {
+	// VisitPackets reads all new raw packets that are available, invoking the given callback for each packet.
+	VisitPackets(cancel <-chan struct{}, visitor func(data []byte, timestamp time.Time) error) error
+
+	// PacketType returns the type of packet this source reads
+	PacketType() gopacket.LayerType
+
+	// Close closes the packet source
+	Close()
+}
+
+type handshakeState struct {
+	parser  *Parser
+	started time.Time
+	// the next sequence numbers expected from the client and the server
+	clientSeq  uint32
+	serverSeq  uint32
+	serverSeen bool
+}
+
+type metadataEntry struct {
+	metadata *Metadata
+	lastSeen time.Time
+}
+
+// Snooper decodes the TLS handshakes captured by a raw socket, to report the
+// metadata of the connections. Only the segments starting with a handshake
+// record are captured, so the handshake messages spanning several segments,
+// like long certificate chains, may be partially decoded.
+type Snooper struct {
+	source  packetSource
+	decoder *gopacket.DecodingLayerParser
+	eth     layers.Ethernet
+	ipv4    layers.IPv4
+	ipv6    layers.IPv6
+	tcp     layers.TCP
+	payload gopacket.Payload
+	layers  []gopacket.LayerType
+
+	// handshakes is only accessed by the goroutine reading the packets
+	handshakes map[types.ConnectionKey]*handshakeState
+
+	mux      sync.Mutex
+	metadata map[types.ConnectionKey]*metadataEntry
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSnooper returns a Snooper of the TLS handshakes of the root network
+// namespace
+func NewSnooper(cfg *config.Config) (*Snooper, error) {
+	bpfFilter, err := generateBPFFilter()
+	if err != nil {
+		return nil, fmt.Errorf("error creating bpf classic filter: %w", err)
+	}
+
+	// Create the RAW_SOCKET inside the root network namespace
+	var (
+		packetSrc *filterpkg.AFPacketSource
+		srcErr    error
+		ns        netns.NsHandle
+	)
+	if ns, err = cfg.GetRootNetNs(); err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	err = util.WithNS(ns, func() error {
+		packetSrc, srcErr = filterpkg.NewPacketSource(nil, bpfFilter)
+		return srcErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSnooper(packetSrc), nil
+}
+
+func newSnooper(source packetSource) *Snooper {
+	s := &Snooper{
+		source:     source,
+		handshakes: make(map[types.ConnectionKey]*handshakeState),
+		metadata:   make(map[types.ConnectionKey]*metadataEntry),
+		exit:       make(chan struct{}),
+	}
+	s.decoder = gopacket.NewDecodingLayerParser(source.PacketType(), &s.eth, &s.ipv4, &s.ipv6, &s.tcp, &s.payload)
+	s.decoder.IgnoreUnsupported = true
+	return s
+}
+
+// Start starts reading the packets
+func (s *Snooper) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.pollPackets()
+	}()
+}
+
+// Close stops reading the packets and closes the underlying socket
+func (s *Snooper) Close() {
+	close(s.exit)
+	s.wg.Wait()
+	s.source.Close()
+}
+
+// Get returns the metadata of the given connection, in any direction, or nil
+// when its handshake wasn't decoded
+func (s *Snooper) Get(conn types.ConnectionKey) *Metadata {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	entry, ok := s.metadata[conn]
+	if !ok {
+		entry, ok = s.metadata[reverse(conn)]
+	}
+	if !ok {
+		return nil
+	}
+	entry.lastSeen = time.Now()
+	return entry.metadata
+}
+
+func (s *Snooper) pollPackets() {
+	for {
+		err := s.source.VisitPackets(s.exit, s.processPacket)
+		if err != nil {
+			log.Warnf("error reading packet: %s", err)
+		}
+
+		// Properly synchronizes termination process
+		select {
+		case <-s.exit:
+			return
+		default:
+		}
+
+		s.expire(time.Now())
+		// Sleep briefly and try again
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// processPacket decodes the TLS records of a captured packet. The packet data
+// can't be referenced after this call, as it is reused by the packet source.
+func (s *Snooper) processPacket(data []byte, ts time.Time) error {
+	if err := s.decoder.DecodeLayers(data, &s.layers); err != nil {
+		snooperTelemetry.decodingErrors.Inc()
+		return nil
+	}
+
+	var (
+		saddr, daddr util.Address
+		payloadLen   int
+		hasTCP       bool
+	)
+	for _, layer := range s.layers {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			saddr, daddr = util.AddressFromNetIP(s.ipv4.SrcIP), util.AddressFromNetIP(s.ipv4.DstIP)
+			payloadLen = int(s.ipv4.Length) - int(s.ipv4.IHL)*4
+		case layers.LayerTypeIPv6:
+			saddr, daddr = util.AddressFromNetIP(s.ipv6.SrcIP), util.AddressFromNetIP(s.ipv6.DstIP)
+			payloadLen = int(s.ipv6.Length)
+		case layers.LayerTypeTCP:
+			hasTCP = true
+		}
+	}
+	if !hasTCP || len(s.tcp.Payload) == 0 {
+		return nil
+	}
+	payload := s.tcp.Payload
+	// the length of the segment, which may be longer than the captured
+	// payload
+	segmentLen := payloadLen - int(s.tcp.DataOffset)*4
+	if segmentLen < len(payload) {
+		segmentLen = len(payload)
+	}
+
+	key := types.NewConnectionKey(saddr, daddr, uint16(s.tcp.SrcPort), uint16(s.tcp.DstPort))
+	fromClient := true
+	state, ok := s.handshakes[key]
+	if !ok {
+		if state, ok = s.handshakes[reverse(key)]; ok {
+			fromClient = false
+		}
+	}
+	if !ok {
+		// the handshakes are decoded from the hello of the client
+		if len(payload) <= recordHeaderLength || payload[recordHeaderLength] != clientHelloMessage {
+			return nil
+		}
+		if len(s.handshakes) >= maxHandshakes {
+			snooperTelemetry.dropped.Inc()
+			return nil
+		}
+		state = &handshakeState{parser: NewParser(), started: ts, clientSeq: s.tcp.Seq}
+		s.handshakes[key] = state
+	}
+
+	seq := s.tcp.Seq
+	expected := &state.clientSeq
+	if !fromClient {
+		expected = &state.serverSeq
+		if !state.serverSeen {
+			state.serverSeen = true
+			state.serverSeq = seq
+		}
+	}
+	if diff := int32(seq - *expected); diff < 0 {
+		// a retransmission
+		return nil
+	} else if diff > 0 {
+		// some segments weren't captured
+		state.parser.Resync(fromClient)
+	}
+	*expected = seq + uint32(segmentLen)
+
+	done := state.parser.Feed(payload, fromClient)
+	if len(payload) < segmentLen {
+		// the end of the segment wasn't captured
+		state.parser.Resync(fromClient)
+	}
+	if done {
+		s.complete(key, state, fromClient, ts)
+	}
+	return nil
+}
+
+// complete stores the metadata of a handshake that is over, keyed by the
+// connection from the client to the server
+func (s *Snooper) complete(key types.ConnectionKey, state *handshakeState, fromClient bool, now time.Time) {
+	if !fromClient {
+		key = reverse(key)
+	}
+	delete(s.handshakes, key)
+
+	metadata := state.parser.Metadata()
+	if metadata == nil {
+		return
+	}
+	snooperTelemetry.handshakes.Inc()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.metadata[key]; !ok && len(s.metadata) >= maxConnections {
+		snooperTelemetry.dropped.Inc()
+		return
+	}
+	s.metadata[key] = &metadataEntry{metadata: metadata, lastSeen: now}
+}
+
+// expire drops the handshakes and the metadata that timed out
+func (s *Snooper) expire(now time.Time) {
+	for key, state := range s.handshakes {
+		if now.Sub(state.started) > handshakeTimeout {
+			s.complete(key, state, true, now)
+		}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for key, entry := range s.metadata {
+		if now.Sub(entry.lastSeen) > metadataExpiry {
+			delete(s.metadata, key)
+		}
+	}
+}
+
+func reverse(key types.ConnectionKey) types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: key.DstIPHigh,
+		SrcIPLow:  key.DstIPLow,
+		DstIPHigh: key.SrcIPHigh,
+		DstIPLow:  key.SrcIPLow,
+		SrcPort:   key.DstPort,
+		DstPort:   key.SrcPort,
+	}
+}
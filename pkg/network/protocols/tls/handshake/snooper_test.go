@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package handshake
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/bpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+type packet struct {
+	data      []byte
+	timestamp time.Time
+}
+
+// capturedPackets reads the Ethernet frames of a pcap file
+func capturedPackets(t *testing.T, path string) []packet {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+	var packets []packet
+	for {
+		data, ci, err := r.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet{data: data, timestamp: ci.Timestamp})
+	}
+}
+
+type testPacketSource struct{}
+
+func (testPacketSource) VisitPackets(<-chan struct{}, func([]byte, time.Time) error) error {
+	return nil
+}
+
+func (testPacketSource) PacketType() gopacket.LayerType {
+	return layers.LayerTypeEthernet
+}
+
+func (testPacketSource) Close() {}
+
+func TestBPFFilter(t *testing.T) {
+	instructions, err := generateBPFFilter()
+	require.NoError(t, err)
+	var disassembled []bpf.Instruction
+	for _, raw := range instructions {
+		disassembled = append(disassembled, raw.Disassemble())
+	}
+	vm, err := bpf.NewVM(disassembled)
+	require.NoError(t, err)
+
+	var captured, dropped int
+	for _, p := range capturedPackets(t, "testdata/tls12.pcap") {
+		decoded := gopacket.NewPacket(p.data, layers.LayerTypeEthernet, gopacket.Default)
+		tcp := decoded.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		handshake := len(tcp.Payload) > 1 && tcp.Payload[0] == handshakeRecord && tcp.Payload[1] == 3
+
+		n, err := vm.Run(p.data)
+		require.NoError(t, err)
+		assert.Equal(t, handshake, n > 0)
+		if n > 0 {
+			captured++
+		} else {
+			dropped++
+		}
+	}
+	// the hello messages of the client and of the server, along with the
+	// second flight of the client
+	assert.Equal(t, 3, captured)
+	// the TCP handshake and the application data
+	assert.NotZero(t, dropped)
+}
+
+func TestSnooper(t *testing.T) {
+	s := newSnooper(testPacketSource{})
+	for _, file := range []string{"testdata/tls10.pcap", "testdata/tls13.pcap"} {
+		for _, p := range capturedPackets(t, file) {
+			require.NoError(t, s.processPacket(p.data, p.timestamp))
+		}
+	}
+	assert.Empty(t, s.handshakes)
+
+	// the captures use the same addresses and ports
+	key := types.NewConnectionKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 45678, 443)
+	m := s.Get(key)
+	require.NotNil(t, m)
+	assert.Equal(t, uint16(tls.VersionTLS13), m.Version)
+	assert.Equal(t, "www.example.com", m.ServerName)
+	assert.Equal(t, m, s.Get(reverse(key)))
+
+	// the metadata of the connections that are not looked up expires
+	s.expire(time.Now().Add(metadataExpiry / 2))
+	assert.NotNil(t, s.Get(key))
+	s.expire(time.Now().Add(2 * metadataExpiry))
+	assert.Nil(t, s.Get(key))
+}
+
+func TestSnooperHandshakeTimeout(t *testing.T) {
+	s := newSnooper(testPacketSource{})
+	packets := capturedPackets(t, "testdata/tls12.pcap")
+	start := packets[0].timestamp
+	for _, p := range packets {
+		decoded := gopacket.NewPacket(p.data, layers.LayerTypeEthernet, gopacket.Default)
+		tcp := decoded.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		if len(tcp.Payload) == 0 {
+			continue
+		}
+		if tcp.SrcPort == 443 {
+			// the capture of the flight of the server is truncated after
+			// its hello message
+			helloLen := int(tcp.Payload[6])<<16 | int(tcp.Payload[7])<<8 | int(tcp.Payload[8])
+			truncated := len(p.data) - len(tcp.Payload) + recordHeaderLength + handshakeHeaderLength + helloLen
+			require.NoError(t, s.processPacket(p.data[:truncated], p.timestamp))
+			break
+		}
+		require.NoError(t, s.processPacket(p.data, p.timestamp))
+	}
+	require.Len(t, s.handshakes, 1)
+
+	key := types.NewConnectionKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 45678, 443)
+	s.expire(start.Add(handshakeTimeout / 2))
+	assert.Len(t, s.handshakes, 1)
+	assert.Nil(t, s.Get(key))
+
+	// the metadata decoded so far is kept
+	s.expire(start.Add(2 * handshakeTimeout))
+	assert.Empty(t, s.handshakes)
+	m := s.Get(key)
+	require.NotNil(t, m)
+	assert.Equal(t, uint16(tls.VersionTLS12), m.Version)
+	assert.Equal(t, "api.example.com", m.ServerName)
+	assert.True(t, m.CertNotAfter.IsZero())
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
 	nettelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
@@ -35,6 +36,7 @@ var stateTelemetry = struct {
 	httpStatsDropped      *nettelemetry.StatCounterWrapper
 	http2StatsDropped     *nettelemetry.StatCounterWrapper
 	kafkaStatsDropped     *nettelemetry.StatCounterWrapper
+	databaseStatsDropped  *nettelemetry.StatCounterWrapper
 	dnsPidCollisions      *nettelemetry.StatCounterWrapper
 }{
 	nettelemetry.NewStatCounterWrapper(stateModuleName, "closed_conn_dropped", []string{}, "Counter measuring the number of dropped closed connections"),
@@ -46,6 +48,7 @@ var stateTelemetry = struct {
 	nettelemetry.NewStatCounterWrapper(stateModuleName, "http_stats_dropped", []string{}, "Counter measuring the number of http stats dropped"),
 	nettelemetry.NewStatCounterWrapper(stateModuleName, "http2_stats_dropped", []string{}, "Counter measuring the number of http2 stats dropped"),
 	nettelemetry.NewStatCounterWrapper(stateModuleName, "kafka_stats_dropped", []string{}, "Counter measuring the number of kafka stats dropped"),
+	nettelemetry.NewStatCounterWrapper(stateModuleName, "database_stats_dropped", []string{}, "Counter measuring the number of database stats dropped"),
 	nettelemetry.NewStatCounterWrapper(stateModuleName, "dns_pid_collisions", []string{}, "Counter measuring the number of DNS PID collisions"),
 }
 
@@ -77,6 +80,7 @@ type State interface {
 		http map[http.Key]*http.RequestStats,
 		http2 map[http.Key]*http.RequestStats,
 		kafka map[kafka.Key]*kafka.RequestStat,
+		database map[database.Key]*database.RequestStat,
 	) Delta
 
 	// GetTelemetryDelta returns the telemetry delta since last time the given client requested telemetry data.
@@ -114,6 +118,7 @@ type Delta struct {
 	HTTP     map[http.Key]*http.RequestStats
 	HTTP2    map[http.Key]*http.RequestStats
 	Kafka    map[kafka.Key]*kafka.RequestStat
+	Database map[database.Key]*database.RequestStat
 	DNSStats dns.StatsByKeyByNameByType
 }
 
@@ -127,6 +132,7 @@ type lastStateTelemetry struct {
 	httpStatsDropped      int64
 	http2StatsDropped     int64
 	kafkaStatsDropped     int64
+	databaseStatsDropped  int64
 	dnsPidCollisions      int64
 }
 
@@ -140,11 +146,12 @@ type client struct {
 	closedConnections []ConnectionStats
 	stats             map[uint32]StatCounters
 	// maps by dns key the domain (string) to stats structure
-	dnsStats        dns.StatsByKeyByNameByType
-	httpStatsDelta  map[http.Key]*http.RequestStats
-	http2StatsDelta map[http.Key]*http.RequestStats
-	kafkaStatsDelta map[kafka.Key]*kafka.RequestStat
-	lastTelemetries map[ConnTelemetryType]int64
+	dnsStats           dns.StatsByKeyByNameByType
+	httpStatsDelta     map[http.Key]*http.RequestStats
+	http2StatsDelta    map[http.Key]*http.RequestStats
+	kafkaStatsDelta    map[kafka.Key]*kafka.RequestStat
+	databaseStatsDelta map[database.Key]*database.RequestStat
+	lastTelemetries    map[ConnTelemetryType]int64
 }
 
 func (c *client) Reset(active map[uint32]*ConnectionStats) {
@@ -159,6 +166,7 @@ func (c *client) Reset(active map[uint32]*ConnectionStats) {
 	c.httpStatsDelta = make(map[http.Key]*http.RequestStats)
 	c.http2StatsDelta = make(map[http.Key]*http.RequestStats)
 	c.kafkaStatsDelta = make(map[kafka.Key]*kafka.RequestStat)
+	c.databaseStatsDelta = make(map[database.Key]*database.RequestStat)
 
 	// XXX: we should change the way we clean this map once
 	// https://github.com/golang/go/issues/20135 is solved
@@ -188,20 +196,23 @@ type networkState struct {
 	maxDNSStats    int
 	maxHTTPStats   int
 	maxKafkaStats  int
+	// maxDatabaseStats bounds the database stats of each client
+	maxDatabaseStats int
 
 	mergeStatsBuffers [2][]byte
 }
 
 // NewState creates a new network state
-func NewState(clientExpiry time.Duration, maxClosedConns, maxClientStats int, maxDNSStats int, maxHTTPStats int, maxKafkaStats int) State {
+func NewState(clientExpiry time.Duration, maxClosedConns, maxClientStats int, maxDNSStats int, maxHTTPStats int, maxKafkaStats int, maxDatabaseStats int) State {
 	return &networkState{
-		clients:        map[string]*client{},
-		clientExpiry:   clientExpiry,
-		maxClosedConns: maxClosedConns,
-		maxClientStats: maxClientStats,
-		maxDNSStats:    maxDNSStats,
-		maxHTTPStats:   maxHTTPStats,
-		maxKafkaStats:  maxKafkaStats,
+		clients:          map[string]*client{},
+		clientExpiry:     clientExpiry,
+		maxClosedConns:   maxClosedConns,
+		maxClientStats:   maxClientStats,
+		maxDNSStats:      maxDNSStats,
+		maxHTTPStats:     maxHTTPStats,
+		maxKafkaStats:    maxKafkaStats,
+		maxDatabaseStats: maxDatabaseStats,
 		mergeStatsBuffers: [2][]byte{
 			make([]byte, ConnectionByteKeyMaxLen),
 			make([]byte, ConnectionByteKeyMaxLen),
@@ -248,6 +259,7 @@ func (ns *networkState) GetDelta(
 	httpStats map[http.Key]*http.RequestStats,
 	http2Stats map[http.Key]*http.RequestStats,
 	kafkaStats map[kafka.Key]*kafka.RequestStat,
+	databaseStats map[database.Key]*database.RequestStat,
 ) Delta {
 	ns.Lock()
 	defer ns.Unlock()
@@ -278,6 +290,9 @@ func (ns *networkState) GetDelta(
 	if len(http2Stats) > 0 {
 		ns.storeHTTP2Stats(http2Stats)
 	}
+	if len(databaseStats) > 0 {
+		ns.storeDatabaseStats(databaseStats)
+	}
 
 	return Delta{
 		BufferedData: BufferedData{
@@ -288,6 +303,7 @@ func (ns *networkState) GetDelta(
 		HTTP2:    client.http2StatsDelta,
 		DNSStats: client.dnsStats,
 		Kafka:    client.kafkaStatsDelta,
+		Database: client.databaseStatsDelta,
 	}
 }
 
@@ -340,11 +356,12 @@ func (ns *networkState) logTelemetry() {
 	httpStatsDroppedDelta := stateTelemetry.httpStatsDropped.Load() - ns.lastTelemetry.httpStatsDropped
 	http2StatsDroppedDelta := stateTelemetry.http2StatsDropped.Load() - ns.lastTelemetry.http2StatsDropped
 	kafkaStatsDroppedDelta := stateTelemetry.kafkaStatsDropped.Load() - ns.lastTelemetry.kafkaStatsDropped
+	databaseStatsDroppedDelta := stateTelemetry.databaseStatsDropped.Load() - ns.lastTelemetry.databaseStatsDropped
 	dnsPidCollisionsDelta := stateTelemetry.dnsPidCollisions.Load() - ns.lastTelemetry.dnsPidCollisions
 
 	// Flush log line if any metric is non-zero
 	if statsUnderflowsDelta > 0 || statsCookieCollisionsDelta > 0 || closedConnDroppedDelta > 0 || connDroppedDelta > 0 || timeSyncCollisionsDelta > 0 ||
-		dnsStatsDroppedDelta > 0 || httpStatsDroppedDelta > 0 || http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 || dnsPidCollisionsDelta > 0 {
+		dnsStatsDroppedDelta > 0 || httpStatsDroppedDelta > 0 || http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 || databaseStatsDroppedDelta > 0 || dnsPidCollisionsDelta > 0 {
 		s := "state telemetry: "
 		s += " [%d stats stats_underflows]"
 		s += " [%d stats cookie collisions]"
@@ -354,6 +371,7 @@ func (ns *networkState) logTelemetry() {
 		s += " [%d HTTP stats dropped]"
 		s += " [%d HTTP2 stats dropped]"
 		s += " [%d Kafka stats dropped]"
+		s += " [%d database stats dropped]"
 		s += " [%d DNS pid collisions]"
 		s += " [%d time sync collisions]"
 		log.Warnf(s,
@@ -365,6 +383,7 @@ func (ns *networkState) logTelemetry() {
 			httpStatsDroppedDelta,
 			http2StatsDroppedDelta,
 			kafkaStatsDroppedDelta,
+			databaseStatsDroppedDelta,
 			dnsPidCollisionsDelta,
 			timeSyncCollisionsDelta)
 	}
@@ -378,6 +397,7 @@ func (ns *networkState) logTelemetry() {
 	ns.lastTelemetry.httpStatsDropped = stateTelemetry.httpStatsDropped.Load()
 	ns.lastTelemetry.http2StatsDropped = stateTelemetry.http2StatsDropped.Load()
 	ns.lastTelemetry.kafkaStatsDropped = stateTelemetry.kafkaStatsDropped.Load()
+	ns.lastTelemetry.databaseStatsDropped = stateTelemetry.databaseStatsDropped.Load()
 	ns.lastTelemetry.dnsPidCollisions = stateTelemetry.dnsPidCollisions.Load()
 }
 
@@ -605,6 +625,37 @@ func (ns *networkState) storeKafkaStats(allStats map[kafka.Key]*kafka.RequestSta
 	}
 }
 
+// storeDatabaseStats stores the latest database stats for all clients
+func (ns *networkState) storeDatabaseStats(allStats map[database.Key]*database.RequestStat) {
+	if len(ns.clients) == 1 {
+		for _, client := range ns.clients {
+			if len(client.databaseStatsDelta) == 0 {
+				// optimization for the common case:
+				// if there is only one client and no previous state, no memory allocation is needed
+				client.databaseStatsDelta = allStats
+				return
+			}
+		}
+	}
+
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.databaseStatsDelta[key]
+			if !ok && len(client.databaseStatsDelta) >= ns.maxDatabaseStats {
+				stateTelemetry.databaseStatsDropped.Inc()
+				continue
+			}
+
+			if prevStats != nil {
+				prevStats.CombineWith(stats)
+				client.databaseStatsDelta[key] = prevStats
+			} else {
+				client.databaseStatsDelta[key] = stats
+			}
+		}
+	}
+}
+
 func (ns *networkState) getClient(clientID string) *client {
 	if c, ok := ns.clients[clientID]; ok {
 		return c
@@ -619,6 +670,7 @@ func (ns *networkState) getClient(clientID string) *client {
 		httpStatsDelta:        map[http.Key]*http.RequestStats{},
 		http2StatsDelta:       map[http.Key]*http.RequestStats{},
 		kafkaStatsDelta:       map[kafka.Key]*kafka.RequestStat{},
+		databaseStatsDelta:    map[database.Key]*database.RequestStat{},
 		lastTelemetries:       make(map[ConnTelemetryType]int64),
 	}
 	ns.clients[clientID] = c
@@ -986,6 +1038,10 @@ func (ns *networkState) mergeConnectionStats(a, b *ConnectionStats) (collision b
 		a.IPTranslation = b.IPTranslation
 	}
 
+	if a.TLSMetadata == nil {
+		a.TLSMetadata = b.TLSMetadata
+	}
+
 	a.ProtocolStack.MergeWith(b.ProtocolStack)
 
 	return false
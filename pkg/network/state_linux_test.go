@@ -37,7 +37,7 @@ func TestStatsOverflow(t *testing.T) {
 	state.RegisterClient(client)
 
 	// Get the connections once to register stats
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 
 	// Expect Last.SentPackets to be math.MaxUint32-1
@@ -50,7 +50,7 @@ func TestStatsOverflow(t *testing.T) {
 	conn.Monotonic.SentPackets = 10
 	conn.Monotonic.RecvPackets = 11
 
-	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, uint64(12), conns[0].Last.SentPackets)
 	assert.Equal(t, uint64(14), conns[0].Last.RecvPackets)
@@ -30,8 +30,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	usmtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/tls/handshake"
 	nettelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
 	"github.com/DataDog/datadog-agent/pkg/network/usm"
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -89,6 +91,8 @@ type Tracer struct {
 
 	gwLookup *gatewayLookup
 
+	tlsSnooper *handshake.Snooper
+
 	sysctlUDPConnTimeout       *sysctl.Int
 	sysctlUDPConnStreamTimeout *sysctl.Int
 
@@ -173,6 +177,7 @@ func newTracer(cfg *config.Config) (*Tracer, error) {
 		cfg.MaxDNSStatsBuffered,
 		cfg.MaxHTTPStatsBuffered,
 		cfg.MaxKafkaStatsBuffered,
+		cfg.MaxDatabaseStatsBuffered,
 	)
 
 	gwLookup := newGatewayLookup(cfg)
@@ -180,6 +185,8 @@ func newTracer(cfg *config.Config) (*Tracer, error) {
 		log.Info("gateway lookup enabled")
 	}
 
+	tlsSnooper := newTLSSnooper(cfg)
+
 	tr := &Tracer{
 		config:                     cfg,
 		state:                      state,
@@ -192,6 +199,7 @@ func newTracer(cfg *config.Config) (*Tracer, error) {
 		sysctlUDPConnTimeout:       sysctl.NewInt(cfg.ProcRoot, "net/netfilter/nf_conntrack_udp_timeout", time.Minute),
 		sysctlUDPConnStreamTimeout: sysctl.NewInt(cfg.ProcRoot, "net/netfilter/nf_conntrack_udp_timeout_stream", time.Minute),
 		gwLookup:                   gwLookup,
+		tlsSnooper:                 tlsSnooper,
 		ebpfTracer:                 ebpfTracer,
 		bpfTelemetry:               bpfTelemetry,
 		lastCheck:                  atomic.NewInt64(time.Now().Unix()),
@@ -246,6 +254,10 @@ func (tr *Tracer) start() error {
 		return fmt.Errorf("could not start reverse dns monitor: %w", err)
 	}
 
+	if tr.tlsSnooper != nil {
+		tr.tlsSnooper.Start()
+	}
+
 	return nil
 }
 
@@ -290,6 +302,21 @@ func newReverseDNS(c *config.Config) dns.ReverseDNS {
 	return rdns
 }
 
+func newTLSSnooper(c *config.Config) *handshake.Snooper {
+	if !c.EnableTLSMetadata {
+		return nil
+	}
+
+	snooper, err := handshake.NewSnooper(c)
+	if err != nil {
+		log.Errorf("could not instantiate tls handshake snooper: %s", err)
+		return nil
+	}
+
+	log.Info("tls metadata collection enabled")
+	return snooper
+}
+
 func (t *Tracer) storeClosedConnections(connections []network.ConnectionStats) {
 	var rejected int
 	_ = t.timeResolver.Sync()
@@ -303,6 +330,7 @@ func (t *Tracer) storeClosedConnections(connections []network.ConnectionStats) {
 
 		cs.IPTranslation = t.conntracker.GetTranslationForConn(*cs)
 		t.connVia(cs)
+		t.connTLSMetadata(cs)
 		if cs.IPTranslation != nil {
 			t.conntracker.DeleteTranslation(*cs)
 		}
@@ -358,6 +386,9 @@ func (t *Tracer) Stop() {
 	if t.gwLookup != nil {
 		t.gwLookup.Close()
 	}
+	if t.tlsSnooper != nil {
+		t.tlsSnooper.Close()
+	}
 	t.reverseDNS.Close()
 	t.ebpfTracer.Stop()
 	t.usmMonitor.Stop()
@@ -379,7 +410,7 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	}
 	active := t.activeBuffer.Connections()
 
-	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.usmMonitor.GetHTTPStats(), t.usmMonitor.GetHTTP2Stats(), t.usmMonitor.GetKafkaStats())
+	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.usmMonitor.GetHTTPStats(), t.usmMonitor.GetHTTP2Stats(), t.usmMonitor.GetKafkaStats(), t.usmMonitor.GetDatabaseStats())
 	t.activeBuffer.Reset()
 
 	ips := make([]util.Address, 0, len(delta.Conns)*2)
@@ -401,6 +432,7 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		HTTP:                        delta.HTTP,
 		HTTP2:                       delta.HTTP2,
 		Kafka:                       delta.Kafka,
+		Database:                    delta.Database,
 		ConnTelemetry:               ctm,
 		KernelHeaderFetchResult:     khfr,
 		CompilationTelemetryByAsset: rctm,
@@ -509,6 +541,7 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 		// since gateway resolution connects to the ec2 metadata
 		// endpoint)
 		t.connVia(&active[i])
+		t.connTLSMetadata(&active[i])
 		t.addProcessInfo(&active[i])
 	}
 
@@ -722,6 +755,14 @@ func (t *Tracer) connVia(cs *network.ConnectionStats) {
 	cs.Via = t.gwLookup.Lookup(cs)
 }
 
+func (t *Tracer) connTLSMetadata(cs *network.ConnectionStats) {
+	if t.tlsSnooper == nil || cs.Type != network.TCP {
+		return // tls metadata collection is not enabled
+	}
+
+	cs.TLSMetadata = t.tlsSnooper.Get(types.NewConnectionKey(cs.Source, cs.Dest, cs.SPort, cs.DPort))
+}
+
 // DebugCachedConntrack dumps the cached NAT conntrack data
 func (t *Tracer) DebugCachedConntrack(ctx context.Context) (interface{}, error) {
 	ns, err := t.config.GetRootNetNs()
@@ -813,6 +854,9 @@ func newUSMMonitor(c *config.Config, tracer connection.Tracer, bpfTelemetry *net
 	if c.EnableKafkaMonitoring {
 		log.Info("kafka monitoring enabled")
 	}
+	if c.EnableDatabaseMonitoring {
+		log.Info("database monitoring enabled")
+	}
 	if c.EnableGoTLSSupport {
 		log.Info("goTLS monitoring enabled")
 	}
@@ -19,6 +19,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/database/snooper"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/events"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
@@ -63,6 +65,10 @@ type Monitor struct {
 	kafkaConsumer   *events.Consumer
 	kafkaTelemetry  *kafka.Telemetry
 	kafkaStatkeeper *kafka.KafkaStatKeeper
+
+	// databaseSnooper decodes the traffic of the databases, captured by a
+	// raw socket
+	databaseSnooper *snooper.Snooper
 	// termination
 	closeFilterFn func()
 }
@@ -173,6 +179,14 @@ func NewMonitor(c *config.Config, connectionProtocolMap, sockFD *ebpf.Map, bpfTe
 		httpMonitor.kafkaStatkeeper = kafkaStatkeeper
 	}
 
+	if c.EnableDatabaseMonitoring {
+		httpMonitor.databaseSnooper, err = snooper.NewSnooper(c)
+		if err != nil {
+			closeFilterFn()
+			return nil, fmt.Errorf("error creating database snooper: %w", err)
+		}
+	}
+
 	return httpMonitor, nil
 }
 
@@ -231,6 +245,10 @@ func (m *Monitor) Start() error {
 		m.kafkaConsumer.Start()
 	}
 
+	if m.databaseSnooper != nil {
+		m.databaseSnooper.Start()
+	}
+
 	err = m.ebpfProgram.Start()
 	if err != nil {
 		return err
@@ -293,6 +311,15 @@ func (m *Monitor) GetKafkaStats() map[kafka.Key]*kafka.RequestStat {
 	return m.kafkaStatkeeper.GetAndResetAllStats()
 }
 
+// GetDatabaseStats returns a map of the stats of the database transactions
+func (m *Monitor) GetDatabaseStats() map[database.Key]*database.RequestStat {
+	if m == nil || m.databaseSnooper == nil {
+		return nil
+	}
+
+	return m.databaseSnooper.GetAndResetAllStats()
+}
+
 // Stop HTTP monitoring
 func (m *Monitor) Stop() {
 	if m == nil {
@@ -309,6 +336,9 @@ func (m *Monitor) Stop() {
 	if m.kafkaEnabled {
 		m.kafkaConsumer.Stop()
 	}
+	if m.databaseSnooper != nil {
+		m.databaseSnooper.Close()
+	}
 	m.httpStatkeeper.Close()
 	if m.http2Statkeeper != nil {
 		m.http2Statkeeper.Close()
@@ -64,6 +64,20 @@ const (
 	K8sCR
 	// K8sVerticalPodAutoscaler represents a Kubernetes VerticalPod Autoscaler
 	K8sVerticalPodAutoscaler
+	// K8sHorizontalPodAutoscaler represents a Kubernetes Horizontal Pod Autoscaler
+	K8sHorizontalPodAutoscaler
+	// K8sNetworkPolicy represents a Kubernetes NetworkPolicy
+	K8sNetworkPolicy
+	// K8sLimitRange represents a Kubernetes LimitRange
+	K8sLimitRange
+	// K8sStorageClass represents a Kubernetes StorageClass
+	K8sStorageClass
+	// K8sPodDisruptionBudget represents a Kubernetes PodDisruptionBudget
+	K8sPodDisruptionBudget
+	// K8sEndpointSlice represents a Kubernetes EndpointSlice
+	K8sEndpointSlice
+	// K8sResourceQuota represents a Kubernetes ResourceQuota
+	K8sResourceQuota
 )
 
 // NodeTypes returns the current existing NodesTypes as a slice to iterate over.
@@ -91,6 +105,13 @@ func NodeTypes() []NodeType {
 		K8sCR,
 		K8sCRD,
 		K8sVerticalPodAutoscaler,
+		K8sHorizontalPodAutoscaler,
+		K8sNetworkPolicy,
+		K8sLimitRange,
+		K8sStorageClass,
+		K8sPodDisruptionBudget,
+		K8sEndpointSlice,
+		K8sResourceQuota,
 	}
 }
 
@@ -140,6 +161,20 @@ func (n NodeType) String() string {
 		return "CustomResource"
 	case K8sVerticalPodAutoscaler:
 		return "VerticalPodAutoscaler"
+	case K8sHorizontalPodAutoscaler:
+		return "HorizontalPodAutoscaler"
+	case K8sNetworkPolicy:
+		return "NetworkPolicy"
+	case K8sLimitRange:
+		return "LimitRange"
+	case K8sStorageClass:
+		return "StorageClass"
+	case K8sPodDisruptionBudget:
+		return "PodDisruptionBudget"
+	case K8sEndpointSlice:
+		return "EndpointSlice"
+	case K8sResourceQuota:
+		return "ResourceQuota"
 	case K8sUnsetType:
 		return "UnsetType"
 	default:
@@ -173,6 +208,13 @@ func (n NodeType) Orchestrator() string {
 		K8sCR,
 		K8sNamespace,
 		K8sVerticalPodAutoscaler,
+		K8sHorizontalPodAutoscaler,
+		K8sNetworkPolicy,
+		K8sLimitRange,
+		K8sStorageClass,
+		K8sPodDisruptionBudget,
+		K8sEndpointSlice,
+		K8sResourceQuota,
 		K8sUnsetType:
 		return "k8s"
 	default:
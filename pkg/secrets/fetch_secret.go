@@ -117,7 +117,8 @@ type Secret struct {
 var runCommand = execCommand
 
 // fetchSecret receives a list of secrets name to fetch, exec a custom
-// executable to fetch the actual secrets and returns them.
+// executable to fetch the actual secrets and returns them. It doesn't update
+// the cache, so that it can be called without holding secretsLock.
 func fetchSecret(secretsHandle []string) (map[string]string, error) {
 	payload := map[string]interface{}{
 		"version": PayloadVersion,
@@ -157,8 +158,6 @@ func fetchSecret(secretsHandle []string) (map[string]string, error) {
 			return nil, fmt.Errorf("decrypted secret for '%s' is empty", sec)
 		}
 
-		// add it to the cache
-		secretCache[sec] = v.Value
 		res[sec] = v.Value
 	}
 	return res, nil
@@ -105,6 +105,9 @@ Secrets handle decrypted:
 	used in 'test2' configuration in entry 'instances/password'
 - 'pass3':
 	used in 'test2' configuration in entry 'instances/password'
+
+=== Secrets refresh ===
+Periodic refresh is disabled
 `
 
 	assert.Equal(t, expectedResult, buffer.String())
@@ -147,6 +150,9 @@ Secrets handle decrypted:
 	used in 'test2' configuration in entry 'instances/password'
 - 'pass3':
 	used in 'test2' configuration in entry 'instances/password'
+
+=== Secrets refresh ===
+Periodic refresh is disabled
 `
 
 	assert.Equal(t, expectedResult, buffer.String())
@@ -13,6 +13,7 @@ package secrets
 import (
 	"fmt"
 	"io"
+	"time"
 )
 
 // SecretBackendOutputMaxSize defines max size of the JSON output from a secrets reader backend
@@ -31,3 +32,19 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 func GetDebugInfo(w io.Writer) {
 	fmt.Fprintf(w, "Secret feature is not available in this version of the agent")
 }
+
+// Refresh placeholder when compiled without the 'secrets' build tag
+func Refresh() ([]string, error) {
+	return nil, fmt.Errorf("secret feature is not available in this version of the agent")
+}
+
+// SubscribeToChanges placeholder when compiled without the 'secrets' build tag
+func SubscribeToChanges(callback ChangeCallback) func() {
+	return func() {}
+}
+
+// StartRefreshRoutine placeholder when compiled without the 'secrets' build tag
+func StartRefreshRoutine(interval time.Duration) {}
+
+// StopRefreshRoutine placeholder when compiled without the 'secrets' build tag
+func StopRefreshRoutine() {}
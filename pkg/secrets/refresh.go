@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tlmSecretRefresh        = telemetry.NewCounter("secret_backend", "refresh", []string{"status"}, "Number of secret refreshes")
+	tlmSecretRefreshChanged = telemetry.NewCounter("secret_backend", "refresh_changed_handles", nil, "Number of secret handles whose value changed during a refresh")
+
+	// refreshLock serializes the refreshes
+	refreshLock sync.Mutex
+
+	subscribersLock sync.Mutex
+	subscribers     []*subscriber
+
+	refreshStop     chan struct{}
+	lastRefresh     time.Time
+	lastRefreshErr  error
+	refreshInterval time.Duration
+)
+
+// Refresh fetches again every secret handle known to the agent from the secret backend. The cache is updated
+// with the new values and every subscriber is notified of the handles whose value changed. It returns the list
+// of handles that changed, sorted. Secret values are never returned nor logged.
+func Refresh() ([]string, error) {
+	// refreshes are serialized, so that an older refresh doesn't overwrite the values of a newer one
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+
+	secretsLock.Lock()
+	if secretBackendCommand == "" {
+		secretsLock.Unlock()
+		return nil, fmt.Errorf("no secret_backend_command set: secrets feature is not enabled")
+	}
+
+	handles := make([]string, 0, len(secretCache))
+	oldValues := make(map[string]string, len(secretCache))
+	for handle, value := range secretCache {
+		handles = append(handles, handle)
+		oldValues[handle] = value
+	}
+	sort.Strings(handles)
+	secretsLock.Unlock()
+
+	if len(handles) == 0 {
+		secretsLock.Lock()
+		lastRefresh, lastRefreshErr = time.Now(), nil
+		secretsLock.Unlock()
+		tlmSecretRefresh.Inc("success")
+		return nil, nil
+	}
+
+	// the secret backend command is run without holding secretsLock, so that it doesn't block Decrypt
+	newValues, err := secretFetcher(handles)
+
+	secretsLock.Lock()
+	lastRefresh, lastRefreshErr = time.Now(), err
+	if err != nil {
+		// we keep using the previous values until a refresh succeeds
+		secretsLock.Unlock()
+		tlmSecretRefresh.Inc("error")
+		return nil, fmt.Errorf("could not refresh secrets: %s", err)
+	}
+
+	changes := []Change{}
+	for _, handle := range handles {
+		newValue, ok := newValues[handle]
+		if !ok || newValue == oldValues[handle] {
+			continue
+		}
+		secretCache[handle] = newValue
+
+		origins := []string{}
+		for _, context := range secretOrigin[handle] {
+			origins = append(origins, context.origin)
+		}
+		changes = append(changes, Change{
+			Handle:   handle,
+			Origins:  origins,
+			OldValue: oldValues[handle],
+			NewValue: newValue,
+		})
+	}
+	secretsLock.Unlock()
+
+	tlmSecretRefresh.Inc("success")
+	tlmSecretRefreshChanged.Add(float64(len(changes)))
+
+	changedHandles := make([]string, 0, len(changes))
+	for _, change := range changes {
+		changedHandles = append(changedHandles, change.Handle)
+	}
+
+	if len(changes) == 0 {
+		log.Debugf("Refreshed %d secrets, none of them changed", len(handles))
+		return changedHandles, nil
+	}
+	log.Infof("Refreshed %d secrets, %d changed: %v", len(handles), len(changes), changedHandles)
+
+	// Subscribers are notified without holding secretsLock as they will most likely decrypt their configuration again.
+	subscribersLock.Lock()
+	callbacks := make([]ChangeCallback, 0, len(subscribers))
+	for _, s := range subscribers {
+		callbacks = append(callbacks, s.callback)
+	}
+	subscribersLock.Unlock()
+	for _, callback := range callbacks {
+		callback(changes)
+	}
+
+	return changedHandles, nil
+}
+
+// subscriber wraps a callback so that it can be identified when unsubscribing
+type subscriber struct {
+	callback ChangeCallback
+}
+
+// SubscribeToChanges registers a callback to be called each time a refresh changes the value of at least one
+// secret. The returned function unregisters it.
+func SubscribeToChanges(callback ChangeCallback) func() {
+	s := &subscriber{callback: callback}
+
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	subscribers = append(subscribers, s)
+
+	return func() {
+		subscribersLock.Lock()
+		defer subscribersLock.Unlock()
+		for i, other := range subscribers {
+			if other == s {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// StartRefreshRoutine periodically refreshes secrets until StopRefreshRoutine is called. Nothing is done if
+// the interval is not strictly positive or if the routine is already running.
+func StartRefreshRoutine(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+	if refreshStop != nil {
+		return
+	}
+	refreshStop = make(chan struct{})
+	refreshInterval = interval
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := Refresh(); err != nil {
+					log.Errorf("Periodic secret refresh failed: %s", err)
+				}
+			}
+		}
+	}(refreshStop)
+}
+
+// StopRefreshRoutine stops the routine started by StartRefreshRoutine.
+func StopRefreshRoutine() {
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+	if refreshStop != nil {
+		close(refreshStop)
+		refreshStop = nil
+		refreshInterval = 0
+	}
+}
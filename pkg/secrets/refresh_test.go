@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build secrets
+// +build secrets
+
+package secrets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshNoCommand(t *testing.T) {
+	t.Cleanup(resetPackageVars)
+
+	_, err := Refresh()
+	require.Error(t, err)
+}
+
+func TestRefresh(t *testing.T) {
+	t.Cleanup(resetPackageVars)
+	secretBackendCommand = "some_command"
+
+	secretCache = map[string]string{"pass1": "password1", "pass2": "password2"}
+	secretFetcher = func(handles []string) (map[string]string, error) {
+		return map[string]string{"pass1": "password1", "pass2": "password2"}, nil
+	}
+	_, err := Decrypt(testConf, "test")
+	require.NoError(t, err)
+
+	var notified []Change
+	SubscribeToChanges(func(changes []Change) {
+		notified = append(notified, changes...)
+	})
+
+	// nothing changed in the backend
+	changed, err := Refresh()
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, notified)
+
+	secretFetcher = func(handles []string) (map[string]string, error) {
+		assert.Equal(t, []string{"pass1", "pass2"}, handles)
+		return map[string]string{"pass1": "password1", "pass2": "rotated"}, nil
+	}
+	changed, err = Refresh()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pass2"}, changed)
+	assert.Equal(t, []Change{
+		{
+			Handle:   "pass2",
+			Origins:  []string{"test"},
+			OldValue: "password2",
+			NewValue: "rotated",
+		},
+	}, notified)
+
+	// the new value is used by subsequent decryptions
+	newConf, err := Decrypt(testConf, "test")
+	require.NoError(t, err)
+	assert.Contains(t, string(newConf), "rotated")
+}
+
+func TestRefreshError(t *testing.T) {
+	t.Cleanup(resetPackageVars)
+	secretBackendCommand = "some_command"
+	secretCache = map[string]string{"pass1": "password1"}
+
+	SubscribeToChanges(func(changes []Change) {
+		require.Fail(t, "subscribers should not be notified on error")
+	})
+	secretFetcher = func(handles []string) (map[string]string, error) {
+		return nil, fmt.Errorf("some error")
+	}
+
+	_, err := Refresh()
+	require.Error(t, err)
+	assert.Equal(t, "password1", secretCache["pass1"])
+	assert.Error(t, lastRefreshErr)
+}
+
+func TestRefreshDoesNotBlockDecrypt(t *testing.T) {
+	t.Cleanup(resetPackageVars)
+	secretBackendCommand = "some_command"
+	secretCache = map[string]string{"pass1": "password1", "pass2": "password2"}
+
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	secretFetcher = func(handles []string) (map[string]string, error) {
+		close(fetching)
+		<-release
+		return map[string]string{"pass1": "password1", "pass2": "rotated"}, nil
+	}
+
+	done := make(chan []string)
+	go func() {
+		changed, err := Refresh()
+		assert.NoError(t, err)
+		done <- changed
+	}()
+	<-fetching
+
+	// the cached values are served while the secret backend command runs
+	newConf, err := Decrypt(testConf, "test")
+	require.NoError(t, err)
+	assert.Contains(t, string(newConf), "password2")
+
+	close(release)
+	assert.Equal(t, []string{"pass2"}, <-done)
+	assert.Equal(t, "rotated", secretCache["pass2"])
+}
+
+func TestUnsubscribeFromChanges(t *testing.T) {
+	t.Cleanup(resetPackageVars)
+	secretBackendCommand = "some_command"
+	secretCache = map[string]string{"pass1": "password1"}
+	secretFetcher = func(handles []string) (map[string]string, error) {
+		return map[string]string{"pass1": "rotated"}, nil
+	}
+
+	kept := 0
+	SubscribeToChanges(func(changes []Change) { kept++ })
+	unsubscribe := SubscribeToChanges(func(changes []Change) {
+		require.Fail(t, "unsubscribed callbacks should not be notified")
+	})
+	unsubscribe()
+	// unsubscribing twice is a no-op
+	unsubscribe()
+
+	_, err := Refresh()
+	require.NoError(t, err)
+	assert.Equal(t, 1, kept)
+}
@@ -14,7 +14,9 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -25,6 +27,10 @@ import (
 type handleToContext map[string][]secretContext
 
 var (
+	// secretsLock protects the cache and origin of secrets, which can be accessed concurrently by Decrypt and
+	// Refresh.
+	secretsLock sync.Mutex
+
 	// testing purpose
 	secretFetcher       = fetchSecret
 	scrubberAddReplacer = scrubber.AddStrippedKeys
@@ -181,6 +187,9 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 		return data, nil
 	}
 
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+
 	var config interface{}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
@@ -222,6 +231,9 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		for handle, secret := range secrets {
+			secretCache[handle] = secret
+		}
 
 		// Replace all new encrypted secrets in the config
 		err = walk(
@@ -259,6 +271,9 @@ type secretInfo struct {
 	ExecutablePermissionsDetails interface{}
 	ExecutablePermissionsError   string
 	Handles                      map[string][][]string
+	RefreshInterval              string
+	LastRefresh                  string
+	LastRefreshError             string
 }
 
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
@@ -299,6 +314,19 @@ func GetDebugInfo(w io.Writer) {
 		info.ExecutablePermissionsError = err.Error()
 	}
 
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+
+	if refreshInterval > 0 {
+		info.RefreshInterval = refreshInterval.String()
+	}
+	if !lastRefresh.IsZero() {
+		info.LastRefresh = lastRefresh.UTC().Format(time.RFC3339)
+	}
+	if lastRefreshErr != nil {
+		info.LastRefreshError = lastRefreshErr.Error()
+	}
+
 	// we sort handles so the output is consistent and testable
 	orderedHandles := []string{}
 	for handle := range secretOrigin {
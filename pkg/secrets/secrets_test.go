@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -143,6 +144,9 @@ func resetPackageVars() {
 	secretBackendTimeout = 0
 	scrubberAddReplacer = scrubber.AddStrippedKeys
 	removeTrailingLinebreak = false
+	subscribers = nil
+	lastRefresh = time.Time{}
+	lastRefreshErr = nil
 }
 
 func TestIsEnc(t *testing.T) {
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrets
+
+// Change describes a secret handle whose value changed during a refresh.
+type Change struct {
+	// Handle is the secret handle, as found between 'ENC[' and ']' in configurations.
+	Handle string
+	// Origins are the names of the configurations in which the handle was found.
+	Origins []string
+	// OldValue is the value used until the refresh.
+	OldValue string
+	// NewValue is the value returned by the secret backend during the refresh.
+	NewValue string
+}
+
+// ChangeCallback is called with every secret whose value changed during a refresh.
+type ChangeCallback func(changes []Change)
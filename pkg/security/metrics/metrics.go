@@ -44,6 +44,13 @@ var (
 	// Tags: rule_id
 	MetricRateLimiterAllow = newRuntimeMetric(".rules.rate_limiter.allow")
 
+	// Rule action metrics
+
+	// MetricRuleActionDropped is the name of the metric used to count the hash and snapshot actions dropped because
+	// the queue of the actions waiting to be performed was full
+	// Tags: action
+	MetricRuleActionDropped = newRuntimeMetric(".rules.action.dropped")
+
 	// Syscall monitoring metrics
 
 	// MetricSyscalls is the name of the metric used to count each syscall executed on the host
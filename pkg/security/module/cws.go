@@ -476,6 +476,9 @@ func (c *CWSConsumer) RuleMatch(rule *rules.Rule, event eval.Event) {
 		return // if the triggered rule is only meant to tag secdumps, dont send it
 	}
 
+	// perform the actions of the rule before the processes are killed by one of them
+	c.probe.HandleActions(rule, ev)
+
 	// needs to be resolved here, outside of the callback as using process tree
 	// which can be modified during queuing
 	service := c.probe.GetService(ev)
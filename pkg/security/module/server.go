@@ -35,13 +35,62 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
+// maxActionsDelay is the maximum time an event is held after its retention period, waiting for the
+// rule actions performed asynchronously to complete
+const maxActionsDelay = 10 * time.Second
+
 type pendingMsg struct {
-	ruleID    string
-	data      []byte
-	tags      []string
-	service   string
-	extTagsCb func() []string
-	sendAfter time.Time
+	ruleID        string
+	data          []byte
+	tags          []string
+	service       string
+	extTagsCb     func() []string
+	sendAfter     time.Time
+	actionReports []*model.ActionReport
+}
+
+// isReady returns whether the message can be sent
+func (msg *pendingMsg) isReady(now time.Time) bool {
+	if msg.sendAfter.After(now) {
+		return false
+	}
+
+	if msg.sendAfter.Add(maxActionsDelay).After(now) {
+		for _, report := range msg.actionReports {
+			if report.IsPending() {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// addActionReports adds the reports of the rule actions to the "actions" section of the event,
+// the actions that didn't complete in time being reported as failed
+func (msg *pendingMsg) addActionReports() {
+	reports := make([]*model.ActionReport, 0, len(msg.actionReports))
+	for _, report := range msg.actionReports {
+		if report.IsPending() {
+			// only the type of a pending report can be read
+			report = &model.ActionReport{
+				Type:   report.Type,
+				Status: model.ActionFailed,
+				Error:  "timed out",
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	actions, err := serializers.MarshalActionReports(reports)
+	if err != nil {
+		seclog.Errorf("failed to marshal rule actions: %v", err)
+		return
+	}
+
+	data := append(msg.data[:len(msg.data)-1], `,"actions":`...)
+	data = append(data, actions...)
+	msg.data = append(data, '}')
 }
 
 // APIServer represents a gRPC server in charge of receiving events sent by
@@ -273,24 +322,27 @@ func (a *APIServer) dequeue(now time.Time, cb func(msg *pendingMsg)) {
 	a.queueLock.Lock()
 	defer a.queueLock.Unlock()
 
-	var i int
-	var msg *pendingMsg
-
-	for i != len(a.queue) {
-		msg = a.queue[i]
+	// the messages are ordered by retention period, but the ones waiting for their
+	// actions are kept in the queue without holding back the following ones
+	kept := a.queue[:0]
+	for i, msg := range a.queue {
 		if msg.sendAfter.After(now) {
+			kept = append(kept, a.queue[i:]...)
 			break
 		}
-		cb(msg)
 
-		i++
+		if !msg.isReady(now) {
+			kept = append(kept, msg)
+			continue
+		}
+		cb(msg)
 	}
 
-	if i >= len(a.queue) {
-		a.queue = a.queue[0:0]
-	} else if i > 0 {
-		a.queue = a.queue[i:]
+	// release the references to the sent messages
+	for i := len(kept); i < len(a.queue); i++ {
+		a.queue[i] = nil
 	}
+	a.queue = kept
 }
 
 func (a *APIServer) start(ctx context.Context) {
@@ -301,6 +353,10 @@ func (a *APIServer) start(ctx context.Context) {
 		select {
 		case now := <-ticker.C:
 			a.dequeue(now, func(msg *pendingMsg) {
+				if len(msg.actionReports) > 0 {
+					msg.addActionReports()
+				}
+
 				if msg.extTagsCb != nil {
 					msg.tags = append(msg.tags, msg.extTagsCb()...)
 				}
@@ -410,6 +466,15 @@ func (a *APIServer) SendEvent(rule *rules.Rule, event Event, extTagsCb func() []
 		ruleEvent.AgentContext.PolicyVersion = policy.Version
 	}
 
+	// the reports of the actions performed asynchronously are marshaled once they are completed
+	var actionReports []*model.ActionReport
+	if ev, ok := event.(*model.Event); ok && hasPendingAction(ev.ActionReports) {
+		actionReports, ev.ActionReports = ev.ActionReports, nil
+		defer func() {
+			ev.ActionReports = actionReports
+		}()
+	}
+
 	probeJSON, err := marshalEvent(event, a.probe)
 	if err != nil {
 		seclog.Errorf("failed to marshal event: %v", err)
@@ -427,11 +492,12 @@ func (a *APIServer) SendEvent(rule *rules.Rule, event Event, extTagsCb func() []
 	seclog.Tracef("Sending event message for rule `%s` to security-agent `%s`", rule.ID, string(data))
 
 	msg := &pendingMsg{
-		ruleID:    rule.Definition.ID,
-		data:      data,
-		extTagsCb: extTagsCb,
-		service:   service,
-		sendAfter: time.Now().Add(a.retention),
+		ruleID:        rule.Definition.ID,
+		data:          data,
+		extTagsCb:     extTagsCb,
+		service:       service,
+		sendAfter:     time.Now().Add(a.retention),
+		actionReports: actionReports,
 	}
 
 	msg.tags = append(msg.tags, "rule_id:"+rule.Definition.ID)
@@ -441,6 +507,15 @@ func (a *APIServer) SendEvent(rule *rules.Rule, event Event, extTagsCb func() []
 	a.enqueue(msg)
 }
 
+func hasPendingAction(reports []*model.ActionReport) bool {
+	for _, report := range reports {
+		if report.IsPending() {
+			return true
+		}
+	}
+	return false
+}
+
 func marshalEvent(event Event, probe *sprobe.Probe) ([]byte, error) {
 	if ev, ok := event.(*model.Event); ok {
 		return serializers.MarshalEvent(ev, probe.GetResolvers())
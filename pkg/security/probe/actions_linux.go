@@ -0,0 +1,414 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/security/resolvers/process"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+const (
+	// maxSnapshotProcesses is the maximum number of processes captured by a snapshot action
+	maxSnapshotProcesses = 64
+	// maxSnapshotOpenFiles is the maximum number of open files reported per process by a snapshot action
+	maxSnapshotOpenFiles = 128
+	// actionQueueSize is the maximum number of hash and snapshot actions waiting to be performed
+	actionQueueSize = 256
+	// hashBytesPerSecond is the rate at which the hash actions can read files
+	hashBytesPerSecond = 64 * 1024 * 1024
+)
+
+var (
+	errActionQueueFull = errors.New("too many actions are waiting to be performed")
+	errProbeStopped    = errors.New("the probe is stopped")
+)
+
+type killRateLimiter struct {
+	definition rules.RateLimitDefinition
+	limiter    *rate.Limiter
+}
+
+// actionFunc performs a hash or snapshot action, release frees the resources it holds
+// when it won't be performed
+type actionFunc struct {
+	perform func(report *model.ActionReport)
+	release func()
+}
+
+// asyncAction is a hash or snapshot action performed out of the event handling goroutine
+type asyncAction struct {
+	report *model.ActionReport
+	actionFunc
+}
+
+// HandleActions performs the actions of a rule on the event it matched, and reports their
+// outcome in the event. The kill actions are performed inline, while the hash and snapshot
+// actions are queued to be performed asynchronously, their reports remaining pending until
+// then. The data they need that is lost when a process is killed is captured beforehand:
+// the file to hash is opened and, if the rule kills processes, the snapshot is taken inline.
+func (p *Probe) HandleActions(rule *rules.Rule, ev *model.Event) {
+	ev.ActionReports = nil
+
+	var kills []*rules.KillDefinition
+	for _, action := range rule.Definition.Actions {
+		if action.Kill != nil {
+			kills = append(kills, action.Kill)
+		}
+	}
+
+	for _, action := range rule.Definition.Actions {
+		switch {
+		case action.Hash != nil:
+			fnc, err := p.hashFile(action.Hash, ev)
+			ev.ActionReports = append(ev.ActionReports, p.queueAction(model.HashAction, fnc, err))
+		case action.Snapshot != nil:
+			fnc, err := p.snapshotProcesses(ev)
+			if err != nil || len(kills) == 0 {
+				ev.ActionReports = append(ev.ActionReports, p.queueAction(model.SnapshotAction, fnc, err))
+				continue
+			}
+
+			// the killed processes would be missing from the snapshot
+			report := &model.ActionReport{Type: model.SnapshotAction}
+			fnc.perform(report)
+			ev.ActionReports = append(ev.ActionReports, report)
+		}
+	}
+
+	for _, kill := range kills {
+		ev.ActionReports = append(ev.ActionReports, p.killProcesses(rule, kill, ev))
+	}
+}
+
+// queueAction queues an action to be performed asynchronously, and returns its pending report.
+// The action is dropped if too many actions are already queued, or if the probe is stopped.
+func (p *Probe) queueAction(actionType string, fnc actionFunc, err error) *model.ActionReport {
+	if err != nil {
+		return failedAction(&model.ActionReport{Type: actionType}, err)
+	}
+
+	action := &asyncAction{
+		report:     model.NewPendingActionReport(actionType),
+		actionFunc: fnc,
+	}
+
+	if p.ctx.Err() != nil {
+		action.drop(errProbeStopped)
+		return action.report
+	}
+
+	select {
+	case p.actionQueue <- action:
+	default:
+		p.droppedActions[actionType].Inc()
+		action.drop(errActionQueueFull)
+	}
+
+	return action.report
+}
+
+// drop completes an action that won't be performed
+func (a *asyncAction) drop(err error) {
+	if a.release != nil {
+		a.release()
+	}
+	failedAction(a.report, err)
+	a.report.Complete()
+}
+
+// performActions performs the queued hash and snapshot actions until the probe is stopped,
+// the actions still queued then are dropped
+func (p *Probe) performActions() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case action := <-p.actionQueue:
+			action.perform(action.report)
+			action.report.Complete()
+		case <-p.ctx.Done():
+			for {
+				select {
+				case action := <-p.actionQueue:
+					action.drop(errProbeStopped)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// hashFile opens the file of the event, so that it can still be hashed once its process is
+// killed, and returns the function hashing it. The file is closed by the function, or by its
+// release function if the action is dropped.
+func (p *Probe) hashFile(hash *rules.HashDefinition, ev *model.Event) (actionFunc, error) {
+	value, err := ev.GetFieldValue(ev.GetEventType().String() + ".file.path")
+	if err != nil {
+		return actionFunc{}, err
+	}
+
+	path, _ := value.(string)
+	if path == "" {
+		return actionFunc{}, errors.New("the path of the file isn't resolved")
+	}
+
+	// resolve the path in the mount namespace of the process
+	f, err := os.Open(filepath.Join(utils.RootPath(int32(ev.PIDContext.Pid)), path))
+	if err != nil {
+		return actionFunc{}, err
+	}
+
+	return actionFunc{release: func() { _ = f.Close() }, perform: func(report *model.ActionReport) {
+		defer f.Close()
+
+		report.Path = path
+
+		info, err := f.Stat()
+		if err != nil {
+			failedAction(report, err)
+			return
+		}
+		if !info.Mode().IsRegular() {
+			failedAction(report, errors.New("not a regular file"))
+			return
+		}
+		if info.Size() > hash.MaxFileSize {
+			failedAction(report, fmt.Errorf("file size %d exceeds the maximum of %d bytes", info.Size(), hash.MaxFileSize))
+			return
+		}
+		if !p.hashRateLimiter.AllowN(time.Now(), int(info.Size())) {
+			report.Status = model.ActionRateLimited
+			return
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, io.LimitReader(f, hash.MaxFileSize)); err != nil {
+			failedAction(report, err)
+			return
+		}
+
+		report.SHA256 = hex.EncodeToString(h.Sum(nil))
+		report.Status = model.ActionPerformed
+	}}, nil
+}
+
+// snapshotProcesses returns the function capturing the process tree of the event
+func (p *Probe) snapshotProcesses(ev *model.Event) (actionFunc, error) {
+	root, _ := ev.ResolveProcessCacheEntry()
+	if root == nil || root.Pid == 0 {
+		return actionFunc{}, errors.New("the process of the event isn't resolved")
+	}
+	rootPid := root.Pid
+
+	return actionFunc{perform: func(report *model.ActionReport) {
+		envsWithValue := make(map[string]bool, len(p.Config.Probe.EnvsWithValue))
+		for _, name := range p.Config.Probe.EnvsWithValue {
+			envsWithValue[name] = true
+		}
+
+		// the entries are copied while the process cache is locked, as they can be modified
+		// by the event handling goroutine
+		p.resolvers.ProcessResolver.Walk(func(entry *model.ProcessCacheEntry) {
+			if !entry.ExitTime.IsZero() || !isDescendant(entry, rootPid) {
+				return
+			}
+
+			argv, _ := process.GetProcessArgv(&entry.Process)
+			if p.scrubber != nil {
+				argv, _ = p.scrubber.ScrubCommand(argv)
+			}
+
+			var envs []string
+			if entry.EnvsEntry != nil {
+				envs = filterEnvs(entry.EnvsEntry.Values, envsWithValue)
+			}
+
+			report.Processes = append(report.Processes, &model.SnapshotProcess{
+				Pid:  entry.Pid,
+				PPid: entry.PPid,
+				Path: entry.FileEvent.PathnameStr,
+				Argv: argv,
+				Envs: envs,
+			})
+		})
+
+		sort.Slice(report.Processes, func(i, j int) bool {
+			return report.Processes[i].Pid < report.Processes[j].Pid
+		})
+		if len(report.Processes) > maxSnapshotProcesses {
+			report.Processes = report.Processes[:maxSnapshotProcesses]
+		}
+
+		for _, snapshot := range report.Processes {
+			snapshot.OpenFiles = openFiles(snapshot.Pid)
+		}
+		report.Status = model.ActionPerformed
+	}}, nil
+}
+
+// filterEnvs returns the names of the environment variables, with their values for the ones
+// that are allowed to be exported
+func filterEnvs(values []string, envsWithValue map[string]bool) []string {
+	envs := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, _, found := strings.Cut(value, "="); found && !envsWithValue[name] {
+			envs = append(envs, name)
+		} else {
+			envs = append(envs, value)
+		}
+	}
+	return envs
+}
+
+// isDescendant returns whether the entry is the root process or one of its descendants
+func isDescendant(entry *model.ProcessCacheEntry, rootPid uint32) bool {
+	for ; entry != nil; entry = entry.Ancestor {
+		if entry.Pid == rootPid {
+			return true
+		}
+	}
+	return false
+}
+
+// openFiles returns the sorted paths of the files opened by a process
+func openFiles(pid uint32) []string {
+	fdDir := utils.ProcFDPath(int32(pid))
+
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, fd := range fds {
+		path, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		files = append(files, path)
+	}
+
+	sort.Strings(files)
+	if len(files) > maxSnapshotOpenFiles {
+		files = files[:maxSnapshotOpenFiles]
+	}
+
+	return files
+}
+
+func (p *Probe) killProcesses(rule *rules.Rule, kill *rules.KillDefinition, ev *model.Event) *model.ActionReport {
+	report := &model.ActionReport{
+		Type:   model.KillAction,
+		Signal: kill.Signal,
+		Scope:  kill.Scope,
+	}
+
+	signal, found := model.ParseSignal(kill.Signal)
+	if !found {
+		return failedAction(report, fmt.Errorf("unsupported signal '%s'", kill.Signal))
+	}
+
+	var pids []uint32
+	switch kill.Scope {
+	case rules.KillScopeContainer:
+		containerID := ev.ContainerContext.ID
+		if containerID == "" {
+			return failedAction(report, errors.New("the event doesn't come from a container"))
+		}
+
+		p.resolvers.ProcessResolver.Walk(func(entry *model.ProcessCacheEntry) {
+			if entry.ExitTime.IsZero() && entry.ContainerID == containerID {
+				pids = append(pids, entry.Pid)
+			}
+		})
+		sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	default:
+		pids = []uint32{ev.PIDContext.Pid}
+	}
+
+	if !p.allowKill(rule, kill) {
+		report.Status = model.ActionRateLimited
+		return report
+	}
+
+	var errs *multierror.Error
+	for _, pid := range pids {
+		// never kill init or the agent itself
+		if pid <= 1 || int(pid) == os.Getpid() {
+			continue
+		}
+
+		if err := syscall.Kill(int(pid), syscall.Signal(signal)); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("pid %d: %w", pid, err))
+			continue
+		}
+		report.PIDs = append(report.PIDs, pid)
+	}
+
+	if len(report.PIDs) == 0 {
+		if errs == nil {
+			return failedAction(report, errors.New("no process to kill"))
+		}
+		return failedAction(report, errs)
+	}
+
+	if errs != nil {
+		seclog.Debugf("failed to kill some processes for rule `%s`: %v", rule.ID, errs)
+	}
+	report.Status = model.ActionPerformed
+
+	return report
+}
+
+// allowKill returns whether the rate limit of the kill action of a rule allows it to be performed
+func (p *Probe) allowKill(rule *rules.Rule, kill *rules.KillDefinition) bool {
+	if kill.RateLimit == nil {
+		return true
+	}
+
+	p.killRateLimitersLock.Lock()
+	defer p.killRateLimitersLock.Unlock()
+
+	// the limiter is recreated when the rule is reloaded with a different rate limit
+	limiter, found := p.killRateLimiters[rule.ID]
+	if !found || limiter.definition != *kill.RateLimit {
+		limiter = &killRateLimiter{
+			definition: *kill.RateLimit,
+			limiter:    rate.NewLimiter(rate.Every(kill.RateLimit.Period/time.Duration(kill.RateLimit.Limit)), kill.RateLimit.Limit),
+		}
+		p.killRateLimiters[rule.ID] = limiter
+	}
+
+	return limiter.limiter.Allow()
+}
+
+func failedAction(report *model.ActionReport, err error) *model.ActionReport {
+	report.Status = model.ActionFailed
+	report.Error = err.Error()
+	return report
+}
@@ -114,6 +114,13 @@ type PlatformProbe struct {
 	isRuntimeDiscarded bool
 	constantOffsets    map[string]uint64
 	runtimeCompiled    bool
+
+	// Rule actions section
+	killRateLimiters     map[eval.RuleID]*killRateLimiter
+	killRateLimitersLock sync.Mutex
+	actionQueue          chan *asyncAction
+	droppedActions       map[string]*atomic.Uint64
+	hashRateLimiter      *rate.Limiter
 }
 
 func (p *Probe) detectKernelVersion() error {
@@ -296,6 +303,9 @@ func (p *Probe) Start() error {
 		return err
 	}
 
+	p.wg.Add(1)
+	go p.performActions()
+
 	return p.updateProbes([]eval.EventType{
 		model.ForkEventType.String(),
 		model.ExecEventType.String(),
@@ -442,6 +452,15 @@ func (p *Probe) SendStats() error {
 			}
 		}
 	}
+
+	for action, count := range p.droppedActions {
+		if value := count.Swap(0); value > 0 {
+			if err := p.StatsdClient.Count(metrics.MetricRuleActionDropped, int64(value), []string{"action:" + action}, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricRuleActionDropped metric: %w", err)
+			}
+		}
+	}
+
 	return p.monitor.SendStats()
 }
 
@@ -1435,6 +1454,13 @@ func NewProbe(config *config.Config, opts Opts) (*Probe, error) {
 			erpcRequest:          &erpc.ERPCRequest{},
 			isRuntimeDiscarded:   !opts.DontDiscardRuntime,
 			anomalyDetectionSent: make(map[model.EventType]*atomic.Uint64),
+			killRateLimiters:     make(map[eval.RuleID]*killRateLimiter),
+			actionQueue:          make(chan *asyncAction, actionQueueSize),
+			droppedActions: map[string]*atomic.Uint64{
+				model.HashAction:     atomic.NewUint64(0),
+				model.SnapshotAction: atomic.NewUint64(0),
+			},
+			hashRateLimiter: rate.NewLimiter(hashBytesPerSecond, rules.MaxHashFileSize),
 		},
 	}
 
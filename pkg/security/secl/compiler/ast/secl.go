@@ -185,7 +185,8 @@ type Unary struct {
 type Primary struct {
 	Pos lexer.Position
 
-	Ident         *string     `parser:"@Ident"`
+	Call          *Call       `parser:"@@"`
+	Ident         *string     `parser:"| @Ident"`
 	CIDR          *string     `parser:"| @CIDR"`
 	IP            *string     `parser:"| @IP"`
 	Number        *int        `parser:"| @Int"`
@@ -197,6 +198,16 @@ type Primary struct {
 	SubExpression *Expression `parser:"| \"(\" @@ \")\""`
 }
 
+// Call describes a call to a transform function, like `basename(open.file.path)`.
+// The result of a call can be indexed, like `split(open.file.path, "/")[1]`
+type Call struct {
+	Pos lexer.Position
+
+	Name  string     `parser:"@Ident \"(\""`
+	Args  []*Primary `parser:"[ @@ { \",\" @@ } ] \")\""`
+	Index *int       `parser:"[ \"[\" @Int \"]\" ]"`
+}
+
 // StringMember describes a String based array member
 type StringMember struct {
 	Pos lexer.Position
@@ -347,3 +347,26 @@ func TestCIDRArrayMatches(t *testing.T) {
 
 	print(t, rule)
 }
+
+func TestCall(t *testing.T) {
+	rule, err := parseRule(`basename(open.file.path) == "passwd" && lower(dirname(open.file.path)) == "/etc"`)
+	if err != nil {
+		t.Error(err)
+	}
+
+	print(t, rule)
+}
+
+func TestCallIndex(t *testing.T) {
+	rule, err := parseRule(`split(open.file.path, "/")[-1] in ["passwd", "shadow"] && not (len(process.argv) > 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	call := rule.BooleanExpression.Expression.Comparison.BitOperation.Unary.Primary.Call
+	if call == nil || call.Name != "split" || len(call.Args) != 2 || call.Index == nil || *call.Index != -1 {
+		t.Errorf("unexpected call: %+v", call)
+	}
+}
@@ -1380,6 +1380,226 @@ func TestFieldValues(t *testing.T) {
 	}
 }
 
+func TestTransforms(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/CAT",
+			uid:  1,
+			array: []*testItem{
+				{key: 1, value: "/etc/passwd"},
+				{key: 2, value: "/tmp/test"},
+			},
+		},
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `lower(process.name) == "/usr/bin/cat"`, Expected: true},
+		{Expr: `process.name == "/usr/bin/cat"`, Expected: false},
+		{Expr: `lower(process.name) =~ "/usr/bin/*"`, Expected: true},
+		{Expr: `lower(process.name) in ["/usr/bin/ls", "/usr/bin/cat"]`, Expected: true},
+		{Expr: `lower("ABC") == "abc"`, Expected: true},
+		{Expr: `basename(process.name) == "CAT"`, Expected: true},
+		{Expr: `basename(process.name) in ["cat", "ls"]`, Expected: false},
+		{Expr: `basename(lower(process.name)) in ["cat", "ls"]`, Expected: true},
+		{Expr: `dirname(process.name) == "/usr/bin"`, Expected: true},
+		{Expr: `dirname(open.filename) == "/etc"`, Expected: true},
+		{Expr: `len(process.name) == 12`, Expected: true},
+		{Expr: `len(process.name) > 20`, Expected: false},
+		{Expr: `len(process.array.value) == 2`, Expected: true},
+		{Expr: `len(split(process.name, "/")) == 4`, Expected: true},
+		{Expr: `split(process.name, "/")[1] == "usr"`, Expected: true},
+		{Expr: `split(process.name, "/")[-1] == "CAT"`, Expected: true},
+		{Expr: `split(process.name, "/")[9] == ""`, Expected: true},
+		{Expr: `split(process.name, "/")[-9] == ""`, Expected: true},
+		{Expr: `startswith(process.name, "/usr/")`, Expected: true},
+		{Expr: `startswith(process.name, "/etc/")`, Expected: false},
+		{Expr: `endswith(process.name, "CAT")`, Expected: true},
+		{Expr: `!endswith(process.name, "cat")`, Expected: true},
+		{Expr: `endswith(lower(process.name), "cat")`, Expected: true},
+		{Expr: `startswith(process.array.value, "/tmp/")`, Expected: true},
+		{Expr: `startswith(process.array.value, "/var/")`, Expected: false},
+		{Expr: `basename(process.array.value) in ["test"]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []string{
+		`unknown(process.name) == "cat"`,
+		`lower(process.name, "a") == "cat"`,
+		`startswith(process.name)`,
+		`lower(process.name)[1] == "cat"`,
+		`startswith(process.name, process.argv0)`,
+		`lower(process.uid) == "cat"`,
+		`split(process.array.value, "/")[0] == "cat"`,
+		`lower(process.name) == 1`,
+	}
+
+	for _, expr := range tests {
+		if _, _, err := eval(t, &testEvent{}, expr); err == nil {
+			t.Errorf("should report an error for `%s`", expr)
+		}
+	}
+}
+
+func TestTransformStringCmpOpts(t *testing.T) {
+	state := NewState(&testModel{}, "", nil)
+	call := &ast.Call{Name: "basename"}
+
+	field := &StringEvaluator{
+		EvalFnc: func(ctx *Context) string {
+			return "/usr/bin/CAT"
+		},
+		Field:         "process.name",
+		StringCmpOpts: StringCmpOpts{ScalarCaseInsensitive: true, GlobCaseInsensitive: true},
+	}
+
+	result, err := mapStrings(call, field, state, basename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transformed := result.(*StringEvaluator)
+	if transformed.StringCmpOpts != field.StringCmpOpts {
+		t.Errorf("expected the comparison options of the field, got %+v", transformed.StringCmpOpts)
+	}
+
+	for _, value := range []*StringEvaluator{
+		{Value: "cat", ValueType: ScalarValueType},
+		{Value: "c*", ValueType: GlobValueType},
+	} {
+		evaluator, err := StringEquals(transformed, value, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !evaluator.Eval(NewContext(nil)).(bool) {
+			t.Errorf("expected `%s` to match", value.Value)
+		}
+	}
+}
+
+func TestStringVariableComparison(t *testing.T) {
+	// only fields and the results of transform functions are compared to patterns
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `"${str}" == "aaa"`, Expected: true},
+		{Expr: `"${str}" == ~"a*"`, Expected: false},
+		{Expr: `lower("${str}") == ~"a*"`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, &testEvent{}, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+}
+
+func TestTransformPartial(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/abc",
+		},
+		open: testOpen{
+			filename: "/tmp/xyz",
+		},
+	}
+
+	tests := []struct {
+		Expr        string
+		Field       Field
+		IsDiscarder bool
+	}{
+		{Expr: `basename(open.filename) == "xyz"`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `basename(open.filename) == "abc"`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `startswith(open.filename, "/etc/")`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `startswith(open.filename, "/tmp/")`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `startswith(open.filename, "/etc/") && basename(process.name) == "abc"`, Field: "process.name", IsDiscarder: false},
+		{Expr: `startswith(open.filename, "/tmp/") && basename(process.name) == "cat"`, Field: "process.name", IsDiscarder: true},
+		{Expr: `len(open.filename) > 100`, Field: "open.filename", IsDiscarder: true},
+	}
+
+	ctx := NewContext(event)
+
+	for _, test := range tests {
+		model := &testModel{}
+
+		opts := newOptsWithParams(testConstants, nil)
+
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+		if err := rule.GenPartials(); err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		result, err := rule.PartialEval(ctx, test.Field)
+		if err != nil {
+			t.Fatalf("error while partial evaluating `%s` for `%s`: %s", test.Expr, test.Field, err)
+		}
+
+		if !result != test.IsDiscarder {
+			t.Fatalf("expected result `%t` for `%s`, got `%t`\n%s", test.IsDiscarder, test.Field, !result, test.Expr)
+		}
+	}
+}
+
+func TestTransformFieldValues(t *testing.T) {
+	tests := []struct {
+		Expr     string
+		Field    string
+		Expected []FieldValue
+	}{
+		{Expr: `startswith(open.filename, "/etc/")`, Field: "open.filename", Expected: []FieldValue{{Value: "/etc/*", Type: PatternValueType}}},
+		{Expr: `endswith(open.filename, ".conf")`, Field: "open.filename", Expected: []FieldValue{{Value: "*.conf", Type: PatternValueType}}},
+		{Expr: `startswith(open.filename, "/etc/*")`, Field: "open.filename"},
+		{Expr: `basename(open.filename) == "passwd"`, Field: "open.filename"},
+		{Expr: `startswith(lower(open.filename), "/etc/")`, Field: "open.filename"},
+	}
+
+	for _, test := range tests {
+		model := &testModel{}
+
+		opts := newOptsWithParams(testConstants, nil)
+
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+		values := rule.GetFieldValues(test.Field)
+		if len(values) != len(test.Expected) {
+			t.Fatalf("expected field values %+v, got %+v for `%s`", test.Expected, values, test.Expr)
+		}
+		for i, value := range values {
+			if value.Type != test.Expected[i].Type || value.Value != test.Expected[i].Value {
+				t.Errorf("field values differ %+v != %+v", test.Expected[i], value)
+			}
+		}
+	}
+}
+
 func BenchmarkArray(b *testing.B) {
 	event := &testEvent{
 		process: testProcess{
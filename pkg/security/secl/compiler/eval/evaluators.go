@@ -92,10 +92,12 @@ type StringEvaluator struct {
 	Weight        int
 	OpOverrides   *OpOverrides
 	ValueType     FieldValueType
-	StringCmpOpts StringCmpOpts // only Field evaluator can set this value
+	StringCmpOpts StringCmpOpts // only Field evaluators and the results of transform functions can set this value
 
 	// used during compilation of partial
 	isDeterministic bool
+	// set on the results of transform functions, compared to patterns like fields
+	isTransform bool
 }
 
 // Eval returns the result of the evaluation
@@ -146,10 +148,12 @@ type StringArrayEvaluator struct {
 	Field         Field
 	Weight        int
 	OpOverrides   *OpOverrides
-	StringCmpOpts StringCmpOpts // only Field evaluator can set this value
+	StringCmpOpts StringCmpOpts // only Field evaluators and the results of transform functions can set this value
 
 	// used during compilation of partial
 	isDeterministic bool
+	// set on the results of transform functions, compared to patterns like fields
+	isTransform bool
 }
 
 // Eval returns the result of the evaluation
@@ -308,7 +308,7 @@ func StringEquals(a *StringEvaluator, b *StringEvaluator, state *State) (*BoolEv
 		if a.StringCmpOpts.ScalarCaseInsensitive || b.StringCmpOpts.ScalarCaseInsensitive {
 			op = strings.EqualFold
 		}
-	} else if a.Field != "" {
+	} else if a.Field != "" || a.isTransform {
 		matcher, err := b.ToStringMatcher(a.StringCmpOpts)
 		if err != nil {
 			return nil, err
@@ -319,7 +319,7 @@ func StringEquals(a *StringEvaluator, b *StringEvaluator, state *State) (*BoolEv
 				return matcher.Matches(as)
 			}
 		}
-	} else if b.Field != "" {
+	} else if b.Field != "" || b.isTransform {
 		matcher, err := a.ToStringMatcher(b.StringCmpOpts)
 		if err != nil {
 			return nil, err
@@ -474,7 +474,7 @@ func StringArrayContains(a *StringEvaluator, b *StringArrayEvaluator, state *Sta
 		}
 	} else if a.Field != "" && a.StringCmpOpts.ScalarCaseInsensitive {
 		cmp = strings.EqualFold
-	} else if b.Field != "" {
+	} else if b.Field != "" || b.isTransform {
 		matcher, err := a.ToStringMatcher(b.StringCmpOpts)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,352 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package eval
+
+import (
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/ast"
+)
+
+// transform describes a transform function. The first argument of a transform is the
+// value to transform, the other ones are string literals.
+type transform struct {
+	args    int
+	indexed bool
+	compile func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error)
+}
+
+var transforms = map[string]transform{
+	"lower": {
+		args: 1,
+		compile: func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+			return mapStrings(call, operand, state, strings.ToLower)
+		},
+	},
+	"basename": {
+		args: 1,
+		compile: func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+			return mapStrings(call, operand, state, basename)
+		},
+	},
+	"dirname": {
+		args: 1,
+		compile: func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+			return mapStrings(call, operand, state, dirname)
+		},
+	},
+	"len": {
+		args:    1,
+		compile: lengthTransform,
+	},
+	"split": {
+		args:    2,
+		indexed: true,
+		compile: splitTransform,
+	},
+	"startswith": {
+		args: 2,
+		compile: func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+			return matchStrings(call, operand, args[0], state, strings.HasPrefix)
+		},
+	},
+	"endswith": {
+		args: 2,
+		compile: func(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+			return matchStrings(call, operand, args[0], state, strings.HasSuffix)
+		},
+	},
+}
+
+func basename(s string) string {
+	if s == "" {
+		return ""
+	}
+	return path.Base(s)
+}
+
+func dirname(s string) string {
+	if s == "" {
+		return ""
+	}
+	return path.Dir(s)
+}
+
+func callToEvaluator(call *ast.Call, opts *Opts, state *State) (interface{}, lexer.Position, error) {
+	fn, exists := transforms[call.Name]
+	if !exists {
+		return nil, call.Pos, NewError(call.Pos, "unknown function '%s'", call.Name)
+	}
+
+	if len(call.Args) != fn.args {
+		return nil, call.Pos, NewError(call.Pos, "function '%s' expects %d argument(s), got %d", call.Name, fn.args, len(call.Args))
+	}
+
+	if call.Index != nil && !fn.indexed {
+		return nil, call.Pos, NewError(call.Pos, "the result of function '%s' can't be indexed", call.Name)
+	}
+
+	operand, pos, err := nodeToEvaluator(call.Args[0], opts, state)
+	if err != nil {
+		return nil, pos, err
+	}
+
+	args := make([]string, 0, len(call.Args)-1)
+	for _, arg := range call.Args[1:] {
+		if arg.String == nil {
+			return nil, arg.Pos, NewError(arg.Pos, "function '%s' expects string literals as extra arguments", call.Name)
+		}
+		args = append(args, *arg.String)
+	}
+
+	evaluator, err := fn.compile(call, operand, args, state)
+	if err != nil {
+		return nil, call.Pos, err
+	}
+
+	return evaluator, call.Pos, nil
+}
+
+// mapStrings returns an evaluator applying fnc to a string or to each string of an array. The result
+// keeps the comparison options of the operand and is compared to patterns like a field.
+func mapStrings(call *ast.Call, operand interface{}, state *State, fnc func(string) string) (interface{}, error) {
+	switch operand := operand.(type) {
+	case *StringEvaluator:
+		if operand.EvalFnc == nil {
+			return &StringEvaluator{
+				Value:     fnc(operand.Value),
+				ValueType: ScalarValueType,
+			}, nil
+		}
+
+		ea := operand.EvalFnc
+		return &StringEvaluator{
+			EvalFnc: func(ctx *Context) string {
+				return fnc(ea(ctx))
+			},
+			ValueType:       ScalarValueType,
+			Weight:          operand.Weight + FunctionWeight,
+			StringCmpOpts:   operand.StringCmpOpts,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+			isTransform:     true,
+		}, nil
+	case *StringArrayEvaluator:
+		if operand.EvalFnc == nil {
+			values := make([]string, 0, len(operand.Values))
+			for _, value := range operand.Values {
+				values = append(values, fnc(value))
+			}
+			return &StringArrayEvaluator{
+				Values: values,
+			}, nil
+		}
+
+		ea := operand.EvalFnc
+		return &StringArrayEvaluator{
+			EvalFnc: func(ctx *Context) []string {
+				values := ea(ctx)
+				result := make([]string, 0, len(values))
+				for _, value := range values {
+					result = append(result, fnc(value))
+				}
+				return result
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			StringCmpOpts:   operand.StringCmpOpts,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+			isTransform:     true,
+		}, nil
+	}
+
+	return nil, NewError(call.Pos, "function '%s' expects a %s or an %s of %s", call.Name, reflect.String, reflect.Array, reflect.String)
+}
+
+// lengthTransform returns an evaluator of the length of a string or of the number of elements of an array
+func lengthTransform(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+	switch operand := operand.(type) {
+	case *StringEvaluator:
+		if operand.EvalFnc == nil {
+			return &IntEvaluator{
+				Value: len(operand.Value),
+			}, nil
+		}
+
+		ea := operand.EvalFnc
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int {
+				return len(ea(ctx))
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+		}, nil
+	case *StringArrayEvaluator:
+		if operand.EvalFnc == nil {
+			return &IntEvaluator{
+				Value: len(operand.Values),
+			}, nil
+		}
+
+		ea := operand.EvalFnc
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int {
+				return len(ea(ctx))
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+		}, nil
+	case *IntArrayEvaluator:
+		if operand.EvalFnc == nil {
+			return &IntEvaluator{
+				Value: len(operand.Values),
+			}, nil
+		}
+
+		ea := operand.EvalFnc
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int {
+				return len(ea(ctx))
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+		}, nil
+	}
+
+	return nil, NewError(call.Pos, "function '%s' expects a %s or an %s", call.Name, reflect.String, reflect.Array)
+}
+
+// splitTransform returns an evaluator of the parts of a string, or of one of them when the
+// result is indexed. Negative indexes start from the end, out of range indexes return an empty string.
+func splitTransform(call *ast.Call, operand interface{}, args []string, state *State) (interface{}, error) {
+	str, ok := operand.(*StringEvaluator)
+	if !ok {
+		return nil, NewError(call.Pos, "function '%s' expects a %s", call.Name, reflect.String)
+	}
+	sep := args[0]
+
+	if call.Index == nil {
+		if str.EvalFnc == nil {
+			return &StringArrayEvaluator{
+				Values: strings.Split(str.Value, sep),
+			}, nil
+		}
+
+		ea := str.EvalFnc
+		return &StringArrayEvaluator{
+			EvalFnc: func(ctx *Context) []string {
+				return strings.Split(ea(ctx), sep)
+			},
+			Weight:          str.Weight + FunctionWeight,
+			StringCmpOpts:   str.StringCmpOpts,
+			isDeterministic: str.IsDeterministicFor(state.field),
+			isTransform:     true,
+		}, nil
+	}
+
+	index := *call.Index
+	part := func(s string) string {
+		parts := strings.Split(s, sep)
+		i := index
+		if i < 0 {
+			i += len(parts)
+		}
+		if i < 0 || i >= len(parts) {
+			return ""
+		}
+		return parts[i]
+	}
+
+	return mapStrings(call, str, state, part)
+}
+
+// matchStrings returns an evaluator of whether a string, or one of the strings of an array, matches a literal
+func matchStrings(call *ast.Call, operand interface{}, literal string, state *State, fnc func(s, literal string) bool) (interface{}, error) {
+	switch operand := operand.(type) {
+	case *StringEvaluator:
+		if operand.EvalFnc == nil {
+			return &BoolEvaluator{
+				Value: fnc(operand.Value, literal),
+			}, nil
+		}
+
+		if err := updateMatchFieldValues(call, operand.Field, literal, state); err != nil {
+			return nil, err
+		}
+
+		ea := operand.EvalFnc
+		return &BoolEvaluator{
+			EvalFnc: func(ctx *Context) bool {
+				return fnc(ea(ctx), literal)
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+		}, nil
+	case *StringArrayEvaluator:
+		match := func(values []string) bool {
+			for _, value := range values {
+				if fnc(value, literal) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if operand.EvalFnc == nil {
+			return &BoolEvaluator{
+				Value: match(operand.Values),
+			}, nil
+		}
+
+		if err := updateMatchFieldValues(call, operand.Field, literal, state); err != nil {
+			return nil, err
+		}
+
+		ea := operand.EvalFnc
+		return &BoolEvaluator{
+			EvalFnc: func(ctx *Context) bool {
+				return match(ea(ctx))
+			},
+			Weight:          operand.Weight + FunctionWeight,
+			isDeterministic: operand.IsDeterministicFor(state.field),
+		}, nil
+	}
+
+	return nil, NewError(call.Pos, "function '%s' expects a %s or an %s of %s", call.Name, reflect.String, reflect.Array, reflect.String)
+}
+
+// updateMatchFieldValues registers the pattern equivalent to startswith or endswith on a field so
+// that it can be used as an approver. Patterns on paths are globs where `*` doesn't match `/`, only
+// the prefixes of directories have an equivalent glob.
+func updateMatchFieldValues(call *ast.Call, field Field, literal string, state *State) error {
+	if field == "" || literal == "" || strings.Contains(literal, "*") {
+		return nil
+	}
+
+	var pattern string
+	switch {
+	case strings.HasSuffix(field, "path"):
+		if call.Name != "startswith" || !strings.HasSuffix(literal, "/") {
+			return nil
+		}
+		pattern = literal + "**"
+	case call.Name == "startswith":
+		pattern = literal + "*"
+	default:
+		pattern = "*" + literal
+	}
+
+	value := FieldValue{Value: pattern, Type: PatternValueType}
+
+	// the pattern is only a hint for the approvers, it shouldn't prevent the rule from loading
+	if err := state.model.ValidateField(field, value); err != nil {
+		return nil
+	}
+
+	return state.UpdateFieldValues(field, value)
+}
@@ -837,6 +837,12 @@ func initSignalConstants() {
 	}
 }
 
+// ParseSignal returns the value of a signal from its name, SIGKILL for example
+func ParseSignal(name string) (int, bool) {
+	value, found := signalConstants[name]
+	return value, found
+}
+
 func initPipeBufFlagConstants() {
 	for k, v := range PipeBufFlagConstants {
 		SECLConstants[k] = &eval.IntEvaluator{Value: int(v)}
@@ -245,6 +245,8 @@ type Event struct {
 	TimestampRaw uint64         `field:"event.timestamp,handler:ResolveEventTimestamp" json:"-"`      // SECLDoc[event.timestamp] Definition:`Timestamp of the event`
 	Timestamp    time.Time      `field:"-"`
 	Rules        []*MatchedRule `field:"-"`
+	// ActionReports are the outcome of the actions of the rule the event is sent for
+	ActionReports []*ActionReport `field:"-"`
 
 	// context shared with all events
 	ProcessCacheEntry      *ProcessCacheEntry     `field:"-" json:"-" platform:"linux"`
@@ -451,6 +453,86 @@ type MatchedRule struct {
 	PolicyVersion string
 }
 
+// Rule action types
+const (
+	KillAction     = "kill"
+	HashAction     = "hash"
+	SnapshotAction = "snapshot"
+)
+
+// Rule action statuses
+const (
+	// ActionPerformed is the status of a successful action
+	ActionPerformed = "performed"
+	// ActionRateLimited is the status of an action skipped because of its rate limit
+	ActionRateLimited = "rate_limited"
+	// ActionFailed is the status of an action that couldn't be performed
+	ActionFailed = "failed"
+	// ActionPending is the status of an action still being performed asynchronously
+	ActionPending = "pending"
+)
+
+// ActionReport describes the outcome of a rule action on an event
+type ActionReport struct {
+	Type   string
+	Status string
+	Error  string
+
+	// kill action
+	Signal string
+	Scope  string
+	PIDs   []uint32
+
+	// hash action
+	Path   string
+	SHA256 string
+
+	// snapshot action
+	Processes []*SnapshotProcess
+
+	// done is closed once an asynchronous action is completed, it is nil for synchronous actions
+	done chan struct{}
+}
+
+// NewPendingActionReport returns the report of an action performed asynchronously. The report
+// must not be read, except for its type, until Complete is called.
+func NewPendingActionReport(actionType string) *ActionReport {
+	return &ActionReport{
+		Type:   actionType,
+		Status: ActionPending,
+		done:   make(chan struct{}),
+	}
+}
+
+// Complete marks an asynchronous action as completed
+func (r *ActionReport) Complete() {
+	close(r.done)
+}
+
+// IsPending returns whether an asynchronous action is still being performed
+func (r *ActionReport) IsPending() bool {
+	if r.done == nil {
+		return false
+	}
+
+	select {
+	case <-r.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// SnapshotProcess describes a process captured by the snapshot action
+type SnapshotProcess struct {
+	Pid       uint32
+	PPid      uint32
+	Path      string
+	Argv      []string
+	Envs      []string
+	OpenFiles []string
+}
+
 // NewMatchedRule return a new MatchedRule instance
 func NewMatchedRule(ruleID, ruleVersion string, ruleTags map[string]string, policyName, policyVersion string) *MatchedRule {
 	return &MatchedRule{
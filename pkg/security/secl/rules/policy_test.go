@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/go-multierror"
@@ -692,3 +693,108 @@ func TestActionSetVariableInvalid(t *testing.T) {
 		}
 	})
 }
+
+func TestRuleActions(t *testing.T) {
+	testPolicy := &PolicyDef{
+		Rules: []*RuleDefinition{{
+			ID:         "test_rule",
+			Expression: `open.file.path == "/tmp/test"`,
+			Actions: []ActionDefinition{{
+				Hash: &HashDefinition{},
+			}, {
+				Snapshot: &SnapshotDefinition{},
+			}, {
+				Kill: &KillDefinition{
+					RateLimit: &RateLimitDefinition{
+						Limit:  5,
+						Period: time.Minute,
+					},
+				},
+			}},
+		}},
+	}
+
+	evaluationSet, errs := loadPolicyIntoProbeEvaluationRuleSet(t, testPolicy, PolicyLoaderOpts{})
+	assert.Nil(t, errs.ErrorOrNil())
+
+	rule := evaluationSet.RuleSets[DefaultRuleSetTagValue].GetRules()["test_rule"]
+	if !assert.NotNil(t, rule) {
+		return
+	}
+
+	actions := rule.Definition.Actions
+	assert.Len(t, actions, 3)
+	assert.Equal(t, int64(DefaultHashMaxFileSize), actions[0].Hash.MaxFileSize)
+	assert.NotNil(t, actions[1].Snapshot)
+	assert.Equal(t, DefaultKillSignal, actions[2].Kill.Signal)
+	assert.Equal(t, KillScopeProcess, actions[2].Kill.Scope)
+	assert.Equal(t, RateLimitDefinition{Limit: 5, Period: time.Minute}, *actions[2].Kill.RateLimit)
+}
+
+func TestRuleActionsInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		action     ActionDefinition
+	}{
+		{
+			name:   "empty-action",
+			action: ActionDefinition{},
+		},
+		{
+			name: "multiple-sections",
+			action: ActionDefinition{
+				Kill:     &KillDefinition{},
+				Snapshot: &SnapshotDefinition{},
+			},
+		},
+		{
+			name:   "unknown-signal",
+			action: ActionDefinition{Kill: &KillDefinition{Signal: "SIGFOO"}},
+		},
+		{
+			name:   "invalid-scope",
+			action: ActionDefinition{Kill: &KillDefinition{Scope: "host"}},
+		},
+		{
+			name:   "invalid-rate-limit",
+			action: ActionDefinition{Kill: &KillDefinition{RateLimit: &RateLimitDefinition{Limit: 1}}},
+		},
+		{
+			name:   "negative-max-file-size",
+			action: ActionDefinition{Hash: &HashDefinition{MaxFileSize: -1}},
+		},
+		{
+			name:   "too-large-max-file-size",
+			action: ActionDefinition{Hash: &HashDefinition{MaxFileSize: MaxHashFileSize + 1}},
+		},
+		{
+			name:       "hash-without-file",
+			expression: `bind.addr.port == 80`,
+			action:     ActionDefinition{Hash: &HashDefinition{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expression := test.expression
+			if expression == "" {
+				expression = `open.file.path == "/tmp/test"`
+			}
+
+			testPolicy := &PolicyDef{
+				Rules: []*RuleDefinition{{
+					ID:         "test_rule",
+					Expression: expression,
+					Actions:    []ActionDefinition{test.action},
+				}},
+			}
+
+			if _, err := loadPolicyIntoProbeEvaluationRuleSet(t, testPolicy, PolicyLoaderOpts{}); err == nil {
+				t.Error("policy should fail to load")
+			} else {
+				t.Log(err)
+			}
+		})
+	}
+}
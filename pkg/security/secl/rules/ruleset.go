@@ -116,13 +116,32 @@ func (rd *RuleDefinition) MergeWith(rd2 *RuleDefinition) error {
 
 // ActionDefinition describes a rule action section
 type ActionDefinition struct {
-	Set *SetDefinition `yaml:"set"`
+	Set      *SetDefinition      `yaml:"set"`
+	Kill     *KillDefinition     `yaml:"kill"`
+	Hash     *HashDefinition     `yaml:"hash"`
+	Snapshot *SnapshotDefinition `yaml:"snapshot"`
 }
 
 // Check returns an error if the action in invalid
 func (a *ActionDefinition) Check() error {
-	if a.Set == nil {
-		return errors.New("missing 'set' section in action")
+	defined := 0
+	for _, section := range []bool{a.Set != nil, a.Kill != nil, a.Hash != nil, a.Snapshot != nil} {
+		if section {
+			defined++
+		}
+	}
+
+	switch {
+	case defined == 0:
+		return errors.New("missing 'set', 'kill', 'hash' or 'snapshot' section in action")
+	case defined > 1:
+		return errors.New("only one of 'set', 'kill', 'hash' and 'snapshot' can be defined in an action")
+	case a.Kill != nil:
+		return a.Kill.Check()
+	case a.Hash != nil:
+		return a.Hash.Check()
+	case a.Snapshot != nil:
+		return nil
 	}
 
 	if a.Set.Name == "" {
@@ -136,6 +155,93 @@ func (a *ActionDefinition) Check() error {
 	return nil
 }
 
+// Kill action scopes
+const (
+	// KillScopeProcess kills the process of the event
+	KillScopeProcess = "process"
+	// KillScopeContainer kills all the processes of the container of the event
+	KillScopeContainer = "container"
+
+	// DefaultKillSignal is the signal sent when the kill action doesn't specify one
+	DefaultKillSignal = "SIGKILL"
+)
+
+// KillDefinition describes the 'kill' section of a rule action
+type KillDefinition struct {
+	Signal    string               `yaml:"signal"`
+	Scope     string               `yaml:"scope"`
+	RateLimit *RateLimitDefinition `yaml:"rate_limit"`
+}
+
+// Check returns an error if the kill action is invalid, and applies its defaults
+func (k *KillDefinition) Check() error {
+	if k.Signal == "" {
+		k.Signal = DefaultKillSignal
+	}
+	if _, found := model.ParseSignal(k.Signal); !found {
+		return fmt.Errorf("unsupported signal '%s'", k.Signal)
+	}
+
+	switch k.Scope {
+	case "":
+		k.Scope = KillScopeProcess
+	case KillScopeProcess, KillScopeContainer:
+	default:
+		return fmt.Errorf("invalid kill scope '%s', must be '%s' or '%s'", k.Scope, KillScopeProcess, KillScopeContainer)
+	}
+
+	if k.RateLimit != nil {
+		return k.RateLimit.Check()
+	}
+
+	return nil
+}
+
+// RateLimitDefinition limits the number of times an action is performed by a rule
+type RateLimitDefinition struct {
+	Limit  int           `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+}
+
+// Check returns an error if the rate limit is invalid
+func (r *RateLimitDefinition) Check() error {
+	if r.Limit <= 0 {
+		return errors.New("rate limit 'limit' must be positive")
+	}
+	if r.Period <= 0 {
+		return errors.New("rate limit 'period' must be positive")
+	}
+	return nil
+}
+
+// DefaultHashMaxFileSize is the size of the largest file hashed when the hash action doesn't specify one
+const DefaultHashMaxFileSize = 64 * 1024 * 1024
+
+// MaxHashFileSize is the size of the largest file a hash action can be configured to hash
+const MaxHashFileSize = 256 * 1024 * 1024
+
+// HashDefinition describes the 'hash' section of a rule action
+type HashDefinition struct {
+	MaxFileSize int64 `yaml:"max_file_size"`
+}
+
+// Check returns an error if the hash action is invalid, and applies its defaults
+func (h *HashDefinition) Check() error {
+	if h.MaxFileSize < 0 {
+		return errors.New("'max_file_size' can't be negative")
+	}
+	if h.MaxFileSize > MaxHashFileSize {
+		return fmt.Errorf("'max_file_size' can't exceed %d bytes", MaxHashFileSize)
+	}
+	if h.MaxFileSize == 0 {
+		h.MaxFileSize = DefaultHashMaxFileSize
+	}
+	return nil
+}
+
+// SnapshotDefinition describes the 'snapshot' section of a rule action
+type SnapshotDefinition struct{}
+
 // Scope describes the scope variables
 type Scope string
 
@@ -441,6 +547,15 @@ func (rs *RuleSet) AddRule(parsingContext *ast.ParsingContext, ruleDef *RuleDefi
 		return nil, &ErrRuleLoad{Definition: ruleDef, Err: err}
 	}
 
+	for _, action := range ruleDef.Actions {
+		// the hash action applies to the file of the event
+		if action.Hash != nil {
+			if _, err := rs.eventCtor().GetFieldType(eventType + ".file.path"); err != nil {
+				return nil, &ErrRuleLoad{Definition: ruleDef, Err: fmt.Errorf("hash action requires an event with a file, `%s` events don't have one", eventType)}
+			}
+		}
+	}
+
 	// ignore event types not supported
 	if _, exists := rs.opts.EventTypeEnabled["*"]; !exists {
 		if _, exists := rs.opts.EventTypeEnabled[eventType]; !exists {
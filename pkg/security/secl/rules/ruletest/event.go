@@ -0,0 +1,280 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build unix
+// +build unix
+
+// Package ruletest implements the unit tests of SECL rules against event fixtures
+// and the replay of recorded events against a rule set, without a kernel
+package ruletest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+// ErrUnsupportedEventType is returned for the events rules can't apply to,
+// like the custom events sent by the agent itself
+var ErrUnsupportedEventType = errors.New("unsupported event type")
+
+// EventData defines the structure used to represent an event with SECL field values
+type EventData struct {
+	Type   eval.EventType
+	Values map[string]interface{}
+}
+
+// Event is an event decoded from a fixture or a recorded event stream
+type Event struct {
+	eval.Event
+	// RuleID is the rule that triggered a recorded event, if known
+	RuleID string
+	// Ignored lists the fields of a serialized event that have no SECL equivalent
+	Ignored []string
+}
+
+// DecodeEvent decodes an event from either the EventData format, or the format of
+// the events sent by the runtime security agent
+func DecodeEvent(data []byte) (*Event, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	if _, found := raw["evt"]; found {
+		return decodeSerializedEvent(raw)
+	}
+
+	var eventData EventData
+	for key, value := range raw {
+		switch strings.ToLower(key) {
+		case "type":
+			eventType, ok := value.(string)
+			if !ok {
+				return nil, errors.New("event type must be a string")
+			}
+			eventData.Type = eventType
+		case "values":
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("event values must be an object")
+			}
+			eventData.Values = values
+		}
+	}
+
+	event, err := NewEvent(eventData.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SetFieldValues(event, eventData.Values); err != nil {
+		return nil, err
+	}
+
+	return &Event{Event: event}, nil
+}
+
+// NewEvent returns an empty event of the given type
+func NewEvent(eventType eval.EventType) (eval.Event, error) {
+	kind := model.ParseEvalEventType(eventType)
+	if kind == model.UnknownEventType || kind >= model.MaxKernelEventType {
+		return nil, fmt.Errorf("%w `%s`", ErrUnsupportedEventType, eventType)
+	}
+
+	m := &model.Model{}
+	event := m.NewDefaultEventWithType(kind)
+	event.Init()
+
+	return event, nil
+}
+
+// SetFieldValues sets SECL field values on an event
+func SetFieldValues(event eval.Event, values map[string]interface{}) error {
+	for field, value := range values {
+		v, err := fieldValue(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for `%s`: %w", field, err)
+		}
+		if err := event.SetFieldValue(field, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldValue converts a decoded JSON or YAML value to the type expected by the accessors
+func fieldValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return nil, err
+		}
+		return int(i), nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{}, nil
+		}
+		switch v[0].(type) {
+		case string:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, errors.New("mixed types in array")
+				}
+				values = append(values, s)
+			}
+			return values, nil
+		default:
+			values := make([]int, 0, len(v))
+			for _, item := range v {
+				i, err := fieldValue(item)
+				if err != nil {
+					return nil, err
+				}
+				n, ok := i.(int)
+				if !ok {
+					return nil, errors.New("arrays must contain strings or integers")
+				}
+				values = append(values, n)
+			}
+			return values, nil
+		}
+	default:
+		return value, nil
+	}
+}
+
+// decodeSerializedEvent sets the SECL fields matching the attributes of a serialized event.
+// Attributes without a SECL equivalent, like the resolved timestamps, are ignored.
+func decodeSerializedEvent(raw map[string]interface{}) (*Event, error) {
+	evt, _ := raw["evt"].(map[string]interface{})
+	eventType, _ := evt["name"].(string)
+	if eventType == "" {
+		return nil, errors.New("serialized event without `evt.name`")
+	}
+
+	event, err := NewEvent(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Event{Event: event}
+	if agent, ok := raw["agent"].(map[string]interface{}); ok {
+		result.RuleID, _ = agent["rule_id"].(string)
+	}
+
+	leaves := make(map[string]interface{})
+	for key, value := range raw {
+		switch key {
+		case "evt", "agent":
+			continue
+		}
+		flatten(key, value, leaves)
+	}
+
+	for path, value := range leaves {
+		fields := serializedFieldNames(eventType, path)
+
+		v, err := fieldValue(value)
+		if err == nil {
+			for _, field := range fields {
+				if err = event.SetFieldValue(field, v); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil || len(fields) == 0 {
+			result.Ignored = append(result.Ignored, path)
+		}
+	}
+	sort.Strings(result.Ignored)
+
+	return result, nil
+}
+
+// flatten collects the leaves of a serialized event by dotted path. Arrays of
+// objects, like the process ancestors, can't be represented as field values.
+func flatten(path string, value interface{}, leaves map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(path+"."+key, child, leaves)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				return
+			}
+		}
+		leaves[path] = v
+	default:
+		leaves[path] = v
+	}
+}
+
+// serializedFieldNames returns the SECL fields matching the path of a serialized attribute
+func serializedFieldNames(eventType eval.EventType, path string) []string {
+	parts := strings.Split(path, ".")
+
+	switch parts[0] {
+	case "file":
+		// file attributes are the ones of the event, open.file.path for example
+		return []string{eventType + "." + path}
+	case "container":
+		return []string{path}
+	case "process":
+		if len(parts) > 2 && parts[1] == "container" {
+			return []string{strings.Join(parts[1:], ".")}
+		}
+
+		fields := []string{processFieldName(parts)}
+		// the process of an exec event is the executed one
+		if eventType == "exec" && (len(parts) < 2 || parts[1] != "parent") {
+			fields = append(fields, "exec"+strings.TrimPrefix(fields[0], "process"))
+		}
+		return fields
+	default:
+		return []string{path}
+	}
+}
+
+// processFieldName maps the attributes of a serialized process to the SECL process fields
+func processFieldName(parts []string) string {
+	mapped := make([]string, 0, len(parts))
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch {
+		case part == "executable":
+			mapped = append(mapped, "file")
+		case part == "interpreter":
+			mapped = append(mapped, "interpreter", "file")
+		case part == "credentials":
+			// credentials are process fields in SECL
+		case last && part == "args":
+			mapped = append(mapped, "argv")
+		case last && part == "envs":
+			mapped = append(mapped, "envp")
+		default:
+			mapped = append(mapped, part)
+		}
+	}
+	return strings.Join(mapped, ".")
+}
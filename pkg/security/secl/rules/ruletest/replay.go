@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build unix
+// +build unix
+
+package ruletest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+)
+
+const maxEventSize = 16 * 1024 * 1024
+
+// ReplayedEvent describes a recorded event that no longer triggers the rule it was recorded with
+type ReplayedEvent struct {
+	Line    int           `json:"line"`
+	RuleID  eval.RuleID   `json:"rule_id"`
+	Matched []eval.RuleID `json:"matched"`
+}
+
+// ReplayReport is the result of the replay of an event stream
+type ReplayReport struct {
+	Events int `json:"events"`
+	// Skipped is the number of events of types without rules, like the agent events
+	Skipped int `json:"skipped"`
+	// Errors are the events that couldn't be decoded
+	Errors []string `json:"errors,omitempty"`
+	// Matches is the number of events triggering each rule
+	Matches map[eval.RuleID]int `json:"matches"`
+	// Lost lists the recorded events that no longer trigger their rule
+	Lost []ReplayedEvent `json:"lost,omitempty"`
+}
+
+// Failed returns whether the replay found events that couldn't be decoded or lost their rule
+func (report *ReplayReport) Failed() bool {
+	return len(report.Errors) > 0 || len(report.Lost) > 0
+}
+
+// Replay evaluates a stream of events, one JSON event per line, against the rule set.
+// Events recorded with the ID of the rule that triggered them are checked to still trigger it.
+func (r *Runner) Replay(reader io.Reader) (*ReplayReport, error) {
+	report := &ReplayReport{
+		Matches: make(map[eval.RuleID]int),
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		report.Events++
+
+		event, err := DecodeEvent(data)
+		if errors.Is(err, ErrUnsupportedEventType) {
+			report.Skipped++
+			continue
+		} else if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		matched := r.Evaluate(event.Event)
+		found := false
+		for _, id := range matched {
+			report.Matches[id]++
+			if id == event.RuleID {
+				found = true
+			}
+		}
+
+		if event.RuleID != "" && !found {
+			report.Lost = append(report.Lost, ReplayedEvent{
+				Line:    line,
+				RuleID:  event.RuleID,
+				Matched: matched,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Write prints the number of events triggering each rule and the lost events
+func (report *ReplayReport) Write(w io.Writer) {
+	fmt.Fprintf(w, "%d events replayed, %d skipped\n", report.Events, report.Skipped)
+
+	ids := make([]eval.RuleID, 0, len(report.Matches))
+	for id := range report.Matches {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if len(ids) > 0 {
+		fmt.Fprintln(w, "\nMatches:")
+		for _, id := range ids {
+			fmt.Fprintf(w, "  %s: %d\n", id, report.Matches[id])
+		}
+	}
+
+	if len(report.Lost) > 0 {
+		fmt.Fprintln(w, "\nEvents no longer triggering their rule:")
+		for _, lost := range report.Lost {
+			fmt.Fprintf(w, "  line %d: rule `%s`, matched %v\n", lost.Line, lost.RuleID, lost.Matched)
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintln(w, "\nErrors:")
+		for _, err := range report.Errors {
+			fmt.Fprintf(w, "  %s\n", err)
+		}
+	}
+}
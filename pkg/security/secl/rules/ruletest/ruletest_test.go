@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package ruletest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+)
+
+const policiesDir = "testdata/policies"
+
+func newTestRunner(t *testing.T) *Runner {
+	ruleOpts, evalOpts := rules.NewEvalOpts(map[eval.EventType]bool{"*": true})
+
+	provider, err := rules.NewPoliciesDirProvider(policiesDir, false)
+	require.NoError(t, err)
+
+	ruleSet := rules.NewRuleSet(&model.Model{}, model.NewDefaultEvent, ruleOpts, evalOpts)
+	evaluationSet, err := rules.NewEvaluationSet([]*rules.RuleSet{ruleSet})
+	require.NoError(t, err)
+	require.NoError(t, evaluationSet.LoadPolicies(rules.NewPolicyLoader(provider), rules.PolicyLoaderOpts{}).ErrorOrNil())
+
+	return NewRunner(ruleSet)
+}
+
+func TestDecodeEvent(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(policiesDir, "events/cat_shadow.json"))
+	require.NoError(t, err)
+
+	event, err := DecodeEvent(data)
+	require.NoError(t, err)
+	assert.Equal(t, "open", event.GetType())
+	assert.Empty(t, event.RuleID)
+
+	value, err := event.GetFieldValue("open.file.path")
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/shadow", value)
+
+	data, err = os.ReadFile(filepath.Join(policiesDir, "events/nginx_shell.json"))
+	require.NoError(t, err)
+
+	event, err = DecodeEvent(data)
+	require.NoError(t, err)
+	assert.Equal(t, "exec", event.GetType())
+	assert.Equal(t, "shell_from_web_server", event.RuleID)
+
+	for field, expected := range map[string]interface{}{
+		"process.file.path":        "/usr/bin/sh",
+		"exec.file.name":           "sh",
+		"process.user":             "www-data",
+		"process.parent.file.name": "nginx",
+		"process.argv":             []string{"-c", "id"},
+		"exec.argv0":               "sh",
+		"container.id":             "3f8a9d7c1b2e",
+	} {
+		value, err := event.GetFieldValue(field)
+		require.NoError(t, err, field)
+		assert.Equal(t, expected, value, field)
+	}
+	assert.Contains(t, event.Ignored, "date")
+
+	_, err = DecodeEvent([]byte(`{"evt": {"name": "ruleset_loaded"}}`))
+	assert.ErrorIs(t, err, ErrUnsupportedEventType)
+
+	_, err = DecodeEvent([]byte(`{"type": "open", "values": {"open.file.path": 1}}`))
+	assert.Error(t, err)
+}
+
+func TestRunDir(t *testing.T) {
+	runner := newTestRunner(t)
+
+	report, err := runner.RunDir(policiesDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Passed)
+	assert.Equal(t, 0, report.Failed)
+
+	_, err = runner.RunDir(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRunFileFailures(t *testing.T) {
+	runner := newTestRunner(t)
+
+	testFile := filepath.Join(t.TempDir(), "failures_test.yaml")
+	require.NoError(t, os.WriteFile(testFile, []byte(`
+tests:
+  - name: cat reads passwd
+    type: open
+    values:
+      open.file.path: /etc/passwd
+      process.file.name: cat
+    match: [shadow_read]
+  - name: chown reads shadow
+    type: open
+    values:
+      open.file.path: /etc/shadow
+      process.file.name: chown
+    no_match: [shadow_read]
+  - name: wrong event type
+    type: exec
+    match: [shadow_read, unknown_rule]
+  - name: missing fixture
+    event: missing.json
+    match: [shadow_read]
+`), 0644))
+
+	results, err := runner.RunFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for _, result := range results {
+		assert.False(t, result.Passed(), result.Name)
+	}
+
+	require.Len(t, results[0].Failures, 1)
+	failure := results[0].Failures[0]
+	assert.Equal(t, "shadow_read", failure.RuleID)
+	assert.True(t, failure.ExpectedMatch)
+	assert.Equal(t, []MacroValue{{ID: "password_tools", Value: false}}, failure.Macros)
+	assert.Equal(t, []FieldValue{{Field: "open.file.path", Value: "/etc/passwd"}}, failure.Fields)
+
+	require.Len(t, results[1].Failures, 1)
+	failure = results[1].Failures[0]
+	assert.False(t, failure.ExpectedMatch)
+	assert.Equal(t, []MacroValue{{ID: "password_tools", Value: false}}, failure.Macros)
+	assert.Equal(t, []FieldValue{{Field: "open.file.path", Value: "/etc/shadow"}}, failure.Fields)
+
+	require.Len(t, results[2].Failures, 2)
+	assert.Equal(t, "rule applies to `open` events, not `exec` events", results[2].Failures[0].Reason)
+	assert.Equal(t, "rule not found in the policies", results[2].Failures[1].Reason)
+
+	assert.Contains(t, results[3].Error, "missing.json")
+
+	var out strings.Builder
+	(&Report{Results: results, Failed: len(results)}).Write(&out)
+	assert.Contains(t, out.String(), "FAIL  failures_test.yaml: cat reads passwd")
+	assert.Contains(t, out.String(), "macro `password_tools` is false")
+	assert.Contains(t, out.String(), "field `open.file.path` doesn't match: \"/etc/passwd\"")
+}
+
+func TestReplay(t *testing.T) {
+	runner := newTestRunner(t)
+
+	nginxShell, err := os.ReadFile(filepath.Join(policiesDir, "events/nginx_shell.json"))
+	require.NoError(t, err)
+	// recorded with a rule the event doesn't trigger anymore
+	lost := strings.Replace(string(nginxShell), `"nginx"`, `"apache2"`, -1)
+
+	stream := strings.Join([]string{
+		strings.Join(strings.Fields(string(nginxShell)), ""),
+		"",
+		`{"type": "open", "values": {"open.file.path": "/etc/gshadow", "process.file.name": "cat"}}`,
+		`{"evt": {"name": "ruleset_loaded"}, "agent": {"rule_id": "ruleset_loaded"}}`,
+		strings.Join(strings.Fields(lost), ""),
+		`{"type": "open"`,
+	}, "\n")
+
+	report, err := runner.Replay(strings.NewReader(stream))
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Events)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, map[eval.RuleID]int{"shell_from_web_server": 1, "shadow_read": 1}, report.Matches)
+	assert.Equal(t, []ReplayedEvent{{Line: 5, RuleID: "shell_from_web_server", Matched: []eval.RuleID{}}}, report.Lost)
+	require.Len(t, report.Errors, 1)
+	assert.True(t, strings.HasPrefix(report.Errors[0], "line 6:"))
+	assert.True(t, report.Failed())
+}
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build unix
+// +build unix
+
+package ruletest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+)
+
+var identRegexp = regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_.]*`)
+
+// Runner evaluates events against a rule set and reports the rules they trigger
+type Runner struct {
+	ruleSet *rules.RuleSet
+	matched map[eval.RuleID]bool
+}
+
+// NewRunner returns a new Runner for the rule set
+func NewRunner(ruleSet *rules.RuleSet) *Runner {
+	r := &Runner{
+		ruleSet: ruleSet,
+		matched: make(map[eval.RuleID]bool),
+	}
+	ruleSet.AddListener(r)
+	return r
+}
+
+// RuleMatch implements the rules.RuleSetListener interface
+func (r *Runner) RuleMatch(rule *rules.Rule, event eval.Event) {
+	r.matched[rule.ID] = true
+}
+
+// EventDiscarderFound implements the rules.RuleSetListener interface
+func (r *Runner) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field eval.Field, eventType eval.EventType) {
+}
+
+// Evaluate returns the sorted IDs of the rules triggered by the event
+func (r *Runner) Evaluate(event eval.Event) []eval.RuleID {
+	for id := range r.matched {
+		delete(r.matched, id)
+	}
+
+	r.ruleSet.Evaluate(event)
+
+	matched := make([]eval.RuleID, 0, len(r.matched))
+	for id := range r.matched {
+		matched = append(matched, id)
+	}
+	sort.Strings(matched)
+
+	return matched
+}
+
+// RuleFailure describes a rule that didn't behave as expected on an event
+type RuleFailure struct {
+	RuleID        eval.RuleID `json:"rule_id"`
+	ExpectedMatch bool        `json:"expected_match"`
+	// Reason is set when the rule can't be evaluated on the event at all
+	Reason string `json:"reason,omitempty"`
+	// Macros are the values of the boolean macros used by the rule
+	Macros []MacroValue `json:"macros,omitempty"`
+	// Fields are the fields of the rule that explain the outcome, with their value in the event
+	Fields []FieldValue `json:"fields,omitempty"`
+}
+
+// MacroValue is the value of a macro for an event
+type MacroValue struct {
+	ID    eval.MacroID `json:"id"`
+	Value bool         `json:"value"`
+}
+
+// FieldValue is the value of a field in an event
+type FieldValue struct {
+	Field eval.Field  `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+// explain returns the values of the macros used by a rule, and the fields that
+// evaluate to the outcome of the rule on the event: the ones that evaluate to
+// false when the rule was expected to match, the ones that evaluate to true otherwise.
+func (r *Runner) explain(ruleID eval.RuleID, event eval.Event, expectedMatch bool) *RuleFailure {
+	failure := &RuleFailure{
+		RuleID:        ruleID,
+		ExpectedMatch: expectedMatch,
+	}
+
+	rule, found := r.ruleSet.GetRules()[ruleID]
+	if !found {
+		failure.Reason = "rule not found in the policies"
+		return failure
+	}
+
+	if eventType, err := rules.GetRuleEventType(rule.Rule); err == nil && eventType != event.GetType() {
+		failure.Reason = fmt.Sprintf("rule applies to `%s` events, not `%s` events", eventType, event.GetType())
+		return failure
+	}
+
+	outcome := !expectedMatch
+	ctx := eval.NewContext(event)
+
+	seen := make(map[string]bool)
+	for _, ident := range identRegexp.FindAllString(rule.Expression, -1) {
+		if seen[ident] {
+			continue
+		}
+		seen[ident] = true
+
+		macro := rule.Opts.MacroStore.Get(ident)
+		if macro == nil {
+			continue
+		}
+
+		// only boolean macros can be evaluated on their own, the
+		// values of list macros are covered by the fields using them
+		evaluator, ok := macro.GetEvaluator().Value.(*eval.BoolEvaluator)
+		if !ok {
+			continue
+		}
+
+		value := evaluator.Value
+		if evaluator.EvalFnc != nil {
+			value = evaluator.EvalFnc(ctx)
+		}
+		failure.Macros = append(failure.Macros, MacroValue{ID: ident, Value: value})
+	}
+
+	fields := rule.GetEvaluator().GetFields()
+	sort.Strings(fields)
+	for _, field := range fields {
+		partial := rule.GetPartialEval(field)
+		if partial == nil {
+			continue
+		}
+
+		if partial(ctx) == outcome {
+			value, err := event.GetFieldValue(field)
+			if err != nil {
+				value = fmt.Sprintf("<%v>", err)
+			}
+			failure.Fields = append(failure.Fields, FieldValue{Field: field, Value: value})
+		}
+	}
+
+	return failure
+}
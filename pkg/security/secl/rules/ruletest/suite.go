@@ -0,0 +1,237 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build unix
+// +build unix
+
+package ruletest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+)
+
+// TestFilePattern is the pattern of the test files of a policy directory
+const TestFilePattern = "*_test.yaml"
+
+// TestFile describes the test cases of a test file
+type TestFile struct {
+	Tests []*TestCase `yaml:"tests"`
+}
+
+// TestCase describes an event and the rules it should and should not trigger
+type TestCase struct {
+	Name string `yaml:"name"`
+	// Event is the path of the event fixture, relative to the test file
+	Event string `yaml:"event"`
+	// Type is the type of the event when there is no fixture
+	Type eval.EventType `yaml:"type"`
+	// Values are SECL field values set on the event, on top of the fixture
+	Values map[string]interface{} `yaml:"values"`
+	// Match lists the rules the event should trigger
+	Match []eval.RuleID `yaml:"match"`
+	// NoMatch lists the rules the event should not trigger
+	NoMatch []eval.RuleID `yaml:"no_match"`
+}
+
+// CaseResult is the result of a test case
+type CaseResult struct {
+	File     string         `json:"file"`
+	Name     string         `json:"name"`
+	Error    string         `json:"error,omitempty"`
+	Matched  []eval.RuleID  `json:"matched"`
+	Failures []*RuleFailure `json:"failures,omitempty"`
+}
+
+// Passed returns whether the test case passed
+func (c *CaseResult) Passed() bool {
+	return c.Error == "" && len(c.Failures) == 0
+}
+
+// Report is the result of the test files of a policy directory
+type Report struct {
+	Results []*CaseResult `json:"results"`
+	Passed  int           `json:"passed"`
+	Failed  int           `json:"failed"`
+}
+
+// RunDir runs the test files of a policy directory
+func (r *Runner) RunDir(dir string) (*Report, error) {
+	files, err := filepath.Glob(filepath.Join(dir, TestFilePattern))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test file matching %s in %s", TestFilePattern, dir)
+	}
+
+	report := &Report{}
+	for _, file := range files {
+		results, err := r.RunFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range results {
+			if result.Passed() {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+		}
+		report.Results = append(report.Results, results...)
+	}
+
+	return report, nil
+}
+
+// RunFile runs the test cases of a test file
+func (r *Runner) RunFile(file string) ([]*CaseResult, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var testFile TestFile
+	if err := yaml.UnmarshalStrict(content, &testFile); err != nil {
+		return nil, fmt.Errorf("invalid test file %s: %w", file, err)
+	}
+
+	results := make([]*CaseResult, 0, len(testFile.Tests))
+	for i, test := range testFile.Tests {
+		result := &CaseResult{
+			File: filepath.Base(file),
+			Name: test.Name,
+		}
+		if result.Name == "" {
+			result.Name = fmt.Sprintf("test #%d", i+1)
+		}
+
+		if err := r.runCase(filepath.Dir(file), test, result); err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *Runner) runCase(dir string, test *TestCase, result *CaseResult) error {
+	if len(test.Match) == 0 && len(test.NoMatch) == 0 {
+		return errors.New("the test case should list the rules to `match` or not (`no_match`)")
+	}
+
+	event, err := test.newEvent(dir)
+	if err != nil {
+		return err
+	}
+
+	result.Matched = r.Evaluate(event)
+	matched := make(map[eval.RuleID]bool, len(result.Matched))
+	for _, id := range result.Matched {
+		matched[id] = true
+	}
+
+	for _, id := range test.Match {
+		if !matched[id] {
+			result.Failures = append(result.Failures, r.explain(id, event, true))
+		}
+	}
+	for _, id := range test.NoMatch {
+		if matched[id] {
+			result.Failures = append(result.Failures, r.explain(id, event, false))
+		}
+	}
+
+	return nil
+}
+
+// newEvent returns the event of a test case
+func (test *TestCase) newEvent(dir string) (eval.Event, error) {
+	var event eval.Event
+
+	switch {
+	case test.Event != "":
+		path := test.Event
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded, err := DecodeEvent(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event fixture %s: %w", test.Event, err)
+		}
+		event = decoded.Event
+	case test.Type != "":
+		var err error
+		if event, err = NewEvent(test.Type); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("the test case should have an `event` fixture or an event `type`")
+	}
+
+	if err := SetFieldValues(event, test.Values); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// Write prints the results of the test cases, with the macros and fields
+// explaining the failures
+func (report *Report) Write(w io.Writer) {
+	for _, result := range report.Results {
+		if result.Passed() {
+			fmt.Fprintf(w, "PASS  %s: %s\n", result.File, result.Name)
+			continue
+		}
+
+		fmt.Fprintf(w, "FAIL  %s: %s\n", result.File, result.Name)
+		if result.Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", result.Error)
+			continue
+		}
+
+		for _, failure := range result.Failures {
+			if failure.ExpectedMatch {
+				fmt.Fprintf(w, "      rule `%s` was expected to match\n", failure.RuleID)
+			} else {
+				fmt.Fprintf(w, "      rule `%s` was not expected to match\n", failure.RuleID)
+			}
+
+			if failure.Reason != "" {
+				fmt.Fprintf(w, "        %s\n", failure.Reason)
+			}
+			for _, macro := range failure.Macros {
+				fmt.Fprintf(w, "        macro `%s` is %t\n", macro.ID, macro.Value)
+			}
+			for _, field := range failure.Fields {
+				fmt.Fprintf(w, "        field `%s` %s: %#v\n", field.Field, fieldVerb(failure.ExpectedMatch), field.Value)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d failed\n", report.Passed, report.Failed)
+}
+
+func fieldVerb(expectedMatch bool) string {
+	if expectedMatch {
+		return "doesn't match"
+	}
+	return "matches"
+}
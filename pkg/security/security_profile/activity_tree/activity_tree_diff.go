@@ -0,0 +1,351 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package activity_tree
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+// DiffStatus is the status of a node in the diff of two activity trees
+type DiffStatus string
+
+const (
+	// DiffAdded is the status of a node only found in the new activity tree
+	DiffAdded DiffStatus = "added"
+	// DiffRemoved is the status of a node only found in the base activity tree
+	DiffRemoved DiffStatus = "removed"
+	// DiffUnchanged is the status of a process node found in both activity trees
+	DiffUnchanged DiffStatus = "unchanged"
+)
+
+var (
+	diffAddedColor         = "#2e7d32"
+	diffAddedFillColor     = "#c8e6c9"
+	diffRemovedColor       = "#c62828"
+	diffRemovedFillColor   = "#ffcdd2"
+	diffUnchangedColor     = "#9e9e9e"
+	diffUnchangedFillColor = "white"
+)
+
+// NodeDiff is a file, DNS name or socket added to or removed from a process
+type NodeDiff struct {
+	Name   string     `json:"name"`
+	Status DiffStatus `json:"status"`
+}
+
+// ProcessNodeDiff is the diff of the process nodes with the same executable in two activity trees.
+// Unchanged processes are only reported when some of their descendants changed.
+type ProcessNodeDiff struct {
+	Path     string             `json:"path"`
+	Status   DiffStatus         `json:"status"`
+	Files    []*NodeDiff        `json:"files,omitempty"`
+	DNSNames []*NodeDiff        `json:"dns_names,omitempty"`
+	Sockets  []*NodeDiff        `json:"sockets,omitempty"`
+	Children []*ProcessNodeDiff `json:"children,omitempty"`
+}
+
+// DiffSummary counts the nodes added and removed between two activity trees
+type DiffSummary struct {
+	AddedProcesses   int `json:"added_processes"`
+	RemovedProcesses int `json:"removed_processes"`
+	AddedFiles       int `json:"added_files"`
+	RemovedFiles     int `json:"removed_files"`
+	AddedDNSNames    int `json:"added_dns_names"`
+	RemovedDNSNames  int `json:"removed_dns_names"`
+	AddedSockets     int `json:"added_sockets"`
+	RemovedSockets   int `json:"removed_sockets"`
+}
+
+// ActivityTreeDiff is the structural diff of two activity trees
+type ActivityTreeDiff struct {
+	Summary   DiffSummary        `json:"summary"`
+	Processes []*ProcessNodeDiff `json:"processes,omitempty"`
+}
+
+// HasDrift returns whether the activity trees differ
+func (d *ActivityTreeDiff) HasDrift() bool {
+	return d.Summary != DiffSummary{}
+}
+
+// Diff computes the diff between the activity tree and a new one. Process nodes are matched
+// by executable path, the processes with the same executable under the same parent are merged.
+func (at *ActivityTree) Diff(newTree *ActivityTree) *ActivityTreeDiff {
+	d := &ActivityTreeDiff{}
+	d.Processes = diffProcessNodes(at.ProcessNodes, newTree.ProcessNodes, &d.Summary)
+	return d
+}
+
+type processNodeGroup struct {
+	base []*ProcessNode
+	new  []*ProcessNode
+}
+
+func diffProcessNodes(base []*ProcessNode, new []*ProcessNode, summary *DiffSummary) []*ProcessNodeDiff {
+	groups := make(map[string]*processNodeGroup)
+	getGroup := func(path string) *processNodeGroup {
+		group, ok := groups[path]
+		if !ok {
+			group = &processNodeGroup{}
+			groups[path] = group
+		}
+		return group
+	}
+	for _, pn := range base {
+		group := getGroup(pn.Process.FileEvent.PathnameStr)
+		group.base = append(group.base, pn)
+	}
+	for _, pn := range new {
+		group := getGroup(pn.Process.FileEvent.PathnameStr)
+		group.new = append(group.new, pn)
+	}
+
+	paths := make([]string, 0, len(groups))
+	for path := range groups {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var diffs []*ProcessNodeDiff
+	for _, path := range paths {
+		group := groups[path]
+
+		pnd := &ProcessNodeDiff{
+			Path:   path,
+			Status: DiffUnchanged,
+		}
+		switch {
+		case len(group.base) == 0:
+			pnd.Status = DiffAdded
+			summary.AddedProcesses++
+		case len(group.new) == 0:
+			pnd.Status = DiffRemoved
+			summary.RemovedProcesses++
+		}
+
+		pnd.Files = diffKeys(collectFiles(group.base), collectFiles(group.new), &summary.AddedFiles, &summary.RemovedFiles)
+		pnd.DNSNames = diffKeys(collectDNSNames(group.base), collectDNSNames(group.new), &summary.AddedDNSNames, &summary.RemovedDNSNames)
+		pnd.Sockets = diffKeys(collectSockets(group.base), collectSockets(group.new), &summary.AddedSockets, &summary.RemovedSockets)
+		pnd.Children = diffProcessNodes(collectChildren(group.base), collectChildren(group.new), summary)
+
+		if pnd.Status == DiffUnchanged && len(pnd.Files) == 0 && len(pnd.DNSNames) == 0 && len(pnd.Sockets) == 0 && len(pnd.Children) == 0 {
+			continue
+		}
+		diffs = append(diffs, pnd)
+	}
+
+	return diffs
+}
+
+// diffKeys returns the sorted keys only found in one of the sets, and counts them
+func diffKeys(base map[string]bool, new map[string]bool, added *int, removed *int) []*NodeDiff {
+	var diffs []*NodeDiff
+	for key := range new {
+		if !base[key] {
+			diffs = append(diffs, &NodeDiff{Name: key, Status: DiffAdded})
+			*added++
+		}
+	}
+	for key := range base {
+		if !new[key] {
+			diffs = append(diffs, &NodeDiff{Name: key, Status: DiffRemoved})
+			*removed++
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+func collectChildren(nodes []*ProcessNode) []*ProcessNode {
+	var children []*ProcessNode
+	for _, pn := range nodes {
+		children = append(children, pn.Children...)
+	}
+	return children
+}
+
+func collectFiles(nodes []*ProcessNode) map[string]bool {
+	files := make(map[string]bool)
+	for _, pn := range nodes {
+		for _, fn := range pn.Files {
+			fn.collectPaths("", files)
+		}
+	}
+	return files
+}
+
+// collectPaths adds the paths of the files accessed in the file tree, intermediate
+// directories are only added when they were accessed too
+func (fn *FileNode) collectPaths(prefix string, paths map[string]bool) {
+	path := prefix + "/" + fn.Name
+	if fn.File != nil || len(fn.Children) == 0 {
+		paths[path] = true
+	}
+	for _, child := range fn.Children {
+		child.collectPaths(path, paths)
+	}
+}
+
+func collectDNSNames(nodes []*ProcessNode) map[string]bool {
+	names := make(map[string]bool)
+	for _, pn := range nodes {
+		for name := range pn.DNSNames {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func collectSockets(nodes []*ProcessNode) map[string]bool {
+	sockets := make(map[string]bool)
+	for _, pn := range nodes {
+		for _, sock := range pn.Sockets {
+			if len(sock.Bind) == 0 {
+				sockets[sock.Family] = true
+			}
+			for _, bind := range sock.Bind {
+				sockets[fmt.Sprintf("%s [%s]:%d", sock.Family, bind.IP, bind.Port)] = true
+			}
+		}
+	}
+	return sockets
+}
+
+func diffMarker(status DiffStatus) string {
+	switch status {
+	case DiffAdded:
+		return "+"
+	case DiffRemoved:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// Write prints the diff, with the added nodes prefixed by '+' and the removed ones by '-'
+func (d *ActivityTreeDiff) Write(w io.Writer) {
+	for _, pnd := range d.Processes {
+		pnd.write(w, "")
+	}
+
+	s := d.Summary
+	if len(d.Processes) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "processes: +%d -%d, files: +%d -%d, dns names: +%d -%d, sockets: +%d -%d\n",
+		s.AddedProcesses, s.RemovedProcesses,
+		s.AddedFiles, s.RemovedFiles,
+		s.AddedDNSNames, s.RemovedDNSNames,
+		s.AddedSockets, s.RemovedSockets,
+	)
+}
+
+func (pnd *ProcessNodeDiff) write(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s %sprocess %s\n", diffMarker(pnd.Status), indent, pnd.Path)
+
+	for _, kind := range []struct {
+		label string
+		nodes []*NodeDiff
+	}{
+		{label: "file", nodes: pnd.Files},
+		{label: "dns", nodes: pnd.DNSNames},
+		{label: "socket", nodes: pnd.Sockets},
+	} {
+		for _, node := range kind.nodes {
+			fmt.Fprintf(w, "%s %s    %s %s\n", diffMarker(node.Status), indent, kind.label, node.Name)
+		}
+	}
+
+	for _, child := range pnd.Children {
+		child.write(w, indent+"    ")
+	}
+}
+
+func diffColors(status DiffStatus) (string, string) {
+	switch status {
+	case DiffAdded:
+		return diffAddedColor, diffAddedFillColor
+	case DiffRemoved:
+		return diffRemovedColor, diffRemovedFillColor
+	default:
+		return diffUnchangedColor, diffUnchangedFillColor
+	}
+}
+
+// PrepareGraphData returns a graph from the diff, the added nodes are green, the removed ones red
+func (d *ActivityTreeDiff) PrepareGraphData(title string) utils.Graph {
+	data := utils.Graph{
+		Title: title,
+		Nodes: make(map[utils.GraphID]utils.Node),
+	}
+
+	for _, pnd := range d.Processes {
+		pnd.prepareGraphNode(&data)
+	}
+
+	return data
+}
+
+func (pnd *ProcessNodeDiff) prepareGraphNode(data *utils.Graph) utils.GraphID {
+	processID := utils.NewGraphID(utils.NewNodeIDFromPtr(pnd))
+	color, fillColor := diffColors(pnd.Status)
+	data.Nodes[processID] = utils.Node{
+		ID:        processID,
+		Label:     pnd.Path,
+		Size:      60,
+		Color:     color,
+		FillColor: fillColor,
+		Shape:     processShape,
+	}
+
+	for _, kind := range []struct {
+		nodes []*NodeDiff
+		shape string
+	}{
+		{nodes: pnd.Files, shape: fileShape},
+		{nodes: pnd.DNSNames, shape: networkShape},
+		{nodes: pnd.Sockets, shape: networkShape},
+	} {
+		for _, node := range kind.nodes {
+			nodeID := processID.Derive(utils.NewNodeIDFromPtr(node))
+			color, fillColor := diffColors(node.Status)
+			data.Nodes[nodeID] = utils.Node{
+				ID:        nodeID,
+				Label:     node.Name,
+				Size:      30,
+				Color:     color,
+				FillColor: fillColor,
+				Shape:     kind.shape,
+			}
+			data.Edges = append(data.Edges, utils.Edge{
+				From:  processID,
+				To:    nodeID,
+				Color: color,
+			})
+		}
+	}
+
+	for _, child := range pnd.Children {
+		childID := child.prepareGraphNode(data)
+		color, _ := diffColors(child.Status)
+		data.Edges = append(data.Edges, utils.Edge{
+			From:  processID,
+			To:    childID,
+			Color: color,
+		})
+	}
+
+	return processID
+}
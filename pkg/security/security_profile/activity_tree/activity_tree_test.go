@@ -66,3 +66,95 @@ func TestInsertFileEvent(t *testing.T) {
 
 	assert.Equal(t, expectedDebugOuput, debugOutput)
 }
+
+func newDiffTestProcessNode(path string, files []string, dnsNames []string, children ...*ProcessNode) *ProcessNode {
+	pn := &ProcessNode{
+		Files:    make(map[string]*FileNode),
+		DNSNames: make(map[string]*DNSNode),
+		Children: children,
+	}
+	pn.Process.FileEvent.PathnameStr = path
+
+	stats := NewActivityTreeNodeStats()
+	for _, file := range files {
+		event := &model.Event{
+			Open: model.OpenEvent{
+				File: model.FileEvent{
+					IsPathnameStrResolved: true,
+					PathnameStr:           file,
+				},
+			},
+			FieldHandlers: &model.DefaultFieldHandlers{},
+		}
+		pn.InsertFileEvent(&event.Open.File, event, Unknown, stats, false)
+	}
+	for _, name := range dnsNames {
+		pn.DNSNames[name] = NewDNSNode(&model.DNSEvent{Name: name}, nil, Unknown)
+	}
+
+	return pn
+}
+
+func TestActivityTreeDiff(t *testing.T) {
+	base := &ActivityTree{
+		ProcessNodes: []*ProcessNode{
+			newDiffTestProcessNode("/usr/sbin/nginx", []string{"/etc/nginx/nginx.conf", "/var/log/nginx/access.log"}, nil,
+				newDiffTestProcessNode("/usr/sbin/nginx", []string{"/var/www/index.html"}, nil),
+			),
+			newDiffTestProcessNode("/usr/bin/cron", nil, nil),
+		},
+	}
+	newTree := &ActivityTree{
+		ProcessNodes: []*ProcessNode{
+			newDiffTestProcessNode("/usr/sbin/nginx", []string{"/etc/nginx/nginx.conf", "/var/log/nginx/error.log"}, nil,
+				newDiffTestProcessNode("/usr/sbin/nginx", []string{"/var/www/index.html"}, nil),
+				newDiffTestProcessNode("/bin/sh", nil, []string{"evil.example.com"}),
+			),
+			newDiffTestProcessNode("/usr/bin/cron", nil, nil),
+		},
+	}
+
+	diff := base.Diff(newTree)
+	assert.True(t, diff.HasDrift())
+	assert.Equal(t, DiffSummary{
+		AddedProcesses: 1,
+		AddedFiles:     1,
+		RemovedFiles:   1,
+		AddedDNSNames:  1,
+	}, diff.Summary)
+
+	// unchanged processes without drift are not reported
+	if !assert.Len(t, diff.Processes, 1) {
+		return
+	}
+	nginx := diff.Processes[0]
+	assert.Equal(t, "/usr/sbin/nginx", nginx.Path)
+	assert.Equal(t, DiffUnchanged, nginx.Status)
+	assert.Equal(t, []*NodeDiff{
+		{Name: "/var/log/nginx/access.log", Status: DiffRemoved},
+		{Name: "/var/log/nginx/error.log", Status: DiffAdded},
+	}, nginx.Files)
+	assert.Equal(t, []*ProcessNodeDiff{{
+		Path:     "/bin/sh",
+		Status:   DiffAdded,
+		DNSNames: []*NodeDiff{{Name: "evil.example.com", Status: DiffAdded}},
+	}}, nginx.Children)
+
+	var builder strings.Builder
+	diff.Write(&builder)
+	assert.Equal(t, strings.TrimLeft(`
+  process /usr/sbin/nginx
+-     file /var/log/nginx/access.log
++     file /var/log/nginx/error.log
++     process /bin/sh
++         dns evil.example.com
+
+processes: +1 -0, files: +1 -1, dns names: +1 -0, sockets: +0 -0
+`, "\n"), builder.String())
+
+	graph := diff.PrepareGraphData("diff")
+	assert.Len(t, graph.Nodes, 5)
+	assert.Len(t, graph.Edges, 4)
+
+	assert.False(t, base.Diff(base).HasDrift())
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package dump
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+)
+
+// Diff computes the structural diff between the activity dump and a new activity dump of the same workload
+func (ad *ActivityDump) Diff(newDump *ActivityDump) *activity_tree.ActivityTreeDiff {
+	ad.Lock()
+	defer ad.Unlock()
+	newDump.Lock()
+	defer newDump.Unlock()
+
+	return ad.ActivityTree.Diff(newDump.ActivityTree)
+}
+
+// DiffTitle returns the title describing the diff between the activity dump and a new one
+func (ad *ActivityDump) DiffTitle(newDump *ActivityDump) string {
+	return fmt.Sprintf("%s -> %s", ad.GetSelectorStr(), newDump.GetSelectorStr())
+}
+
+// EncodeDiffDOT encodes the diff of two activity dumps in the DOT format
+func EncodeDiffDOT(title string, diff *activity_tree.ActivityTreeDiff) (*bytes.Buffer, error) {
+	data := diff.PrepareGraphData(title)
+	t := template.Must(template.New("tmpl").Parse(ActivityDumpGraphTemplate))
+	raw := bytes.NewBuffer(nil)
+	if err := t.Execute(raw, data); err != nil {
+		return nil, fmt.Errorf("couldn't encode diff in %s: %w", config.Dot, err)
+	}
+	return raw, nil
+}
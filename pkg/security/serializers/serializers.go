@@ -543,6 +543,46 @@ type SecurityProfileContextSerializer struct {
 	Tags []string `json:"tags"`
 }
 
+// SnapshotProcessSerializer serializes a process captured by the snapshot action
+// easyjson:json
+type SnapshotProcessSerializer struct {
+	// Process ID
+	Pid uint32 `json:"pid"`
+	// Parent process ID
+	PPid uint32 `json:"ppid,omitempty"`
+	// Path of the process executable
+	Path string `json:"path,omitempty"`
+	// Command line arguments
+	Args []string `json:"args,omitempty"`
+	// Environment variables of the process
+	Envs []string `json:"envs,omitempty"`
+	// Files opened by the process
+	OpenFiles []string `json:"open_files,omitempty"`
+}
+
+// ActionReportSerializer serializes the outcome of a rule action
+// easyjson:json
+type ActionReportSerializer struct {
+	// Type of the action
+	Type string `json:"type" jsonschema:"enum=kill,enum=hash,enum=snapshot"`
+	// Status of the action
+	Status string `json:"status" jsonschema:"enum=performed,enum=rate_limited,enum=failed"`
+	// Error encountered while performing the action
+	Error string `json:"error,omitempty"`
+	// Signal sent by the kill action
+	Signal string `json:"signal,omitempty"`
+	// Scope of the kill action
+	Scope string `json:"scope,omitempty"`
+	// Processes killed by the kill action
+	PIDs []uint32 `json:"pids,omitempty"`
+	// Path of the file hashed by the hash action
+	Path string `json:"path,omitempty"`
+	// SHA256 of the file hashed by the hash action
+	SHA256 string `json:"sha256,omitempty"`
+	// Processes captured by the snapshot action
+	Processes []*SnapshotProcessSerializer `json:"processes,omitempty"`
+}
+
 // EventSerializer serializes an event to JSON
 // easyjson:json
 type EventSerializer struct {
@@ -567,7 +607,36 @@ type EventSerializer struct {
 	*DDContextSerializer                    `json:"dd,omitempty"`
 	*ContainerContextSerializer             `json:"container,omitempty"`
 	*SecurityProfileContextSerializer       `json:"security_profile,omitempty"`
-	Date                                    utils.EasyjsonTime `json:"date,omitempty"`
+	Actions                                 []*ActionReportSerializer `json:"actions,omitempty"`
+	Date                                    utils.EasyjsonTime        `json:"date,omitempty"`
+}
+
+func newActionReportSerializers(reports []*model.ActionReport) []*ActionReportSerializer {
+	serializers := make([]*ActionReportSerializer, 0, len(reports))
+	for _, report := range reports {
+		s := &ActionReportSerializer{
+			Type:   report.Type,
+			Status: report.Status,
+			Error:  report.Error,
+			Signal: report.Signal,
+			Scope:  report.Scope,
+			PIDs:   report.PIDs,
+			Path:   report.Path,
+			SHA256: report.SHA256,
+		}
+		for _, process := range report.Processes {
+			s.Processes = append(s.Processes, &SnapshotProcessSerializer{
+				Pid:       process.Pid,
+				PPid:      process.PPid,
+				Path:      process.Path,
+				Args:      process.Argv,
+				Envs:      process.Envs,
+				OpenFiles: process.OpenFiles,
+			})
+		}
+		serializers = append(serializers, s)
+	}
+	return serializers
 }
 
 func newSecurityProfileContextSerializer(e *model.SecurityProfileContext) *SecurityProfileContextSerializer {
@@ -1061,6 +1130,23 @@ func MarshalEvent(event *model.Event, probe *resolvers.Resolvers) ([]byte, error
 	return w.BuildBytes()
 }
 
+// MarshalActionReports marshals the outcome of rule actions, to be added to the "actions" section
+// of an event marshaled before the actions were completed
+func MarshalActionReports(reports []*model.ActionReport) ([]byte, error) {
+	w := &jwriter.Writer{
+		Flags: jwriter.NilSliceAsEmpty | jwriter.NilMapAsEmpty,
+	}
+	w.RawByte('[')
+	for i, s := range newActionReportSerializers(reports) {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		s.MarshalEasyJSON(w)
+	}
+	w.RawByte(']')
+	return w.BuildBytes()
+}
+
 func MarshalCustomEvent(event *events.CustomEvent) ([]byte, error) {
 	w := &jwriter.Writer{
 		Flags: jwriter.NilSliceAsEmpty | jwriter.NilMapAsEmpty,
@@ -1110,6 +1196,10 @@ func NewEventSerializer(event *model.Event, resolvers *resolvers.Resolvers) *Eve
 		s.SecurityProfileContextSerializer = newSecurityProfileContextSerializer(&event.SecurityProfileContext)
 	}
 
+	if len(event.ActionReports) > 0 {
+		s.Actions = newActionReportSerializers(event.ActionReports)
+	}
+
 	switch eventType {
 	case model.FileChmodEventType:
 		s.FileEventSerializer = &FileEventSerializer{
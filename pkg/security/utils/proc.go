@@ -106,6 +106,11 @@ func ProcExePath(pid int32) string {
 	return util.HostProc(strconv.FormatUint(uint64(pid), 10), "exe")
 }
 
+// ProcFDPath returns the path to the file descriptors directory of a pid in /proc
+func ProcFDPath(pid int32) string {
+	return util.HostProc(strconv.FormatInt(int64(pid), 10), "fd")
+}
+
 // StatusPath returns the path to the status file of a pid in /proc
 func StatusPath(pid int32) string {
 	return util.HostProc(strconv.FormatInt(int64(pid), 10), "status")
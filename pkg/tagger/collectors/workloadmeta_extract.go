@@ -137,6 +137,10 @@ func (c *WorkloadMetaCollector) processEvents(evBundle workloadmeta.EventBundle)
 				tagInfos = append(tagInfos, c.handleECSTask(ev)...)
 			case workloadmeta.KindContainerImageMetadata:
 				tagInfos = append(tagInfos, c.handleContainerImage(ev)...)
+			case workloadmeta.KindProcess:
+				tagInfos = append(tagInfos, c.handleProcess(ev)...)
+			case workloadmeta.KindNomadAllocation:
+				tagInfos = append(tagInfos, c.handleNomadAllocation(ev)...)
 			default:
 				log.Errorf("cannot handle event for entity %q with kind %q", entityID.ID, entityID.Kind)
 			}
@@ -195,6 +199,7 @@ func (c *WorkloadMetaCollector) handleContainer(ev workloadmeta.Event) []*TagInf
 	}
 
 	c.labelsToTags(container.Labels, tags)
+	c.extractionRules.Apply(utils.ContainerLabelSource, container.Labels, tags)
 
 	// standard tags from environment
 	c.extractFromMapWithFn(container.EnvVars, standardEnvKeys, tags.AddStandard)
@@ -207,6 +212,7 @@ func (c *WorkloadMetaCollector) handleContainer(ev workloadmeta.Event) []*TagInf
 	for envName, envValue := range container.EnvVars {
 		utils.AddMetadataAsTags(envName, envValue, c.containerEnvAsTags, c.globContainerEnvLabels, tags)
 	}
+	c.extractionRules.Apply(utils.ContainerEnvSource, container.EnvVars, tags)
 
 	// static tags for ECS and EKS Fargate containers
 	for tag, value := range c.staticTags {
@@ -262,6 +268,7 @@ func (c *WorkloadMetaCollector) handleContainerImage(ev workloadmeta.Event) []*T
 	tags.AddLow("architecture", image.Architecture)
 
 	c.labelsToTags(image.Labels, tags)
+	c.extractionRules.Apply(utils.ImageLabelSource, image.Labels, tags)
 
 	low, orch, high, standard := tags.Compute()
 	return []*TagInfo{
@@ -276,6 +283,27 @@ func (c *WorkloadMetaCollector) handleContainerImage(ev workloadmeta.Event) []*T
 	}
 }
 
+func (c *WorkloadMetaCollector) handleProcess(ev workloadmeta.Event) []*TagInfo {
+	process := ev.Entity.(*workloadmeta.Process)
+
+	tags := utils.NewTagList()
+	tags.AddStandard(tagKeyService, process.Service)
+	tags.AddStandard(tagKeyEnv, process.Env)
+	tags.AddStandard(tagKeyVersion, process.Version)
+
+	low, orch, high, standard := tags.Compute()
+	return []*TagInfo{
+		{
+			Source:               processSource,
+			Entity:               buildTaggerEntityID(process.EntityID),
+			HighCardTags:         high,
+			OrchestratorCardTags: orch,
+			LowCardTags:          low,
+			StandardTags:         standard,
+		},
+	}
+}
+
 func (c *WorkloadMetaCollector) labelsToTags(labels map[string]string, tags *utils.TagList) {
 	// standard tags from labels
 	c.extractFromMapWithFn(labels, standardDockerLabels, tags.AddStandard)
@@ -318,6 +346,11 @@ func (c *WorkloadMetaCollector) handleKubePod(ev workloadmeta.Event) []*TagInfo
 		utils.AddMetadataAsTags(name, value, c.nsLabelsAsTags, c.globNsLabels, tags)
 	}
 
+	c.extractionRules.Apply(utils.PodLabelSource, pod.Labels, tags)
+	c.extractionRules.Apply(utils.PodAnnotationSource, pod.Annotations, tags)
+	c.extractionRules.Apply(utils.NamespaceLabelSource, pod.NamespaceLabels, tags)
+	c.extractionRules.ApplyOwners(pod.OwnersLabels, tags)
+
 	kubeServiceDisabled := false
 	for _, disabledTag := range config.Datadog.GetStringSlice("kubernetes_ad_tags_disabled") {
 		if disabledTag == "kube_service" {
@@ -463,6 +496,49 @@ func (c *WorkloadMetaCollector) handleECSTask(ev workloadmeta.Event) []*TagInfo
 	return tagInfos
 }
 
+func (c *WorkloadMetaCollector) handleNomadAllocation(ev workloadmeta.Event) []*TagInfo {
+	alloc := ev.Entity.(*workloadmeta.NomadAllocation)
+
+	// tag names match the ones extracted from the NOMAD_* environment
+	// variables of the containers
+	allocTags := utils.NewTagList()
+	allocTags.AddLow("nomad_job", alloc.JobName)
+	allocTags.AddLow("nomad_group", alloc.TaskGroup)
+	allocTags.AddLow("nomad_namespace", alloc.Namespace)
+	allocTags.AddLow("nomad_dc", alloc.Datacenter)
+	allocTags.AddOrchestrator("nomad_alloc_name", alloc.Name)
+	allocTags.AddOrchestrator("nomad_alloc_id", alloc.ID)
+
+	tagInfos := make([]*TagInfo, 0, len(alloc.Containers))
+	for _, allocContainer := range alloc.Containers {
+		container, err := c.store.GetContainer(allocContainer.ID)
+		if err != nil {
+			log.Debugf("allocation %q has reference to non-existing container %q", alloc.ID, allocContainer.ID)
+			continue
+		}
+
+		c.registerChild(alloc.EntityID, container.EntityID)
+
+		tags := allocTags.Copy()
+
+		tags.AddLow("nomad_task", allocContainer.Name)
+
+		low, orch, high, standard := tags.Compute()
+		tagInfos = append(tagInfos, &TagInfo{
+			// allocationSource here is not a mistake. the source
+			// is always from the parent resource.
+			Source:               allocationSource,
+			Entity:               buildTaggerEntityID(container.EntityID),
+			HighCardTags:         high,
+			OrchestratorCardTags: orch,
+			LowCardTags:          low,
+			StandardTags:         standard,
+		})
+	}
+
+	return tagInfos
+}
+
 func (c *WorkloadMetaCollector) handleGardenContainer(container *workloadmeta.Container) []*TagInfo {
 	return []*TagInfo{
 		{
@@ -673,6 +749,10 @@ func buildTaggerEntityID(entityID workloadmeta.EntityID) string {
 		return fmt.Sprintf("ecs_task://%s", entityID.ID)
 	case workloadmeta.KindContainerImageMetadata:
 		return fmt.Sprintf("container_image_metadata://%s", entityID.ID)
+	case workloadmeta.KindProcess:
+		return fmt.Sprintf("process://%s", entityID.ID)
+	case workloadmeta.KindNomadAllocation:
+		return fmt.Sprintf("nomad_allocation://%s", entityID.ID)
 	default:
 		log.Errorf("can't recognize entity %q with kind %q; trying %s://%s as tagger entity",
 			entityID.ID, entityID.Kind, entityID.ID, entityID.Kind)
@@ -27,6 +27,8 @@ const (
 	taskSource           = workloadmetaCollectorName + "-" + string(workloadmeta.KindECSTask)
 	containerSource      = workloadmetaCollectorName + "-" + string(workloadmeta.KindContainer)
 	containerImageSource = workloadmetaCollectorName + "-" + string(workloadmeta.KindContainerImageMetadata)
+	processSource        = workloadmetaCollectorName + "-" + string(workloadmeta.KindProcess)
+	allocationSource     = workloadmetaCollectorName + "-" + string(workloadmeta.KindNomadAllocation)
 )
 
 // CollectorPriorities holds collector priorities
@@ -56,6 +58,8 @@ type WorkloadMetaCollector struct {
 	globContainerLabels    map[string]glob.Glob
 	globContainerEnvLabels map[string]glob.Glob
 
+	extractionRules *utils.ExtractionRules
+
 	collectEC2ResourceTags bool
 }
 
@@ -159,6 +163,8 @@ func NewWorkloadMetaCollector(ctx context.Context, store workloadmeta.Store, p p
 	nsLabelsAsTags := config.Datadog.GetStringMapString("kubernetes_namespace_labels_as_tags")
 	c.initPodMetaAsTags(labelsAsTags, annotationsAsTags, nsLabelsAsTags)
 
+	c.extractionRules = utils.GetExtractionRules(config.Datadog)
+
 	return c
 }
 
@@ -190,4 +196,5 @@ func init() {
 	CollectorPriorities[taskSource] = NodeOrchestrator
 	CollectorPriorities[containerSource] = NodeRuntime
 	CollectorPriorities[containerImageSource] = NodeRuntime
+	CollectorPriorities[processSource] = NodeRuntime
 }
@@ -87,6 +87,7 @@ func TestHandleKubePod(t *testing.T) {
 		labelsAsTags      map[string]string
 		annotationsAsTags map[string]string
 		nsLabelsAsTags    map[string]string
+		extractionRules   []utils.ExtractionRuleConfig
 		pod               workloadmeta.KubernetesPod
 		expected          []*TagInfo
 	}{
@@ -393,6 +394,73 @@ func TestHandleKubePod(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "extraction rules",
+			extractionRules: []utils.ExtractionRuleConfig{
+				{
+					Source:  "pod_annotation",
+					Key:     "example\\.com/(.+)",
+					TagName: "example_%%key_1%%",
+				},
+				{
+					Source:      "pod_label",
+					Key:         "shard",
+					Value:       "shard-(?P<index>\\d+)",
+					TagName:     "shard_index",
+					TagValue:    "%%value_index%%",
+					Cardinality: "orchestrator",
+				},
+				{
+					Source:  "namespace_label",
+					Key:     "cost-center",
+					TagName: "cost_center",
+				},
+				{
+					Source:    "owner_label",
+					OwnerKind: "deployment",
+					Key:       "team",
+					TagName:   "team",
+				},
+			},
+			pod: workloadmeta.KubernetesPod{
+				EntityID: podEntityID,
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Annotations: map[string]string{
+						"example.com/tier": "gold",
+					},
+					Labels: map[string]string{
+						"shard": "shard-3",
+					},
+				},
+				NamespaceLabels: map[string]string{
+					"cost-center": "1234",
+				},
+				OwnersLabels: map[string]map[string]string{
+					"deployment": {"team": "container-integrations"},
+					"replicaset": {"team": "ignored"},
+				},
+			},
+			expected: []*TagInfo{
+				{
+					Source:       podSource,
+					Entity:       podTaggerEntityID,
+					HighCardTags: []string{},
+					OrchestratorCardTags: []string{
+						fmt.Sprintf("pod_name:%s", podName),
+						"shard_index:3",
+					},
+					LowCardTags: []string{
+						fmt.Sprintf("kube_namespace:%s", podNamespace),
+						"example_tier:gold",
+						"cost_center:1234",
+						"team:container-integrations",
+					},
+					StandardTags: []string{},
+				},
+			},
+		},
 		{
 			name: "disable kube_service",
 			pod: workloadmeta.KubernetesPod{
@@ -427,9 +495,10 @@ func TestHandleKubePod(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			collector := &WorkloadMetaCollector{
-				store:      store,
-				children:   make(map[string]map[string]struct{}),
-				staticTags: tt.staticTags,
+				store:           store,
+				children:        make(map[string]map[string]struct{}),
+				staticTags:      tt.staticTags,
+				extractionRules: utils.NewExtractionRules(tt.extractionRules),
 			}
 
 			collector.initPodMetaAsTags(tt.labelsAsTags, tt.annotationsAsTags, tt.nsLabelsAsTags)
@@ -600,6 +669,76 @@ func TestHandleECSTask(t *testing.T) {
 	}
 }
 
+func TestHandleNomadAllocation(t *testing.T) {
+	const (
+		containerID = "foobarquux"
+		allocID     = "5456bd7a-9fc0-c0dd-6131-cbee77f57577"
+	)
+
+	store := workloadmetatesting.NewStore()
+	store.Set(&workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainer,
+			ID:   containerID,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: "redis-" + allocID,
+		},
+	})
+
+	collector := &WorkloadMetaCollector{
+		store:    store,
+		children: make(map[string]map[string]struct{}),
+	}
+
+	actual := collector.handleNomadAllocation(workloadmeta.Event{
+		Type: workloadmeta.EventTypeSet,
+		Entity: &workloadmeta.NomadAllocation{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindNomadAllocation,
+				ID:   allocID,
+			},
+			EntityMeta: workloadmeta.EntityMeta{
+				Name:      "redis.cache[0]",
+				Namespace: "default",
+			},
+			JobName:    "redis",
+			TaskGroup:  "cache",
+			Datacenter: "dc1",
+			Containers: []workloadmeta.OrchestratorContainer{
+				{ID: containerID, Name: "redis"},
+				{ID: "unknown", Name: "exporter"},
+			},
+		},
+	})
+
+	assertTagInfoListEqual(t, []*TagInfo{
+		{
+			Source:       allocationSource,
+			Entity:       fmt.Sprintf("container_id://%s", containerID),
+			HighCardTags: []string{},
+			OrchestratorCardTags: []string{
+				"nomad_alloc_id:" + allocID,
+				"nomad_alloc_name:redis.cache[0]",
+			},
+			LowCardTags: []string{
+				"nomad_job:redis",
+				"nomad_group:cache",
+				"nomad_task:redis",
+				"nomad_namespace:default",
+				"nomad_dc:dc1",
+			},
+			StandardTags: []string{},
+		},
+	}, actual)
+
+	assert.Equal(t, map[string]map[string]struct{}{
+		"nomad_allocation://" + allocID: {
+			fmt.Sprintf("container_id://%s", containerID): {},
+		},
+	}, collector.children)
+}
+
 func TestHandleContainer(t *testing.T) {
 	const (
 		containerName = "foobar"
@@ -623,12 +762,13 @@ func TestHandleContainer(t *testing.T) {
 	taggerEntityID := fmt.Sprintf("container_id://%s", entityID.ID)
 
 	tests := []struct {
-		name         string
-		staticTags   map[string]string
-		labelsAsTags map[string]string
-		envAsTags    map[string]string
-		container    workloadmeta.Container
-		expected     []*TagInfo
+		name            string
+		staticTags      map[string]string
+		labelsAsTags    map[string]string
+		envAsTags       map[string]string
+		extractionRules []utils.ExtractionRuleConfig
+		container       workloadmeta.Container
+		expected        []*TagInfo
 	}{
 		{
 			name: "fully formed container",
@@ -670,6 +810,49 @@ func TestHandleContainer(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "extraction rules",
+			extractionRules: []utils.ExtractionRuleConfig{
+				{
+					Source:   "container_env",
+					Key:      "(.+)_SHARD",
+					TagName:  "%%key_1%%_shard",
+					TagValue: "%%value%%",
+				},
+				{
+					Source:      "container_label",
+					Key:         "com\\.example\\.build",
+					TagName:     "build",
+					Cardinality: "high",
+				},
+			},
+			container: workloadmeta.Container{
+				EntityID: entityID,
+				EntityMeta: workloadmeta.EntityMeta{
+					Name: containerName,
+					Labels: map[string]string{
+						"com.example.build": "1234",
+					},
+				},
+				EnvVars: map[string]string{
+					"CACHE_SHARD": "7",
+				},
+			},
+			expected: []*TagInfo{
+				{
+					Source: containerSource,
+					Entity: taggerEntityID,
+					HighCardTags: []string{
+						fmt.Sprintf("container_name:%s", containerName),
+						fmt.Sprintf("container_id:%s", entityID.ID),
+						"build:1234",
+					},
+					OrchestratorCardTags: []string{},
+					LowCardTags:          []string{"CACHE_shard:7"},
+					StandardTags:         []string{},
+				},
+			},
+		},
 		{
 			name: "tags from environment",
 			container: workloadmeta.Container{
@@ -1021,7 +1204,8 @@ func TestHandleContainer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			collector := &WorkloadMetaCollector{
-				staticTags: tt.staticTags,
+				staticTags:      tt.staticTags,
+				extractionRules: utils.NewExtractionRules(tt.extractionRules),
 			}
 			collector.initContainerMetaAsTags(tt.labelsAsTags, tt.envAsTags)
 
@@ -1118,6 +1302,79 @@ func TestHandleContainerImage(t *testing.T) {
 	}
 }
 
+func TestHandleProcess(t *testing.T) {
+	entityID := workloadmeta.EntityID{
+		Kind: workloadmeta.KindProcess,
+		ID:   "1234",
+	}
+
+	tests := []struct {
+		name     string
+		process  workloadmeta.Process
+		expected []*TagInfo
+	}{
+		{
+			name: "unified service tagging",
+			process: workloadmeta.Process{
+				EntityID: entityID,
+				Pid:      1234,
+				Service:  "billing",
+				Env:      "production",
+				Version:  "1.2.3",
+			},
+			expected: []*TagInfo{
+				{
+					Source:               processSource,
+					Entity:               "process://1234",
+					HighCardTags:         []string{},
+					OrchestratorCardTags: []string{},
+					LowCardTags: []string{
+						"env:production",
+						"service:billing",
+						"version:1.2.3",
+					},
+					StandardTags: []string{
+						"env:production",
+						"service:billing",
+						"version:1.2.3",
+					},
+				},
+			},
+		},
+		{
+			name: "inferred service only",
+			process: workloadmeta.Process{
+				EntityID: entityID,
+				Pid:      1234,
+				Service:  "nginx",
+			},
+			expected: []*TagInfo{
+				{
+					Source:               processSource,
+					Entity:               "process://1234",
+					HighCardTags:         []string{},
+					OrchestratorCardTags: []string{},
+					LowCardTags:          []string{"service:nginx"},
+					StandardTags:         []string{"service:nginx"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &WorkloadMetaCollector{}
+
+			actual := collector.handleProcess(workloadmeta.Event{
+				Type:   workloadmeta.EventTypeSet,
+				Entity: &tt.process,
+			})
+
+			assertTagInfoListEqual(t, tt.expected, actual)
+		})
+	}
+}
+
 func TestHandleDelete(t *testing.T) {
 	const (
 		podName       = "datadog-agent-foobar"
@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package collectors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// admissionEnabledLabelKey is the pod label enabling the mutations of the
+// admission controller, which injects DD_ENV, DD_SERVICE and DD_VERSION.
+const admissionEnabledLabelKey = "admission.datadoghq.com/enabled"
+
+// imageTagLatest is the default image tag, which isn't a hint of the version
+const imageTagLatest = "latest"
+
+// unifiedServiceTagKeys are the tags audited by DiagnoseUnifiedServiceTagging
+var unifiedServiceTagKeys = []string{tagKeyEnv, tagKeyService, tagKeyVersion}
+
+// ustValues collects the values of the unified service tags of an entity
+type ustValues map[string][]workloadmeta.UnifiedServiceTagValue
+
+func (v ustValues) add(tag, value, source string) {
+	if value == "" {
+		return
+	}
+	v[tag] = append(v[tag], workloadmeta.UnifiedServiceTagValue{Value: value, Source: source})
+}
+
+// addFromTagList adds the unified service tags found in tags computed from a
+// JSON annotation or label, as they end up in the tagger
+func (v ustValues) addFromTagList(tags *utils.TagList, source string) {
+	low, orch, high, _ := tags.Compute()
+	for _, list := range [][]string{low, orch, high} {
+		for _, tag := range list {
+			key, value, found := strings.Cut(tag, ":")
+			if !found {
+				continue
+			}
+			for _, ustKey := range unifiedServiceTagKeys {
+				if key == ustKey {
+					v.add(key, value, source)
+				}
+			}
+		}
+	}
+}
+
+// DiagnoseUnifiedServiceTagging audits the env, service and version tags of
+// the containers, including the ones of Kubernetes pods, and of the processes
+// of the store. It reports the value of each tag and where it comes from, so
+// that missing and conflicting values can be found.
+func DiagnoseUnifiedServiceTagging(store workloadmeta.Store) workloadmeta.UnifiedServiceTaggingReport {
+	report := workloadmeta.UnifiedServiceTaggingReport{
+		Entities:    []workloadmeta.UnifiedServiceTaggingEntity{},
+		Missing:     make(map[string]int),
+		Conflicting: make(map[string]int),
+		Values:      make(map[string]map[string]int),
+	}
+	for _, tag := range unifiedServiceTagKeys {
+		report.Values[tag] = make(map[string]int)
+	}
+
+	containerValues := make(map[string]ustValues)
+	containerImageTags := make(map[string]string)
+	for _, container := range store.ListContainers() {
+		name := container.Name
+		values := make(ustValues)
+
+		pod, err := store.GetKubernetesPodForContainer(container.ID)
+		if err == nil {
+			containerName := podContainerName(pod, container)
+			name = fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, containerName)
+			addPodContainerUSTValues(values, pod, container, containerName)
+		} else {
+			addContainerUSTValues(values, container, "")
+		}
+
+		// the image tag is only reported as a hint of the version, as
+		// it often differs from it
+		imageTag := ""
+		if container.Image.Tag != imageTagLatest {
+			imageTag = container.Image.Tag
+		}
+
+		containerValues[container.ID] = values
+		containerImageTags[container.ID] = imageTag
+		report.Entities = append(report.Entities, buildUSTEntity(container.EntityID, name, values, imageTag))
+	}
+
+	for _, process := range store.ListProcesses() {
+		values := make(ustValues)
+		values.add(tagKeyEnv, process.Env, "process environment")
+		values.add(tagKeyService, process.Service, "process environment or command line")
+		values.add(tagKeyVersion, process.Version, "process environment")
+
+		// processes running in a container are also tagged with
+		// the tags of the container
+		if process.ContainerID != "" {
+			for tag, containerTagValues := range containerValues[process.ContainerID] {
+				for _, v := range containerTagValues {
+					values.add(tag, v.Value, fmt.Sprintf("container %s: %s", process.ContainerID, v.Source))
+				}
+			}
+		}
+
+		name := fmt.Sprintf("%s (pid %d)", process.Name, process.Pid)
+		report.Entities = append(report.Entities, buildUSTEntity(process.EntityID, name, values, containerImageTags[process.ContainerID]))
+	}
+
+	sort.Slice(report.Entities, func(i, j int) bool {
+		if report.Entities[i].Kind != report.Entities[j].Kind {
+			return report.Entities[i].Kind < report.Entities[j].Kind
+		}
+		if report.Entities[i].Name != report.Entities[j].Name {
+			return report.Entities[i].Name < report.Entities[j].Name
+		}
+		return report.Entities[i].ID < report.Entities[j].ID
+	})
+
+	for _, e := range report.Entities {
+		for _, tag := range e.Missing {
+			report.Missing[tag]++
+		}
+		for _, tag := range e.Conflicting {
+			report.Conflicting[tag]++
+		}
+		for tag, values := range e.Tags {
+			for value := range distinctUSTValues(values) {
+				report.Values[tag][value]++
+			}
+		}
+	}
+
+	return report
+}
+
+// addContainerUSTValues adds the unified service tags set in the environment
+// and the labels of a container
+func addContainerUSTValues(values ustValues, container *workloadmeta.Container, envSourceSuffix string) {
+	for envVar, tag := range standardEnvKeys {
+		values.add(tag, container.EnvVars[envVar], "environment variable "+envVar+envSourceSuffix)
+	}
+
+	for label, tag := range standardDockerLabels {
+		values.add(tag, container.Labels[label], "container label "+label)
+	}
+
+	if lbl, ok := container.Labels[autodiscoveryLabelTagsKey]; ok {
+		tags := utils.NewTagList()
+		parseContainerADTagsLabels(tags, lbl)
+		values.addFromTagList(tags, "container label "+autodiscoveryLabelTagsKey)
+	}
+}
+
+// addPodContainerUSTValues adds the unified service tags set in the labels
+// and annotations of a pod for one of its containers, and in the container itself
+func addPodContainerUSTValues(values ustValues, pod *workloadmeta.KubernetesPod, container *workloadmeta.Container, containerName string) {
+	for _, tag := range unifiedServiceTagKeys {
+		label := podStandardLabelPrefix + tag
+		values.add(tag, pod.Labels[label], "pod label "+label)
+
+		containerLabel := fmt.Sprintf(podStandardLabelPrefix+"%s.%s", containerName, tag)
+		values.add(tag, pod.Labels[containerLabel], "pod label "+containerLabel)
+	}
+
+	for _, annotation := range []string{podTagsAnnotation, fmt.Sprintf(podContainerTagsAnnotationFormat, containerName)} {
+		if value, found := pod.Annotations[annotation]; found {
+			tags := utils.NewTagList()
+			if err := parseJSONValue(value, tags); err != nil {
+				log.Debugf("can't parse value for annotation %s: %s", annotation, err)
+				continue
+			}
+			values.addFromTagList(tags, "pod annotation "+annotation)
+		}
+	}
+
+	// the admission controller injects the standard tags of the pod or
+	// its owner as environment variables
+	envSourceSuffix := ""
+	if pod.Labels[admissionEnabledLabelKey] == "true" {
+		envSourceSuffix = " (may be injected by the admission controller)"
+	}
+	addContainerUSTValues(values, container, envSourceSuffix)
+}
+
+// podContainerName returns the name of a container in the pod spec
+func podContainerName(pod *workloadmeta.KubernetesPod, container *workloadmeta.Container) string {
+	for _, podContainer := range pod.Containers {
+		if podContainer.ID == container.ID {
+			return podContainer.Name
+		}
+	}
+	return container.Name
+}
+
+func buildUSTEntity(entityID workloadmeta.EntityID, name string, values ustValues, imageTag string) workloadmeta.UnifiedServiceTaggingEntity {
+	entity := workloadmeta.UnifiedServiceTaggingEntity{
+		Kind:     entityID.Kind,
+		ID:       entityID.ID,
+		Name:     name,
+		Tags:     values,
+		ImageTag: imageTag,
+	}
+
+	for _, tag := range unifiedServiceTagKeys {
+		switch len(distinctUSTValues(values[tag])) {
+		case 0:
+			entity.Missing = append(entity.Missing, tag)
+		case 1:
+		default:
+			entity.Conflicting = append(entity.Conflicting, tag)
+		}
+	}
+
+	return entity
+}
+
+func distinctUSTValues(values []workloadmeta.UnifiedServiceTagValue) map[string]struct{} {
+	distinct := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		distinct[v.Value] = struct{}{}
+	}
+	return distinct
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+	workloadmetatesting "github.com/DataDog/datadog-agent/pkg/workloadmeta/testing"
+)
+
+func TestDiagnoseUnifiedServiceTagging(t *testing.T) {
+	store := workloadmetatesting.NewStore()
+
+	store.Set(&workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "docker-container"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: "frontend",
+			Labels: map[string]string{
+				"com.datadoghq.tags.service": "frontend",
+				"com.datadoghq.ad.tags":      `["env:staging"]`,
+			},
+		},
+		EnvVars: map[string]string{"DD_SERVICE": "web"},
+		Image:   workloadmeta.ContainerImage{Tag: "latest"},
+	})
+
+	store.Set(&workloadmeta.Container{
+		EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "pod-container"},
+		EntityMeta: workloadmeta.EntityMeta{Name: "k8s_api_api-7d9f"},
+		EnvVars: map[string]string{
+			"DD_ENV":     "prod",
+			"DD_SERVICE": "api",
+			"DD_VERSION": "1.0",
+		},
+		Image: workloadmeta.ContainerImage{Tag: "1.1"},
+	})
+	store.Set(&workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod-uid"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      "api-7d9f",
+			Namespace: "default",
+			Labels: map[string]string{
+				"tags.datadoghq.com/env":          "prod",
+				"tags.datadoghq.com/api.service":  "api",
+				"admission.datadoghq.com/enabled": "true",
+			},
+			Annotations: map[string]string{
+				"ad.datadoghq.com/tags": `{"version": "1.0"}`,
+			},
+		},
+		Containers: []workloadmeta.OrchestratorContainer{{ID: "pod-container", Name: "api"}},
+	})
+
+	store.Set(&workloadmeta.Container{
+		EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "worker-container"},
+		EntityMeta: workloadmeta.EntityMeta{Name: "worker"},
+		EnvVars: map[string]string{
+			"DD_ENV":     "prod",
+			"DD_SERVICE": "worker",
+		},
+		Image: workloadmeta.ContainerImage{Tag: "2.3"},
+	})
+
+	store.Set(&workloadmeta.Process{
+		EntityID:    workloadmeta.EntityID{Kind: workloadmeta.KindProcess, ID: "42"},
+		Pid:         42,
+		Name:        "gunicorn",
+		ContainerID: "pod-container",
+		Service:     "api-worker",
+	})
+
+	report := DiagnoseUnifiedServiceTagging(store)
+	require.Len(t, report.Entities, 4)
+
+	docker := report.Entities[1]
+	assert.Equal(t, "frontend", docker.Name)
+	assert.Equal(t, []string{"version"}, docker.Missing)
+	assert.Equal(t, []string{"service"}, docker.Conflicting)
+	assert.Empty(t, docker.ImageTag)
+	assert.ElementsMatch(t, []workloadmeta.UnifiedServiceTagValue{
+		{Value: "web", Source: "environment variable DD_SERVICE"},
+		{Value: "frontend", Source: "container label com.datadoghq.tags.service"},
+	}, docker.Tags["service"])
+	assert.Equal(t, []workloadmeta.UnifiedServiceTagValue{
+		{Value: "staging", Source: "container label com.datadoghq.ad.tags"},
+	}, docker.Tags["env"])
+
+	pod := report.Entities[0]
+	assert.Equal(t, "default/api-7d9f/api", pod.Name)
+	assert.Empty(t, pod.Missing)
+	assert.Empty(t, pod.Conflicting)
+	assert.Equal(t, []workloadmeta.UnifiedServiceTagValue{
+		{Value: "prod", Source: "pod label tags.datadoghq.com/env"},
+		{Value: "prod", Source: "environment variable DD_ENV (may be injected by the admission controller)"},
+	}, pod.Tags["env"])
+	assert.Equal(t, []workloadmeta.UnifiedServiceTagValue{
+		{Value: "1.0", Source: "pod annotation ad.datadoghq.com/tags"},
+		{Value: "1.0", Source: "environment variable DD_VERSION (may be injected by the admission controller)"},
+	}, pod.Tags["version"])
+	assert.Equal(t, "1.1", pod.ImageTag)
+
+	// the image tag is a hint, not a version
+	worker := report.Entities[2]
+	assert.Equal(t, "worker", worker.Name)
+	assert.Equal(t, []string{"version"}, worker.Missing)
+	assert.Empty(t, worker.Conflicting)
+	assert.Empty(t, worker.Tags["version"])
+	assert.Equal(t, "2.3", worker.ImageTag)
+
+	process := report.Entities[3]
+	assert.Equal(t, workloadmeta.KindProcess, process.Kind)
+	assert.Equal(t, "gunicorn (pid 42)", process.Name)
+	assert.Empty(t, process.Missing)
+	assert.Equal(t, []string{"service"}, process.Conflicting)
+	assert.Equal(t, "1.1", process.ImageTag)
+
+	assert.Equal(t, map[string]int{"version": 2}, report.Missing)
+	assert.Equal(t, map[string]int{"service": 2}, report.Conflicting)
+	assert.Equal(t, map[string]map[string]int{
+		"env":     {"staging": 1, "prod": 3},
+		"service": {"web": 1, "frontend": 1, "api": 2, "api-worker": 1, "worker": 1},
+		"version": {"1.0": 2},
+	}, report.Values)
+}
+
+func TestDiagnoseUnifiedServiceTaggingImageTagIsNotAVersion(t *testing.T) {
+	store := workloadmetatesting.NewStore()
+	store.Set(&workloadmeta.Container{
+		EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "container"},
+		EntityMeta: workloadmeta.EntityMeta{Name: "worker"},
+		EnvVars: map[string]string{
+			"DD_ENV":     "prod",
+			"DD_SERVICE": "worker",
+		},
+		Image: workloadmeta.ContainerImage{Tag: "2.3"},
+	})
+
+	report := DiagnoseUnifiedServiceTagging(store)
+	require.Len(t, report.Entities, 1)
+	assert.Equal(t, []string{"version"}, report.Entities[0].Missing)
+	assert.Equal(t, "2.3", report.Entities[0].ImageTag)
+	assert.Equal(t, map[string]int{"version": 1}, report.Missing)
+	assert.Empty(t, report.Values["version"])
+}
@@ -8,9 +8,13 @@ package local
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	tagger_api "github.com/DataDog/datadog-agent/pkg/tagger/api"
@@ -31,10 +35,22 @@ type Tagger struct {
 	workloadStore workloadmeta.Store
 	collector     *collectors.WorkloadMetaCollector
 
+	// synced is closed once the workloadmeta collectors have reported
+	// their initial state, or after tagger_snapshot.reconcile_timeout.
+	synced chan struct{}
+
+	// snapshotPath is where the tag store is persisted, empty if
+	// tagger_snapshot.enabled is false.
+	snapshotPath string
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
+// syncPollInterval is the interval at which the tagger checks whether the
+// workloadmeta collectors have reported their initial state.
+const syncPollInterval = 1 * time.Second
+
 // NewTagger returns an allocated tagger. You still have to run Init() once the
 // config package is ready. You are probably looking for tagger.Tag() using
 // the global instance instead of creating your own.
@@ -42,6 +58,7 @@ func NewTagger(workloadStore workloadmeta.Store) *Tagger {
 	return &Tagger{
 		tagStore:      tagstore.NewTagStore(),
 		workloadStore: workloadStore,
+		synced:        make(chan struct{}),
 	}
 }
 
@@ -57,15 +74,114 @@ func (t *Tagger) Init(ctx context.Context) error {
 		t.tagStore,
 	)
 
+	if config.Datadog.GetBool("tagger_snapshot.enabled") {
+		t.snapshotPath = config.Datadog.GetString("tagger_snapshot.path")
+		if t.snapshotPath == "" {
+			t.snapshotPath = filepath.Join(config.Datadog.GetString("run_path"), "tagger-snapshot.json")
+		}
+
+		maxAge := time.Duration(config.Datadog.GetInt("tagger_snapshot.max_age")) * time.Second
+		n, err := t.tagStore.Restore(t.snapshotPath, maxAge)
+		if err != nil {
+			log.Infof("Not restoring the tagger snapshot: %s", err)
+		} else {
+			log.Infof("Restored the tags of %d entities from the tagger snapshot", n)
+		}
+	}
+
 	go t.tagStore.Run(t.ctx)
 	go t.collector.Run(t.ctx)
+	go func() {
+		reconcileTimeout := time.Duration(config.Datadog.GetInt("tagger_snapshot.reconcile_timeout")) * time.Second
+		if !t.waitForSync(t.ctx, reconcileTimeout) {
+			return
+		}
+
+		// Snapshots are only written once restored tags have been
+		// reconciled, so that stale tags are not persisted again.
+		if t.snapshotPath != "" {
+			interval := time.Duration(config.Datadog.GetInt("tagger_snapshot.interval")) * time.Second
+			t.runSnapshots(t.ctx, interval)
+		}
+	}()
 
 	return nil
 }
 
+// waitForSync waits for the workloadmeta collectors to report their initial
+// state, then prunes the restored tags that they did not report again. It
+// returns false if the context is cancelled first.
+func (t *Tagger) waitForSync(ctx context.Context, timeout time.Duration) bool {
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(timeout)
+	initialized := false
+
+	// Once the store is initialized, wait for one more tick so that the
+	// tagger collector processes the last events of the collectors.
+	for !initialized {
+		initialized = t.workloadStore.IsInitialized()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+
+		if !initialized && time.Now().After(deadline) {
+			log.Warnf("Workloadmeta collectors did not report their initial state after %s, pruning restored tags", timeout)
+			break
+		}
+	}
+
+	t.tagStore.PruneRestored()
+	close(t.synced)
+
+	return true
+}
+
+// runSnapshots periodically writes the content of the tag store to disk.
+func (t *Tagger) runSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.snapshot()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Tagger) snapshot() {
+	if err := t.tagStore.Snapshot(t.snapshotPath); err != nil {
+		log.Warnf("Could not write the tagger snapshot: %s", err)
+	}
+}
+
+// Synced returns a channel that is closed once the workloadmeta collectors
+// have reported their initial state to the tagger.
+func (t *Tagger) Synced() <-chan struct{} {
+	return t.synced
+}
+
 // Stop queues a shutdown of Tagger
 func (t *Tagger) Stop() error {
 	t.cancel()
+
+	// Write a last snapshot so that the next agent starts with fresh tags,
+	// unless restored tags have not been reconciled yet.
+	if t.snapshotPath != "" {
+		select {
+		case <-t.synced:
+			t.snapshot()
+		default:
+		}
+	}
+
 	return nil
 }
 
@@ -7,11 +7,16 @@ package local
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/tagger/tagstore"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
@@ -40,3 +45,53 @@ func TestTagBuilder(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"high", "low1", "low2"}, tb.Get())
 }
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagger-snapshot.json")
+
+	mockConfig := config.Mock(t)
+	mockConfig.Set("tagger_snapshot.enabled", true)
+	mockConfig.Set("tagger_snapshot.path", path)
+
+	previous := tagstore.NewTagStore()
+	previous.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Entity:      "container_id://stopped",
+			Source:      "workloadmeta-container",
+			LowCardTags: []string{"image_name:redis"},
+		},
+	})
+	require.NoError(t, previous.Snapshot(path))
+
+	tagger := NewTagger(workloadmeta.NewStore(nil))
+	tagger.Init(context.Background())
+
+	tags, err := tagger.Tag("container_id://stopped", collectors.LowCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image_name:redis"}, tags)
+
+	select {
+	case <-tagger.Synced():
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "tagger did not sync")
+	}
+
+	// the container was not reported again by the collectors
+	tags, err = tagger.Tag("container_id://stopped", collectors.LowCardinality)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	tagger.tagStore.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Entity:      "container_id://running",
+			Source:      "workloadmeta-container",
+			LowCardTags: []string{"image_name:nginx"},
+		},
+	})
+	require.NoError(t, tagger.Stop())
+
+	restored := tagstore.NewTagStore()
+	_, err = restored.Restore(path, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image_name:nginx"}, restored.Lookup("container_id://running", collectors.LowCardinality))
+}
@@ -0,0 +1,210 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tagstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/types"
+)
+
+// snapshotVersion is the version of the format of the snapshots. Snapshots
+// with another version are ignored.
+const snapshotVersion = 1
+
+// snapshot is the on-disk representation of the content of the store.
+type snapshot struct {
+	Version   int                                      `json:"version"`
+	Timestamp time.Time                                `json:"timestamp"`
+	Entities  map[string]map[string]snapshotSourceTags `json:"entities"`
+}
+
+type snapshotSourceTags struct {
+	LowCardTags          []string `json:"low_card_tags,omitempty"`
+	OrchestratorCardTags []string `json:"orchestrator_card_tags,omitempty"`
+	HighCardTags         []string `json:"high_card_tags,omitempty"`
+	StandardTags         []string `json:"standard_tags,omitempty"`
+}
+
+// Snapshot writes the content of the store to path, so that it can be
+// restored when the agent restarts. Tags that are about to expire, like the
+// tags of deleted entities, are not written.
+func (s *TagStore) Snapshot(path string) error {
+	snap := snapshot{
+		Version:   snapshotVersion,
+		Timestamp: s.clock.Now(),
+		Entities:  make(map[string]map[string]snapshotSourceTags),
+	}
+
+	s.RLock()
+	for entityID, storedTags := range s.store {
+		for source, st := range storedTags.sourceTags {
+			if !st.expiryDate.IsZero() || st.isEmpty() {
+				continue
+			}
+
+			if _, ok := snap.Entities[entityID]; !ok {
+				snap.Entities[entityID] = make(map[string]snapshotSourceTags)
+			}
+
+			snap.Entities[entityID][source] = snapshotSourceTags{
+				LowCardTags:          st.lowCardTags,
+				OrchestratorCardTags: st.orchestratorCardTags,
+				HighCardTags:         st.highCardTags,
+				StandardTags:         st.standardTags,
+			}
+		}
+	}
+	data, err := json.Marshal(snap)
+	s.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves a
+	// truncated snapshot behind.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Restore loads the snapshot written at path, unless it is older than maxAge.
+// Restored tags are served until the collectors report the same entities
+// again, and are removed by PruneRestored otherwise. Tags already reported by
+// the collectors are never overridden. It returns the number of restored
+// entities.
+func (s *TagStore) Restore(path string, maxAge time.Duration) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(data, &snap); err != nil {
+		return 0, fmt.Errorf("could not parse tagger snapshot %s: %w", path, err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return 0, fmt.Errorf("unsupported tagger snapshot version %d", snap.Version)
+	}
+
+	if age := s.clock.Since(snap.Timestamp); age > maxAge {
+		return 0, fmt.Errorf("tagger snapshot is too old (%s)", age.Round(time.Second))
+	}
+
+	events := []types.EntityEvent{}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for entityID, sources := range snap.Entities {
+		storedTags, exist := s.store[entityID]
+		eventType := types.EventTypeModified
+		if !exist {
+			eventType = types.EventTypeAdded
+			storedTags = newEntityTags(entityID)
+		}
+
+		changed := false
+		for source, st := range sources {
+			if _, ok := storedTags.sourceTags[source]; ok {
+				continue
+			}
+
+			storedTags.sourceTags[source] = sourceTags{
+				lowCardTags:          st.LowCardTags,
+				orchestratorCardTags: st.OrchestratorCardTags,
+				highCardTags:         st.HighCardTags,
+				standardTags:         st.StandardTags,
+				restored:             true,
+			}
+			changed = true
+		}
+
+		if !changed {
+			continue
+		}
+
+		s.store[entityID] = storedTags
+		storedTags.cacheValid = false
+		events = append(events, types.EntityEvent{
+			EventType: eventType,
+			Entity:    storedTags.toEntity(),
+		})
+	}
+
+	if len(events) > 0 {
+		s.notifySubscribers(events)
+	}
+
+	return len(events), nil
+}
+
+// PruneRestored deletes the restored tags that the collectors did not report
+// again. It is to be called once the collectors have reported their initial
+// state.
+func (s *TagStore) PruneRestored() {
+	s.Lock()
+	defer s.Unlock()
+
+	events := []types.EntityEvent{}
+
+	for entityID, storedTags := range s.store {
+		changed := false
+		for source, st := range storedTags.sourceTags {
+			if st.restored {
+				delete(storedTags.sourceTags, source)
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if len(storedTags.sourceTags) == 0 {
+			delete(s.store, entityID)
+			events = append(events, types.EntityEvent{
+				EventType: types.EventTypeDeleted,
+				Entity:    storedTags.toEntity(),
+			})
+		} else {
+			storedTags.cacheValid = false
+			events = append(events, types.EntityEvent{
+				EventType: types.EventTypeModified,
+				Entity:    storedTags.toEntity(),
+			})
+		}
+	}
+
+	if len(events) > 0 {
+		s.notifySubscribers(events)
+	}
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tagstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagger-snapshot.json")
+
+	clk := clock.NewMock()
+	clk.Add(time.Since(time.Unix(0, 0)))
+
+	previous := newTagStoreWithClock(clk)
+	previous.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:       "source1",
+			Entity:       "running",
+			LowCardTags:  []string{"low"},
+			HighCardTags: []string{"high"},
+			StandardTags: []string{"service:foo"},
+		},
+		{
+			Source:      "source2",
+			Entity:      "running",
+			LowCardTags: []string{"other"},
+		},
+		{
+			Source:      "source1",
+			Entity:      "stopped",
+			LowCardTags: []string{"stopped"},
+		},
+		{
+			Source:      "source1",
+			Entity:      "deleted",
+			LowCardTags: []string{"deleted"},
+		},
+	})
+	previous.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:       "source1",
+			Entity:       "deleted",
+			DeleteEntity: true,
+		},
+	})
+	require.NoError(t, previous.Snapshot(path))
+
+	clk.Add(time.Minute)
+
+	_, err := newTagStoreWithClock(clk).Restore(path, 30*time.Second)
+	assert.Error(t, err, "snapshot should be too old")
+
+	store := newTagStoreWithClock(clk)
+	n, err := store.Restore(path, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.ElementsMatch(t, []string{"low", "other", "high"}, store.Lookup("running", collectors.HighCardinality))
+	assert.ElementsMatch(t, []string{"stopped"}, store.Lookup("stopped", collectors.LowCardinality))
+	assert.Empty(t, store.Lookup("deleted", collectors.LowCardinality))
+
+	standard, err := store.LookupStandard("running")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"service:foo"}, standard)
+
+	// source1 reports the running entity again, with new tags
+	store.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:      "source1",
+			Entity:      "running",
+			LowCardTags: []string{"low2"},
+		},
+	})
+
+	store.PruneRestored()
+
+	assert.ElementsMatch(t, []string{"low2"}, store.Lookup("running", collectors.HighCardinality))
+	_, err = store.GetEntityTags("stopped")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRestoreDoesNotOverrideCollectedTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagger-snapshot.json")
+
+	previous := NewTagStore()
+	previous.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:      "source1",
+			Entity:      "test",
+			LowCardTags: []string{"old"},
+		},
+	})
+	require.NoError(t, previous.Snapshot(path))
+
+	store := NewTagStore()
+	store.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:      "source1",
+			Entity:      "test",
+			LowCardTags: []string{"new"},
+		},
+	})
+
+	n, err := store.Restore(path, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	store.PruneRestored()
+	assert.Equal(t, []string{"new"}, store.Lookup("test", collectors.LowCardinality))
+}
+
+func TestRestoreConfirmedTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagger-snapshot.json")
+
+	info := &collectors.TagInfo{
+		Source:      "source1",
+		Entity:      "test",
+		LowCardTags: []string{"tag"},
+	}
+
+	previous := NewTagStore()
+	previous.ProcessTagInfo([]*collectors.TagInfo{info})
+	require.NoError(t, previous.Snapshot(path))
+
+	store := NewTagStore()
+	_, err := store.Restore(path, time.Minute)
+	require.NoError(t, err)
+
+	ch := store.Subscribe(collectors.LowCardinality)
+	defer store.Unsubscribe(ch)
+	<-ch // initial burst
+
+	// the same tags are reported again: they are not pruned, and no event
+	// is sent since they didn't change
+	store.ProcessTagInfo([]*collectors.TagInfo{info})
+	store.PruneRestored()
+
+	assert.Equal(t, []string{"tag"}, store.Lookup("test", collectors.LowCardinality))
+	select {
+	case events := <-ch:
+		assert.Failf(t, "unexpected events", "%v", events)
+	default:
+	}
+}
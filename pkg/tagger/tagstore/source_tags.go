@@ -15,6 +15,10 @@ type sourceTags struct {
 	highCardTags         []string
 	standardTags         []string
 	expiryDate           time.Time
+
+	// restored is true if the tags were loaded from a snapshot and not
+	// reported again by their collector yet.
+	restored bool
 }
 
 func (st *sourceTags) isEmpty() bool {
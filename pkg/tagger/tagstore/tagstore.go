@@ -130,6 +130,12 @@ func (s *TagStore) ProcessTagInfo(tagInfos []*collectors.TagInfo) {
 		eventType := types.EventTypeModified
 		if exist {
 			st, ok := storedTags.sourceTags[info.Source]
+			if ok && st.restored {
+				// the collector confirmed the restored tags, they
+				// don't need to be pruned anymore
+				st.restored = false
+				storedTags.sourceTags[info.Source] = st
+			}
 			if ok && reflect.DeepEqual(st, newSt) {
 				continue
 			}
@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+)
+
+// ExtractionRuleSource is the kind of metadata a tag extraction rule applies to.
+type ExtractionRuleSource string
+
+// Defined ExtractionRuleSources
+const (
+	PodLabelSource       ExtractionRuleSource = "pod_label"
+	PodAnnotationSource  ExtractionRuleSource = "pod_annotation"
+	NamespaceLabelSource ExtractionRuleSource = "namespace_label"
+	OwnerLabelSource     ExtractionRuleSource = "owner_label"
+	ContainerEnvSource   ExtractionRuleSource = "container_env"
+	ContainerLabelSource ExtractionRuleSource = "container_label"
+	ImageLabelSource     ExtractionRuleSource = "image_label"
+)
+
+var extractionRuleSources = map[ExtractionRuleSource]struct{}{
+	PodLabelSource:       {},
+	PodAnnotationSource:  {},
+	NamespaceLabelSource: {},
+	OwnerLabelSource:     {},
+	ContainerEnvSource:   {},
+	ContainerLabelSource: {},
+	ImageLabelSource:     {},
+}
+
+// ExtractionRuleConfig is the configuration of a tag extraction rule, as
+// defined in `tagger_extraction_rules`.
+type ExtractionRuleConfig struct {
+	// Source is the kind of metadata the rule applies to.
+	Source string `mapstructure:"source" json:"source"`
+	// OwnerKind restricts an `owner_label` rule to the labels of the owners
+	// of this kind, for instance `deployment`.
+	OwnerKind string `mapstructure:"owner_kind" json:"owner_kind"`
+	// Key is the regular expression the whole key must match.
+	Key string `mapstructure:"key" json:"key"`
+	// Value is the regular expression the whole value must match. Any value
+	// matches if it is empty.
+	Value string `mapstructure:"value" json:"value"`
+	// TagName is the template of the name of the tag.
+	TagName string `mapstructure:"tag_name" json:"tag_name"`
+	// TagValue is the template of the value of the tag, `%%value%%` by
+	// default.
+	TagValue string `mapstructure:"tag_value" json:"tag_value"`
+	// Cardinality is `low` (default), `orchestrator` or `high`.
+	Cardinality string `mapstructure:"cardinality" json:"cardinality"`
+}
+
+type extractionRule struct {
+	ownerKind   string
+	key         *regexp.Regexp
+	value       *regexp.Regexp
+	tagName     string
+	tagValue    string
+	cardinality string
+}
+
+// ExtractionRules extracts tags from the labels, annotations and environment
+// variables of the workloads, as configured by `tagger_extraction_rules`.
+//
+// The tag names and values are templates in which `%%key%%` and `%%value%%`
+// are replaced by the key and value of the metadata, and `%%key_<group>%%`
+// and `%%value_<group>%%` by the given capture group, named or numbered, of
+// the key and value regular expressions.
+type ExtractionRules struct {
+	bySource map[ExtractionRuleSource][]*extractionRule
+}
+
+// NewExtractionRules compiles the tag extraction rules. Invalid rules are
+// logged and ignored.
+func NewExtractionRules(configs []ExtractionRuleConfig) *ExtractionRules {
+	r := &ExtractionRules{
+		bySource: make(map[ExtractionRuleSource][]*extractionRule),
+	}
+
+	for i, ruleConfig := range configs {
+		rule, err := newExtractionRule(ruleConfig)
+		if err != nil {
+			log.Errorf("Ignoring tag extraction rule #%d: %v", i, err)
+			continue
+		}
+
+		source := ExtractionRuleSource(ruleConfig.Source)
+		r.bySource[source] = append(r.bySource[source], rule)
+	}
+
+	return r
+}
+
+// GetExtractionRules returns the tag extraction rules defined in
+// `tagger_extraction_rules`.
+func GetExtractionRules(cfg config.Config) *ExtractionRules {
+	var configs []ExtractionRuleConfig
+	if cfg.IsSet("tagger_extraction_rules") {
+		if err := cfg.UnmarshalKey("tagger_extraction_rules", &configs); err != nil {
+			log.Errorf("Could not parse tagger_extraction_rules: %v", err)
+		}
+	}
+
+	return NewExtractionRules(configs)
+}
+
+func newExtractionRule(ruleConfig ExtractionRuleConfig) (*extractionRule, error) {
+	if _, found := extractionRuleSources[ExtractionRuleSource(ruleConfig.Source)]; !found {
+		return nil, fmt.Errorf("unknown source %q", ruleConfig.Source)
+	}
+	if ruleConfig.OwnerKind != "" && ExtractionRuleSource(ruleConfig.Source) != OwnerLabelSource {
+		return nil, fmt.Errorf("owner_kind can only be used with the %q source", OwnerLabelSource)
+	}
+	if ruleConfig.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if ruleConfig.TagName == "" {
+		return nil, fmt.Errorf("tag_name is required")
+	}
+
+	rule := &extractionRule{
+		ownerKind: strings.ToLower(ruleConfig.OwnerKind),
+		tagName:   ruleConfig.TagName,
+		tagValue:  ruleConfig.TagValue,
+	}
+	if rule.tagValue == "" {
+		rule.tagValue = "%%value%%"
+	}
+
+	var err error
+	if rule.key, err = regexp.Compile("^(?:" + ruleConfig.Key + ")$"); err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if ruleConfig.Value != "" {
+		if rule.value, err = regexp.Compile("^(?:" + ruleConfig.Value + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	}
+
+	switch ruleConfig.Cardinality {
+	case "", "low":
+		rule.cardinality = "low"
+	case "orchestrator", "high":
+		rule.cardinality = ruleConfig.Cardinality
+	default:
+		return nil, fmt.Errorf("unknown cardinality %q", ruleConfig.Cardinality)
+	}
+
+	return rule, nil
+}
+
+// HasSource returns true if there are rules for the given source. Collecting
+// some metadata, like the labels of the owners of the pods, is only needed if
+// rules use it.
+func (r *ExtractionRules) HasSource(source ExtractionRuleSource) bool {
+	return r != nil && len(r.bySource[source]) > 0
+}
+
+// Apply adds the tags extracted from the metadata of the given source.
+func (r *ExtractionRules) Apply(source ExtractionRuleSource, metadata map[string]string, tags *TagList) {
+	if r == nil {
+		return
+	}
+
+	for _, rule := range r.bySource[source] {
+		rule.apply(metadata, tags)
+	}
+}
+
+// ApplyOwners adds the tags extracted from the labels of the owners of a pod,
+// indexed by lowercase owner kind.
+func (r *ExtractionRules) ApplyOwners(ownersLabels map[string]map[string]string, tags *TagList) {
+	if r == nil {
+		return
+	}
+
+	for _, rule := range r.bySource[OwnerLabelSource] {
+		for kind, labels := range ownersLabels {
+			if rule.ownerKind != "" && rule.ownerKind != kind {
+				continue
+			}
+			rule.apply(labels, tags)
+		}
+	}
+}
+
+func (rule *extractionRule) apply(metadata map[string]string, tags *TagList) {
+	for key, value := range metadata {
+		keyMatch := rule.key.FindStringSubmatch(key)
+		if keyMatch == nil {
+			continue
+		}
+
+		var valueMatch []string
+		if rule.value != nil {
+			if valueMatch = rule.value.FindStringSubmatch(value); valueMatch == nil {
+				continue
+			}
+		}
+
+		resolve := func(tmpl string) string {
+			return rule.resolve(tmpl, key, value, keyMatch, valueMatch)
+		}
+
+		tagName := resolve(rule.tagName)
+		tagValue := resolve(rule.tagValue)
+
+		switch rule.cardinality {
+		case "high":
+			tags.AddHigh(tagName, tagValue)
+		case "orchestrator":
+			tags.AddOrchestrator(tagName, tagValue)
+		default:
+			tags.AddLow(tagName, tagValue)
+		}
+	}
+}
+
+// resolve replaces the template variables of tmpl. Unknown variables are
+// replaced by an empty string.
+func (rule *extractionRule) resolve(tmpl, key, value string, keyMatch, valueMatch []string) string {
+	resolved := tmpl
+	for _, v := range tmplvar.ParseString(tmpl) {
+		var replacement string
+		switch string(v.Name) {
+		case "key":
+			replacement = submatch(key, rule.key, keyMatch, string(v.Key))
+		case "value":
+			replacement = submatch(value, rule.value, valueMatch, string(v.Key))
+		}
+		resolved = strings.Replace(resolved, string(v.Raw), replacement, -1)
+	}
+	return resolved
+}
+
+// submatch returns the whole string if group is empty, or the capture group,
+// named or numbered, of the match of re.
+func submatch(s string, re *regexp.Regexp, match []string, group string) string {
+	if group == "" {
+		return s
+	}
+	if re == nil || match == nil {
+		return ""
+	}
+
+	index, err := strconv.Atoi(group)
+	if err != nil {
+		index = re.SubexpIndex(group)
+	}
+	if index < 0 || index >= len(match) {
+		return ""
+	}
+
+	return match[index]
+}
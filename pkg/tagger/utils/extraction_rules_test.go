@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractionRulesApply(t *testing.T) {
+	tests := []struct {
+		name             string
+		rules            []ExtractionRuleConfig
+		source           ExtractionRuleSource
+		metadata         map[string]string
+		wantLow          []string
+		wantOrchestrator []string
+		wantHigh         []string
+	}{
+		{
+			name: "whole key and value",
+			rules: []ExtractionRuleConfig{{
+				Source:  "pod_label",
+				Key:     "app\\.kubernetes\\.io/.*",
+				TagName: "%%key%%",
+			}},
+			source: PodLabelSource,
+			metadata: map[string]string{
+				"app.kubernetes.io/name": "redis",
+				"team":                   "storage",
+			},
+			wantLow: []string{"app.kubernetes.io/name:redis"},
+		},
+		{
+			name: "numbered and named capture groups",
+			rules: []ExtractionRuleConfig{{
+				Source:   "pod_annotation",
+				Key:      "ad\\.example\\.com/(?P<field>[a-z]+)",
+				Value:    "([a-z]+)-(\\d+)",
+				TagName:  "example_%%key_field%%",
+				TagValue: "%%value_1%%",
+			}},
+			source: PodAnnotationSource,
+			metadata: map[string]string{
+				"ad.example.com/tier":  "gold-1",
+				"ad.example.com/shard": "42",
+			},
+			wantLow: []string{"example_tier:gold"},
+		},
+		{
+			name: "cardinalities",
+			rules: []ExtractionRuleConfig{
+				{
+					Source:      "container_env",
+					Key:         "BUILD_ID",
+					TagName:     "build_id",
+					Cardinality: "high",
+				},
+				{
+					Source:      "container_env",
+					Key:         "SHARD",
+					TagName:     "shard",
+					Cardinality: "orchestrator",
+				},
+			},
+			source: ContainerEnvSource,
+			metadata: map[string]string{
+				"BUILD_ID": "1234",
+				"SHARD":    "3",
+			},
+			wantOrchestrator: []string{"shard:3"},
+			wantHigh:         []string{"build_id:1234"},
+		},
+		{
+			name: "other source",
+			rules: []ExtractionRuleConfig{{
+				Source:  "image_label",
+				Key:     ".*",
+				TagName: "%%key%%",
+			}},
+			source:   ContainerLabelSource,
+			metadata: map[string]string{"team": "storage"},
+		},
+		{
+			name: "invalid rules are ignored",
+			rules: []ExtractionRuleConfig{
+				{Source: "unknown", Key: ".*", TagName: "%%key%%"},
+				{Source: "pod_label", Key: "(", TagName: "%%key%%"},
+				{Source: "pod_label", Key: ".*"},
+				{Source: "pod_label", Key: ".*", TagName: "%%key%%", Cardinality: "medium"},
+				{Source: "pod_label", OwnerKind: "deployment", Key: ".*", TagName: "%%key%%"},
+				{Source: "pod_label", Key: "team", TagName: "team"},
+			},
+			source:   PodLabelSource,
+			metadata: map[string]string{"team": "storage"},
+			wantLow:  []string{"team:storage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := NewTagList()
+			NewExtractionRules(tt.rules).Apply(tt.source, tt.metadata, tags)
+
+			low, orchestrator, high, _ := tags.Compute()
+			assert.ElementsMatch(t, tt.wantLow, low)
+			assert.ElementsMatch(t, tt.wantOrchestrator, orchestrator)
+			assert.ElementsMatch(t, tt.wantHigh, high)
+		})
+	}
+}
+
+func TestExtractionRulesApplyOwners(t *testing.T) {
+	rules := NewExtractionRules([]ExtractionRuleConfig{
+		{
+			Source:    "owner_label",
+			OwnerKind: "Deployment",
+			Key:       "team",
+			TagName:   "team",
+		},
+		{
+			Source:  "owner_label",
+			Key:     "release",
+			TagName: "release",
+		},
+	})
+	assert.True(t, rules.HasSource(OwnerLabelSource))
+	assert.False(t, rules.HasSource(PodLabelSource))
+
+	tags := NewTagList()
+	rules.ApplyOwners(map[string]map[string]string{
+		"deployment": {"team": "storage"},
+		"replicaset": {"team": "other", "release": "stable"},
+	}, tags)
+
+	low, _, _, _ := tags.Compute()
+	assert.ElementsMatch(t, []string{"team:storage", "release:stable"}, low)
+}
+
+func TestExtractionRulesNil(t *testing.T) {
+	var rules *ExtractionRules
+	tags := NewTagList()
+
+	assert.False(t, rules.HasSource(PodLabelSource))
+	rules.Apply(PodLabelSource, map[string]string{"team": "storage"}, tags)
+	rules.ApplyOwners(map[string]map[string]string{"deployment": {"team": "storage"}}, tags)
+
+	low, _, _, _ := tags.Compute()
+	assert.Empty(t, low)
+}
@@ -52,6 +52,7 @@ type DCAClientInterface interface {
 	GetNodeLabels(nodeName string) (map[string]string, error)
 	GetNodeAnnotations(nodeName string) (map[string]string, error)
 	GetNamespaceLabels(nsName string) (map[string]string, error)
+	GetOwnerLabels(nsName, kind, name string) (map[string]string, error)
 	GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error)
 	GetKubernetesMetadataNames(nodeName, ns, podName string) ([]string, error)
 	GetCFAppsMetadataForNode(nodename string) (map[string][]string, error)
@@ -388,6 +389,13 @@ func (c *DCAClient) GetNamespaceLabels(nsName string) (map[string]string, error)
 	return result, err
 }
 
+// GetOwnerLabels returns the labels of the owner of a pod, like a Deployment, from the Cluster Agent.
+func (c *DCAClient) GetOwnerLabels(nsName, kind, name string) (map[string]string, error) {
+	var result map[string]string
+	err := c.doJSONQuery(context.TODO(), "api/v1/tags/owner/"+nsName+"/"+kind+"/"+name, "GET", nil, &result, false)
+	return result, err
+}
+
 // GetNodeAnnotations returns the node annotations from the Cluster Agent.
 func (c *DCAClient) GetNodeAnnotations(nodeName string) (map[string]string, error) {
 	var result map[string]string
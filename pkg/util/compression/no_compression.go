@@ -8,6 +8,8 @@
 
 package compression
 
+import "io"
+
 // ContentEncoding describes the HTTP header value associated with the compression method
 // empty here since there's no compression
 // var instead of const to ease testing
@@ -23,6 +25,11 @@ func Decompress(src []byte) ([]byte, error) {
 	return src, nil
 }
 
+// NewReader returns a reader that will not decompress anything
+func NewReader(src io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(src), nil
+}
+
 // CompressBound returns the worst case size needed for a destination buffer
 func CompressBound(sourceLen int) int {
 	return sourceLen
@@ -49,6 +49,11 @@ func Decompress(src []byte) ([]byte, error) {
 	return dst, nil
 }
 
+// NewReader returns a reader decompressing the data read from src with zlib
+func NewReader(src io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(src)
+}
+
 // CompressBound returns the worst case size needed for a destination buffer
 // This is allowed to return a value _larger_ than 'sourceLen'.
 // Ref: https://refspecs.linuxbase.org/LSB_3.0.0/LSB-Core-generic/LSB-Core-generic/zlib-compressbound-1.html
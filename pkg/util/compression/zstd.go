@@ -9,6 +9,8 @@
 package compression
 
 import (
+	"io"
+
 	zstd_0 "github.com/DataDog/zstd_0"
 )
 
@@ -29,6 +31,11 @@ func Decompress(src []byte) ([]byte, error) {
 	return zstd_0.Decompress(nil, src)
 }
 
+// NewReader returns a reader decompressing the data read from src with zstd
+func NewReader(src io.Reader) (io.ReadCloser, error) {
+	return zstd_0.NewReader(src), nil
+}
+
 // CompressBound returns the worst case size needed for a destination buffer
 func CompressBound(sourceLen int) int {
 	return zstd_0.CompressBound(sourceLen)
@@ -53,6 +53,8 @@ const (
 	tokenKey                  = "tokenKey"
 	metadataMapExpire         = 2 * time.Minute
 	metadataMapperCachePrefix = "KubernetesMetadataMapping"
+	ownerLabelsExpire         = 5 * time.Minute
+	ownerLabelsCachePrefix    = "KubernetesOwnerLabels"
 )
 
 // APIClient provides authenticated access to the
@@ -583,6 +585,68 @@ func (c *APIClient) NodeAnnotations(nodeName string) (map[string]string, error)
 	return node.Annotations, nil
 }
 
+// OwnerLabels is used to fetch the labels attached to a workload owning pods,
+// like a Deployment or a Job. The labels are cached for a few minutes, as the
+// owners are shared by many pods.
+func (c *APIClient) OwnerLabels(namespace, kind, name string) (map[string]string, error) {
+	cacheKey := cache.BuildAgentKey(ownerLabelsCachePrefix, namespace, kind, name)
+	if labels, found := cache.Cache.Get(cacheKey); found {
+		return labels.(map[string]string), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.timeoutSeconds)*time.Second)
+	defer cancel()
+
+	var objectMeta metav1.ObjectMeta
+	switch kind {
+	case "Deployment":
+		deploy, err := c.Cl.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = deploy.ObjectMeta
+	case "ReplicaSet":
+		rs, err := c.Cl.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = rs.ObjectMeta
+	case "StatefulSet":
+		sts, err := c.Cl.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = sts.ObjectMeta
+	case "DaemonSet":
+		ds, err := c.Cl.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = ds.ObjectMeta
+	case "Job":
+		job, err := c.Cl.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = job.ObjectMeta
+	case "CronJob":
+		cronJob, err := c.Cl.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objectMeta = cronJob.ObjectMeta
+	default:
+		return nil, fmt.Errorf("unsupported owner kind %q", kind)
+	}
+
+	labels := objectMeta.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	cache.Cache.Set(cacheKey, labels, ownerLabelsExpire)
+	return labels, nil
+}
+
 // GetNodeForPod retrieves a pod and returns the name of the node it is scheduled on
 func (c *APIClient) GetNodeForPod(ctx context.Context, namespace, podName string) (string, error) {
 	pod, err := c.Cl.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+func TestOwnerLabels(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := &APIClient{Cl: client, timeoutSeconds: 5}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "default",
+			Labels:    map[string]string{"team": "storage"},
+		},
+	}
+	_, err := client.BatchV1().CronJobs("default").Create(context.TODO(), cronJob, metav1.CreateOptions{})
+	require.NoError(t, err)
+	defer cache.Cache.Delete(cache.BuildAgentKey(ownerLabelsCachePrefix, "default", "CronJob", "backup"))
+
+	labels, err := c.OwnerLabels("default", "CronJob", "backup")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "storage"}, labels)
+
+	// the labels are served from the cache
+	require.NoError(t, client.BatchV1().CronJobs("default").Delete(context.TODO(), "backup", metav1.DeleteOptions{}))
+	labels, err = c.OwnerLabels("default", "CronJob", "backup")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "storage"}, labels)
+
+	_, err = c.OwnerLabels("default", "Job", "missing")
+	assert.Error(t, err)
+	_, err = c.OwnerLabels("default", "Pod", "backup")
+	assert.Error(t, err)
+}
@@ -368,3 +368,18 @@ func GetNamespaceLabels(nsName string) (map[string]string, error) {
 	}
 	return ns.Labels, nil
 }
+
+// GetOwnerLabels retrieves the labels of the queried pod owner, like a
+// Deployment or a Job.
+func GetOwnerLabels(ns, kind, name string) (map[string]string, error) {
+	if !config.Datadog.GetBool("kubernetes_collect_metadata_tags") {
+		return nil, log.Errorf("Metadata collection is disabled on the Cluster Agent")
+	}
+
+	as, err := GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return as.OwnerLabels(ns, kind, name)
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package nomad
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// DefaultAgentURL is the default address of the HTTP API of the Nomad agent
+	DefaultAgentURL = "http://127.0.0.1:4646"
+
+	// Environment variables used by the Nomad CLI
+	agentURLEnvVar = "NOMAD_ADDR"
+	tokenEnvVar    = "NOMAD_TOKEN"
+
+	tokenHeader = "X-Nomad-Token"
+
+	// Nomad API paths
+	agentSelfPath       = "/agent/self"
+	nodeAllocationsPath = "/node/%s/allocations"
+
+	requestTimeout = 5 * time.Second
+)
+
+// Client represents a client for the HTTP API of the Nomad agent.
+type Client struct {
+	agentURL   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new client for the specified Nomad agent.
+func NewClient(agentURL, token string, tlsVerify bool) *Client {
+	return &Client{
+		agentURL: agentURL,
+		token:    token,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: !tlsVerify,
+				},
+			},
+		},
+	}
+}
+
+// NewClientFromConfig creates a new client for the Nomad agent set in the
+// configuration, falling back to the environment variables of the Nomad CLI.
+func NewClientFromConfig() *Client {
+	agentURL := config.Datadog.GetString("nomad.agent_url")
+	if agentURL == "" {
+		agentURL = os.Getenv(agentURLEnvVar)
+	}
+	if agentURL == "" {
+		agentURL = DefaultAgentURL
+	}
+
+	token := config.Datadog.GetString("nomad.token")
+	if token == "" {
+		token = os.Getenv(tokenEnvVar)
+	}
+
+	return NewClient(agentURL, token, config.Datadog.GetBool("nomad.tls_verify"))
+}
+
+// GetAgent returns the configuration and stats of the Nomad agent.
+func (c *Client) GetAgent(ctx context.Context) (*Agent, error) {
+	var a Agent
+	if err := c.get(ctx, agentSelfPath, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetNodeAllocations returns the allocations of a client node, including terminal ones.
+func (c *Client) GetNodeAllocations(ctx context.Context, nodeID string) ([]Allocation, error) {
+	var allocs []Allocation
+	if err := c.get(ctx, fmt.Sprintf(nodeAllocationsPath, url.PathEscape(nodeID)), &allocs); err != nil {
+		return nil, err
+	}
+	return allocs, nil
+}
+
+func (c *Client) makeURL(requestPath string) (string, error) {
+	u, err := url.Parse(c.agentURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join("/v1", requestPath)
+	return u.String(), nil
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	url, err := c.makeURL(path)
+	if err != nil {
+		return fmt.Errorf("Error constructing Nomad API request URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to create new request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set(tokenHeader, c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected HTTP status code in Nomad API reply: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Failed to decode Nomad API JSON payload to type %s: %s", reflect.TypeOf(v), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package nomad
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDummyNomad(t *testing.T, token string, files map[string]string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get(tokenHeader) != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		file, found := files[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestGetAgent(t *testing.T) {
+	ts := newDummyNomad(t, "", map[string]string{
+		"/v1/agent/self": "./testdata/agent_self.json",
+	})
+
+	agent, err := NewClient(ts.URL, "", true).GetAgent(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, AgentConfig{Datacenter: "dc1", Region: "global", NodeName: "nomad-client-1"}, agent.Config)
+	assert.Equal(t, "f7476465-4d6e-c0de-26d0-e383c49be941", agent.NodeID())
+}
+
+func TestGetNodeAllocations(t *testing.T) {
+	ts := newDummyNomad(t, "secret", map[string]string{
+		"/v1/node/f7476465-4d6e-c0de-26d0-e383c49be941/allocations": "./testdata/node_allocations.json",
+	})
+
+	_, err := NewClient(ts.URL, "", true).GetNodeAllocations(context.Background(), "f7476465-4d6e-c0de-26d0-e383c49be941")
+	assert.EqualError(t, err, "Unexpected HTTP status code in Nomad API reply: 403")
+
+	allocs, err := NewClient(ts.URL, "secret", true).GetNodeAllocations(context.Background(), "f7476465-4d6e-c0de-26d0-e383c49be941")
+	require.NoError(t, err)
+	require.Len(t, allocs, 2)
+
+	running := allocs[0]
+	assert.Equal(t, "5456bd7a-9fc0-c0dd-6131-cbee77f57577", running.ID)
+	assert.Equal(t, "redis.cache[0]", running.Name)
+	assert.False(t, running.IsTerminal())
+	assert.Equal(t, "running", running.TaskStates["redis"].State)
+
+	group := running.GetTaskGroup()
+	require.NotNil(t, group)
+	assert.Equal(t, "cache", group.Name)
+	assert.Equal(t, []string{`com.datadoghq.ad.check_names=["redisdb"]`}, group.Services[0].Tags)
+	require.Len(t, group.Tasks, 1)
+	assert.Equal(t, Task{
+		Name:   "redis",
+		Driver: "docker",
+		Meta:   map[string]string{"com.datadoghq.ad.init_configs": "[{}]"},
+	}, group.Tasks[0])
+
+	complete := allocs[1]
+	assert.True(t, complete.IsTerminal())
+	assert.Nil(t, complete.GetTaskGroup())
+}
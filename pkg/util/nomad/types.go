@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package nomad
+
+// Allocation client statuses
+const (
+	AllocClientStatusPending  = "pending"
+	AllocClientStatusRunning  = "running"
+	AllocClientStatusComplete = "complete"
+	AllocClientStatusFailed   = "failed"
+	AllocClientStatusLost     = "lost"
+)
+
+// Agent represents the response of the agent self endpoint.
+// See https://developer.hashicorp.com/nomad/api-docs/agent#query-self
+type Agent struct {
+	Config AgentConfig `json:"config"`
+	Stats  AgentStats  `json:"stats"`
+}
+
+// AgentConfig holds the configuration of the agent.
+type AgentConfig struct {
+	Datacenter string `json:"Datacenter"`
+	Region     string `json:"Region"`
+	NodeName   string `json:"NodeName"`
+}
+
+// AgentStats holds the stats of the agent. Client stats are only
+// present when the agent runs in client mode.
+type AgentStats struct {
+	Client map[string]string `json:"client"`
+}
+
+// NodeID returns the ID of the client node of the agent, empty if the
+// agent doesn't run in client mode.
+func (a *Agent) NodeID() string {
+	return a.Stats.Client["node_id"]
+}
+
+// Allocation represents an allocation in the node allocations endpoint response.
+// See https://developer.hashicorp.com/nomad/api-docs/nodes#list-node-allocations
+type Allocation struct {
+	ID           string               `json:"ID"`
+	Name         string               `json:"Name"`
+	Namespace    string               `json:"Namespace"`
+	NodeID       string               `json:"NodeID"`
+	NodeName     string               `json:"NodeName"`
+	JobID        string               `json:"JobID"`
+	TaskGroup    string               `json:"TaskGroup"`
+	ClientStatus string               `json:"ClientStatus"`
+	Job          *Job                 `json:"Job"`
+	TaskStates   map[string]TaskState `json:"TaskStates"`
+}
+
+// IsTerminal returns whether the allocation has stopped running on the client.
+func (a *Allocation) IsTerminal() bool {
+	switch a.ClientStatus {
+	case AllocClientStatusComplete, AllocClientStatusFailed, AllocClientStatusLost:
+		return true
+	}
+	return false
+}
+
+// GetTaskGroup returns the definition of the task group of the allocation, nil if unknown.
+func (a *Allocation) GetTaskGroup() *TaskGroup {
+	if a.Job == nil {
+		return nil
+	}
+
+	for i := range a.Job.TaskGroups {
+		if a.Job.TaskGroups[i].Name == a.TaskGroup {
+			return &a.Job.TaskGroups[i]
+		}
+	}
+	return nil
+}
+
+// Job represents the job an allocation is running.
+type Job struct {
+	ID         string            `json:"ID"`
+	Name       string            `json:"Name"`
+	Namespace  string            `json:"Namespace"`
+	Meta       map[string]string `json:"Meta"`
+	TaskGroups []TaskGroup       `json:"TaskGroups"`
+}
+
+// TaskGroup represents a task group of a job.
+type TaskGroup struct {
+	Name     string            `json:"Name"`
+	Meta     map[string]string `json:"Meta"`
+	Services []Service         `json:"Services"`
+	Tasks    []Task            `json:"Tasks"`
+}
+
+// Task represents a task of a task group.
+type Task struct {
+	Name     string            `json:"Name"`
+	Driver   string            `json:"Driver"`
+	Meta     map[string]string `json:"Meta"`
+	Services []Service         `json:"Services"`
+}
+
+// Service represents a service registered by a task group or a task.
+type Service struct {
+	Name      string            `json:"Name"`
+	PortLabel string            `json:"PortLabel"`
+	TaskName  string            `json:"TaskName"`
+	Tags      []string          `json:"Tags"`
+	Meta      map[string]string `json:"Meta"`
+}
+
+// TaskState represents the state of a task of an allocation.
+type TaskState struct {
+	State  string `json:"State"`
+	Failed bool   `json:"Failed"`
+}
@@ -19,6 +19,8 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/kubeapiserver"
 	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/kubelet"
 	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/kubemetadata"
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/nomad"
 	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/podman"
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/process"
 	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors/internal/remoteworkloadmeta"
 )
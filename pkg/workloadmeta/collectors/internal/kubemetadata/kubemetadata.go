@@ -17,7 +17,9 @@ import (
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -40,6 +42,7 @@ type collector struct {
 	updateFreq             time.Duration
 	lastUpdate             time.Time
 	collectNamespaceLabels bool
+	collectOwnersLabels    bool
 }
 
 func init() {
@@ -100,6 +103,7 @@ func (c *collector) Start(_ context.Context, store workloadmeta.Store) error {
 
 	c.updateFreq = time.Duration(config.Datadog.GetInt("kubernetes_metadata_tag_update_freq")) * time.Second
 	c.collectNamespaceLabels = len(config.Datadog.GetStringMapString("kubernetes_namespace_labels_as_tags")) > 0
+	c.collectOwnersLabels = utils.GetExtractionRules(config.Datadog).HasSource(utils.OwnerLabelSource)
 
 	return err
 }
@@ -180,6 +184,10 @@ func (c *collector) parsePods(
 		}
 	}
 
+	// Owners are often shared by several pods, their labels are only fetched
+	// once per pull.
+	ownerLabelsCache := make(map[string]map[string]string)
+
 	for _, pod := range pods {
 		if pod.Metadata.UID == "" {
 			continue
@@ -214,6 +222,8 @@ func (c *collector) parsePods(
 			log.Debugf("Could not fetch namespace labels for pod %s/%s: %v", pod.Metadata.Namespace, pod.Metadata.Name, err)
 		}
 
+		ownersLabels := c.getOwnersLabels(apiserver.GetOwnerLabels, ownerLabelsCache, pod)
+
 		entityID := workloadmeta.EntityID{
 			Kind: workloadmeta.KindKubernetesPod,
 			ID:   pod.Metadata.UID,
@@ -231,6 +241,7 @@ func (c *collector) parsePods(
 			},
 			KubeServices:    services,
 			NamespaceLabels: nsLabels,
+			OwnersLabels:    ownersLabels,
 		}
 
 		events = append(events, workloadmeta.CollectorEvent{
@@ -281,6 +292,59 @@ func (c *collector) getNamespaceLabels(getNamespaceLabelsFromAPIServerFunc func(
 	return getNamespaceLabelsFromAPIServerFunc(ns)
 }
 
+// getOwnersLabels returns the labels of the owners of the pod, and of the
+// Deployments and CronJobs owning them, indexed by lowercase kind. It returns
+// nil if no tag extraction rule uses them.
+func (c *collector) getOwnersLabels(getOwnerLabelsFromAPIServerFunc func(string, string, string) (map[string]string, error), cache map[string]map[string]string, pod *kubelet.Pod) map[string]map[string]string {
+	if !c.collectOwnersLabels || len(pod.Metadata.Owners) == 0 {
+		return nil
+	}
+
+	if c.isDCAEnabled() {
+		getOwnerLabelsFromAPIServerFunc = c.dcaClient.GetOwnerLabels
+	}
+
+	ns := pod.Metadata.Namespace
+	ownersLabels := make(map[string]map[string]string)
+	addOwner := func(kind, name string) {
+		cacheKey := ns + "/" + kind + "/" + name
+		labels, found := cache[cacheKey]
+		if !found {
+			var err error
+			labels, err = getOwnerLabelsFromAPIServerFunc(ns, kind, name)
+			if err != nil {
+				log.Debugf("Could not fetch labels of the %s %s/%s: %v", kind, ns, name, err)
+			}
+			cache[cacheKey] = labels
+		}
+
+		if labels != nil {
+			ownersLabels[strings.ToLower(kind)] = labels
+		}
+	}
+
+	for _, owner := range pod.Metadata.Owners {
+		addOwner(owner.Kind, owner.Name)
+
+		switch owner.Kind {
+		case kubernetes.ReplicaSetKind:
+			if deployment := kubernetes.ParseDeploymentForReplicaSet(owner.Name); deployment != "" {
+				addOwner(kubernetes.DeploymentKind, deployment)
+			}
+		case kubernetes.JobKind:
+			if cronJob, _ := kubernetes.ParseCronJobForJob(owner.Name); cronJob != "" {
+				addOwner(kubernetes.CronJobKind, cronJob)
+			}
+		}
+	}
+
+	if len(ownersLabels) == 0 {
+		return nil
+	}
+
+	return ownersLabels
+}
+
 func (c *collector) isDCAEnabled() bool {
 	if c.dcaEnabled && c.dcaClient != nil {
 		v := c.dcaClient.Version()
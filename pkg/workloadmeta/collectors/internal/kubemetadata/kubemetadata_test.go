@@ -44,6 +44,9 @@ type FakeDCAClient struct {
 	NamespaceLabels    map[string]string
 	NamespaceLabelsErr error
 
+	OwnerLabels    map[string]map[string]string
+	OwnerLabelsErr error
+
 	PodMetadataForNode    apiv1.NamespacesPodsStringsSet
 	PodMetadataForNodeErr error
 
@@ -87,6 +90,10 @@ func (f *FakeDCAClient) GetNamespaceLabels(nsName string) (map[string]string, er
 	return f.NamespaceLabels, f.NamespaceLabelsErr
 }
 
+func (f *FakeDCAClient) GetOwnerLabels(nsName, kind, name string) (map[string]string, error) {
+	return f.OwnerLabels[kind+"/"+name], f.OwnerLabelsErr
+}
+
 func (f *FakeDCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error) {
 	return f.PodMetadataForNode, f.PodMetadataForNodeErr
 }
@@ -394,6 +401,90 @@ func TestKubeMetadataCollector_getNamespaceLabels(t *testing.T) {
 	}
 }
 
+func TestKubeMetadataCollector_getOwnersLabels(t *testing.T) {
+	pod := &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:      "redis-6b9f8c7d5-x2x4z",
+			Namespace: "default",
+			Owners: []kubelet.PodOwner{
+				{Kind: "ReplicaSet", Name: "redis-6b9f8c7d5"},
+			},
+		},
+	}
+
+	ownersLabels := map[string]map[string]string{
+		"ReplicaSet/redis-6b9f8c7d5": {"pod-template-hash": "6b9f8c7d5"},
+		"Deployment/redis":           {"team": "storage"},
+	}
+	want := map[string]map[string]string{
+		"replicaset": {"pod-template-hash": "6b9f8c7d5"},
+		"deployment": {"team": "storage"},
+	}
+
+	tests := []struct {
+		name                string
+		dcaClient           clusteragent.DCAClientInterface
+		clusterAgentEnabled bool
+		collectOwnersLabels bool
+		want                map[string]map[string]string
+		wantCalls           int
+	}{
+		{
+			name: "owner labels not used",
+			want: nil,
+		},
+		{
+			name:                "cluster agent not enabled",
+			collectOwnersLabels: true,
+			dcaClient:           &FakeDCAClient{},
+			want:                want,
+			wantCalls:           2,
+		},
+		{
+			name:                "cluster agent enabled",
+			collectOwnersLabels: true,
+			clusterAgentEnabled: true,
+			dcaClient: &FakeDCAClient{
+				LocalVersion: version.Version{Major: 1, Minor: 12},
+				OwnerLabels:  ownersLabels,
+			},
+			want: want,
+		},
+		{
+			name:                "cluster agent enabled and failed to get owner labels",
+			collectOwnersLabels: true,
+			clusterAgentEnabled: true,
+			dcaClient: &FakeDCAClient{
+				LocalVersion:   version.Version{Major: 1, Minor: 12},
+				OwnerLabelsErr: errors.New("failed to get owner labels"),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &collector{
+				dcaClient:           tt.dcaClient,
+				dcaEnabled:          tt.clusterAgentEnabled,
+				collectOwnersLabels: tt.collectOwnersLabels,
+			}
+
+			calls := 0
+			getOwnerLabels := func(ns, kind, name string) (map[string]string, error) {
+				calls++
+				return ownersLabels[kind+"/"+name], nil
+			}
+
+			// The second lookup hits the cache
+			cache := make(map[string]map[string]string)
+			for i := 0; i < 2; i++ {
+				assert.Equal(t, tt.want, c.getOwnersLabels(getOwnerLabels, cache, pod))
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
 func TestKubeMetadataCollector_parsePods(t *testing.T) {
 	pods := []*kubelet.Pod{{
 		Metadata: kubelet.PodMetadata{
@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package nomad implements the workloadmeta collector for the allocations of
+// the local Nomad client.
+package nomad
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	nomadutil "github.com/DataDog/datadog-agent/pkg/util/nomad"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	collectorID   = "nomad"
+	componentName = "workloadmeta-nomad"
+)
+
+type collector struct {
+	store      workloadmeta.Store
+	client     *nomadutil.Client
+	nodeID     string
+	datacenter string
+	seen       map[workloadmeta.EntityID]struct{}
+}
+
+func init() {
+	workloadmeta.RegisterCollector(collectorID, func() workloadmeta.Collector {
+		return &collector{
+			seen: make(map[workloadmeta.EntityID]struct{}),
+		}
+	})
+}
+
+func (c *collector) Start(ctx context.Context, store workloadmeta.Store) error {
+	if !config.IsFeaturePresent(config.Nomad) {
+		return errors.NewDisabled(componentName, "Agent is not running on Nomad")
+	}
+
+	c.store = store
+	c.client = nomadutil.NewClientFromConfig()
+
+	agent, err := c.client.GetAgent(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.nodeID = agent.NodeID()
+	if c.nodeID == "" {
+		return errors.NewDisabled(componentName, "Nomad agent is not running in client mode")
+	}
+	c.datacenter = agent.Config.Datacenter
+
+	return nil
+}
+
+func (c *collector) Pull(ctx context.Context) error {
+	allocs, err := c.client.GetNodeAllocations(ctx, c.nodeID)
+	if err != nil {
+		return err
+	}
+
+	// allocations are always parsed, as their containers are only
+	// known once the container runtime collector has found them.
+	c.store.Notify(c.parseAllocations(allocs))
+
+	return nil
+}
+
+func (c *collector) parseAllocations(allocs []nomadutil.Allocation) []workloadmeta.CollectorEvent {
+	events := []workloadmeta.CollectorEvent{}
+	seen := make(map[workloadmeta.EntityID]struct{})
+
+	containerIDs := make(map[string]string)
+	for _, container := range c.store.ListContainers() {
+		containerIDs[container.Name] = container.ID
+	}
+
+	for _, alloc := range allocs {
+		if alloc.IsTerminal() {
+			continue
+		}
+
+		group := alloc.GetTaskGroup()
+		if group == nil {
+			log.Debugf("cannot find task group %q of allocation %q, skipping", alloc.TaskGroup, alloc.ID)
+			continue
+		}
+
+		entityID := workloadmeta.EntityID{
+			Kind: workloadmeta.KindNomadAllocation,
+			ID:   alloc.ID,
+		}
+
+		seen[entityID] = struct{}{}
+
+		entity := &workloadmeta.NomadAllocation{
+			EntityID: entityID,
+			EntityMeta: workloadmeta.EntityMeta{
+				Name:      alloc.Name,
+				Namespace: alloc.Namespace,
+			},
+			JobName:         alloc.Job.Name,
+			TaskGroup:       alloc.TaskGroup,
+			Datacenter:      c.datacenter,
+			Containers:      []workloadmeta.OrchestratorContainer{},
+			TaskMeta:        make(map[string]map[string]string, len(group.Tasks)),
+			TaskServiceTags: make(map[string][]string, len(group.Tasks)),
+		}
+
+		for _, task := range group.Tasks {
+			entity.TaskMeta[task.Name] = mergeMeta(alloc.Job.Meta, group.Meta, task.Meta)
+			entity.TaskServiceTags[task.Name] = serviceTags(task, group.Services)
+
+			containerID, found := containerIDs[containerName(alloc.ID, task.Name)]
+			if !found {
+				log.Tracef("cannot find container of task %q of allocation %q", task.Name, alloc.ID)
+				continue
+			}
+
+			entity.Containers = append(entity.Containers, workloadmeta.OrchestratorContainer{
+				ID:   containerID,
+				Name: task.Name,
+			})
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Source: workloadmeta.SourceNodeOrchestrator,
+			Type:   workloadmeta.EventTypeSet,
+			Entity: entity,
+		})
+	}
+
+	for seenID := range c.seen {
+		if _, ok := seen[seenID]; ok {
+			continue
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceNodeOrchestrator,
+			Entity: &workloadmeta.NomadAllocation{EntityID: seenID},
+		})
+	}
+
+	c.seen = seen
+
+	return events
+}
+
+// containerName returns the name given by the docker and podman drivers
+// to the container of a task
+func containerName(allocID, taskName string) string {
+	return strings.ReplaceAll(taskName, "/", "_") + "-" + allocID
+}
+
+// mergeMeta merges the job, group and task meta, the most specific one
+// taking precedence, as Nomad does when running a task
+func mergeMeta(metas ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, meta := range metas {
+		for k, v := range meta {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// serviceTags returns the tags of the services of a task and of the
+// group services that are not attached to another task
+func serviceTags(task nomadutil.Task, groupServices []nomadutil.Service) []string {
+	tags := []string{}
+	for _, service := range groupServices {
+		if service.TaskName != "" && service.TaskName != task.Name {
+			continue
+		}
+		tags = append(tags, service.Tags...)
+	}
+	for _, service := range task.Services {
+		tags = append(tags, service.Tags...)
+	}
+	return tags
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package nomad
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nomadutil "github.com/DataDog/datadog-agent/pkg/util/nomad"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+	workloadmetaTesting "github.com/DataDog/datadog-agent/pkg/workloadmeta/testing"
+)
+
+func TestParseAllocations(t *testing.T) {
+	store := workloadmetaTesting.NewStore()
+	store.Set(&workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainer,
+			ID:   "3b8ee58d1dcb",
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: "redis-5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+		},
+	})
+
+	allocs := []nomadutil.Allocation{
+		{
+			ID:           "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+			Name:         "redis.cache[0]",
+			Namespace:    "default",
+			TaskGroup:    "cache",
+			ClientStatus: nomadutil.AllocClientStatusRunning,
+			Job: &nomadutil.Job{
+				Name: "redis",
+				Meta: map[string]string{"owner": "cache-team", "tier": "job"},
+				TaskGroups: []nomadutil.TaskGroup{
+					{
+						Name: "cache",
+						Meta: map[string]string{"tier": "group"},
+						Services: []nomadutil.Service{
+							{Name: "redis-cache", Tags: []string{"com.datadoghq.ad.check_names=[\"redisdb\"]"}},
+							{Name: "exporter", TaskName: "exporter", Tags: []string{"metrics"}},
+						},
+						Tasks: []nomadutil.Task{
+							{
+								Name: "redis",
+								Meta: map[string]string{"com.datadoghq.ad.init_configs": "[{}]"},
+							},
+							{
+								Name: "exporter",
+								Services: []nomadutil.Service{
+									{Name: "exporter-http", Tags: []string{"http"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID:           "a8198d79-cfdb-6593-a999-1e9adabcba2e",
+			TaskGroup:    "work",
+			ClientStatus: nomadutil.AllocClientStatusComplete,
+		},
+	}
+
+	c := &collector{
+		store:      store,
+		datacenter: "dc1",
+		seen: map[workloadmeta.EntityID]struct{}{
+			{Kind: workloadmeta.KindNomadAllocation, ID: "gone"}: {},
+		},
+	}
+
+	events := c.parseAllocations(allocs)
+
+	expected := []workloadmeta.CollectorEvent{
+		{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceNodeOrchestrator,
+			Entity: &workloadmeta.NomadAllocation{
+				EntityID: workloadmeta.EntityID{
+					Kind: workloadmeta.KindNomadAllocation,
+					ID:   "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+				},
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "redis.cache[0]",
+					Namespace: "default",
+				},
+				JobName:    "redis",
+				TaskGroup:  "cache",
+				Datacenter: "dc1",
+				Containers: []workloadmeta.OrchestratorContainer{
+					{ID: "3b8ee58d1dcb", Name: "redis"},
+				},
+				TaskMeta: map[string]map[string]string{
+					"redis": {
+						"owner":                         "cache-team",
+						"tier":                          "group",
+						"com.datadoghq.ad.init_configs": "[{}]",
+					},
+					"exporter": {
+						"owner": "cache-team",
+						"tier":  "group",
+					},
+				},
+				TaskServiceTags: map[string][]string{
+					"redis":    {"com.datadoghq.ad.check_names=[\"redisdb\"]"},
+					"exporter": {"com.datadoghq.ad.check_names=[\"redisdb\"]", "metrics", "http"},
+				},
+			},
+		},
+		{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceNodeOrchestrator,
+			Entity: &workloadmeta.NomadAllocation{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindNomadAllocation, ID: "gone"},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, events)
+
+	// allocations that are gone are unset on the next pull
+	events = c.parseAllocations(nil)
+	assert.Equal(t, []workloadmeta.CollectorEvent{
+		{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceNodeOrchestrator,
+			Entity: &workloadmeta.NomadAllocation{
+				EntityID: workloadmeta.EntityID{
+					Kind: workloadmeta.KindNomadAllocation,
+					ID:   "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+				},
+			},
+		},
+	}, events)
+}
@@ -0,0 +1,310 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+// Package process implements the process collector for workloadmeta, which
+// reads the processes running on the host from procfs.
+package process
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	collectorID   = "process"
+	componentName = "workloadmeta-process"
+
+	envVarService = "DD_SERVICE"
+	envVarEnv     = "DD_ENV"
+	envVarVersion = "DD_VERSION"
+)
+
+type collector struct {
+	store    workloadmeta.Store
+	probe    procutil.Probe
+	procPath string
+	interval time.Duration
+
+	// processes holds the processes sent to the store during the last
+	// collection, to only read the environment and the cgroups of the new
+	// processes.
+	processes  map[int32]*workloadmeta.Process
+	users      map[int32]string
+	lookupUser func(uid string) (*user.User, error)
+
+	// scrubber hides the sensitive arguments of the command lines, as they
+	// are exposed by workload-list and included in flares.
+	scrubber *procutil.DataScrubber
+}
+
+func init() {
+	workloadmeta.RegisterCollector(collectorID, func() workloadmeta.Collector {
+		return &collector{
+			processes:  make(map[int32]*workloadmeta.Process),
+			users:      make(map[int32]string),
+			lookupUser: user.LookupId,
+			scrubber:   procutil.NewDefaultDataScrubber(),
+		}
+	})
+}
+
+func (c *collector) Start(ctx context.Context, store workloadmeta.Store) error {
+	if !config.Datadog.GetBool("workloadmeta_process_collection.enabled") {
+		return errors.NewDisabled(componentName, "process collection is disabled")
+	}
+
+	c.store = store
+	c.probe = procutil.NewProcessProbe()
+	c.procPath = util.HostProc()
+	initScrubber(c.scrubber)
+	c.interval = time.Duration(config.Datadog.GetInt("workloadmeta_process_collection.interval")) * time.Second
+	if c.interval <= 0 {
+		c.interval = 30 * time.Second
+	}
+
+	if err := c.collect(); err != nil {
+		c.probe.Close()
+		return err
+	}
+
+	go c.run(ctx)
+
+	return nil
+}
+
+// Pull is a no-op: procfs is scanned by run, at its own interval, as reading
+// all the processes is too expensive to be done at every pull.
+func (c *collector) Pull(ctx context.Context) error {
+	return nil
+}
+
+func (c *collector) run(ctx context.Context) {
+	health := health.RegisterLiveness(componentName)
+	ticker := time.NewTicker(c.interval)
+
+	for {
+		select {
+		case <-health.C:
+
+		case <-ticker.C:
+			if err := c.collect(); err != nil {
+				log.Warnf("error collecting processes: %s", err)
+			}
+
+		case <-ctx.Done():
+			ticker.Stop()
+			c.probe.Close()
+
+			if err := health.Deregister(); err != nil {
+				log.Warnf("error de-registering health check: %s", err)
+			}
+
+			return
+		}
+	}
+}
+
+// collect scans procfs and notifies the store of the processes that started
+// or exited since the previous scan.
+func (c *collector) collect() error {
+	procs, err := c.probe.ProcessesByPID(time.Now(), false)
+	if err != nil {
+		return err
+	}
+
+	var events []workloadmeta.CollectorEvent
+	processes := make(map[int32]*workloadmeta.Process, len(procs))
+
+	for pid, proc := range procs {
+		creationTime := time.UnixMilli(proc.Stats.CreateTime)
+		cmdline := c.scrubber.ScrubProcessCommand(proc)
+
+		if previous, found := c.processes[pid]; found && previous.CreationTime.Equal(creationTime) && equalCmdlines(previous.Cmdline, cmdline) {
+			processes[pid] = previous
+			continue
+		}
+
+		process := c.buildProcess(proc, cmdline, creationTime)
+		processes[pid] = process
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceProcessCollector,
+			Entity: process,
+		})
+	}
+
+	for pid, process := range c.processes {
+		if _, found := processes[pid]; found {
+			continue
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceProcessCollector,
+			Entity: &workloadmeta.Process{
+				EntityID: process.EntityID,
+			},
+		})
+	}
+
+	c.processes = processes
+	c.scrubber.IncrementCacheAge()
+	c.store.Notify(events)
+
+	return nil
+}
+
+// buildProcess builds the workloadmeta entity of a process. Only the scrubbed
+// command line is stored, the raw one is only used to infer the service name.
+func (c *collector) buildProcess(proc *procutil.Process, cmdline []string, creationTime time.Time) *workloadmeta.Process {
+	process := &workloadmeta.Process{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindProcess,
+			ID:   strconv.Itoa(int(proc.Pid)),
+		},
+		Pid:          proc.Pid,
+		Ppid:         proc.Ppid,
+		Name:         proc.Name,
+		Cmdline:      cmdline,
+		Exe:          proc.Exe,
+		CreationTime: creationTime,
+	}
+
+	if len(proc.Uids) > 0 {
+		process.User = c.getUser(proc.Uids[0])
+	}
+
+	pid := strconv.Itoa(int(proc.Pid))
+	containerID, err := containerIDForPID(c.procPath, pid)
+	if err != nil {
+		log.Debugf("unable to get the container ID of process %s: %s", pid, err)
+	}
+	process.ContainerID = containerID
+
+	envVars, err := readEnvVars(c.procPath, pid, envVarService, envVarEnv, envVarVersion)
+	if err != nil {
+		log.Tracef("unable to read the environment of process %s: %s", pid, err)
+	}
+	process.Service = envVars[envVarService]
+	process.Env = envVars[envVarEnv]
+	process.Version = envVars[envVarVersion]
+
+	if process.Service == "" {
+		process.Service = inferServiceName(proc.Cmdline)
+	}
+
+	return process
+}
+
+// initScrubber configures the scrubber with the same settings as the process
+// check, so that command lines are scrubbed the same way in both.
+func initScrubber(scrubber *procutil.DataScrubber) {
+	if config.Datadog.IsSet("process_config.scrub_args") {
+		scrubber.Enabled = config.Datadog.GetBool("process_config.scrub_args")
+	}
+
+	if config.Datadog.IsSet("process_config.custom_sensitive_words") {
+		scrubber.AddCustomSensitiveWords(config.Datadog.GetStringSlice("process_config.custom_sensitive_words"))
+	}
+
+	if config.Datadog.GetBool("process_config.strip_proc_arguments") {
+		scrubber.StripAllArguments = true
+	}
+}
+
+func (c *collector) getUser(uid int32) string {
+	if name, found := c.users[uid]; found {
+		return name
+	}
+
+	name := strconv.Itoa(int(uid))
+	if u, err := c.lookupUser(name); err == nil {
+		name = u.Username
+	}
+	c.users[uid] = name
+
+	return name
+}
+
+// containerIDForPID returns the ID of the container running a process, read
+// from <proc>/<pid>/cgroup, or an empty string if it runs on the host.
+func containerIDForPID(procPath, pid string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(procPath, pid, "cgroup"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		// Lines are formatted as <hierarchy-ID>:<controllers>:<path>
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		id, err := cgroups.ContainerFilter(parts[2], filepath.Base(parts[2]))
+		if err == nil && id != "" {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// readEnvVars returns the values of the given environment variables of a
+// process, read from <proc>/<pid>/environ.
+func readEnvVars(procPath, pid string, names ...string) (map[string]string, error) {
+	content, err := os.ReadFile(filepath.Join(procPath, pid, "environ"))
+	if err != nil {
+		return nil, err
+	}
+
+	envVars := make(map[string]string, len(names))
+	for _, envVar := range bytes.Split(content, []byte{0}) {
+		name, value, found := strings.Cut(string(envVar), "=")
+		if !found {
+			continue
+		}
+
+		for _, n := range names {
+			if name == n {
+				envVars[name] = value
+			}
+		}
+	}
+
+	return envVars, nil
+}
+
+func equalCmdlines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/process/procutil/mocks"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const testContainerID = "0f2b8e3d1c9a4b5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6"
+
+func writeProcFile(t *testing.T, procPath, pid, name, content string) {
+	dir := filepath.Join(procPath, pid)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func newTestProcess(pid int32, createTime int64, cmdline ...string) *procutil.Process {
+	return &procutil.Process{
+		Pid:     pid,
+		Ppid:    1,
+		Name:    filepath.Base(cmdline[0]),
+		Cmdline: cmdline,
+		Uids:    []int32{1000},
+		Stats:   &procutil.Stats{CreateTime: createTime},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	procPath := t.TempDir()
+	writeProcFile(t, procPath, "10", "environ", "PATH=/usr/bin\x00DD_SERVICE=billing\x00DD_ENV=prod\x00DD_VERSION=1.2\x00")
+	writeProcFile(t, procPath, "10", "cgroup", "0::/system.slice/billing.service\n")
+	writeProcFile(t, procPath, "20", "cgroup", "12:memory:/kubepods/besteffort/pod1234/"+testContainerID+"\n")
+
+	probe := &mocks.Probe{}
+	store := workloadmeta.NewMockStore()
+	c := &collector{
+		store:     store,
+		probe:     probe,
+		procPath:  procPath,
+		processes: make(map[int32]*workloadmeta.Process),
+		users:     make(map[int32]string),
+		scrubber:  procutil.NewDefaultDataScrubber(),
+		lookupUser: func(uid string) (*user.User, error) {
+			if uid == "1000" {
+				return &user.User{Uid: uid, Username: "dd-user"}, nil
+			}
+			return nil, errors.New("unknown user")
+		},
+	}
+
+	probe.On("ProcessesByPID", mock.Anything, false).Return(map[int32]*procutil.Process{
+		10: newTestProcess(10, 1000, "/usr/bin/billing", "--port", "8080", "--password=hunter2"),
+		20: newTestProcess(20, 2000, "python3", "-u", "/app/worker.py"),
+	}, nil).Once()
+	require.NoError(t, c.collect())
+
+	billing, err := store.GetProcess(10)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", billing.Service)
+	assert.Equal(t, "prod", billing.Env)
+	assert.Equal(t, "1.2", billing.Version)
+	assert.Equal(t, "dd-user", billing.User)
+	assert.Equal(t, []string{"/usr/bin/billing", "--port", "8080", "--password=********"}, billing.Cmdline)
+	assert.Equal(t, "", billing.ContainerID)
+	assert.Equal(t, time.UnixMilli(1000), billing.CreationTime)
+
+	worker, err := store.GetProcess(20)
+	require.NoError(t, err)
+	assert.Equal(t, "worker", worker.Service)
+	assert.Equal(t, "", worker.Env)
+	assert.Equal(t, testContainerID, worker.ContainerID)
+
+	// pid 10 exited and was reused, pid 20 exited
+	writeProcFile(t, procPath, "10", "environ", "PATH=/usr/bin\x00")
+	probe.On("ProcessesByPID", mock.Anything, false).Return(map[int32]*procutil.Process{
+		10: newTestProcess(10, 3000, "/usr/sbin/nginx"),
+	}, nil).Once()
+	require.NoError(t, c.collect())
+
+	assert.Len(t, store.ListProcesses(), 1)
+	nginx, err := store.GetProcess(10)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", nginx.Service)
+	assert.Equal(t, "", nginx.Env)
+	_, err = store.GetProcess(20)
+	assert.Error(t, err)
+
+	probe.AssertExpectations(t)
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// interpreters are the executables running a script given as first
+// non-option argument, which is then used to infer the service name.
+var interpreters = map[string]struct{}{
+	"python":  {},
+	"python2": {},
+	"python3": {},
+	"node":    {},
+	"nodejs":  {},
+	"ruby":    {},
+	"php":     {},
+	"perl":    {},
+}
+
+// javaOptionsWithValue are the options of the java command taking a value in
+// the next argument.
+var javaOptionsWithValue = map[string]struct{}{
+	"-cp":           {},
+	"-classpath":    {},
+	"--class-path":  {},
+	"-p":            {},
+	"--module-path": {},
+}
+
+// inferServiceName returns the name of the service run by a process whose
+// DD_SERVICE environment variable is not set:
+//   - the `dd.service` system property, the name of the jar, or the name of
+//     the main class for java processes,
+//   - the name of the module or script for interpreted languages,
+//   - the name of the executable otherwise.
+func inferServiceName(cmdline []string) string {
+	// Some processes overwrite their command line with a single string
+	if len(cmdline) == 1 {
+		cmdline = strings.Fields(cmdline[0])
+	}
+	if len(cmdline) == 0 {
+		return ""
+	}
+
+	// Overwritten command lines often look like `nginx: master process`
+	exe := strings.TrimSuffix(filepath.Base(cmdline[0]), ":")
+	args := cmdline[1:]
+
+	if exe == "java" {
+		if name := inferJavaServiceName(args); name != "" {
+			return name
+		}
+		return exe
+	}
+
+	if _, found := interpreters[strings.TrimRight(exe, "0123456789.")]; found {
+		if name := inferScriptServiceName(args); name != "" {
+			return name
+		}
+	}
+
+	return exe
+}
+
+func inferJavaServiceName(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "-Ddd.service="):
+			return strings.TrimPrefix(arg, "-Ddd.service=")
+		case arg == "-jar" && i+1 < len(args):
+			return strings.TrimSuffix(filepath.Base(args[i+1]), ".jar")
+		case !strings.HasPrefix(arg, "-"):
+			// main class
+			return arg[strings.LastIndex(arg, ".")+1:]
+		}
+		if _, found := javaOptionsWithValue[arg]; found {
+			i++
+		}
+	}
+
+	return ""
+}
+
+func inferScriptServiceName(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-m" && i+1 < len(args):
+			// python module
+			return args[i+1]
+		case arg == "-c" || arg == "-e":
+			// inline code
+			return ""
+		case !strings.HasPrefix(arg, "-"):
+			name := filepath.Base(arg)
+			return strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferServiceName(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdline  []string
+		expected string
+	}{
+		{
+			name:     "empty",
+			cmdline:  nil,
+			expected: "",
+		},
+		{
+			name:     "executable",
+			cmdline:  []string{"/usr/sbin/nginx", "-g", "daemon off;"},
+			expected: "nginx",
+		},
+		{
+			name:     "overwritten command line",
+			cmdline:  []string{"nginx: master process /usr/sbin/nginx"},
+			expected: "nginx",
+		},
+		{
+			name:     "java system property",
+			cmdline:  []string{"/usr/bin/java", "-Xmx1g", "-Ddd.service=payments", "-jar", "app.jar"},
+			expected: "payments",
+		},
+		{
+			name:     "java jar",
+			cmdline:  []string{"java", "-Xmx1g", "-jar", "/opt/payments-api.jar"},
+			expected: "payments-api",
+		},
+		{
+			name:     "java main class",
+			cmdline:  []string{"java", "-cp", "/opt/lib/*", "com.example.PaymentsServer", "--port", "80"},
+			expected: "PaymentsServer",
+		},
+		{
+			name:     "python script",
+			cmdline:  []string{"/usr/bin/python3.11", "-u", "/app/worker.py"},
+			expected: "worker",
+		},
+		{
+			name:     "python module",
+			cmdline:  []string{"python", "-m", "gunicorn", "app:app"},
+			expected: "gunicorn",
+		},
+		{
+			name:     "node script",
+			cmdline:  []string{"node", "server.js"},
+			expected: "server",
+		},
+		{
+			name:     "interpreter without script",
+			cmdline:  []string{"ruby", "-e", "puts 1"},
+			expected: "ruby",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, inferServiceName(tt.cmdline))
+		})
+	}
+}
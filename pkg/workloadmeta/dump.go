@@ -57,6 +57,8 @@ func (s *store) Dump(verbose bool) WorkloadDumpResponse {
 			info = e.String(verbose)
 		case *ContainerImageMetadata:
 			info = e.String(verbose)
+		case *NomadAllocation:
+			info = e.String(verbose)
 		default:
 			return "", fmt.Errorf("unsupported type %T", e)
 		}
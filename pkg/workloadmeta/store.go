@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -58,6 +59,7 @@ type store struct {
 
 	ongoingPullsMut sync.Mutex
 	ongoingPulls    map[string]time.Time // collector ID => time when last pull started
+	pulled          map[string]struct{}  // IDs of the collectors that completed a pull
 }
 
 var _ Store = &store{}
@@ -81,6 +83,7 @@ func newStore(catalog CollectorCatalog) *store {
 		collectors:   make(map[string]Collector),
 		eventCh:      make(chan []CollectorEvent, eventChBufferSize),
 		ongoingPulls: make(map[string]time.Time),
+		pulled:       make(map[string]struct{}),
 	}
 }
 
@@ -327,6 +330,28 @@ func (s *store) GetImage(id string) (*ContainerImageMetadata, error) {
 	return entity.(*ContainerImageMetadata), nil
 }
 
+// GetProcess implements Store#GetProcess
+func (s *store) GetProcess(pid int32) (*Process, error) {
+	entity, err := s.getEntityByKind(KindProcess, strconv.Itoa(int(pid)))
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*Process), nil
+}
+
+// ListProcesses implements Store#ListProcesses
+func (s *store) ListProcesses() []*Process {
+	entities := s.listEntitiesByKind(KindProcess)
+
+	processes := make([]*Process, 0, len(entities))
+	for _, entity := range entities {
+		processes = append(processes, entity.(*Process))
+	}
+
+	return processes
+}
+
 // Notify implements Store#Notify
 func (s *store) Notify(events []CollectorEvent) {
 	if len(events) > 0 {
@@ -334,6 +359,27 @@ func (s *store) Notify(events []CollectorEvent) {
 	}
 }
 
+// IsInitialized implements Store#IsInitialized
+func (s *store) IsInitialized() bool {
+	s.collectorMut.RLock()
+	defer s.collectorMut.RUnlock()
+
+	if len(s.candidates) > 0 {
+		return false
+	}
+
+	s.ongoingPullsMut.Lock()
+	defer s.ongoingPullsMut.Unlock()
+
+	for id := range s.collectors {
+		if _, ok := s.pulled[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Reset implements Store#Reset
 func (s *store) Reset(newEntities []Entity, source Source) {
 	s.storeMut.RLock()
@@ -464,6 +510,7 @@ func (s *store) pull(ctx context.Context) {
 			pullDuration := time.Now().Sub(s.ongoingPulls[id])
 			telemetry.PullDuration.Observe(pullDuration.Seconds(), id)
 			s.ongoingPulls[id] = time.Time{}
+			s.pulled[id] = struct{}{}
 			s.ongoingPullsMut.Unlock()
 		}(id, c)
 	}
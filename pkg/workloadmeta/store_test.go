@@ -6,8 +6,10 @@
 package workloadmeta
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
 
@@ -993,6 +995,41 @@ func TestNoDataRace(t *testing.T) {
 	})
 }
 
+type fakeCollector struct {
+	startErr error
+}
+
+func (c *fakeCollector) Start(context.Context, Store) error {
+	return c.startErr
+}
+
+func (c *fakeCollector) Pull(context.Context) error {
+	return nil
+}
+
+func TestIsInitialized(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newStore(CollectorCatalog{
+		"foo": func() Collector { return &fakeCollector{} },
+		"bar": func() Collector { return &fakeCollector{startErr: errors.NewDisabled("bar", "disabled")} },
+	})
+	assert.Assert(t, !s.IsInitialized())
+
+	s.startCandidates(ctx)
+	assert.Assert(t, !s.IsInitialized(), "collectors have not been pulled yet")
+
+	s.pull(ctx)
+
+	// pulls run in their own goroutines
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.IsInitialized() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Assert(t, s.IsInitialized())
+}
+
 func newTestStore() *store {
 	return &store{
 		store:   make(map[Kind]map[string]*cachedEntity),
@@ -7,6 +7,7 @@ package testing
 
 import (
 	"context"
+	"strconv"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/errors"
@@ -127,6 +128,28 @@ func (s *Store) GetImage(id string) (*workloadmeta.ContainerImageMetadata, error
 	return entity.(*workloadmeta.ContainerImageMetadata), nil
 }
 
+// GetProcess implements Store#GetProcess
+func (s *Store) GetProcess(pid int32) (*workloadmeta.Process, error) {
+	entity, err := s.getEntityByKind(workloadmeta.KindProcess, strconv.Itoa(int(pid)))
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*workloadmeta.Process), nil
+}
+
+// ListProcesses implements Store#ListProcesses
+func (s *Store) ListProcesses() []*workloadmeta.Process {
+	entities := s.listEntitiesByKind(workloadmeta.KindProcess)
+
+	processes := make([]*workloadmeta.Process, 0, len(entities))
+	for _, entity := range entities {
+		processes = append(processes, entity.(*workloadmeta.Process))
+	}
+
+	return processes
+}
+
 // Set sets an entity in the store.
 func (s *Store) Set(entity workloadmeta.Entity) {
 	s.mu.Lock()
@@ -174,6 +197,11 @@ func (s *Store) Notify(events []workloadmeta.CollectorEvent) {
 	panic("not implemented")
 }
 
+// IsInitialized always returns true in the testing store.
+func (s *Store) IsInitialized() bool {
+	return true
+}
+
 // Dump is not implemented in the testing store.
 func (s *Store) Dump(verbose bool) workloadmeta.WorkloadDumpResponse {
 	panic("not implemented")
@@ -84,10 +84,24 @@ type Store interface {
 	// with kind KindContainerImageMetadata and the given ID.
 	GetImage(id string) (*ContainerImageMetadata, error)
 
+	// GetProcess returns metadata about a process.  It fetches the entity
+	// with kind KindProcess and the given PID.
+	GetProcess(pid int32) (*Process, error)
+
+	// ListProcesses returns metadata about all known processes, equivalent
+	// to all entities with kind KindProcess.
+	ListProcesses() []*Process
+
 	// Notify notifies the store with a slice of events.  It should only be
 	// used by workloadmeta collectors.
 	Notify(events []CollectorEvent)
 
+	// IsInitialized returns true once all the collectors have been started,
+	// or have failed to start permanently, and have completed their first
+	// pull.  Collectors that keep failing to start with a retriable error
+	// prevent the store from ever being initialized.
+	IsInitialized() bool
+
 	// Dump lists the content of the store, for debugging purposes.
 	Dump(verbose bool) WorkloadDumpResponse
 
@@ -110,6 +124,8 @@ const (
 	KindKubernetesPod          Kind = "kubernetes_pod"
 	KindECSTask                Kind = "ecs_task"
 	KindContainerImageMetadata Kind = "container_image_metadata"
+	KindProcess                Kind = "process"
+	KindNomadAllocation        Kind = "nomad_allocation"
 )
 
 // Source is the source name of an entity.
@@ -139,6 +155,10 @@ const (
 	// SourceRemoteWorkloadmeta represents entities detected by the remote
 	// workloadmeta.
 	SourceRemoteWorkloadmeta Source = "remote_workloadmeta"
+
+	// SourceProcessCollector represents processes detected by reading the
+	// procfs of the host.
+	SourceProcessCollector Source = "process_collector"
 )
 
 // ContainerRuntime is the container runtime used by a container.
@@ -529,8 +549,12 @@ type KubernetesPod struct {
 	QOSClass                   string
 	KubeServices               []string
 	NamespaceLabels            map[string]string
-	FinishedAt                 time.Time
-	SecurityContext            *PodSecurityContext
+	// OwnersLabels holds the labels of the owners of the pod, and of their
+	// owners (like the Deployment of a ReplicaSet), indexed by lowercase
+	// kind. They are only collected when tag extraction rules use them.
+	OwnersLabels    map[string]map[string]string
+	FinishedAt      time.Time
+	SecurityContext *PodSecurityContext
 }
 
 // GetID implements Entity#GetID.
@@ -588,6 +612,9 @@ func (p KubernetesPod) String(verbose bool) string {
 		_, _ = fmt.Fprintln(&sb, "PVCs:", sliceToString(p.PersistentVolumeClaimNames))
 		_, _ = fmt.Fprintln(&sb, "Kube Services:", sliceToString(p.KubeServices))
 		_, _ = fmt.Fprintln(&sb, "Namespace Labels:", mapToString(p.NamespaceLabels))
+		for kind, labels := range p.OwnersLabels {
+			_, _ = fmt.Fprintf(&sb, "Owner Labels (%s): %s\n", kind, mapToString(labels))
+		}
 		if !p.FinishedAt.IsZero() {
 			_, _ = fmt.Fprintln(&sb, "Finished At:", p.FinishedAt)
 		}
@@ -691,6 +718,80 @@ func (t ECSTask) String(verbose bool) string {
 
 var _ Entity = &ECSTask{}
 
+// NomadAllocation is an Entity representing a Nomad allocation. The name
+// of the allocation is in EntityMeta.Name and its namespace in EntityMeta.Namespace.
+type NomadAllocation struct {
+	EntityID
+	EntityMeta
+	JobName    string
+	TaskGroup  string
+	Datacenter string
+	// Containers are the containers of the tasks of the allocation,
+	// OrchestratorContainer.Name is the name of the task.
+	Containers []OrchestratorContainer
+	// TaskMeta is the meta of each task, keyed by task name. It is the
+	// merge of the job, group and task meta, as it is set in the
+	// environment of the task by Nomad.
+	TaskMeta map[string]map[string]string
+	// TaskServiceTags are the tags of the services of each task, keyed
+	// by task name. Group services are attached to all the tasks.
+	TaskServiceTags map[string][]string
+}
+
+// GetID implements Entity#GetID.
+func (a NomadAllocation) GetID() EntityID {
+	return a.EntityID
+}
+
+// Merge implements Entity#Merge.
+func (a *NomadAllocation) Merge(e Entity) error {
+	aa, ok := e.(*NomadAllocation)
+	if !ok {
+		return fmt.Errorf("cannot merge NomadAllocation with different kind %T", e)
+	}
+
+	return merge(a, aa)
+}
+
+// DeepCopy implements Entity#DeepCopy.
+func (a NomadAllocation) DeepCopy() Entity {
+	cp := deepcopy.Copy(a).(NomadAllocation)
+	return &cp
+}
+
+// String implements Entity#String.
+func (a NomadAllocation) String(verbose bool) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintln(&sb, "----------- Entity ID -----------")
+	_, _ = fmt.Fprint(&sb, a.EntityID.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity Meta -----------")
+	_, _ = fmt.Fprint(&sb, a.EntityMeta.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Containers -----------")
+	for _, c := range a.Containers {
+		_, _ = fmt.Fprint(&sb, c.String(verbose))
+	}
+
+	_, _ = fmt.Fprintln(&sb, "----------- Allocation Info -----------")
+	_, _ = fmt.Fprintln(&sb, "Job Name:", a.JobName)
+	_, _ = fmt.Fprintln(&sb, "Task Group:", a.TaskGroup)
+	_, _ = fmt.Fprintln(&sb, "Datacenter:", a.Datacenter)
+
+	if verbose {
+		for task, meta := range a.TaskMeta {
+			_, _ = fmt.Fprintln(&sb, "Task", task, "Meta:", mapToString(meta))
+		}
+		for task, tags := range a.TaskServiceTags {
+			_, _ = fmt.Fprintln(&sb, "Task", task, "Service Tags:", sliceToString(tags))
+		}
+	}
+
+	return sb.String()
+}
+
+var _ Entity = &NomadAllocation{}
+
 // ContainerImageMetadata is an Entity that represents container image metadata
 type ContainerImageMetadata struct {
 	EntityID
@@ -804,6 +905,76 @@ func printHistory(out io.Writer, history v1.History) {
 
 var _ Entity = &ContainerImageMetadata{}
 
+// Process is an Entity that represents a process running on the host.  Its ID
+// is the PID of the process.
+type Process struct {
+	EntityID
+	Pid          int32
+	Ppid         int32
+	Name         string
+	Cmdline      []string
+	Exe          string
+	User         string
+	CreationTime time.Time
+	// ContainerID is the ID of the container running the process, if any.
+	ContainerID string
+	// Service, Env and Version are the unified service tags of the process,
+	// read from its DD_SERVICE, DD_ENV and DD_VERSION environment variables.
+	// When DD_SERVICE is not set, Service is inferred from the command line.
+	Service string
+	Env     string
+	Version string
+}
+
+// GetID implements Entity#GetID.
+func (p Process) GetID() EntityID {
+	return p.EntityID
+}
+
+// Merge implements Entity#Merge.
+func (p *Process) Merge(e Entity) error {
+	otherProcess, ok := e.(*Process)
+	if !ok {
+		return fmt.Errorf("cannot merge Process with different kind %T", e)
+	}
+
+	return merge(p, otherProcess)
+}
+
+// DeepCopy implements Entity#DeepCopy.
+func (p Process) DeepCopy() Entity {
+	cp := deepcopy.Copy(p).(Process)
+	return &cp
+}
+
+// String implements Entity#String.
+func (p Process) String(verbose bool) string {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity ID -----------")
+	_, _ = fmt.Fprint(&sb, p.EntityID.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Process Info -----------")
+	_, _ = fmt.Fprintln(&sb, "PID:", p.Pid)
+	_, _ = fmt.Fprintln(&sb, "Name:", p.Name)
+	_, _ = fmt.Fprintln(&sb, "Container ID:", p.ContainerID)
+	_, _ = fmt.Fprintln(&sb, "Service:", p.Service)
+	_, _ = fmt.Fprintln(&sb, "Env:", p.Env)
+	_, _ = fmt.Fprintln(&sb, "Version:", p.Version)
+
+	if verbose {
+		_, _ = fmt.Fprintln(&sb, "PPID:", p.Ppid)
+		_, _ = fmt.Fprintln(&sb, "Cmdline:", strings.Join(p.Cmdline, " "))
+		_, _ = fmt.Fprintln(&sb, "Exe:", p.Exe)
+		_, _ = fmt.Fprintln(&sb, "User:", p.User)
+		_, _ = fmt.Fprintln(&sb, "Creation Time:", p.CreationTime)
+	}
+
+	return sb.String()
+}
+
+var _ Entity = &Process{}
+
 // CollectorEvent is an event generated by a metadata collector, to be handled
 // by the metadata store.
 type CollectorEvent struct {
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// UnifiedServiceTaggingReport is the audit of the unified service tags (env,
+// service and version) of the containers and processes of the store.
+type UnifiedServiceTaggingReport struct {
+	Entities []UnifiedServiceTaggingEntity `json:"entities"`
+	// Missing is the number of entities without a value for each tag
+	Missing map[string]int `json:"missing"`
+	// Conflicting is the number of entities with different values for each tag
+	Conflicting map[string]int `json:"conflicting"`
+	// Values is the number of entities using each value of each tag
+	Values map[string]map[string]int `json:"values"`
+}
+
+// UnifiedServiceTaggingEntity is the audit of the unified service tags of an entity.
+type UnifiedServiceTaggingEntity struct {
+	Kind Kind   `json:"kind"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Tags are the values found for each tag, with their source
+	Tags        map[string][]UnifiedServiceTagValue `json:"tags"`
+	Missing     []string                            `json:"missing,omitempty"`
+	Conflicting []string                            `json:"conflicting,omitempty"`
+	// ImageTag is the tag of the image of the container, only reported as a
+	// hint of the version: it is not a value of the version tag.
+	ImageTag string `json:"image_tag,omitempty"`
+}
+
+// UnifiedServiceTagValue is a value of a unified service tag and where it comes from.
+type UnifiedServiceTagValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// HasProblems returns whether the entity has missing or conflicting tags.
+func (e UnifiedServiceTaggingEntity) HasProblems() bool {
+	return len(e.Missing) > 0 || len(e.Conflicting) > 0
+}
+
+// Write writes the report in a given writer. Entities without
+// problems are only written in verbose mode.
+func (r UnifiedServiceTaggingReport) Write(writer io.Writer, verbose bool) {
+	if writer != color.Output {
+		color.NoColor = true
+	}
+
+	for _, e := range r.Entities {
+		if !verbose && !e.HasProblems() {
+			continue
+		}
+
+		fmt.Fprintf(writer, "\n=== Entity %s %s ===\n", color.GreenString(string(e.Kind)), color.GreenString(e.Name))
+		fmt.Fprintln(writer, "ID:", e.ID)
+		for _, tag := range sortedKeys(e.Tags) {
+			for _, v := range e.Tags[tag] {
+				fmt.Fprintf(writer, "%s:%s (from %s)\n", tag, v.Value, v.Source)
+			}
+		}
+		if e.ImageTag != "" {
+			fmt.Fprintf(writer, "image tag: %s (not used as version)\n", e.ImageTag)
+		}
+		if len(e.Missing) > 0 {
+			fmt.Fprintln(writer, color.YellowString("Missing:"), strings.Join(e.Missing, ", "))
+		}
+		if len(e.Conflicting) > 0 {
+			fmt.Fprintln(writer, color.RedString("Conflicting:"), strings.Join(e.Conflicting, ", "))
+		}
+		fmt.Fprintln(writer, "===")
+	}
+
+	fmt.Fprintf(writer, "\n=== Summary: %d entities ===\n", len(r.Entities))
+	for _, tag := range sortedKeys(r.Values) {
+		fmt.Fprintf(writer, "%s: %d missing, %d conflicting\n", tag, r.Missing[tag], r.Conflicting[tag])
+		values := r.Values[tag]
+		for _, value := range sortedKeys(values) {
+			fmt.Fprintf(writer, "  %s: %d entities\n", value, values[value])
+		}
+	}
+	fmt.Fprintln(writer, "===")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}